@@ -2,83 +2,346 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/johnjeffers/awscogs/backend/internal/api"
 	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/azure"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
 	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/debug"
+	"github.com/johnjeffers/awscogs/backend/internal/export"
+	"github.com/johnjeffers/awscogs/backend/internal/logging"
+	"github.com/johnjeffers/awscogs/backend/internal/notify"
 	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
 )
 
 func main() {
 	configPath := flag.String("config", "", "Path to config file")
+	quickScan := flag.Bool("quick", false, "Scan only the current account in the default profile's region")
+	listEnv := flag.Bool("env", false, "List the environment variable for each config setting and exit")
+	exportSnapshots := flag.String("export-snapshots", "", "Write the snapshot history and saved views to this archive file and exit")
+	importSnapshots := flag.String("import-snapshots", "", "Add the snapshots and saved views in this archive file to the snapshot directory and exit")
+	profileName := flag.String("profile", "", "Profile whose snapshots -export-snapshots and -import-snapshots use (default: the top-level configuration)")
 	flag.Parse()
 
+	if *listEnv {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VARIABLE\tSETTING\tFORMAT")
+		for _, v := range config.EnvVars() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Path, v.Format)
+		}
+		w.Flush()
+		return
+	}
+
 	// Load config first so we can use the log level
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		slog.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	if *exportSnapshots != "" || *importSnapshots != "" {
+		if err := runSnapshotArchive(cfg, *profileName, *exportSnapshots, *importSnapshots); err != nil {
+			slog.Error("snapshot archive failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *quickScan {
+		cfg.EnableQuickScan()
+	}
+	if cfg.AWS.QuickScan && len(cfg.AWS.Regions) == 0 {
+		region, err := aws.DefaultProfileRegion(context.Background())
+		if err != nil {
+			slog.Error("failed to resolve quick scan region", "error", err)
+			os.Exit(1)
+		}
+		cfg.AWS.Regions = []string{region}
+	}
 
-	// Setup logger with configured level
-	var logLevel slog.Level
-	switch cfg.Log.Level {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
-	}
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
+	// Setup loggers with the configured levels
+	levels, err := logging.New(os.Stdout, cfg.Log)
+	if err != nil {
+		slog.Error("invalid log levels", "error", err)
+		os.Exit(1)
+	}
+	logger := levels.Logger("")
+	discoveryLogger := levels.Logger(config.LogComponentDiscovery)
+	pricingLogger := levels.Logger(config.LogComponentPricing)
 	slog.SetDefault(logger)
 
 	// Create pricing provider
 	ctx := context.Background()
 	pricingProvider, err := pricing.NewAWSProvider(ctx, cfg.Pricing.RefreshIntervalMinutes, cfg.Pricing.RateLimitPerSecond)
 	if err != nil {
-		logger.Error("failed to initialize AWS pricing provider", "error", err)
+		pricingLogger.Error("failed to initialize AWS pricing provider", "error", err)
 		os.Exit(1)
 	}
-	logger.Info("pricing provider initialized", "rateLimitPerSecond", cfg.Pricing.RateLimitPerSecond)
+	pricingProvider.SetMaxCacheEntries(cfg.Pricing.CacheMaxEntries)
+	pricingLogger.Info("pricing provider initialized", "rateLimitPerSecond", cfg.Pricing.RateLimitPerSecond, "cacheMaxEntries", cfg.Pricing.CacheMaxEntries)
+
+	// Create discovery and snapshot history for the default profile and each
+	// named profile
+	profiles := make([]api.Profile, 0, len(cfg.Profiles)+1)
+	for _, name := range append([]string{""}, cfg.ProfileNames()...) {
+		profileCfg, _ := cfg.ForProfile(name)
+		profile, err := newProfile(profileCfg, pricingProvider, discoveryLogger)
+		if err != nil {
+			logger.Error("failed to initialize profile", "profile", name, "error", err)
+			os.Exit(1)
+		}
+		profiles = append(profiles, profile)
+	}
+	clouds, snapshots := profiles[0].Clouds, profiles[0].Snapshots
+	logger.Info("discovery service initialized", "resourceCacheTTL", cfg.Cache.ResourceTTLMinutes, "accountCacheTTL", cfg.Cache.AccountTTLMinutes, "resultCacheTTL", cfg.Cache.ResultTTLMinutes, "awsRateLimitPerSecond", cfg.AWS.RateLimit.RequestsPerSecond, "profiles", cfg.ProfileNames())
+	if cfg.AWS.QuickScan {
+		logger.Info("quick scan mode: scanning only the current account", "regions", cfg.AWS.Regions)
+	}
 
-	// Create discovery service
-	discovery := aws.NewDiscovery(pricingProvider, logger, cfg.Cache.ResourceTTLMinutes, cfg.Cache.AccountTTLMinutes)
-	logger.Info("discovery service initialized", "resourceCacheTTL", cfg.Cache.ResourceTTLMinutes, "accountCacheTTL", cfg.Cache.AccountTTLMinutes)
+	// Azure is scanned by the default profile only
+	if cfg.Azure.Enabled {
+		creds, err := azure.CredentialsFromEnv()
+		if err != nil {
+			logger.Error("failed to initialize Azure provider", "error", err)
+			os.Exit(1)
+		}
+		azurePrices := azure.NewPriceSource(time.Duration(cfg.Pricing.RefreshIntervalMinutes) * time.Minute)
+		clouds.RegisterDiscoverer(azure.NewDiscoverer(creds, azurePrices, cfg.Azure.Subscriptions, discoveryLogger))
+		logger.Warn("EXPERIMENTAL Azure VM discovery enabled", "subscriptions", cfg.Azure.Subscriptions)
+	}
+	logger.Info("snapshot store initialized", "dir", cfg.Snapshots.Dir, "snapshots", len(snapshots.List()))
+
+	// Create notifications
+	notifier, err := notify.NewNotifier(cfg.Notify, logger)
+	if err != nil {
+		logger.Error("failed to initialize notifications", "error", err)
+		os.Exit(1)
+	}
+	monitorCtx, stopMonitor := context.WithCancel(ctx)
+	defer stopMonitor()
+	if notifier.Enabled() {
+		monitor := notify.NewMonitor(cfg, snapshots, notifier, logger)
+		snapshots.Subscribe(monitor.SnapshotAdded)
+		go monitor.Run(monitorCtx)
+		logger.Info("notifications initialized", "sinks", len(cfg.Notify.Sinks), "weeklyDigest", cfg.Notify.WeeklyDigest.Enabled)
+	}
+
+	// Push cost metrics to Datadog after each full scan
+	var exporters []*export.Datadog
+	if cfg.Export.Datadog.APIKey != "" {
+		for _, profile := range profiles {
+			exporter := export.NewDatadog(cfg.Export.Datadog, profile.Config.Profile, logger)
+			profile.Snapshots.Subscribe(exporter.SnapshotAdded)
+			exporters = append(exporters, exporter)
+		}
+		logger.Info("Datadog export initialized", "site", cfg.Export.Datadog.Site)
+	}
+
+	// Publish each full scan to SNS and/or SQS for downstream pipelines
+	var publishers []*export.Publisher
+	if publish := cfg.Export.Publish; publish.SNSTopicARN != "" || publish.SQSQueueURL != "" {
+		for _, profile := range profiles {
+			publisher := export.NewPublisher(publish, profile.Config.Profile, logger)
+			profile.Snapshots.Subscribe(publisher.SnapshotAdded)
+			publishers = append(publishers, publisher)
+		}
+		logger.Info("snapshot publishing initialized", "snsTopic", publish.SNSTopicARN, "sqsQueue", publish.SQSQueueURL, "format", publish.Format)
+	}
+
+	// Archive every snapshot to S3 for long-term history
+	var archivers []*export.Archiver
+	if archive := cfg.Export.S3; archive.Bucket != "" {
+		for _, profile := range profiles {
+			archiver := export.NewArchiver(archive, profile.Config.Profile, logger)
+			profile.Snapshots.Subscribe(archiver.SnapshotAdded)
+			archivers = append(archivers, archiver)
+		}
+		logger.Info("S3 snapshot archive initialized", "bucket", archive.Bucket, "prefix", archive.Prefix, "format", archive.Format)
+	}
+
+	// Pre-warm prices for every lookup seen so far
+	warmer := pricing.NewWarmer(pricingProvider, cfg.Pricing.WarmFile, time.Duration(cfg.Pricing.RefreshIntervalMinutes)*time.Minute, pricingLogger)
+	for _, profile := range profiles {
+		profile.Snapshots.Subscribe(func(*snapshot.Snapshot) { warmer.Save() })
+	}
+	warmCtx, stopWarmer := context.WithCancel(ctx)
+	defer stopWarmer()
+	go warmer.Run(warmCtx)
+	pricingLogger.Info("pricing warmer initialized", "warmFile", cfg.Pricing.WarmFile)
 
 	// Create and start server
-	server := api.NewServer(cfg, discovery, logger)
+	server := api.NewServer(profiles, levels)
+
+	// Retry failed price lookups in the background and rescan what they price
+	if retry := cfg.Pricing.Retry; retry.MaxAttempts > 0 {
+		retrier := pricing.NewRetrier(pricingProvider, time.Duration(retry.IntervalSeconds)*time.Second, retry.MaxAttempts, pricingLogger)
+		retrier.OnResolved(server.PricesResolved)
+		go retrier.Run(warmCtx)
+		pricingLogger.Info("pricing retries initialized", "intervalSeconds", retry.IntervalSeconds, "maxAttempts", retry.MaxAttempts)
+	}
 
 	// Graceful shutdown
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
+	// SIGUSR1 toggles debug logging for every component
+	toggleDebug := make(chan os.Signal, 1)
+	signal.Notify(toggleDebug, syscall.SIGUSR1)
 	go func() {
-		if err := server.Start(); err != nil {
+		for range toggleDebug {
+			logger.Warn("debug logging toggled", "enabled", levels.ToggleDebug())
+		}
+	}()
+
+	addrs, err := server.Listen()
+	if err != nil {
+		logger.Error("failed to listen", "error", err)
+		os.Exit(1)
+	}
+	go func() {
+		if err := server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Error("server error", "error", err)
+			os.Exit(1)
 		}
 	}()
 
-	logger.Info("awscogs started", "port", cfg.Server.Port)
+	var debugServer *debug.Server
+	if cfg.Server.DebugAddr != "" {
+		debugServer = debug.NewServer(cfg.Server.DebugAddr, logger)
+		go func() {
+			if err := debugServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("debug server error", "error", err)
+			}
+		}()
+	}
+
+	logger.Info("awscogs started", "addresses", addrs)
 
 	<-done
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Scans get the drain timeout to finish, and cancelled ones a little longer
+	// to record what they found
+	drain := time.Duration(cfg.Server.ShutdownDrainSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), drain+10*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Error("shutdown error", "error", err)
 	}
+	if debugServer != nil {
+		debugServer.Shutdown(ctx)
+	}
+
+	stopMonitor()
+	notifier.Wait()
+	for _, exporter := range exporters {
+		exporter.Wait()
+	}
+	for _, publisher := range publishers {
+		publisher.Wait()
+	}
+	for _, archiver := range archivers {
+		archiver.Wait()
+	}
 
 	logger.Info("awscogs stopped")
 }
+
+// newProfile creates the discovery service, cloud registry, and snapshot
+// history for one scan profile. Profiles share the pricing provider.
+func newProfile(cfg *config.Config, pricingProvider *pricing.AWSProvider, logger *slog.Logger) (api.Profile, error) {
+	prices := pricing.WithOverrides(pricingProvider, cfg.Pricing.Overrides)
+	discovery := aws.NewDiscoveryWithConfigFactory(prices, logger, cfg.Cache.ResourceTTLMinutes, cfg.Cache.AccountTTLMinutes, aws.EndpointConfigFactory(cfg.AWS.EndpointURL))
+	discovery.SetSTSConfig(cfg.AWS.STS)
+	discovery.SetRateLimit(cfg.AWS.RateLimit.RequestsPerSecond, cfg.AWS.RateLimit.Burst)
+	discovery.SetDataTransfer(cfg.AWS.DataTransfer)
+	discovery.SetMessaging(cfg.AWS.Messaging)
+	discovery.SetCloudWatch(cfg.AWS.CloudWatch)
+	if cfg.AWS.CloudTrail.LookupOwners {
+		discovery.SetOwnerLookup(cfg.AWS.CloudTrail.LookbackDays)
+	}
+	if err := discovery.LoadPlugins(cfg.AWS.Plugins); err != nil {
+		return api.Profile{}, err
+	}
+
+	clouds := cloud.NewRegistry()
+	clouds.RegisterDiscoverer(aws.NewResourceDiscoverer(discovery, aws.NewScopeResolver(cfg, discovery, logger)))
+
+	snapshots, err := snapshot.NewStore(cfg.Snapshots.Dir, cfg.Snapshots.MaxCount)
+	if err != nil {
+		return api.Profile{}, fmt.Errorf("creating snapshot store: %w", err)
+	}
+	if err := snapshots.SetRetention(cfg.Snapshots.Retention); err != nil {
+		return api.Profile{}, fmt.Errorf("compacting snapshots: %w", err)
+	}
+
+	return api.Profile{Config: cfg, Discovery: discovery, Clouds: clouds, Snapshots: snapshots}, nil
+}
+
+// runSnapshotArchive exports a profile's snapshot store to exportPath and/or
+// imports the archive at importPath into it, without starting the server
+func runSnapshotArchive(cfg *config.Config, profileName, exportPath, importPath string) error {
+	profileCfg, ok := cfg.ForProfile(profileName)
+	if !ok {
+		return fmt.Errorf("unknown profile %q", profileName)
+	}
+	if profileCfg.Snapshots.Dir == "" {
+		return fmt.Errorf("snapshots are only kept in memory; set AWSCOGS_SNAPSHOTS_DIR")
+	}
+	// Exporting only reads the store, so it's opened without a maximum count
+	// or retention policy, which would prune it. An import writes to it and
+	// applies both, as the server would.
+	maxCount := 0
+	if importPath != "" {
+		maxCount = profileCfg.Snapshots.MaxCount
+	}
+	snapshots, err := snapshot.NewStore(profileCfg.Snapshots.Dir, maxCount)
+	if err != nil {
+		return fmt.Errorf("opening snapshot store: %w", err)
+	}
+	if importPath != "" {
+		if err := snapshots.SetRetention(profileCfg.Snapshots.Retention); err != nil {
+			return fmt.Errorf("compacting snapshots: %w", err)
+		}
+	}
+
+	if importPath != "" {
+		f, err := os.Open(importPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		result, err := snapshots.Import(f, int64(profileCfg.Snapshots.MaxImportMB)<<20)
+		if err != nil {
+			return fmt.Errorf("importing %s: %w", importPath, err)
+		}
+		slog.Info("imported snapshots", "file", importPath, "snapshots", result.Snapshots, "views", result.Views, "skipped", result.Skipped)
+	}
+
+	if exportPath != "" {
+		f, err := os.Create(exportPath)
+		if err != nil {
+			return err
+		}
+		if err := snapshots.Export(f); err != nil {
+			f.Close()
+			return fmt.Errorf("exporting to %s: %w", exportPath, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		slog.Info("exported snapshots", "file", exportPath, "snapshots", len(snapshots.List()), "views", len(snapshots.Views()))
+	}
+	return nil
+}
@@ -0,0 +1,410 @@
+// Package aggregate groups costed resources by arbitrary dimensions such as
+// account, region, service, or tag.
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/costcenter"
+	"github.com/johnjeffers/awscogs/backend/internal/environment"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Dimensions resources can be grouped by. Tags are grouped with TagPrefix
+// followed by the tag key, e.g. tag:team.
+const (
+	DimAccount     = "account" // Account or subscription ID
+	DimAccountName = "accountName"
+	DimRegion      = "region"
+	DimService     = "service" // Resource type: ec2, ebs, rds, ...
+	DimState       = "state"
+	DimVPC         = "vpc"
+	DimSubnet      = "subnet"
+	DimCostCenter  = "costCenter"  // Unallocated if no cost center rule matches
+	DimEnvironment = "environment" // Unclassified if nothing classifies the resource
+	TagPrefix      = "tag:"
+)
+
+// dimensionValues returns each fixed dimension's value for a resource
+var dimensionValues = map[string]func(snapshot.Resource) string{
+	DimAccount:     func(r snapshot.Resource) string { return r.AccountID },
+	DimAccountName: func(r snapshot.Resource) string { return r.AccountName },
+	DimRegion:      func(r snapshot.Resource) string { return r.Region },
+	DimService:     func(r snapshot.Resource) string { return r.Type },
+	DimState:       func(r snapshot.Resource) string { return r.State },
+	DimVPC:         func(r snapshot.Resource) string { return r.VPCID },
+	DimSubnet:      func(r snapshot.Resource) string { return r.SubnetID },
+	DimCostCenter:  costCenter,
+	DimEnvironment: environmentOf,
+}
+
+// ValidateDimensions returns an error if any dimension is unknown or repeated
+func ValidateDimensions(dims []string) error {
+	if len(dims) == 0 {
+		return fmt.Errorf("at least one dimension is required")
+	}
+	seen := make(map[string]bool, len(dims))
+	for _, dim := range dims {
+		if seen[dim] {
+			return fmt.Errorf("duplicate dimension: %s", dim)
+		}
+		seen[dim] = true
+
+		if key, ok := strings.CutPrefix(dim, TagPrefix); ok {
+			if key == "" {
+				return fmt.Errorf("tag dimension needs a key, e.g. %steam", TagPrefix)
+			}
+			continue
+		}
+		if _, ok := dimensionValues[dim]; !ok {
+			return fmt.Errorf("unknown dimension: %s (valid: %s, %s, %s, %s, %s, %s, %s, %s, %s, %s<key>)",
+				dim, DimAccount, DimAccountName, DimRegion, DimService, DimState, DimVPC, DimSubnet, DimCostCenter, DimEnvironment, TagPrefix)
+		}
+	}
+	return nil
+}
+
+// value returns r's value for dim. Resources without the tag have an empty value.
+func value(r snapshot.Resource, dim string) string {
+	if key, ok := strings.CutPrefix(dim, TagPrefix); ok {
+		return r.Tags[key]
+	}
+	return dimensionValues[dim](r)
+}
+
+// GroupBy totals resources for each distinct combination of dimension values.
+// Dimensions must already be validated. Groups are sorted by cost, highest first.
+func GroupBy(resources []snapshot.Resource, dims []string) []types.CostGroup {
+	index := make(map[string]int)
+	groups := []types.CostGroup{}
+
+	for _, r := range resources {
+		values := make([]string, len(dims))
+		for i, dim := range dims {
+			values[i] = value(r, dim)
+		}
+
+		key := strings.Join(values, "\x00")
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, types.CostGroup{Values: values})
+		}
+		groups[i].Count++
+		groups[i].TotalCost = groups[i].TotalCost.Add(r.HourlyCost)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].TotalCost != groups[j].TotalCost {
+			return groups[i].TotalCost > groups[j].TotalCost
+		}
+		return strings.Join(groups[i].Values, "\x00") < strings.Join(groups[j].Values, "\x00")
+	})
+	return groups
+}
+
+// CompareGroups groups the resources of a baseline and of the current scan by
+// dims and pairs up the groups, so each row shows what changed since the
+// baseline. Dimensions must already be validated. Rows are sorted by the size
+// of the change, largest first.
+func CompareGroups(baseline, current []snapshot.Resource, dims []string) []types.CostGroupComparison {
+	index := make(map[string]int)
+	rows := []types.CostGroupComparison{}
+	row := func(values []string) *types.CostGroupComparison {
+		key := strings.Join(values, "\x00")
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, types.CostGroupComparison{Values: values})
+		}
+		return &rows[i]
+	}
+
+	for _, g := range GroupBy(baseline, dims) {
+		r := row(g.Values)
+		r.BaselineCount, r.BaselineCost = g.Count, g.TotalCost
+	}
+	for _, g := range GroupBy(current, dims) {
+		r := row(g.Values)
+		r.Count, r.TotalCost = g.Count, g.TotalCost
+	}
+	for i := range rows {
+		rows[i].Delta = rows[i].TotalCost.Add(-rows[i].BaselineCost)
+		_, rows[i].DeltaPercent = delta(rows[i].TotalCost, rows[i].BaselineCost)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := math.Abs(float64(rows[i].Delta)), math.Abs(float64(rows[j].Delta))
+		if a != b {
+			return a > b
+		}
+		return strings.Join(rows[i].Values, "\x00") < strings.Join(rows[j].Values, "\x00")
+	})
+	return rows
+}
+
+// AccountSummaries builds per-account cost summaries, with each account's
+// resource count and hourly cost by resource type and hourly cost by region,
+// highest cost first
+func AccountSummaries(resources []snapshot.Resource) []types.AccountSummary {
+	index := make(map[string]int)
+	summaries := []types.AccountSummary{}
+
+	for _, g := range GroupBy(resources, []string{DimAccount, DimAccountName, DimService}) {
+		i, ok := index[g.Values[0]]
+		if !ok {
+			i = len(summaries)
+			index[g.Values[0]] = i
+			summaries = append(summaries, types.AccountSummary{
+				AccountID:   g.Values[0],
+				AccountName: g.Values[1],
+				Counts:      map[string]int{},
+				Services:    map[string]types.CostValue{},
+				Regions:     map[string]types.CostValue{},
+			})
+		}
+		s := &summaries[i]
+		if g.Values[len(g.Values)-1] == "datatransfer" {
+			s.DataTransferCost = s.DataTransferCost.Add(g.TotalCost)
+		} else {
+			s.Counts[g.Values[2]] += g.Count
+		}
+		s.Services[g.Values[2]] = s.Services[g.Values[2]].Add(g.TotalCost)
+		s.TotalCost = s.TotalCost.Add(g.TotalCost)
+	}
+	for _, g := range GroupBy(resources, []string{DimAccount, DimRegion}) {
+		regions := summaries[index[g.Values[0]]].Regions
+		regions[g.Values[1]] = regions[g.Values[1]].Add(g.TotalCost)
+	}
+
+	sortBy(summaries, DefaultOrder, func(a types.AccountSummary) sortKey {
+		return sortKey{cost: a.TotalCost, name: a.AccountName, id: a.AccountID}
+	})
+	return summaries
+}
+
+// RegionSummaries builds per-region cost summaries, with each region's
+// resource count by resource type and hourly cost by account ID, highest cost
+// first
+func RegionSummaries(resources []snapshot.Resource) []types.RegionSummary {
+	index := make(map[string]int)
+	summaries := []types.RegionSummary{}
+
+	for _, g := range GroupBy(resources, []string{DimRegion, DimService}) {
+		i, ok := index[g.Values[0]]
+		if !ok {
+			i = len(summaries)
+			index[g.Values[0]] = i
+			summaries = append(summaries, types.RegionSummary{Region: g.Values[0], Counts: map[string]int{}, Accounts: map[string]types.CostValue{}})
+		}
+		s := &summaries[i]
+		if g.Values[len(g.Values)-1] == "datatransfer" {
+			s.DataTransferCost = s.DataTransferCost.Add(g.TotalCost)
+		} else {
+			s.Counts[g.Values[1]] += g.Count
+		}
+		s.TotalCost = s.TotalCost.Add(g.TotalCost)
+	}
+	for _, g := range GroupBy(resources, []string{DimRegion, DimAccount}) {
+		accounts := summaries[index[g.Values[0]]].Accounts
+		accounts[g.Values[1]] = accounts[g.Values[1]].Add(g.TotalCost)
+	}
+
+	sortBy(summaries, DefaultOrder, func(r types.RegionSummary) sortKey {
+		return sortKey{cost: r.TotalCost, name: r.Region, id: r.Region}
+	})
+	return summaries
+}
+
+// AddDeltas sets each account and region summary's previous total and
+// percent change from the resources of an earlier scan. Accounts and regions
+// missing from the earlier scan get a previous total of 0 and no percent.
+func AddDeltas(accounts []types.AccountSummary, regions []types.RegionSummary, previous []snapshot.Resource) {
+	accountTotals := make(map[string]types.CostValue)
+	regionTotals := make(map[string]types.CostValue)
+	for _, r := range previous {
+		accountTotals[r.AccountID] = accountTotals[r.AccountID].Add(r.HourlyCost)
+		regionTotals[r.Region] = regionTotals[r.Region].Add(r.HourlyCost)
+	}
+	for i := range accounts {
+		accounts[i].PreviousTotalCost, accounts[i].DeltaPercent = delta(accounts[i].TotalCost, accountTotals[accounts[i].AccountID])
+	}
+	for i := range regions {
+		regions[i].PreviousTotalCost, regions[i].DeltaPercent = delta(regions[i].TotalCost, regionTotals[regions[i].Region])
+	}
+}
+
+// delta returns the previous total and the percent change from it. It is only
+// used when there is a previous snapshot, so the total is always set, to 0 for
+// accounts and regions that are new since; the percent is unset in that case.
+func delta(current, previous types.CostValue) (*types.CostValue, *float64) {
+	if previous == 0 {
+		return &previous, nil
+	}
+	percent := math.Round(float64((current-previous)/previous)*1000) / 10
+	return &previous, &percent
+}
+
+// costCenter returns r's cost center, or Unallocated if it has none
+func costCenter(r snapshot.Resource) string {
+	if r.CostCenter == "" {
+		return costcenter.Unallocated
+	}
+	return r.CostCenter
+}
+
+// CostCenterCosts totals resources by cost center, highest cost first, with
+// each cost center's hourly cost by resource type
+func CostCenterCosts(resources []snapshot.Resource) []types.CostCenterCost {
+	index := make(map[string]int)
+	costs := []types.CostCenterCost{}
+
+	for _, g := range GroupBy(resources, []string{DimCostCenter, DimService}) {
+		i, ok := index[g.Values[0]]
+		if !ok {
+			i = len(costs)
+			index[g.Values[0]] = i
+			costs = append(costs, types.CostCenterCost{CostCenter: g.Values[0], Services: map[string]types.CostValue{}})
+		}
+		c := &costs[i]
+		c.Count += g.Count
+		c.TotalCost = c.TotalCost.Add(g.TotalCost)
+		c.Services[g.Values[1]] = c.Services[g.Values[1]].Add(g.TotalCost)
+	}
+
+	sort.SliceStable(costs, func(i, j int) bool {
+		if costs[i].TotalCost != costs[j].TotalCost {
+			return costs[i].TotalCost > costs[j].TotalCost
+		}
+		return costs[i].CostCenter < costs[j].CostCenter
+	})
+	return costs
+}
+
+// environmentOf returns r's environment, or Unclassified if it has none
+func environmentOf(r snapshot.Resource) string {
+	if r.Environment == "" {
+		return environment.Unclassified
+	}
+	return r.Environment
+}
+
+// EnvironmentCosts totals classified resources by environment, highest cost
+// first, with each environment's hourly cost by resource type. production
+// reports which environments are production.
+func EnvironmentCosts(resources []snapshot.Resource, production func(string) bool) []types.EnvironmentCost {
+	index := make(map[string]int)
+	costs := []types.EnvironmentCost{}
+
+	for _, g := range GroupBy(resources, []string{DimEnvironment, DimService}) {
+		i, ok := index[g.Values[0]]
+		if !ok {
+			i = len(costs)
+			index[g.Values[0]] = i
+			costs = append(costs, types.EnvironmentCost{
+				Environment: g.Values[0],
+				Production:  production(g.Values[0]),
+				Services:    map[string]types.CostValue{},
+			})
+		}
+		c := &costs[i]
+		c.Count += g.Count
+		c.TotalCost = c.TotalCost.Add(g.TotalCost)
+		c.Services[g.Values[1]] = c.Services[g.Values[1]].Add(g.TotalCost)
+	}
+
+	sort.SliceStable(costs, func(i, j int) bool {
+		if costs[i].TotalCost != costs[j].TotalCost {
+			return costs[i].TotalCost > costs[j].TotalCost
+		}
+		return costs[i].Environment < costs[j].Environment
+	})
+	return costs
+}
+
+// VPCResourceTypes lists the resource types that belong to a VPC
+var VPCResourceTypes = []string{"ec2", "rds", "elb", "nat", "eip"}
+
+// VPCCosts totals resources by VPC and subnet, highest cost first. Resources
+// outside a VPC are skipped.
+func VPCCosts(resources []snapshot.Resource) []types.VPCCost {
+	index := make(map[string]int)
+	vpcs := []types.VPCCost{}
+
+	for _, g := range GroupBy(resources, []string{DimAccount, DimRegion, DimVPC, DimSubnet, DimAccountName}) {
+		accountID, region, vpcID, subnetID := g.Values[0], g.Values[1], g.Values[2], g.Values[3]
+		if vpcID == "" {
+			continue
+		}
+
+		key := accountID + "\x00" + region + "\x00" + vpcID
+		i, ok := index[key]
+		if !ok {
+			i = len(vpcs)
+			index[key] = i
+			vpcs = append(vpcs, types.VPCCost{AccountID: accountID, AccountName: g.Values[4], Region: region, VPCID: vpcID})
+		}
+		v := &vpcs[i]
+		v.Count += g.Count
+		v.TotalCost = v.TotalCost.Add(g.TotalCost)
+		v.Subnets = append(v.Subnets, types.SubnetCost{SubnetID: subnetID, Count: g.Count, TotalCost: g.TotalCost})
+	}
+
+	sort.SliceStable(vpcs, func(i, j int) bool { return vpcs[i].TotalCost > vpcs[j].TotalCost })
+	return vpcs
+}
+
+// AutoScalingGroupCosts totals EC2 instances by Auto Scaling group, highest
+// cost first. Instances outside a group are skipped.
+func AutoScalingGroupCosts(instances []types.EC2Instance) []types.AutoScalingGroupCost {
+	index := make(map[string]int)
+	groups := []types.AutoScalingGroupCost{}
+
+	for _, inst := range instances {
+		if inst.AutoScalingGroup == "" {
+			continue
+		}
+		key := inst.AccountID + "\x00" + inst.Region + "\x00" + inst.AutoScalingGroup
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, types.AutoScalingGroupCost{
+				AccountID:   inst.AccountID,
+				AccountName: inst.AccountName,
+				Region:      inst.Region,
+				Name:        inst.AutoScalingGroup,
+			})
+		}
+		g := &groups[i]
+		g.Count++
+		if inst.State == "running" {
+			g.RunningCount++
+		}
+		g.TotalCost = g.TotalCost.Add(inst.HourlyCost)
+		if g.AutoScalingCapacity == nil {
+			g.AutoScalingCapacity = inst.AutoScalingCapacity
+		}
+		if !slices.Contains(g.InstanceTypes, inst.InstanceType) {
+			g.InstanceTypes = append(g.InstanceTypes, inst.InstanceType)
+		}
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		sort.Strings(g.InstanceTypes)
+		if g.AutoScalingCapacity != nil && g.RunningCount > 0 {
+			g.MaxCost = g.TotalCost / types.CostValue(g.RunningCount) * types.CostValue(g.MaxSize)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].TotalCost > groups[j].TotalCost })
+	return groups
+}
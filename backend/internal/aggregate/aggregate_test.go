@@ -0,0 +1,236 @@
+package aggregate
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+var testResources = []snapshot.Resource{
+	{Type: "ec2", ID: "i-1", AccountID: "111", AccountName: "prod", Region: "us-east-1", HourlyCost: 1, Tags: map[string]string{"team": "api"}},
+	{Type: "ec2", ID: "i-2", AccountID: "111", AccountName: "prod", Region: "us-west-2", HourlyCost: 2, Tags: map[string]string{"team": "web"}},
+	{Type: "ebs", ID: "vol-1", AccountID: "111", AccountName: "prod", Region: "us-east-1", HourlyCost: 0.5, Tags: map[string]string{"team": "api"}},
+	{Type: "ecs", ID: "c/s", AccountID: "222", AccountName: "dev", Region: "us-east-1", HourlyCost: 0.25},
+}
+
+func TestGroupBy(t *testing.T) {
+	groups := GroupBy(testResources, []string{DimAccount, "tag:team"})
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+
+	// Sorted by cost, highest first
+	want := []struct {
+		account, team string
+		count         int
+		cost          float64
+	}{
+		{"111", "web", 1, 2},
+		{"111", "api", 2, 1.5},
+		{"222", "", 1, 0.25},
+	}
+	for i, w := range want {
+		g := groups[i]
+		if g.Values[0] != w.account || g.Values[1] != w.team || g.Count != w.count || float64(g.TotalCost) != w.cost {
+			t.Fatalf("group %d: expected %+v, got %+v", i, w, g)
+		}
+	}
+}
+
+func TestSummaries(t *testing.T) {
+	accounts := AccountSummaries(testResources)
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 account summaries, got %+v", accounts)
+	}
+	prod := accounts[0]
+	if prod.AccountID != "111" || prod.AccountName != "prod" || prod.Counts["ec2"] != 2 || prod.Counts["ebs"] != 1 || prod.TotalCost != 3.5 {
+		t.Fatalf("unexpected prod summary: %+v", prod)
+	}
+	if len(prod.Services) != 2 || prod.Services["ec2"] != 3 || prod.Services["ebs"] != 0.5 {
+		t.Fatalf("unexpected prod service costs: %+v", prod.Services)
+	}
+	if len(prod.Regions) != 2 || prod.Regions["us-east-1"] != 1.5 || prod.Regions["us-west-2"] != 2 {
+		t.Fatalf("unexpected prod region costs: %+v", prod.Regions)
+	}
+
+	regions := RegionSummaries(testResources)
+	if len(regions) != 2 {
+		t.Fatalf("expected 2 region summaries, got %+v", regions)
+	}
+	east := regions[1] // us-west-2 costs more
+	if east.Region != "us-east-1" || east.Counts["ec2"] != 1 || east.Counts["ebs"] != 1 || east.Counts["ecs"] != 1 || east.TotalCost != 1.75 {
+		t.Fatalf("unexpected us-east-1 summary: %+v", east)
+	}
+	if len(east.Accounts) != 2 || east.Accounts["111"] != 1.5 || east.Accounts["222"] != 0.25 {
+		t.Fatalf("unexpected us-east-1 account costs: %+v", east.Accounts)
+	}
+}
+
+func TestDataTransferSummaries(t *testing.T) {
+	resources := append(slices.Clone(testResources),
+		snapshot.Resource{Type: "datatransfer", ID: "i-1", AccountID: "111", AccountName: "prod", Region: "us-east-1", HourlyCost: 0.2})
+	accounts := AccountSummaries(resources)
+	if prod := accounts[0]; prod.DataTransferCost != 0.2 || prod.Counts["ec2"] != 2 || math.Abs(float64(prod.TotalCost)-3.7) > 1e-9 {
+		t.Fatalf("unexpected prod summary: %+v", prod)
+	}
+	regions := RegionSummaries(resources)
+	if east := regions[1]; east.Region != "us-east-1" || east.DataTransferCost != 0.2 {
+		t.Fatalf("unexpected us-east-1 summary: %+v", east)
+	}
+}
+
+func TestAddDeltas(t *testing.T) {
+	accounts := AccountSummaries(testResources)
+	regions := RegionSummaries(testResources)
+	previous := []snapshot.Resource{
+		{Type: "ec2", ID: "i-1", AccountID: "111", Region: "us-east-1", HourlyCost: 1},
+		{Type: "ec2", ID: "i-2", AccountID: "111", Region: "us-west-2", HourlyCost: 1.5},
+	}
+	AddDeltas(accounts, regions, previous)
+
+	prod, dev := accounts[0], accounts[1]
+	if *prod.PreviousTotalCost != 2.5 || *prod.DeltaPercent != 40 {
+		t.Errorf("prod: previous %v, delta %v; want 2.5 and 40%%", *prod.PreviousTotalCost, *prod.DeltaPercent)
+	}
+	if *dev.PreviousTotalCost != 0 || dev.DeltaPercent != nil {
+		t.Errorf("new account should have a previous total of 0 and no delta, got %+v", dev)
+	}
+	if east := regions[1]; *east.PreviousTotalCost != 1 || *east.DeltaPercent != 75 {
+		t.Errorf("us-east-1: previous %v, delta %v; want 1 and 75%%", *east.PreviousTotalCost, *east.DeltaPercent)
+	}
+}
+
+func TestCompareGroups(t *testing.T) {
+	baseline := []snapshot.Resource{
+		{Type: "ec2", ID: "i-1", AccountID: "111", Region: "us-east-1", HourlyCost: 1},
+		{Type: "ec2", ID: "i-2", AccountID: "111", Region: "us-west-2", HourlyCost: 1.5},
+		{Type: "rds", ID: "db-1", AccountID: "111", Region: "us-east-1", HourlyCost: 1},
+	}
+	rows := CompareGroups(baseline, testResources, []string{DimAccount, DimService})
+
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.Values[1])
+	}
+	if !slices.Equal(got, []string{"rds", "ebs", "ec2", "ecs"}) {
+		t.Fatalf("rows = %v, want the largest change first", got)
+	}
+	if rds := rows[0]; rds.Count != 0 || rds.BaselineCount != 1 || rds.Delta != -1 || *rds.DeltaPercent != -100 {
+		t.Errorf("removed rds group: %+v", rds)
+	}
+	if ec2 := rows[2]; ec2.BaselineCost != 2.5 || ec2.TotalCost != 3 || ec2.Delta != 0.5 || *ec2.DeltaPercent != 20 {
+		t.Errorf("ec2 group: %+v", ec2)
+	}
+	if ebs := rows[1]; ebs.BaselineCount != 0 || ebs.DeltaPercent != nil {
+		t.Errorf("new ebs group should have no percent change, got %+v", ebs)
+	}
+}
+
+func TestValidateDimensions(t *testing.T) {
+	if err := ValidateDimensions([]string{DimAccount, DimService, "tag:team"}); err != nil {
+		t.Fatalf("expected valid dimensions, got %v", err)
+	}
+	for _, dims := range [][]string{nil, {"bogus"}, {"tag:"}, {DimRegion, DimRegion}} {
+		if err := ValidateDimensions(dims); err == nil {
+			t.Fatalf("expected %v to be rejected", dims)
+		}
+	}
+}
+
+func TestVPCCosts(t *testing.T) {
+	resources := []snapshot.Resource{
+		{Type: "ec2", ID: "i-1", AccountID: "111", AccountName: "prod", Region: "us-east-1", VPCID: "vpc-a", SubnetID: "subnet-1", HourlyCost: 1},
+		{Type: "rds", ID: "db-1", AccountID: "111", AccountName: "prod", Region: "us-east-1", VPCID: "vpc-a", SubnetID: "subnet-2", HourlyCost: 2},
+		{Type: "elb", ID: "lb-1", AccountID: "111", AccountName: "prod", Region: "us-east-1", VPCID: "vpc-b", HourlyCost: 0.5},
+		{Type: "ebs", ID: "vol-1", AccountID: "111", AccountName: "prod", Region: "us-east-1", HourlyCost: 9},
+	}
+
+	vpcs := VPCCosts(resources)
+	if len(vpcs) != 2 {
+		t.Fatalf("expected 2 VPCs, got %+v", vpcs)
+	}
+	a := vpcs[0]
+	if a.VPCID != "vpc-a" || a.AccountName != "prod" || a.Count != 2 || a.TotalCost != 3 {
+		t.Fatalf("unexpected first VPC: %+v", a)
+	}
+	if len(a.Subnets) != 2 || a.Subnets[0].SubnetID != "subnet-2" || a.Subnets[0].TotalCost != 2 {
+		t.Fatalf("unexpected subnets: %+v", a.Subnets)
+	}
+	if b := vpcs[1]; b.VPCID != "vpc-b" || len(b.Subnets) != 1 || b.Subnets[0].SubnetID != "" {
+		t.Fatalf("unexpected second VPC: %+v", b)
+	}
+}
+
+func TestAutoScalingGroupCosts(t *testing.T) {
+	capacity := &types.AutoScalingCapacity{MinSize: 1, MaxSize: 4, DesiredCapacity: 2}
+	instances := []types.EC2Instance{
+		{InstanceID: "i-1", AccountID: "111", Region: "us-east-1", InstanceType: "m5.large", State: "running", HourlyCost: 0.1, AutoScalingGroup: "web", AutoScalingCapacity: capacity},
+		{InstanceID: "i-2", AccountID: "111", Region: "us-east-1", InstanceType: "m5a.large", State: "running", HourlyCost: 0.08, AutoScalingGroup: "web", AutoScalingCapacity: capacity},
+		{InstanceID: "i-3", AccountID: "111", Region: "us-east-1", InstanceType: "t3.micro", State: "stopped", AutoScalingGroup: "batch"},
+		{InstanceID: "i-4", AccountID: "111", Region: "us-east-1", InstanceType: "c5.large", State: "running", HourlyCost: 5},
+	}
+
+	groups := AutoScalingGroupCosts(instances)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	web := groups[0]
+	if web.Name != "web" || web.Count != 2 || web.RunningCount != 2 || web.DesiredCapacity != 2 {
+		t.Fatalf("unexpected first group: %+v", web)
+	}
+	if !slices.Equal(web.InstanceTypes, []string{"m5.large", "m5a.large"}) {
+		t.Fatalf("InstanceTypes = %v", web.InstanceTypes)
+	}
+	if math.Abs(float64(web.MaxCost-0.36)) > 1e-9 {
+		t.Fatalf("MaxCost = %v, want 0.36", web.MaxCost)
+	}
+	if batch := groups[1]; batch.Name != "batch" || batch.RunningCount != 0 || batch.AutoScalingCapacity != nil || batch.MaxCost != 0 {
+		t.Fatalf("unexpected second group: %+v", batch)
+	}
+}
+
+func TestCostCenterCosts(t *testing.T) {
+	resources := []snapshot.Resource{
+		{Type: "ec2", ID: "i-1", HourlyCost: 1, CostCenter: "platform"},
+		{Type: "ebs", ID: "vol-1", HourlyCost: 0.5, CostCenter: "platform"},
+		{Type: "ec2", ID: "i-2", HourlyCost: 2},
+	}
+
+	costs := CostCenterCosts(resources)
+	if len(costs) != 2 {
+		t.Fatalf("expected 2 cost centers, got %+v", costs)
+	}
+	if u := costs[0]; u.CostCenter != "unallocated" || u.Count != 1 || u.TotalCost != 2 {
+		t.Fatalf("unexpected first cost center: %+v", u)
+	}
+	if p := costs[1]; p.CostCenter != "platform" || p.Count != 2 || p.TotalCost != 1.5 || p.Services["ebs"] != 0.5 {
+		t.Fatalf("unexpected second cost center: %+v", p)
+	}
+}
+
+func TestBilling(t *testing.T) {
+	if got := Billing(100, config.BillingConfig{}, true); got != nil {
+		t.Fatalf("Billing() without discount or credits = %+v, want nil", got)
+	}
+
+	cfg := config.BillingConfig{DiscountPercent: 10, MonthlyCredits: 730}
+	got := Billing(100, cfg, true)
+	want := types.BillingTotals{ListCost: 100, DiscountPercent: 10, Discount: 10, Credits: 1, NetCost: 89}
+	if *got != want {
+		t.Fatalf("Billing() = %+v, want %+v", *got, want)
+	}
+
+	// Credits cover the whole bill, so a filtered total only gets the discount
+	if got := Billing(100, cfg, false); got.Credits != 0 || got.NetCost != 90 {
+		t.Fatalf("filtered Billing() = %+v, want only the discount", *got)
+	}
+
+	// Credits never take the net cost below zero
+	if got := Billing(0.5, cfg, true); got.Credits != 0.45 || got.NetCost != 0 {
+		t.Fatalf("Billing() with more credits than cost = %+v", *got)
+	}
+}
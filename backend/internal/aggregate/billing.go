@@ -0,0 +1,28 @@
+package aggregate
+
+import (
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Billing applies cfg's discount and credits to an hourly list cost, or
+// returns nil if cfg has neither. Credits cover the whole bill, so they're
+// only applied when wholeBill is true, never to a filtered subset, and never
+// take the net cost below zero.
+func Billing(listCost types.CostValue, cfg config.BillingConfig, wholeBill bool) *types.BillingTotals {
+	if cfg.DiscountPercent == 0 && cfg.MonthlyCredits == 0 {
+		return nil
+	}
+
+	totals := &types.BillingTotals{
+		ListCost:        listCost,
+		DiscountPercent: cfg.DiscountPercent,
+		Discount:        listCost * types.CostValue(cfg.DiscountPercent/100),
+	}
+	totals.NetCost = listCost - totals.Discount
+	if wholeBill {
+		totals.Credits = min(types.CostValue(cfg.MonthlyCredits)/types.HoursPerMonth, totals.NetCost)
+		totals.NetCost = totals.NetCost.Add(-totals.Credits)
+	}
+	return totals
+}
@@ -0,0 +1,203 @@
+package aggregate
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Fields summaries and resource lists can be sorted by
+const (
+	SortCost = "cost" // Highest hourly cost first
+	SortName = "name" // Alphabetical by name, then ID
+)
+
+// Order is how summaries and resource lists are sorted. Ties are broken by
+// name and then ID, so the same data is always returned in the same order.
+type Order struct {
+	Field   string
+	Reverse bool // Lowest cost first, or reverse alphabetical
+}
+
+// DefaultOrder sorts by cost, highest first
+var DefaultOrder = Order{Field: SortCost}
+
+// ParseOrder parses a ?sort= value: cost or name, optionally prefixed with
+// "-" to reverse it. An empty value is DefaultOrder.
+func ParseOrder(s string) (Order, error) {
+	if s == "" {
+		return DefaultOrder, nil
+	}
+	field, reverse := strings.CutPrefix(s, "-")
+	if field != SortCost && field != SortName {
+		return Order{}, fmt.Errorf("invalid sort %q (valid: %s, %s, -%s, -%s)", s, SortCost, SortName, SortCost, SortName)
+	}
+	return Order{Field: field, Reverse: reverse}, nil
+}
+
+// sortKey is what an item is sorted by
+type sortKey struct {
+	cost types.CostValue
+	name string
+	id   string // Unique within a list, e.g. account, region, and resource ID
+}
+
+func (o Order) less(a, b sortKey) bool {
+	if o.Field == SortCost {
+		if a.cost != b.cost {
+			return (a.cost > b.cost) != o.Reverse
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.id < b.id
+	}
+	if a.name != b.name {
+		return (a.name < b.name) != o.Reverse
+	}
+	return (a.id < b.id) != o.Reverse
+}
+
+func sortBy[T any](items []T, order Order, key func(T) sortKey) {
+	sort.SliceStable(items, func(i, j int) bool { return order.less(key(items[i]), key(items[j])) })
+}
+
+// resourceKey is the sort key of a resource
+func resourceKey(cost types.CostValue, name, accountID, region, id string) sortKey {
+	return sortKey{cost: cost, name: name, id: accountID + "|" + region + "|" + id}
+}
+
+// SortResources sorts flattened resources in place
+func SortResources(resources []snapshot.Resource, order Order) {
+	sortBy(resources, order, func(r snapshot.Resource) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.Type+"|"+r.ID)
+	})
+}
+
+// Sort sorts resp's summaries and resource lists in place
+func Sort(resp *types.CostResponse, order Order) {
+	sortBy(resp.Accounts, order, func(a types.AccountSummary) sortKey {
+		return sortKey{cost: a.TotalCost, name: a.AccountName, id: a.AccountID}
+	})
+	sortBy(resp.Regions, order, func(r types.RegionSummary) sortKey {
+		return sortKey{cost: r.TotalCost, name: r.Region, id: r.Region}
+	})
+	sortBy(resp.Environments, order, func(e types.EnvironmentCost) sortKey {
+		return sortKey{cost: e.TotalCost, name: e.Environment, id: e.Environment}
+	})
+	sortBy(resp.UnpricedResources, order, func(r types.UnpricedResource) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ResourceType+"|"+r.ResourceID)
+	})
+	sortBy(resp.EC2Instances, order, func(r types.EC2Instance) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.InstanceID)
+	})
+	sortBy(resp.EBSVolumes, order, func(r types.EBSVolume) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.VolumeID)
+	})
+	sortBy(resp.ECSServices, order, func(r types.ECSService) sortKey {
+		return resourceKey(r.HourlyCost, r.ServiceName, r.AccountID, r.Region, r.ClusterName+"/"+r.ServiceName)
+	})
+	sortBy(resp.RDSInstances, order, func(r types.RDSInstance) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.DBInstanceID)
+	})
+	sortBy(resp.EKSClusters, order, func(r types.EKSCluster) sortKey {
+		return resourceKey(r.HourlyCost, r.ClusterName, r.AccountID, r.Region, r.ClusterName)
+	})
+	sortBy(resp.LoadBalancers, order, func(r types.LoadBalancer) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.NATGateways, order, func(r types.NATGateway) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ID)
+	})
+	sortBy(resp.ElasticIPs, order, func(r types.ElasticIP) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.AllocationID)
+	})
+	sortBy(resp.Secrets, order, func(r types.Secret) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.PublicIPv4s, order, func(r types.PublicIPv4) sortKey {
+		return resourceKey(r.HourlyCost, r.InstanceName, r.AccountID, r.Region, r.PublicIP)
+	})
+	sortBy(resp.Lambdas, order, func(r types.LambdaFunction) sortKey {
+		return resourceKey(r.HourlyCost, r.FunctionName, r.AccountID, r.Region, r.FunctionARN)
+	})
+	sortBy(resp.CapacityReservations, order, func(r types.CapacityReservation) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ID)
+	})
+	sortBy(resp.DedicatedHosts, order, func(r types.DedicatedHost) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.HostID)
+	})
+	sortBy(resp.KinesisStreams, order, func(r types.KinesisStream) sortKey {
+		return resourceKey(r.HourlyCost, r.StreamName, r.AccountID, r.Region, r.StreamARN)
+	})
+	sortBy(resp.FirehoseStreams, order, func(r types.FirehoseStream) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.SQSQueues, order, func(r types.SQSQueue) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.SNSTopics, order, func(r types.SNSTopic) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.EventBuses, order, func(r types.EventBus) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.Alarms, order, func(r types.Alarm) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.Dashboards, order, func(r types.Dashboard) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ARN)
+	})
+	sortBy(resp.CustomMetrics, order, func(r types.CustomMetrics) sortKey {
+		return resourceKey(r.HourlyCost, r.Namespace, r.AccountID, r.Region, r.Namespace)
+	})
+	sortBy(resp.DataTransfer, order, func(r types.DataTransfer) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ResourceType+"|"+r.ResourceID)
+	})
+	sortBy(resp.PluginResources, order, func(r types.PluginResource) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.Type+"|"+r.ID)
+	})
+	sortBy(resp.VirtualMachines, order, func(r types.VirtualMachine) sortKey {
+		return resourceKey(r.HourlyCost, r.Name, r.AccountID, r.Region, r.ID)
+	})
+}
+
+// Sorted returns a copy of resp with its summaries and resource lists sorted,
+// leaving resp, which may be cached and shared, as it is
+func Sorted(resp *types.CostResponse, order Order) *types.CostResponse {
+	out := *resp
+	out.Accounts = slices.Clone(resp.Accounts)
+	out.Regions = slices.Clone(resp.Regions)
+	out.Environments = slices.Clone(resp.Environments)
+	out.UnpricedResources = slices.Clone(resp.UnpricedResources)
+	out.EC2Instances = slices.Clone(resp.EC2Instances)
+	out.EBSVolumes = slices.Clone(resp.EBSVolumes)
+	out.ECSServices = slices.Clone(resp.ECSServices)
+	out.RDSInstances = slices.Clone(resp.RDSInstances)
+	out.EKSClusters = slices.Clone(resp.EKSClusters)
+	out.LoadBalancers = slices.Clone(resp.LoadBalancers)
+	out.NATGateways = slices.Clone(resp.NATGateways)
+	out.ElasticIPs = slices.Clone(resp.ElasticIPs)
+	out.Secrets = slices.Clone(resp.Secrets)
+	out.PublicIPv4s = slices.Clone(resp.PublicIPv4s)
+	out.Lambdas = slices.Clone(resp.Lambdas)
+	out.CapacityReservations = slices.Clone(resp.CapacityReservations)
+	out.DedicatedHosts = slices.Clone(resp.DedicatedHosts)
+	out.KinesisStreams = slices.Clone(resp.KinesisStreams)
+	out.FirehoseStreams = slices.Clone(resp.FirehoseStreams)
+	out.SQSQueues = slices.Clone(resp.SQSQueues)
+	out.SNSTopics = slices.Clone(resp.SNSTopics)
+	out.EventBuses = slices.Clone(resp.EventBuses)
+	out.Alarms = slices.Clone(resp.Alarms)
+	out.Dashboards = slices.Clone(resp.Dashboards)
+	out.CustomMetrics = slices.Clone(resp.CustomMetrics)
+	out.DataTransfer = slices.Clone(resp.DataTransfer)
+	out.PluginResources = slices.Clone(resp.PluginResources)
+	out.VirtualMachines = slices.Clone(resp.VirtualMachines)
+	Sort(&out, order)
+	return &out
+}
@@ -0,0 +1,114 @@
+package aggregate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestParseOrder(t *testing.T) {
+	tests := map[string]Order{
+		"":      DefaultOrder,
+		"cost":  {Field: SortCost},
+		"-cost": {Field: SortCost, Reverse: true},
+		"name":  {Field: SortName},
+		"-name": {Field: SortName, Reverse: true},
+	}
+	for s, want := range tests {
+		if got, err := ParseOrder(s); err != nil || got != want {
+			t.Errorf("ParseOrder(%q) = %+v, %v; want %+v", s, got, err, want)
+		}
+	}
+	if _, err := ParseOrder("size"); err == nil {
+		t.Error("expected an error for an unknown sort field")
+	}
+}
+
+func TestSorted(t *testing.T) {
+	resp := &types.CostResponse{
+		Accounts: []types.AccountSummary{
+			{AccountID: "333", AccountName: "staging", TotalCost: 1},
+			{AccountID: "111", AccountName: "prod", TotalCost: 5},
+			{AccountID: "222", AccountName: "dev", TotalCost: 1},
+		},
+		EC2Instances: []types.EC2Instance{
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-b", Name: "web", HourlyCost: 0.1},
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-a", Name: "web", HourlyCost: 0.1},
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-c", Name: "api", HourlyCost: 0.4},
+		},
+	}
+
+	byCost := Sorted(resp, DefaultOrder)
+	if got := accountIDs(byCost.Accounts); got != "111,222,333" {
+		t.Errorf("accounts by cost = %s; want ties broken by name", got)
+	}
+	if got := instanceIDs(byCost.EC2Instances); got != "i-c,i-a,i-b" {
+		t.Errorf("instances by cost = %s; want ties broken by ID", got)
+	}
+	if got := accountIDs(resp.Accounts); got != "333,111,222" {
+		t.Errorf("Sorted reordered the original: %s", got)
+	}
+
+	byName := Sorted(resp, Order{Field: SortName, Reverse: true})
+	if got := accountIDs(byName.Accounts); got != "333,111,222" {
+		t.Errorf("accounts by reverse name = %s", got)
+	}
+	if got := instanceIDs(byName.EC2Instances); got != "i-b,i-a,i-c" {
+		t.Errorf("instances by reverse name = %s", got)
+	}
+
+	lowest := Sorted(resp, Order{Field: SortCost, Reverse: true})
+	if got := accountIDs(lowest.Accounts); got != "222,333,111" {
+		t.Errorf("accounts by lowest cost = %s", got)
+	}
+}
+
+func accountIDs(accounts []types.AccountSummary) string {
+	s := ""
+	for i, a := range accounts {
+		if i > 0 {
+			s += ","
+		}
+		s += a.AccountID
+	}
+	return s
+}
+
+func instanceIDs(instances []types.EC2Instance) string {
+	s := ""
+	for i, inst := range instances {
+		if i > 0 {
+			s += ","
+		}
+		s += inst.InstanceID
+	}
+	return s
+}
+
+func TestSortResources(t *testing.T) {
+	resources := snapshot.Resources(&types.CostResponse{
+		EC2Instances: []types.EC2Instance{{AccountID: "111", Region: "us-east-1", InstanceID: "i-a", Name: "web", HourlyCost: 0.1}},
+		Lambdas: []types.LambdaFunction{{
+			AccountID: "111", Region: "us-east-1", FunctionARN: "arn:aws:lambda:us-east-1:111:function:api", FunctionName: "api", HourlyCost: 0.4,
+			CostComponents: []types.CostComponent{{Name: "requests", HourlyCost: 0.1}, {Name: "compute", HourlyCost: 0.3}},
+		}},
+		SQSQueues: []types.SQSQueue{{AccountID: "111", Region: "us-east-1", ARN: "arn:aws:sqs:us-east-1:111:jobs", Name: "jobs", HourlyCost: 0.1}},
+	})
+
+	SortResources(resources, DefaultOrder)
+	var got []string
+	for _, r := range resources {
+		got = append(got, r.Type)
+	}
+	if strings.Join(got, ",") != "lambda,sqs,ec2" {
+		t.Errorf("resources by cost = %v; want ties broken by name", got)
+	}
+	if fn := resources[0]; fn.ARN != fn.ID || len(fn.Components) != 2 {
+		t.Errorf("lambda = %+v; want its ARN and cost components", fn)
+	}
+	if resources[2].ARN != "" {
+		t.Errorf("ec2 ARN = %q; want none", resources[2].ARN)
+	}
+}
@@ -0,0 +1,37 @@
+package aggregate
+
+import "github.com/johnjeffers/awscogs/backend/internal/types"
+
+// InstanceStorageCosts returns the hourly cost of the EBS volumes attached to
+// each instance, by instance ID. A Multi-Attach volume's cost is split evenly
+// among its instances.
+func InstanceStorageCosts(volumes []types.EBSVolume) map[string]types.CostValue {
+	costs := make(map[string]types.CostValue)
+	for _, vol := range volumes {
+		if len(vol.AttachedInstanceIDs) == 0 {
+			continue
+		}
+		share := (vol.HourlyCost / types.CostValue(len(vol.AttachedInstanceIDs))).Round()
+		for _, id := range vol.AttachedInstanceIDs {
+			costs[id] = costs[id].Add(share)
+		}
+	}
+	return costs
+}
+
+// AttributeStoppedStorage sets each stopped EC2 instance's attached storage
+// cost from the volumes in resp. Volumes that weren't scanned, such as when
+// the scan is filtered to ec2, aren't counted.
+func AttributeStoppedStorage(resp *types.CostResponse) {
+	var costs map[string]types.CostValue
+	for i := range resp.EC2Instances {
+		inst := &resp.EC2Instances[i]
+		if inst.State != "stopped" {
+			continue
+		}
+		if costs == nil {
+			costs = InstanceStorageCosts(resp.EBSVolumes)
+		}
+		inst.AttachedStorageCost = costs[inst.InstanceID]
+	}
+}
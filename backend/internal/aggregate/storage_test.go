@@ -0,0 +1,34 @@
+package aggregate
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestAttributeStoppedStorage(t *testing.T) {
+	resp := &types.CostResponse{
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-stopped", State: "stopped"},
+			{InstanceID: "i-running", State: "running", HourlyCost: 0.096},
+			{InstanceID: "i-bare", State: "stopped"},
+		},
+		EBSVolumes: []types.EBSVolume{
+			{VolumeID: "vol-root", AttachedInstanceIDs: []string{"i-stopped"}, HourlyCost: 0.01},
+			{VolumeID: "vol-shared", AttachedInstanceIDs: []string{"i-stopped", "i-running"}, HourlyCost: 0.2},
+			{VolumeID: "vol-running", AttachedInstanceIDs: []string{"i-running"}, HourlyCost: 0.05},
+			{VolumeID: "vol-orphan", HourlyCost: 0.03},
+		},
+	}
+
+	AttributeStoppedStorage(resp)
+	if got := resp.EC2Instances[0].AttachedStorageCost; got != 0.11 {
+		t.Errorf("stopped instance storage = %v, want 0.11", got)
+	}
+	if got := resp.EC2Instances[1].AttachedStorageCost; got != 0 {
+		t.Errorf("running instances shouldn't be attributed storage, got %v", got)
+	}
+	if got := resp.EC2Instances[2].AttachedStorageCost; got != 0 {
+		t.Errorf("instance without volumes = %v, want 0", got)
+	}
+}
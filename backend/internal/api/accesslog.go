@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// clfTimeFormat is the Common Log Format timestamp, e.g. 10/Oct/2000:13:55:36 -0700
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// accessLog returns middleware logging each request in cfg's format, or nil
+// if access logging is off. JSON records go to logger. CLF lines go to w, and
+// like JSON records are only written when logger is enabled for info.
+func accessLog(cfg config.AccessLogConfig, logger *slog.Logger, w io.Writer) func(http.Handler) http.Handler {
+	if cfg.Format == config.AccessLogOff {
+		return nil
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = body
+			}
+			ww := middleware.NewWrapResponseWriter(rw, r.ProtoMajor)
+
+			defer func() {
+				if !logger.Enabled(r.Context(), slog.LevelInfo) {
+					return
+				}
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				user := requestUser(r, cfg.UserHeader)
+
+				if cfg.Format == config.AccessLogCLF {
+					fmt.Fprintf(w, "%s - %s [%s] %q %d %d\n",
+						clientHost(r.RemoteAddr), orDash(user), start.Format(clfTimeFormat),
+						r.Method+" "+r.RequestURI+" "+r.Proto, status, ww.BytesWritten())
+					return
+				}
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("query", r.URL.RawQuery),
+					slog.Int("status", status),
+					slog.Float64("durationMs", float64(time.Since(start).Microseconds())/1000),
+					slog.String("remoteAddr", r.RemoteAddr),
+					slog.String("requestId", middleware.GetReqID(r.Context())),
+				}
+				if user != "" {
+					attrs = append(attrs, slog.String("user", user))
+				}
+				if cfg.BodySizes {
+					attrs = append(attrs, slog.Int64("requestBytes", body.n), slog.Int("responseBytes", ww.BytesWritten()))
+				}
+				logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// requestUser returns the user an authenticating proxy put in header, or the
+// HTTP basic auth user if header is empty
+func requestUser(r *http.Request, header string) string {
+	if header != "" {
+		return r.Header.Get(header)
+	}
+	user, _, _ := r.BasicAuth()
+	return user
+}
+
+// clientHost returns addr without its port
+func clientHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return orDash(addr)
+}
+
+// orDash returns s, or "-" for a missing CLF field
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	// CLF fields are space-separated
+	return strings.ReplaceAll(s, " ", "%20")
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
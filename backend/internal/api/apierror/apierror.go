@@ -0,0 +1,113 @@
+// Package apierror writes API errors as a JSON envelope with a typed code, so
+// clients can handle failures without parsing messages.
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/smithy-go"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Code identifies a kind of failure
+type Code string
+
+// Error codes
+const (
+	CodeInvalidFilter        Code = "INVALID_FILTER"         // An account, region, resource, or dimension filter is invalid
+	CodeInvalidParameter     Code = "INVALID_PARAMETER"      // A query or path parameter is invalid
+	CodeInvalidRequest       Code = "INVALID_REQUEST"        // The request body is invalid
+	CodeNotFound             Code = "NOT_FOUND"              // The requested data doesn't exist
+	CodeResourceTypeDisabled Code = "RESOURCE_TYPE_DISABLED" // The resource type is not enabled
+	CodeUnknownProfile       Code = "UNKNOWN_PROFILE"        // ?profile= names a profile that isn't configured
+	CodeAWSAuthFailed        Code = "AWS_AUTH_FAILED"        // AWS credentials are missing, invalid, or expired
+	CodeAWSAccessDenied      Code = "AWS_ACCESS_DENIED"      // AWS credentials lack a required permission
+	CodeThrottled            Code = "THROTTLED"              // An AWS API throttled requests
+	CodeRateLimited          Code = "RATE_LIMITED"           // The client made too many API requests
+	CodeScanInProgress       Code = "SCAN_IN_PROGRESS"       // A scan with an overlapping scope is already queued or running
+	CodeShuttingDown         Code = "SHUTTING_DOWN"          // The server is shutting down and no longer starts scans
+	CodeTimeout              Code = "TIMEOUT"                // The request ran out of time
+	CodeInternal             Code = "INTERNAL"               // Anything else
+)
+
+// Error is the JSON body of every API error response
+type Error struct {
+	Code      Code   `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Write writes an error response. details is optional structured context,
+// such as the allowed values for a parameter.
+func Write(w http.ResponseWriter, r *http.Request, status int, code Code, message string, details any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Error{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
+
+// Internal writes an error response for an unexpected failure, classifying
+// AWS authentication, permission, and throttling errors and timeouts. The
+// message never includes err's text, which can contain account details.
+func Internal(w http.ResponseWriter, r *http.Request, err error) {
+	status, code, message := Classify(err)
+	Write(w, r, status, code, message, nil)
+}
+
+// AWS error codes by category
+var (
+	authErrorCodes = map[string]bool{
+		"ExpiredToken":                true,
+		"ExpiredTokenException":       true,
+		"InvalidClientTokenId":        true,
+		"UnrecognizedClientException": true,
+		"SignatureDoesNotMatch":       true,
+		"AuthFailure":                 true,
+		"InvalidAccessKeyId":          true,
+		"InvalidToken":                true,
+	}
+	deniedErrorCodes = map[string]bool{
+		"AccessDenied":          true,
+		"AccessDeniedException": true,
+		"UnauthorizedOperation": true,
+		"AuthorizationError":    true,
+	}
+	throttledErrorCodes = map[string]bool{
+		"Throttling":                             true,
+		"ThrottlingException":                    true,
+		"ThrottledException":                     true,
+		"RequestLimitExceeded":                   true,
+		"TooManyRequestsException":               true,
+		"RequestThrottled":                       true,
+		"RequestThrottledException":              true,
+		"ProvisionedThroughputExceededException": true,
+	}
+)
+
+// Classify returns the status, code, and client-safe message for err
+func Classify(err error) (int, Code, string) {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch code := apiErr.ErrorCode(); {
+		case authErrorCodes[code]:
+			return http.StatusBadGateway, CodeAWSAuthFailed, "AWS rejected the credentials awsCOGS is running with (" + code + ")"
+		case deniedErrorCodes[code]:
+			return http.StatusBadGateway, CodeAWSAccessDenied, "AWS denied a request awsCOGS needs to make (" + code + "); see /api/v1/permissions-check"
+		case throttledErrorCodes[code]:
+			return http.StatusServiceUnavailable, CodeThrottled, "AWS throttled requests (" + code + "); try again shortly"
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, CodeTimeout, "the request timed out"
+	}
+	return http.StatusInternalServerError, CodeInternal, "internal server error"
+}
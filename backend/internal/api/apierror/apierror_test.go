@@ -0,0 +1,59 @@
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestWrite(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/costs", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "host/abc-000001"))
+	rec := httptest.NewRecorder()
+
+	Write(rec, req, http.StatusBadRequest, CodeInvalidFilter, "unknown resource type", map[string]string{"parameter": "resource"})
+
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body Error
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if body.Code != CodeInvalidFilter || body.Message != "unknown resource type" || body.RequestID != "host/abc-000001" || body.Details == nil {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	wrap := func(code string) error {
+		return fmt.Errorf("listing accounts: %w", &smithy.GenericAPIError{Code: code, Message: "secret details"})
+	}
+	tests := []struct {
+		err    error
+		status int
+		code   Code
+	}{
+		{wrap("ExpiredToken"), http.StatusBadGateway, CodeAWSAuthFailed},
+		{wrap("AccessDeniedException"), http.StatusBadGateway, CodeAWSAccessDenied},
+		{wrap("ThrottlingException"), http.StatusServiceUnavailable, CodeThrottled},
+		{fmt.Errorf("scanning: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, CodeTimeout},
+		{errors.New("boom"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		status, code, message := Classify(tt.err)
+		if status != tt.status || code != tt.code {
+			t.Errorf("Classify(%v) = %d %s, want %d %s", tt.err, status, code, tt.status, tt.code)
+		}
+		if message == "" || message == tt.err.Error() {
+			t.Errorf("Classify(%v) message = %q", tt.err, message)
+		}
+	}
+}
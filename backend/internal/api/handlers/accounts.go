@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// AccountMetadataList is the API response for account metadata
+type AccountMetadataList struct {
+	Accounts []snapshot.AccountMetadata `json:"accounts"`
+}
+
+// accountMetadata returns every account's metadata by account ID. Entries
+// saved through the API replace configured ones.
+func (h *CostsHandler) accountMetadata() map[string]snapshot.AccountMetadata {
+	accounts := make(map[string]snapshot.AccountMetadata, len(h.config.AccountMetadata))
+	for _, m := range h.config.AccountMetadata {
+		accounts[m.ID] = snapshot.AccountMetadata{
+			AccountID: m.ID,
+			AccountMetadataSpec: snapshot.AccountMetadataSpec{
+				DisplayName: m.DisplayName,
+				Environment: m.Environment,
+				OwnerEmail:  m.OwnerEmail,
+				Color:       m.Color,
+			},
+			Source: snapshot.AccountMetadataFromConfig,
+		}
+	}
+	if h.snapshots != nil {
+		for _, m := range h.snapshots.AccountMetadata() {
+			accounts[m.AccountID] = m
+		}
+	}
+	return accounts
+}
+
+// annotateAccounts adds each account's metadata to its summary
+func (h *CostsHandler) annotateAccounts(resp *types.CostResponse) {
+	accounts := h.accountMetadata()
+	if len(accounts) == 0 {
+		return
+	}
+	summaries := make([]types.AccountSummary, len(resp.Accounts))
+	for i, a := range resp.Accounts {
+		if m, ok := accounts[a.AccountID]; ok {
+			a.DisplayName, a.Environment, a.OwnerEmail, a.Color = m.DisplayName, m.Environment, m.OwnerEmail, m.Color
+		}
+		summaries[i] = a
+	}
+	resp.Accounts = summaries
+}
+
+// ListAccountMetadata returns the metadata of every account that has some,
+// from the config file and the API
+func (h *CostsHandler) ListAccountMetadata(w http.ResponseWriter, r *http.Request) {
+	byID := h.accountMetadata()
+	accounts := make([]snapshot.AccountMetadata, 0, len(byID))
+	for _, m := range byID {
+		accounts = append(accounts, m)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+	h.writeJSON(w, http.StatusOK, AccountMetadataList{Accounts: accounts})
+}
+
+// GetAccountMetadata returns one account's metadata
+func (h *CostsHandler) GetAccountMetadata(w http.ResponseWriter, r *http.Request) {
+	m, ok := h.accountMetadata()[chi.URLParam(r, "id")]
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, snapshot.ErrAccountNotFound.Error(), nil)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, m)
+}
+
+// PutAccountMetadata saves an account's metadata, replacing what the config
+// file or an earlier request set
+func (h *CostsHandler) PutAccountMetadata(w http.ResponseWriter, r *http.Request) {
+	var spec snapshot.AccountMetadataSpec
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return
+	}
+	spec.DisplayName = strings.TrimSpace(spec.DisplayName)
+	spec.Environment = strings.ToLower(strings.TrimSpace(spec.Environment))
+	spec.OwnerEmail = strings.TrimSpace(spec.OwnerEmail)
+	id := chi.URLParam(r, "id")
+	check := config.AccountMetadataConfig{ID: id, DisplayName: spec.DisplayName, Environment: spec.Environment, OwnerEmail: spec.OwnerEmail, Color: spec.Color}
+	if err := check.Validate(); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+
+	m, err := h.snapshots.SetAccountMetadata(id, spec)
+	if err != nil {
+		h.logger.Error("failed to save account metadata", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	// Cached results carry the old labels
+	h.results.clear()
+	h.writeJSON(w, http.StatusOK, m)
+}
+
+// DeleteAccountMetadata removes the metadata saved for an account through
+// the API. Metadata from the config file applies again afterwards.
+func (h *CostsHandler) DeleteAccountMetadata(w http.ResponseWriter, r *http.Request) {
+	err := h.snapshots.DeleteAccountMetadata(chi.URLParam(r, "id"))
+	if errors.Is(err, snapshot.ErrAccountNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to delete account metadata", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.results.clear()
+	w.WriteHeader(http.StatusNoContent)
+}
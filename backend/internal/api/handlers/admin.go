@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+)
+
+// GetPricingCache returns price cache hit and miss counts, entries per
+// service, and when cached prices expire
+func (h *CostsHandler) GetPricingCache(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.discovery.PricingProvider().CacheStats()); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// GetTelemetry returns the AWS API calls made and time spent scanning each
+// account since the server started
+func (h *CostsHandler) GetTelemetry(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.discovery.Telemetry())
+}
+
+// GetAccountStatuses checks that the credentials of every configured or
+// discovered account work, and reports when each was last scanned
+func (h *CostsHandler) GetAccountStatuses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	regions, err := h.scope.Regions(ctx, nil)
+	if err != nil {
+		h.logger.Error("failed to get regions", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	accounts, err := h.scope.Accounts(ctx, parseArrayParam(r, "account"))
+	if err != nil {
+		h.logger.Error("failed to get accounts", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.discovery.AccountStatuses(ctx, accounts, regions))
+}
+
+// RefreshPricing clears the price cache, so the next scan fetches current
+// prices, and returns the emptied cache's stats. Cached scan results are
+// cleared too, since their costs used the old prices.
+func (h *CostsHandler) RefreshPricing(w http.ResponseWriter, r *http.Request) {
+	provider := h.discovery.PricingProvider()
+	if err := provider.RefreshCache(r.Context()); err != nil {
+		h.logger.Error("failed to refresh pricing cache", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.results.clear()
+	h.logger.Info("pricing cache refreshed")
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(provider.CacheStats()); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// ExportSnapshots returns the snapshot history and saved views as a gzipped
+// tar archive that ImportSnapshots can load into another instance
+func (h *CostsHandler) ExportSnapshots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="awscogs-snapshots-%s.tar.gz"`, time.Now().UTC().Format("2006-01-02")))
+	if err := h.snapshots.Export(w); err != nil {
+		h.logger.Error("failed to write snapshot export", "error", err)
+	}
+}
+
+// ImportSnapshots adds the snapshots and views in an archive from
+// ExportSnapshots, skipping those already stored. Archives over the
+// configured size, compressed or not, are rejected with a 413.
+func (h *CostsHandler) ImportSnapshots(w http.ResponseWriter, r *http.Request) {
+	maxBytes := int64(h.config.Snapshots.MaxImportMB) << 20
+	result, err := h.snapshots.Import(http.MaxBytesReader(w, r.Body, maxBytes), maxBytes)
+	var tooLarge *http.MaxBytesError
+	if errors.Is(err, snapshot.ErrArchiveTooLarge) || errors.As(err, &tooLarge) {
+		apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+	if errors.Is(err, snapshot.ErrInvalidArchive) {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to import snapshots", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.logger.Info("imported snapshots", "snapshots", result.Snapshots, "views", result.Views, "skipped", result.Skipped)
+	// Cached results were compared against the history before the import
+	h.results.clear()
+	h.writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// parseAge accepts an age such as "90d", "1y", or a Go duration like "36h".
+// A year is 365 days.
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "y": 365 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			if count, err := strconv.Atoi(n); err == nil && count >= 0 {
+				return time.Duration(count) * unit, nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid olderThan %q: use an age like 90d, 1y, or 36h", value)
+}
+
+// olderThan returns a copy of resp with only the resources created before
+// cutoff, and its totals, summaries, and unpriced, ignored, and high-cost
+// lists and counts rebuilt. Resources that don't report a creation time are
+// dropped, since their age is unknown. Environments and billing totals are
+// left to the caller.
+func olderThan(resp *types.CostResponse, cutoff time.Time) *types.CostResponse {
+	out := *resp
+	out.EC2Instances = createdBefore(resp.EC2Instances, cutoff, func(r types.EC2Instance) string { return r.CreatedAt })
+	out.EBSVolumes = createdBefore(resp.EBSVolumes, cutoff, func(r types.EBSVolume) string { return r.CreatedAt })
+	out.ECSServices = createdBefore(resp.ECSServices, cutoff, func(r types.ECSService) string { return r.CreatedAt })
+	out.RDSInstances = createdBefore(resp.RDSInstances, cutoff, func(r types.RDSInstance) string { return r.CreatedAt })
+	out.EKSClusters = createdBefore(resp.EKSClusters, cutoff, func(r types.EKSCluster) string { return r.CreatedAt })
+	out.LoadBalancers = createdBefore(resp.LoadBalancers, cutoff, func(r types.LoadBalancer) string { return r.CreatedAt })
+	out.NATGateways = createdBefore(resp.NATGateways, cutoff, func(r types.NATGateway) string { return r.CreatedAt })
+	out.Secrets = createdBefore(resp.Secrets, cutoff, func(r types.Secret) string { return r.CreatedAt })
+	out.CapacityReservations = createdBefore(resp.CapacityReservations, cutoff, func(r types.CapacityReservation) string { return r.CreatedAt })
+	out.DedicatedHosts = createdBefore(resp.DedicatedHosts, cutoff, func(r types.DedicatedHost) string { return r.CreatedAt })
+	out.KinesisStreams = createdBefore(resp.KinesisStreams, cutoff, func(r types.KinesisStream) string { return r.CreatedAt })
+	out.FirehoseStreams = createdBefore(resp.FirehoseStreams, cutoff, func(r types.FirehoseStream) string { return r.CreatedAt })
+	out.SQSQueues = createdBefore(resp.SQSQueues, cutoff, func(r types.SQSQueue) string { return r.CreatedAt })
+	out.EventBuses = createdBefore(resp.EventBuses, cutoff, func(r types.EventBus) string { return r.CreatedAt })
+	out.PluginResources = createdBefore(resp.PluginResources, cutoff, func(r types.PluginResource) string { return r.CreatedAt })
+	out.ElasticIPs = nil
+	out.PublicIPv4s = nil
+	out.Lambdas = nil
+	out.SNSTopics = nil
+	out.Alarms = nil
+	out.Dashboards = nil
+	out.CustomMetrics = nil
+	out.DataTransfer = nil
+	out.VirtualMachines = nil
+
+	rebuildDerived(&out, resp)
+	out.IgnoredResources = createdBefore(resp.IgnoredResources, cutoff, func(r types.IgnoredResource) string { return r.CreatedAt })
+	out.IgnoredCount = len(out.IgnoredResources)
+	out.IgnoredCost = 0
+	for _, r := range out.IgnoredResources {
+		out.IgnoredCost = out.IgnoredCost.Add(r.HourlyCost)
+	}
+	return &out
+}
+
+// rebuildDerived recomputes what scan derived from resp's resources for out,
+// which holds some of them: its totals and summaries, and its unpriced list
+// and high-cost count. The rebuilt summaries have no deltas, so nothing is
+// compared.
+func rebuildDerived(out, resp *types.CostResponse) {
+	recomputeTotals(out)
+
+	kept := make(map[string]bool)
+	out.HighCostCount = 0
+	for _, r := range snapshot.Resources(out) {
+		kept[r.Key()] = true
+		if r.HighCost {
+			out.HighCostCount++
+		}
+	}
+	out.UnpricedResources = nil
+	for _, u := range resp.UnpricedResources {
+		if kept[snapshot.Resource{Type: u.ResourceType, AccountID: u.AccountID, Region: u.Region, ID: u.ResourceID}.Key()] {
+			out.UnpricedResources = append(out.UnpricedResources, u)
+		}
+	}
+	out.UnpricedCount = len(out.UnpricedResources)
+	out.ComparedTo = ""
+}
+
+func createdBefore[T any](items []T, cutoff time.Time, createdAt func(T) string) []T {
+	var out []T
+	for _, item := range items {
+		created, err := time.Parse(time.RFC3339, createdAt(item))
+		if err == nil && created.Before(cutoff) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
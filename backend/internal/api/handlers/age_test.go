@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"1y":  365 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+	}
+	for value, want := range tests {
+		if got, err := parseAge(value); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "old", "-5d", "1.5y"} {
+		if _, err := parseAge(value); err == nil {
+			t.Errorf("parseAge(%q) should fail", value)
+		}
+	}
+}
+
+func TestOlderThanKeepsOnlyOldResources(t *testing.T) {
+	resp := &types.CostResponse{
+		TotalCost: 7,
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-old", AccountID: "1", Region: "us-east-1", CreatedAt: "2024-01-01T00:00:00Z", HourlyCost: 1},
+			{InstanceID: "i-new", AccountID: "1", Region: "us-east-1", CreatedAt: "2026-06-01T00:00:00Z", HourlyCost: 2},
+		},
+		EBSVolumes: []types.EBSVolume{
+			{VolumeID: "vol-old", AccountID: "2", Region: "us-west-2", CreatedAt: "2023-05-01T00:00:00Z", HourlyCost: 0.5},
+		},
+		ElasticIPs: []types.ElasticIP{{AllocationID: "eipalloc-1", AccountID: "1", Region: "us-east-1", HourlyCost: 3.5}},
+	}
+
+	got := olderThan(resp, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	if len(got.EC2Instances) != 1 || got.EC2Instances[0].InstanceID != "i-old" {
+		t.Errorf("expected only i-old, got %+v", got.EC2Instances)
+	}
+	if len(got.EBSVolumes) != 1 || got.ElasticIPs != nil {
+		t.Errorf("expected the old volume and no Elastic IPs, got %+v and %+v", got.EBSVolumes, got.ElasticIPs)
+	}
+	if got.TotalCost != 1.5 || len(got.Accounts) != 2 || len(got.Regions) != 2 {
+		t.Errorf("totals not rebuilt: total %v, %d accounts, %d regions", got.TotalCost, len(got.Accounts), len(got.Regions))
+	}
+	if len(resp.EC2Instances) != 2 || resp.TotalCost != 7 {
+		t.Error("olderThan modified the cached response")
+	}
+}
+
+func TestOlderThanRebuildsDerivedFields(t *testing.T) {
+	resp := &types.CostResponse{
+		ComparedTo: "2025-09-30T00:00:00Z",
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-old", AccountID: "1", Region: "us-east-1", CreatedAt: "2024-01-01T00:00:00Z", HourlyCost: 9, HighCost: true},
+			{InstanceID: "i-new", AccountID: "1", Region: "us-east-1", CreatedAt: "2026-06-01T00:00:00Z", HourlyCost: 8, HighCost: true},
+		},
+		UnpricedCount: 2,
+		UnpricedResources: []types.UnpricedResource{
+			{ResourceType: "ec2", ResourceID: "i-old", AccountID: "1", Region: "us-east-1"},
+			{ResourceType: "ec2", ResourceID: "i-new", AccountID: "1", Region: "us-east-1"},
+		},
+		IgnoredCount: 2,
+		IgnoredCost:  3,
+		IgnoredResources: []types.IgnoredResource{
+			{ResourceType: "ebs", ResourceID: "vol-old", CreatedAt: "2023-05-01T00:00:00Z", HourlyCost: 1},
+			{ResourceType: "ebs", ResourceID: "vol-new", CreatedAt: "2026-05-01T00:00:00Z", HourlyCost: 2},
+		},
+		HighCostCount: 2,
+	}
+
+	got := olderThan(resp, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	if got.UnpricedCount != 1 || got.UnpricedResources[0].ResourceID != "i-old" {
+		t.Errorf("unpriced = %d %+v, want only i-old", got.UnpricedCount, got.UnpricedResources)
+	}
+	if got.IgnoredCount != 1 || got.IgnoredCost != 1 || got.IgnoredResources[0].ResourceID != "vol-old" {
+		t.Errorf("ignored = %d costing %v: %+v, want only vol-old", got.IgnoredCount, got.IgnoredCost, got.IgnoredResources)
+	}
+	if got.HighCostCount != 1 {
+		t.Errorf("high cost count = %d, want 1", got.HighCostCount)
+	}
+	if got.ComparedTo != "" {
+		t.Errorf("comparedTo = %q, want none since the summaries have no deltas", got.ComparedTo)
+	}
+	if resp.UnpricedCount != 2 || resp.IgnoredCount != 2 || resp.ComparedTo == "" {
+		t.Error("olderThan modified the cached response")
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetAutoScalingGroupCosts scans EC2 instances and totals their costs by Auto
+// Scaling group, with each group's capacity
+func (h *CostsHandler) GetAutoScalingGroupCosts(w http.ResponseWriter, r *http.Request) {
+	if !h.serviceEnabled(w, r, "ec2") {
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, "ec2")
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(r.Context(), filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	groups := aggregate.AutoScalingGroupCosts(response.EC2Instances)
+	result := &types.AutoScalingGroupCostResponse{
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Status:      response.Status,
+		Diagnostics: response.Diagnostics,
+		Currency:    "USD",
+		Groups:      groups,
+		Filters:     filters,
+	}
+	for _, g := range groups {
+		result.TotalCost = result.TotalCost.Add(g.TotalCost)
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// maxBaselineDescriptionLength caps a baseline's description
+const maxBaselineDescriptionLength = 500
+
+// BaselineComparisonResponse is the response for comparing current costs
+// against a baseline
+type BaselineComparisonResponse struct {
+	Timestamp    string                      `json:"timestamp"`
+	Status       string                      `json:"status"`
+	Diagnostics  []types.Diagnostic          `json:"diagnostics,omitempty"`
+	Baseline     snapshot.Baseline           `json:"baseline"`
+	Dimensions   []string                    `json:"dimensions"`
+	BaselineCost types.CostValue             `json:"baselineCost"`
+	TotalCost    types.CostValue             `json:"totalCost"`
+	Delta        types.CostValue             `json:"delta"`
+	DeltaPercent *float64                    `json:"deltaPercent,omitempty"`
+	Currency     string                      `json:"currency"`
+	Groups       []types.CostGroupComparison `json:"groups"`
+	Filters      types.AppliedFilters        `json:"filters"`
+}
+
+// ListBaselines returns the baselines, oldest snapshot first
+func (h *CostsHandler) ListBaselines(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.snapshots.Baselines())
+}
+
+// CreateBaseline pins a snapshot under a name. Without a snapshot ID, the
+// latest unfiltered snapshot is pinned.
+func (h *CostsHandler) CreateBaseline(w http.ResponseWriter, r *http.Request) {
+	var spec snapshot.BaselineSpec
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return
+	}
+	if err := validateBaselineSpec(&spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+
+	baseline, err := h.snapshots.CreateBaseline(spec)
+	if errors.Is(err, snapshot.ErrSnapshotNotFound) {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to save baseline", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, baseline)
+}
+
+// DeleteBaseline removes a baseline. Its snapshot is unpinned and pruned
+// like any other.
+func (h *CostsHandler) DeleteBaseline(w http.ResponseWriter, r *http.Request) {
+	err := h.snapshots.DeleteBaseline(chi.URLParam(r, "id"))
+	if errors.Is(err, snapshot.ErrBaselineNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to delete baseline", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompareBaseline scans resources and totals their costs, and those of the
+// baseline's snapshot, by the dimensions in the dims query parameter, which
+// defaults to account and service
+func (h *CostsHandler) CompareBaseline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	baseline, snap, ok := h.snapshots.Baseline(chi.URLParam(r, "id"))
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, snapshot.ErrBaselineNotFound.Error(), nil)
+		return
+	}
+	if snap == nil {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "baseline snapshot "+baseline.SnapshotID+" not found", nil)
+		return
+	}
+
+	dims := parseArrayParam(r, "dims")
+	if len(dims) == 0 {
+		dims = []string{aggregate.DimAccount, aggregate.DimService}
+	}
+	if err := aggregate.ValidateDimensions(dims); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidFilter, err.Error(), nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	before := snapshot.FilterResources(h.resources(snap.Response), filters)
+	after := h.resources(response)
+
+	var baselineCost, totalCost types.CostValue
+	for _, res := range before {
+		baselineCost = baselineCost.Add(res.HourlyCost)
+	}
+	for _, res := range after {
+		totalCost = totalCost.Add(res.HourlyCost)
+	}
+
+	result := BaselineComparisonResponse{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Status:       response.Status,
+		Diagnostics:  response.Diagnostics,
+		Baseline:     baseline,
+		Dimensions:   dims,
+		BaselineCost: baselineCost,
+		TotalCost:    totalCost,
+		Delta:        totalCost.Add(-baselineCost),
+		DeltaPercent: percentChange(totalCost, baselineCost),
+		Currency:     "USD",
+		Groups:       aggregate.CompareGroups(before, after, dims),
+		Filters:      filters,
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// percentChange returns the change from previous to current in percent,
+// rounded to one decimal, or nil if previous is 0
+func percentChange(current, previous types.CostValue) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	percent := math.Round(float64((current-previous)/previous)*1000) / 10
+	return &percent
+}
+
+// validateBaselineSpec trims a baseline's fields and checks its name is set
+func validateBaselineSpec(spec *snapshot.BaselineSpec) error {
+	spec.Name = strings.TrimSpace(spec.Name)
+	spec.Description = strings.TrimSpace(spec.Description)
+	spec.SnapshotID = strings.TrimSpace(spec.SnapshotID)
+	if spec.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(spec.Name) > maxViewNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxViewNameLength)
+	}
+	if len(spec.Description) > maxBaselineDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxBaselineDescriptionLength)
+	}
+	return nil
+}
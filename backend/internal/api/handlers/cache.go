@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// resultCache holds complete scan results keyed by filter set, so repeat
+// requests within the TTL don't merge and price every resource again
+type resultCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+type cachedResult struct {
+	response  *types.CostResponse
+	expiresAt time.Time
+}
+
+// newResultCache creates a result cache. A zero TTL disables caching.
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{ttl: ttl, entries: map[string]cachedResult{}}
+}
+
+// get returns the cached response for filters if it hasn't expired
+func (c *resultCache) get(filters types.AppliedFilters) (*types.CostResponse, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := resultCacheKey(filters)
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.response, true
+}
+
+// put caches a response for filters. Only complete results are cached so a
+// partial failure is retried on the next request.
+func (c *resultCache) put(filters types.AppliedFilters, response *types.CostResponse) {
+	if c.ttl <= 0 || response.Status != types.ResponseStatusOK {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[resultCacheKey(filters)] = cachedResult{response: response, expiresAt: now.Add(c.ttl)}
+}
+
+// clear removes every cached result
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]cachedResult{}
+}
+
+// resultCacheKey normalizes filters so the same set in any order or case
+// shares a cache entry
+func resultCacheKey(filters types.AppliedFilters) string {
+	normalize := func(values []string) string {
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			out = append(out, strings.ToLower(strings.TrimSpace(v)))
+		}
+		slices.Sort(out)
+		return strings.Join(slices.Compact(out), ",")
+	}
+	return normalize(filters.Accounts) + "|" + normalize(filters.Regions) + "|" + normalize(filters.ResourceTypes)
+}
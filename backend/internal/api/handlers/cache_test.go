@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestResultCache(t *testing.T) {
+	cache := newResultCache(time.Minute)
+	response := &types.CostResponse{Status: types.ResponseStatusOK, TotalCost: 1}
+
+	cache.put(types.AppliedFilters{Accounts: []string{"Prod", "dev"}, Regions: []string{"us-east-1"}}, response)
+	got, ok := cache.get(types.AppliedFilters{Accounts: []string{"dev", "prod"}, Regions: []string{"us-east-1"}})
+	if !ok || got != response {
+		t.Fatal("expected filters in a different order and case to hit the cache")
+	}
+	if _, ok := cache.get(types.AppliedFilters{Accounts: []string{"dev"}}); ok {
+		t.Fatal("expected different filters to miss the cache")
+	}
+
+	cache.put(types.AppliedFilters{}, &types.CostResponse{Status: types.ResponseStatusPartial})
+	if _, ok := cache.get(types.AppliedFilters{}); ok {
+		t.Fatal("expected partial results not to be cached")
+	}
+
+	cache.clear()
+	if _, ok := cache.get(types.AppliedFilters{Accounts: []string{"prod", "dev"}, Regions: []string{"us-east-1"}}); ok {
+		t.Fatal("expected clear to remove cached results")
+	}
+}
+
+func TestResultCacheDisabled(t *testing.T) {
+	cache := newResultCache(0)
+	cache.put(types.AppliedFilters{}, &types.CostResponse{Status: types.ResponseStatusOK})
+	if _, ok := cache.get(types.AppliedFilters{}); ok {
+		t.Fatal("expected a zero TTL to disable caching")
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/calculator"
+)
+
+// Calculate prices the hypothetical resources in the request body
+func (h *CostsHandler) Calculate(w http.ResponseWriter, r *http.Request) {
+	var req calculator.Request
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error(), map[string][]string{"types": calculator.ItemTypes})
+		return
+	}
+
+	result := calculator.Price(r.Context(), h.discovery.PricingProvider(), req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
 	"github.com/johnjeffers/awscogs/backend/internal/aws"
 	"github.com/johnjeffers/awscogs/backend/internal/config"
 	"github.com/johnjeffers/awscogs/backend/internal/version"
@@ -35,9 +36,13 @@ type VersionInfo struct {
 
 // ConfigResponse is the response for configuration
 type ConfigResponse struct {
-	Accounts []AccountInfo `json:"accounts"`
-	Regions  []string      `json:"regions"`
-	Version  VersionInfo   `json:"version"`
+	Accounts  []AccountInfo `json:"accounts"`
+	Regions   []string      `json:"regions"`
+	Services  []string      `json:"services"`
+	Profile   string        `json:"profile,omitempty"`   // Selected profile (empty = default)
+	Profiles  []string      `json:"profiles,omitempty"`  // Named profiles selectable with ?profile=
+	QuickScan bool          `json:"quickScan,omitempty"` // Only the current account is scanned
+	Version   VersionInfo   `json:"version"`
 }
 
 // AccountInfo provides account information
@@ -57,7 +62,7 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		discovered, err := h.discovery.DiscoverRegions(ctx)
 		if err != nil {
 			h.logger.Error("failed to discover regions", "error", err)
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Internal(w, r, err)
 			return
 		}
 		regions = append(regions, discovered...)
@@ -102,7 +107,7 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 		discoveredAccounts, err := h.discovery.DiscoverAccounts(ctx, h.config.AWS.AssumeRoleName)
 		if err != nil {
 			h.logger.Error("failed to discover accounts", "error", err)
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apierror.Internal(w, r, err)
 			return
 		}
 		for _, acc := range discoveredAccounts {
@@ -123,7 +128,7 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 			discoveredAccounts, err := h.discovery.DiscoverGovCloudAccounts(ctx, h.config.AWS.GovCloud.AssumeRoleName)
 			if err != nil {
 				h.logger.Error("failed to discover govcloud accounts", "error", err)
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				apierror.Internal(w, r, err)
 				return
 			}
 			for _, acc := range discoveredAccounts {
@@ -140,8 +145,12 @@ func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := ConfigResponse{
-		Accounts: accounts,
-		Regions:  regions,
+		Accounts:  accounts,
+		Regions:   regions,
+		Services:  h.config.AWS.EnabledServices(),
+		Profile:   h.config.Profile,
+		Profiles:  h.config.ProfileNames(),
+		QuickScan: h.config.AWS.QuickScan,
 		Version: VersionInfo{
 			Version:   version.Version,
 			GitCommit: version.GitCommit,
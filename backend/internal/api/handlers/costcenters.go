@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetCostCenterCosts totals costs by the cost center the costCenters rules
+// assign each resource to. Resources no rule matches are unallocated.
+func (h *CostsHandler) GetCostCenterCosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	result := &types.CostCenterResponse{
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Status:      response.Status,
+		Diagnostics: response.Diagnostics,
+		TotalCost:   response.TotalCost,
+		Currency:    "USD",
+		CostCenters: aggregate.CostCenterCosts(snapshot.Resources(response)),
+		Filters:     filters,
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
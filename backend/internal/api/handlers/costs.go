@@ -3,13 +3,27 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/graphql-go/graphql"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
 	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
 	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/costcenter"
+	"github.com/johnjeffers/awscogs/backend/internal/debug"
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/threshold"
 	"github.com/johnjeffers/awscogs/backend/internal/types"
 )
 
@@ -17,16 +31,130 @@ import (
 type CostsHandler struct {
 	config    *config.Config
 	discovery *aws.Discovery
+	scope     *aws.ScopeResolver
+	clouds    *cloud.Registry
+	snapshots *snapshot.Store
 	logger    *slog.Logger
+	results   *resultCache
+	jobs      *scanJobs
+	inFlight  inFlight
+
+	graphQLSchema func() (graphql.Schema, error)
 }
 
 // NewCostsHandler creates a new costs handler
-func NewCostsHandler(cfg *config.Config, discovery *aws.Discovery, logger *slog.Logger) *CostsHandler {
-	return &CostsHandler{
+func NewCostsHandler(cfg *config.Config, discovery *aws.Discovery, clouds *cloud.Registry, snapshots *snapshot.Store, logger *slog.Logger) *CostsHandler {
+	h := &CostsHandler{
 		config:    cfg,
 		discovery: discovery,
+		scope:     aws.NewScopeResolver(cfg, discovery, logger),
+		clouds:    clouds,
+		snapshots: snapshots,
 		logger:    logger,
 	}
+	h.results = newResultCache(time.Duration(cfg.Cache.ResultTTLMinutes) * time.Minute)
+	h.jobs = newScanJobs(cfg.Server.ScanJobs, h.scan)
+	h.graphQLSchema = sync.OnceValues(h.newGraphQLSchema)
+	return h
+}
+
+// recordSnapshot stores a completed scan in the snapshot history. Failed scans
+// are skipped so they don't show up as every resource being removed.
+func (h *CostsHandler) recordSnapshot(ts time.Time, response *types.CostResponse) {
+	if h.snapshots == nil || response.Status == types.ResponseStatusFailed {
+		return
+	}
+	if _, err := h.snapshots.Add(ts, response); err != nil {
+		h.logger.Error("failed to record snapshot", "error", err)
+	}
+}
+
+// addDeltas compares a new scan's account and region totals with the latest
+// recorded snapshot that covered the same filters
+func (h *CostsHandler) addDeltas(response *types.CostResponse, filters types.AppliedFilters) {
+	if h.snapshots == nil {
+		return
+	}
+	previous := h.snapshots.Latest(filters)
+	if previous == nil {
+		return
+	}
+	resources := snapshot.FilterResources(snapshot.Resources(previous.Response), filters)
+	aggregate.AddDeltas(response.Accounts, response.Regions, resources)
+	response.ComparedTo = previous.Timestamp.Format(time.RFC3339)
+}
+
+// scan discovers resources across all registered cloud providers and records
+// the result as a snapshot. Repeat requests for the same filters are served
+// from the result cache, and were recorded when they were first scanned.
+func (h *CostsHandler) scan(ctx context.Context, filters types.AppliedFilters) (*types.CostResponse, error) {
+	if response, ok := h.results.get(filters); ok {
+		h.logger.Debug("result cache hit", "filters", filters)
+		return response, nil
+	}
+	// A scan cancelled before it starts would record an empty snapshot
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	h.inFlight.add()
+	defer h.inFlight.done()
+
+	debug.ScansTotal.Add(1)
+	debug.ScansInFlight.Add(1)
+	response, err := h.clouds.Discover(ctx, cloud.Scope{
+		Accounts:      filters.Accounts,
+		Regions:       filters.Regions,
+		ResourceTypes: filters.ResourceTypes,
+	})
+	debug.ScansInFlight.Add(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	response = applyMissingPrices(response, h.config.Pricing.MissingPrice)
+	response = h.applyIgnores(response)
+	costcenter.Annotate(response, h.config.CostCenters.Rules)
+	threshold.Annotate(response, h.config.CostThresholds)
+	if h.config.AWS.StoppedInstanceStorage {
+		aggregate.AttributeStoppedStorage(response)
+	}
+	wholeBill := len(filters.Accounts) == 0 && len(filters.Regions) == 0 && len(filters.ResourceTypes) == 0
+	response.Billing = aggregate.Billing(response.TotalCost, h.config.Billing, wholeBill)
+	if wholeBill && h.config.AWS.ProbeExcludedRegions {
+		response.Diagnostics = append(response.Diagnostics, h.excludedRegionDiagnostics(ctx)...)
+	}
+	now := time.Now().UTC()
+	response.Timestamp = now.Format(time.RFC3339)
+	response.Filters = filters
+	if response.Status == "" {
+		response.Status = types.ResponseStatusOK
+	}
+	h.addDeltas(response, filters)
+	h.annotateAccounts(response)
+	h.annotateEnvironments(response)
+	aggregate.Sort(response, aggregate.DefaultOrder)
+	h.recordSnapshot(now, response)
+	h.results.put(filters, response)
+	return response, nil
+}
+
+// ScanAll runs a full scan with no filters, as the dashboard does on its first
+// load, so the result is cached and recorded before anyone asks for it
+func (h *CostsHandler) ScanAll(ctx context.Context) error {
+	_, err := h.scan(ctx, types.AppliedFilters{})
+	return err
+}
+
+// PricesResolved rescans the resources priced with lookups that failed and
+// have since been fetched by the pricing retrier, so the cached result and
+// the latest snapshot include their prices. Only the affected resource types
+// and regions are discovered again.
+func (h *CostsHandler) PricesResolved(ctx context.Context, keys []pricing.PriceKey) error {
+	if h.discovery.InvalidatePrices(keys) == 0 {
+		return nil
+	}
+	h.results.clear()
+	return h.ScanAll(ctx)
 }
 
 func copyResponseHealth(dst, src *types.CostResponse) {
@@ -39,9 +167,10 @@ func copyResponseHealth(dst, src *types.CostResponse) {
 
 // ClearCache clears cached discovery and pricing data.
 func (h *CostsHandler) ClearCache(w http.ResponseWriter, r *http.Request) {
+	h.results.clear()
 	if err := h.discovery.ClearCaches(r.Context()); err != nil {
 		h.logger.Error("failed to clear caches", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Internal(w, r, err)
 		return
 	}
 
@@ -51,55 +180,70 @@ func (h *CostsHandler) ClearCache(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetScanStatus returns when each account, region, and resource type was last
+// scanned and when its cached result expires
+func (h *CostsHandler) GetScanStatus(w http.ResponseWriter, r *http.Request) {
+	response := types.ScanStatusResponse{
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		TTLMinutes: h.config.Cache.ResourceTTLMinutes,
+		Cells:      h.discovery.ScanCells(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
 // GetCosts returns all cost data
 func (h *CostsHandler) GetCosts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	order, ok := sortOrder(w, r)
+	if !ok {
+		return
+	}
 	started := time.Now()
 
-	// Parse filters from query params
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-	resourceFilter := parseArrayParam(r, "resource")
 	requestID := r.URL.Query().Get("_rid")
 
-	h.logger.Info("cost request started",
-		"requestId", requestID,
-		"accounts", accountFilter,
-		"regions", regionFilter,
-		"resources", resourceFilter)
-
-	// Get regions (discover or use config)
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	// Parse filters from query params
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
 		return
 	}
 
-	// Get accounts (discover or use config)
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+	minAge := r.URL.Query().Get("olderThan")
+	var age time.Duration
+	if minAge != "" {
+		var err error
+		if age, err = parseAge(minAge); err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error(), nil)
+			return
+		}
 	}
 
-	// Discover resources
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, resourceFilter)
+	h.logger.Info("cost request started",
+		"requestId", requestID,
+		"accounts", filters.Accounts,
+		"regions", filters.Regions,
+		"resources", filters.ResourceTypes)
+
+	response, err := h.scan(ctx, filters)
 	if err != nil {
 		h.logger.Error("failed to discover resources", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Internal(w, r, err)
 		return
 	}
-
-	response.Timestamp = time.Now().UTC().Format(time.RFC3339)
-	response.Filters = types.AppliedFilters{
-		Accounts:      accountFilter,
-		Regions:       regionFilter,
-		ResourceTypes: resourceFilter,
+	if minAge != "" {
+		response = olderThan(response, time.Now().Add(-age))
+		response.Filters.OlderThan = minAge
+		response.Billing = aggregate.Billing(response.TotalCost, h.config.Billing, false)
+		h.annotateAccounts(response)
+		h.annotateEnvironments(response)
 	}
-	if response.Status == "" {
-		response.Status = types.ResponseStatusOK
+
+	if order != aggregate.DefaultOrder {
+		response = aggregate.Sorted(response, order)
 	}
 
 	h.logger.Info("cost request completed",
@@ -117,28 +261,20 @@ func (h *CostsHandler) GetCosts(w http.ResponseWriter, r *http.Request) {
 // GetAccountCosts returns account-level cost summaries
 func (h *CostsHandler) GetAccountCosts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	order, ok := sortOrder(w, r)
+	if !ok {
 		return
 	}
 
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	filters, ok := h.parseFilters(w, r)
+	if !ok {
 		return
 	}
 
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, nil)
+	response, err := h.scan(ctx, filters)
 	if err != nil {
 		h.logger.Error("failed to discover resources", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Internal(w, r, err)
 		return
 	}
 
@@ -146,15 +282,14 @@ func (h *CostsHandler) GetAccountCosts(w http.ResponseWriter, r *http.Request) {
 	result := &types.CostResponse{
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		TotalCost: response.TotalCost,
+		Billing:   response.Billing,
 		Currency:  "USD",
 		Accounts:  response.Accounts,
-		Filters: types.AppliedFilters{
-			Accounts: accountFilter,
-			Regions:  regionFilter,
-		},
+		Filters:   filters,
 	}
 
 	copyResponseHealth(result, response)
+	result = aggregate.Sorted(result, order)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -165,28 +300,20 @@ func (h *CostsHandler) GetAccountCosts(w http.ResponseWriter, r *http.Request) {
 // GetRegionCosts returns region-level cost summaries
 func (h *CostsHandler) GetRegionCosts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	order, ok := sortOrder(w, r)
+	if !ok {
 		return
 	}
 
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	filters, ok := h.parseFilters(w, r)
+	if !ok {
 		return
 	}
 
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, nil)
+	response, err := h.scan(ctx, filters)
 	if err != nil {
 		h.logger.Error("failed to discover resources", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		apierror.Internal(w, r, err)
 		return
 	}
 
@@ -194,15 +321,14 @@ func (h *CostsHandler) GetRegionCosts(w http.ResponseWriter, r *http.Request) {
 	result := &types.CostResponse{
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		TotalCost: response.TotalCost,
+		Billing:   response.Billing,
 		Currency:  "USD",
 		Regions:   response.Regions,
-		Filters: types.AppliedFilters{
-			Accounts: accountFilter,
-			Regions:  regionFilter,
-		},
+		Filters:   filters,
 	}
 
 	copyResponseHealth(result, response)
+	result = aggregate.Sorted(result, order)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -210,283 +336,109 @@ func (h *CostsHandler) GetRegionCosts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// GetEC2Costs returns EC2 instance costs
-func (h *CostsHandler) GetEC2Costs(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+// narrowFunc adjusts an endpoint's copy of the scanned resources, e.g. to keep
+// only some of them. It must replace a slice rather than modify it, since the
+// scan result is cached.
+type narrowFunc func(ctx context.Context, filters types.AppliedFilters, resp *types.CostResponse) error
 
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
+// serveResourceTypes writes the costs of the enabled resourceTypes. They come
+// from the shared scan, so the missing-price policy, ignore rules, cost
+// centers, and thresholds apply as they do for GetCosts. A disabled type is
+// left out, and a 404 is written if all of them are. If narrow is set, the
+// totals and unpriced and high-cost lists are rebuilt after it runs.
+func (h *CostsHandler) serveResourceTypes(w http.ResponseWriter, r *http.Request, resourceTypes []string, narrow narrowFunc) {
+	enabled := slices.DeleteFunc(slices.Clone(resourceTypes), func(rt string) bool {
+		return !slices.Contains(h.config.AWS.EnabledServices(), rt)
+	})
+	if len(enabled) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeResourceTypeDisabled, disabledMessage(resourceTypes), nil)
+		return
+	}
 
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	ctx := r.Context()
+	order, ok := sortOrder(w, r)
+	if !ok {
 		return
 	}
 
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	filters, ok := h.parseFilters(w, r, enabled...)
+	if !ok {
 		return
 	}
 
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"ec2"})
+	response, err := h.scan(ctx, filters)
 	if err != nil {
-		h.logger.Error("failed to discover EC2 instances", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		h.logger.Error("failed to discover resources", "error", err, "resources", enabled)
+		apierror.Internal(w, r, err)
 		return
 	}
 
-	// Calculate EC2-only total cost
-	var ec2Total types.CostValue
-	for _, inst := range response.EC2Instances {
-		ec2Total += inst.HourlyCost
+	result := *response
+	if narrow != nil {
+		if err := narrow(ctx, filters, &result); err != nil {
+			h.logger.Error("failed to narrow resources", "error", err, "resources", enabled)
+			apierror.Internal(w, r, err)
+			return
+		}
+		rebuildDerived(&result, response)
+		result.Billing = aggregate.Billing(result.TotalCost, h.config.Billing, false)
 	}
 
-	result := &types.CostResponse{
-		Timestamp:    time.Now().UTC().Format(time.RFC3339),
-		TotalCost:    ec2Total,
-		Currency:     "USD",
-		EC2Instances: response.EC2Instances,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"ec2"},
-		},
-	}
+	// Return only the resources, as the summaries are served by their own
+	// endpoints
+	result.Accounts = nil
+	result.Regions = nil
+	result.Environments = nil
+	result.ComparedTo = ""
 
-	copyResponseHealth(result, response)
+	h.writeJSON(w, http.StatusOK, aggregate.Sorted(&result, order))
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+// GetEC2Costs returns EC2 instance costs
+func (h *CostsHandler) GetEC2Costs(w http.ResponseWriter, r *http.Request) {
+	h.serveResourceTypes(w, r, []string{"ec2"}, nil)
 }
 
 // GetEBSCosts returns EBS volume costs
 func (h *CostsHandler) GetEBSCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"ebs"})
-	if err != nil {
-		h.logger.Error("failed to discover EBS volumes", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate EBS-only total cost
-	var ebsTotal types.CostValue
-	for _, vol := range response.EBSVolumes {
-		ebsTotal += vol.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp:  time.Now().UTC().Format(time.RFC3339),
-		TotalCost:  ebsTotal,
-		Currency:   "USD",
-		EBSVolumes: response.EBSVolumes,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"ebs"},
-		},
+	var narrow narrowFunc
+	if r.URL.Query().Get("unattached") == "true" {
+		narrow = func(_ context.Context, _ types.AppliedFilters, resp *types.CostResponse) error {
+			resp.EBSVolumes = unattachedVolumes(resp.EBSVolumes)
+			return nil
+		}
 	}
+	h.serveResourceTypes(w, r, []string{"ebs"}, narrow)
+}
 
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
+// unattachedVolumes returns the volumes not attached to any instance
+func unattachedVolumes(volumes []types.EBSVolume) []types.EBSVolume {
+	out := []types.EBSVolume{}
+	for _, vol := range volumes {
+		if len(vol.AttachedInstanceIDs) == 0 {
+			out = append(out, vol)
+		}
 	}
+	return out
 }
 
 // GetRDSCosts returns RDS instance costs
 func (h *CostsHandler) GetRDSCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"rds"})
-	if err != nil {
-		h.logger.Error("failed to discover RDS instances", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate RDS-only total cost
-	var rdsTotal types.CostValue
-	for _, inst := range response.RDSInstances {
-		rdsTotal += inst.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp:    time.Now().UTC().Format(time.RFC3339),
-		TotalCost:    rdsTotal,
-		Currency:     "USD",
-		RDSInstances: response.RDSInstances,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"rds"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+	h.serveResourceTypes(w, r, []string{"rds"}, nil)
 }
 
 // GetECSCosts returns ECS service costs
 func (h *CostsHandler) GetECSCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"ecs"})
-	if err != nil {
-		h.logger.Error("failed to discover ECS services", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate ECS-only total cost
-	var ecsTotal types.CostValue
-	for _, svc := range response.ECSServices {
-		ecsTotal += svc.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp:   time.Now().UTC().Format(time.RFC3339),
-		TotalCost:   ecsTotal,
-		Currency:    "USD",
-		ECSServices: response.ECSServices,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"ecs"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+	h.serveResourceTypes(w, r, []string{"ecs"}, nil)
 }
 
 // GetEKSCosts returns EKS cluster costs
 func (h *CostsHandler) GetEKSCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"eks"})
-	if err != nil {
-		h.logger.Error("failed to discover EKS clusters", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate EKS-only total cost
-	var eksTotal types.CostValue
-	for _, cluster := range response.EKSClusters {
-		eksTotal += cluster.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp:   time.Now().UTC().Format(time.RFC3339),
-		TotalCost:   eksTotal,
-		Currency:    "USD",
-		EKSClusters: response.EKSClusters,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"eks"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+	h.serveResourceTypes(w, r, []string{"eks"}, nil)
 }
 
 // GetELBCosts returns Elastic Load Balancer costs
 func (h *CostsHandler) GetELBCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
 	// Parse usage query params
 	includeUsage := r.URL.Query().Get("includeUsage") == "true"
 	usageWindow := r.URL.Query().Get("usageWindow")
@@ -494,458 +446,117 @@ func (h *CostsHandler) GetELBCosts(w http.ResponseWriter, r *http.Request) {
 		usageWindow = "1h"
 	}
 	if includeUsage && usageWindow != "1h" && usageWindow != "24h" && usageWindow != "30d" {
-		http.Error(w, "invalid usageWindow: must be 1h, 24h, or 30d", http.StatusBadRequest)
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "invalid usageWindow: must be 1h, 24h, or 30d", nil)
 		return
 	}
 
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"elb"})
-	if err != nil {
-		h.logger.Error("failed to discover load balancers", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Enrich with CloudWatch usage if requested
-	if includeUsage && len(response.LoadBalancers) > 0 {
-		h.discovery.EnrichELBUsage(ctx, response.LoadBalancers, usageWindow, accounts)
-	}
-
-	// Calculate ELB-only total cost
-	var elbTotal types.CostValue
-	for _, lb := range response.LoadBalancers {
-		elbTotal += lb.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp:     time.Now().UTC().Format(time.RFC3339),
-		TotalCost:     elbTotal,
-		Currency:      "USD",
-		LoadBalancers: response.LoadBalancers,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"elb"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
+	var narrow narrowFunc
+	if includeUsage {
+		// Enrich with CloudWatch usage, which adds LCU costs
+		narrow = func(ctx context.Context, filters types.AppliedFilters, resp *types.CostResponse) error {
+			if len(resp.LoadBalancers) == 0 {
+				return nil
+			}
+			accounts, err := h.scope.Accounts(ctx, filters.Accounts)
+			if err != nil {
+				return err
+			}
+			resp.LoadBalancers = slices.Clone(resp.LoadBalancers)
+			h.discovery.EnrichELBUsage(ctx, resp.LoadBalancers, usageWindow, accounts)
+			return nil
+		}
 	}
+	h.serveResourceTypes(w, r, []string{"elb"}, narrow)
 }
 
 // GetNATGatewayCosts returns NAT Gateway costs
 func (h *CostsHandler) GetNATGatewayCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"nat"})
-	if err != nil {
-		h.logger.Error("failed to discover NAT gateways", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate NAT Gateway-only total cost
-	var natTotal types.CostValue
-	for _, nat := range response.NATGateways {
-		natTotal += nat.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp:   time.Now().UTC().Format(time.RFC3339),
-		TotalCost:   natTotal,
-		Currency:    "USD",
-		NATGateways: response.NATGateways,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"nat"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+	h.serveResourceTypes(w, r, []string{"nat"}, nil)
 }
 
 // GetElasticIPCosts returns Elastic IP costs
 func (h *CostsHandler) GetElasticIPCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"eip"})
-	if err != nil {
-		h.logger.Error("failed to discover elastic IPs", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate EIP-only total cost
-	var eipTotal types.CostValue
-	for _, eip := range response.ElasticIPs {
-		eipTotal += eip.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp:  time.Now().UTC().Format(time.RFC3339),
-		TotalCost:  eipTotal,
-		Currency:   "USD",
-		ElasticIPs: response.ElasticIPs,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"eip"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+	h.serveResourceTypes(w, r, []string{"eip"}, nil)
 }
 
 // GetSecretsCosts returns Secrets Manager costs
 func (h *CostsHandler) GetSecretsCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"secrets"})
-	if err != nil {
-		h.logger.Error("failed to discover secrets", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate Secrets-only total cost
-	var secretsTotal types.CostValue
-	for _, secret := range response.Secrets {
-		secretsTotal += secret.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		TotalCost: secretsTotal,
-		Currency:  "USD",
-		Secrets:   response.Secrets,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"secrets"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+	h.serveResourceTypes(w, r, []string{"secrets"}, nil)
 }
 
 // GetPublicIPv4Costs returns Public IPv4 address costs
 func (h *CostsHandler) GetPublicIPv4Costs(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"publicipv4"})
-	if err != nil {
-		h.logger.Error("failed to discover public IPv4 addresses", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Calculate Public IPv4-only total cost
-	var publicIPv4Total types.CostValue
-	for _, pip := range response.PublicIPv4s {
-		publicIPv4Total += pip.HourlyCost
-	}
+	h.serveResourceTypes(w, r, []string{"publicipv4"}, nil)
+}
 
-	result := &types.CostResponse{
-		Timestamp:   time.Now().UTC().Format(time.RFC3339),
-		TotalCost:   publicIPv4Total,
-		Currency:    "USD",
-		PublicIPv4s: response.PublicIPv4s,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"publicipv4"},
-		},
-	}
+// GetKinesisCosts returns Kinesis data stream and Firehose delivery stream
+// costs. Either type can be disabled on its own.
+func (h *CostsHandler) GetKinesisCosts(w http.ResponseWriter, r *http.Request) {
+	h.serveResourceTypes(w, r, []string{"kinesis", "firehose"}, nil)
+}
 
-	copyResponseHealth(result, response)
+// GetMessagingCosts returns SQS queue, SNS topic, and EventBridge bus costs.
+// Each type can be disabled on its own.
+func (h *CostsHandler) GetMessagingCosts(w http.ResponseWriter, r *http.Request) {
+	h.serveResourceTypes(w, r, []string{"sqs", "sns", "eventbridge"}, nil)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+// GetCloudWatchCosts returns CloudWatch alarm, dashboard, and custom metric
+// costs. Each type can be disabled on its own.
+func (h *CostsHandler) GetCloudWatchCosts(w http.ResponseWriter, r *http.Request) {
+	h.serveResourceTypes(w, r, []string{"alarm", "dashboard", "custommetrics"}, nil)
 }
 
 // GetLambdaCosts returns Lambda function costs
 func (h *CostsHandler) GetLambdaCosts(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	accountFilter := parseArrayParam(r, "account")
-	regionFilter := parseArrayParam(r, "region")
-
-	regions, err := h.getRegions(ctx, regionFilter)
-	if err != nil {
-		h.logger.Error("failed to get regions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	accounts, err := h.getAccounts(ctx, accountFilter)
-	if err != nil {
-		h.logger.Error("failed to get accounts", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response, err := h.discovery.DiscoverResources(ctx, accounts, regions, []string{"lambda"})
-	if err != nil {
-		h.logger.Error("failed to discover Lambda functions", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	var lambdaTotal types.CostValue
-	for _, fn := range response.Lambdas {
-		lambdaTotal += fn.HourlyCost
-	}
-
-	result := &types.CostResponse{
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		TotalCost: lambdaTotal,
-		Currency:  "USD",
-		Lambdas:   response.Lambdas,
-		Filters: types.AppliedFilters{
-			Accounts:      accountFilter,
-			Regions:       regionFilter,
-			ResourceTypes: []string{"lambda"},
-		},
-	}
-
-	copyResponseHealth(result, response)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.logger.Error("failed to encode response", "error", err)
-	}
+	h.serveResourceTypes(w, r, []string{"lambda"}, nil)
 }
 
-// getRegions returns regions to query - either from filter, discovery, or config
-func (h *CostsHandler) getRegions(ctx context.Context, filter []string) ([]string, error) {
-	// If filter specified, use that
-	if len(filter) > 0 {
-		return filter, nil
-	}
-
-	var regions []string
-
-	// Commercial regions
-	if h.config.AWS.DiscoverRegions {
-		discovered, err := h.discovery.DiscoverRegions(ctx)
-		if err != nil {
-			return nil, err
-		}
-		regions = append(regions, discovered...)
-	} else if len(h.config.AWS.Regions) > 0 {
-		regions = append(regions, h.config.AWS.Regions...)
-	}
-
-	// GovCloud regions
-	if h.config.AWS.GovCloud.Enabled {
-		govRegions, err := h.getGovCloudRegions(ctx)
-		if err != nil {
-			if len(regions) == 0 {
-				return nil, err
-			}
-			// Log but don't fail if commercial regions can still work.
-			h.logger.Error("failed to get govcloud regions", "error", err)
-		} else {
-			regions = append(regions, govRegions...)
-		}
-	}
-
-	if len(regions) == 0 {
-		if h.config.AWS.GovCloud.Enabled {
-			return []string{"us-gov-west-1"}, nil
-		}
-		return []string{"us-east-1"}, nil
-	}
-
-	return regions, nil
+// GetCapacityReservationCosts returns On-Demand Capacity Reservation costs
+func (h *CostsHandler) GetCapacityReservationCosts(w http.ResponseWriter, r *http.Request) {
+	h.serveResourceTypes(w, r, []string{"capacityreservation"}, nil)
 }
 
-// getGovCloudRegions returns GovCloud regions from config or discovery
-func (h *CostsHandler) getGovCloudRegions(ctx context.Context) ([]string, error) {
-	if h.config.AWS.GovCloud.DiscoverRegions {
-		account := aws.Account{Partition: "aws-us-gov"}
-		if len(h.config.AWS.GovCloud.Accounts) > 0 {
-			// Use first configured GovCloud account's credentials to discover regions.
-			account.Name = h.config.AWS.GovCloud.Accounts[0].Name
-			account.RoleARN = h.config.AWS.GovCloud.Accounts[0].RoleARN
-		}
-		regions, err := h.discovery.DiscoverGovCloudRegions(ctx, account)
-		if err == nil || len(h.config.AWS.GovCloud.Regions) == 0 {
-			return regions, err
-		}
-		h.logger.Warn("falling back to configured govcloud regions after discovery failed", "error", err)
-	}
+// GetDedicatedHostCosts returns Dedicated Host costs
+func (h *CostsHandler) GetDedicatedHostCosts(w http.ResponseWriter, r *http.Request) {
+	h.serveResourceTypes(w, r, []string{"dedicatedhost"}, nil)
+}
 
-	if len(h.config.AWS.GovCloud.Regions) > 0 {
-		return h.config.AWS.GovCloud.Regions, nil
+// serviceEnabled writes a 404 and returns false if resourceType is disabled
+// in the AWS services config
+func (h *CostsHandler) serviceEnabled(w http.ResponseWriter, r *http.Request, resourceType string) bool {
+	if slices.Contains(h.config.AWS.EnabledServices(), resourceType) {
+		return true
 	}
-
-	return []string{"us-gov-west-1"}, nil
+	apierror.Write(w, r, http.StatusNotFound, apierror.CodeResourceTypeDisabled, disabledMessage([]string{resourceType}), nil)
+	return false
 }
 
-// getAccounts returns accounts to query - either from filter, discovery, or config
-func (h *CostsHandler) getAccounts(ctx context.Context, filter []string) ([]aws.Account, error) {
-	var accounts []aws.Account
-
-	// Commercial accounts
-	if h.config.AWS.DiscoverAccounts {
-		discovered, err := h.discovery.DiscoverAccounts(ctx, h.config.AWS.AssumeRoleName)
-		if err != nil {
-			return nil, err
-		}
-		accounts = append(accounts, discovered...)
-	} else if len(h.config.AWS.Accounts) > 0 {
-		for _, acc := range h.config.AWS.Accounts {
-			accounts = append(accounts, aws.Account{
-				Name:    acc.Name,
-				RoleARN: acc.RoleARN,
-			})
-		}
+// disabledMessage says that every one of resourceTypes is disabled
+func disabledMessage(resourceTypes []string) string {
+	quoted := make([]string, len(resourceTypes))
+	for i, rt := range resourceTypes {
+		quoted[i] = strconv.Quote(rt)
+	}
+	switch len(quoted) {
+	case 1:
+		return fmt.Sprintf("resource type %s is disabled", quoted[0])
+	case 2:
+		return fmt.Sprintf("resource types %s and %s are disabled", quoted[0], quoted[1])
+	default:
+		last := len(quoted) - 1
+		return fmt.Sprintf("resource types %s, and %s are disabled", strings.Join(quoted[:last], ", "), quoted[last])
 	}
+}
 
-	// GovCloud accounts
-	if h.config.AWS.GovCloud.Enabled {
-		if h.config.AWS.GovCloud.DiscoverAccounts {
-			discovered, err := h.discovery.DiscoverGovCloudAccounts(ctx, h.config.AWS.GovCloud.AssumeRoleName)
-			if err != nil {
-				return nil, err
-			}
-			accounts = append(accounts, discovered...)
-		} else if len(h.config.AWS.GovCloud.Accounts) > 0 {
-			for _, acc := range h.config.AWS.GovCloud.Accounts {
-				accounts = append(accounts, aws.Account{
-					Name:      acc.Name,
-					RoleARN:   acc.RoleARN,
-					Partition: "aws-us-gov",
-				})
-			}
-		} else {
-			accounts = append(accounts, aws.Account{
-				Partition: "aws-us-gov",
-			})
-		}
-	}
-
-	// If filter specified, filter all accounts
-	if len(filter) > 0 {
-		filterSet := make(map[string]bool)
-		for _, name := range filter {
-			filterSet[name] = true
-		}
-
-		var filtered []aws.Account
-		for _, acc := range accounts {
-			if filterSet[acc.Name] || filterSet[acc.ID] {
-				filtered = append(filtered, acc)
-			}
-		}
-		return filtered, nil
+// sortOrder parses the sort query parameter, writing an error if it's invalid
+func sortOrder(w http.ResponseWriter, r *http.Request) (aggregate.Order, bool) {
+	order, err := aggregate.ParseOrder(r.URL.Query().Get("sort"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error(), nil)
+		return aggregate.Order{}, false
 	}
-
-	return accounts, nil
+	return order, true
 }
 
 // parseArrayParam parses a comma-separated query parameter into a slice
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+)
+
+// maxVersionWait caps how long a version request can long-poll, well below
+// the server's write timeout
+const maxVersionWait = 2 * time.Minute
+
+// GetCostVersion returns the snapshot store's version, which increases every
+// time a scan is recorded. With `since` set to a version the client already
+// has, or an If-Modified-Since header, it answers 304 Not Modified when
+// nothing is newer. With `wait` it long-polls, holding the request open for
+// up to that long until something is.
+func (h *CostsHandler) GetCostVersion(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	since := int64(-1)
+	if value := query.Get("since"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, fmt.Sprintf("invalid since %q: use a version number", value), nil)
+			return
+		}
+		since = n
+	}
+	var wait time.Duration
+	if value := query.Get("wait"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 || d > maxVersionWait {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, fmt.Sprintf("invalid wait %q: use a duration of at most %s", value, maxVersionWait), nil)
+			return
+		}
+		wait = d
+	}
+	var modifiedSince time.Time
+	if value := r.Header.Get("If-Modified-Since"); value != "" {
+		if ts, err := http.ParseTime(value); err == nil {
+			modifiedSince = ts
+		}
+	}
+
+	// Without a store the version stays 0, so there's nothing to wait for
+	var v snapshot.Version
+	if h.snapshots != nil {
+		v = h.snapshots.Version()
+	}
+	if h.snapshots != nil && wait > 0 && !newerVersion(v, since, modifiedSince) {
+		ctx, cancel := context.WithTimeout(r.Context(), wait)
+		v = h.snapshots.WaitNewer(ctx, max(since, v.Version))
+		cancel()
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	if !v.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", v.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if !newerVersion(v, since, modifiedSince) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, v)
+}
+
+// newerVersion reports whether v is newer than what the client has: a
+// version above since, if set, and changed after modifiedSince, if set.
+// Without either, every version is new.
+func newerVersion(v snapshot.Version, since int64, modifiedSince time.Time) bool {
+	if since >= 0 && v.Version <= since {
+		return false
+	}
+	// HTTP dates have whole seconds
+	if !modifiedSince.IsZero() && !v.UpdatedAt.Truncate(time.Second).After(modifiedSince) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestGetCostVersionWithoutStore(t *testing.T) {
+	h := &CostsHandler{config: config.DefaultConfig()}
+
+	rec := httptest.NewRecorder()
+	h.GetCostVersion(rec, httptest.NewRequest(http.MethodGet, "/api/v1/costs/version?wait=1m", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"version\":0,\"updatedAt\":\"0001-01-01T00:00:00Z\"}\n" {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.GetCostVersion(rec, httptest.NewRequest(http.MethodGet, "/api/v1/costs/version?since=0&wait=1m", nil))
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304 without waiting", rec.Code)
+	}
+}
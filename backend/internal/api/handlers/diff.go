@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// CostDiffResponse is the response for the cost diff endpoint
+type CostDiffResponse struct {
+	Timestamp         string               `json:"timestamp"`
+	Status            string               `json:"status"`
+	Diagnostics       []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Since             string               `json:"since"`
+	BaselineID        string               `json:"baselineId"`
+	BaselineTimestamp string               `json:"baselineTimestamp"`
+	Currency          string               `json:"currency"`
+	Filters           types.AppliedFilters `json:"filters"`
+	snapshot.Diff
+}
+
+// GetCostDiff returns resources added, removed, and changed since the latest
+// snapshot taken at or before the `since` query parameter.
+func (h *CostsHandler) GetCostDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	since, err := parseSince(r.URL.Query().Get("since"), time.Now().UTC())
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	baseline := h.snapshots.AtOrBefore(since, filters)
+	if baseline == nil {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "no snapshot found at or before since", nil)
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	before := snapshot.FilterResources(snapshot.Resources(baseline.Response), filters)
+	after := snapshot.Resources(response)
+
+	result := CostDiffResponse{
+		Timestamp:         response.Timestamp,
+		Status:            response.Status,
+		Diagnostics:       response.Diagnostics,
+		Since:             since.Format(time.RFC3339),
+		BaselineID:        baseline.ID,
+		BaselineTimestamp: baseline.Timestamp.Format(time.RFC3339),
+		Currency:          "USD",
+		Filters:           filters,
+		Diff:              snapshot.Compare(before, after),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// parseSince accepts an RFC 3339 timestamp, a Unix timestamp in seconds, or a
+// relative duration such as "24h" or "7d".
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("since is required")
+	}
+
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts.UTC(), nil
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid since %q: use an RFC 3339 timestamp, Unix seconds, or a duration like 24h or 7d", value)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/digest"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+)
+
+// DigestHandler handles cost digest requests
+type DigestHandler struct {
+	config    *config.Config
+	snapshots *snapshot.Store
+	logger    *slog.Logger
+}
+
+// NewDigestHandler creates a new digest handler
+func NewDigestHandler(cfg *config.Config, snapshots *snapshot.Store, logger *slog.Logger) *DigestHandler {
+	return &DigestHandler{
+		config:    cfg,
+		snapshots: snapshots,
+		logger:    logger,
+	}
+}
+
+// GetWeeklyDigest returns a summary of what changed over the last week of snapshots
+func (h *DigestHandler) GetWeeklyDigest(w http.ResponseWriter, r *http.Request) {
+	result, err := digest.Weekly(h.snapshots, h.config.Budget, time.Now().UTC())
+	if errors.Is(err, digest.ErrNoSnapshots) {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "no unfiltered cost scans have been recorded yet", nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to build weekly digest", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+)
+
+// inFlight counts scans in progress, so shutdown can wait for them to record
+// their snapshots. Unlike a sync.WaitGroup, scans can start while something
+// is waiting.
+type inFlight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // Closed when n drops to 0
+}
+
+func (f *inFlight) add() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.n++
+}
+
+func (f *inFlight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n--
+	if f.n == 0 {
+		close(f.idle)
+	}
+}
+
+// wait blocks until no scans are in progress or ctx is done, and reports
+// whether they all finished
+func (f *inFlight) wait(ctx context.Context) bool {
+	for {
+		f.mu.Lock()
+		n, idle := f.n, f.idle
+		f.mu.Unlock()
+		if n == 0 {
+			return true
+		}
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// WaitForScans blocks until every scan in progress has finished and recorded
+// its snapshot, or ctx is done, and reports whether they all finished
+func (h *CostsHandler) WaitForScans(ctx context.Context) bool {
+	return h.inFlight.wait(ctx)
+}
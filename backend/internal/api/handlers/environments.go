@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/environment"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// classifier returns an environment classifier for the config's rules and
+// the environments in account metadata
+func (h *CostsHandler) classifier() *environment.Classifier {
+	accounts := make(map[string]string)
+	for id, m := range h.accountMetadata() {
+		if m.Environment != "" {
+			accounts[id] = m.Environment
+		}
+	}
+	return environment.New(h.config.Environments, accounts)
+}
+
+// resources flattens a response's resources and classifies their
+// environments
+func (h *CostsHandler) resources(resp *types.CostResponse) []snapshot.Resource {
+	resources := snapshot.Resources(resp)
+	h.classifier().Annotate(resources)
+	return resources
+}
+
+// annotateEnvironments sets a response's cost summary by environment
+func (h *CostsHandler) annotateEnvironments(resp *types.CostResponse) {
+	c := h.classifier()
+	resources := snapshot.Resources(resp)
+	c.Annotate(resources)
+	resp.Environments = aggregate.EnvironmentCosts(resources, c.Production)
+}
+
+// GetEnvironmentCosts totals costs by environment and splits them into
+// production, non-production, and unclassified
+func (h *CostsHandler) GetEnvironmentCosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	c := h.classifier()
+	resources := snapshot.Resources(response)
+	c.Annotate(resources)
+	result := &types.EnvironmentResponse{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Status:       response.Status,
+		Diagnostics:  response.Diagnostics,
+		TotalCost:    response.TotalCost,
+		Currency:     "USD",
+		Environments: aggregate.EnvironmentCosts(resources, c.Production),
+		Filters:      filters,
+	}
+	for _, e := range result.Environments {
+		switch {
+		case e.Production:
+			result.ProductionCost = result.ProductionCost.Add(e.TotalCost)
+		case e.Environment == environment.Unclassified:
+			result.UnclassifiedCost = result.UnclassifiedCost.Add(e.TotalCost)
+		default:
+			result.NonProductionCost = result.NonProductionCost.Add(e.TotalCost)
+		}
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/focus"
+)
+
+// GetFOCUSExport scans resources and returns them as FinOps FOCUS rows, as
+// CSV (default) or JSON, charged for the current hour, day, or month
+func (h *CostsHandler) GetFOCUSExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now().UTC()
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = focus.PeriodMonth
+	}
+	if !focus.ValidPeriod(period) {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, fmt.Sprintf("invalid period %q: use hour, day, or month", period), nil)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "csv" && format != "json" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, fmt.Sprintf("invalid format %q: use csv or json", format), nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	rows := focus.Rows(response, period, now)
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rows); err != nil {
+			h.logger.Error("failed to encode response", "error", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="awscogs-focus-%s.csv"`, now.Format("2006-01-02")))
+	if err := focus.WriteCSV(w, rows); err != nil {
+		h.logger.Error("failed to write FOCUS export", "error", err)
+	}
+}
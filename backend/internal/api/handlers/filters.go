@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// filterError reports query filter values that can't match anything, so the
+// client gets a 400 instead of a scan that silently returns nothing
+type filterError struct {
+	Parameter string   `json:"parameter"`
+	Invalid   []string `json:"invalid"`
+	Valid     []string `json:"valid,omitempty"` // Empty when the valid values can't be listed
+}
+
+func (e *filterError) Error() string {
+	noun := map[string]string{
+		"account":  "account",
+		"region":   "region",
+		"resource": "resource type",
+	}[e.Parameter]
+	if len(e.Invalid) > 1 {
+		noun += "s"
+	}
+	quoted := make([]string, len(e.Invalid))
+	for i, v := range e.Invalid {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	msg := fmt.Sprintf("unknown %s %s", noun, strings.Join(quoted, ", "))
+	if len(e.Valid) > 0 {
+		msg += fmt.Sprintf(" (valid: %s)", strings.Join(e.Valid, ", "))
+	}
+	return msg
+}
+
+// invalidValues returns a filterError for the requested values valid rejects,
+// or nil if all are accepted
+func invalidValues(parameter string, requested []string, valid func(string) bool) *filterError {
+	var invalid []string
+	for _, v := range requested {
+		if !valid(v) && !slices.Contains(invalid, v) {
+			invalid = append(invalid, v)
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	return &filterError{Parameter: parameter, Invalid: invalid}
+}
+
+// validateResourceTypes returns a filterError naming the requested resource
+// types that aren't in valid
+func validateResourceTypes(requested, valid []string) error {
+	if err := invalidValues("resource", requested, func(rt string) bool { return slices.Contains(valid, rt) }); err != nil {
+		err.Valid = valid
+		return err
+	}
+	return nil
+}
+
+// validateFilters checks that every requested resource type is enabled,
+// every region is handled by a provider (and, when AWS regions are
+// discovered, enabled), and every account is known. Accounts are only
+// checked when AWS is the only provider, since other providers' accounts
+// aren't known until they are scanned.
+func (h *CostsHandler) validateFilters(ctx context.Context, filters types.AppliedFilters, resourceTypes []string) error {
+	if err := validateResourceTypes(filters.ResourceTypes, resourceTypes); err != nil {
+		return err
+	}
+
+	if len(filters.Regions) > 0 {
+		if err := invalidValues("region", filters.Regions, h.clouds.HandlesRegion); err != nil {
+			return err
+		}
+		if h.config.AWS.DiscoverRegions && slices.ContainsFunc(filters.Regions, aws.IsRegion) {
+			regions, err := h.scope.Regions(ctx, nil)
+			if err != nil {
+				return fmt.Errorf("listing regions: %w", err)
+			}
+			enabled := func(region string) bool { return !aws.IsRegion(region) || slices.Contains(regions, region) }
+			if err := invalidValues("region", filters.Regions, enabled); err != nil {
+				err.Valid = regions
+				return err
+			}
+		}
+	}
+
+	if len(filters.Accounts) > 0 && h.awsOnly() {
+		accounts, err := h.scope.Accounts(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("listing accounts: %w", err)
+		}
+		var known []string
+		for _, acc := range accounts {
+			for _, v := range []string{acc.Name, acc.ID, roleAccountID(acc.RoleARN)} {
+				if v != "" && !slices.Contains(known, v) {
+					known = append(known, v)
+				}
+			}
+		}
+		// With only the default credentials there is nothing to check against
+		if len(known) > 0 {
+			if err := invalidValues("account", filters.Accounts, func(account string) bool { return slices.Contains(known, account) }); err != nil {
+				err.Valid = known
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseFilters reads the account and region query filters and validates them
+// with resourceTypes, the types the endpoint scans: those from the resource
+// parameter, or the fixed types of a single-service endpoint. It writes a
+// 400 and returns false if any filter is unknown.
+func (h *CostsHandler) parseFilters(w http.ResponseWriter, r *http.Request, resourceTypes ...string) (types.AppliedFilters, bool) {
+	filters := types.AppliedFilters{
+		Accounts:      parseArrayParam(r, "account"),
+		Regions:       parseArrayParam(r, "region"),
+		ResourceTypes: resourceTypes,
+	}
+	return filters, h.validFilters(w, r, filters)
+}
+
+// validFilters validates filters against the enabled resource types, writing
+// a 400 listing the valid values and returning false if any are unknown
+func (h *CostsHandler) validFilters(w http.ResponseWriter, r *http.Request, filters types.AppliedFilters) bool {
+	return h.writeFilterError(w, r, h.validateFilters(r.Context(), filters, h.clouds.ResourceTypes()))
+}
+
+// writeFilterError writes err as a 400 if it is a filterError, or a 500
+// otherwise. It returns true if err is nil.
+func (h *CostsHandler) writeFilterError(w http.ResponseWriter, r *http.Request, err error) bool {
+	if err == nil {
+		return true
+	}
+	var fe *filterError
+	if errors.As(err, &fe) {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidFilter, fe.Error(), fe)
+		return false
+	}
+	h.logger.Error("failed to validate filters", "error", err)
+	apierror.Internal(w, r, err)
+	return false
+}
+
+// awsOnly reports whether AWS is the only registered provider
+func (h *CostsHandler) awsOnly() bool {
+	for _, d := range h.clouds.Discoverers() {
+		if d.Provider() != "aws" {
+			return false
+		}
+	}
+	return true
+}
+
+// roleAccountID returns the account ID in a role ARN such as
+// arn:aws:iam::123456789012:role/awscogs
+func roleAccountID(roleARN string) string {
+	parts := strings.Split(roleARN, ":")
+	if len(parts) < 6 {
+		return ""
+	}
+	return parts[4]
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func newFilterTestHandler(cfg *config.Config, providers ...cloud.ResourceDiscoverer) *CostsHandler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clouds := cloud.NewRegistry()
+	for _, p := range providers {
+		clouds.RegisterDiscoverer(p)
+	}
+	return &CostsHandler{config: cfg, scope: aws.NewScopeResolver(cfg, nil, logger), clouds: clouds, logger: logger}
+}
+
+func TestValidateFilters(t *testing.T) {
+	cfg := &config.Config{AWS: config.AWSConfig{Accounts: []config.AccountConfig{
+		{Name: "prod", RoleARN: "arn:aws:iam::111111111111:role/awscogs"},
+	}}}
+	h := newFilterTestHandler(cfg, aws.NewResourceDiscoverer(nil, nil))
+	ctx := context.Background()
+
+	valid := types.AppliedFilters{Accounts: []string{"prod", "111111111111"}, Regions: []string{"us-east-1"}, ResourceTypes: []string{"ec2"}}
+	if err := h.validateFilters(ctx, valid, []string{"ec2", "ebs"}); err != nil {
+		t.Fatalf("expected valid filters to pass, got %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		filters   types.AppliedFilters
+		parameter string
+		invalid   []string
+	}{
+		{"resource", types.AppliedFilters{ResourceTypes: []string{"ec2", "ec3", "ec3"}}, "resource", []string{"ec3"}},
+		{"region", types.AppliedFilters{Regions: []string{"us-east-1", "useast1", "US-EAST-1"}}, "region", []string{"useast1", "US-EAST-1"}},
+		{"account", types.AppliedFilters{Accounts: []string{"staging"}}, "account", []string{"staging"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fe *filterError
+			if err := h.validateFilters(ctx, tt.filters, []string{"ec2", "ebs"}); !errors.As(err, &fe) {
+				t.Fatalf("expected a filterError, got %v", err)
+			}
+			if fe.Parameter != tt.parameter || !slices.Equal(fe.Invalid, tt.invalid) {
+				t.Errorf("got %s %v, want %s %v", fe.Parameter, fe.Invalid, tt.parameter, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestValidateFiltersListsValidValues(t *testing.T) {
+	cfg := &config.Config{AWS: config.AWSConfig{Accounts: []config.AccountConfig{{Name: "prod"}}}}
+	h := newFilterTestHandler(cfg, aws.NewResourceDiscoverer(nil, nil))
+
+	var fe *filterError
+	err := h.validateFilters(context.Background(), types.AppliedFilters{Accounts: []string{"dev"}}, nil)
+	if !errors.As(err, &fe) || !slices.Equal(fe.Valid, []string{"prod"}) {
+		t.Fatalf("expected valid accounts to be listed, got %v", err)
+	}
+	if got, want := fe.Error(), `unknown account "dev" (valid: prod)`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err = validateResourceTypes([]string{"foo", "bar"}, []string{"ec2"})
+	if got, want := err.Error(), `unknown resource types "foo", "bar" (valid: ec2)`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestValidateFiltersSkipsUnknowableAccounts(t *testing.T) {
+	// Default credentials only: there are no account names to check against
+	h := newFilterTestHandler(&config.Config{}, aws.NewResourceDiscoverer(nil, nil))
+	if err := h.validateFilters(context.Background(), types.AppliedFilters{Accounts: []string{"anything"}}, nil); err != nil {
+		t.Fatalf("expected accounts to pass without a known list, got %v", err)
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	cfg := &config.Config{AWS: config.AWSConfig{Accounts: []config.AccountConfig{{Name: "prod"}}, Services: []string{"ec2"}}}
+	h := newFilterTestHandler(cfg, aws.NewResourceDiscoverer(nil, aws.NewScopeResolver(cfg, nil, nil)))
+
+	w := httptest.NewRecorder()
+	filters, ok := h.parseFilters(w, httptest.NewRequest(http.MethodGet, "/api/v1/costs/ec2?account=prod&region=us-east-1,eu-west-1", nil), "ec2")
+	if !ok {
+		t.Fatalf("expected valid filters to pass, got %d: %s", w.Code, w.Body)
+	}
+	want := types.AppliedFilters{Accounts: []string{"prod"}, Regions: []string{"us-east-1", "eu-west-1"}, ResourceTypes: []string{"ec2"}}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("got %+v, want %+v", filters, want)
+	}
+
+	// Single-service endpoints reject unknown regions like the others
+	w = httptest.NewRecorder()
+	if _, ok := h.parseFilters(w, httptest.NewRequest(http.MethodGet, "/api/v1/costs/ec2?region=useast1", nil), "ec2"); ok || w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a 400 for an unknown region, got %d", w.Code)
+	}
+}
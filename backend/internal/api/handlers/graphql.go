@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/graph"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GraphQLRequest is a GraphQL query sent over HTTP
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+func (h *CostsHandler) newGraphQLSchema() (graphql.Schema, error) {
+	return graph.NewSchema(graph.Resolvers{
+		Scan: func(ctx context.Context, filters types.AppliedFilters) (*types.CostResponse, error) {
+			if err := h.validateFilters(ctx, filters, h.clouds.ResourceTypes()); err != nil {
+				return nil, err
+			}
+			return h.scan(ctx, filters)
+		},
+		Snapshots: h.snapshots,
+	})
+}
+
+// GraphQL executes a GraphQL query against the cost model. Queries are
+// accepted as a JSON POST body or in the query parameter of a GET.
+func (h *CostsHandler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	schema, err := h.graphQLSchema()
+	if err != nil {
+		h.logger.Error("failed to build GraphQL schema", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	var req GraphQLRequest
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if variables := q.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "invalid variables: "+err.Error(), nil)
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return
+	}
+	if req.Query == "" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "query is required", nil)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
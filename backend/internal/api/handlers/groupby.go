@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetGroupedCosts scans resources and totals their costs by the dimensions in
+// the dims query parameter, e.g. dims=account,region,tag:team
+func (h *CostsHandler) GetGroupedCosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dims := parseArrayParam(r, "dims")
+	if err := aggregate.ValidateDimensions(dims); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidFilter, err.Error(), nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	result := &types.GroupedCostResponse{
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Status:      response.Status,
+		Diagnostics: response.Diagnostics,
+		Dimensions:  dims,
+		TotalCost:   response.TotalCost,
+		Currency:    "USD",
+		Groups:      aggregate.GroupBy(h.resources(response), dims),
+		Filters:     filters,
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/aws"
+)
+
+// GetIAMPolicy returns the IAM policies awsCOGS needs for the current
+// configuration, optionally narrowed to the requested resource types
+func (h *ConfigHandler) GetIAMPolicy(w http.ResponseWriter, r *http.Request) {
+	resourceTypes := parseArrayParam(r, "resource")
+	if err := validateResourceTypes(resourceTypes, h.config.AWS.EnabledServices()); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidFilter, err.Error(), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(aws.RequiredPolicies(h.config, resourceTypes)); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/ignore"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// IgnoreRuleList is the API response for the ignore rules
+type IgnoreRuleList struct {
+	Tag   string                `json:"tag,omitempty"` // Resources with this tag set to "true" are ignored
+	Rules []snapshot.IgnoreRule `json:"rules"`
+}
+
+// ignoreRules returns the rules in the config file, with the IDs config-1,
+// config-2, and so on, followed by the ones created through the API
+func (h *CostsHandler) ignoreRules() []snapshot.IgnoreRule {
+	rules := make([]snapshot.IgnoreRule, 0, len(h.config.Ignore.Rules))
+	for i, r := range h.config.Ignore.Rules {
+		rules = append(rules, snapshot.IgnoreRule{
+			ID: "config-" + strconv.Itoa(i+1),
+			IgnoreRuleSpec: snapshot.IgnoreRuleSpec{
+				Reason:        r.Reason,
+				Resources:     r.Resources,
+				ResourceTypes: r.ResourceTypes,
+				Accounts:      r.Accounts,
+				Tags:          r.Tags,
+			},
+			Source: snapshot.IgnoreRuleFromConfig,
+		})
+	}
+	if h.snapshots != nil {
+		rules = append(rules, h.snapshots.IgnoreRules()...)
+	}
+	return rules
+}
+
+// applyIgnores removes the resources ignore rules match from resp, rebuilds
+// its totals and summaries without them, and lists them with their cost
+func (h *CostsHandler) applyIgnores(resp *types.CostResponse) *types.CostResponse {
+	m := ignore.New(h.config.Ignore.Tag, h.ignoreRules())
+	if m.Empty() {
+		return resp
+	}
+
+	ignored := make(map[string]bool)
+	var resources []types.IgnoredResource
+	var cost types.CostValue
+	for _, r := range snapshot.Resources(resp) {
+		rule, ok := m.Match(r)
+		if !ok {
+			continue
+		}
+		ignored[r.Key()] = true
+		cost = cost.Add(r.HourlyCost)
+		resources = append(resources, types.IgnoredResource{
+			ResourceType: r.Type, ResourceID: r.ID, Name: r.Name,
+			AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+			HourlyCost: r.HourlyCost, CreatedAt: r.CreatedAt, Rule: rule.ID, Reason: rule.Reason,
+		})
+	}
+	if len(resources) == 0 {
+		return resp
+	}
+
+	out := *snapshot.KeepResources(resp, func(r snapshot.Resource) bool { return !ignored[r.Key()] })
+	recomputeTotals(&out)
+	out.UnpricedResources = nil
+	for _, u := range resp.UnpricedResources {
+		if !ignored[snapshot.Resource{Type: u.ResourceType, AccountID: u.AccountID, Region: u.Region, ID: u.ResourceID}.Key()] {
+			out.UnpricedResources = append(out.UnpricedResources, u)
+		}
+	}
+	out.UnpricedCount = len(out.UnpricedResources)
+	out.IgnoredCount = len(resources)
+	out.IgnoredCost = cost
+	out.IgnoredResources = resources
+	return &out
+}
+
+// ListIgnoreRules returns the ignore rules from the config file and the API
+func (h *CostsHandler) ListIgnoreRules(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, IgnoreRuleList{Tag: h.config.Ignore.Tag, Rules: h.ignoreRules()})
+}
+
+// CreateIgnoreRule saves a new ignore rule
+func (h *CostsHandler) CreateIgnoreRule(w http.ResponseWriter, r *http.Request) {
+	var spec snapshot.IgnoreRuleSpec
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return
+	}
+	spec.Reason = strings.TrimSpace(spec.Reason)
+	if err := spec.Config().Validate(); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "ignore rule "+err.Error(), nil)
+		return
+	}
+
+	rule, err := h.snapshots.CreateIgnoreRule(spec)
+	if err != nil {
+		h.logger.Error("failed to save ignore rule", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	// Cached results still include the resources the rule ignores
+	h.results.clear()
+	h.writeJSON(w, http.StatusCreated, rule)
+}
+
+// DeleteIgnoreRule removes an ignore rule created through the API. Rules
+// from the config file can't be deleted.
+func (h *CostsHandler) DeleteIgnoreRule(w http.ResponseWriter, r *http.Request) {
+	err := h.snapshots.DeleteIgnoreRule(chi.URLParam(r, "id"))
+	if errors.Is(err, snapshot.ErrIgnoreRuleNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to delete ignore rule", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.results.clear()
+	w.WriteHeader(http.StatusNoContent)
+}
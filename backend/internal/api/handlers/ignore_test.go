@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/ignore"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestApplyIgnores(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Ignore.Rules = []config.IgnoreRule{{Reason: "DR standby", Resources: []string{"dr-*"}, ResourceTypes: []string{"rds"}}}
+	h := &CostsHandler{config: cfg}
+
+	resp := &types.CostResponse{
+		TotalCost: 1.6,
+		RDSInstances: []types.RDSInstance{
+			{DBInstanceID: "dr-orders", AccountID: "1", Region: "us-west-2", HourlyCost: 1},
+			{DBInstanceID: "orders", AccountID: "1", Region: "us-east-1", HourlyCost: 0.5},
+		},
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-spare", AccountID: "1", Region: "us-east-1", HourlyCost: 0.1, Tags: map[string]string{"awscogs:ignore": "true"}},
+		},
+		UnpricedCount:     1,
+		UnpricedResources: []types.UnpricedResource{{ResourceType: "ec2", ResourceID: "i-spare", AccountID: "1", Region: "us-east-1"}},
+	}
+	got := h.applyIgnores(resp)
+	if len(got.RDSInstances) != 1 || got.RDSInstances[0].DBInstanceID != "orders" || len(got.EC2Instances) != 0 {
+		t.Fatalf("kept %+v and %+v, want only the orders database", got.RDSInstances, got.EC2Instances)
+	}
+	if got.TotalCost.Round() != 0.5 || got.IgnoredCost.Round() != 1.1 || got.IgnoredCount != 2 {
+		t.Errorf("total = %v, ignored = %v across %d; want 0.5 and 1.1 across 2", got.TotalCost, got.IgnoredCost, got.IgnoredCount)
+	}
+	if got.IgnoredResources[0].Rule != ignore.TagRule || got.IgnoredResources[1].Rule != "config-1" {
+		t.Errorf("ignored = %+v, want the tag then the config rule", got.IgnoredResources)
+	}
+	if got.UnpricedCount != 0 || len(resp.RDSInstances) != 2 {
+		t.Errorf("unpriced = %d, cached RDS instances = %d; want the ignored instance unlisted and the cache untouched", got.UnpricedCount, len(resp.RDSInstances))
+	}
+}
+
+// ec2Provider stands in for the AWS discoverer with a fixed set of instances
+type ec2Provider struct{ instances []types.EC2Instance }
+
+func (ec2Provider) Provider() string                 { return "aws" }
+func (ec2Provider) ResourceTypes() []string          { return []string{"ec2"} }
+func (ec2Provider) HandlesRegion(region string) bool { return region == "us-east-1" }
+func (p ec2Provider) Discover(context.Context, cloud.Scope) (*types.CostResponse, error) {
+	resp := &types.CostResponse{EC2Instances: p.instances}
+	for _, inst := range p.instances {
+		resp.TotalCost = resp.TotalCost.Add(inst.HourlyCost)
+	}
+	return resp, nil
+}
+
+func TestServiceEndpointAppliesIgnores(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Ignore.Rules = []config.IgnoreRule{{Reason: "load test", Resources: []string{"i-loadtest"}}}
+	h := newFilterTestHandler(cfg, ec2Provider{instances: []types.EC2Instance{
+		{InstanceID: "i-web", AccountID: "1", Region: "us-east-1", HourlyCost: 0.5},
+		{InstanceID: "i-loadtest", AccountID: "1", Region: "us-east-1", HourlyCost: 2},
+	}})
+	h.results = newResultCache(0)
+
+	rec := httptest.NewRecorder()
+	h.GetEC2Costs(rec, httptest.NewRequest(http.MethodGet, "/api/v1/costs/ec2", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var got types.CostResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(got.EC2Instances) != 1 || got.EC2Instances[0].InstanceID != "i-web" {
+		t.Fatalf("instances = %+v, want only i-web", got.EC2Instances)
+	}
+	if got.TotalCost.Round() != 0.5 || got.IgnoredCount != 1 || got.IgnoredCost.Round() != 2 {
+		t.Errorf("total = %v, ignored = %v across %d; want 0.5 and 2 across 1", got.TotalCost, got.IgnoredCost, got.IgnoredCount)
+	}
+}
+
+func TestApplyIgnoresCloudWatch(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Ignore.Rules = []config.IgnoreRule{{Reason: "legacy monitoring", Resources: []string{"legacy-*", "Legacy/*"}}}
+	h := &CostsHandler{config: cfg}
+
+	resp := &types.CostResponse{
+		TotalCost: 0.004,
+		Alarms: []types.Alarm{
+			{Name: "legacy-cpu", ARN: "arn:aws:cloudwatch:us-east-1:1:alarm:legacy-cpu", AccountID: "1", Region: "us-east-1", HourlyCost: 0.001},
+			{Name: "api-errors", ARN: "arn:aws:cloudwatch:us-east-1:1:alarm:api-errors", AccountID: "1", Region: "us-east-1", HourlyCost: 0.001},
+		},
+		CustomMetrics: []types.CustomMetrics{{Namespace: "Legacy/App", AccountID: "1", Region: "us-east-1", HourlyCost: 0.002}},
+	}
+	got := h.applyIgnores(resp)
+	if len(got.Alarms) != 1 || got.Alarms[0].Name != "api-errors" || len(got.CustomMetrics) != 0 {
+		t.Fatalf("kept %+v and %+v, want only the api-errors alarm", got.Alarms, got.CustomMetrics)
+	}
+	if got.TotalCost.Round() != 0.001 || got.IgnoredCount != 2 {
+		t.Errorf("total = %v, ignored = %d; want 0.001 and 2", got.TotalCost, got.IgnoredCount)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/logging"
+)
+
+// LogHandler handles log level requests
+type LogHandler struct {
+	levels *logging.Levels
+	logger *slog.Logger
+}
+
+// NewLogHandler creates a new log level handler
+func NewLogHandler(levels *logging.Levels, logger *slog.Logger) *LogHandler {
+	return &LogHandler{
+		levels: levels,
+		logger: logger,
+	}
+}
+
+// GetLogLevels returns the log levels in effect
+func (h *LogHandler) GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	h.writeState(w)
+}
+
+// SetLogLevels replaces the log levels until the server restarts
+func (h *LogHandler) SetLogLevels(w http.ResponseWriter, r *http.Request) {
+	var spec logging.LevelSpec
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return
+	}
+	if err := h.levels.Set(spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+	state := h.levels.State()
+	h.logger.Info("log levels changed", "level", state.Level, "components", state.Components)
+	h.writeState(w)
+}
+
+// ResetLogLevels restores the configured log levels
+func (h *LogHandler) ResetLogLevels(w http.ResponseWriter, r *http.Request) {
+	h.levels.Reset()
+	h.logger.Info("log levels reset to configured levels")
+	h.writeState(w)
+}
+
+func (h *LogHandler) writeState(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.levels.State()); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// CheckPermissions probes each service in each account and region and reports
+// which combinations would fail a scan due to missing IAM permissions
+func (h *CostsHandler) CheckPermissions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accountFilter := parseArrayParam(r, "account")
+	regionFilter := parseArrayParam(r, "region")
+	resourceTypes := parseArrayParam(r, "resource")
+	filters := types.AppliedFilters{Accounts: accountFilter, Regions: regionFilter, ResourceTypes: resourceTypes}
+	if !h.writeFilterError(w, r, h.validateFilters(ctx, filters, h.config.AWS.EnabledServices())) {
+		return
+	}
+	if len(resourceTypes) == 0 {
+		resourceTypes = h.config.AWS.EnabledServices()
+	}
+
+	regions, err := h.scope.Regions(ctx, regionFilter)
+	if err != nil {
+		h.logger.Error("failed to get regions", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	accounts, err := h.scope.Accounts(ctx, accountFilter)
+	if err != nil {
+		h.logger.Error("failed to get accounts", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	response := h.discovery.CheckPermissions(ctx, accounts, regions, resourceTypes)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/recommend"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetGP3Recommendations scans EBS volumes and returns the savings from
+// migrating each gp2 volume to gp3 with matching performance
+func (h *CostsHandler) GetGP3Recommendations(w http.ResponseWriter, r *http.Request) {
+	if !h.serviceEnabled(w, r, "ebs") {
+		return
+	}
+
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, "ebs")
+	if !ok {
+		return
+	}
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	volumes, diagnostics := recommend.GP3Savings(ctx, h.discovery.PricingProvider(), response.EBSVolumes)
+	result := recommend.NewGP3Report(volumes, filters)
+	result.Diagnostics = slices.Concat(response.Diagnostics, diagnostics)
+	result.Status = recommendationStatus(response.Status, diagnostics)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// GetGravitonRecommendations scans EC2 and RDS instances and returns the
+// savings from moving x86 instances to their Graviton equivalents
+func (h *CostsHandler) GetGravitonRecommendations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var scanTypes []string
+	for _, rt := range []string{"ec2", "rds"} {
+		if slices.Contains(h.config.AWS.EnabledServices(), rt) {
+			scanTypes = append(scanTypes, rt)
+		}
+	}
+	if len(scanTypes) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeResourceTypeDisabled, "resource types \"ec2\" and \"rds\" are disabled", nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, scanTypes...)
+	if !ok {
+		return
+	}
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	instances, diagnostics := recommend.GravitonSavings(ctx, h.discovery.PricingProvider(), response)
+	result := recommend.NewGravitonReport(instances, filters)
+	result.Diagnostics = slices.Concat(response.Diagnostics, diagnostics)
+	result.Status = recommendationStatus(response.Status, diagnostics)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// GetLoadBalancerRecommendations scans load balancers and returns the classic
+// and idle ones, with the savings from deleting those that are idle
+func (h *CostsHandler) GetLoadBalancerRecommendations(w http.ResponseWriter, r *http.Request) {
+	if !h.serviceEnabled(w, r, "elb") {
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, "elb")
+	if !ok {
+		return
+	}
+	response, err := h.scan(r.Context(), filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	result := recommend.NewLoadBalancerReport(recommend.LoadBalancerSavings(response.LoadBalancers), filters)
+	result.Diagnostics = response.Diagnostics
+	result.Status = recommendationStatus(response.Status, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// GetOffHoursRecommendations scans EC2 and RDS instances and returns the
+// savings from stopping non-production ones outside the configured schedule
+func (h *CostsHandler) GetOffHoursRecommendations(w http.ResponseWriter, r *http.Request) {
+	var scanTypes []string
+	for _, rt := range []string{"ec2", "rds"} {
+		if slices.Contains(h.config.AWS.EnabledServices(), rt) {
+			scanTypes = append(scanTypes, rt)
+		}
+	}
+	if len(scanTypes) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeResourceTypeDisabled, "resource types \"ec2\" and \"rds\" are disabled", nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, scanTypes...)
+	if !ok {
+		return
+	}
+	response, err := h.scan(r.Context(), filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	offHours := h.config.Recommend.OffHours
+	result := recommend.NewOffHoursReport(recommend.OffHoursSavings(response, offHours), offHours, filters)
+	result.Diagnostics = response.Diagnostics
+	result.Status = recommendationStatus(response.Status, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// GetAuroraIORecommendations scans RDS instances and Aurora clusters and
+// compares each cluster's cost under Aurora Standard and I/O-Optimized storage
+func (h *CostsHandler) GetAuroraIORecommendations(w http.ResponseWriter, r *http.Request) {
+	if !h.serviceEnabled(w, r, "rds") {
+		return
+	}
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, "rds")
+	if !ok {
+		return
+	}
+
+	regions, err := h.scope.Regions(ctx, filters.Regions)
+	if err != nil {
+		h.logger.Error("failed to get regions", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	accounts, err := h.scope.Accounts(ctx, filters.Accounts)
+	if err != nil {
+		h.logger.Error("failed to get accounts", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	clusters, clusterDiagnostics := h.discovery.DiscoverAuroraClusters(ctx, accounts, regions)
+
+	comparisons, diagnostics := recommend.AuroraIOSavings(ctx, h.discovery.PricingProvider(), clusters, response.RDSInstances)
+	diagnostics = append(clusterDiagnostics, diagnostics...)
+	result := recommend.NewAuroraIOReport(comparisons, filters)
+	result.Diagnostics = append(append([]types.Diagnostic(nil), response.Diagnostics...), diagnostics...)
+	result.Status = recommendationStatus(response.Status, diagnostics)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// recommendationStatus combines the scan status with pricing failures for the
+// proposed resources
+func recommendationStatus(scanStatus string, diagnostics []types.Diagnostic) string {
+	status := scanStatus
+	if status == "" {
+		status = types.ResponseStatusOK
+	}
+	if len(diagnostics) > 0 && status == types.ResponseStatusOK {
+		status = types.ResponseStatusPartial
+	}
+	return status
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetRegionActivity probes the enabled regions full scans leave out for
+// chargeable resources in each account
+func (h *CostsHandler) GetRegionActivity(w http.ResponseWriter, r *http.Request) {
+	response, err := h.probeExcludedRegions(r.Context(), parseArrayParam(r, "account"))
+	if err != nil {
+		h.logger.Error("failed to probe excluded regions", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// probeExcludedRegions probes the excluded regions of the accounts matching
+// accountFilter, and logs a warning for each active one
+func (h *CostsHandler) probeExcludedRegions(ctx context.Context, accountFilter []string) (*types.RegionActivityResponse, error) {
+	excluded, err := h.scope.ExcludedRegions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing excluded regions: %w", err)
+	}
+	if len(excluded) == 0 {
+		return &types.RegionActivityResponse{Status: types.ResponseStatusOK, ExcludedRegions: []string{}, Regions: []types.RegionActivity{}}, nil
+	}
+	accounts, err := h.scope.Accounts(ctx, accountFilter)
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts: %w", err)
+	}
+
+	response := h.discovery.ProbeRegions(ctx, accounts, excluded)
+	for _, a := range response.Regions {
+		if a.Active {
+			h.logger.Warn("region excluded from scans has chargeable resources",
+				"account", a.AccountName,
+				"region", a.Region,
+				"runningInstances", a.RunningInstances,
+				"volumes", a.Volumes,
+				"natGateways", a.NATGateways)
+		}
+	}
+	return response, nil
+}
+
+// excludedRegionDiagnostics returns a warning for each account and region
+// left out of scans that has chargeable resources
+func (h *CostsHandler) excludedRegionDiagnostics(ctx context.Context) []types.Diagnostic {
+	response, err := h.probeExcludedRegions(ctx, nil)
+	if err != nil {
+		h.logger.Warn("failed to probe excluded regions", "error", err)
+		return nil
+	}
+	var diagnostics []types.Diagnostic
+	for _, a := range response.Regions {
+		if !a.Active {
+			continue
+		}
+		diagnostics = append(diagnostics, types.Diagnostic{
+			Level:       "warning",
+			AccountID:   a.AccountID,
+			AccountName: a.AccountName,
+			Region:      a.Region,
+			Operation:   "RegionActivityProbe",
+			Message: fmt.Sprintf("region is excluded from scans but has %d running instances, %d volumes, and %d NAT gateways",
+				a.RunningInstances, a.Volumes, a.NATGateways),
+		})
+	}
+	return diagnostics
+}
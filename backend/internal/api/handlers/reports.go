@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/costcenter"
+	"github.com/johnjeffers/awscogs/backend/internal/recommend"
+	"github.com/johnjeffers/awscogs/backend/internal/report"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetPDFReport scans resources and returns a cost report as a PDF, with
+// changes measured from the latest snapshot at or before since (default 30d)
+func (h *CostsHandler) GetPDFReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now().UTC()
+
+	sinceParam := r.URL.Query().Get("since")
+	if sinceParam == "" {
+		sinceParam = "30d"
+	}
+	since, err := parseSince(sinceParam, now)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, err.Error(), nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	// Look up the baseline before scanning so the scan's own snapshot can't be used
+	baseline := h.snapshots.AtOrBefore(since, filters)
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	pdf := report.New(response, filters, baseline, now).PDF()
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="awscogs-report-%s.pdf"`, now.Format("2006-01-02")))
+	if _, err := w.Write(pdf); err != nil {
+		h.logger.Error("failed to write report", "error", err)
+	}
+}
+
+// GetChargebackReport scans resources and splits their cost for an hour, day,
+// or month (default) among cost centers, as JSON (default) or CSV. Shared
+// resources are split by the costCenters.sharedCosts rules.
+func (h *CostsHandler) GetChargebackReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now().UTC()
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "month"
+	}
+	hours, ok := costcenter.PeriodHours[period]
+	if !ok {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, fmt.Sprintf("invalid period %q: use hour, day, or month", period), nil)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "csv" && format != "json" {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, fmt.Sprintf("invalid format %q: use csv or json", format), nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	lines, shared := costcenter.Chargeback(snapshot.Resources(response), h.config.CostCenters.SharedCosts, hours)
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="awscogs-chargeback-%s-%s.csv"`, period, now.Format("2006-01-02")))
+		if err := costcenter.WriteChargebackCSV(w, lines); err != nil {
+			h.logger.Error("failed to write chargeback report", "error", err)
+		}
+		return
+	}
+
+	result := &costcenter.ChargebackReport{
+		Timestamp:   now.Format(time.RFC3339),
+		Status:      response.Status,
+		Diagnostics: response.Diagnostics,
+		Currency:    "USD",
+		Period:      period,
+		Hours:       hours,
+		SharedCost:  shared,
+		Lines:       lines,
+		Filters:     filters,
+	}
+	for _, l := range lines {
+		result.TotalCost = result.TotalCost.Add(l.TotalCost)
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// GetRICoverageReport scans running EC2 instances and active Reserved
+// Instances and reports how much of each family's usage the reservations
+// cover, and which reservations go unused
+func (h *CostsHandler) GetRICoverageReport(w http.ResponseWriter, r *http.Request) {
+	if !h.serviceEnabled(w, r, "ec2") {
+		return
+	}
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, "ec2")
+	if !ok {
+		return
+	}
+
+	regions, err := h.scope.Regions(ctx, filters.Regions)
+	if err != nil {
+		h.logger.Error("failed to get regions", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	accounts, err := h.scope.Accounts(ctx, filters.Accounts)
+	if err != nil {
+		h.logger.Error("failed to get accounts", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	reservations, diagnostics := h.discovery.DiscoverReservedInstances(ctx, accounts, regions)
+	coverage, usages := recommend.ReservedInstanceCoverage(response.EC2Instances, reservations)
+
+	result := &recommend.RICoverageReport{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Status:       response.Status,
+		Diagnostics:  append(append([]types.Diagnostic(nil), response.Diagnostics...), diagnostics...),
+		Currency:     "USD",
+		Coverage:     coverage,
+		Reservations: usages,
+		Filters:      filters,
+	}
+	for _, c := range coverage {
+		result.RunningUnits += c.RunningUnits
+		result.CoveredUnits += c.CoveredUnits
+		result.ReservedUnits += c.ReservedUnits
+		result.UnusedUnits += c.UnusedUnits
+		result.OnDemandHourlyCost = result.OnDemandHourlyCost.Add(c.OnDemandHourlyCost)
+	}
+	for _, u := range usages {
+		result.UnusedHourlyCost = result.UnusedHourlyCost.Add(u.UnusedHourlyCost)
+	}
+	result.CoveragePercent = recommend.Percent(result.CoveredUnits, result.RunningUnits)
+	result.UtilizationPercent = recommend.Percent(result.ReservedUnits-result.UnusedUnits, result.ReservedUnits)
+	if result.Status == "" || result.Status == types.ResponseStatusOK {
+		result.Status = types.ResponseStatusOK
+		if len(diagnostics) > 0 {
+			result.Status = types.ResponseStatusPartial
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// GetIPv4Report scans the resources that hold public IPv4 addresses and
+// lists each address with its monthly cost, flagging those that could be
+// released or replaced by IPv6 or private connectivity
+func (h *CostsHandler) GetIPv4Report(w http.ResponseWriter, r *http.Request) {
+	var scanTypes []string
+	for _, rt := range []string{"eip", "publicipv4", "nat", "elb", "rds"} {
+		if slices.Contains(h.config.AWS.EnabledServices(), rt) {
+			scanTypes = append(scanTypes, rt)
+		}
+	}
+	if len(scanTypes) == 0 {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeResourceTypeDisabled, "resource types \"eip\", \"publicipv4\", \"nat\", \"elb\", and \"rds\" are disabled", nil)
+		return
+	}
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, scanTypes...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	addresses, diagnostics := recommend.IPv4Audit(ctx, h.discovery.PricingProvider(), response)
+	result := recommend.NewIPv4Report(addresses, filters)
+	result.Diagnostics = slices.Concat(response.Diagnostics, diagnostics)
+	result.Status = recommendationStatus(response.Status, diagnostics)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
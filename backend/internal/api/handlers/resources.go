@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// ResourceListResponse is the response for listing resources of every type
+// in one shape
+type ResourceListResponse struct {
+	Timestamp   string               `json:"timestamp"`
+	Status      string               `json:"status"`
+	Diagnostics []types.Diagnostic   `json:"diagnostics,omitempty"`
+	TotalCost   types.CostValue      `json:"totalCost"`
+	Currency    string               `json:"currency"`
+	Count       int                  `json:"count"`
+	Resources   []ListedResource     `json:"resources"`
+	Filters     types.AppliedFilters `json:"filters"`
+}
+
+// ListedResource is a resource in the unified list, with its tags
+type ListedResource struct {
+	snapshot.Resource
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ListResources scans resources and returns them as one list in the
+// type-independent snapshot.Resource shape, sorted by the sort parameter, so
+// clients can handle every type without knowing each one's fields
+func (h *CostsHandler) ListResources(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	order, ok := sortOrder(w, r)
+	if !ok {
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	resources := h.resources(response)
+	aggregate.SortResources(resources, order)
+	result := ResourceListResponse{
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Status:      response.Status,
+		Diagnostics: response.Diagnostics,
+		TotalCost:   response.TotalCost,
+		Currency:    "USD",
+		Count:       len(resources),
+		Resources:   make([]ListedResource, len(resources)),
+		Filters:     filters,
+	}
+	for i, res := range resources {
+		result.Resources[i] = ListedResource{Resource: res, Tags: res.Tags}
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// GetResource returns the full detail for a single resource from the most
+// recent snapshot that contains it
+func (h *CostsHandler) GetResource(w http.ResponseWriter, r *http.Request) {
+	resourceType := chi.URLParam(r, "type")
+	id, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidParameter, "invalid resource id", nil)
+		return
+	}
+
+	filters, ok := h.parseFilters(w, r)
+	if !ok {
+		return
+	}
+
+	detail := h.snapshots.FindResource(resourceType, id, filters)
+	if detail == nil {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "resource not found in any snapshot", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(detail); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// providerDisplayNames are the display names of resource types discovered by
+// providers other than AWS
+var providerDisplayNames = map[string]string{
+	"vm": "Azure Virtual Machines",
+}
+
+// ListResourceTypes returns every resource type a discoverer is registered
+// for, with its IAM actions and whether the current config discovers it, so
+// clients can build filters without hardcoding the list
+func (h *CostsHandler) ListResourceTypes(w http.ResponseWriter, r *http.Request) {
+	enabled := h.clouds.ResourceTypes()
+	result := types.ResourceTypesResponse{ResourceTypes: []types.ResourceTypeInfo{}}
+
+	if h.discovery != nil {
+		for _, disc := range h.discovery.Discoverers() {
+			actions := slices.Clone(disc.IAMActions())
+			if actions == nil {
+				actions = []string{}
+			}
+			result.ResourceTypes = append(result.ResourceTypes, types.ResourceTypeInfo{
+				Key:         disc.ResourceType(),
+				DisplayName: disc.DisplayName(),
+				Provider:    "aws",
+				Scope:       disc.Scope(),
+				IAMActions:  actions,
+				Enabled:     slices.Contains(enabled, disc.ResourceType()),
+			})
+		}
+	}
+	for _, d := range h.clouds.Discoverers() {
+		if d.Provider() == "aws" {
+			continue
+		}
+		for _, rt := range d.ResourceTypes() {
+			name := providerDisplayNames[rt]
+			if name == "" {
+				name = rt
+			}
+			result.ResourceTypes = append(result.ResourceTypes, types.ResourceTypeInfo{
+				Key:         rt,
+				DisplayName: name,
+				Provider:    d.Provider(),
+				Scope:       aws.ScopeRegional,
+				IAMActions:  []string{},
+				Enabled:     slices.Contains(enabled, rt),
+			})
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, result)
+}
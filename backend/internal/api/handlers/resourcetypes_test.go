@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// vmProvider stands in for the Azure discoverer
+type vmProvider struct{}
+
+func (vmProvider) Provider() string                 { return "azure" }
+func (vmProvider) ResourceTypes() []string          { return []string{"vm"} }
+func (vmProvider) HandlesRegion(region string) bool { return region == "eastus" }
+func (vmProvider) Discover(context.Context, cloud.Scope) (*types.CostResponse, error) {
+	return &types.CostResponse{}, nil
+}
+
+func TestListResourceTypes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.DefaultConfig()
+	cfg.AWS.Services = []string{"ec2", "dashboard"}
+	discovery := aws.NewDiscovery(nil, logger, 5, 60)
+	h := newFilterTestHandler(cfg, aws.NewResourceDiscoverer(discovery, aws.NewScopeResolver(cfg, discovery, logger)), vmProvider{})
+	h.discovery = discovery
+
+	rec := httptest.NewRecorder()
+	h.ListResourceTypes(rec, httptest.NewRequest(http.MethodGet, "/resource-types", nil))
+	var got types.ResourceTypesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	byKey := make(map[string]types.ResourceTypeInfo)
+	for _, rt := range got.ResourceTypes {
+		byKey[rt.Key] = rt
+	}
+	if len(got.ResourceTypes) != len(config.AWSResourceTypes)+2 || got.ResourceTypes[0].Key != "ec2" {
+		t.Fatalf("got %d resource types starting with %q, want every built-in type, data transfer, and vm in scan order", len(got.ResourceTypes), got.ResourceTypes[0].Key)
+	}
+	if ec2 := byKey["ec2"]; !ec2.Enabled || ec2.DisplayName != "EC2 Instances" || ec2.Scope != aws.ScopeRegional || len(ec2.IAMActions) == 0 {
+		t.Errorf("ec2 = %+v, want an enabled regional type with IAM actions", ec2)
+	}
+	if dashboard := byKey["dashboard"]; !dashboard.Enabled || dashboard.Scope != aws.ScopeGlobal {
+		t.Errorf("dashboard = %+v, want an enabled global type", dashboard)
+	}
+	if byKey["rds"].Enabled {
+		t.Error("rds is enabled although services leaves it out")
+	}
+	if vm := byKey["vm"]; vm.Provider != "azure" || !vm.Enabled || vm.DisplayName != "Azure Virtual Machines" {
+		t.Errorf("vm = %+v, want the Azure provider's type", vm)
+	}
+}
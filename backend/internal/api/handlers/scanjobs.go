@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Scan job statuses
+const (
+	ScanJobQueued    = "queued"
+	ScanJobRunning   = "running"
+	ScanJobSucceeded = "succeeded"
+	ScanJobFailed    = "failed"
+)
+
+const (
+	// scanJobTTL is how long a finished job and its result are kept
+	scanJobTTL = time.Hour
+	// maxScanJobs caps the jobs kept. The oldest finished jobs are dropped
+	// first when it is reached.
+	maxScanJobs = 100
+)
+
+// ScanRequest is the request body to start a scan job. Empty fields scan
+// everything, as with the cost endpoints' query parameters.
+type ScanRequest struct {
+	Accounts      []string `json:"accounts,omitempty"`
+	Regions       []string `json:"regions,omitempty"`
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+}
+
+// ScanJob is the API response describing a scan job
+type ScanJob struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"` // queued, running, succeeded, or failed
+	Filters    types.AppliedFilters `json:"filters"`
+	Progress   ScanProgress         `json:"progress"`
+	CreatedAt  string               `json:"createdAt"`
+	StartedAt  string               `json:"startedAt,omitempty"`
+	FinishedAt string               `json:"finishedAt,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	ResultURL  string               `json:"resultUrl,omitempty"` // Relative to /api/v1; set once the job has finished
+}
+
+// ScanProgress counts the account and region pairs (or subscriptions) a scan
+// has finished. Total grows as each provider starts, and is 0 for a scan
+// served from the result cache.
+type ScanProgress struct {
+	Done    int     `json:"done"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+// errShuttingDown is returned by scanJobs.start once the server has started
+// shutting down, and is the error of jobs still queued at that point
+var errShuttingDown = errors.New("server is shutting down")
+
+// errScanQueueFull is returned by scanJobs.start when as many jobs as
+// allowed are already waiting to run
+var errScanQueueFull = errors.New("too many scans are queued")
+
+// scanInProgressError is returned by scanJobs.start when the reject policy
+// refuses a scan
+type scanInProgressError struct {
+	job ScanJob // The overlapping job
+}
+
+func (e *scanInProgressError) Error() string {
+	return "scan " + e.job.ID + " with an overlapping scope is already " + e.job.Status
+}
+
+// scanJob is a scan queued, running, or finished in the background. Fields
+// other than id, filters, progress, and created are guarded by scanJobs.mu.
+type scanJob struct {
+	id       string
+	filters  types.AppliedFilters
+	progress *cloud.Progress
+	created  time.Time
+
+	started  time.Time
+	finished time.Time
+	result   *types.CostResponse
+	err      error
+}
+
+// active reports whether the job is queued or running
+func (job *scanJob) active() bool {
+	return job.finished.IsZero()
+}
+
+// scanJobs runs scans in the background for the job API, keeping finished
+// jobs and their results for scanJobTTL. A scan requested while another with
+// an overlapping scope is active is rejected, queued, or coalesced with it,
+// and at most maxConcurrent scans run at once.
+type scanJobs struct {
+	scan          func(context.Context, types.AppliedFilters) (*types.CostResponse, error)
+	policy        string
+	maxConcurrent int // 0 = unlimited
+	maxQueued     int
+
+	mu     sync.Mutex
+	jobs   map[string]*scanJob
+	queue  []*scanJob      // Queued jobs, oldest first
+	closed bool            // No new jobs are accepted
+	ctx    context.Context // Parent of every job's context
+	cancel context.CancelFunc
+}
+
+func newScanJobs(cfg config.ScanJobsConfig, scan func(context.Context, types.AppliedFilters) (*types.CostResponse, error)) *scanJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scanJobs{
+		scan:          scan,
+		policy:        cfg.OverlapPolicy,
+		maxConcurrent: cfg.MaxConcurrent,
+		maxQueued:     cfg.MaxQueued,
+		jobs:          make(map[string]*scanJob),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// start queues a scan as a new job and runs it when the overlap policy and
+// concurrency limit allow. With the coalesce policy, an active job with the
+// same scope is returned instead. With the reject policy, an overlapping
+// active job is returned as a *scanInProgressError. errScanQueueFull is
+// returned if the job would have to wait behind maxQueued others, and
+// errShuttingDown once the jobs are closed.
+func (j *scanJobs) start(filters types.AppliedFilters) (ScanJob, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ScanJob{}, errShuttingDown
+	}
+
+	now := time.Now().UTC()
+	j.prune(now)
+	switch j.policy {
+	case config.ScanOverlapCoalesce:
+		if same := j.oldestActive(func(job *scanJob) bool { return resultCacheKey(job.filters) == resultCacheKey(filters) }); same != nil {
+			return same.view(), nil
+		}
+	case config.ScanOverlapReject:
+		if other := j.oldestActive(func(job *scanJob) bool { return scopesOverlap(job.filters, filters) }); other != nil {
+			return ScanJob{}, &scanInProgressError{job: other.view()}
+		}
+	}
+	if len(j.queue) >= j.maxQueued {
+		return ScanJob{}, errScanQueueFull
+	}
+
+	job := &scanJob{
+		id:       strings.ToLower(rand.Text()),
+		filters:  filters,
+		progress: &cloud.Progress{},
+		created:  now,
+	}
+	j.jobs[job.id] = job
+	j.queue = append(j.queue, job)
+	j.schedule()
+	return job.view(), nil
+}
+
+// oldestActive returns the oldest active job match accepts, or nil. Callers
+// must hold mu.
+func (j *scanJobs) oldestActive(match func(*scanJob) bool) *scanJob {
+	var oldest *scanJob
+	for _, job := range j.jobs {
+		if job.active() && match(job) && (oldest == nil || job.created.Before(oldest.created)) {
+			oldest = job
+		}
+	}
+	return oldest
+}
+
+// schedule starts queued jobs, oldest first, while there is room under the
+// concurrency limit. A job waits while a running job's scope overlaps it, so
+// the second scan is served from the cells the first one cached. Callers
+// must hold mu.
+func (j *scanJobs) schedule() {
+	running := 0
+	for _, job := range j.jobs {
+		if job.active() && !job.started.IsZero() {
+			running++
+		}
+	}
+
+	var waiting []*scanJob
+	for _, job := range j.queue {
+		if (j.maxConcurrent > 0 && running >= j.maxConcurrent) || j.blocked(job) {
+			waiting = append(waiting, job)
+			continue
+		}
+		job.started = time.Now().UTC()
+		running++
+		go j.run(job)
+	}
+	j.queue = waiting
+}
+
+// blocked reports whether a running job overlaps job. Callers must hold mu.
+func (j *scanJobs) blocked(job *scanJob) bool {
+	for _, other := range j.jobs {
+		if other != job && other.active() && !other.started.IsZero() && scopesOverlap(other.filters, job.filters) {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *scanJobs) run(job *scanJob) {
+	result, err := j.scan(cloud.WithProgress(j.ctx, job.progress), job.filters)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job.finished = time.Now().UTC()
+	job.result, job.err = result, err
+	j.schedule()
+}
+
+// get returns a job's status, and its result or error once it has finished
+func (j *scanJobs) get(id string) (ScanJob, *types.CostResponse, error, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok {
+		return ScanJob{}, nil, nil, false
+	}
+	return job.view(), job.result, job.err, true
+}
+
+// close stops accepting new jobs and fails the queued ones, leaving running
+// jobs to finish
+func (j *scanJobs) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.closed = true
+	now := time.Now().UTC()
+	for _, job := range j.queue {
+		job.finished, job.err = now, errShuttingDown
+	}
+	j.queue = nil
+}
+
+// stop cancels every running job and fails the queued ones
+func (j *scanJobs) stop() {
+	j.cancel()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now().UTC()
+	for _, job := range j.queue {
+		job.finished, job.err = now, j.ctx.Err()
+	}
+	j.queue = nil
+}
+
+// prune drops expired jobs and, if there are still too many, the oldest
+// finished ones. Callers must hold mu.
+func (j *scanJobs) prune(now time.Time) {
+	var finished []*scanJob
+	for id, job := range j.jobs {
+		switch {
+		case job.active():
+		case now.Sub(job.finished) > scanJobTTL:
+			delete(j.jobs, id)
+		default:
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(a, b int) bool { return finished[a].finished.Before(finished[b].finished) })
+	for i := 0; len(j.jobs) >= maxScanJobs && i < len(finished); i++ {
+		delete(j.jobs, finished[i].id)
+	}
+}
+
+// scopesOverlap reports whether scans with filters a and b share an account,
+// region, and resource type. An empty filter matches everything. Values are
+// compared like resultCacheKey does.
+func scopesOverlap(a, b types.AppliedFilters) bool {
+	overlap := func(x, y []string) bool {
+		if len(x) == 0 || len(y) == 0 {
+			return true
+		}
+		for _, v := range x {
+			if slices.ContainsFunc(y, func(w string) bool { return strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(w)) }) {
+				return true
+			}
+		}
+		return false
+	}
+	return overlap(a.Accounts, b.Accounts) && overlap(a.Regions, b.Regions) && overlap(a.ResourceTypes, b.ResourceTypes)
+}
+
+// view returns the job's API representation. Callers must hold scanJobs.mu.
+func (job *scanJob) view() ScanJob {
+	done, total := job.progress.Counts()
+	v := ScanJob{
+		ID:        job.id,
+		Status:    ScanJobQueued,
+		Filters:   job.filters,
+		Progress:  ScanProgress{Done: done, Total: total},
+		CreatedAt: job.created.Format(time.RFC3339),
+	}
+	if total > 0 {
+		v.Progress.Percent = math.Round(float64(done)/float64(total)*1000) / 10
+	}
+	if job.started.IsZero() && job.finished.IsZero() {
+		return v
+	}
+	if !job.started.IsZero() {
+		v.Status = ScanJobRunning
+		v.StartedAt = job.started.Format(time.RFC3339)
+	}
+	if job.finished.IsZero() {
+		return v
+	}
+
+	v.FinishedAt = job.finished.Format(time.RFC3339)
+	v.ResultURL = "/scans/" + job.id + "/result"
+	v.Progress.Percent = 100
+	if job.err != nil {
+		v.Status = ScanJobFailed
+		v.Error = job.err.Error()
+	} else {
+		v.Status = ScanJobSucceeded
+	}
+	return v
+}
+
+// CloseScanJobs stops accepting scan jobs and fails the queued ones at
+// shutdown, leaving running jobs to finish
+func (h *CostsHandler) CloseScanJobs() {
+	h.jobs.close()
+}
+
+// StopScanJobs cancels scan jobs still in progress, e.g. at shutdown
+func (h *CostsHandler) StopScanJobs() {
+	h.jobs.stop()
+}
+
+// StartScan starts a scan in the background and returns its job. The job's
+// status and progress are polled with GetScan, and its result fetched with
+// GetScanResult.
+func (h *CostsHandler) StartScan(w http.ResponseWriter, r *http.Request) {
+	var req ScanRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return
+	}
+
+	filters := types.AppliedFilters{Accounts: req.Accounts, Regions: req.Regions, ResourceTypes: req.ResourceTypes}
+	if !h.validFilters(w, r, filters) {
+		return
+	}
+
+	job, err := h.jobs.start(filters)
+	var inProgress *scanInProgressError
+	if errors.As(err, &inProgress) {
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeScanInProgress, inProgress.Error(), inProgress.job)
+		return
+	}
+	if errors.Is(err, errScanQueueFull) {
+		apierror.Write(w, r, http.StatusTooManyRequests, apierror.CodeRateLimited, "too many scans are queued; retry once some have finished", nil)
+		return
+	}
+	if errors.Is(err, errShuttingDown) {
+		apierror.Write(w, r, http.StatusServiceUnavailable, apierror.CodeShuttingDown, "the server is shutting down; retry against another instance", nil)
+		return
+	}
+	h.logger.Info("scan job requested",
+		"job", job.ID,
+		"status", job.Status,
+		"accounts", filters.Accounts,
+		"regions", filters.Regions,
+		"resources", filters.ResourceTypes)
+
+	w.Header().Set("Location", "scans/"+job.ID)
+	h.writeJSON(w, http.StatusAccepted, job)
+}
+
+// GetScan returns a scan job's status and progress
+func (h *CostsHandler) GetScan(w http.ResponseWriter, r *http.Request) {
+	job, _, _, ok := h.jobs.get(chi.URLParam(r, "id"))
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "scan job not found; finished jobs are kept for an hour", nil)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, job)
+}
+
+// GetScanResult returns a finished scan job's cost response. A job that is
+// still queued or running is returned as its status with 202 Accepted.
+func (h *CostsHandler) GetScanResult(w http.ResponseWriter, r *http.Request) {
+	job, result, err, ok := h.jobs.get(chi.URLParam(r, "id"))
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "scan job not found; finished jobs are kept for an hour", nil)
+		return
+	}
+	switch job.Status {
+	case ScanJobQueued, ScanJobRunning:
+		h.writeJSON(w, http.StatusAccepted, job)
+	case ScanJobFailed:
+		apierror.Internal(w, r, err)
+	default:
+		h.writeJSON(w, http.StatusOK, result)
+	}
+}
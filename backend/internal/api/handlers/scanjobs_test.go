@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// waitForJob polls a job until it finishes
+func waitForJob(t *testing.T, jobs *scanJobs, id string) (ScanJob, *types.CostResponse, error) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if job, result, err, _ := jobs.get(id); job.Status != ScanJobQueued && job.Status != ScanJobRunning {
+			return job, result, err
+		}
+	}
+	t.Fatalf("job %s did not finish", id)
+	return ScanJob{}, nil, nil
+}
+
+// blockingScan returns a scan function that reports starting each scan on
+// started and waits for release to finish it
+func blockingScan(started chan<- types.AppliedFilters, release <-chan struct{}) func(context.Context, types.AppliedFilters) (*types.CostResponse, error) {
+	return func(ctx context.Context, filters types.AppliedFilters) (*types.CostResponse, error) {
+		progress := cloud.ProgressFrom(ctx)
+		progress.Add(4)
+		progress.Done()
+		started <- filters
+		<-release
+		return &types.CostResponse{Filters: filters, TotalCost: 2}, nil
+	}
+}
+
+func TestScanJobReportsProgressAndResult(t *testing.T) {
+	started := make(chan types.AppliedFilters, 1)
+	release := make(chan struct{})
+	jobs := newScanJobs(config.ScanJobsConfig{OverlapPolicy: config.ScanOverlapQueue, MaxQueued: 10}, blockingScan(started, release))
+	defer jobs.stop()
+
+	job, err := jobs.start(types.AppliedFilters{Regions: []string{"us-east-1"}})
+	if err != nil || job.ResultURL != "" {
+		t.Fatalf("new job = %+v, %v; want no result URL", job, err)
+	}
+
+	<-started
+	running, _, _, ok := jobs.get(job.ID)
+	if !ok || running.Status != ScanJobRunning || running.Progress != (ScanProgress{Done: 1, Total: 4, Percent: 25}) {
+		t.Errorf("running job = %+v, want running at 1 of 4", running)
+	}
+
+	close(release)
+	done, result, err := waitForJob(t, jobs, job.ID)
+	if done.Status != ScanJobSucceeded || err != nil || result.TotalCost != 2 {
+		t.Errorf("finished job = %+v, %v, %v; want succeeded with the scan's result", done, result, err)
+	}
+	if done.ResultURL != "/scans/"+job.ID+"/result" || done.Progress.Percent != 100 {
+		t.Errorf("finished job = %+v, want a result URL and 100%%", done)
+	}
+}
+
+func TestScanJobFailure(t *testing.T) {
+	jobs := newScanJobs(config.ScanJobsConfig{OverlapPolicy: config.ScanOverlapQueue, MaxQueued: 10}, func(context.Context, types.AppliedFilters) (*types.CostResponse, error) {
+		return nil, errors.New("no credentials")
+	})
+	defer jobs.stop()
+
+	job, _ := jobs.start(types.AppliedFilters{})
+	done, _, err := waitForJob(t, jobs, job.ID)
+	if done.Status != ScanJobFailed || done.Error != "no credentials" || err == nil {
+		t.Errorf("failed job = %+v, %v", done, err)
+	}
+	if _, _, _, ok := jobs.get("unknown"); ok {
+		t.Error("get() found a job that was never started")
+	}
+}
+
+func TestScanJobOverlapPolicies(t *testing.T) {
+	east := types.AppliedFilters{Regions: []string{"us-east-1"}}
+	eastEC2 := types.AppliedFilters{Regions: []string{"US-EAST-1"}, ResourceTypes: []string{"ec2"}}
+	west := types.AppliedFilters{Regions: []string{"us-west-2"}}
+
+	t.Run("reject", func(t *testing.T) {
+		started, release := make(chan types.AppliedFilters, 2), make(chan struct{})
+		jobs := newScanJobs(config.ScanJobsConfig{OverlapPolicy: config.ScanOverlapReject, MaxQueued: 10}, blockingScan(started, release))
+		defer close(release)
+
+		first, _ := jobs.start(east)
+		_, err := jobs.start(eastEC2)
+		var inProgress *scanInProgressError
+		if !errors.As(err, &inProgress) || inProgress.job.ID != first.ID {
+			t.Errorf("overlapping scan error = %v, want the first job", err)
+		}
+		if _, err := jobs.start(west); err != nil {
+			t.Errorf("scan of another region was rejected: %v", err)
+		}
+	})
+
+	t.Run("coalesce", func(t *testing.T) {
+		started, release := make(chan types.AppliedFilters, 2), make(chan struct{})
+		jobs := newScanJobs(config.ScanJobsConfig{OverlapPolicy: config.ScanOverlapCoalesce, MaxQueued: 10}, blockingScan(started, release))
+
+		first, _ := jobs.start(east)
+		same, _ := jobs.start(types.AppliedFilters{Regions: []string{" US-East-1"}})
+		if same.ID != first.ID {
+			t.Errorf("same scope started job %s, want %s", same.ID, first.ID)
+		}
+		<-started
+		narrower, _ := jobs.start(eastEC2)
+		if narrower.ID == first.ID || narrower.Status != ScanJobQueued {
+			t.Errorf("overlapping scope = %+v, want a new queued job", narrower)
+		}
+		// The queued job is found even though the first one overlaps it too
+		if again, _ := jobs.start(eastEC2); again.ID != narrower.ID {
+			t.Errorf("same scope as the queued job started %s, want %s", again.ID, narrower.ID)
+		}
+
+		close(release)
+		if done, _, _ := waitForJob(t, jobs, narrower.ID); done.Status != ScanJobSucceeded {
+			t.Errorf("queued job = %+v, want it to run after the first", done)
+		}
+	})
+
+	t.Run("max concurrent", func(t *testing.T) {
+		started, release := make(chan types.AppliedFilters, 2), make(chan struct{})
+		jobs := newScanJobs(config.ScanJobsConfig{OverlapPolicy: config.ScanOverlapQueue, MaxConcurrent: 1, MaxQueued: 10}, blockingScan(started, release))
+
+		jobs.start(east)
+		second, _ := jobs.start(west)
+		<-started
+		if job, _, _, _ := jobs.get(second.ID); job.Status != ScanJobQueued {
+			t.Errorf("second scan = %+v, want queued behind the limit", job)
+		}
+		close(release)
+		waitForJob(t, jobs, second.ID)
+	})
+}
+
+func TestScanJobQueueLimit(t *testing.T) {
+	started, release := make(chan types.AppliedFilters, 1), make(chan struct{})
+	jobs := newScanJobs(config.ScanJobsConfig{OverlapPolicy: config.ScanOverlapQueue, MaxConcurrent: 1, MaxQueued: 1}, blockingScan(started, release))
+
+	running, _ := jobs.start(types.AppliedFilters{Regions: []string{"us-east-1"}})
+	<-started
+	queued, err := jobs.start(types.AppliedFilters{Regions: []string{"us-west-2"}})
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	if _, err := jobs.start(types.AppliedFilters{Regions: []string{"eu-west-1"}}); !errors.Is(err, errScanQueueFull) {
+		t.Errorf("start() with a full queue error = %v, want errScanQueueFull", err)
+	}
+
+	close(release)
+	waitForJob(t, jobs, running.ID)
+	waitForJob(t, jobs, queued.ID)
+	if _, err := jobs.start(types.AppliedFilters{Regions: []string{"eu-west-1"}}); err != nil {
+		t.Errorf("start() once the queue drained error = %v", err)
+	}
+}
+
+func TestScanJobsClose(t *testing.T) {
+	started, release := make(chan types.AppliedFilters, 1), make(chan struct{})
+	jobs := newScanJobs(config.ScanJobsConfig{OverlapPolicy: config.ScanOverlapQueue, MaxConcurrent: 1, MaxQueued: 10}, blockingScan(started, release))
+	defer jobs.stop()
+
+	running, _ := jobs.start(types.AppliedFilters{Regions: []string{"us-east-1"}})
+	queued, _ := jobs.start(types.AppliedFilters{Regions: []string{"us-west-2"}})
+	<-started
+	jobs.close()
+
+	if _, err := jobs.start(types.AppliedFilters{}); !errors.Is(err, errShuttingDown) {
+		t.Errorf("start() after close error = %v, want errShuttingDown", err)
+	}
+	if job, _, err, _ := jobs.get(queued.ID); job.Status != ScanJobFailed || !errors.Is(err, errShuttingDown) {
+		t.Errorf("queued job = %+v, %v; want failed by the shutdown", job, err)
+	}
+	close(release)
+	if done, _, _ := waitForJob(t, jobs, running.ID); done.Status != ScanJobSucceeded {
+		t.Errorf("running job = %+v, want it left to finish", done)
+	}
+}
+
+func TestInFlightWait(t *testing.T) {
+	var f inFlight
+	if !f.wait(context.Background()) {
+		t.Fatal("wait() with nothing in flight should return true")
+	}
+
+	f.add()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if f.wait(ctx) {
+		t.Error("wait() returned true with a scan in flight")
+	}
+
+	f.add()
+	done := make(chan bool)
+	go func() { done <- f.wait(context.Background()) }()
+	f.done()
+	f.done()
+	if !<-done {
+		t.Error("wait() should return true once every scan is done")
+	}
+}
+
+func TestScanJobsPruneExpiredAndOldest(t *testing.T) {
+	jobs := newScanJobs(config.DefaultConfig().Server.ScanJobs, nil)
+	now := time.Now()
+	jobs.jobs["expired"] = &scanJob{id: "expired", progress: &cloud.Progress{}, finished: now.Add(-2 * scanJobTTL)}
+	jobs.jobs["running"] = &scanJob{id: "running", progress: &cloud.Progress{}}
+	for i := 0; i < maxScanJobs; i++ {
+		id := string(rune('a'+i%26)) + time.Duration(i).String()
+		jobs.jobs[id] = &scanJob{id: id, progress: &cloud.Progress{}, finished: now.Add(-time.Duration(maxScanJobs-i) * time.Second)}
+	}
+
+	jobs.prune(now)
+	if _, ok := jobs.jobs["expired"]; ok {
+		t.Error("expired job was kept")
+	}
+	if _, ok := jobs.jobs["running"]; !ok {
+		t.Error("running job was dropped")
+	}
+	if len(jobs.jobs) != maxScanJobs-1 {
+		t.Errorf("kept %d jobs, want room for one more (%d)", len(jobs.jobs), maxScanJobs-1)
+	}
+	if _, ok := jobs.jobs["a0s"]; ok {
+		t.Error("oldest finished job was kept")
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/recommend"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// applyMissingPrices costs the resources whose price lookup failed with the
+// pricing.missingPrice policy and lists them in the response. Resources that
+// were only partly priced keep the prices that were found.
+func applyMissingPrices(resp *types.CostResponse, policy string) *types.CostResponse {
+	var unpriced []snapshot.Resource
+	for _, r := range snapshot.Resources(resp) {
+		if r.PriceSource == pricing.PriceSourceMissing {
+			unpriced = append(unpriced, r)
+		}
+	}
+	if len(unpriced) == 0 {
+		return resp
+	}
+
+	out := *resp
+	handling := types.UnpricedZero
+	estimated := make(map[string]types.CostValue)
+	switch policy {
+	case config.MissingPriceOmit:
+		handling = types.UnpricedOmitted
+		out = *omitUnpriced(&out)
+		recomputeTotals(&out)
+	case config.MissingPriceEstimate:
+		out.EC2Instances = estimateEC2(resp.EC2Instances, estimated)
+		out.RDSInstances = estimateRDS(resp.RDSInstances, estimated)
+		if len(estimated) > 0 {
+			recomputeTotals(&out)
+		}
+	case config.MissingPriceUnknown:
+		handling = types.UnpricedUnknown
+		// The status is left alone: a scan whose discovery succeeded is
+		// still cached, so missing prices don't force a rescan per request
+		out.Diagnostics = append(slices.Clone(out.Diagnostics), types.Diagnostic{
+			Level:     "warning",
+			Operation: "pricing",
+			Message:   fmt.Sprintf("%d resources have no price, so the total cost is understated", len(unpriced)),
+		})
+	}
+
+	out.UnpricedCount = len(unpriced)
+	out.UnpricedResources = make([]types.UnpricedResource, len(unpriced))
+	for i, r := range unpriced {
+		u := types.UnpricedResource{
+			ResourceType: r.Type, ResourceID: r.ID, Name: r.Name,
+			AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+			HourlyCost: r.HourlyCost, Handling: handling,
+		}
+		if cost, ok := estimated[r.Key()]; ok {
+			u.HourlyCost, u.Handling = cost, types.UnpricedEstimated
+		}
+		out.UnpricedResources[i] = u
+	}
+	return &out
+}
+
+// recomputeTotals recomputes a response's total cost and summaries from its
+// resources
+func recomputeTotals(resp *types.CostResponse) {
+	resources := snapshot.Resources(resp)
+	resp.TotalCost = 0
+	for _, r := range resources {
+		resp.TotalCost = resp.TotalCost.Add(r.HourlyCost)
+	}
+	resp.Accounts = aggregate.AccountSummaries(resources)
+	resp.Regions = aggregate.RegionSummaries(resources)
+}
+
+// omitUnpriced returns a copy of resp without the resources whose price
+// lookup failed
+func omitUnpriced(resp *types.CostResponse) *types.CostResponse {
+	return snapshot.KeepResources(resp, func(r snapshot.Resource) bool {
+		return r.PriceSource != pricing.PriceSourceMissing
+	})
+}
+
+// familyRates averages the hourly instance cost per normalized unit of priced
+// instances by region, family, and the settings their price depends on
+type familyRates map[string]struct {
+	total types.CostValue
+	count int
+}
+
+func (f familyRates) add(key string, components []types.CostComponent, units float64) {
+	var cost types.CostValue
+	for _, c := range components {
+		// Storage and surcharges don't scale with size
+		if c.Name == "compute" || c.Name == "multi-az standby" {
+			cost = cost.Add(c.HourlyCost)
+		}
+	}
+	if cost <= 0 {
+		return
+	}
+	rate := f[key]
+	rate.total = rate.total.Add(cost / types.CostValue(units))
+	rate.count++
+	f[key] = rate
+}
+
+func (f familyRates) rate(key string) (types.CostValue, bool) {
+	rate, ok := f[key]
+	if !ok {
+		return 0, false
+	}
+	return rate.total / types.CostValue(rate.count), true
+}
+
+// estimateEC2 prices running EC2 instances that have no price from the
+// other instances of their family, region, and tenancy. The cost of each
+// estimated instance is recorded in estimated by resource key.
+func estimateEC2(instances []types.EC2Instance, estimated map[string]types.CostValue) []types.EC2Instance {
+	rates := make(familyRates)
+	key := func(inst types.EC2Instance) (string, float64, bool) {
+		family, units, flexible := recommend.InstanceUnits(inst.InstanceType)
+		return strings.Join([]string{inst.Region, family, inst.Tenancy}, "|"), units, flexible
+	}
+	for _, inst := range instances {
+		if k, units, flexible := key(inst); flexible && inst.PriceSource != pricing.PriceSourceMissing {
+			rates.add(k, inst.CostComponents, units)
+		}
+	}
+
+	out := make([]types.EC2Instance, len(instances))
+	copy(out, instances)
+	for i := range out {
+		inst := &out[i]
+		if inst.PriceSource != pricing.PriceSourceMissing || inst.HourlyCost > 0 || inst.State != "running" {
+			continue
+		}
+		k, units, flexible := key(*inst)
+		rate, ok := rates.rate(k)
+		if !flexible || !ok {
+			continue
+		}
+		inst.CostComponents = []types.CostComponent{types.HourlyComponent("compute (estimated)", "normalized unit", units, rate)}
+		inst.HourlyCost = types.SumComponents(inst.CostComponents)
+		inst.PriceSource = pricing.PriceSourceEstimate
+		estimated[snapshot.Resource{Type: "ec2", AccountID: inst.AccountID, Region: inst.Region, ID: inst.InstanceID}.Key()] = inst.HourlyCost
+	}
+	return out
+}
+
+// estimateRDS prices RDS instances that have no price from the other
+// instances of their family, region, engine, and deployment. Storage isn't
+// estimated.
+func estimateRDS(instances []types.RDSInstance, estimated map[string]types.CostValue) []types.RDSInstance {
+	rates := make(familyRates)
+	key := func(inst types.RDSInstance) (string, float64, bool) {
+		family, units, flexible := recommend.InstanceUnits(strings.TrimPrefix(inst.InstanceClass, "db."))
+		return strings.Join([]string{inst.Region, family, inst.Engine, fmt.Sprint(inst.MultiAZ)}, "|"), units, flexible
+	}
+	for _, inst := range instances {
+		if k, units, flexible := key(inst); flexible && inst.PriceSource != pricing.PriceSourceMissing {
+			rates.add(k, inst.CostComponents, units)
+		}
+	}
+
+	out := make([]types.RDSInstance, len(instances))
+	copy(out, instances)
+	for i := range out {
+		inst := &out[i]
+		if inst.PriceSource != pricing.PriceSourceMissing || inst.HourlyCost > 0 {
+			continue
+		}
+		k, units, flexible := key(*inst)
+		rate, ok := rates.rate(k)
+		if !flexible || !ok {
+			continue
+		}
+		inst.CostComponents = []types.CostComponent{types.HourlyComponent("compute (estimated)", "normalized unit", units, rate)}
+		inst.HourlyCost = types.SumComponents(inst.CostComponents)
+		inst.PriceSource = pricing.PriceSourceEstimate
+		estimated[snapshot.Resource{Type: "rds", AccountID: inst.AccountID, Region: inst.Region, ID: inst.DBInstanceID}.Key()] = inst.HourlyCost
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func unpricedResponse() *types.CostResponse {
+	return &types.CostResponse{
+		Status:    types.ResponseStatusOK,
+		TotalCost: 0.292,
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-large", AccountID: "1", Region: "us-east-1", InstanceType: "m5.large", Tenancy: "default", State: "running",
+				HourlyCost: 0.096, PriceSource: pricing.PriceSourceCache, CostComponents: []types.CostComponent{types.HourlyComponent("compute", "hour", 1, 0.096)}},
+			{InstanceID: "i-2xlarge", AccountID: "1", Region: "us-east-1", InstanceType: "m5.2xlarge", Tenancy: "default", State: "running",
+				PriceSource: pricing.PriceSourceMissing},
+			{InstanceID: "i-other", AccountID: "1", Region: "us-east-1", InstanceType: "c5.large", Tenancy: "default", State: "running",
+				PriceSource: pricing.PriceSourceMissing},
+		},
+		EBSVolumes: []types.EBSVolume{
+			{VolumeID: "vol-1", AccountID: "1", Region: "us-east-1", HourlyCost: 0.196, PriceSource: pricing.PriceSourceAPI},
+		},
+	}
+}
+
+func TestApplyMissingPrices(t *testing.T) {
+	resp := unpricedResponse()
+	got := applyMissingPrices(resp, config.MissingPriceZero)
+	if got.UnpricedCount != 2 || got.TotalCost != resp.TotalCost || got.UnpricedResources[0].Handling != types.UnpricedZero {
+		t.Errorf("zero: %d unpriced, total %v, %+v", got.UnpricedCount, got.TotalCost, got.UnpricedResources)
+	}
+
+	got = applyMissingPrices(resp, config.MissingPriceOmit)
+	if len(got.EC2Instances) != 1 || got.UnpricedCount != 2 || got.UnpricedResources[1].Handling != types.UnpricedOmitted {
+		t.Errorf("omit: %d instances, %+v", len(got.EC2Instances), got.UnpricedResources)
+	}
+	if len(resp.EC2Instances) != 3 {
+		t.Error("omit modified the cached response")
+	}
+
+	got = applyMissingPrices(resp, config.MissingPriceEstimate)
+	if cost := got.EC2Instances[1].HourlyCost; !almostEqual(float64(cost), 0.384) || got.EC2Instances[1].PriceSource != pricing.PriceSourceEstimate {
+		t.Errorf("estimate: m5.2xlarge = %v from %q, want 0.384 from 4 larges", cost, got.EC2Instances[1].PriceSource)
+	}
+	if got.UnpricedResources[0].Handling != types.UnpricedEstimated || got.UnpricedResources[1].Handling != types.UnpricedZero {
+		t.Errorf("estimate: handling = %+v, want the m5 estimated and the c5 at zero", got.UnpricedResources)
+	}
+	if !almostEqual(float64(got.TotalCost), 0.676) || resp.EC2Instances[1].HourlyCost != 0 {
+		t.Errorf("estimate: total %v, want 0.676 without changing the cached response", got.TotalCost)
+	}
+
+	got = applyMissingPrices(resp, config.MissingPriceUnknown)
+	if got.Status != types.ResponseStatusOK || len(got.Diagnostics) != 1 || got.UnpricedResources[0].Handling != types.UnpricedUnknown {
+		t.Errorf("mark-unknown: status %q, %d diagnostics, %+v", got.Status, len(got.Diagnostics), got.UnpricedResources)
+	}
+}
+
+func TestApplyMissingPricesOmitsOtherTypes(t *testing.T) {
+	resp := &types.CostResponse{
+		TotalCost: 0.045,
+		NATGateways: []types.NATGateway{
+			{ID: "nat-1", AccountID: "1", Region: "us-east-1", HourlyCost: 0.045, PriceSource: pricing.PriceSourceAPI},
+			{ID: "nat-2", AccountID: "1", Region: "us-east-1", PriceSource: pricing.PriceSourceMissing},
+		},
+		Lambdas: []types.LambdaFunction{
+			{FunctionARN: "arn:aws:lambda:us-east-1:1:function:resize", AccountID: "1", Region: "us-east-1", PriceSource: pricing.PriceSourceMissing},
+		},
+	}
+	got := applyMissingPrices(resp, config.MissingPriceOmit)
+	if len(got.NATGateways) != 1 || got.NATGateways[0].ID != "nat-1" || len(got.Lambdas) != 0 {
+		t.Fatalf("kept %+v and %+v, want only nat-1", got.NATGateways, got.Lambdas)
+	}
+	if got.UnpricedCount != 2 || got.TotalCost.Round() != 0.045 || len(got.Regions) != 1 {
+		t.Errorf("%d unpriced, total %v, regions %+v", got.UnpricedCount, got.TotalCost, got.Regions)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// maxViewNameLength caps view names so they fit in the dashboard's view picker
+const maxViewNameLength = 100
+
+// ViewList is the API response for the saved views
+type ViewList struct {
+	Views []snapshot.View `json:"views"`
+}
+
+// ListViews returns the saved views
+func (h *CostsHandler) ListViews(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, ViewList{Views: h.snapshots.Views()})
+}
+
+// GetView returns one saved view
+func (h *CostsHandler) GetView(w http.ResponseWriter, r *http.Request) {
+	view, ok := h.snapshots.View(chi.URLParam(r, "id"))
+	if !ok {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, snapshot.ErrViewNotFound.Error(), nil)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, view)
+}
+
+// CreateView saves a new view
+func (h *CostsHandler) CreateView(w http.ResponseWriter, r *http.Request) {
+	spec, ok := h.decodeView(w, r)
+	if !ok {
+		return
+	}
+	view, err := h.snapshots.CreateView(spec)
+	if err != nil {
+		h.logger.Error("failed to save view", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, view)
+}
+
+// UpdateView replaces a saved view
+func (h *CostsHandler) UpdateView(w http.ResponseWriter, r *http.Request) {
+	spec, ok := h.decodeView(w, r)
+	if !ok {
+		return
+	}
+	view, err := h.snapshots.UpdateView(chi.URLParam(r, "id"), spec)
+	if errors.Is(err, snapshot.ErrViewNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to save view", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, view)
+}
+
+// DeleteView removes a saved view
+func (h *CostsHandler) DeleteView(w http.ResponseWriter, r *http.Request) {
+	err := h.snapshots.DeleteView(chi.URLParam(r, "id"))
+	if errors.Is(err, snapshot.ErrViewNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to delete view", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeView reads and validates a view from the request body. Filters are
+// checked the same way as the cost endpoints' query parameters. It writes an
+// error and returns false if the view is invalid.
+func (h *CostsHandler) decodeView(w http.ResponseWriter, r *http.Request) (snapshot.ViewSpec, bool) {
+	var spec snapshot.ViewSpec
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "invalid request body: "+err.Error(), nil)
+		return spec, false
+	}
+	if err := validateViewSpec(&spec); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error(), nil)
+		return spec, false
+	}
+	if len(spec.GroupBy) > 0 {
+		if err := aggregate.ValidateDimensions(spec.GroupBy); err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidFilter, err.Error(), nil)
+			return spec, false
+		}
+	}
+	filters := types.AppliedFilters{Accounts: spec.Accounts, Regions: spec.Regions, ResourceTypes: spec.ResourceTypes}
+	return spec, h.validFilters(w, r, filters)
+}
+
+// validateViewSpec trims a view's name and tags and checks they are set
+func validateViewSpec(spec *snapshot.ViewSpec) error {
+	spec.Name = strings.TrimSpace(spec.Name)
+	if spec.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(spec.Name) > maxViewNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxViewNameLength)
+	}
+	tags := make(map[string]string, len(spec.Tags))
+	for key, value := range spec.Tags {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return errors.New("tag keys must not be empty")
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+	spec.Tags = tags
+	return nil
+}
+
+func (h *CostsHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetVPCCosts scans the resource types that live in a VPC and totals their
+// costs by VPC and subnet
+func (h *CostsHandler) GetVPCCosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+	if len(filters.ResourceTypes) == 0 {
+		enabled := h.clouds.ResourceTypes()
+		for _, rt := range aggregate.VPCResourceTypes {
+			if slices.Contains(enabled, rt) {
+				filters.ResourceTypes = append(filters.ResourceTypes, rt)
+			}
+		}
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	vpcs := aggregate.VPCCosts(snapshot.Resources(response))
+	result := &types.VPCCostResponse{
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Status:      response.Status,
+		Diagnostics: response.Diagnostics,
+		Currency:    "USD",
+		VPCs:        vpcs,
+		Filters:     filters,
+	}
+	for _, v := range vpcs {
+		result.TotalCost = result.TotalCost.Add(v.TotalCost)
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
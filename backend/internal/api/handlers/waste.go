@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+	"github.com/johnjeffers/awscogs/backend/internal/waste"
+)
+
+// GetWaste scans the resource types the waste rules inspect and returns the
+// resources flagged as waste
+func (h *CostsHandler) GetWaste(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, ok := h.parseFilters(w, r, parseArrayParam(r, "resource")...)
+	if !ok {
+		return
+	}
+	if len(filters.ResourceTypes) == 0 {
+		enabled := h.clouds.ResourceTypes()
+		for _, rt := range waste.ResourceTypes() {
+			if slices.Contains(enabled, rt) {
+				filters.ResourceTypes = append(filters.ResourceTypes, rt)
+			}
+		}
+	}
+
+	response, err := h.scan(ctx, filters)
+	if err != nil {
+		h.logger.Error("failed to discover resources", "error", err)
+		apierror.Internal(w, r, err)
+		return
+	}
+
+	findings := waste.Find(response, waste.Options{
+		UnusedSecretDays:    h.config.Waste.UnusedSecretDays,
+		StoppedInstanceDays: h.config.Waste.StoppedInstanceDays,
+	})
+	result := &waste.Report{
+		Timestamp:         time.Now().UTC().Format(time.RFC3339),
+		Status:            response.Status,
+		Diagnostics:       response.Diagnostics,
+		Currency:          "USD",
+		Findings:          findings,
+		IgnoredCount:      response.IgnoredCount,
+		IgnoredHourlyCost: response.IgnoredCost,
+		Filters:           filters,
+	}
+	for _, f := range findings {
+		result.TotalHourlyCost = result.TotalHourlyCost.Add(f.HourlyCost)
+	}
+	if result.Status == "" {
+		result.Status = types.ResponseStatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
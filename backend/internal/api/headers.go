@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/cors"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// corsHandler returns CORS middleware for the configured origins, or nil if
+// no cross-origin callers are allowed
+func corsHandler(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil
+	}
+	return cors.Handler(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   []string{"Link", "X-Request-Id"},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           300,
+	})
+}
+
+// securityHeaders adds the configured security headers to every response
+func securityHeaders(cfg config.SecurityHeadersConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			if cfg.HSTSMaxAgeSeconds > 0 {
+				h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(cfg.HSTSMaxAgeSeconds)+"; includeSubDomains")
+			}
+			if cfg.ContentSecurityPolicy != "" {
+				h.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// swaggerUICSP is the policy for the API docs page, which loads Swagger UI
+// from unpkg.com and starts it with an inline script
+var swaggerUICSP = func() string {
+	start := strings.Index(swaggerUIHTML, "<script>") + len("<script>")
+	end := strings.LastIndex(swaggerUIHTML, "</script>")
+	sum := sha256.Sum256([]byte(swaggerUIHTML[start:end]))
+	hash := "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+	return "default-src 'self'; script-src 'self' https://unpkg.com " + hash + "; style-src 'self' 'unsafe-inline' https://unpkg.com; " +
+		"img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'; base-uri 'self'"
+}()
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestCORSAllowsOnlyConfiguredOrigins(t *testing.T) {
+	if corsHandler(config.CORSConfig{}) != nil {
+		t.Fatal("expected no CORS middleware without allowed origins")
+	}
+
+	handler := corsHandler(config.CORSConfig{AllowedOrigins: []string{"https://finops.example.com"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for origin, allowed := range map[string]bool{"https://finops.example.com": true, "https://evil.example.com": false} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/costs", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin") == origin; got != allowed {
+			t.Errorf("origin %s allowed = %v, want %v", origin, got, allowed)
+		}
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := config.SecurityHeadersConfig{Enabled: true, HSTSMaxAgeSeconds: 31536000, ContentSecurityPolicy: config.DefaultContentSecurityPolicy}
+	handler := securityHeaders(cfg)(http.HandlerFunc(swaggerUIHandler))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q", got)
+	}
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=31536000; includeSubDomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, "https://unpkg.com 'sha256-") {
+		t.Errorf("expected the docs page to allow Swagger UI, got CSP %q", got)
+	}
+
+	// Without a policy the docs page doesn't add one
+	rec = httptest.NewRecorder()
+	http.HandlerFunc(swaggerUIHandler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+	if got := rec.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("expected no CSP, got %q", got)
+	}
+}
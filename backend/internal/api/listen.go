@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// listen opens a listener on the Unix socket if server.socketPath is set, and
+// otherwise one for each server.bindAddress entry, or one on the port on all
+// interfaces if there are none. An interface name listens on each of the
+// interface's addresses, except IPv6 link-local ones.
+func listen(cfg config.ServerConfig) ([]net.Listener, error) {
+	if cfg.SocketPath != "" {
+		l, err := listenUnix(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+
+	addrs, err := bindAddresses(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenUnix listens on the Unix socket at cfg.SocketPath, replacing a stale
+// socket left by a server that didn't shut down cleanly. The socket file is
+// removed when the listener closes.
+func listenUnix(cfg config.ServerConfig) (net.Listener, error) {
+	mode, err := cfg.SocketFileMode()
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(cfg.SocketPath); err == nil {
+		if info.Mode().Type() != os.ModeSocket {
+			return nil, fmt.Errorf("%s exists and isn't a socket", cfg.SocketPath)
+		}
+		// A socket that refuses connections has no server behind it
+		if conn, err := net.Dial("unix", cfg.SocketPath); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is listening on %s", cfg.SocketPath)
+		}
+		if err := os.Remove(cfg.SocketPath); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", cfg.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(cfg.SocketPath, mode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("setting socket permissions: %w", err)
+	}
+	return l, nil
+}
+
+// bindAddresses returns the host:port addresses to listen on
+func bindAddresses(cfg config.ServerConfig) ([]string, error) {
+	entries := cfg.BindAddresses()
+	if len(entries) == 0 {
+		return []string{":" + strconv.Itoa(cfg.Port)}, nil
+	}
+
+	var addrs []string
+	for _, entry := range entries {
+		host, port := config.SplitBindAddress(entry, cfg.Port)
+		if net.ParseIP(host) != nil {
+			addrs = append(addrs, net.JoinHostPort(host, port))
+			continue
+		}
+		iface, err := net.InterfaceByName(host)
+		if err != nil {
+			// Not an interface: a host name, resolved by net.Listen
+			addrs = append(addrs, net.JoinHostPort(host, port))
+			continue
+		}
+		ifaceAddrs, err := iface.Addrs()
+		if err != nil {
+			return nil, fmt.Errorf("listing addresses of interface %s: %w", host, err)
+		}
+		var found bool
+		for _, a := range ifaceAddrs {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok || (ipNet.IP.To4() == nil && ipNet.IP.IsLinkLocalUnicast()) {
+				continue
+			}
+			addrs = append(addrs, net.JoinHostPort(ipNet.IP.String(), port))
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("interface %s has no addresses to listen on", host)
+		}
+	}
+	return addrs, nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestBindAddresses(t *testing.T) {
+	got, err := bindAddresses(config.ServerConfig{Port: 8080})
+	if err != nil || !slices.Equal(got, []string{":8080"}) {
+		t.Fatalf("default = %q, %v, want all interfaces", got, err)
+	}
+
+	got, err = bindAddresses(config.ServerConfig{Port: 8080, BindAddress: "127.0.0.1,::,localhost:9090"})
+	if err != nil || !slices.Equal(got, []string{"127.0.0.1:8080", "[::]:8080", "localhost:9090"}) {
+		t.Fatalf("addresses = %q, %v", got, err)
+	}
+
+	// Listen on the loopback interface by name, wherever it's called
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		got, err = bindAddresses(config.ServerConfig{Port: 8080, BindAddress: iface.Name})
+		if err != nil || !slices.Contains(got, "127.0.0.1:8080") {
+			t.Fatalf("interface %s = %q, %v, want its IPv4 loopback address", iface.Name, got, err)
+		}
+		break
+	}
+}
+
+func TestListenOpensEachAddress(t *testing.T) {
+	listeners, err := listen(config.ServerConfig{BindAddress: "127.0.0.1:0,localhost:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	if len(listeners) != 2 {
+		t.Fatalf("got %d listeners, want 2", len(listeners))
+	}
+	for _, l := range listeners {
+		if !l.Addr().(*net.TCPAddr).IP.IsLoopback() {
+			t.Errorf("listener on %s, want loopback", l.Addr())
+		}
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "awscogs.sock")
+	cfg := config.ServerConfig{SocketPath: path, SocketMode: "0600"}
+
+	// A stale socket with no server behind it is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listeners, err := listen(cfg)
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	l := listeners[0]
+	defer l.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://awscogs/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want ok", body)
+	}
+
+	// A live socket isn't taken over
+	if _, err := listen(cfg); err == nil {
+		t.Fatal("expected an error while another server listens on the socket")
+	}
+
+	file := filepath.Join(t.TempDir(), "notasocket")
+	os.WriteFile(file, nil, 0o600)
+	if _, err := listen(config.ServerConfig{SocketPath: file}); err == nil {
+		t.Fatal("expected an error for a path that isn't a socket")
+	}
+}
+
+func TestServerListenAddresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "awscogs.sock")
+	for _, tt := range []struct {
+		server config.ServerConfig
+		want   func(string) bool
+	}{
+		{config.ServerConfig{SocketPath: path}, func(addr string) bool { return addr == "unix:"+path }},
+		{config.ServerConfig{BindAddress: "127.0.0.1:0"}, func(addr string) bool {
+			return strings.HasPrefix(addr, "127.0.0.1:") && addr != "127.0.0.1:0"
+		}},
+	} {
+		s := &Server{config: &config.Config{Server: tt.server}}
+		addrs, err := s.Listen()
+		if err != nil {
+			t.Fatalf("Listen() error = %v", err)
+		}
+		for _, l := range s.listeners {
+			l.Close()
+		}
+		if len(addrs) != 1 || !tt.want(addrs[0]) {
+			t.Errorf("Listen() with %+v = %q", tt.server, addrs)
+		}
+	}
+}
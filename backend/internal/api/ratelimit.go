@@ -0,0 +1,135 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// clientBucket is a token bucket for one client
+type clientBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// maxRateLimitClients is how many clients get their own bucket. Past it, new
+// clients share overflowKey's until a sweep makes room.
+const maxRateLimitClients = 10000
+
+// overflowKey is the bucket shared by clients past maxRateLimitClients
+const overflowKey = "overflow"
+
+// rateLimiter keeps one token bucket per client. Buckets that have refilled
+// are dropped, so idle clients don't accumulate.
+type rateLimiter struct {
+	rate       float64 // Tokens per second
+	burst      float64
+	keyHeader  string
+	keys       map[string]bool // KeyHeader values to use, if not trustKey
+	trustKey   bool            // Use any KeyHeader value
+	maxClients int
+
+	mu        sync.Mutex
+	clients   map[string]*clientBucket
+	lastSweep time.Time
+}
+
+// newRateLimiter returns a limiter for cfg, or nil if it is unlimited
+func newRateLimiter(cfg config.APIRateLimitConfig) *rateLimiter {
+	if cfg.RequestsPerMinute <= 0 {
+		return nil
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = cfg.RequestsPerMinute
+	}
+	keys := make(map[string]bool, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		keys[key] = true
+	}
+	return &rateLimiter{
+		rate:       float64(cfg.RequestsPerMinute) / 60,
+		burst:      float64(burst),
+		keyHeader:  cfg.KeyHeader,
+		keys:       keys,
+		trustKey:   cfg.TrustKeyHeader,
+		maxClients: maxRateLimitClients,
+		clients:    make(map[string]*clientBucket),
+	}
+}
+
+// allow takes a token from key's bucket at now. If none is left it returns
+// false and how long until one is.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.clients[key]
+	if !ok && len(l.clients) >= l.maxClients {
+		key = overflowKey
+		b, ok = l.clients[key]
+	}
+	if !ok {
+		b = &clientBucket{tokens: l.burst, last: now}
+		l.clients[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep drops buckets that would be full by now, at most once a minute
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.clients {
+		if now.Sub(b.last) >= refill {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// clientKey identifies the client making r: by its KeyHeader value if that's
+// trusted, otherwise by IP address
+func (l *rateLimiter) clientKey(r *http.Request) string {
+	if l.keyHeader != "" {
+		if key := r.Header.Get(l.keyHeader); key != "" && (l.trustKey || l.keys[key]) {
+			return "key:" + key
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// handler returns middleware that writes a 429 with Retry-After when a
+// client is over its limit
+func (l *rateLimiter) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, retryAfter := l.allow(l.clientKey(r), time.Now())
+		if !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			apierror.Write(w, r, http.StatusTooManyRequests, apierror.CodeRateLimited,
+				"too many requests; try again in "+strconv.Itoa(seconds)+"s", map[string]int{"retryAfterSeconds": seconds})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
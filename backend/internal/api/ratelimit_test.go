@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestRateLimiterAllowsBurstThenRefills(t *testing.T) {
+	l := newRateLimiter(config.APIRateLimitConfig{RequestsPerMinute: 60, Burst: 2})
+	now := time.Now()
+
+	for i := range 2 {
+		if ok, _ := l.allow("ip:10.0.0.1", now); !ok {
+			t.Fatalf("request %d should be within the burst", i+1)
+		}
+	}
+	ok, retryAfter := l.allow("ip:10.0.0.1", now)
+	if ok || retryAfter != time.Second {
+		t.Fatalf("allow() = %v, %v; want false, 1s", ok, retryAfter)
+	}
+	if ok, _ := l.allow("ip:10.0.0.2", now); !ok {
+		t.Fatal("other clients should have their own bucket")
+	}
+	if ok, _ := l.allow("ip:10.0.0.1", now.Add(time.Second)); !ok {
+		t.Fatal("expected a token after refilling for a second")
+	}
+
+	// Full buckets are dropped by the next sweep
+	l.allow("ip:10.0.0.3", now.Add(2*time.Minute))
+	if len(l.clients) != 1 {
+		t.Fatalf("expected idle clients to be swept, got %d", len(l.clients))
+	}
+}
+
+func TestRateLimiterHandler(t *testing.T) {
+	if newRateLimiter(config.APIRateLimitConfig{}) != nil {
+		t.Fatal("expected no limiter without a limit")
+	}
+
+	l := newRateLimiter(config.APIRateLimitConfig{RequestsPerMinute: 1, KeyHeader: "X-API-Key", Keys: []string{"dashboard"}})
+	handler := l.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/costs", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(""); rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d", rec.Code)
+	}
+	rec := request("")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("second request status = %d, Retry-After = %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := request("dashboard"); rec.Code != http.StatusOK {
+		t.Fatalf("expected a keyed client to be limited separately, got %d", rec.Code)
+	}
+	if rec := request("rotated"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected an unknown key to be limited by IP, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiterClientKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/costs", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-API-Key", "anything")
+
+	tests := []struct {
+		cfg  config.APIRateLimitConfig
+		want string
+	}{
+		{config.APIRateLimitConfig{KeyHeader: "X-API-Key"}, "ip:10.0.0.1"},
+		{config.APIRateLimitConfig{KeyHeader: "X-API-Key", Keys: []string{"dashboard"}}, "ip:10.0.0.1"},
+		{config.APIRateLimitConfig{KeyHeader: "X-API-Key", Keys: []string{"anything"}}, "key:anything"},
+		{config.APIRateLimitConfig{KeyHeader: "X-API-Key", TrustKeyHeader: true}, "key:anything"},
+	}
+	for _, tt := range tests {
+		tt.cfg.RequestsPerMinute = 60
+		if got := newRateLimiter(tt.cfg).clientKey(req); got != tt.want {
+			t.Errorf("clientKey() with %+v = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterCapsClients(t *testing.T) {
+	l := newRateLimiter(config.APIRateLimitConfig{RequestsPerMinute: 60, Burst: 1})
+	l.maxClients = 2
+	now := time.Now()
+
+	l.allow("ip:10.0.0.1", now)
+	l.allow("ip:10.0.0.2", now)
+	if ok, _ := l.allow("ip:10.0.0.3", now); !ok {
+		t.Fatal("the first client past the cap should get the overflow bucket's token")
+	}
+	if ok, _ := l.allow("ip:10.0.0.4", now); ok {
+		t.Fatal("clients past the cap should share one bucket")
+	}
+	if len(l.clients) != 3 {
+		t.Fatalf("tracked %d clients, want 2 plus the overflow bucket", len(l.clients))
+	}
+}
@@ -1,73 +1,123 @@
 package api
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
 	"github.com/johnjeffers/awscogs/backend/internal/api/handlers"
-	"github.com/johnjeffers/awscogs/backend/internal/aws"
-	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/logging"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
 )
 
-// NewRouter creates and configures the HTTP router
-func NewRouter(cfg *config.Config, discovery *aws.Discovery, logger *slog.Logger) *chi.Mux {
+// NewRouter creates and configures the HTTP router. The first profile is the
+// default. It also returns each profile's costs handler, for scans the server
+// runs itself.
+func NewRouter(profiles []Profile, levels *logging.Levels, logger *slog.Logger) (*chi.Mux, []*handlers.CostsHandler) {
+	cfg := profiles[0].Config
 	r := chi.NewRouter()
 
 	// Base middleware (applied to all routes)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300,
-	}))
-
-	// Health check endpoints (without logging)
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+	if cfg.Server.TrustProxyHeaders {
+		r.Use(middleware.RealIP)
+	}
+	if c := corsHandler(cfg.Server.CORS); c != nil {
+		r.Use(c)
+	}
+	if cfg.Server.Headers.Enabled {
+		r.Use(securityHeaders(cfg.Server.Headers))
+	}
+
+	// Health check endpoint at the root so probes work regardless of base path
+	r.Get("/health", healthHandler)
+	ready := readyHandler(cfg.Server.BlockUntilFirstScan, profiles[0].Snapshots)
+	r.Get("/health/ready", ready)
+
+	basePath := cfg.Server.BasePath
+	if basePath == "" {
+		return r, registerRoutes(r, profiles, levels, logger)
+	}
+
+	// Serve everything else under the configured prefix
+	r.Get(basePath, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, basePath+"/", http.StatusMovedPermanently)
+	})
+	var costsHandlers []*handlers.CostsHandler
+	r.Route(basePath, func(r chi.Router) {
+		r.Get("/health", healthHandler)
+		r.Get("/health/ready", ready)
+		costsHandlers = registerRoutes(r, profiles, levels, logger)
+	})
+
+	return r, costsHandlers
+}
+
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// readyHandler reports whether the server is ready for traffic. With block
+// set, it isn't until the default profile has at least one snapshot.
+func readyHandler(block bool, snapshots *snapshot.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if block && (snapshots == nil || len(snapshots.List()) == 0) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("waiting for first scan"))
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
-	})
+	}
+}
+
+// registerRoutes registers the API, config, and SPA routes on r. Paths are
+// relative to the configured base path. It returns each profile's costs
+// handler, in profile order.
+func registerRoutes(r chi.Router, profiles []Profile, levels *logging.Levels, logger *slog.Logger) []*handlers.CostsHandler {
+	cfg := profiles[0].Config
 	r.Get("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 
-	// Handlers
-	costsHandler := handlers.NewCostsHandler(cfg, discovery, logger)
-	configHandler := handlers.NewConfigHandler(cfg, discovery, logger)
+	// Handlers, one set per profile
+	logHandler := handlers.NewLogHandler(levels, logger)
+	profileRoutes := make(map[string][]route, len(profiles))
+	costsHandlers := make([]*handlers.CostsHandler, 0, len(profiles))
+	for _, p := range profiles {
+		costsHandler := handlers.NewCostsHandler(p.Config, p.Discovery, p.Clouds, p.Snapshots, logger)
+		costsHandlers = append(costsHandlers, costsHandler)
+		configHandler := handlers.NewConfigHandler(p.Config, p.Discovery, logger)
+		digestHandler := handlers.NewDigestHandler(p.Config, p.Snapshots, logger)
+		profileRoutes[p.Config.Profile] = apiRoutes(costsHandler, configHandler, digestHandler, logHandler)
+	}
+	routes := withProfiles(profileRoutes)
 
 	// Routes (with logging)
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(middleware.Logger)
-
-		// Configuration
-		r.Get("/config", configHandler.GetConfig)
-
-		// Costs
-		r.Get("/costs", costsHandler.GetCosts)
-		r.Get("/costs/accounts", costsHandler.GetAccountCosts)
-		r.Get("/costs/regions", costsHandler.GetRegionCosts)
-		r.Get("/costs/ec2", costsHandler.GetEC2Costs)
-		r.Get("/costs/ebs", costsHandler.GetEBSCosts)
-		r.Get("/costs/ecs", costsHandler.GetECSCosts)
-		r.Get("/costs/rds", costsHandler.GetRDSCosts)
-		r.Get("/costs/eks", costsHandler.GetEKSCosts)
-		r.Get("/costs/elb", costsHandler.GetELBCosts)
-		r.Get("/costs/nat", costsHandler.GetNATGatewayCosts)
-		r.Get("/costs/eip", costsHandler.GetElasticIPCosts)
-		r.Get("/costs/secrets", costsHandler.GetSecretsCosts)
-		r.Get("/costs/publicipv4", costsHandler.GetPublicIPv4Costs)
-		r.Get("/costs/lambda", costsHandler.GetLambdaCosts)
-		r.Get("/cache/clear", costsHandler.ClearCache)
-		r.Post("/cache/clear", costsHandler.ClearCache)
+		if log := accessLog(cfg.Log.Access, logger, os.Stdout); log != nil {
+			r.Use(log)
+		}
+		if limiter := newRateLimiter(cfg.Server.RateLimit); limiter != nil {
+			r.Use(limiter.handler)
+		}
+
+		for _, rt := range routes {
+			r.Method(rt.method, rt.path, rt.handler)
+		}
+
+		// API documentation
+		r.Get("/openapi.json", openAPIHandler(routes, cfg.Server.BasePath, logger))
+		r.Get("/docs", swaggerUIHandler)
 	})
 
 	// Serve config.yaml from mounted ConfigMap if available, otherwise fall through to embedded SPA
@@ -80,7 +130,27 @@ func NewRouter(cfg *config.Config, discovery *aws.Discovery, logger *slog.Logger
 	}
 
 	// Serve embedded frontend for all other routes
-	r.Handle("/*", NewSPAHandler())
+	r.Handle("/*", NewSPAHandler(cfg.Server.BasePath))
+	return costsHandlers
+}
 
-	return r
+// withProfiles returns the default profile's routes with each handler
+// replaced by one that dispatches to the profile named by ?profile=
+func withProfiles(profileRoutes map[string][]route) []route {
+	routes := slices.Clone(profileRoutes[""])
+	for i := range routes {
+		if len(profileRoutes) > 1 {
+			routes[i].doc.Parameters = append(slices.Clone(routes[i].doc.Parameters), profileParam)
+		}
+		routes[i].handler = func(w http.ResponseWriter, r *http.Request) {
+			name := r.URL.Query().Get("profile")
+			prs, ok := profileRoutes[name]
+			if !ok {
+				apierror.Write(w, r, http.StatusNotFound, apierror.CodeUnknownProfile, fmt.Sprintf("unknown profile %q", name), nil)
+				return
+			}
+			prs[i].handler(w, r)
+		}
+	}
+	return routes
 }
@@ -0,0 +1,641 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/johnjeffers/awscogs/backend/internal/api/apierror"
+	"github.com/johnjeffers/awscogs/backend/internal/api/handlers"
+	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/calculator"
+	"github.com/johnjeffers/awscogs/backend/internal/costcenter"
+	"github.com/johnjeffers/awscogs/backend/internal/digest"
+	"github.com/johnjeffers/awscogs/backend/internal/logging"
+	"github.com/johnjeffers/awscogs/backend/internal/openapi"
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/recommend"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+	"github.com/johnjeffers/awscogs/backend/internal/version"
+	"github.com/johnjeffers/awscogs/backend/internal/waste"
+)
+
+// route is an /api/v1 endpoint along with its OpenAPI description. Routes are
+// registered and documented from the same table so the spec can't drift.
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	doc     openapi.Operation
+}
+
+var (
+	accountParam  = openapi.Query("account", "Comma-separated account names or IDs")
+	regionParam   = openapi.Query("region", "Comma-separated regions")
+	profileParam  = openapi.Query("profile", "Scan profile (default: the top-level configuration)")
+	resourceParam = openapi.Query("resource", "Comma-separated resource types (ec2, ebs, ecs, rds, eks, elb, nat, eip, secrets, publicipv4, lambda, capacityreservation, dedicatedhost, kinesis, firehose, sqs, sns, eventbridge, alarm, dashboard, custommetrics, vm)")
+	sortParam     = openapi.Query("sort", "Sort summaries and resources by cost (highest first, the default) or name; prefix with - to reverse, e.g. -cost")
+)
+
+// apiRoutes returns the /api/v1 route table
+func apiRoutes(costs *handlers.CostsHandler, config *handlers.ConfigHandler, digests *handlers.DigestHandler, logs *handlers.LogHandler) []route {
+	resourceRoute := func(path, id, summary string, handler http.HandlerFunc, extra ...openapi.Parameter) route {
+		return route{http.MethodGet, path, handler, openapi.Operation{
+			OperationID: id,
+			Summary:     summary,
+			Tags:        []string{"costs"},
+			Parameters:  append([]openapi.Parameter{accountParam, regionParam, sortParam}, extra...),
+			Response:    types.CostResponse{},
+		}}
+	}
+
+	return []route{
+		{http.MethodGet, "/config", config.GetConfig, openapi.Operation{
+			OperationID: "getConfig",
+			Summary:     "List available accounts, regions, and version information",
+			Tags:        []string{"config"},
+			Response:    handlers.ConfigResponse{},
+		}},
+		{http.MethodGet, "/iam-policy", config.GetIAMPolicy, openapi.Operation{
+			OperationID: "getIAMPolicy",
+			Summary:     "Minimal IAM policies for the current configuration",
+			Description: "servicePolicy is attached to the credentials awsCOGS runs with. scanRolePolicy is attached to the role assumed in each scanned account.",
+			Tags:        []string{"config"},
+			Parameters:  []openapi.Parameter{openapi.Query("resource", "Comma-separated AWS resource types to scan (default: all)")},
+			Response:    aws.IAMPolicies{},
+		}},
+		{http.MethodGet, "/permissions-check", costs.CheckPermissions, openapi.Operation{
+			OperationID: "checkPermissions",
+			Summary:     "Check IAM permissions for each account, region, and service without scanning",
+			Description: "Makes one lightweight call per API action a scan needs and reports the ones that are denied or fail.",
+			Tags:        []string{"config"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam, openapi.Query("resource", "Comma-separated AWS resource types to check (default: all)")},
+			Response:    types.PermissionsCheckResponse{},
+		}},
+		{http.MethodGet, "/resource-types", costs.ListResourceTypes, openapi.Operation{
+			OperationID: "listResourceTypes",
+			Summary:     "Resource types awsCOGS can discover",
+			Description: "Lists every built-in, plugin, and other-provider resource type in scan order, with its display name, the IAM actions it needs, whether it's scanned in each region or once per account, and whether the current config discovers it. Keys are the values the resource filter accepts.",
+			Tags:        []string{"config"},
+			Response:    types.ResourceTypesResponse{},
+		}},
+		{http.MethodGet, "/region-activity", costs.GetRegionActivity, openapi.Operation{
+			OperationID: "getRegionActivity",
+			Summary:     "Find chargeable resources in regions excluded from scans",
+			Description: "Counts running EC2 instances, EBS volumes, and available NAT gateways in each account's enabled regions that full scans leave out. Nothing is priced. A region with any of them has spend the scans don't show, and status is partial. Regions are only excluded when they're configured rather than discovered.",
+			Tags:        []string{"config"},
+			Parameters:  []openapi.Parameter{accountParam},
+			Response:    types.RegionActivityResponse{},
+		}},
+		{http.MethodGet, "/costs", costs.GetCosts, openapi.Operation{
+			OperationID: "getCosts",
+			Summary:     "Scan all resources and return their costs",
+			Description: "Every scan is recorded as a snapshot for diffs and digests.",
+			Tags:        []string{"costs"},
+			Parameters: []openapi.Parameter{
+				accountParam, regionParam, resourceParam, sortParam,
+				openapi.Query("olderThan", "Only resources created longer ago than this age, such as 90d, 1y, or 36h. Resources without a creation time are left out."),
+				openapi.Query("_rid", "Client request ID for log correlation"),
+			},
+			Response: types.CostResponse{},
+		}},
+		{http.MethodPost, "/scans", costs.StartScan, openapi.Operation{
+			OperationID: "startScan",
+			Summary:     "Start a scan in the background",
+			Description: "Returns a job at once instead of holding the request open for the whole scan. Poll getScan for status and progress, then fetch getScanResult, which returns the same response as getCosts. The body takes the same filters as getCosts; an empty body scans everything. The scan is recorded as a snapshot. Finished jobs are kept for an hour. A scan whose scope overlaps a queued or running one is handled by server.scanJobs.overlapPolicy: reject returns 409 SCAN_IN_PROGRESS with the other job as details, queue starts it once the other finishes, and coalesce (the default) returns the other job if it has the same scope and queues it otherwise. Scans beyond server.scanJobs.maxConcurrent are queued, and once server.scanJobs.maxQueued are waiting, more are rejected with 429 RATE_LIMITED.",
+			Tags:        []string{"scans"},
+			Body:        handlers.ScanRequest{},
+			Response:    handlers.ScanJob{},
+			Status:      http.StatusAccepted,
+		}},
+		{http.MethodGet, "/scans/{id}", costs.GetScan, openapi.Operation{
+			OperationID: "getScan",
+			Summary:     "Status and progress of a scan job",
+			Tags:        []string{"scans"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "Scan job ID")},
+			Response:    handlers.ScanJob{},
+		}},
+		{http.MethodGet, "/scans/{id}/result", costs.GetScanResult, openapi.Operation{
+			OperationID: "getScanResult",
+			Summary:     "Result of a finished scan job",
+			Description: "Returns the cost response of a succeeded job, or the error of a failed one. While the job is running it returns 202 with the job's status.",
+			Tags:        []string{"scans"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "Scan job ID")},
+			Response:    types.CostResponse{},
+		}},
+		{http.MethodGet, "/costs/version", costs.GetCostVersion, openapi.Operation{
+			OperationID: "getCostVersion",
+			Summary:     "Version of the latest recorded scan",
+			Description: "The version increases every time a scan is recorded, so clients can check it cheaply instead of refetching costs on a timer. With since set to the version a client has, or an If-Modified-Since header, the response is 304 Not Modified unless something newer was recorded. With wait, the request long-polls until something is or the wait runs out.",
+			Tags:        []string{"costs"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("since", "Version the client already has"),
+				openapi.Query("wait", "How long to wait for a newer version, such as 30s, up to 2m"),
+				{Name: "If-Modified-Since", In: "header", Description: "HTTP date of the client's data; compared against the version's updatedAt", Schema: &openapi.Schema{Type: "string"}},
+			},
+			Response: snapshot.Version{},
+		}},
+		{http.MethodGet, "/costs/diff", costs.GetCostDiff, openapi.Operation{
+			OperationID: "getCostDiff",
+			Summary:     "Resources added, removed, and changed since an earlier snapshot",
+			Tags:        []string{"costs"},
+			Parameters: []openapi.Parameter{
+				{Name: "since", In: "query", Required: true, Description: "RFC 3339 timestamp, Unix seconds, or a relative duration such as 24h or 7d", Schema: &openapi.Schema{Type: "string"}},
+				accountParam, regionParam, resourceParam,
+			},
+			Response: handlers.CostDiffResponse{},
+		}},
+		{http.MethodGet, "/costs/groupby", costs.GetGroupedCosts, openapi.Operation{
+			OperationID: "getGroupedCosts",
+			Summary:     "Costs totaled by any combination of dimensions",
+			Description: "Each group has one value per dimension, in the order requested. Resources without a grouped tag have an empty value for it.",
+			Tags:        []string{"costs"},
+			Parameters: []openapi.Parameter{
+				{Name: "dims", In: "query", Required: true, Description: "Comma-separated dimensions: account, accountName, region, service, state, vpc, subnet, costCenter, environment, or tag:<key>", Schema: &openapi.Schema{Type: "string"}},
+				accountParam, regionParam, resourceParam,
+			},
+			Response: types.GroupedCostResponse{},
+		}},
+		{http.MethodGet, "/costs/vpcs", costs.GetVPCCosts, openapi.Operation{
+			OperationID: "getVPCCosts",
+			Summary:     "Costs by VPC and subnet",
+			Description: "Totals EC2, RDS, load balancer, NAT gateway, and Elastic IP costs by VPC, with a breakdown by subnet. Load balancers span subnets and are reported under an empty subnet ID. Resources outside a VPC are left out.",
+			Tags:        []string{"costs"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam, resourceParam},
+			Response:    types.VPCCostResponse{},
+		}},
+		{http.MethodGet, "/costs/costcenters", costs.GetCostCenterCosts, openapi.Operation{
+			OperationID: "getCostCenterCosts",
+			Summary:     "Costs by cost center",
+			Description: "Totals costs by the cost center the costCenters config rules assign each resource to, with a breakdown by resource type. Resources no rule matches are reported as unallocated.",
+			Tags:        []string{"costs"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam, resourceParam},
+			Response:    types.CostCenterResponse{},
+		}},
+		{http.MethodGet, "/costs/environments", costs.GetEnvironmentCosts, openapi.Operation{
+			OperationID: "getEnvironmentCosts",
+			Summary:     "Costs by environment, split into production and non-production",
+			Description: "Totals costs by each resource's environment, with a breakdown by resource type. A resource's environment comes from the first environments config rule it matches, then its environment tags, then its account's metadata. Environments listed in environments.production count toward productionCost; resources nothing classifies are reported as unclassified.",
+			Tags:        []string{"costs"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam, resourceParam},
+			Response:    types.EnvironmentResponse{},
+		}},
+		{http.MethodGet, "/costs/asg", costs.GetAutoScalingGroupCosts, openapi.Operation{
+			OperationID: "getAutoScalingGroupCosts",
+			Summary:     "Costs by Auto Scaling group",
+			Description: "Totals EC2 instance costs by Auto Scaling group, with each group's minimum, maximum, and desired capacity and an estimate of its cost at maximum capacity. Instances outside a group are left out.",
+			Tags:        []string{"costs"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    types.AutoScalingGroupCostResponse{},
+		}},
+		{http.MethodGet, "/costs/waste", costs.GetWaste, openapi.Operation{
+			OperationID: "getWaste",
+			Summary:     "Resources that cost money without doing useful work",
+			Description: "Flags waste such as idle Elastic IPs, unattached EBS volumes, and long-stopped EC2 instances, highest cost first. Only the resource types the waste rules inspect are scanned unless resource is given.",
+			Tags:        []string{"costs"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam, resourceParam},
+			Response:    waste.Report{},
+		}},
+		resourceRoute("/costs/accounts", "getAccountCosts", "Account cost summaries", costs.GetAccountCosts),
+		resourceRoute("/costs/regions", "getRegionCosts", "Region cost summaries", costs.GetRegionCosts),
+		resourceRoute("/costs/ec2", "getEC2Costs", "EC2 instance costs", costs.GetEC2Costs),
+		resourceRoute("/costs/ebs", "getEBSCosts", "EBS volume costs", costs.GetEBSCosts,
+			openapi.Query("unattached", "Set to true to return only volumes not attached to an instance"),
+		),
+		resourceRoute("/costs/ecs", "getECSCosts", "ECS service costs", costs.GetECSCosts),
+		resourceRoute("/costs/rds", "getRDSCosts", "RDS instance costs", costs.GetRDSCosts),
+		resourceRoute("/costs/eks", "getEKSCosts", "EKS cluster costs", costs.GetEKSCosts),
+		resourceRoute("/costs/elb", "getELBCosts", "Load balancer costs", costs.GetELBCosts,
+			openapi.Query("includeUsage", "Set to true to include CloudWatch request and bandwidth usage"),
+			openapi.Parameter{Name: "usageWindow", In: "query", Description: "Usage window", Schema: &openapi.Schema{Type: "string", Enum: []string{"1h", "24h", "30d"}}},
+		),
+		resourceRoute("/costs/nat", "getNATGatewayCosts", "NAT gateway costs", costs.GetNATGatewayCosts),
+		resourceRoute("/costs/eip", "getElasticIPCosts", "Elastic IP costs", costs.GetElasticIPCosts),
+		resourceRoute("/costs/secrets", "getSecretsCosts", "Secrets Manager secret costs", costs.GetSecretsCosts),
+		resourceRoute("/costs/publicipv4", "getPublicIPv4Costs", "Public IPv4 address costs", costs.GetPublicIPv4Costs),
+		resourceRoute("/costs/lambda", "getLambdaCosts", "Lambda function costs", costs.GetLambdaCosts),
+		resourceRoute("/costs/kinesis", "getKinesisCosts", "Kinesis data stream and Firehose delivery stream costs", costs.GetKinesisCosts),
+		resourceRoute("/costs/messaging", "getMessagingCosts", "SQS queue, SNS topic, and EventBridge bus costs from the last day's requests", costs.GetMessagingCosts),
+		resourceRoute("/costs/cloudwatch", "getCloudWatchCosts", "CloudWatch alarm, dashboard, and custom metric costs", costs.GetCloudWatchCosts),
+		resourceRoute("/costs/capacityreservation", "getCapacityReservationCosts", "Unused On-Demand Capacity Reservation costs", costs.GetCapacityReservationCosts),
+		resourceRoute("/costs/dedicatedhost", "getDedicatedHostCosts", "Dedicated Host costs", costs.GetDedicatedHostCosts),
+		{http.MethodGet, "/recommendations/aurora-io", costs.GetAuroraIORecommendations, openapi.Operation{
+			OperationID: "getAuroraIORecommendations",
+			Summary:     "Aurora Standard vs I/O-Optimized storage cost per cluster",
+			Description: "Reads each Aurora cluster's billed read and write I/Os (VolumeReadIOPs, VolumeWriteIOPs) and storage (VolumeBytesUsed) from CloudWatch over the last 14 days, projects them to a month, and prices the cluster under both storage configurations. I/O-Optimized has no I/O charges but higher storage prices and instance prices 30% higher. recommended is the cheaper configuration, and savings are from switching to it. Highest savings first.",
+			Tags:        []string{"recommendations"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    recommend.AuroraIOReport{},
+		}},
+		{http.MethodGet, "/recommendations/gp3", costs.GetGP3Recommendations, openapi.Operation{
+			OperationID: "getGP3Recommendations",
+			Summary:     "Savings from migrating gp2 volumes to gp3",
+			Description: "Prices each gp2 volume as gp3 with the same size and matching IOPS and throughput. Only volumes that would cost less are returned, highest savings first.",
+			Tags:        []string{"recommendations"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    recommend.GP3Report{},
+		}},
+		{http.MethodGet, "/recommendations/graviton", costs.GetGravitonRecommendations, openapi.Operation{
+			OperationID: "getGravitonRecommendations",
+			Summary:     "Savings from moving x86 EC2 and RDS instances to Graviton",
+			Description: "Maps each running x86 instance to the Graviton type of the same size (m5 to m7g, db.r5 to db.r7g, and so on) and compares on-demand prices. compatibility is high for like-for-like families and medium when local storage, networking, or burst behavior differs. RDS instances are only included for engines that run on Graviton.",
+			Tags:        []string{"recommendations"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    recommend.GravitonReport{},
+		}},
+		{http.MethodGet, "/recommendations/load-balancers", costs.GetLoadBalancerRecommendations, openapi.Operation{
+			OperationID: "getLoadBalancerRecommendations",
+			Summary:     "Classic and idle load balancers",
+			Description: "Lists load balancers with warnings: classic load balancers, which are deprecated, and load balancers with no registered or no healthy targets. Idle load balancers report the savings from deleting them, highest first.",
+			Tags:        []string{"recommendations"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    recommend.LoadBalancerReport{},
+		}},
+		{http.MethodGet, "/recommendations/off-hours", costs.GetOffHoursRecommendations, openapi.Operation{
+			OperationID: "getOffHoursRecommendations",
+			Summary:     "Savings from stopping non-production instances outside business hours",
+			Description: "Finds running EC2 instances and available RDS instances whose environment tag (recommendations.offHours.environmentTags) has a non-production value, and estimates the savings from running them only on the configured schedule. RDS storage is billed while a database is stopped and is not counted as savings. Highest savings first.",
+			Tags:        []string{"recommendations"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    recommend.OffHoursReport{},
+		}},
+		{http.MethodGet, "/reports/pdf", costs.GetPDFReport, openapi.Operation{
+			OperationID: "getPDFReport",
+			Summary:     "Cost report as a PDF",
+			Description: "Scans resources and renders cost by service, account, and region, the most expensive resources, and the resources added, removed, and changed since an earlier snapshot.",
+			Tags:        []string{"reports"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("since", "Compare against the latest snapshot at or before this time: RFC 3339, Unix seconds, or a duration such as 7d (default 30d)"),
+				accountParam, regionParam, resourceParam,
+			},
+			Responses: map[string]*openapi.Response{"200": {
+				Description: "PDF report",
+				Content:     map[string]*openapi.MediaType{"application/pdf": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+			}},
+		}},
+		{http.MethodGet, "/reports/chargeback", costs.GetChargebackReport, openapi.Operation{
+			OperationID: "getChargebackReport",
+			Summary:     "Showback and chargeback by cost center",
+			Description: "Scans resources and charges each cost center for its resources over an hour, day, or 730-hour month at their current hourly cost. Resources matching a costCenters.sharedCosts rule, such as NAT gateways or EKS control planes, are split among the other cost centers in proportion to their direct cost, or evenly. format=csv returns the lines as CSV.",
+			Tags:        []string{"reports"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("period", "Charge period: hour, day, or month (default month)"),
+				openapi.Query("format", "json (default) or csv"),
+				accountParam, regionParam, resourceParam,
+			},
+			Response: costcenter.ChargebackReport{},
+		}},
+		{http.MethodGet, "/reports/ri-coverage", costs.GetRICoverageReport, openapi.Operation{
+			OperationID: "getRICoverageReport",
+			Summary:     "EC2 Reserved Instance coverage and unused reservations by instance family and region",
+			Description: "Scans running EC2 instances and active Reserved Instances, and applies the reservations the way AWS billing does: exact instance type matches first, then size-flexible regional Linux/UNIX reservations across their family. Usage is in normalized units per hour (a large instance is 4, an xlarge 8). Reservations apply across all scanned accounts. Instances are assumed to run Linux, so reservations for other platforms are listed as unmatched.",
+			Tags:        []string{"reports"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    recommend.RICoverageReport{},
+		}},
+		{http.MethodGet, "/reports/ipv4", costs.GetIPv4Report, openapi.Operation{
+			OperationID: "getIPv4Report",
+			Summary:     "Public IPv4 addresses, their cost, and candidates for IPv6 or private connectivity",
+			Description: "Scans Elastic IPs, public IPv4 addresses auto-assigned to EC2 instances, NAT gateways, internet-facing load balancers (one address per subnet), and publicly accessible RDS instances, and lists every public IPv4 address with its monthly cost at the in-use rate, highest first. Load balancer, RDS, and NAT gateway addresses without a scanned Elastic IP aren't counted in cost totals, so inTotals is false for them. Idle Elastic IPs, EC2 addresses, application load balancers, and public databases are flagged as migration candidates with a suggestion.",
+			Tags:        []string{"reports"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam},
+			Response:    recommend.IPv4Report{},
+		}},
+		{http.MethodGet, "/exports/focus", costs.GetFOCUSExport, openapi.Operation{
+			OperationID: "getFOCUSExport",
+			Summary:     "Cost estimates as FinOps FOCUS rows",
+			Description: "Scans resources and returns one FOCUS 1.0 row per resource, charged for the current UTC hour, day, or month at the resource's current hourly cost. Costs are on-demand estimates, so BilledCost, EffectiveCost, ListCost, and ContractedCost are equal. The scanned account is reported as both BillingAccountId and SubAccountId. x_Awscogs columns carry the awsCOGS resource type, size, and hourly cost.",
+			Tags:        []string{"exports"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("period", "Charge period: hour, day, or month (default month)"),
+				openapi.Query("format", "csv (default) or json"),
+				accountParam, regionParam, resourceParam,
+			},
+			Responses: map[string]*openapi.Response{"200": {
+				Description: "FOCUS rows as CSV with a header, or a JSON array of rows with the same column names for format=json",
+				Content: map[string]*openapi.MediaType{
+					"text/csv":         {Schema: &openapi.Schema{Type: "string"}},
+					"application/json": {Schema: &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "object"}}},
+				},
+			}},
+		}},
+		{http.MethodGet, "/resources", costs.ListResources, openapi.Operation{
+			OperationID: "listResources",
+			Summary:     "Scan resources and list every type in one shape",
+			Description: "Each resource has its type, ID, ARN where the type reports one, name, account, region, state, tags, hourly cost, and cost components, whatever service it belongs to. Use getCosts for the fields specific to each type.",
+			Tags:        []string{"resources"},
+			Parameters:  []openapi.Parameter{accountParam, regionParam, resourceParam, sortParam},
+			Response:    handlers.ResourceListResponse{},
+		}},
+		{http.MethodGet, "/resources/{type}/{id}", costs.GetResource, openapi.Operation{
+			OperationID: "getResource",
+			Summary:     "Full detail for one resource from the latest snapshot that contains it",
+			Description: "Includes tags, a pricing breakdown, and related resources from the same scan. IDs containing slashes (ECS services, load balancer ARNs) must be URL-encoded.",
+			Tags:        []string{"resources"},
+			Parameters: []openapi.Parameter{
+				openapi.Path("type", "Resource type (ec2, ebs, ecs, rds, eks, elb, nat, eip, secrets, publicipv4, lambda, capacityreservation, dedicatedhost, kinesis, firehose, sqs, sns, eventbridge, alarm, dashboard, custommetrics, vm)"),
+				openapi.Path("id", "Resource ID: instance, volume, or allocation ID, ARN, cluster/service for ECS, or public IP"),
+				accountParam, regionParam,
+			},
+			Response: snapshot.Detail{},
+		}},
+		{http.MethodPost, "/graphql", costs.GraphQL, openapi.Operation{
+			OperationID: "graphQL",
+			Summary:     "Query costs, snapshots, and resource detail with GraphQL",
+			Description: "Fields match the JSON API. The costs field scans resources and records a snapshot; snapshots and resource read from history. Introspection is supported.",
+			Tags:        []string{"graphql"},
+			Body:        handlers.GraphQLRequest{},
+			Response:    graphql.Result{},
+		}},
+		{http.MethodGet, "/graphql", costs.GraphQL, openapi.Operation{
+			OperationID: "graphQLGet",
+			Summary:     "Query costs, snapshots, and resource detail with GraphQL",
+			Tags:        []string{"graphql"},
+			Parameters: []openapi.Parameter{
+				{Name: "query", In: "query", Required: true, Description: "GraphQL query", Schema: &openapi.Schema{Type: "string"}},
+				openapi.Query("operationName", "Operation to run if the query contains several"),
+				openapi.Query("variables", "JSON-encoded variables"),
+			},
+			Response: graphql.Result{},
+		}},
+		{http.MethodPost, "/calculator", costs.Calculate, openapi.Operation{
+			OperationID: "calculate",
+			Summary:     "Price hypothetical resources",
+			Description: "Prices each item with the same on-demand prices scans use, multiplied by its count. Item types: ec2 (instanceType, tenancy, and attached volumes), ebs (volumeType, sizeGiB, iops, throughput), rds (instanceType, engine, multiAz, and storage as volumeType and sizeGiB), ecs (count is Fargate tasks), eks, elb (base hourly charge only), nat, eip, publicipv4, secrets, and lambda (requestsPerMonth, durationMs, memoryMb). Items that can't be priced report an error and the status is partial.",
+			Tags:        []string{"calculator"},
+			Body:        calculator.Request{},
+			Response:    calculator.Result{},
+		}},
+		{http.MethodGet, "/accounts", costs.ListAccountMetadata, openapi.Operation{
+			OperationID: "listAccountMetadata",
+			Summary:     "Display names, environments, owners, and colors of accounts",
+			Description: "Metadata comes from accountMetadata in the config file and from PUT /accounts/{id}, which takes precedence. Cost responses include it in their account summaries.",
+			Tags:        []string{"accounts"},
+			Response:    handlers.AccountMetadataList{},
+		}},
+		{http.MethodGet, "/accounts/{id}", costs.GetAccountMetadata, openapi.Operation{
+			OperationID: "getAccountMetadata",
+			Summary:     "One account's metadata",
+			Tags:        []string{"accounts"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "12-digit AWS account ID")},
+			Response:    snapshot.AccountMetadata{},
+		}},
+		{http.MethodPut, "/accounts/{id}", costs.PutAccountMetadata, openapi.Operation{
+			OperationID: "putAccountMetadata",
+			Summary:     "Set an account's metadata",
+			Description: "Replaces the account's metadata from the config file or an earlier request. It's kept with snapshots, so it persists when AWSCOGS_SNAPSHOTS_DIR is set. Cached scan results are cleared so cost responses show it right away.",
+			Tags:        []string{"accounts"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "12-digit AWS account ID")},
+			Body:        snapshot.AccountMetadataSpec{},
+			Response:    snapshot.AccountMetadata{},
+		}},
+		{http.MethodDelete, "/accounts/{id}", costs.DeleteAccountMetadata, openapi.Operation{
+			OperationID: "deleteAccountMetadata",
+			Summary:     "Remove metadata set through the API",
+			Description: "Metadata from the config file applies again.",
+			Tags:        []string{"accounts"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "12-digit AWS account ID")},
+			Status:      http.StatusNoContent,
+		}},
+		{http.MethodGet, "/views", costs.ListViews, openapi.Operation{
+			OperationID: "listViews",
+			Summary:     "Saved views",
+			Description: "Named filter presets saved from the dashboard, sorted by name.",
+			Tags:        []string{"views"},
+			Response:    handlers.ViewList{},
+		}},
+		{http.MethodPost, "/views", costs.CreateView, openapi.Operation{
+			OperationID: "createView",
+			Summary:     "Save a view",
+			Description: "Saves a named set of account, region, resource type, and tag filters and groupBy dimensions. The ID is derived from the name, e.g. prod-us-east-only, and doesn't change when the view is renamed. Filters are validated like the cost endpoints' query parameters. Views are kept with snapshots, so they persist when AWSCOGS_SNAPSHOTS_DIR is set.",
+			Tags:        []string{"views"},
+			Body:        snapshot.ViewSpec{},
+			Response:    snapshot.View{},
+			Status:      http.StatusCreated,
+		}},
+		{http.MethodGet, "/views/{id}", costs.GetView, openapi.Operation{
+			OperationID: "getView",
+			Summary:     "One saved view",
+			Tags:        []string{"views"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "View ID")},
+			Response:    snapshot.View{},
+		}},
+		{http.MethodPut, "/views/{id}", costs.UpdateView, openapi.Operation{
+			OperationID: "updateView",
+			Summary:     "Replace a saved view",
+			Tags:        []string{"views"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "View ID")},
+			Body:        snapshot.ViewSpec{},
+			Response:    snapshot.View{},
+		}},
+		{http.MethodDelete, "/views/{id}", costs.DeleteView, openapi.Operation{
+			OperationID: "deleteView",
+			Summary:     "Delete a saved view",
+			Tags:        []string{"views"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "View ID")},
+			Status:      http.StatusNoContent,
+		}},
+		{http.MethodGet, "/ignore-rules", costs.ListIgnoreRules, openapi.Operation{
+			OperationID: "listIgnoreRules",
+			Summary:     "Rules that exclude resources from totals and the waste report",
+			Description: "Lists the rules in the config file, with the IDs config-1, config-2, and so on, followed by the ones created through the API, and the tag that ignores a resource when it's set to true.",
+			Tags:        []string{"ignore"},
+			Response:    handlers.IgnoreRuleList{},
+		}},
+		{http.MethodPost, "/ignore-rules", costs.CreateIgnoreRule, openapi.Operation{
+			OperationID: "createIgnoreRule",
+			Summary:     "Ignore resources",
+			Description: "Excludes resources matching resource ID or name patterns (\"*\" matches any characters), resource types, accounts, and tags from totals and the waste report. A rule needs a reason and resources or tags. The ID is derived from the reason. Ignored resources are listed in ignoredResources with their cost in ignoredCost. Rules are kept with snapshots, so they persist when AWSCOGS_SNAPSHOTS_DIR is set.",
+			Tags:        []string{"ignore"},
+			Body:        snapshot.IgnoreRuleSpec{},
+			Response:    snapshot.IgnoreRule{},
+			Status:      http.StatusCreated,
+		}},
+		{http.MethodDelete, "/ignore-rules/{id}", costs.DeleteIgnoreRule, openapi.Operation{
+			OperationID: "deleteIgnoreRule",
+			Summary:     "Delete an ignore rule created through the API",
+			Tags:        []string{"ignore"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "Ignore rule ID")},
+			Status:      http.StatusNoContent,
+		}},
+		{http.MethodGet, "/baselines", costs.ListBaselines, openapi.Operation{
+			OperationID: "listBaselines",
+			Summary:     "Named snapshots pinned for comparison",
+			Tags:        []string{"baselines"},
+			Response:    []snapshot.Baseline{},
+		}},
+		{http.MethodPost, "/baselines", costs.CreateBaseline, openapi.Operation{
+			OperationID: "createBaseline",
+			Summary:     "Pin a snapshot as a baseline",
+			Description: "Pins a snapshot under a name such as \"pre-migration\" or \"Q3 budget\", the latest unfiltered snapshot unless snapshotId is set. The ID is derived from the name. Pinned snapshots are exempt from retention and AWSCOGS_SNAPSHOTS_MAX_COUNT.",
+			Tags:        []string{"baselines"},
+			Body:        snapshot.BaselineSpec{},
+			Response:    snapshot.Baseline{},
+			Status:      http.StatusCreated,
+		}},
+		{http.MethodDelete, "/baselines/{id}", costs.DeleteBaseline, openapi.Operation{
+			OperationID: "deleteBaseline",
+			Summary:     "Delete a baseline and unpin its snapshot",
+			Tags:        []string{"baselines"},
+			Parameters:  []openapi.Parameter{openapi.Path("id", "Baseline ID")},
+			Status:      http.StatusNoContent,
+		}},
+		{http.MethodGet, "/baselines/{id}/compare", costs.CompareBaseline, openapi.Operation{
+			OperationID: "compareBaseline",
+			Summary:     "Current costs compared against a baseline",
+			Description: "Totals the current scan and the baseline's snapshot by the requested dimensions and pairs up the groups, largest change first. Groups missing from the baseline have a baseline cost of 0 and no percent change.",
+			Tags:        []string{"baselines"},
+			Parameters: []openapi.Parameter{
+				openapi.Path("id", "Baseline ID"),
+				{Name: "dims", In: "query", Description: "Comma-separated dimensions, as for /costs/groupby. Defaults to account,service.", Schema: &openapi.Schema{Type: "string"}},
+				accountParam, regionParam, resourceParam,
+			},
+			Response: handlers.BaselineComparisonResponse{},
+		}},
+		{http.MethodGet, "/digest/weekly", digests.GetWeeklyDigest, openapi.Operation{
+			OperationID: "getWeeklyDigest",
+			Summary:     "Summary of what changed over the last week of snapshots",
+			Tags:        []string{"digest"},
+			Response:    digest.Digest{},
+		}},
+		{http.MethodGet, "/scan-status", costs.GetScanStatus, openapi.Operation{
+			OperationID: "getScanStatus",
+			Summary:     "When each account, region, and resource type was last scanned",
+			Description: "Scans only rescan cells whose cached result has expired; the rest are served from the discovery cache.",
+			Tags:        []string{"costs"},
+			Response:    types.ScanStatusResponse{},
+		}},
+		{http.MethodGet, "/cache/clear", costs.ClearCache, openapi.Operation{
+			OperationID: "clearCacheGet",
+			Summary:     "Clear cached discovery and pricing data",
+			Tags:        []string{"cache"},
+			Response:    map[string]string{},
+		}},
+		{http.MethodPost, "/cache/clear", costs.ClearCache, openapi.Operation{
+			OperationID: "clearCache",
+			Summary:     "Clear cached discovery and pricing data",
+			Tags:        []string{"cache"},
+			Response:    map[string]string{},
+		}},
+		{http.MethodGet, "/admin/pricing/cache", costs.GetPricingCache, openapi.Operation{
+			OperationID: "getPricingCache",
+			Summary:     "Price cache hit and miss counts, entries per service, and expiry",
+			Tags:        []string{"admin"},
+			Response:    pricing.CacheStats{},
+		}},
+		{http.MethodPost, "/admin/pricing/refresh", costs.RefreshPricing, openapi.Operation{
+			OperationID: "refreshPricing",
+			Summary:     "Clear the price cache so the next scan fetches current prices",
+			Description: "Cached scan results are cleared too. Hit and miss counts are kept.",
+			Tags:        []string{"admin"},
+			Response:    pricing.CacheStats{},
+		}},
+		{http.MethodGet, "/admin/telemetry", costs.GetTelemetry, openapi.Operation{
+			OperationID: "getTelemetry",
+			Summary:     "AWS API calls, retries, throttles, and latency per account, region, and service, with scan durations",
+			Description: "Counts start when the server starts. Only calls made while scanning an account are counted; cached results make no calls.",
+			Tags:        []string{"admin"},
+			Response:    types.TelemetryResponse{},
+		}},
+		{http.MethodGet, "/admin/accounts/status", costs.GetAccountStatuses, openapi.Operation{
+			OperationID: "getAccountStatuses",
+			Summary:     "Credential health of every configured or discovered account",
+			Description: "Assumes each account's scan role and calls sts:GetCallerIdentity, so broken trust policies show up right away, and reports when each account was last scanned, when a scan last succeeded, and the last scan error. Scan times are kept in memory since the server started.",
+			Tags:        []string{"admin"},
+			Parameters:  []openapi.Parameter{accountParam},
+			Response:    types.AccountStatusResponse{},
+		}},
+		{http.MethodGet, "/admin/snapshots/export", costs.ExportSnapshots, openapi.Operation{
+			OperationID: "exportSnapshots",
+			Summary:     "Download the snapshot history and saved views as a gzipped tar archive",
+			Description: "The archive can be loaded into another instance with POST /admin/snapshots/import or awscogs -import-snapshots.",
+			Tags:        []string{"admin"},
+			Responses: map[string]*openapi.Response{"200": {
+				Description: "Snapshot archive",
+				Content:     map[string]*openapi.MediaType{"application/gzip": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+			}},
+		}},
+		{http.MethodPost, "/admin/snapshots/import", costs.ImportSnapshots, openapi.Operation{
+			OperationID: "importSnapshots",
+			Summary:     "Add the snapshots and saved views in an exported archive",
+			Description: "Send the archive as the request body. Snapshots and views that already exist are skipped, and the retention policy and max count are applied afterwards. Archives over the configured import size are rejected with a 413.",
+			Tags:        []string{"admin"},
+			RequestBody: &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]*openapi.MediaType{"application/gzip": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+			},
+			Response: snapshot.ImportResult{},
+		}},
+		{http.MethodGet, "/admin/log-levels", logs.GetLogLevels, openapi.Operation{
+			OperationID: "getLogLevels",
+			Summary:     "Log levels in effect, by default and for each component",
+			Tags:        []string{"admin"},
+			Response:    logging.LevelState{},
+		}},
+		{http.MethodPut, "/admin/log-levels", logs.SetLogLevels, openapi.Operation{
+			OperationID: "setLogLevels",
+			Summary:     "Change log levels until the server restarts",
+			Description: "Components not listed use level. Turns off debug logging forced on by SIGUSR1.",
+			Tags:        []string{"admin"},
+			Body:        logging.LevelSpec{},
+			Response:    logging.LevelState{},
+		}},
+		{http.MethodDelete, "/admin/log-levels", logs.ResetLogLevels, openapi.Operation{
+			OperationID: "resetLogLevels",
+			Summary:     "Restore the configured log levels",
+			Tags:        []string{"admin"},
+			Response:    logging.LevelState{},
+		}},
+	}
+}
+
+// openAPIHandler serves the OpenAPI document for routes
+func openAPIHandler(routes []route, basePath string, logger *slog.Logger) http.HandlerFunc {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "awsCOGS API",
+		Description: "Cost of goods sold for AWS resources across accounts and regions.",
+		Version:     version.Version,
+	}, basePath+"/api/v1")
+	b.SetErrorBody(apierror.Error{})
+	for _, rt := range routes {
+		b.Add(rt.method, rt.path, rt.doc)
+	}
+	doc := b.Document()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(doc); err != nil {
+			logger.Error("failed to encode response", "error", err)
+		}
+	}
+}
+
+// swaggerUIHTML loads Swagger UI from a CDN and points it at openapi.json
+const swaggerUIHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>awsCOGS API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	// Replace the frontend policy, which would block Swagger UI
+	if w.Header().Get("Content-Security-Policy") != "" {
+		w.Header().Set("Content-Security-Policy", swaggerUICSP)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIHTML))
+}
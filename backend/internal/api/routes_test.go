@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/openapi"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	routes := apiRoutes(nil, nil, nil, nil)
+	handler := openAPIHandler(routes, "/awscogs", slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+
+	var doc openapi.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if doc.Servers[0].URL != "/awscogs/api/v1" {
+		t.Fatalf("server URL = %q", doc.Servers[0].URL)
+	}
+	for _, rt := range routes {
+		if doc.Paths[rt.path][strings.ToLower(rt.method)] == nil {
+			t.Fatalf("%s %s missing from spec", rt.method, rt.path)
+		}
+	}
+
+	costResponse := doc.Components.Schemas["CostResponse"]
+	if costResponse == nil || costResponse.Properties["ec2Instances"] == nil {
+		t.Fatalf("CostResponse schema incomplete: %+v", costResponse)
+	}
+	if diff := doc.Components.Schemas["CostDiffResponse"]; diff == nil || diff.Properties["added"] == nil {
+		t.Fatalf("embedded diff fields not flattened: %+v", diff)
+	}
+}
+
+func TestWithProfilesDispatchesByQuery(t *testing.T) {
+	handlerFor := func(name string) []route {
+		return []route{{method: "GET", path: "/config", handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}}}
+	}
+	routes := withProfiles(map[string][]route{"": handlerFor("default"), "dev-org": handlerFor("dev-org")})
+
+	for query, want := range map[string]string{"": "default", "?profile=dev-org": "dev-org"} {
+		rec := httptest.NewRecorder()
+		routes[0].handler(rec, httptest.NewRequest("GET", "/api/v1/config"+query, nil))
+		if rec.Body.String() != want {
+			t.Fatalf("query %q served %q, want %q", query, rec.Body.String(), want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	routes[0].handler(rec, httptest.NewRequest("GET", "/api/v1/config?profile=prod-org", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown profile status = %d", rec.Code)
+	}
+	if len(routes[0].doc.Parameters) != 1 || routes[0].doc.Parameters[0].Name != "profile" {
+		t.Fatalf("expected profile parameter to be documented, got %+v", routes[0].doc.Parameters)
+	}
+}
+
+func TestReadyHandlerBlocksUntilFirstSnapshot(t *testing.T) {
+	store, err := snapshot.NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	readyHandler(false, store)(rec, httptest.NewRequest("GET", "/health/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("not blocking: status = %d, want 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	readyHandler(true, store)(rec, httptest.NewRequest("GET", "/health/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("before first scan: status = %d, want 503", rec.Code)
+	}
+
+	if _, err := store.Add(time.Now(), &types.CostResponse{}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	rec = httptest.NewRecorder()
+	readyHandler(true, store)(rec, httptest.NewRequest("GET", "/health/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("after first scan: status = %d, want 200", rec.Code)
+	}
+}
@@ -2,49 +2,229 @@ package api
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
+	"github.com/johnjeffers/awscogs/backend/internal/api/handlers"
 	"github.com/johnjeffers/awscogs/backend/internal/aws"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
 	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/logging"
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
 )
 
+// Profile is the discovery service, cloud providers, and snapshot history of
+// one scan profile. API requests select a profile with ?profile=.
+type Profile struct {
+	Config    *config.Config
+	Discovery *aws.Discovery
+	Clouds    *cloud.Registry
+	Snapshots *snapshot.Store
+}
+
 // Server is the HTTP server for the awscogs API
 type Server struct {
-	server    *http.Server
-	config    *config.Config
-	discovery *aws.Discovery
-	logger    *slog.Logger
+	server *http.Server
+	config *config.Config
+	logger *slog.Logger
+
+	profiles  []Profile
+	costs     []*handlers.CostsHandler
+	listeners []net.Listener  // Opened by Listen
+	scanCtx   context.Context // Background scans stop when it's cancelled on shutdown
+	stopScans context.CancelFunc
+
+	// cancelRequests cancels every request's context, and the scans they
+	// started, when the shutdown drain timeout runs out
+	cancelRequests context.CancelFunc
 }
 
-// NewServer creates a new API server
-func NewServer(cfg *config.Config, discovery *aws.Discovery, logger *slog.Logger) *Server {
-	router := NewRouter(cfg, discovery, logger)
+// NewServer creates a new API server. The first profile is the default, used
+// when a request doesn't name one. It logs as the api component of levels.
+func NewServer(profiles []Profile, levels *logging.Levels) *Server {
+	cfg := profiles[0].Config
+	logger := levels.Logger(config.LogComponentAPI)
+	router, costs := NewRouter(profiles, levels, logger)
+	scanCtx, stopScans := context.WithCancel(context.Background())
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
 
 	return &Server{
 		server: &http.Server{
-			Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
 			Handler:      router,
 			ReadTimeout:  30 * time.Second,
 			WriteTimeout: 5 * time.Minute,
 			IdleTimeout:  60 * time.Second,
+			BaseContext:  func(net.Listener) context.Context { return requestCtx },
 		},
-		config:    cfg,
-		discovery: discovery,
-		logger:    logger,
+		config:         cfg,
+		logger:         logger,
+		profiles:       profiles,
+		costs:          costs,
+		scanCtx:        scanCtx,
+		stopScans:      stopScans,
+		cancelRequests: cancelRequests,
+	}
+}
+
+// Listen opens a listener on the Unix socket or on each bind address and
+// returns their addresses, with unix: before a socket's path
+func (s *Server) Listen() ([]string, error) {
+	listeners, err := listen(s.config.Server)
+	if err != nil {
+		return nil, err
+	}
+	s.listeners = listeners
+
+	addrs := make([]string, len(listeners))
+	for i, l := range listeners {
+		addrs[i] = l.Addr().String()
+		if l.Addr().Network() == "unix" {
+			addrs[i] = "unix:" + addrs[i]
+		}
 	}
+	return addrs, nil
 }
 
-// Start begins listening for requests
+// Start serves requests on the listeners opened by Listen, which it calls
+// first if nothing else has, and starts the startup scan if one is
+// configured. It returns when the server shuts down or any listener fails.
 func (s *Server) Start() error {
-	s.logger.Info("starting server", "port", s.config.Server.Port)
-	return s.server.ListenAndServe()
+	if s.listeners == nil {
+		if _, err := s.Listen(); err != nil {
+			return err
+		}
+	}
+	if s.config.Server.ScanOnStartup {
+		s.scanOnStartup()
+	}
+
+	errs := make(chan error, len(s.listeners))
+	for _, l := range s.listeners {
+		go func() { errs <- s.server.Serve(l) }()
+	}
+	// Serve returns when the server shuts down or a listener fails
+	return <-errs
+}
+
+const (
+	// startupScanRetry is how long failed startup scans wait to be retried,
+	// doubling after each failure up to maxStartupScanRetry
+	startupScanRetry    = 30 * time.Second
+	maxStartupScanRetry = 15 * time.Minute
+)
+
+// scanOnStartup runs a full scan of each profile in the background. Profiles
+// are scanned one after another so they don't compete for the AWS rate limit,
+// and failed scans are retried with backoff so /health/ready doesn't wait on
+// a transient failure forever.
+func (s *Server) scanOnStartup() {
+	go retryScans(s.scanCtx, len(s.costs), startupScanRetry, maxStartupScanRetry, func(ctx context.Context, i int, retryIn time.Duration) error {
+		started := time.Now()
+		if err := s.costs[i].ScanAll(ctx); err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("startup scan failed", "profile", s.profiles[i].Config.Profile, "retryIn", retryIn.String(), "error", err)
+			}
+			return err
+		}
+		s.logger.Info("startup scan completed", "profile", s.profiles[i].Config.Profile, "duration", time.Since(started).String())
+		return nil
+	})
 }
 
-// Shutdown gracefully stops the server
+// retryScans calls scan for each of n profiles in turn, then again for those
+// that failed once wait has passed, doubling wait each round up to maxWait,
+// until every scan has succeeded or ctx is done. scan is told how long a
+// failure will wait to be retried.
+func retryScans(ctx context.Context, n int, wait, maxWait time.Duration, scan func(ctx context.Context, i int, retryIn time.Duration) error) {
+	pending := make([]int, n)
+	for i := range pending {
+		pending[i] = i
+	}
+	for {
+		var failed []int
+		for _, i := range pending {
+			if err := scan(ctx, i, wait); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				failed = append(failed, i)
+			}
+		}
+		if len(failed) == 0 {
+			return
+		}
+		pending = failed
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		wait = min(wait*2, maxWait)
+	}
+}
+
+// PricesResolved rescans, in the background, the resources of each profile
+// priced with lookups the pricing retrier has resolved. It is meant to be
+// registered with pricing.Retrier.OnResolved.
+func (s *Server) PricesResolved(keys []pricing.PriceKey) {
+	go func() {
+		for i, h := range s.costs {
+			if err := h.PricesResolved(s.scanCtx, keys); err != nil {
+				if s.scanCtx.Err() != nil {
+					return
+				}
+				s.logger.Error("rescan after resolving prices failed", "profile", s.profiles[i].Config.Profile, "error", err)
+			}
+		}
+	}()
+}
+
+// Shutdown gracefully stops the server. It stops accepting connections and
+// scan jobs, and gives scans in progress, whether started by a request, a
+// job, the startup scan, or a rescan after resolving prices, up to the
+// configured drain timeout to finish. Scans still running after that are
+// cancelled, and what they found so far is recorded as a partial snapshot
+// before Shutdown returns, unless ctx is done first.
 func (s *Server) Shutdown(ctx context.Context) error {
-	s.logger.Info("shutting down server")
-	return s.server.Shutdown(ctx)
+	drain := time.Duration(s.config.Server.ShutdownDrainSeconds) * time.Second
+	s.logger.Info("shutting down server", "drainTimeout", drain.String())
+	for _, h := range s.costs {
+		h.CloseScanJobs()
+	}
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.server.Shutdown(ctx) }()
+
+	drainCtx, cancel := context.WithTimeout(ctx, drain)
+	drained := s.waitForScans(drainCtx)
+	cancel()
+	if !drained {
+		s.logger.Warn("cancelling scans still in progress after the drain timeout")
+	}
+
+	s.stopScans()
+	s.cancelRequests()
+	for _, h := range s.costs {
+		h.StopScanJobs()
+	}
+	if !drained && !s.waitForScans(ctx) {
+		s.logger.Warn("shutdown timed out before cancelled scans recorded their snapshots")
+	}
+	return <-shutdown
+}
+
+// waitForScans waits for every profile's scans in progress to finish, and
+// reports whether they did before ctx was done
+func (s *Server) waitForScans(ctx context.Context) bool {
+	for _, h := range s.costs {
+		if !h.WaitForScans(ctx) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetryScansUntilEverySucceeds(t *testing.T) {
+	var (
+		calls   []int
+		waits   []time.Duration
+		failing = map[int]int{1: 3} // Profile 1 fails its first 3 scans
+	)
+	retryScans(context.Background(), 3, time.Millisecond, 3*time.Millisecond, func(_ context.Context, i int, retryIn time.Duration) error {
+		calls = append(calls, i)
+		if failing[i] > 0 {
+			failing[i]--
+			waits = append(waits, retryIn)
+			return errors.New("throttled")
+		}
+		return nil
+	})
+
+	if want := []int{0, 1, 2, 1, 1, 1}; !slices.Equal(calls, want) {
+		t.Errorf("scans = %v, want each profile once and the failing one until it succeeds: %v", calls, want)
+	}
+	if want := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}; !slices.Equal(waits, want) {
+		t.Errorf("retry waits = %v, want doubling up to the cap: %v", waits, want)
+	}
+}
+
+func TestRetryScansStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		retryScans(ctx, 1, time.Millisecond, time.Millisecond, func(context.Context, int, time.Duration) error {
+			calls++
+			if calls == 2 {
+				cancel()
+			}
+			return errors.New("no credentials")
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retryScans kept retrying after its context was cancelled")
+	}
+	if calls != 2 {
+		t.Errorf("scans = %d, want none after the cancellation", calls)
+	}
+}
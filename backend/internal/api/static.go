@@ -1,48 +1,64 @@
 package api
 
 import (
+	"bytes"
 	"embed"
 	"fmt"
 	"io/fs"
 	"net/http"
+	"regexp"
 	"strings"
 )
 
 //go:embed all:dist
 var frontendFS embed.FS
 
+// rootRelativeAttr matches src/href attributes pointing at root-relative URLs
+var rootRelativeAttr = regexp.MustCompile(`(src|href)="(/[^"]*)"`)
+
 // SPAHandler serves the embedded frontend files with SPA support.
 // For paths that don't match a static file, it serves index.html
 // to allow client-side routing to handle the request.
 type SPAHandler struct {
-	fs http.Handler
+	fs       http.Handler
+	dist     fs.FS
+	basePath string
+	index    []byte // index.html rewritten for basePath, nil if not embedded
 }
 
-// NewSPAHandler creates a handler for serving the embedded frontend.
-func NewSPAHandler() *SPAHandler {
+// NewSPAHandler creates a handler for serving the embedded frontend under basePath.
+func NewSPAHandler(basePath string) *SPAHandler {
 	dist, err := fs.Sub(frontendFS, "dist")
 	if err != nil {
 		panic(fmt.Sprintf("failed to get embedded frontend filesystem: %v", err))
 	}
-	return &SPAHandler{
-		fs: http.FileServer(http.FS(dist)),
+
+	var fileServer http.Handler = http.FileServer(http.FS(dist))
+	if basePath != "" {
+		fileServer = http.StripPrefix(basePath, fileServer)
+	}
+
+	h := &SPAHandler{
+		fs:       fileServer,
+		dist:     dist,
+		basePath: basePath,
 	}
+	if indexContent, err := fs.ReadFile(dist, "index.html"); err == nil {
+		h.index = rewriteIndexHTML(indexContent, basePath)
+	}
+	return h
 }
 
 func (h *SPAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-
-	// Try to serve the file directly
-	dist, err := fs.Sub(frontendFS, "dist")
-	if err != nil {
-		http.Error(w, "frontend not available", http.StatusInternalServerError)
-		return
+	path := strings.TrimPrefix(r.URL.Path, h.basePath)
+	if path == "" {
+		path = "/"
 	}
 
 	// Check if the path exists as a file
 	if path != "/" {
 		cleanPath := strings.TrimPrefix(path, "/")
-		if _, err := fs.Stat(dist, cleanPath); err == nil {
+		if _, err := fs.Stat(h.dist, cleanPath); err == nil {
 			h.fs.ServeHTTP(w, r)
 			return
 		}
@@ -50,16 +66,35 @@ func (h *SPAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// For paths that don't exist as files, serve index.html (SPA routing)
 	if path == "/" || !strings.Contains(path, ".") {
-		indexContent, err := fs.ReadFile(dist, "index.html")
-		if err != nil {
+		if h.index == nil {
 			http.Error(w, "Frontend not found", http.StatusNotFound)
 			return
 		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.Write(indexContent)
+		w.Write(h.index)
 		return
 	}
 
 	// For other paths (like missing assets), let the file server handle it
 	h.fs.ServeHTTP(w, r)
 }
+
+// rewriteIndexHTML prefixes root-relative asset URLs with basePath and injects a
+// meta tag the frontend reads to build API URLs. Assets already built with the
+// prefix (VITE_BASE_PATH) are left alone.
+func rewriteIndexHTML(content []byte, basePath string) []byte {
+	if basePath != "" {
+		content = rootRelativeAttr.ReplaceAllFunc(content, func(match []byte) []byte {
+			parts := rootRelativeAttr.FindSubmatch(match)
+			url := string(parts[2])
+			if strings.HasPrefix(url, "//") || url == basePath || strings.HasPrefix(url, basePath+"/") {
+				return match
+			}
+			return fmt.Appendf(nil, `%s="%s%s"`, parts[1], basePath, url)
+		})
+	}
+
+	meta := fmt.Appendf(nil, `<head>
+    <meta name="awscogs-base-path" content="%s" />`, basePath)
+	return bytes.Replace(content, []byte("<head>"), meta, 1)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteIndexHTMLPrefixesRootRelativeAssets(t *testing.T) {
+	index := []byte(`<html><head><link rel="icon" href="/vite.svg" /><script type="module" src="/assets/index.js"></script></head></html>`)
+
+	got := string(rewriteIndexHTML(index, "/awscogs"))
+
+	if !strings.Contains(got, `href="/awscogs/vite.svg"`) {
+		t.Fatalf("icon href not rewritten: %s", got)
+	}
+	if !strings.Contains(got, `src="/awscogs/assets/index.js"`) {
+		t.Fatalf("script src not rewritten: %s", got)
+	}
+	if !strings.Contains(got, `<meta name="awscogs-base-path" content="/awscogs" />`) {
+		t.Fatalf("base path meta tag missing: %s", got)
+	}
+}
+
+func TestRewriteIndexHTMLLeavesPrefixedAssets(t *testing.T) {
+	index := []byte(`<html><head><script src="/awscogs/assets/index.js"></script><link href="//cdn.example.com/x.css"></head></html>`)
+
+	got := string(rewriteIndexHTML(index, "/awscogs"))
+
+	if strings.Contains(got, "/awscogs/awscogs/") {
+		t.Fatalf("already-prefixed asset was rewritten twice: %s", got)
+	}
+	if !strings.Contains(got, `href="//cdn.example.com/x.css"`) {
+		t.Fatalf("protocol-relative URL was rewritten: %s", got)
+	}
+}
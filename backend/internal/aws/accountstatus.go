@@ -0,0 +1,181 @@
+package aws
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// accountStatusTracker remembers how the scans of each account last went,
+// so broken scan roles can be found without reading logs
+type accountStatusTracker struct {
+	mu       sync.Mutex
+	accounts map[string]*accountScanStatus // keyed by accountIdentityKey
+}
+
+// accountScanStatus is how one account's scans last went
+type accountScanStatus struct {
+	id, name    string
+	lastScan    time.Time
+	lastSuccess time.Time
+	lastError   string // The first error of the last scan that had one, until one succeeds
+	lastErrorAt time.Time
+}
+
+func newAccountStatusTracker() *accountStatusTracker {
+	return &accountStatusTracker{accounts: make(map[string]*accountScanStatus)}
+}
+
+// account returns key's status, creating it if needed. t.mu must be held.
+func (t *accountStatusTracker) account(acc Account) *accountScanStatus {
+	key := accountIdentityKey(acc)
+	a, ok := t.accounts[key]
+	if !ok {
+		a = &accountScanStatus{id: acc.ID, name: acc.Name}
+		t.accounts[key] = a
+	}
+	return a
+}
+
+// identify records the ID and name resolved for acc
+func (t *accountStatusTracker) identify(acc Account, identity accountIdentity) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.account(acc)
+	if identity.id != "" && identity.id != "unknown" {
+		a.id = identity.id
+	}
+	if identity.name != "" && identity.name != "unknown" {
+		a.name = identity.name
+	}
+}
+
+// record records a scan of one of acc's regions that finished at now with
+// diagnostics. A scan with no errors succeeded and clears the last error.
+func (t *accountStatusTracker) record(acc Account, diagnostics []types.Diagnostic, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.account(acc)
+	a.lastScan = now
+	for _, diag := range diagnostics {
+		if diag.Level == "error" {
+			a.lastError, a.lastErrorAt = diag.Message, now
+			return
+		}
+	}
+	a.lastSuccess = now
+	a.lastError, a.lastErrorAt = "", time.Time{}
+}
+
+// get returns acc's status, or nil if it hasn't been scanned
+func (t *accountStatusTracker) get(acc Account) *accountScanStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a, ok := t.accounts[accountIdentityKey(acc)]
+	if !ok {
+		return nil
+	}
+	copied := *a
+	return &copied
+}
+
+// AccountStatuses checks that each account's credentials work, by assuming
+// its scan role and calling sts:GetCallerIdentity in its partition's default
+// region, and reports when each was last scanned successfully and the last
+// scan error. Role assumption isn't cached, so a fixed trust policy shows up
+// right away. With no accounts, the default credentials are checked in each
+// of regions' partitions.
+func (d *Discovery) AccountStatuses(ctx context.Context, accounts []Account, regions []string) *types.AccountStatusResponse {
+	if len(accounts) == 0 {
+		accounts = defaultAccountsForRegions(regions)
+	}
+	statuses := make([]types.AccountStatus, len(accounts))
+	var wg sync.WaitGroup
+	for i, acc := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = d.accountStatusOf(ctx, acc)
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if a.Status != b.Status {
+			return a.Status == types.CredentialStatusFailed
+		}
+		return a.AccountName < b.AccountName
+	})
+
+	response := &types.AccountStatusResponse{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    types.ResponseStatusOK,
+		Checked:   len(statuses),
+		Accounts:  statuses,
+	}
+	for _, s := range statuses {
+		if s.Status == types.CredentialStatusFailed {
+			response.Failed++
+		}
+	}
+	switch {
+	case response.Failed > 0 && response.Failed == response.Checked:
+		response.Status = types.ResponseStatusFailed
+	case response.Failed > 0:
+		response.Status = types.ResponseStatusPartial
+	}
+	return response
+}
+
+// accountStatusOf checks one account's credentials and adds its scan status
+func (d *Discovery) accountStatusOf(ctx context.Context, acc Account) types.AccountStatus {
+	status := types.AccountStatus{
+		AccountID:   acc.ID,
+		AccountName: acc.Name,
+		Partition:   acc.AccountPartition(),
+		RoleARN:     acc.RoleARN,
+		Status:      types.CredentialStatusOK,
+	}
+
+	cfg, err := d.getConfigForAccount(ctx, acc, DefaultRegionForPartition(acc.AccountPartition()))
+	if err == nil {
+		var accountID string
+		if accountID, err = d.getAccountID(ctx, cfg); err == nil && status.AccountID == "" {
+			status.AccountID = accountID
+		}
+	}
+	if err != nil {
+		d.logger.Debug("account credential check failed", "account", acc.Name, "error", err)
+		status.Status = types.CredentialStatusFailed
+		status.Error = err.Error()
+	}
+
+	if scanned := d.accountStatus.get(acc); scanned != nil {
+		if status.AccountID == "" {
+			status.AccountID = scanned.id
+		}
+		if status.AccountName == "" {
+			status.AccountName = scanned.name
+		}
+		status.LastScanAt = formatTime(scanned.lastScan)
+		status.LastSuccessAt = formatTime(scanned.lastSuccess)
+		status.LastScanError = scanned.lastError
+		status.LastScanErrorAt = formatTime(scanned.lastErrorAt)
+	}
+	if status.AccountName == "" {
+		status.AccountName = status.AccountID
+	}
+	return status
+}
+
+// formatTime formats t as RFC 3339 in UTC, or returns "" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
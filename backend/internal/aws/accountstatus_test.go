@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestAccountStatusTracker(t *testing.T) {
+	tracker := newAccountStatusTracker()
+	acc := Account{Name: "prod", RoleARN: "arn:aws:iam::111111111111:role/awscogs"}
+	if tracker.get(acc) != nil {
+		t.Fatal("expected no status before a scan")
+	}
+
+	first := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tracker.identify(acc, accountIdentity{id: "111111111111", name: "prod"})
+	tracker.record(acc, []types.Diagnostic{{Level: "warning", Message: "no price"}}, first)
+
+	failed := first.Add(time.Hour)
+	tracker.record(acc, []types.Diagnostic{
+		{Level: "warning", Message: "no price"},
+		{Level: "error", Message: "AccessDenied: not authorized to perform sts:AssumeRole"},
+	}, failed)
+	s := tracker.get(acc)
+	if s.id != "111111111111" || !s.lastSuccess.Equal(first) || !s.lastScan.Equal(failed) || s.lastError == "" || !s.lastErrorAt.Equal(failed) {
+		t.Fatalf("after a failed scan: %+v", s)
+	}
+
+	// A successful scan clears the error
+	fixed := failed.Add(time.Hour)
+	tracker.record(acc, nil, fixed)
+	if s := tracker.get(acc); !s.lastSuccess.Equal(fixed) || s.lastError != "" || !s.lastErrorAt.IsZero() {
+		t.Fatalf("after a successful scan: %+v", s)
+	}
+
+	// The unknown identity of a failed lookup doesn't replace the known one
+	tracker.identify(acc, accountIdentity{id: "unknown", name: "unknown"})
+	if s := tracker.get(acc); s.id != "111111111111" || s.name != "prod" {
+		t.Fatalf("identity = %s, %s", s.id, s.name)
+	}
+}
@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// auroraUsageWindow is how far back Aurora storage and I/O usage is read
+const auroraUsageWindow = 14 * 24 * time.Hour
+
+// auroraMetricsPerCluster is the number of CloudWatch queries per cluster:
+// read I/Os, write I/Os, and volume bytes used
+const auroraMetricsPerCluster = 3
+
+// DiscoverAuroraClusters returns the Aurora clusters in each account and
+// region with their recent storage and I/O usage, and diagnostics for the
+// ones that couldn't be listed. Results are cached like resources, as the
+// "auroracluster" type.
+func (d *Discovery) DiscoverAuroraClusters(ctx context.Context, accounts []Account, regions []string) ([]types.AuroraCluster, []types.Diagnostic) {
+	diagnostics := newDiagnosticCollector()
+	ctx = contextWithDiagnostics(ctx, diagnostics)
+	ctx = contextWithDiscoveryRun(ctx)
+	if len(accounts) == 0 {
+		accounts = defaultAccountsForRegions(regions)
+	}
+
+	var (
+		all []types.AuroraCluster
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+	)
+	for _, account := range accounts {
+		for _, region := range regions {
+			if account.AccountPartition() != PartitionForRegion(region) {
+				continue
+			}
+
+			wg.Add(1)
+			go func(acc Account, reg string) {
+				defer wg.Done()
+
+				cfg, err := d.getConfigForAccount(ctx, acc, reg)
+				if err != nil {
+					recordDiagnostic(ctx, newDiagnostic("error", "account", acc.ID, acc.Name, reg, "getConfig", "", err))
+					return
+				}
+				identity, err := d.resolveAccountIdentity(ctx, acc, cfg)
+				if err != nil {
+					recordDiagnostic(ctx, newDiagnostic("warning", "account", "", acc.Name, reg, "getAccountID", "", err))
+				}
+
+				clusters := getOrDiscoverResource(d, ctx, cfg, identity.id, identity.name, reg, "auroracluster", d.discoverAuroraClusters)
+				mu.Lock()
+				all = append(all, clusters...)
+				mu.Unlock()
+			}(account, region)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.ClusterID < b.ClusterID
+	})
+	return all, diagnostics.snapshot()
+}
+
+// discoverAuroraClusters lists the provisioned and Serverless v2 Aurora
+// clusters in one account and region, with their usage from CloudWatch.
+// Serverless v1 clusters can't use I/O-Optimized storage and are skipped.
+func (d *Discovery) discoverAuroraClusters(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.AuroraCluster, error) {
+	client := rds.NewFromConfig(cfg)
+
+	var clusters []types.AuroraCluster
+	paginator := rds.NewDescribeDBClustersPaginator(client, &rds.DescribeDBClustersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing DB clusters: %w", err)
+		}
+		for _, c := range page.DBClusters {
+			engine := aws.ToString(c.Engine)
+			// DescribeDBClusters also returns Multi-AZ DB clusters of RDS engines
+			if !strings.HasPrefix(engine, "aurora") || aws.ToString(c.EngineMode) == "serverless" {
+				continue
+			}
+			storageType := aws.ToString(c.StorageType)
+			if storageType == "" {
+				storageType = "aurora"
+			}
+			members := make([]string, 0, len(c.DBClusterMembers))
+			for _, m := range c.DBClusterMembers {
+				members = append(members, aws.ToString(m.DBInstanceIdentifier))
+			}
+			clusters = append(clusters, types.AuroraCluster{
+				AccountID:     accountID,
+				AccountName:   accountName,
+				Region:        region,
+				ClusterID:     aws.ToString(c.DBClusterIdentifier),
+				ARN:           aws.ToString(c.DBClusterArn),
+				Engine:        engine,
+				EngineVersion: aws.ToString(c.EngineVersion),
+				StorageType:   storageType,
+				Members:       members,
+				UsageWindow:   "14d",
+			})
+		}
+	}
+
+	if len(clusters) > 0 {
+		d.fetchAuroraUsage(ctx, cloudwatch.NewFromConfig(cfg), clusters)
+	}
+	return clusters, nil
+}
+
+// fetchAuroraUsage sets each cluster's billed I/Os and average storage over
+// the usage window. Clusters whose metrics couldn't be read are marked
+// unavailable rather than failing discovery.
+func (d *Discovery) fetchAuroraUsage(ctx context.Context, client *cloudwatch.Client, clusters []types.AuroraCluster) {
+	end := time.Now().UTC()
+	metrics := [auroraMetricsPerCluster]struct{ name, stat string }{
+		{"VolumeReadIOPs", "Sum"},
+		{"VolumeWriteIOPs", "Sum"},
+		{"VolumeBytesUsed", "Average"},
+	}
+
+	// GetMetricData allows up to 500 queries per call
+	perCall := 500 / auroraMetricsPerCluster
+	for start := 0; start < len(clusters); start += perCall {
+		batch := clusters[start:min(start+perCall, len(clusters))]
+		queries := make([]cwtypes.MetricDataQuery, 0, len(batch)*auroraMetricsPerCluster)
+		for i, c := range batch {
+			for m, metric := range metrics {
+				queries = append(queries, cwtypes.MetricDataQuery{
+					Id: aws.String(fmt.Sprintf("m%d_%d", i, m)),
+					MetricStat: &cwtypes.MetricStat{
+						Metric: &cwtypes.Metric{
+							Namespace:  aws.String("AWS/RDS"),
+							MetricName: aws.String(metric.name),
+							Dimensions: []cwtypes.Dimension{{Name: aws.String("DBClusterIdentifier"), Value: aws.String(c.ClusterID)}},
+						},
+						Period: aws.Int32(86400),
+						Stat:   aws.String(metric.stat),
+					},
+				})
+			}
+		}
+
+		var (
+			sums   = make([][auroraMetricsPerCluster]float64, len(batch))
+			counts = make([][auroraMetricsPerCluster]int, len(batch))
+			err    error
+		)
+		paginator := cloudwatch.NewGetMetricDataPaginator(client, &cloudwatch.GetMetricDataInput{
+			StartTime:         aws.Time(end.Add(-auroraUsageWindow)),
+			EndTime:           aws.Time(end),
+			MetricDataQueries: queries,
+		})
+		for paginator.HasMorePages() {
+			var out *cloudwatch.GetMetricDataOutput
+			if out, err = paginator.NextPage(ctx); err != nil {
+				break
+			}
+			for _, result := range out.MetricDataResults {
+				var i, m int
+				if _, err := fmt.Sscanf(aws.ToString(result.Id), "m%d_%d", &i, &m); err != nil || i >= len(batch) || m >= auroraMetricsPerCluster {
+					continue
+				}
+				for _, v := range result.Values {
+					sums[i][m] += v
+					counts[i][m]++
+				}
+			}
+		}
+
+		for i := range batch {
+			c := &batch[i]
+			if err != nil {
+				d.logger.Debug("failed to fetch Aurora usage", "cluster", c.ClusterID, "error", err)
+				c.UsageStatus, c.UsageError = types.UsageStatusUnavailable, err.Error()
+				continue
+			}
+			c.ReadIOs, c.WriteIOs = sums[i][0], sums[i][1]
+			if n := counts[i][2]; n > 0 {
+				c.StorageGiB = sums[i][2] / float64(n) / (1 << 30)
+			}
+			c.UsageStatus = types.UsageStatusOK
+			if counts[i][0] == 0 && counts[i][1] == 0 || counts[i][2] == 0 {
+				c.UsageStatus, c.UsageError = types.UsageStatusPartial, "no datapoints in window"
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// autoScalingGroupTag is the tag EC2 Auto Scaling puts on the instances it
+// launches
+const autoScalingGroupTag = "aws:autoscaling:groupName"
+
+// autoScalingMaxNames is the most group names DescribeAutoScalingGroups
+// accepts per call
+const autoScalingMaxNames = 100
+
+// describeAutoScalingCapacity returns the min, max, and desired capacity of
+// the named Auto Scaling groups, making one call per 100 groups
+func describeAutoScalingCapacity(ctx context.Context, cfg aws.Config, names []string) (map[string]types.AutoScalingCapacity, error) {
+	client := autoscaling.NewFromConfig(cfg)
+	capacity := make(map[string]types.AutoScalingCapacity, len(names))
+	for start := 0; start < len(names); start += autoScalingMaxNames {
+		paginator := autoscaling.NewDescribeAutoScalingGroupsPaginator(client, &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: names[start:min(start+autoScalingMaxNames, len(names))],
+			MaxRecords:            aws.Int32(autoScalingMaxNames),
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, g := range output.AutoScalingGroups {
+				capacity[aws.ToString(g.AutoScalingGroupName)] = types.AutoScalingCapacity{
+					MinSize:         int(aws.ToInt32(g.MinSize)),
+					MaxSize:         int(aws.ToInt32(g.MaxSize)),
+					DesiredCapacity: int(aws.ToInt32(g.DesiredCapacity)),
+				}
+			}
+		}
+	}
+	return capacity, nil
+}
@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/smithy-go"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func apiTestConfig(url string) aws.Config {
+	return aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(url),
+	}
+}
+
+func TestDescribeAutoScalingCapacity(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			t.Errorf("request is not signed")
+		}
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		if form.Get("Action") != "DescribeAutoScalingGroups" || form.Get("AutoScalingGroupNames.member.1") != "web" {
+			t.Errorf("unexpected request: %v", form)
+		}
+		if form.Get("NextToken") == "" {
+			io.WriteString(w, `<DescribeAutoScalingGroupsResponse><DescribeAutoScalingGroupsResult>
+				<AutoScalingGroups><member><AutoScalingGroupName>web</AutoScalingGroupName><MinSize>1</MinSize><MaxSize>4</MaxSize><DesiredCapacity>2</DesiredCapacity></member></AutoScalingGroups>
+				<NextToken>page2</NextToken>
+			</DescribeAutoScalingGroupsResult></DescribeAutoScalingGroupsResponse>`)
+			return
+		}
+		io.WriteString(w, `<DescribeAutoScalingGroupsResponse><DescribeAutoScalingGroupsResult>
+			<AutoScalingGroups><member><AutoScalingGroupName>batch</AutoScalingGroupName><MinSize>0</MinSize><MaxSize>10</MaxSize><DesiredCapacity>0</DesiredCapacity></member></AutoScalingGroups>
+		</DescribeAutoScalingGroupsResult></DescribeAutoScalingGroupsResponse>`)
+	}))
+	defer srv.Close()
+
+	got, err := describeAutoScalingCapacity(t.Context(), apiTestConfig(srv.URL), []string{"web", "batch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+	if got["web"] != (types.AutoScalingCapacity{MinSize: 1, MaxSize: 4, DesiredCapacity: 2}) || got["batch"].MaxSize != 10 {
+		t.Fatalf("capacity = %+v", got)
+	}
+}
+
+func TestDescribeAutoScalingCapacityError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>not allowed</Message></Error></ErrorResponse>`)
+	}))
+	defer srv.Close()
+
+	_, err := describeAutoScalingCapacity(t.Context(), apiTestConfig(srv.URL), []string{"web"})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+		t.Fatalf("err = %v, want AccessDenied", err)
+	}
+	if permissionStatus(err) != types.PermissionStatusDenied {
+		t.Fatalf("permissionStatus = %q, want denied", permissionStatus(err))
+	}
+}
@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// ResourceTypes lists the resource type filter names AWS discovery supports
+var ResourceTypes = config.AWSResourceTypes
+
+// regionPattern matches AWS region names such as us-east-1 or us-gov-west-1
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-\d+$`)
+
+// IsRegion reports whether name looks like an AWS region
+func IsRegion(name string) bool {
+	return regionPattern.MatchString(name)
+}
+
+// ResourceDiscoverer adapts Discovery to the cloud.ResourceDiscoverer interface
+type ResourceDiscoverer struct {
+	discovery *Discovery
+	scope     *ScopeResolver
+}
+
+// NewResourceDiscoverer creates a cloud.ResourceDiscoverer for AWS
+func NewResourceDiscoverer(discovery *Discovery, scope *ScopeResolver) *ResourceDiscoverer {
+	return &ResourceDiscoverer{
+		discovery: discovery,
+		scope:     scope,
+	}
+}
+
+// Provider implements cloud.ResourceDiscoverer
+func (r *ResourceDiscoverer) Provider() string {
+	return "aws"
+}
+
+// ResourceTypes implements cloud.ResourceDiscoverer. Only enabled services are
+// reported, so disabled ones are never requested.
+func (r *ResourceDiscoverer) ResourceTypes() []string {
+	return r.scope.config.AWS.EnabledServices()
+}
+
+// HandlesRegion implements cloud.ResourceDiscoverer
+func (r *ResourceDiscoverer) HandlesRegion(region string) bool {
+	return IsRegion(region)
+}
+
+// Discover implements cloud.ResourceDiscoverer
+func (r *ResourceDiscoverer) Discover(ctx context.Context, scope cloud.Scope) (*types.CostResponse, error) {
+	regions, err := r.scope.Regions(ctx, scope.Regions)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := r.scope.Accounts(ctx, scope.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	if len(scope.Accounts) > 0 && len(accounts) == 0 {
+		// The filter only names accounts from other providers
+		return &types.CostResponse{Status: types.ResponseStatusOK, Currency: "USD"}, nil
+	}
+
+	resourceTypes := scope.ResourceTypes
+	if len(resourceTypes) == 0 {
+		resourceTypes = r.ResourceTypes()
+	}
+	return r.discovery.DiscoverResources(ctx, accounts, regions, resourceTypes)
+}
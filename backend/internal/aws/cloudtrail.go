@@ -0,0 +1,296 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cttypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// creationEvents are the CloudTrail events that create each resource type
+var creationEvents = map[string]string{
+	"ec2":                 "RunInstances",
+	"ebs":                 "CreateVolume",
+	"ecs":                 "CreateService",
+	"rds":                 "CreateDBInstance",
+	"eks":                 "CreateCluster",
+	"elb":                 "CreateLoadBalancer",
+	"nat":                 "CreateNatGateway",
+	"eip":                 "AllocateAddress",
+	"secrets":             "CreateSecret",
+	"lambda":              "CreateFunction20150331",
+	"capacityreservation": "CreateCapacityReservation",
+	"dedicatedhost":       "AllocateHosts",
+	"kinesis":             "CreateStream",
+	"firehose":            "CreateDeliveryStream",
+	"sqs":                 "CreateQueue",
+	"sns":                 "CreateTopic",
+	"eventbridge":         "CreateEventBus",
+	"alarm":               "PutMetricAlarm",
+	"dashboard":           "PutDashboard",
+}
+
+const (
+	// cloudTrailMaxPages caps the LookupEvents pages read for one event name
+	// in one account and region (50 events a page)
+	cloudTrailMaxPages = 20
+	// cloudTrailRate is CloudTrail's limit of two LookupEvents calls a second
+	// per account and region
+	cloudTrailRate = 2
+	// cloudTrailMaxAttempts is how many times a throttled or failed
+	// LookupEvents call is tried, with the SDK's backoff between attempts
+	cloudTrailMaxAttempts = 6
+	// cloudTrailTimeout bounds a background refresh of one account and
+	// region's owners: every event name at cloudTrailMaxPages pages
+	cloudTrailTimeout = 5 * time.Minute
+)
+
+// ownerLookup is the result of looking up the creators of one event name,
+// kept until the resource TTL expires whether or not it succeeded
+type ownerLookup struct {
+	creators map[string]string
+	err      error
+}
+
+// eventOwner returns the ARN of the principal that made an event's call, or
+// the event's user name if its record has no ARN
+func eventOwner(e cttypes.Event) string {
+	var record struct {
+		UserIdentity struct {
+			ARN string `json:"arn"`
+		} `json:"userIdentity"`
+	}
+	if json.Unmarshal([]byte(aws.ToString(e.CloudTrailEvent)), &record) == nil && record.UserIdentity.ARN != "" {
+		return record.UserIdentity.ARN
+	}
+	return aws.ToString(e.Username)
+}
+
+// lookupCreators returns who made each eventName call since start, keyed by
+// the names of the resources the events list. ARNs are also keyed by their
+// last segment, e.g. a database identifier. Events come newest first, so a
+// reused name maps to its latest creator. Throttled calls are retried with
+// backoff.
+func lookupCreators(ctx context.Context, cfg aws.Config, eventName string, start time.Time) (map[string]string, error) {
+	owners := make(map[string]string)
+	add := func(name, owner string) {
+		if _, ok := owners[name]; !ok && name != "" {
+			owners[name] = owner
+		}
+	}
+
+	client := cloudtrail.NewFromConfig(cfg, func(o *cloudtrail.Options) {
+		o.Retryer = retry.AddWithMaxAttempts(o.Retryer, cloudTrailMaxAttempts)
+	})
+	paginator := cloudtrail.NewLookupEventsPaginator(client, &cloudtrail.LookupEventsInput{
+		LookupAttributes: []cttypes.LookupAttribute{{AttributeKey: cttypes.LookupAttributeKeyEventName, AttributeValue: aws.String(eventName)}},
+		StartTime:        aws.Time(start),
+		MaxResults:       aws.Int32(50),
+	})
+	for page := 0; page < cloudTrailMaxPages && paginator.HasMorePages(); page++ {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return owners, err
+		}
+		for _, e := range output.Events {
+			owner := eventOwner(e)
+			for _, r := range e.Resources {
+				name := aws.ToString(r.ResourceName)
+				add(name, owner)
+				if strings.HasPrefix(name, "arn:") {
+					add(name[strings.LastIndexAny(name, ":/")+1:], owner)
+				}
+			}
+		}
+	}
+	return owners, nil
+}
+
+// SetOwnerLookup makes discovery look up who created each resource in the
+// last lookbackDays of CloudTrail event history. 0 turns lookups off.
+func (d *Discovery) SetOwnerLookup(lookbackDays int) {
+	d.ownerLookback = time.Duration(lookbackDays) * 24 * time.Hour
+}
+
+// resourceOwners returns the cached creators of resources of the given types
+// in cfg's account and region. Lookups that are missing or older than the
+// resource TTL are refreshed in the background, so a scan never waits on
+// CloudTrail and owners appear from the next scan on. A failed lookup is
+// cached like a successful one and recorded as a diagnostic by every scan
+// that reads it, leaving that type's owners unknown.
+func (d *Discovery) resourceOwners(ctx context.Context, cfg aws.Config, accountID, accountName, region string, resourceTypes []string) map[string]string {
+	var stale []string
+	owners := make(map[string]string)
+	for _, rt := range config.AWSResourceTypes {
+		eventName, ok := creationEvents[rt]
+		if !ok || !shouldDiscover(resourceTypes, rt) {
+			continue
+		}
+
+		d.ownerCacheMu.RLock()
+		entry, cached := d.ownerCache[ownerCacheKey(accountID, region, eventName)]
+		d.ownerCacheMu.RUnlock()
+		if !cached || time.Now().After(entry.expiresAt) {
+			stale = append(stale, eventName)
+		}
+		if entry.value.err != nil {
+			recordDiagnostic(ctx, newDiagnostic("warning", rt, accountID, accountName, region, "lookupEvents", "", entry.value.err))
+			continue
+		}
+		for name, owner := range entry.value.creators {
+			if _, ok := owners[name]; !ok {
+				owners[name] = owner
+			}
+		}
+	}
+
+	if len(stale) > 0 {
+		// The refresh outlives the scan, but keeps its telemetry scope
+		ctx := context.WithoutCancel(ctx)
+		d.sfGroup.DoChan("owners|"+accountID+"|"+region, func() (any, error) {
+			d.refreshOwners(ctx, cfg, accountID, accountName, region, stale)
+			return nil, nil
+		})
+	}
+	return owners
+}
+
+// refreshOwners looks up the creators of each of eventNames in cfg's account
+// and region and caches the results for the resource TTL. The calls share
+// one rate limit per account and region, so concurrent refreshes stay under
+// CloudTrail's limit.
+func (d *Discovery) refreshOwners(ctx context.Context, cfg aws.Config, accountID, accountName, region string, eventNames []string) {
+	ctx, cancel := context.WithTimeout(ctx, cloudTrailTimeout)
+	defer cancel()
+
+	// Clone the options so the limiter isn't appended into a slice shared
+	// with the scan's clients
+	cfg.APIOptions = slices.Clone(cfg.APIOptions)
+	d.cloudTrailLimiter.apply(&cfg, accountID+"|"+region)
+
+	start := time.Now().Add(-d.ownerLookback)
+	for _, eventName := range eventNames {
+		creators, err := lookupCreators(ctx, cfg, eventName, start)
+		if err != nil {
+			d.logger.Warn("failed to look up resource owners",
+				"account", accountName,
+				"region", region,
+				"event", eventName,
+				"error", err)
+			creators = nil
+		}
+		now := time.Now()
+		d.ownerCacheMu.Lock()
+		d.ownerCache[ownerCacheKey(accountID, region, eventName)] = cacheEntry[ownerLookup]{
+			value:     ownerLookup{creators: creators, err: err},
+			scannedAt: now,
+			expiresAt: now.Add(d.resourceTTL),
+		}
+		d.ownerCacheMu.Unlock()
+	}
+}
+
+// ownerCacheKey identifies one event name's lookup in the owner cache
+func ownerCacheKey(accountID, region, eventName string) string {
+	return accountID + "|" + region + "|" + eventName
+}
+
+// setOwners sets the owner of each item found in owners. keys returns the
+// owner map keys an item may be listed under.
+func setOwners[T any](items []T, owners map[string]string, keys func(*T) []string, owner func(*T) *string) {
+	for i := range items {
+		for _, key := range keys(&items[i]) {
+			if o, ok := owners[key]; ok {
+				*owner(&items[i]) = o
+				break
+			}
+		}
+	}
+}
+
+// ownerKeys returns the owner map keys of a resource listed under any of names
+func ownerKeys(accountID, region string, names ...string) []string {
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "" {
+			keys = append(keys, accountID+"|"+region+"|"+name)
+		}
+	}
+	return keys
+}
+
+// applyOwners sets the owner of each discovered resource that has a
+// creation event in owners, which is keyed by ownerKeys. resp must not share
+// resources with the discovery cache.
+func applyOwners(resp *types.CostResponse, owners map[string]string) {
+	setOwners(resp.EC2Instances, owners,
+		func(r *types.EC2Instance) []string { return ownerKeys(r.AccountID, r.Region, r.InstanceID) },
+		func(r *types.EC2Instance) *string { return &r.Owner })
+	setOwners(resp.EBSVolumes, owners,
+		func(r *types.EBSVolume) []string { return ownerKeys(r.AccountID, r.Region, r.VolumeID) },
+		func(r *types.EBSVolume) *string { return &r.Owner })
+	setOwners(resp.ECSServices, owners,
+		func(r *types.ECSService) []string { return ownerKeys(r.AccountID, r.Region, r.ServiceName) },
+		func(r *types.ECSService) *string { return &r.Owner })
+	setOwners(resp.RDSInstances, owners,
+		func(r *types.RDSInstance) []string { return ownerKeys(r.AccountID, r.Region, r.DBInstanceID) },
+		func(r *types.RDSInstance) *string { return &r.Owner })
+	setOwners(resp.EKSClusters, owners,
+		func(r *types.EKSCluster) []string { return ownerKeys(r.AccountID, r.Region, r.ClusterName) },
+		func(r *types.EKSCluster) *string { return &r.Owner })
+	setOwners(resp.LoadBalancers, owners,
+		func(r *types.LoadBalancer) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name) },
+		func(r *types.LoadBalancer) *string { return &r.Owner })
+	setOwners(resp.NATGateways, owners,
+		func(r *types.NATGateway) []string { return ownerKeys(r.AccountID, r.Region, r.ID) },
+		func(r *types.NATGateway) *string { return &r.Owner })
+	setOwners(resp.ElasticIPs, owners,
+		func(r *types.ElasticIP) []string { return ownerKeys(r.AccountID, r.Region, r.AllocationID, r.PublicIP) },
+		func(r *types.ElasticIP) *string { return &r.Owner })
+	setOwners(resp.Secrets, owners,
+		func(r *types.Secret) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name) },
+		func(r *types.Secret) *string { return &r.Owner })
+	setOwners(resp.Lambdas, owners,
+		func(r *types.LambdaFunction) []string {
+			return ownerKeys(r.AccountID, r.Region, r.FunctionARN, r.FunctionName)
+		},
+		func(r *types.LambdaFunction) *string { return &r.Owner })
+	setOwners(resp.CapacityReservations, owners,
+		func(r *types.CapacityReservation) []string { return ownerKeys(r.AccountID, r.Region, r.ID) },
+		func(r *types.CapacityReservation) *string { return &r.Owner })
+	setOwners(resp.DedicatedHosts, owners,
+		func(r *types.DedicatedHost) []string { return ownerKeys(r.AccountID, r.Region, r.HostID) },
+		func(r *types.DedicatedHost) *string { return &r.Owner })
+	setOwners(resp.KinesisStreams, owners,
+		func(r *types.KinesisStream) []string {
+			return ownerKeys(r.AccountID, r.Region, r.StreamARN, r.StreamName)
+		},
+		func(r *types.KinesisStream) *string { return &r.Owner })
+	setOwners(resp.FirehoseStreams, owners,
+		func(r *types.FirehoseStream) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name) },
+		func(r *types.FirehoseStream) *string { return &r.Owner })
+	setOwners(resp.SQSQueues, owners,
+		func(r *types.SQSQueue) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name, r.URL) },
+		func(r *types.SQSQueue) *string { return &r.Owner })
+	setOwners(resp.SNSTopics, owners,
+		func(r *types.SNSTopic) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name) },
+		func(r *types.SNSTopic) *string { return &r.Owner })
+	setOwners(resp.EventBuses, owners,
+		func(r *types.EventBus) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name) },
+		func(r *types.EventBus) *string { return &r.Owner })
+	setOwners(resp.Alarms, owners,
+		func(r *types.Alarm) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name) },
+		func(r *types.Alarm) *string { return &r.Owner })
+	setOwners(resp.Dashboards, owners,
+		func(r *types.Dashboard) []string { return ownerKeys(r.AccountID, r.Region, r.ARN, r.Name) },
+		func(r *types.Dashboard) *string { return &r.Owner })
+}
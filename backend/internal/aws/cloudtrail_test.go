@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestLookupCreators(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var input struct {
+			LookupAttributes []map[string]string
+			NextToken        string
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		if input.LookupAttributes[0]["AttributeValue"] != "CreateDBInstance" {
+			t.Errorf("unexpected lookup: %v", input.LookupAttributes)
+		}
+		if input.NextToken == "" {
+			io.WriteString(w, `{"Events":[{"EventName":"CreateDBInstance","Username":"alice",
+				"Resources":[{"ResourceType":"AWS::RDS::DBInstance","ResourceName":"arn:aws:rds:us-east-1:111111111111:db:orders"}],
+				"CloudTrailEvent":"{\"userIdentity\":{\"arn\":\"arn:aws:sts::111111111111:assumed-role/Admin/alice\"}}"}],
+				"NextToken":"page2"}`)
+			return
+		}
+		// An older event for a reused name, without a parsable record
+		io.WriteString(w, `{"Events":[{"EventName":"CreateDBInstance","Username":"bob",
+			"Resources":[{"ResourceName":"orders"},{"ResourceName":"billing"}],"CloudTrailEvent":"not json"}]}`)
+	}))
+	defer srv.Close()
+
+	owners, err := lookupCreators(t.Context(), apiTestConfig(srv.URL), "CreateDBInstance", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 pages, got %d", calls)
+	}
+	if owners["orders"] != "arn:aws:sts::111111111111:assumed-role/Admin/alice" || owners["billing"] != "bob" {
+		t.Fatalf("unexpected owners: %v", owners)
+	}
+}
+
+func TestResourceOwnersRefreshInBackground(t *testing.T) {
+	var calls atomic.Int32
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail.Load() {
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"__type":"InvalidLookupAttributesException","message":"bad lookup"}`)
+			return
+		}
+		io.WriteString(w, `{"Events":[{"EventName":"CreateDBInstance","Username":"alice","Resources":[{"ResourceName":"orders"}]}]}`)
+	}))
+	defer srv.Close()
+
+	d := NewDiscovery(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60)
+	d.SetOwnerLookup(1)
+	cfg := apiTestConfig(srv.URL)
+	waitForLookup := func() cacheEntry[ownerLookup] {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			d.ownerCacheMu.RLock()
+			entry, ok := d.ownerCache[ownerCacheKey("111", "us-east-1", "CreateDBInstance")]
+			d.ownerCacheMu.RUnlock()
+			if ok {
+				return entry
+			}
+		}
+		t.Fatal("owners were never looked up")
+		return cacheEntry[ownerLookup]{}
+	}
+
+	// The first scan doesn't wait for CloudTrail; the next one gets its owners
+	if owners := d.resourceOwners(t.Context(), cfg, "111", "prod", "us-east-1", []string{"rds"}); len(owners) != 0 {
+		t.Fatalf("owners = %v, want none before the lookup finishes", owners)
+	}
+	waitForLookup()
+	if owners := d.resourceOwners(t.Context(), cfg, "111", "prod", "us-east-1", []string{"rds"}); owners["orders"] != "alice" {
+		t.Fatalf("owners = %v, want orders created by alice", owners)
+	}
+
+	// A failed lookup is cached and reported without calling CloudTrail again
+	fail.Store(true)
+	d.ownerCacheMu.Lock()
+	d.ownerCache = make(map[string]cacheEntry[ownerLookup])
+	d.ownerCacheMu.Unlock()
+	d.resourceOwners(t.Context(), cfg, "111", "prod", "us-east-1", []string{"rds"})
+	if entry := waitForLookup(); entry.value.err == nil {
+		t.Fatal("expected the failed lookup to be cached")
+	}
+	before := calls.Load()
+	diagnostics := newDiagnosticCollector()
+	d.resourceOwners(contextWithDiagnostics(t.Context(), diagnostics), cfg, "111", "prod", "us-east-1", []string{"rds"})
+	if diags := diagnostics.snapshot(); len(diags) != 1 || diags[0].ResourceType != "rds" {
+		t.Fatalf("diagnostics = %+v, want the cached rds failure", diags)
+	}
+	if calls.Load() != before {
+		t.Fatal("a cached failure was looked up again")
+	}
+}
+
+func TestApplyOwners(t *testing.T) {
+	resp := &types.CostResponse{
+		EC2Instances:  []types.EC2Instance{{AccountID: "1", Region: "us-east-1", InstanceID: "i-1"}, {AccountID: "2", Region: "us-east-1", InstanceID: "i-1"}},
+		LoadBalancers: []types.LoadBalancer{{AccountID: "1", Region: "us-east-1", Name: "web"}},
+	}
+	owners := map[string]string{
+		ownerKeys("1", "us-east-1", "i-1")[0]: "alice",
+		ownerKeys("1", "us-east-1", "web")[0]: "bob",
+	}
+
+	applyOwners(resp, owners)
+	if resp.EC2Instances[0].Owner != "alice" || resp.EC2Instances[1].Owner != "" || resp.LoadBalancers[0].Owner != "bob" {
+		t.Fatalf("unexpected owners: %+v %+v", resp.EC2Instances, resp.LoadBalancers)
+	}
+}
@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// highResolutionPeriod is the longest alarm period, in seconds, billed as a
+// high resolution alarm
+const highResolutionPeriod = 59
+
+// SetCloudWatch sets the rates CloudWatch alarms, dashboards, and custom
+// metrics are priced at
+func (d *Discovery) SetCloudWatch(cfg config.CloudWatchConfig) {
+	d.cloudWatch = cfg
+}
+
+// getOrDiscoverAlarms returns cached CloudWatch alarms or discovers them
+func (d *Discovery) getOrDiscoverAlarms(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.Alarm {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "alarm", d.discoverAlarms)
+}
+
+// getOrDiscoverDashboards returns cached CloudWatch dashboards or discovers
+// them
+func (d *Discovery) getOrDiscoverDashboards(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.Dashboard {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "dashboard", d.discoverDashboards)
+}
+
+// getOrDiscoverCustomMetrics returns cached custom metric counts or counts
+// them
+func (d *Discovery) getOrDiscoverCustomMetrics(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.CustomMetrics {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "custommetrics", d.discoverCustomMetrics)
+}
+
+// alarmKind returns a metric alarm's kind and the metrics it's billed for.
+// A metric math alarm is billed for each metric in the expression, and an
+// anomaly detection alarm for three: the metric and the band's bounds.
+func alarmKind(a cwtypes.MetricAlarm) (string, int) {
+	metrics, anomaly := 0, false
+	for _, q := range a.Metrics {
+		if q.MetricStat != nil {
+			metrics++
+		}
+		if strings.Contains(aws.ToString(q.Expression), "ANOMALY_DETECTION_BAND") {
+			anomaly = true
+		}
+	}
+	metrics = max(metrics, 1)
+	switch {
+	case anomaly:
+		return types.AlarmKindAnomalyDetection, 3 * metrics
+	case isHighResolution(a):
+		return types.AlarmKindHighResolution, metrics
+	}
+	return types.AlarmKindStandard, metrics
+}
+
+// isHighResolution reports whether a metric alarm evaluates any metric more
+// often than once a minute
+func isHighResolution(a cwtypes.MetricAlarm) bool {
+	if p := aws.ToInt32(a.Period); p > 0 && p <= highResolutionPeriod {
+		return true
+	}
+	for _, q := range a.Metrics {
+		if q.MetricStat != nil {
+			if p := aws.ToInt32(q.MetricStat.Period); p > 0 && p <= highResolutionPeriod {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// discoverAlarms discovers CloudWatch metric and composite alarms in the
+// specified region
+func (d *Discovery) discoverAlarms(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.Alarm, error) {
+	rates := d.cloudWatch
+	var alarms []types.Alarm
+	paginator := cloudwatch.NewDescribeAlarmsPaginator(cloudwatch.NewFromConfig(cfg), &cloudwatch.DescribeAlarmsInput{
+		AlarmTypes: []cwtypes.AlarmType{cwtypes.AlarmTypeMetricAlarm, cwtypes.AlarmTypeCompositeAlarm},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing alarms: %w", err)
+		}
+		for _, a := range output.MetricAlarms {
+			kind, metrics := alarmKind(a)
+			rate := rates.AlarmPerMonth
+			if isHighResolution(a) {
+				rate = rates.HighResolutionAlarmPerMonth
+			}
+			components := []types.CostComponent{types.MonthlyComponent("alarm metrics", "metric-month", float64(metrics), types.CostValue(rate))}
+			alarms = append(alarms, types.Alarm{
+				AccountID:      accountID,
+				AccountName:    accountName,
+				Region:         region,
+				Name:           aws.ToString(a.AlarmName),
+				ARN:            aws.ToString(a.AlarmArn),
+				Kind:           kind,
+				State:          string(a.StateValue),
+				Metrics:        metrics,
+				HourlyCost:     types.SumComponents(components),
+				CostComponents: components,
+				PriceSource:    pricing.PriceSourceConfig,
+			})
+		}
+		for _, a := range output.CompositeAlarms {
+			components := []types.CostComponent{types.MonthlyComponent("composite alarm", "alarm-month", 1, types.CostValue(rates.CompositeAlarmPerMonth))}
+			alarms = append(alarms, types.Alarm{
+				AccountID:      accountID,
+				AccountName:    accountName,
+				Region:         region,
+				Name:           aws.ToString(a.AlarmName),
+				ARN:            aws.ToString(a.AlarmArn),
+				Kind:           types.AlarmKindComposite,
+				State:          string(a.StateValue),
+				HourlyCost:     types.SumComponents(components),
+				CostComponents: components,
+				PriceSource:    pricing.PriceSourceConfig,
+			})
+		}
+	}
+	return alarms, nil
+}
+
+// discoverDashboards discovers the account's CloudWatch dashboards
+func (d *Discovery) discoverDashboards(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.Dashboard, error) {
+	var dashboards []types.Dashboard
+	paginator := cloudwatch.NewListDashboardsPaginator(cloudwatch.NewFromConfig(cfg), &cloudwatch.ListDashboardsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing dashboards: %w", err)
+		}
+		for _, e := range output.DashboardEntries {
+			var lastModified string
+			if e.LastModified != nil {
+				lastModified = e.LastModified.UTC().Format(time.RFC3339)
+			}
+			components := []types.CostComponent{types.MonthlyComponent("dashboard", "dashboard-month", 1, types.CostValue(d.cloudWatch.DashboardPerMonth))}
+			dashboards = append(dashboards, types.Dashboard{
+				AccountID:      accountID,
+				AccountName:    accountName,
+				Region:         region,
+				Name:           aws.ToString(e.DashboardName),
+				ARN:            aws.ToString(e.DashboardArn),
+				Size:           aws.ToInt64(e.Size),
+				LastModified:   lastModified,
+				HourlyCost:     types.SumComponents(components),
+				CostComponents: components,
+				PriceSource:    pricing.PriceSourceConfig,
+			})
+		}
+	}
+	return dashboards, nil
+}
+
+// isCustomNamespace reports whether a metric namespace holds custom metrics.
+// AWS services publish to namespaces starting with "AWS/".
+func isCustomNamespace(namespace string) bool {
+	return !strings.HasPrefix(namespace, "AWS/")
+}
+
+// discoverCustomMetrics counts the custom metrics in the specified region
+// that received data in the last three hours, by namespace
+func (d *Discovery) discoverCustomMetrics(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.CustomMetrics, error) {
+	counts := make(map[string]int)
+	paginator := cloudwatch.NewListMetricsPaginator(cloudwatch.NewFromConfig(cfg), &cloudwatch.ListMetricsInput{
+		RecentlyActive: cwtypes.RecentlyActivePt3h,
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing metrics: %w", err)
+		}
+		for _, m := range output.Metrics {
+			if ns := aws.ToString(m.Namespace); isCustomNamespace(ns) {
+				counts[ns]++
+			}
+		}
+	}
+
+	namespaces := make([]string, 0, len(counts))
+	for ns := range counts {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	out := make([]types.CustomMetrics, 0, len(namespaces))
+	for _, ns := range namespaces {
+		components := []types.CostComponent{types.MonthlyComponent("metrics", "metric-month", float64(counts[ns]), types.CostValue(d.cloudWatch.MetricPerMonth))}
+		out = append(out, types.CustomMetrics{
+			AccountID:      accountID,
+			AccountName:    accountName,
+			Region:         region,
+			Namespace:      ns,
+			Metrics:        counts[ns],
+			HourlyCost:     types.SumComponents(components),
+			CostComponents: components,
+			PriceSource:    pricing.PriceSourceConfig,
+		})
+	}
+	return out, nil
+}
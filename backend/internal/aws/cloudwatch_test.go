@@ -0,0 +1,50 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestAlarmKind(t *testing.T) {
+	stat := func(period int32) cwtypes.MetricDataQuery {
+		return cwtypes.MetricDataQuery{MetricStat: &cwtypes.MetricStat{Period: aws.Int32(period)}}
+	}
+	tests := []struct {
+		name    string
+		alarm   cwtypes.MetricAlarm
+		kind    string
+		metrics int
+	}{
+		{"single metric", cwtypes.MetricAlarm{Period: aws.Int32(300)}, types.AlarmKindStandard, 1},
+		{"high resolution", cwtypes.MetricAlarm{Period: aws.Int32(10)}, types.AlarmKindHighResolution, 1},
+		{"metric math", cwtypes.MetricAlarm{Metrics: []cwtypes.MetricDataQuery{
+			stat(60), stat(60), {Expression: aws.String("m1+m2")},
+		}}, types.AlarmKindStandard, 2},
+		{"high resolution metric math", cwtypes.MetricAlarm{Metrics: []cwtypes.MetricDataQuery{
+			stat(60), stat(30), {Expression: aws.String("m1/m2")},
+		}}, types.AlarmKindHighResolution, 2},
+		{"anomaly detection", cwtypes.MetricAlarm{Metrics: []cwtypes.MetricDataQuery{
+			stat(300), {Expression: aws.String("ANOMALY_DETECTION_BAND(m1, 2)")},
+		}}, types.AlarmKindAnomalyDetection, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, metrics := alarmKind(tt.alarm)
+			if kind != tt.kind || metrics != tt.metrics {
+				t.Errorf("alarmKind() = %s, %d, want %s, %d", kind, metrics, tt.kind, tt.metrics)
+			}
+		})
+	}
+}
+
+func TestIsCustomNamespace(t *testing.T) {
+	for ns, want := range map[string]bool{"AWS/EC2": false, "CWAgent": true, "MyApp/Orders": true} {
+		if got := isCustomNamespace(ns); got != want {
+			t.Errorf("isCustomNamespace(%q) = %v, want %v", ns, got, want)
+		}
+	}
+}
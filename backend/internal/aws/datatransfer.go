@@ -0,0 +1,172 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// bytesPerGB is the size of a GB as AWS bills data transfer
+const bytesPerGB = 1 << 30
+
+// SetDataTransfer sets the assumptions data transfer costs are estimated
+// with. Nothing is estimated unless cfg is enabled.
+func (d *Discovery) SetDataTransfer(cfg config.DataTransferConfig) {
+	d.dataTransfer = cfg
+}
+
+// getOrDiscoverDataTransfer returns cached data transfer estimates or
+// estimates them
+func (d *Discovery) getOrDiscoverDataTransfer(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.DataTransfer {
+	if !d.dataTransfer.Enabled {
+		return nil
+	}
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, config.DataTransferResourceType, d.discoverDataTransfer)
+}
+
+// discoverDataTransfer estimates the data transfer costs of the running EC2
+// instances and available NAT gateways in a region from the bytes they sent
+// over the last 24 hours. Instances and gateways come from their own
+// discovery caches.
+func (d *Discovery) discoverDataTransfer(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.DataTransfer, error) {
+	var (
+		estimates []types.DataTransfer
+		metrics   []cwtypes.Metric
+	)
+	for _, inst := range d.getOrDiscoverEC2(ctx, cfg, accountID, accountName, region) {
+		if inst.State != "running" {
+			continue
+		}
+		estimates = append(estimates, types.DataTransfer{
+			AccountID: accountID, AccountName: accountName, Region: region,
+			ResourceType: "ec2", ResourceID: inst.InstanceID, Name: inst.Name, Tags: inst.Tags,
+		})
+		metrics = append(metrics, instanceMetric("NetworkOut", inst.InstanceID))
+	}
+	ec2Count := len(estimates)
+
+	var natTypes []string
+	for _, nat := range d.getOrDiscoverNATGateways(ctx, cfg, accountID, accountName, region) {
+		if nat.State != "available" {
+			continue
+		}
+		estimates = append(estimates, types.DataTransfer{
+			AccountID: accountID, AccountName: accountName, Region: region,
+			ResourceType: "nat", ResourceID: nat.ID, Name: nat.Name, Tags: nat.Tags,
+		})
+		natTypes = append(natTypes, nat.Type)
+		metrics = append(metrics,
+			natMetric("BytesOutToDestination", nat.ID),
+			natMetric("BytesInFromSource", nat.ID),
+			natMetric("BytesInFromDestination", nat.ID))
+	}
+	if len(estimates) == 0 {
+		return nil, nil
+	}
+
+	sums, err := fetchDailySums(ctx, cloudwatch.NewFromConfig(cfg), metrics)
+	if err != nil {
+		return nil, fmt.Errorf("fetching network metrics: %w", err)
+	}
+
+	a := d.dataTransfer
+	perHour := func(bytes float64) float64 { return bytes / bytesPerGB / 24 }
+	var out []types.DataTransfer
+	for i := range estimates {
+		e := &estimates[i]
+		if i < ec2Count {
+			sent := perHour(sums[i])
+			e.InternetGBPerHour = sent * a.InternetPercent / 100
+			e.InterAZGBPerHour = sent * a.InterAZPercent / 100
+		} else {
+			// NAT gateways process what they send out and what comes back
+			m := ec2Count + 3*(i-ec2Count)
+			if natTypes[i-ec2Count] == "public" {
+				e.InternetGBPerHour = perHour(sums[m])
+			}
+			e.ProcessedGBPerHour = perHour(sums[m+1] + sums[m+2])
+		}
+
+		if e.InternetGBPerHour > 0 {
+			e.CostComponents = append(e.CostComponents, types.HourlyComponent("internet egress", "GB", e.InternetGBPerHour, types.CostValue(a.InternetPerGB)))
+		}
+		if e.InterAZGBPerHour > 0 {
+			e.CostComponents = append(e.CostComponents, types.HourlyComponent("inter-AZ transfer", "GB", e.InterAZGBPerHour, types.CostValue(a.InterAZPerGB)))
+		}
+		if e.ProcessedGBPerHour > 0 {
+			e.CostComponents = append(e.CostComponents, types.HourlyComponent("NAT data processing", "GB", e.ProcessedGBPerHour, types.CostValue(a.NATPerGB)))
+		}
+		e.HourlyCost = types.SumComponents(e.CostComponents)
+		if e.HourlyCost > 0 {
+			out = append(out, *e)
+		}
+	}
+	return out, nil
+}
+
+func instanceMetric(name, instanceID string) cwtypes.Metric {
+	return cwtypes.Metric{
+		Namespace:  aws.String("AWS/EC2"),
+		MetricName: aws.String(name),
+		Dimensions: []cwtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String(instanceID)}},
+	}
+}
+
+func natMetric(name, natGatewayID string) cwtypes.Metric {
+	return cwtypes.Metric{
+		Namespace:  aws.String("AWS/NATGateway"),
+		MetricName: aws.String(name),
+		Dimensions: []cwtypes.Dimension{{Name: aws.String("NatGatewayId"), Value: aws.String(natGatewayID)}},
+	}
+}
+
+// fetchDailySums returns the sum of each metric over the last 24 hours, in
+// the order of metrics
+func fetchDailySums(ctx context.Context, client *cloudwatch.Client, metrics []cwtypes.Metric) ([]float64, error) {
+	now := time.Now().UTC()
+	sums := make([]float64, len(metrics))
+	// GetMetricData allows up to 500 queries per call
+	for start := 0; start < len(metrics); start += 500 {
+		batch := metrics[start:min(start+500, len(metrics))]
+		queries := make([]cwtypes.MetricDataQuery, len(batch))
+		for i := range batch {
+			queries[i] = cwtypes.MetricDataQuery{
+				Id: aws.String(fmt.Sprintf("m%d", i)),
+				MetricStat: &cwtypes.MetricStat{
+					Metric: &batch[i],
+					Period: aws.Int32(86400),
+					Stat:   aws.String("Sum"),
+				},
+			}
+		}
+
+		paginator := cloudwatch.NewGetMetricDataPaginator(client, &cloudwatch.GetMetricDataInput{
+			StartTime:         aws.Time(now.Add(-24 * time.Hour)),
+			EndTime:           aws.Time(now),
+			MetricDataQueries: queries,
+		})
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, result := range out.MetricDataResults {
+				var i int
+				if _, err := fmt.Sscanf(aws.ToString(result.Id), "m%d", &i); err != nil || i >= len(batch) {
+					continue
+				}
+				for _, v := range result.Values {
+					sums[start+i] += v
+				}
+			}
+		}
+	}
+	return sums, nil
+}
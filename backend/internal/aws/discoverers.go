@@ -0,0 +1,257 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Discovery scopes
+const (
+	ScopeRegional = "regional" // Resources live in a region and are scanned in each one
+	ScopeGlobal   = "global"   // Resources belong to the account and are scanned once per account
+)
+
+// GlobalRegion is the region reported for resources of global services
+const GlobalRegion = "global"
+
+// Target is the account and region a discoverer scans
+type Target struct {
+	Config      aws.Config // Credentials for the account, in Region
+	AccountID   string
+	AccountName string
+	Region      string
+}
+
+// Discoverer finds the resources of one type in an account. Regional
+// discoverers run in every scanned region. Global discoverers run once per
+// account, with a config for the partition's default region, and should
+// report their resources' region as GlobalRegion so they aren't counted once
+// per region.
+type Discoverer interface {
+	// ResourceType returns the resource type filter name, e.g. "ec2"
+	ResourceType() string
+
+	// DisplayName returns the name to show for the resource type, e.g.
+	// "EC2 Instances"
+	DisplayName() string
+
+	// Scope returns ScopeRegional or ScopeGlobal
+	Scope() string
+
+	// IAMActions returns the read-only actions the discoverer needs
+	IAMActions() []string
+
+	// Discover adds the target's resources to out. Failures are recorded as
+	// scan diagnostics rather than returned, so other types are still found.
+	Discover(ctx context.Context, target Target, out *types.CostResponse)
+}
+
+// Registry holds the discoverer for each resource type, in the order they're
+// scanned
+type Registry struct {
+	mu          sync.RWMutex
+	discoverers []Discoverer
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a discoverer. Each resource type can only be registered once.
+func (r *Registry) Register(disc Discoverer) error {
+	if disc.Scope() != ScopeRegional && disc.Scope() != ScopeGlobal {
+		return fmt.Errorf("discoverer %q has unknown scope %q", disc.ResourceType(), disc.Scope())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.discoverers {
+		if existing.ResourceType() == disc.ResourceType() {
+			return fmt.Errorf("a discoverer for %q is already registered", disc.ResourceType())
+		}
+	}
+	r.discoverers = append(r.discoverers, disc)
+	return nil
+}
+
+// Discoverers returns the registered discoverers, in the order they're
+// scanned
+func (r *Registry) Discoverers() []Discoverer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.discoverers)
+}
+
+// builtinDiscoverer is one of the resource types awsCOGS discovers itself
+type builtinDiscoverer struct {
+	resourceType string
+	scope        string
+	discover     func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse)
+}
+
+// boundDiscoverer runs a built-in discoverer with a Discovery's caches and
+// pricing
+type boundDiscoverer struct {
+	builtinDiscoverer
+	d *Discovery
+}
+
+func (b boundDiscoverer) ResourceType() string { return b.resourceType }
+func (b boundDiscoverer) DisplayName() string  { return displayNames[b.resourceType] }
+func (b boundDiscoverer) Scope() string        { return b.scope }
+func (b boundDiscoverer) IAMActions() []string { return resourceActions[b.resourceType] }
+
+func (b boundDiscoverer) Discover(ctx context.Context, target Target, out *types.CostResponse) {
+	b.discover(b.d, ctx, target.Config, target.AccountID, target.AccountName, target.Region, out)
+}
+
+// displayNames are the built-in resource types' display names
+var displayNames = map[string]string{
+	"ec2":                           "EC2 Instances",
+	"ebs":                           "EBS Volumes",
+	"ecs":                           "ECS Services",
+	"rds":                           "RDS Instances",
+	"eks":                           "EKS Clusters",
+	"elb":                           "Load Balancers",
+	"nat":                           "NAT Gateways",
+	"eip":                           "Elastic IPs",
+	"secrets":                       "Secrets",
+	"publicipv4":                    "Public IPv4 Addresses",
+	"lambda":                        "Lambda Functions",
+	"capacityreservation":           "Capacity Reservations",
+	"dedicatedhost":                 "Dedicated Hosts",
+	"kinesis":                       "Kinesis Data Streams",
+	"firehose":                      "Firehose Streams",
+	"sqs":                           "SQS Queues",
+	"sns":                           "SNS Topics",
+	"eventbridge":                   "EventBridge Event Buses",
+	"alarm":                         "CloudWatch Alarms",
+	"dashboard":                     "CloudWatch Dashboards",
+	"custommetrics":                 "CloudWatch Custom Metrics",
+	config.DataTransferResourceType: "Data Transfer",
+}
+
+// builtinDiscoverers are the resource types awsCOGS discovers, in the order
+// they're scanned
+var builtinDiscoverers = []builtinDiscoverer{
+	{"ec2", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.EC2Instances = d.getOrDiscoverEC2(ctx, cfg, accountID, accountName, region)
+	}},
+	{"ebs", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.EBSVolumes = d.getOrDiscoverEBS(ctx, cfg, accountID, accountName, region)
+	}},
+	{"ecs", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.ECSServices = d.getOrDiscoverECS(ctx, cfg, accountID, accountName, region)
+	}},
+	{"rds", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.RDSInstances = d.getOrDiscoverRDS(ctx, cfg, accountID, accountName, region)
+	}},
+	{"eks", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.EKSClusters = d.getOrDiscoverEKS(ctx, cfg, accountID, accountName, region)
+	}},
+	{"elb", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.LoadBalancers = d.getOrDiscoverELB(ctx, cfg, accountID, accountName, region)
+	}},
+	{"nat", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.NATGateways = d.getOrDiscoverNATGateways(ctx, cfg, accountID, accountName, region)
+	}},
+	{"eip", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.ElasticIPs = d.getOrDiscoverElasticIPs(ctx, cfg, accountID, accountName, region)
+	}},
+	{"secrets", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.Secrets = d.getOrDiscoverSecrets(ctx, cfg, accountID, accountName, region)
+	}},
+	{"publicipv4", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.PublicIPv4s = d.getOrDiscoverPublicIPv4s(ctx, cfg, accountID, accountName, region)
+	}},
+	{"lambda", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.Lambdas = d.getOrDiscoverLambdas(ctx, cfg, accountID, accountName, region)
+	}},
+	{"capacityreservation", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.CapacityReservations = d.getOrDiscoverCapacityReservations(ctx, cfg, accountID, accountName, region)
+	}},
+	{"dedicatedhost", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.DedicatedHosts = d.getOrDiscoverDedicatedHosts(ctx, cfg, accountID, accountName, region)
+	}},
+	{"kinesis", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.KinesisStreams = d.getOrDiscoverKinesisStreams(ctx, cfg, accountID, accountName, region)
+	}},
+	{"firehose", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.FirehoseStreams = d.getOrDiscoverFirehoseStreams(ctx, cfg, accountID, accountName, region)
+	}},
+	{"sqs", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.SQSQueues = d.getOrDiscoverSQSQueues(ctx, cfg, accountID, accountName, region)
+	}},
+	{"sns", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.SNSTopics = d.getOrDiscoverSNSTopics(ctx, cfg, accountID, accountName, region)
+	}},
+	{"eventbridge", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.EventBuses = d.getOrDiscoverEventBuses(ctx, cfg, accountID, accountName, region)
+	}},
+	{"alarm", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.Alarms = d.getOrDiscoverAlarms(ctx, cfg, accountID, accountName, region)
+	}},
+	{"dashboard", ScopeGlobal, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.Dashboards = d.getOrDiscoverDashboards(ctx, cfg, accountID, accountName, region)
+	}},
+	{"custommetrics", ScopeRegional, func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+		out.CustomMetrics = d.getOrDiscoverCustomMetrics(ctx, cfg, accountID, accountName, region)
+	}},
+}
+
+// scanTask is one account to scan, in one region for regional discoverers or
+// once for global ones
+type scanTask struct {
+	account     Account
+	region      string
+	discoverers []Discoverer
+}
+
+// scanTasks returns the tasks that scan each account in regions with the
+// discoverers for resourceTypes. Regional discoverers get a task per account
+// and region in the account's partition. Global discoverers get one task per
+// account, in the partition's default region, if any of regions is in the
+// account's partition.
+func scanTasks(discoverers []Discoverer, accounts []Account, regions []string, resourceTypes []string) []scanTask {
+	var regional, global []Discoverer
+	for _, disc := range discoverers {
+		if !shouldDiscover(resourceTypes, disc.ResourceType()) {
+			continue
+		}
+		if disc.Scope() == ScopeGlobal {
+			global = append(global, disc)
+		} else {
+			regional = append(regional, disc)
+		}
+	}
+
+	var tasks []scanTask
+	for _, account := range accounts {
+		partition := account.AccountPartition()
+		inPartition := false
+		for _, region := range regions {
+			// Skip mismatched partition combinations (e.g., commercial account + GovCloud region)
+			if PartitionForRegion(region) != partition {
+				continue
+			}
+			inPartition = true
+			if len(regional) > 0 {
+				tasks = append(tasks, scanTask{account: account, region: region, discoverers: regional})
+			}
+		}
+		if inPartition && len(global) > 0 {
+			tasks = append(tasks, scanTask{account: account, region: DefaultRegionForPartition(partition), discoverers: global})
+		}
+	}
+	return tasks
+}
@@ -0,0 +1,217 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestBuiltinDiscoverersCoverResourceTypes(t *testing.T) {
+	var got []string
+	for _, disc := range builtinDiscoverers {
+		if slices.Contains(got, disc.resourceType) {
+			t.Errorf("%s is discovered twice", disc.resourceType)
+		}
+		if disc.scope != ScopeRegional && disc.scope != ScopeGlobal {
+			t.Errorf("%s has unknown scope %q", disc.resourceType, disc.scope)
+		}
+		got = append(got, disc.resourceType)
+	}
+	if !slices.Equal(got, config.AWSResourceTypes) {
+		t.Fatalf("discoverers = %v, want %v", got, config.AWSResourceTypes)
+	}
+}
+
+func TestGlobalDiscoverersRunOncePerAccount(t *testing.T) {
+	d := NewDiscovery(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60)
+
+	var mu sync.Mutex
+	calls := map[string][]string{} // Scope to account|region
+	record := func(scope, accountID, region string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[scope] = append(calls[scope], accountID+"|"+region)
+	}
+	d.registry = NewRegistry()
+	for _, builtin := range []builtinDiscoverer{
+		{"secrets", ScopeRegional, func(_ *Discovery, _ context.Context, _ aws.Config, accountID, _, region string, out *types.CostResponse) {
+			record(ScopeRegional, accountID, region)
+			out.Secrets = []types.Secret{{AccountID: accountID, Region: region, ARN: "regional", HourlyCost: 1}}
+		}},
+		{"iam", ScopeGlobal, func(_ *Discovery, _ context.Context, _ aws.Config, accountID, _, region string, out *types.CostResponse) {
+			record(ScopeGlobal, accountID, region)
+			out.Secrets = []types.Secret{{AccountID: accountID, Region: GlobalRegion, ARN: "global", HourlyCost: 10}}
+		}},
+	} {
+		if err := d.RegisterDiscoverer(boundDiscoverer{builtin, d}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	accounts := []Account{
+		{ID: "111", Name: "prod"},
+		{ID: "222", Name: "dev"},
+		{ID: "333", Name: "gov", Partition: "aws-us-gov"},
+	}
+	regions := []string{"us-east-1", "eu-west-1", "us-west-2", "us-gov-west-1"}
+	resp, err := d.DiscoverResources(context.Background(), accounts, regions, nil)
+	if err != nil {
+		t.Fatalf("DiscoverResources() error = %v", err)
+	}
+
+	global := calls[ScopeGlobal]
+	slices.Sort(global)
+	if want := []string{"111|us-east-1", "222|us-east-1", "333|us-gov-west-1"}; !slices.Equal(global, want) {
+		t.Fatalf("global scans = %v, want one per account in its partition's default region: %v", global, want)
+	}
+	if n := len(calls[ScopeRegional]); n != 7 {
+		t.Fatalf("regional scans = %d, want 7: %v", n, calls[ScopeRegional])
+	}
+
+	// 7 regional resources at $1 and 3 global ones at $10
+	if len(resp.Secrets) != 10 || resp.TotalCost != 37 {
+		t.Fatalf("got %d resources costing %v, want 10 costing 37", len(resp.Secrets), resp.TotalCost)
+	}
+	for _, r := range resp.Regions {
+		if r.Region == GlobalRegion && r.TotalCost != 30 {
+			t.Errorf("global region total = %v, want 30", r.TotalCost)
+		}
+	}
+
+	// Asking only for a global type skips the regional scans
+	calls = map[string][]string{}
+	if _, err := d.DiscoverResources(context.Background(), accounts, regions, []string{"iam"}); err != nil {
+		t.Fatalf("DiscoverResources() error = %v", err)
+	}
+	if len(calls[ScopeRegional]) != 0 || len(calls[ScopeGlobal]) != 3 {
+		t.Fatalf("scans = %v, want only the 3 global ones", calls)
+	}
+
+	// An account with no scanned region in its partition isn't scanned at all
+	calls = map[string][]string{}
+	if _, err := d.DiscoverResources(context.Background(), accounts, []string{"eu-west-1"}, []string{"iam"}); err != nil {
+		t.Fatalf("DiscoverResources() error = %v", err)
+	}
+	if global := calls[ScopeGlobal]; len(global) != 2 || slices.Contains(global, "333|us-gov-west-1") {
+		t.Fatalf("global scans = %v, want only the commercial accounts", global)
+	}
+}
+
+func TestBuiltinDiscoverersHaveDisplayNames(t *testing.T) {
+	d := NewDiscovery(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60)
+	for _, disc := range d.Discoverers() {
+		if disc.DisplayName() == "" {
+			t.Errorf("%s has no display name", disc.ResourceType())
+		}
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	d := NewDiscovery(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60)
+
+	if err := d.RegisterDiscoverer(&pluginDiscoverer{d: d, config: config.PluginConfig{Name: "ec2"}}); err == nil {
+		t.Error("registering a second ec2 discoverer succeeded")
+	}
+	if err := d.RegisterDiscoverer(&pluginDiscoverer{d: d, config: config.PluginConfig{Name: "mwaa", Scope: "zonal"}}); err == nil {
+		t.Error("registering a discoverer with an unknown scope succeeded")
+	}
+	if err := d.RegisterDiscoverer(&pluginDiscoverer{d: d, config: config.PluginConfig{Name: "mwaa"}}); err != nil {
+		t.Fatalf("RegisterDiscoverer() error = %v", err)
+	}
+	discoverers := d.registry.Discoverers()
+	if last := discoverers[len(discoverers)-1]; last.ResourceType() != "mwaa" || last.Scope() != ScopeRegional {
+		t.Fatalf("last discoverer = %s (%s), want regional mwaa", last.ResourceType(), last.Scope())
+	}
+}
+
+func TestSidecarPlugin(t *testing.T) {
+	var got sidecarRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if got.AccountID == "222" {
+			http.Error(w, "no access", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"resources":[{"id":"env-1","name":"airflow","size":"mw1.small","hourlyCost":0.49}]}`)
+	}))
+	defer server.Close()
+
+	d := NewDiscovery(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60)
+	d.registry = NewRegistry()
+	if err := d.LoadPlugins([]config.PluginConfig{{Name: "mwaa", URL: server.URL, SendCredentials: true}}); err != nil {
+		t.Fatalf("LoadPlugins() error = %v", err)
+	}
+	disc := d.registry.Discoverers()[0]
+
+	cfg := aws.Config{Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "TOKEN")}
+	diagnostics := newDiagnosticCollector()
+	ctx := contextWithDiagnostics(context.Background(), diagnostics)
+
+	out := &types.CostResponse{}
+	disc.Discover(ctx, Target{Config: cfg, AccountID: "111", AccountName: "prod", Region: "us-east-1"}, out)
+	if got.ResourceType != "mwaa" || got.Region != "us-east-1" || got.Credentials == nil || got.Credentials.SessionToken != "TOKEN" {
+		t.Fatalf("sidecar request = %+v", got)
+	}
+	want := types.PluginResource{Type: "mwaa", AccountID: "111", AccountName: "prod", Region: "us-east-1", ID: "env-1", Name: "airflow", Size: "mw1.small", HourlyCost: 0.49}
+	if len(out.PluginResources) != 1 || !reflect.DeepEqual(out.PluginResources[0], want) {
+		t.Fatalf("resources = %+v, want %+v", out.PluginResources, want)
+	}
+
+	// A failing sidecar is reported as a diagnostic, not a failed scan
+	out = &types.CostResponse{}
+	disc.Discover(ctx, Target{Config: cfg, AccountID: "222", AccountName: "dev", Region: "us-east-1"}, out)
+	if len(out.PluginResources) != 0 {
+		t.Fatalf("resources = %+v, want none", out.PluginResources)
+	}
+	diags := diagnostics.snapshot()
+	if len(diags) != 1 || diags[0].ResourceType != "mwaa" || !strings.Contains(diags[0].Message, "403") {
+		t.Fatalf("diagnostics = %+v, want the sidecar's 403", diags)
+	}
+}
+
+func TestSidecarPluginsAddUp(t *testing.T) {
+	sidecar := func(id string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"resources":[{"id":%q,"hourlyCost":1}]}`, id)
+		}))
+	}
+	mwaa, opensearch := sidecar("env-1"), sidecar("domain-1")
+	defer mwaa.Close()
+	defer opensearch.Close()
+
+	d := NewDiscovery(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60)
+	d.registry = NewRegistry()
+	if err := d.LoadPlugins([]config.PluginConfig{{Name: "mwaa", URL: mwaa.URL}, {Name: "opensearch", URL: opensearch.URL}}); err != nil {
+		t.Fatalf("LoadPlugins() error = %v", err)
+	}
+
+	out := &types.CostResponse{}
+	target := Target{AccountID: "111", AccountName: "prod", Region: "us-east-1"}
+	for _, disc := range d.registry.Discoverers() {
+		disc.Discover(context.Background(), target, out)
+	}
+	var got []string
+	for _, r := range out.PluginResources {
+		got = append(got, r.Type+"/"+r.ID)
+	}
+	if !slices.Equal(got, []string{"mwaa/env-1", "opensearch/domain-1"}) {
+		t.Fatalf("resources = %v, want both plugins'", got)
+	}
+}
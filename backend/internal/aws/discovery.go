@@ -4,54 +4,123 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	fhtypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
 	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/selfcost"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
 	"github.com/johnjeffers/awscogs/backend/internal/types"
 )
 
 // cacheEntry holds a cached value with expiration
 type cacheEntry[T any] struct {
 	value     T
+	scannedAt time.Time
 	expiresAt time.Time
 }
 
+// ConfigFactory returns the base AWS config for a region. Every AWS client
+// discovery creates is built from a config it returns, so tests can inject
+// one that serves recorded responses.
+type ConfigFactory func(ctx context.Context, region string) (aws.Config, error)
+
+// DefaultConfigFactory loads the default credential chain and shared config
+func DefaultConfigFactory(ctx context.Context, region string) (aws.Config, error) {
+	return awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+}
+
+// EndpointConfigFactory loads the default config with every service's
+// endpoint set to endpointURL, for LocalStack or moto. An empty URL uses AWS.
+func EndpointConfigFactory(endpointURL string) ConfigFactory {
+	if endpointURL == "" {
+		return DefaultConfigFactory
+	}
+	return func(ctx context.Context, region string) (aws.Config, error) {
+		return awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region), awsconfig.WithBaseEndpoint(endpointURL))
+	}
+}
+
 // Discovery handles AWS resource discovery across accounts and regions
 type Discovery struct {
 	pricingProvider pricing.Provider
+	prices          cloud.PriceSource // On-demand hourly prices, backed by pricingProvider
 	logger          *slog.Logger
 
+	// Loads the config AWS clients are built from
+	loadConfig ConfigFactory
+
 	// Cache settings
 	resourceTTL time.Duration
 	accountTTL  time.Duration
 
+	// Limits AWS API calls per account and region (nil = unlimited)
+	rateLimiter *apiRateLimiter
+
+	// Counts AWS API calls and scan time per account
+	telemetry *apiTelemetry
+
+	// How each account's scans last went
+	accountStatus *accountStatusTracker
+
+	// Assumes account roles (nil = the scanned region's STS endpoint, no chaining)
+	roles *roleAssumer
+
+	// Finds each resource type
+	registry *Registry
+
+	// Assumptions for estimating data transfer costs (disabled = none)
+	dataTransfer config.DataTransferConfig
+
+	// Request rates for SQS, SNS, and EventBridge costs
+	messaging config.MessagingConfig
+
+	// Monthly rates for CloudWatch alarms, dashboards, and custom metrics
+	cloudWatch config.CloudWatchConfig
+
 	// Resource discovery cache - keyed by "accountID|region|resourceType"
 	resourceCache   map[string]cacheEntry[any]
 	resourceCacheMu sync.RWMutex
 
+	// Account ID and alias cache - keyed by accountIdentityKey
+	identityCache   map[string]cacheEntry[accountIdentity]
+	identityCacheMu sync.RWMutex
+
 	// Account discovery cache
 	accountCache   *cacheEntry[[]Account]
 	accountCacheMu sync.RWMutex
@@ -72,6 +141,12 @@ type Discovery struct {
 	usageCache   map[string]cacheEntry[map[string]elbUsageData]
 	usageCacheMu sync.RWMutex
 
+	// Resource creators from CloudTrail - keyed by "accountID|region|eventName"
+	ownerCache        map[string]cacheEntry[ownerLookup]
+	ownerCacheMu      sync.RWMutex
+	ownerLookback     time.Duration   // How far back to look up creators (0 = off)
+	cloudTrailLimiter *apiRateLimiter // Paces owner lookups per account and region
+
 	// Singleflight to prevent concurrent duplicate resource discovery
 	sfGroup singleflight.Group
 
@@ -90,8 +165,15 @@ type elbUsageData struct {
 	Error               string
 }
 
+// accountIdentity is an account's resolved ID and display name
+type accountIdentity struct {
+	id   string
+	name string
+}
+
 type diagnosticsContextKey struct{}
 type discoveryRunContextKey struct{}
+type scanStatsContextKey struct{}
 
 var discoveryRunCounter atomic.Uint64
 
@@ -112,14 +194,45 @@ func contextWithDiscoveryRun(ctx context.Context) context.Context {
 	return context.WithValue(ctx, discoveryRunContextKey{}, discoveryRunCounter.Add(1))
 }
 
+// scanStatsCollector counts cells served from cache and rescanned during a run
+type scanStatsCollector struct {
+	cached    atomic.Int64
+	rescanned atomic.Int64
+}
+
+func contextWithScanStats(ctx context.Context, collector *scanStatsCollector) context.Context {
+	return context.WithValue(ctx, scanStatsContextKey{}, collector)
+}
+
+func recordCellScan(ctx context.Context, cached bool) {
+	collector, ok := ctx.Value(scanStatsContextKey{}).(*scanStatsCollector)
+	if !ok || collector == nil {
+		return
+	}
+	if cached {
+		collector.cached.Add(1)
+	} else {
+		collector.rescanned.Add(1)
+	}
+}
+
+func (c *scanStatsCollector) stats() *types.ScanStats {
+	cached, rescanned := int(c.cached.Load()), int(c.rescanned.Load())
+	return &types.ScanStats{Cells: cached + rescanned, Cached: cached, Rescanned: rescanned}
+}
+
 func recordDiagnostic(ctx context.Context, diagnostic types.Diagnostic) {
 	collector, ok := ctx.Value(diagnosticsContextKey{}).(*diagnosticCollector)
 	if !ok || collector == nil {
 		return
 	}
-	collector.mu.Lock()
-	collector.diagnostics = append(collector.diagnostics, diagnostic)
-	collector.mu.Unlock()
+	collector.add(diagnostic)
+}
+
+func (c *diagnosticCollector) add(diagnostics ...types.Diagnostic) {
+	c.mu.Lock()
+	c.diagnostics = append(c.diagnostics, diagnostics...)
+	c.mu.Unlock()
 }
 
 func (c *diagnosticCollector) snapshot() []types.Diagnostic {
@@ -150,15 +263,118 @@ func newDiagnostic(level, resourceType, accountID, accountName, region, operatio
 
 // NewDiscovery creates a new AWS resource discovery service
 func NewDiscovery(pricingProvider pricing.Provider, logger *slog.Logger, resourceTTLMinutes, accountTTLMinutes int) *Discovery {
-	return &Discovery{
-		pricingProvider: pricingProvider,
-		logger:          logger,
-		resourceTTL:     time.Duration(resourceTTLMinutes) * time.Minute,
-		accountTTL:      time.Duration(accountTTLMinutes) * time.Minute,
-		resourceCache:   make(map[string]cacheEntry[any]),
-		usageCache:      make(map[string]cacheEntry[map[string]elbUsageData]),
-		cwSemaphore:     make(chan struct{}, 10),
+	return NewDiscoveryWithConfigFactory(pricingProvider, logger, resourceTTLMinutes, accountTTLMinutes, DefaultConfigFactory)
+}
+
+// NewDiscoveryWithConfigFactory creates a discovery service whose AWS clients
+// are built from configs loaded by loadConfig
+func NewDiscoveryWithConfigFactory(pricingProvider pricing.Provider, logger *slog.Logger, resourceTTLMinutes, accountTTLMinutes int, loadConfig ConfigFactory) *Discovery {
+	d := &Discovery{
+		pricingProvider:   pricingProvider,
+		prices:            pricing.NewAWSPriceSource(pricingProvider),
+		logger:            logger,
+		loadConfig:        loadConfig,
+		resourceTTL:       time.Duration(resourceTTLMinutes) * time.Minute,
+		accountTTL:        time.Duration(accountTTLMinutes) * time.Minute,
+		resourceCache:     make(map[string]cacheEntry[any]),
+		identityCache:     make(map[string]cacheEntry[accountIdentity]),
+		usageCache:        make(map[string]cacheEntry[map[string]elbUsageData]),
+		ownerCache:        make(map[string]cacheEntry[ownerLookup]),
+		cloudTrailLimiter: newAPIRateLimiter(cloudTrailRate, 1),
+		cwSemaphore:       make(chan struct{}, 10),
+		telemetry:         newAPITelemetry(),
+		accountStatus:     newAccountStatusTracker(),
+		registry:          NewRegistry(),
+	}
+	for _, builtin := range builtinDiscoverers {
+		if err := d.registry.Register(boundDiscoverer{builtin, d}); err != nil {
+			panic(err) // The built-in table is fixed, so this is a programming error
+		}
+	}
+	// Data transfer is estimated from the instances and gateways found above,
+	// and only when enabled, so it isn't in the built-in table
+	if err := d.registry.Register(boundDiscoverer{builtinDiscoverer{
+		resourceType: config.DataTransferResourceType,
+		scope:        ScopeRegional,
+		discover: func(d *Discovery, ctx context.Context, cfg aws.Config, accountID, accountName, region string, out *types.CostResponse) {
+			out.DataTransfer = d.getOrDiscoverDataTransfer(ctx, cfg, accountID, accountName, region)
+		},
+	}, d}); err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Discoverers returns the built-in and plugin discoverers, in the order
+// they're scanned
+func (d *Discovery) Discoverers() []Discoverer {
+	return d.registry.Discoverers()
+}
+
+// RegisterDiscoverer adds a discoverer for a resource type awsCOGS doesn't
+// discover itself
+func (d *Discovery) RegisterDiscoverer(disc Discoverer) error {
+	return d.registry.Register(disc)
+}
+
+// PricingProvider returns the provider used to price discovered resources
+func (d *Discovery) PricingProvider() pricing.Provider {
+	return d.pricingProvider
+}
+
+// SetRateLimit limits the AWS API calls made in each account and region to
+// rate per second, allowing bursts of burst calls. A rate of 0 removes the limit.
+func (d *Discovery) SetRateLimit(rate float64, burst int) {
+	d.rateLimiter = newAPIRateLimiter(rate, burst)
+}
+
+// pricedResourceTypes lists the resource types priced with each kind of
+// price lookup
+var pricedResourceTypes = map[string][]string{
+	pricing.KindEC2:          {"ec2", "capacityreservation", "ecs"},
+	pricing.KindCPUCredits:   {"ec2"},
+	pricing.KindMonitoring:   {"ec2"},
+	pricing.KindEBSOptimized: {"ec2"},
+	pricing.KindEBS:          {"ebs"},
+	pricing.KindECS:          {"ecs"},
+	pricing.KindRDS:          {"rds"},
+	pricing.KindRDSStorage:   {"rds"},
+	pricing.KindAurora:       {"auroracluster"},
+	pricing.KindEKS:          {"eks"},
+	pricing.KindELB:          {"elb"},
+	pricing.KindNAT:          {"nat"},
+	pricing.KindEIP:          {"eip"},
+	pricing.KindSecret:       {"secrets"},
+	pricing.KindPublicIPv4:   {"publicipv4"},
+	pricing.KindLambda:       {"lambda"},
+	pricing.KindHost:         {"dedicatedhost"},
+	pricing.KindKinesis:      {"kinesis"},
+	pricing.KindFirehose:     {"firehose"},
+}
+
+// InvalidatePrices drops the cached resources of every account priced with
+// the given lookups, so the next scan discovers them again with the prices
+// now in the pricing cache. It returns how many cache entries were dropped.
+func (d *Discovery) InvalidatePrices(keys []pricing.PriceKey) int {
+	stale := make(map[string]bool)
+	for _, key := range keys {
+		for _, resourceType := range pricedResourceTypes[key.Kind] {
+			stale[key.Region+"|"+resourceType] = true
+		}
+	}
+
+	d.resourceCacheMu.Lock()
+	defer d.resourceCacheMu.Unlock()
+	dropped := 0
+	for cacheKey := range d.resourceCache {
+		// Keys are account|region|type
+		_, regionType, _ := strings.Cut(cacheKey, "|")
+		if stale[regionType] {
+			delete(d.resourceCache, cacheKey)
+			dropped++
+		}
 	}
+	return dropped
 }
 
 // ClearCaches clears cached discovery, usage, account, region, and pricing data.
@@ -171,6 +387,14 @@ func (d *Discovery) ClearCaches(ctx context.Context) error {
 	d.usageCache = make(map[string]cacheEntry[map[string]elbUsageData])
 	d.usageCacheMu.Unlock()
 
+	d.ownerCacheMu.Lock()
+	d.ownerCache = make(map[string]cacheEntry[ownerLookup])
+	d.ownerCacheMu.Unlock()
+
+	d.identityCacheMu.Lock()
+	d.identityCache = make(map[string]cacheEntry[accountIdentity])
+	d.identityCacheMu.Unlock()
+
 	d.accountCacheMu.Lock()
 	d.accountCache = nil
 	d.accountCacheMu.Unlock()
@@ -214,6 +438,12 @@ func PartitionForRegion(region string) string {
 	return "aws"
 }
 
+// resourceARN builds the ARN of a resource whose API doesn't return one, e.g.
+// resourceARN("ec2", "us-east-1", "111111111111", "instance/i-0abc")
+func resourceARN(service, region, accountID, resource string) string {
+	return fmt.Sprintf("arn:%s:%s:%s:%s:%s", PartitionForRegion(region), service, region, accountID, resource)
+}
+
 // DefaultRegionForPartition returns the default region for a partition
 func DefaultRegionForPartition(partition string) string {
 	switch partition {
@@ -294,243 +524,136 @@ func shouldDiscover(resourceTypes []string, resourceType string) bool {
 	return false
 }
 
-// DiscoverResources discovers all resources across the specified accounts and regions
-// resourceTypes filter: empty means all, otherwise only discover specified types (ec2, ebs, ecs, rds, eks, elb, nat, eip, secrets, publicipv4, lambda)
+// DiscoverResources discovers all resources across the specified accounts and regions.
+// Regional resource types are scanned in each region, and global ones once per account.
+// resourceTypes filter: empty means all, otherwise only discover specified types (ec2, ebs, ecs, rds, eks, elb, nat, eip, secrets, publicipv4, lambda, capacityreservation, dedicatedhost, kinesis, firehose, sqs, sns, eventbridge, alarm, dashboard, custommetrics)
 func (d *Discovery) DiscoverResources(ctx context.Context, accounts []Account, regions []string, resourceTypes []string) (*types.CostResponse, error) {
 	diagnostics := newDiagnosticCollector()
 	ctx = contextWithDiagnostics(ctx, diagnostics)
 	ctx = contextWithDiscoveryRun(ctx)
+	scanStats := &scanStatsCollector{}
+	ctx = contextWithScanStats(ctx, scanStats)
+	calls := selfcost.NewCounter()
+	ctx = selfcost.WithCounter(ctx, calls)
 
 	var (
-		allEC2        []types.EC2Instance
-		allEBS        []types.EBSVolume
-		allECS        []types.ECSService
-		allRDS        []types.RDSInstance
-		allEKS        []types.EKSCluster
-		allELB        []types.LoadBalancer
-		allNAT        []types.NATGateway
-		allEIP        []types.ElasticIP
-		allSecrets    []types.Secret
-		allPublicIPv4 []types.PublicIPv4
-		allLambdas    []types.LambdaFunction
-		mu            sync.Mutex
-		wg            sync.WaitGroup
-		totalCost     types.CostValue
+		result = &types.CostResponse{}
+		owners = make(map[string]string) // Keyed by ownerKeys
+		mu     sync.Mutex
+		wg     sync.WaitGroup
 	)
 
 	// If no accounts specified, use default credentials
 	if len(accounts) == 0 {
 		accounts = defaultAccountsForRegions(regions)
 	}
+	progress := cloud.ProgressFrom(ctx)
 
-	for _, account := range accounts {
-		for _, region := range regions {
-			// Skip mismatched partition combinations (e.g., commercial account + GovCloud region)
-			if account.AccountPartition() != PartitionForRegion(region) {
-				continue
+	for _, task := range scanTasks(d.registry.Discoverers(), accounts, regions, resourceTypes) {
+		wg.Add(1)
+		progress.Add(1)
+		go func(acc Account, reg string, discoverers []Discoverer) {
+			defer wg.Done()
+			defer progress.Done()
+
+			// Each task collects its own diagnostics, so its account's
+			// status can be recorded from them
+			taskDiagnostics := newDiagnosticCollector()
+			ctx := contextWithDiagnostics(d.telemetry.withScope(ctx, acc, reg), taskDiagnostics)
+			start := time.Now()
+			defer func() {
+				d.telemetry.recordScan(acc, time.Since(start))
+				found := taskDiagnostics.snapshot()
+				diagnostics.add(found...)
+				d.accountStatus.record(acc, found, time.Now())
+			}()
+
+			cfg, err := d.getConfigForAccount(ctx, acc, reg)
+			if err != nil {
+				d.logger.Error("failed to get config for account",
+					"account", acc.Name,
+					"region", reg,
+					"error", err)
+				recordDiagnostic(ctx, newDiagnostic("error", "account", acc.ID, acc.Name, reg, "getConfig", "", err))
+				return
 			}
 
-			wg.Add(1)
-			go func(acc Account, reg string) {
-				defer wg.Done()
-
-				cfg, err := d.getConfigForAccount(ctx, acc, reg)
-				if err != nil {
-					d.logger.Error("failed to get config for account",
-						"account", acc.Name,
-						"region", reg,
-						"error", err)
-					recordDiagnostic(ctx, newDiagnostic("error", "account", acc.ID, acc.Name, reg, "getConfig", "", err))
-					return
-				}
-
-				// Get account ID if not set
-				accountID := acc.ID
-				if accountID == "" {
-					accountID, err = d.getAccountID(ctx, cfg)
-					if err != nil {
-						d.logger.Warn("failed to get account ID", "error", err)
-						recordDiagnostic(ctx, newDiagnostic("warning", "account", accountID, acc.Name, reg, "getAccountID", "", err))
-						accountID = "unknown"
-					}
-				}
-
-				// Resolve account name: use configured name, or fetch alias, or fall back to account ID
-				accountName := acc.Name
-				if accountName == "" {
-					accountName = d.getAccountAlias(ctx, cfg)
-					if accountName == "" {
-						accountName = accountID
-					}
-				}
-
-				var ec2Instances []types.EC2Instance
-				var ebsVolumes []types.EBSVolume
-				var ecsServices []types.ECSService
-				var rdsInstances []types.RDSInstance
-				var eksClusters []types.EKSCluster
-				var loadBalancers []types.LoadBalancer
-				var natGateways []types.NATGateway
-				var elasticIPs []types.ElasticIP
-				var secrets []types.Secret
-				var publicIPv4s []types.PublicIPv4
-
-				// Discover EC2 instances
-				if shouldDiscover(resourceTypes, "ec2") {
-					ec2Instances = d.getOrDiscoverEC2(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover EBS volumes
-				if shouldDiscover(resourceTypes, "ebs") {
-					ebsVolumes = d.getOrDiscoverEBS(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover ECS services
-				if shouldDiscover(resourceTypes, "ecs") {
-					ecsServices = d.getOrDiscoverECS(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover RDS instances
-				if shouldDiscover(resourceTypes, "rds") {
-					rdsInstances = d.getOrDiscoverRDS(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover EKS clusters
-				if shouldDiscover(resourceTypes, "eks") {
-					eksClusters = d.getOrDiscoverEKS(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover Load Balancers
-				if shouldDiscover(resourceTypes, "elb") {
-					loadBalancers = d.getOrDiscoverELB(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover NAT Gateways
-				if shouldDiscover(resourceTypes, "nat") {
-					natGateways = d.getOrDiscoverNATGateways(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover Elastic IPs
-				if shouldDiscover(resourceTypes, "eip") {
-					elasticIPs = d.getOrDiscoverElasticIPs(ctx, cfg, accountID, accountName, reg)
-				}
-
-				// Discover Secrets
-				if shouldDiscover(resourceTypes, "secrets") {
-					secrets = d.getOrDiscoverSecrets(ctx, cfg, accountID, accountName, reg)
-				}
+			identity, err := d.resolveAccountIdentity(ctx, acc, cfg)
+			if err != nil {
+				d.logger.Warn("failed to get account ID", "error", err)
+				recordDiagnostic(ctx, newDiagnostic("warning", "account", "", acc.Name, reg, "getAccountID", "", err))
+			}
+			d.telemetry.identify(acc, identity)
+			d.accountStatus.identify(acc, identity)
+			accountID, accountName := identity.id, identity.name
 
-				// Discover Public IPv4 addresses
-				if shouldDiscover(resourceTypes, "publicipv4") {
-					publicIPv4s = d.getOrDiscoverPublicIPv4s(ctx, cfg, accountID, accountName, reg)
-				}
+			found := &types.CostResponse{}
+			target := Target{Config: cfg, AccountID: accountID, AccountName: accountName, Region: reg}
+			regional := false
+			for _, disc := range discoverers {
+				disc.Discover(ctx, target, found)
+				regional = regional || disc.Scope() != ScopeGlobal
+			}
 
-				var lambdas []types.LambdaFunction
-				if shouldDiscover(resourceTypes, "lambda") {
-					lambdas = d.getOrDiscoverLambdas(ctx, cfg, accountID, accountName, reg)
-				}
+			var creators map[string]string
+			if regional && d.ownerLookback > 0 {
+				creators = d.resourceOwners(ctx, cfg, accountID, accountName, reg, resourceTypes)
+			}
 
-				mu.Lock()
-				allEC2 = append(allEC2, ec2Instances...)
-				allEBS = append(allEBS, ebsVolumes...)
-				allECS = append(allECS, ecsServices...)
-				allRDS = append(allRDS, rdsInstances...)
-				allEKS = append(allEKS, eksClusters...)
-				allELB = append(allELB, loadBalancers...)
-				allNAT = append(allNAT, natGateways...)
-				allEIP = append(allEIP, elasticIPs...)
-				allSecrets = append(allSecrets, secrets...)
-				allPublicIPv4 = append(allPublicIPv4, publicIPv4s...)
-				allLambdas = append(allLambdas, lambdas...)
-				mu.Unlock()
-			}(account, region)
-		}
+			mu.Lock()
+			for name, owner := range creators {
+				owners[ownerKeys(accountID, reg, name)[0]] = owner
+			}
+			cloud.Merge(result, found)
+			mu.Unlock()
+		}(task.account, task.region, task.discoverers)
 	}
 
 	wg.Wait()
 
-	// Calculate total cost
-	for _, inst := range allEC2 {
-		totalCost += inst.HourlyCost
-	}
-	for _, vol := range allEBS {
-		totalCost += vol.HourlyCost
-	}
-	for _, svc := range allECS {
-		totalCost += svc.HourlyCost
-	}
-	for _, inst := range allRDS {
-		totalCost += inst.HourlyCost
-	}
-	for _, cluster := range allEKS {
-		totalCost += cluster.HourlyCost
-	}
-	for _, lb := range allELB {
-		totalCost += lb.HourlyCost
-	}
-	for _, nat := range allNAT {
-		totalCost += nat.HourlyCost
-	}
-	for _, eip := range allEIP {
-		totalCost += eip.HourlyCost
-	}
-	for _, secret := range allSecrets {
-		totalCost += secret.HourlyCost
-	}
-	for _, pip := range allPublicIPv4 {
-		totalCost += pip.HourlyCost
-	}
-	for _, fn := range allLambdas {
-		totalCost += fn.HourlyCost
+	result.Status = types.ResponseStatusOK
+	result.Diagnostics = diagnostics.snapshot()
+	if len(result.Diagnostics) > 0 {
+		result.Status = types.ResponseStatusPartial
 	}
+	result.Currency = "USD"
+	result.Scan = scanStats.stats()
+	result.Scan.SelfCost = calls.Estimate()
 
-	// Build account and region summaries
-	accountSummaries := d.buildAccountSummaries(allEC2, allEBS, allECS, allRDS, allEKS, allELB, allNAT, allEIP, allSecrets, allPublicIPv4, allLambdas)
-	regionSummaries := d.buildRegionSummaries(allEC2, allEBS, allECS, allRDS, allEKS, allELB, allNAT, allEIP, allSecrets, allPublicIPv4, allLambdas)
-
-	responseStatus := types.ResponseStatusOK
-	responseDiagnostics := diagnostics.snapshot()
-	if len(responseDiagnostics) > 0 {
-		responseStatus = types.ResponseStatusPartial
+	if len(owners) > 0 {
+		applyOwners(result, owners)
+	}
+	if overrides, ok := d.pricingProvider.(*pricing.Overrides); ok {
+		overrides.Annotate(result)
 	}
 
-	result := &types.CostResponse{
-		TotalCost:     totalCost,
-		Status:        responseStatus,
-		Diagnostics:   responseDiagnostics,
-		Currency:      "USD",
-		Accounts:      accountSummaries,
-		Regions:       regionSummaries,
-		EC2Instances:  allEC2,
-		EBSVolumes:    allEBS,
-		ECSServices:   allECS,
-		RDSInstances:  allRDS,
-		EKSClusters:   allEKS,
-		LoadBalancers: allELB,
-		NATGateways:   allNAT,
-		ElasticIPs:    allEIP,
-		Secrets:       allSecrets,
-		PublicIPv4s:   allPublicIPv4,
-		Lambdas:       allLambdas,
+	// Calculate total cost, and build account and region summaries
+	resources := snapshot.Resources(result)
+	for _, r := range resources {
+		result.TotalCost = result.TotalCost.Add(r.HourlyCost)
 	}
+	result.Accounts = aggregate.AccountSummaries(resources)
+	result.Regions = aggregate.RegionSummaries(resources)
 
 	return result, nil
 }
 
 // getConfigForAccount returns an AWS config for the specified account
 func (d *Discovery) getConfigForAccount(ctx context.Context, account Account, region string) (aws.Config, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	cfg, err := d.loadConfig(ctx, region)
 	if err != nil {
 		return aws.Config{}, fmt.Errorf("loading default config: %w", err)
 	}
 
 	// If a role ARN is specified, assume that role
 	if account.RoleARN != "" {
-		stsClient := sts.NewFromConfig(cfg)
-		creds := stscreds.NewAssumeRoleProvider(stsClient, account.RoleARN)
-		cfg.Credentials = aws.NewCredentialsCache(creds)
+		cfg.Credentials = d.roles.credentials(cfg, account, region)
 	}
 
+	d.rateLimiter.apply(&cfg, accountIdentityKey(account)+"|"+region)
+	d.telemetry.apply(&cfg)
+	cfg.APIOptions = append(cfg.APIOptions, selfcost.APIOption)
+
 	return cfg, nil
 }
 
@@ -544,6 +667,54 @@ func (d *Discovery) getAccountID(ctx context.Context, cfg aws.Config) (string, e
 	return *result.Account, nil
 }
 
+// accountIdentityKey identifies an account's credentials for the identity cache
+func accountIdentityKey(acc Account) string {
+	return strings.Join([]string{acc.AccountPartition(), acc.RoleARN, acc.ID, acc.Name}, "|")
+}
+
+// resolveAccountIdentity returns the account's ID and display name. Unset IDs
+// are looked up with STS and unset names fall back to the account alias, then
+// the ID. Lookups are cached for the account TTL so scans served entirely from
+// the resource cache make no API calls. On failure the ID is "unknown".
+func (d *Discovery) resolveAccountIdentity(ctx context.Context, acc Account, cfg aws.Config) (accountIdentity, error) {
+	if acc.ID != "" && acc.Name != "" {
+		return accountIdentity{id: acc.ID, name: acc.Name}, nil
+	}
+
+	key := accountIdentityKey(acc)
+	d.identityCacheMu.RLock()
+	entry, ok := d.identityCache[key]
+	d.identityCacheMu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	identity := accountIdentity{id: acc.ID, name: acc.Name}
+	if identity.id == "" {
+		id, err := d.getAccountID(ctx, cfg)
+		if err != nil {
+			identity.id = "unknown"
+			if identity.name == "" {
+				identity.name = identity.id
+			}
+			return identity, err
+		}
+		identity.id = id
+	}
+	if identity.name == "" {
+		identity.name = d.getAccountAlias(ctx, cfg)
+		if identity.name == "" {
+			identity.name = identity.id
+		}
+	}
+
+	now := time.Now()
+	d.identityCacheMu.Lock()
+	d.identityCache[key] = cacheEntry[accountIdentity]{value: identity, scannedAt: now, expiresAt: now.Add(d.accountTTL)}
+	d.identityCacheMu.Unlock()
+	return identity, nil
+}
+
 // getAccountAlias returns the AWS account alias, or empty string if not set or on error
 func (d *Discovery) getAccountAlias(ctx context.Context, cfg aws.Config) string {
 	iamClient := iam.NewFromConfig(cfg)
@@ -558,6 +729,19 @@ func (d *Discovery) getAccountAlias(ctx context.Context, cfg aws.Config) string
 	return ""
 }
 
+// DefaultProfileRegion returns the region of the default credentials, from
+// AWS_REGION or the shared config profile
+func DefaultProfileRegion(ctx context.Context) (string, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("loading default config: %w", err)
+	}
+	if cfg.Region == "" {
+		return "", fmt.Errorf("no default region; set AWS_REGION or a region in the AWS profile")
+	}
+	return cfg.Region, nil
+}
+
 // DiscoverRegions returns all enabled regions for the current account
 func (d *Discovery) DiscoverRegions(ctx context.Context) ([]string, error) {
 	// Check cache first
@@ -570,7 +754,7 @@ func (d *Discovery) DiscoverRegions(ctx context.Context) ([]string, error) {
 	}
 	d.regionCacheMu.RUnlock()
 
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"))
+	cfg, err := d.loadConfig(ctx, "us-east-1")
 	if err != nil {
 		return nil, fmt.Errorf("loading default config: %w", err)
 	}
@@ -706,7 +890,7 @@ func (d *Discovery) DiscoverGovCloudAccounts(ctx context.Context, assumeRoleName
 }
 
 func (d *Discovery) discoverAccountsInPartition(ctx context.Context, partition, assumeRoleName string) ([]Account, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(DefaultRegionForPartition(partition)))
+	cfg, err := d.loadConfig(ctx, DefaultRegionForPartition(partition))
 	if err != nil {
 		return nil, fmt.Errorf("loading default config: %w", err)
 	}
@@ -757,11 +941,15 @@ func (d *Discovery) discoverAccountsInPartition(ctx context.Context, partition,
 	return accounts, nil
 }
 
-// discoverEC2 discovers EC2 instances in the specified region
+// discoverEC2 discovers EC2 instances in the specified region. Running
+// instances are priced for their tenancy, and burstable instances in
+// unlimited mode are charged for the surplus CPU credits they spent in the
+// last 24 hours.
 func (d *Discovery) discoverEC2(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.EC2Instance, error) {
 	client := ec2.NewFromConfig(cfg)
 
 	var instances []types.EC2Instance
+	var burstable []int // Indexes of running burstable instances
 	paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{})
 
 	for paginator.HasMorePages() {
@@ -780,39 +968,308 @@ func (d *Discovery) discoverEC2(ctx context.Context, cfg aws.Config, accountID,
 				name := getEC2Name(inst.Tags)
 				instanceType := string(inst.InstanceType)
 				state := string(inst.State.Name)
+				tenancy := "default"
+				if inst.Placement != nil && inst.Placement.Tenancy != "" {
+					tenancy = string(inst.Placement.Tenancy)
+				}
+				var reservationID string
+				if inst.CapacityReservationId != nil {
+					reservationID = *inst.CapacityReservationId
+				}
 
 				// Get pricing (only for running instances)
 				var hourlyCost types.CostValue
-				if inst.State.Name == ec2types.InstanceStateNameRunning {
-					price, err := d.pricingProvider.GetEC2Price(ctx, region, instanceType)
+				var components []types.CostComponent
+				priceCtx, freshness := pricing.TrackFreshness(ctx)
+				running := inst.State.Name == ec2types.InstanceStateNameRunning
+				if running {
+					price, err := d.prices.HourlyPrice(priceCtx, cloud.PriceQuery{Service: "ec2", Region: region, SKU: instanceType, Attributes: map[string]string{"tenancy": tenancy}})
 					if err != nil {
 						d.logger.Warn("failed to get EC2 price",
 							"instanceType", instanceType,
+							"tenancy", tenancy,
 							"region", region,
 							"error", err)
 						recordDiagnostic(ctx, newDiagnostic("warning", "ec2", accountID, accountName, region, "pricing", aws.ToString(inst.InstanceId), err))
 					} else {
 						hourlyCost = price
+						components = []types.CostComponent{types.HourlyComponent("compute", "hour", 1, price)}
+					}
+					for _, c := range d.ec2Surcharges(priceCtx, accountID, accountName, region, inst) {
+						hourlyCost = hourlyCost.Add(c.HourlyCost)
+						components = append(components, c)
 					}
 				}
+				if _, ok := burstableFamily(instanceType); ok && running {
+					burstable = append(burstable, len(instances))
+				}
 
+				createdAt, ageDays := creationAge(inst.LaunchTime, time.Now())
+				var stoppedAt string
+				var stoppedDays int
+				if inst.State.Name == ec2types.InstanceStateNameStopped {
+					stoppedAt, stoppedDays = stopTime(aws.ToString(inst.StateTransitionReason), time.Now())
+				}
+				var volumeIDs []string
+				for _, bdm := range inst.BlockDeviceMappings {
+					if bdm.Ebs != nil && bdm.Ebs.VolumeId != nil {
+						volumeIDs = append(volumeIDs, *bdm.Ebs.VolumeId)
+					}
+				}
 				instances = append(instances, types.EC2Instance{
-					AccountID:    accountID,
-					AccountName:  accountName,
-					Region:       region,
-					InstanceID:   *inst.InstanceId,
-					Name:         name,
-					InstanceType: instanceType,
-					State:        state,
-					HourlyCost:   hourlyCost,
+					AccountID:             accountID,
+					AccountName:           accountName,
+					Region:                region,
+					InstanceID:            *inst.InstanceId,
+					ARN:                   resourceARN("ec2", region, accountID, "instance/"+*inst.InstanceId),
+					Name:                  name,
+					InstanceType:          instanceType,
+					State:                 state,
+					Architecture:          string(inst.Architecture),
+					Tenancy:               tenancy,
+					CapacityReservationID: reservationID,
+					AutoScalingGroup:      getEC2Tags(inst.Tags)[autoScalingGroupTag],
+					VPCID:                 aws.ToString(inst.VpcId),
+					SubnetID:              aws.ToString(inst.SubnetId),
+					CreatedAt:             createdAt,
+					AgeDays:               ageDays,
+					StoppedAt:             stoppedAt,
+					StoppedDays:           stoppedDays,
+					VolumeIDs:             volumeIDs,
+					HourlyCost:            hourlyCost,
+					Tags:                  getEC2Tags(inst.Tags),
+					CostComponents:        components,
+					PriceSource:           freshness.Source(),
+					PriceAsOf:             freshness.AsOf(),
 				})
 			}
 		}
 	}
 
+	if len(burstable) > 0 {
+		d.applyCPUCreditCharges(ctx, cfg, accountID, accountName, region, instances, burstable)
+	}
+	d.applyAutoScalingCapacity(ctx, cfg, accountID, accountName, region, instances)
+
 	return instances, nil
 }
 
+// applyAutoScalingCapacity records the capacity of each instance's Auto
+// Scaling group
+func (d *Discovery) applyAutoScalingCapacity(ctx context.Context, cfg aws.Config, accountID, accountName, region string, instances []types.EC2Instance) {
+	var names []string
+	for _, inst := range instances {
+		if inst.AutoScalingGroup != "" && !slices.Contains(names, inst.AutoScalingGroup) {
+			names = append(names, inst.AutoScalingGroup)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	capacity, err := describeAutoScalingCapacity(ctx, cfg, names)
+	if err != nil {
+		d.logger.Warn("failed to describe Auto Scaling groups", "region", region, "error", err)
+		recordDiagnostic(ctx, newDiagnostic("warning", "ec2", accountID, accountName, region, "describeAutoScalingGroups", "", err))
+		return
+	}
+	for i := range instances {
+		if c, ok := capacity[instances[i].AutoScalingGroup]; ok {
+			instances[i].AutoScalingCapacity = &c
+		}
+	}
+}
+
+// detailedMonitoringMetrics is the number of CloudWatch metrics EC2 detailed
+// monitoring bills per instance
+const detailedMonitoringMetrics = 7
+
+// ebsOptimizedSurchargeFamilies are the older instance families where EBS
+// optimization is optional and billed by the hour. Later families are EBS
+// optimized at no extra charge.
+var ebsOptimizedSurchargeFamilies = map[string]bool{
+	"c1": true, "c3": true, "g2": true, "i2": true, "m1": true, "m2": true, "m3": true, "r3": true,
+}
+
+// ec2Surcharges returns cost components for a running instance's optional
+// per-instance charges: detailed monitoring, and EBS optimization on families
+// that bill for it. Charges that can't be priced are reported as diagnostics.
+func (d *Discovery) ec2Surcharges(ctx context.Context, accountID, accountName, region string, inst ec2types.Instance) []types.CostComponent {
+	var components []types.CostComponent
+	instanceID := aws.ToString(inst.InstanceId)
+	instanceType := string(inst.InstanceType)
+
+	if inst.Monitoring != nil && inst.Monitoring.State == ec2types.MonitoringStateEnabled {
+		price, err := d.pricingProvider.GetDetailedMonitoringPrice(ctx, region)
+		if err != nil {
+			d.logger.Warn("failed to get detailed monitoring price", "region", region, "error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "ec2", accountID, accountName, region, "pricing", instanceID, err))
+		} else {
+			components = append(components, types.MonthlyComponent("detailed monitoring", "metric-month", detailedMonitoringMetrics, price))
+		}
+	}
+
+	family, _, _ := strings.Cut(instanceType, ".")
+	if aws.ToBool(inst.EbsOptimized) && ebsOptimizedSurchargeFamilies[family] {
+		price, err := d.pricingProvider.GetEBSOptimizedPrice(ctx, region, instanceType)
+		if err != nil {
+			d.logger.Warn("failed to get EBS-optimized price", "instanceType", instanceType, "region", region, "error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "ec2", accountID, accountName, region, "pricing", instanceID, err))
+		} else {
+			components = append(components, types.HourlyComponent("EBS optimization", "hour", 1, price))
+		}
+	}
+
+	return components
+}
+
+// burstableFamilies are the instance families that earn CPU credits
+var burstableFamilies = map[string]bool{"t2": true, "t3": true, "t3a": true, "t4g": true}
+
+// burstableFamily returns the family of a burstable instance type, e.g. "t3"
+// for "t3.micro"
+func burstableFamily(instanceType string) (string, bool) {
+	family, _, _ := strings.Cut(instanceType, ".")
+	return family, burstableFamilies[family]
+}
+
+// applyCPUCreditCharges records the credit mode of the burstable instances at
+// idx and adds a surplus CPU credit charge to those in unlimited mode. Surplus
+// credits are billed per vCPU-hour, and one credit is one vCPU-minute; the
+// hourly charge is the last 24 hours' charged credits averaged over the day.
+func (d *Discovery) applyCPUCreditCharges(ctx context.Context, cfg aws.Config, accountID, accountName, region string, instances []types.EC2Instance, idx []int) {
+	ids := make([]string, len(idx))
+	for i, n := range idx {
+		ids[i] = instances[n].InstanceID
+	}
+
+	modes, err := describeCreditModes(ctx, ec2.NewFromConfig(cfg), ids)
+	if err != nil {
+		d.logger.Warn("failed to describe instance credit specifications", "region", region, "error", err)
+		recordDiagnostic(ctx, newDiagnostic("warning", "ec2", accountID, accountName, region, "credit specification", "", err))
+		return
+	}
+
+	var unlimited []string
+	for _, n := range idx {
+		inst := &instances[n]
+		inst.CPUCreditMode = modes[inst.InstanceID]
+		if inst.CPUCreditMode == "unlimited" {
+			unlimited = append(unlimited, inst.InstanceID)
+		}
+	}
+	if len(unlimited) == 0 {
+		return
+	}
+
+	charged, err := fetchSurplusCreditsCharged(ctx, cloudwatch.NewFromConfig(cfg), unlimited)
+	if err != nil {
+		d.logger.Warn("failed to fetch CPUSurplusCreditsCharged", "region", region, "error", err)
+		recordDiagnostic(ctx, newDiagnostic("warning", "ec2", accountID, accountName, region, "cpu credits", "", err))
+		return
+	}
+
+	for _, n := range idx {
+		inst := &instances[n]
+		credits := charged[inst.InstanceID]
+		if credits <= 0 {
+			continue
+		}
+		family, _ := burstableFamily(inst.InstanceType)
+		priceCtx, freshness := pricing.TrackFreshness(ctx)
+		price, err := d.pricingProvider.GetCPUCreditPrice(priceCtx, region, family)
+		if err != nil {
+			d.logger.Warn("failed to get CPU credit price", "family", family, "region", region, "error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "ec2", accountID, accountName, region, "pricing", inst.InstanceID, err))
+			continue
+		}
+		component := types.HourlyComponent("surplus CPU credits", "vCPU-hour", credits/24/60, price)
+		inst.CostComponents = append(inst.CostComponents, component)
+		inst.HourlyCost = inst.HourlyCost.Add(component.HourlyCost)
+		inst.PriceSource, inst.PriceAsOf = olderPrice(inst.PriceSource, inst.PriceAsOf, freshness)
+	}
+}
+
+// olderPrice returns whichever of the given price source and the prices
+// recorded in f was fetched first. A missing price is kept.
+func olderPrice(source, asOf string, f *pricing.Freshness) (string, string) {
+	if source == pricing.PriceSourceMissing {
+		return source, asOf
+	}
+	if f.Source() != "" && (source == "" || f.AsOf() < asOf) {
+		return f.Source(), f.AsOf()
+	}
+	return source, asOf
+}
+
+// describeCreditModes returns the CPU credit mode of each instance
+func describeCreditModes(ctx context.Context, client *ec2.Client, instanceIDs []string) (map[string]string, error) {
+	modes := make(map[string]string, len(instanceIDs))
+	// Instance IDs can't be combined with MaxResults, and at most 1000 are
+	// allowed per call
+	for start := 0; start < len(instanceIDs); start += 1000 {
+		end := min(start+1000, len(instanceIDs))
+		out, err := client.DescribeInstanceCreditSpecifications(ctx, &ec2.DescribeInstanceCreditSpecificationsInput{
+			InstanceIds: instanceIDs[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range out.InstanceCreditSpecifications {
+			modes[aws.ToString(spec.InstanceId)] = aws.ToString(spec.CpuCredits)
+		}
+	}
+	return modes, nil
+}
+
+// fetchSurplusCreditsCharged returns the surplus CPU credits charged to each
+// instance over the last 24 hours
+func fetchSurplusCreditsCharged(ctx context.Context, client *cloudwatch.Client, instanceIDs []string) (map[string]float64, error) {
+	now := time.Now().UTC()
+	charged := make(map[string]float64, len(instanceIDs))
+	// GetMetricData allows up to 500 queries per call
+	for start := 0; start < len(instanceIDs); start += 500 {
+		batch := instanceIDs[start:min(start+500, len(instanceIDs))]
+		queries := make([]cwtypes.MetricDataQuery, len(batch))
+		for i, id := range batch {
+			queries[i] = cwtypes.MetricDataQuery{
+				Id: aws.String(fmt.Sprintf("c%d", i)),
+				MetricStat: &cwtypes.MetricStat{
+					Metric: &cwtypes.Metric{
+						Namespace:  aws.String("AWS/EC2"),
+						MetricName: aws.String("CPUSurplusCreditsCharged"),
+						Dimensions: []cwtypes.Dimension{{Name: aws.String("InstanceId"), Value: aws.String(id)}},
+					},
+					Period: aws.Int32(86400),
+					Stat:   aws.String("Sum"),
+				},
+			}
+		}
+
+		paginator := cloudwatch.NewGetMetricDataPaginator(client, &cloudwatch.GetMetricDataInput{
+			StartTime:         aws.Time(now.Add(-24 * time.Hour)),
+			EndTime:           aws.Time(now),
+			MetricDataQueries: queries,
+		})
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, result := range out.MetricDataResults {
+				var i int
+				if _, err := fmt.Sscanf(aws.ToString(result.Id), "c%d", &i); err != nil || i >= len(batch) {
+					continue
+				}
+				for _, v := range result.Values {
+					charged[batch[i]] += v
+				}
+			}
+		}
+	}
+	return charged, nil
+}
+
 // discoverEBS discovers EBS volumes in the specified region
 func (d *Discovery) discoverEBS(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.EBSVolume, error) {
 	client := ec2.NewFromConfig(cfg)
@@ -846,8 +1303,29 @@ func (d *Discovery) discoverEBS(ctx context.Context, cfg aws.Config, accountID,
 				throughput = *vol.Throughput
 			}
 
+			var attachedInstanceIDs []string
+			var device, attachTime string
+			for _, attachment := range vol.Attachments {
+				if attachment.InstanceId == nil {
+					continue
+				}
+				if len(attachedInstanceIDs) == 0 {
+					device = aws.ToString(attachment.Device)
+					if attachment.AttachTime != nil {
+						attachTime = attachment.AttachTime.UTC().Format(time.RFC3339)
+					}
+				}
+				attachedInstanceIDs = append(attachedInstanceIDs, *attachment.InstanceId)
+			}
+			var attachedInstanceID string
+			if len(attachedInstanceIDs) > 0 {
+				attachedInstanceID = attachedInstanceIDs[0]
+			}
+
 			// Get pricing
-			hourlyCost, err := d.pricingProvider.GetEBSPrice(ctx, region, volumeType, size, iops, throughput)
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
+			components, err := d.pricingProvider.GetEBSCostComponents(priceCtx, region, volumeType, size, iops, throughput)
+			hourlyCost := types.SumComponents(components)
 			if err != nil {
 				d.logger.Warn("failed to get EBS price",
 					"volumeType", volumeType,
@@ -856,18 +1334,30 @@ func (d *Discovery) discoverEBS(ctx context.Context, cfg aws.Config, accountID,
 				recordDiagnostic(ctx, newDiagnostic("warning", "ebs", accountID, accountName, region, "pricing", aws.ToString(vol.VolumeId), err))
 			}
 
+			createdAt, ageDays := creationAge(vol.CreateTime, time.Now())
 			volumes = append(volumes, types.EBSVolume{
-				AccountID:   accountID,
-				AccountName: accountName,
-				Region:      region,
-				VolumeID:    *vol.VolumeId,
-				Name:        name,
-				VolumeType:  volumeType,
-				Size:        size,
-				IOPS:        iops,
-				Throughput:  throughput,
-				State:       state,
-				HourlyCost:  hourlyCost,
+				AccountID:           accountID,
+				AccountName:         accountName,
+				Region:              region,
+				VolumeID:            *vol.VolumeId,
+				ARN:                 resourceARN("ec2", region, accountID, "volume/"+*vol.VolumeId),
+				Name:                name,
+				VolumeType:          volumeType,
+				Size:                size,
+				IOPS:                iops,
+				Throughput:          throughput,
+				State:               state,
+				CreatedAt:           createdAt,
+				AgeDays:             ageDays,
+				HourlyCost:          hourlyCost,
+				Tags:                getEC2Tags(vol.Tags),
+				AttachedInstanceIDs: attachedInstanceIDs,
+				AttachedInstanceID:  attachedInstanceID,
+				Device:              device,
+				AttachTime:          attachTime,
+				CostComponents:      components,
+				PriceSource:         freshness.Source(),
+				PriceAsOf:           freshness.AsOf(),
 			})
 		}
 	}
@@ -928,8 +1418,10 @@ func (d *Discovery) discoverRDS(ctx context.Context, cfg aws.Config, accountID,
 
 			// Get pricing for running instances (exclude stopped/deleted states)
 			var hourlyCost types.CostValue
+			var components []types.CostComponent
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
 			if !isRDSNonBillableState(state) {
-				price, err := d.pricingProvider.GetRDSPrice(ctx, region, instanceClass, engine, multiAZ)
+				price, err := d.prices.HourlyPrice(priceCtx, rdsPriceQuery(region, instanceClass, engine, multiAZ))
 				if err != nil {
 					d.logger.Warn("failed to get RDS price",
 						"instanceClass", instanceClass,
@@ -938,24 +1430,51 @@ func (d *Discovery) discoverRDS(ctx context.Context, cfg aws.Config, accountID,
 						"error", err)
 					recordDiagnostic(ctx, newDiagnostic("warning", "rds", accountID, accountName, region, "pricing", aws.ToString(inst.DBInstanceIdentifier), err))
 				} else {
-					hourlyCost = price
+					components = d.rdsComputeComponents(priceCtx, region, instanceClass, engine, multiAZ, price)
+
+					// Aurora storage is billed per cluster, not per instance
+					if allocatedStorage > 0 && !strings.HasPrefix(storageType, "aurora") {
+						storagePrice, err := d.pricingProvider.GetRDSStoragePrice(priceCtx, region, storageType, multiAZ)
+						if err != nil {
+							d.logger.Warn("failed to get RDS storage price",
+								"storageType", storageType,
+								"region", region,
+								"error", err)
+							recordDiagnostic(ctx, newDiagnostic("warning", "rds", accountID, accountName, region, "storagePricing", aws.ToString(inst.DBInstanceIdentifier), err))
+						} else {
+							components = append(components, types.MonthlyComponent("storage", "GB-month", float64(allocatedStorage), storagePrice))
+						}
+					}
+					hourlyCost = types.SumComponents(components)
 				}
 			}
 
+			vpcID, subnetID := rdsNetwork(inst)
+			createdAt, ageDays := creationAge(inst.InstanceCreateTime, time.Now())
 			instances = append(instances, types.RDSInstance{
-				AccountID:        accountID,
-				AccountName:      accountName,
-				Region:           region,
-				DBInstanceID:     *inst.DBInstanceIdentifier,
-				Name:             name,
-				Engine:           engine,
-				EngineVersion:    engineVersion,
-				InstanceClass:    instanceClass,
-				MultiAZ:          multiAZ,
-				StorageType:      storageType,
-				AllocatedStorage: allocatedStorage,
-				State:            state,
-				HourlyCost:       hourlyCost,
+				AccountID:          accountID,
+				AccountName:        accountName,
+				Region:             region,
+				DBInstanceID:       *inst.DBInstanceIdentifier,
+				ARN:                aws.ToString(inst.DBInstanceArn),
+				Name:               name,
+				Engine:             engine,
+				EngineVersion:      engineVersion,
+				InstanceClass:      instanceClass,
+				MultiAZ:            multiAZ,
+				StorageType:        storageType,
+				AllocatedStorage:   allocatedStorage,
+				State:              state,
+				VPCID:              vpcID,
+				SubnetID:           subnetID,
+				PubliclyAccessible: aws.ToBool(inst.PubliclyAccessible),
+				CreatedAt:          createdAt,
+				AgeDays:            ageDays,
+				HourlyCost:         hourlyCost,
+				Tags:               getRDSTags(inst.TagList),
+				CostComponents:     components,
+				PriceSource:        freshness.Source(),
+				PriceAsOf:          freshness.AsOf(),
 			})
 		}
 	}
@@ -963,11 +1482,49 @@ func (d *Discovery) discoverRDS(ctx context.Context, cfg aws.Config, accountID,
 	return instances, nil
 }
 
-// discoverECS discovers ECS services in the specified region
-func (d *Discovery) discoverECS(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.ECSService, error) {
-	client := ecs.NewFromConfig(cfg)
-
-	var services []types.ECSService
+// rdsNetwork returns an RDS instance's VPC and the subnet in its subnet group
+// for the instance's availability zone
+func rdsNetwork(inst rdstypes.DBInstance) (vpcID, subnetID string) {
+	group := inst.DBSubnetGroup
+	if group == nil {
+		return "", ""
+	}
+	az := aws.ToString(inst.AvailabilityZone)
+	for _, subnet := range group.Subnets {
+		if az != "" && subnet.SubnetAvailabilityZone != nil && aws.ToString(subnet.SubnetAvailabilityZone.Name) == az {
+			subnetID = aws.ToString(subnet.SubnetIdentifier)
+			break
+		}
+	}
+	return aws.ToString(group.VpcId), subnetID
+}
+
+// rdsPriceQuery identifies an RDS instance's hourly compute price
+func rdsPriceQuery(region, instanceClass, engine string, multiAZ bool) cloud.PriceQuery {
+	return cloud.PriceQuery{Service: "rds", Region: region, SKU: instanceClass, Attributes: map[string]string{"engine": engine, "multiAz": strconv.FormatBool(multiAZ)}}
+}
+
+// rdsComputeComponents splits an RDS instance price into single-AZ compute and
+// the Multi-AZ standby uplift. If the single-AZ price isn't available the whole
+// price is reported as compute.
+func (d *Discovery) rdsComputeComponents(ctx context.Context, region, instanceClass, engine string, multiAZ bool, price types.CostValue) []types.CostComponent {
+	if multiAZ {
+		single, err := d.prices.HourlyPrice(ctx, rdsPriceQuery(region, instanceClass, engine, false))
+		if err == nil && single > 0 && single <= price {
+			return []types.CostComponent{
+				types.HourlyComponent("compute", "hour", 1, single),
+				types.HourlyComponent("multi-az standby", "hour", 1, price-single),
+			}
+		}
+	}
+	return []types.CostComponent{types.HourlyComponent("compute", "hour", 1, price)}
+}
+
+// discoverECS discovers ECS services in the specified region
+func (d *Discovery) discoverECS(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.ECSService, error) {
+	client := ecs.NewFromConfig(cfg)
+
+	var services []types.ECSService
 
 	// List all clusters
 	clusterPaginator := ecs.NewListClustersPaginator(client, &ecs.ListClustersInput{})
@@ -984,6 +1541,11 @@ func (d *Discovery) discoverECS(ctx context.Context, cfg aws.Config, accountID,
 				clusterName = clusterArn[idx:]
 			}
 
+			// Container instance capacity is looked up for the first EC2
+			// service with running tasks
+			var capacity *ecsCapacity
+			taskDefinitions := make(map[string]*ecstypes.TaskDefinition)
+
 			// List services in this cluster
 			servicePaginator := ecs.NewListServicesPaginator(client, &ecs.ListServicesInput{
 				Cluster: &clusterArn,
@@ -1022,10 +1584,7 @@ func (d *Discovery) discoverECS(ctx context.Context, cfg aws.Config, accountID,
 						serviceName = *svc.ServiceName
 					}
 
-					launchType := "EC2"
-					if svc.LaunchType != "" {
-						launchType = string(svc.LaunchType)
-					}
+					launchType := ecsLaunchType(svc)
 
 					state := "ACTIVE"
 					if svc.Status != nil {
@@ -1044,8 +1603,10 @@ func (d *Discovery) discoverECS(ctx context.Context, cfg aws.Config, accountID,
 
 					// Get pricing for Fargate services
 					var hourlyCost types.CostValue
+					var components []types.CostComponent
+					priceCtx, freshness := pricing.TrackFreshness(ctx)
 					if launchType == "FARGATE" && runningCount > 0 {
-						price, err := d.pricingProvider.GetECSPrice(ctx, region, launchType, runningCount)
+						price, err := d.pricingProvider.GetECSPrice(priceCtx, region, launchType, runningCount)
 						if err != nil {
 							d.logger.Warn("failed to get ECS price",
 								"service", serviceName,
@@ -1054,20 +1615,59 @@ func (d *Discovery) discoverECS(ctx context.Context, cfg aws.Config, accountID,
 							recordDiagnostic(ctx, newDiagnostic("warning", "ecs", accountID, accountName, region, "pricing", clusterName+"/"+serviceName, err))
 						} else {
 							hourlyCost = price
+							components = []types.CostComponent{types.HourlyComponent("fargate tasks", "task-hour", float64(runningCount), price/types.CostValue(runningCount))}
+						}
+					}
+
+					// Attribute a share of the cluster's container instances to
+					// services running on them
+					var cpuReserved, memoryReserved int32
+					var attributed types.CostValue
+					if launchType == "EC2" && runningCount > 0 {
+						if capacity == nil {
+							c, err := d.ecsClusterCapacity(priceCtx, client, clusterArn, clusterName, accountID, accountName, region)
+							if err != nil {
+								d.logger.Warn("failed to get ECS cluster capacity",
+									"cluster", clusterName,
+									"error", err)
+								recordDiagnostic(ctx, newDiagnostic("warning", "ecs", accountID, accountName, region, "describeContainerInstances", clusterName, err))
+							}
+							capacity = &c
+						}
+						td, err := describeTaskDefinition(ctx, client, taskDefinitions, aws.ToString(svc.TaskDefinition))
+						if err != nil {
+							d.logger.Warn("failed to describe task definition",
+								"service", serviceName,
+								"error", err)
+							recordDiagnostic(ctx, newDiagnostic("warning", "ecs", accountID, accountName, region, "describeTaskDefinition", clusterName+"/"+serviceName, err))
+						} else {
+							cpu, memory := taskReservation(td)
+							cpuReserved, memoryReserved = cpu*runningCount, memory*runningCount
+							attributed = capacity.share(cpuReserved, memoryReserved)
 						}
 					}
 
+					createdAt, ageDays := creationAge(svc.CreatedAt, time.Now())
 					services = append(services, types.ECSService{
-						AccountID:    accountID,
-						AccountName:  accountName,
-						Region:       region,
-						ClusterName:  clusterName,
-						ServiceName:  serviceName,
-						LaunchType:   launchType,
-						DesiredCount: desiredCount,
-						RunningCount: runningCount,
-						State:        state,
-						HourlyCost:   hourlyCost,
+						AccountID:            accountID,
+						AccountName:          accountName,
+						Region:               region,
+						ClusterName:          clusterName,
+						ServiceName:          serviceName,
+						ARN:                  aws.ToString(svc.ServiceArn),
+						LaunchType:           launchType,
+						DesiredCount:         desiredCount,
+						RunningCount:         runningCount,
+						State:                state,
+						CPUReserved:          cpuReserved,
+						MemoryReserved:       memoryReserved,
+						AttributedHourlyCost: attributed,
+						CreatedAt:            createdAt,
+						AgeDays:              ageDays,
+						HourlyCost:           hourlyCost,
+						CostComponents:       components,
+						PriceSource:          freshness.Source(),
+						PriceAsOf:            freshness.AsOf(),
 					})
 				}
 			}
@@ -1082,6 +1682,7 @@ func (d *Discovery) discoverEKS(ctx context.Context, cfg aws.Config, accountID,
 	client := eks.NewFromConfig(cfg)
 
 	var clusters []types.EKSCluster
+	var versions map[string]ekstypes.VersionStatus // Looked up on the first active cluster
 
 	// List all clusters
 	listInput := &eks.ListClustersInput{}
@@ -1128,12 +1729,28 @@ func (d *Discovery) discoverEKS(ctx context.Context, cfg aws.Config, accountID,
 
 			// Get pricing for active clusters
 			var hourlyCost types.CostValue
+			var extendedSupport bool
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
 			if status == "ACTIVE" {
-				price, err := d.pricingProvider.GetEKSPrice(ctx, region)
+				if versions == nil {
+					versions, err = describeEKSVersionStatuses(ctx, client)
+					if err != nil {
+						d.logger.Warn("failed to describe EKS cluster versions",
+							"region", region,
+							"error", err)
+						recordDiagnostic(ctx, newDiagnostic("warning", "eks", accountID, accountName, region, "describeClusterVersions", "", err))
+						// Price the remaining clusters at the standard rate
+						versions = map[string]ekstypes.VersionStatus{}
+					}
+				}
+				extendedSupport = versions[version] == ekstypes.VersionStatusExtendedSupport
+
+				price, err := d.prices.HourlyPrice(priceCtx, cloud.PriceQuery{Service: "eks", Region: region, Attributes: map[string]string{"extendedSupport": strconv.FormatBool(extendedSupport)}})
 				if err != nil {
 					d.logger.Warn("failed to get EKS price",
 						"cluster", clusterName,
 						"region", region,
+						"extendedSupport", extendedSupport,
 						"error", err)
 					recordDiagnostic(ctx, newDiagnostic("warning", "eks", accountID, accountName, region, "pricing", clusterName, err))
 				} else {
@@ -1141,15 +1758,29 @@ func (d *Discovery) discoverEKS(ctx context.Context, cfg aws.Config, accountID,
 				}
 			}
 
+			componentName := "control plane"
+			if extendedSupport {
+				componentName = "control plane (extended support)"
+			}
+
+			createdAt, ageDays := creationAge(cluster.CreatedAt, time.Now())
 			clusters = append(clusters, types.EKSCluster{
-				AccountID:   accountID,
-				AccountName: accountName,
-				Region:      region,
-				ClusterName: clusterName,
-				Status:      status,
-				Version:     version,
-				Platform:    platform,
-				HourlyCost:  hourlyCost,
+				AccountID:       accountID,
+				AccountName:     accountName,
+				Region:          region,
+				ClusterName:     clusterName,
+				ARN:             aws.ToString(cluster.Arn),
+				Status:          status,
+				Version:         version,
+				ExtendedSupport: extendedSupport,
+				Platform:        platform,
+				CreatedAt:       createdAt,
+				AgeDays:         ageDays,
+				HourlyCost:      hourlyCost,
+				Tags:            cluster.Tags,
+				CostComponents:  flatRateComponents(componentName, hourlyCost),
+				PriceSource:     freshness.Source(),
+				PriceAsOf:       freshness.AsOf(),
 			})
 		}
 	}
@@ -1157,6 +1788,23 @@ func (d *Discovery) discoverEKS(ctx context.Context, cfg aws.Config, accountID,
 	return clusters, nil
 }
 
+// describeEKSVersionStatuses returns the support status of every Kubernetes
+// version EKS offers in the client's region
+func describeEKSVersionStatuses(ctx context.Context, client *eks.Client) (map[string]ekstypes.VersionStatus, error) {
+	statuses := make(map[string]ekstypes.VersionStatus)
+	paginator := eks.NewDescribeClusterVersionsPaginator(client, &eks.DescribeClusterVersionsInput{IncludeAll: aws.Bool(true)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.ClusterVersions {
+			statuses[aws.ToString(v.ClusterVersion)] = v.VersionStatus
+		}
+	}
+	return statuses, nil
+}
+
 // discoverELB discovers Elastic Load Balancers (ALB, NLB, and CLB) in the specified region
 func (d *Discovery) discoverELB(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.LoadBalancer, error) {
 	var loadBalancers []types.LoadBalancer
@@ -1202,10 +1850,11 @@ func (d *Discovery) discoverELB(ctx context.Context, cfg aws.Config, accountID,
 			}
 
 			// Get base + LCU pricing for active load balancers
-			var baseHourlyCost, lcuHourlyCost types.CostValue
+			var baseHourlyCost, lcuHourlyCost, lcuRate types.CostValue
 			var consumedLCUs float64
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
 			if state == "active" {
-				base, perLCU, err := d.pricingProvider.GetELBPrice(ctx, region, lbType)
+				base, perLCU, err := d.pricingProvider.GetELBPrice(priceCtx, region, lbType)
 				if err != nil {
 					d.logger.Warn("failed to get ELB price",
 						"name", name,
@@ -1222,13 +1871,15 @@ func (d *Discovery) discoverELB(ctx context.Context, cfg aws.Config, accountID,
 						avgLCUs := d.fetchConsumedLCUs(ctx, cloudwatch.NewFromConfig(cfg), meta)
 						if avgLCUs > 0 {
 							consumedLCUs = avgLCUs
+							lcuRate = perLCU
 							lcuHourlyCost = types.CostValue(avgLCUs) * perLCU
 						}
 					}
 				}
 			}
 
-			loadBalancers = append(loadBalancers, types.LoadBalancer{
+			createdAt, ageDays := creationAge(lb.CreatedTime, time.Now())
+			balancer := types.LoadBalancer{
 				AccountID:      accountID,
 				AccountName:    accountName,
 				Region:         region,
@@ -1237,11 +1888,32 @@ func (d *Discovery) discoverELB(ctx context.Context, cfg aws.Config, accountID,
 				Type:           lbType,
 				Scheme:         scheme,
 				State:          state,
-				HourlyCost:     baseHourlyCost + lcuHourlyCost,
+				VPCID:          aws.ToString(lb.VpcId),
+				SubnetIDs:      elbv2SubnetIDs(lb.AvailabilityZones),
+				CreatedAt:      createdAt,
+				AgeDays:        ageDays,
+				HourlyCost:     baseHourlyCost.Add(lcuHourlyCost),
 				BaseHourlyCost: baseHourlyCost,
 				LCUHourlyCost:  lcuHourlyCost,
 				ConsumedLCUs:   consumedLCUs,
-			})
+				CostComponents: elbCostComponents(baseHourlyCost, consumedLCUs, lcuRate),
+				PriceSource:    freshness.Source(),
+				PriceAsOf:      freshness.AsOf(),
+			}
+			if state == "active" {
+				registered, healthy, err := countTargetsV2(ctx, v2Client, arn)
+				if err != nil {
+					d.logger.Warn("failed to describe load balancer targets",
+						"name", name,
+						"region", region,
+						"error", err)
+					recordDiagnostic(ctx, newDiagnostic("warning", "elb", accountID, accountName, region, "describeTargetHealth", name, err))
+				} else {
+					balancer.RegisteredTargets, balancer.HealthyTargets = &registered, &healthy
+				}
+			}
+			balancer.Warnings = loadBalancerWarnings(balancer)
+			loadBalancers = append(loadBalancers, balancer)
 		}
 	}
 
@@ -1266,7 +1938,8 @@ func (d *Discovery) discoverELB(ctx context.Context, cfg aws.Config, accountID,
 			}
 
 			// Get pricing for classic load balancers (no LCU — CLB uses per-GB data processing)
-			base, _, err := d.pricingProvider.GetELBPrice(ctx, region, "classic")
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
+			base, _, err := d.pricingProvider.GetELBPrice(priceCtx, region, "classic")
 			var baseHourlyCost types.CostValue
 			if err != nil {
 				d.logger.Warn("failed to get CLB price",
@@ -1278,7 +1951,8 @@ func (d *Discovery) discoverELB(ctx context.Context, cfg aws.Config, accountID,
 				baseHourlyCost = base
 			}
 
-			loadBalancers = append(loadBalancers, types.LoadBalancer{
+			createdAt, ageDays := creationAge(lb.CreatedTime, time.Now())
+			balancer := types.LoadBalancer{
 				AccountID:      accountID,
 				AccountName:    accountName,
 				Region:         region,
@@ -1287,15 +1961,128 @@ func (d *Discovery) discoverELB(ctx context.Context, cfg aws.Config, accountID,
 				Type:           "classic",
 				Scheme:         scheme,
 				State:          "active", // CLB doesn't have state in the same way
+				VPCID:          aws.ToString(lb.VPCId),
+				SubnetIDs:      lb.Subnets,
+				CreatedAt:      createdAt,
+				AgeDays:        ageDays,
 				HourlyCost:     baseHourlyCost,
 				BaseHourlyCost: baseHourlyCost,
-			})
+				CostComponents: flatRateComponents("load balancer", baseHourlyCost),
+				PriceSource:    freshness.Source(),
+				PriceAsOf:      freshness.AsOf(),
+			}
+			registered := len(lb.Instances)
+			healthy, err := countInServiceInstances(ctx, v1Client, name, registered)
+			if err != nil {
+				d.logger.Warn("failed to describe classic load balancer instance health",
+					"name", name,
+					"region", region,
+					"error", err)
+				recordDiagnostic(ctx, newDiagnostic("warning", "elb", accountID, accountName, region, "describeInstanceHealth", name, err))
+			} else {
+				balancer.RegisteredTargets, balancer.HealthyTargets = &registered, &healthy
+			}
+			balancer.Warnings = loadBalancerWarnings(balancer)
+			loadBalancers = append(loadBalancers, balancer)
 		}
 	}
 
 	return loadBalancers, nil
 }
 
+// countTargetsV2 counts the targets registered in an ALB's or NLB's target
+// groups and how many of them are healthy
+func countTargetsV2(ctx context.Context, client *elasticloadbalancingv2.Client, lbARN string) (registered, healthy int, err error) {
+	paginator := elasticloadbalancingv2.NewDescribeTargetGroupsPaginator(client, &elasticloadbalancingv2.DescribeTargetGroupsInput{
+		LoadBalancerArn: aws.String(lbARN),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, tg := range page.TargetGroups {
+			health, err := client.DescribeTargetHealth(ctx, &elasticloadbalancingv2.DescribeTargetHealthInput{
+				TargetGroupArn: tg.TargetGroupArn,
+			})
+			if err != nil {
+				return 0, 0, err
+			}
+			for _, target := range health.TargetHealthDescriptions {
+				registered++
+				if target.TargetHealth != nil && target.TargetHealth.State == elbv2types.TargetHealthStateEnumHealthy {
+					healthy++
+				}
+			}
+		}
+	}
+	return registered, healthy, nil
+}
+
+// countInServiceInstances counts the healthy instances behind a classic load
+// balancer
+func countInServiceInstances(ctx context.Context, client *elasticloadbalancing.Client, name string, registered int) (int, error) {
+	if registered == 0 {
+		return 0, nil
+	}
+	out, err := client.DescribeInstanceHealth(ctx, &elasticloadbalancing.DescribeInstanceHealthInput{
+		LoadBalancerName: aws.String(name),
+	})
+	if err != nil {
+		return 0, err
+	}
+	var healthy int
+	for _, state := range out.InstanceStates {
+		if aws.ToString(state.State) == "InService" {
+			healthy++
+		}
+	}
+	return healthy, nil
+}
+
+// loadBalancerWarnings flags classic load balancers, which are deprecated,
+// and load balancers with no registered or no healthy targets. An idle load
+// balancer's warning carries the saving from deleting it.
+func loadBalancerWarnings(lb types.LoadBalancer) []types.ResourceWarning {
+	var warnings []types.ResourceWarning
+	if lb.Type == "classic" {
+		warnings = append(warnings, types.ResourceWarning{
+			Code:    types.WarningClassicLoadBalancer,
+			Message: "Classic Load Balancers are deprecated; migrate to an Application or Network Load Balancer",
+		})
+	}
+	if lb.RegisteredTargets == nil {
+		return warnings
+	}
+	savings := lb.HourlyCost * types.HoursPerMonth
+	switch {
+	case *lb.RegisteredTargets == 0:
+		warnings = append(warnings, types.ResourceWarning{
+			Code:           types.WarningNoTargets,
+			Message:        "no targets are registered; delete the load balancer if it isn't used for redirects or fixed responses",
+			MonthlySavings: savings,
+		})
+	case *lb.HealthyTargets == 0:
+		warnings = append(warnings, types.ResourceWarning{
+			Code:           types.WarningNoHealthyTargets,
+			Message:        fmt.Sprintf("none of the %d registered targets are healthy", *lb.RegisteredTargets),
+			MonthlySavings: savings,
+		})
+	}
+	return warnings
+}
+
+// elbv2SubnetIDs returns the subnets an ALB or NLB is attached to
+func elbv2SubnetIDs(zones []elbv2types.AvailabilityZone) []string {
+	var subnets []string
+	for _, zone := range zones {
+		if zone.SubnetId != nil {
+			subnets = append(subnets, *zone.SubnetId)
+		}
+	}
+	return subnets
+}
+
 // discoverNATGateways discovers NAT Gateways in the specified region
 func (d *Discovery) discoverNATGateways(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.NATGateway, error) {
 	client := ec2.NewFromConfig(cfg)
@@ -1340,8 +2127,9 @@ func (d *Discovery) discoverNATGateways(ctx context.Context, cfg aws.Config, acc
 
 			// Get pricing for available NAT gateways
 			var hourlyCost types.CostValue
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
 			if state == "available" {
-				price, err := d.pricingProvider.GetNATGatewayPrice(ctx, region)
+				price, err := d.prices.HourlyPrice(priceCtx, cloud.PriceQuery{Service: "nat", Region: region})
 				if err != nil {
 					d.logger.Warn("failed to get NAT Gateway price",
 						"id", id,
@@ -1353,17 +2141,25 @@ func (d *Discovery) discoverNATGateways(ctx context.Context, cfg aws.Config, acc
 				}
 			}
 
+			createdAt, ageDays := creationAge(nat.CreateTime, time.Now())
 			gateways = append(gateways, types.NATGateway{
-				AccountID:   accountID,
-				AccountName: accountName,
-				Region:      region,
-				ID:          id,
-				Name:        name,
-				State:       state,
-				Type:        natType,
-				VPCID:       vpcID,
-				SubnetID:    subnetID,
-				HourlyCost:  hourlyCost,
+				AccountID:      accountID,
+				AccountName:    accountName,
+				Region:         region,
+				ID:             id,
+				ARN:            resourceARN("ec2", region, accountID, "natgateway/"+id),
+				Name:           name,
+				State:          state,
+				Type:           natType,
+				VPCID:          vpcID,
+				SubnetID:       subnetID,
+				CreatedAt:      createdAt,
+				AgeDays:        ageDays,
+				HourlyCost:     hourlyCost,
+				Tags:           getEC2Tags(nat.Tags),
+				CostComponents: flatRateComponents("gateway", hourlyCost),
+				PriceSource:    freshness.Source(),
+				PriceAsOf:      freshness.AsOf(),
 			})
 		}
 	}
@@ -1371,7 +2167,7 @@ func (d *Discovery) discoverNATGateways(ctx context.Context, cfg aws.Config, acc
 	return gateways, nil
 }
 
-// discoverElasticIPs discovers Elastic IP addresses in the specified region
+// discoverElasticIPs discovers Elastic IPs in the specified region
 func (d *Discovery) discoverElasticIPs(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.ElasticIP, error) {
 	client := ec2.NewFromConfig(cfg)
 
@@ -1380,35 +2176,52 @@ func (d *Discovery) discoverElasticIPs(ctx context.Context, cfg aws.Config, acco
 		return nil, fmt.Errorf("describing Elastic IPs: %w", err)
 	}
 
-	var elasticIPs []types.ElasticIP
-
+	var eniIDs, instanceIDs []string
 	for _, addr := range output.Addresses {
-		allocationID := ""
-		if addr.AllocationId != nil {
-			allocationID = *addr.AllocationId
+		if addr.NetworkInterfaceId != nil {
+			eniIDs = append(eniIDs, *addr.NetworkInterfaceId)
 		}
-
-		publicIP := ""
-		if addr.PublicIp != nil {
-			publicIP = *addr.PublicIp
+		if addr.InstanceId != nil {
+			instanceIDs = append(instanceIDs, *addr.InstanceId)
 		}
+	}
+	interfaces, err := describeNetworkInterfaces(ctx, client, eniIDs)
+	if err != nil {
+		d.logger.Warn("failed to describe Elastic IP network interfaces",
+			"region", region,
+			"error", err)
+		recordDiagnostic(ctx, newDiagnostic("warning", "eip", accountID, accountName, region, "describeNetworkInterfaces", "", err))
+	}
+	instanceStates, err := describeInstanceStates(ctx, client, instanceIDs)
+	if err != nil {
+		d.logger.Warn("failed to describe Elastic IP instances",
+			"region", region,
+			"error", err)
+		recordDiagnostic(ctx, newDiagnostic("warning", "eip", accountID, accountName, region, "describeInstances", "", err))
+	}
 
-		name := getElasticIPName(addr.Tags)
+	var elasticIPs []types.ElasticIP
 
-		associationID := ""
-		if addr.AssociationId != nil {
-			associationID = *addr.AssociationId
-		}
+	for _, addr := range output.Addresses {
+		allocationID := aws.ToString(addr.AllocationId)
+		eniID := aws.ToString(addr.NetworkInterfaceId)
+		instanceID := aws.ToString(addr.InstanceId)
+		associationID := aws.ToString(addr.AssociationId)
+		isAssociated := associationID != ""
+		eni := interfaces[eniID]
+		resourceType, resourceID := associatedResource(instanceID, eniID, eni)
 
-		instanceID := ""
-		if addr.InstanceId != nil {
-			instanceID = *addr.InstanceId
+		// An address is idle unless it's associated with a running instance
+		// or a network interface in use by another service
+		idle := !isAssociated
+		if state, ok := instanceStates[instanceID]; ok && instanceID != "" {
+			idle = state != string(ec2types.InstanceStateNameRunning)
 		}
 
-		isAssociated := associationID != ""
-
-		// Get pricing - only unassociated EIPs cost money
-		price, err := d.pricingProvider.GetElasticIPPrice(ctx, region, isAssociated)
+		// Since February 2024 every public IPv4 address is billed, at the
+		// idle or in-use rate
+		priceCtx, freshness := pricing.TrackFreshness(ctx)
+		price, err := d.pricingProvider.GetElasticIPPrice(priceCtx, region, !idle)
 		var hourlyCost types.CostValue
 		if err != nil {
 			d.logger.Warn("failed to get Elastic IP price",
@@ -1420,23 +2233,131 @@ func (d *Discovery) discoverElasticIPs(ctx context.Context, cfg aws.Config, acco
 			hourlyCost = price
 		}
 
+		componentName := "in-use address"
+		if idle {
+			componentName = "idle address"
+		}
+
 		elasticIPs = append(elasticIPs, types.ElasticIP{
-			AccountID:     accountID,
-			AccountName:   accountName,
-			Region:        region,
-			AllocationID:  allocationID,
-			PublicIP:      publicIP,
-			Name:          name,
-			AssociationID: associationID,
-			InstanceID:    instanceID,
-			IsAssociated:  isAssociated,
-			HourlyCost:    hourlyCost,
+			AccountID:              accountID,
+			AccountName:            accountName,
+			Region:                 region,
+			AllocationID:           allocationID,
+			ARN:                    resourceARN("ec2", region, accountID, "elastic-ip/"+allocationID),
+			PublicIP:               aws.ToString(addr.PublicIp),
+			Name:                   getElasticIPName(addr.Tags),
+			AssociationID:          associationID,
+			InstanceID:             instanceID,
+			NetworkInterfaceID:     eniID,
+			AssociatedResourceType: resourceType,
+			AssociatedResourceID:   resourceID,
+			IsAssociated:           isAssociated,
+			Idle:                   idle,
+			VPCID:                  eni.vpcID,
+			SubnetID:               eni.subnetID,
+			HourlyCost:             hourlyCost,
+			Tags:                   getEC2Tags(addr.Tags),
+			CostComponents:         flatRateComponents(componentName, hourlyCost),
+			PriceSource:            freshness.Source(),
+			PriceAsOf:              freshness.AsOf(),
 		})
 	}
 
 	return elasticIPs, nil
 }
 
+// networkInterface is what awsCOGS needs to know about an ENI
+type networkInterface struct {
+	vpcID         string
+	subnetID      string
+	interfaceType string // e.g. interface, natGateway, network_load_balancer
+	description   string
+}
+
+// describeNetworkInterfaces returns the network interfaces with the given IDs
+func describeNetworkInterfaces(ctx context.Context, client *ec2.Client, ids []string) (map[string]networkInterface, error) {
+	interfaces := make(map[string]networkInterface, len(ids))
+	if len(ids) == 0 {
+		return interfaces, nil
+	}
+
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(client, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("network-interface-id"), Values: ids}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return interfaces, fmt.Errorf("describing network interfaces: %w", err)
+		}
+		for _, eni := range page.NetworkInterfaces {
+			interfaces[aws.ToString(eni.NetworkInterfaceId)] = networkInterface{
+				vpcID:         aws.ToString(eni.VpcId),
+				subnetID:      aws.ToString(eni.SubnetId),
+				interfaceType: string(eni.InterfaceType),
+				description:   aws.ToString(eni.Description),
+			}
+		}
+	}
+	return interfaces, nil
+}
+
+// describeInstanceStates returns the state of each instance, keyed by instance ID
+func describeInstanceStates(ctx context.Context, client *ec2.Client, ids []string) (map[string]string, error) {
+	states := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return states, nil
+	}
+
+	paginator := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("instance-id"), Values: ids}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return states, fmt.Errorf("describing instances: %w", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, inst := range reservation.Instances {
+				if inst.State != nil {
+					states[aws.ToString(inst.InstanceId)] = string(inst.State.Name)
+				}
+			}
+		}
+	}
+	return states, nil
+}
+
+// associatedResource identifies what a public IP is associated with: an EC2
+// instance, a NAT gateway, a load balancer, or otherwise a bare network
+// interface. It returns empty strings for unassociated addresses.
+func associatedResource(instanceID, eniID string, eni networkInterface) (resourceType, resourceID string) {
+	if instanceID != "" {
+		return "ec2", instanceID
+	}
+	if eniID == "" {
+		return "", ""
+	}
+
+	switch {
+	case eni.interfaceType == string(ec2types.NetworkInterfaceTypeNatGateway):
+		// Description: "Interface for NAT Gateway nat-0123456789abcdef0"
+		for _, field := range strings.Fields(eni.description) {
+			if strings.HasPrefix(field, "nat-") {
+				return "nat", field
+			}
+		}
+		return "nat", ""
+	case strings.HasPrefix(eni.description, "ELB "):
+		// Description: "ELB net/my-nlb/50dc6c495c0c9188" or "ELB my-classic-lb"
+		ref := strings.TrimPrefix(eni.description, "ELB ")
+		if parts := strings.Split(ref, "/"); len(parts) == 3 {
+			return "elb", parts[1]
+		}
+		return "elb", ref
+	}
+	return "eni", eniID
+}
+
 // discoverSecrets discovers Secrets Manager secrets in the specified region
 func (d *Discovery) discoverSecrets(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.Secret, error) {
 	client := secretsmanager.NewFromConfig(cfg)
@@ -1467,7 +2388,8 @@ func (d *Discovery) discoverSecrets(ctx context.Context, cfg aws.Config, account
 			}
 
 			// Get pricing
-			price, err := d.pricingProvider.GetSecretPrice(ctx, region)
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
+			price, err := d.prices.HourlyPrice(priceCtx, cloud.PriceQuery{Service: "secrets", Region: region})
 			var hourlyCost types.CostValue
 			if err != nil {
 				d.logger.Warn("failed to get Secret price",
@@ -1479,14 +2401,37 @@ func (d *Discovery) discoverSecrets(ctx context.Context, cfg aws.Config, account
 				hourlyCost = price
 			}
 
+			// A replica lists the secret's primary region; a primary lists its own
+			var primaryRegion string
+			component := "secret"
+			if r := aws.ToString(secret.PrimaryRegion); r != "" && r != region {
+				primaryRegion = r
+				component = "replica secret"
+			}
+
+			now := time.Now()
+			createdAt, ageDays := creationAge(secret.CreatedDate, now)
+			lastAccessedAt, idleDays := creationAge(secret.LastAccessedDate, now)
+			if lastAccessedAt == "" {
+				idleDays = ageDays
+			}
 			secrets = append(secrets, types.Secret{
-				AccountID:   accountID,
-				AccountName: accountName,
-				Region:      region,
-				Name:        name,
-				ARN:         arn,
-				Description: description,
-				HourlyCost:  hourlyCost,
+				AccountID:      accountID,
+				AccountName:    accountName,
+				Region:         region,
+				Name:           name,
+				ARN:            arn,
+				Description:    description,
+				CreatedAt:      createdAt,
+				AgeDays:        ageDays,
+				PrimaryRegion:  primaryRegion,
+				LastAccessedAt: lastAccessedAt,
+				IdleDays:       idleDays,
+				HourlyCost:     hourlyCost,
+				Tags:           getSecretTags(secret.Tags),
+				CostComponents: flatRateComponents(component, hourlyCost),
+				PriceSource:    freshness.Source(),
+				PriceAsOf:      freshness.AsOf(),
 			})
 		}
 	}
@@ -1546,7 +2491,8 @@ func (d *Discovery) discoverPublicIPv4s(ctx context.Context, cfg aws.Config, acc
 				instanceName := getEC2Name(inst.Tags)
 
 				// Get pricing
-				price, err := d.pricingProvider.GetPublicIPv4Price(ctx, region)
+				priceCtx, freshness := pricing.TrackFreshness(ctx)
+				price, err := d.prices.HourlyPrice(priceCtx, cloud.PriceQuery{Service: "publicipv4", Region: region})
 				var hourlyCost types.CostValue
 				if err != nil {
 					d.logger.Warn("failed to get public IPv4 price",
@@ -1558,14 +2504,28 @@ func (d *Discovery) discoverPublicIPv4s(ctx context.Context, cfg aws.Config, acc
 					hourlyCost = price
 				}
 
+				var eniID string
+				for _, eni := range inst.NetworkInterfaces {
+					if eni.Association != nil && aws.ToString(eni.Association.PublicIp) == publicIP {
+						eniID = aws.ToString(eni.NetworkInterfaceId)
+						break
+					}
+				}
+
 				publicIPs = append(publicIPs, types.PublicIPv4{
-					AccountID:    accountID,
-					AccountName:  accountName,
-					Region:       region,
-					PublicIP:     publicIP,
-					InstanceID:   instanceID,
-					InstanceName: instanceName,
-					HourlyCost:   hourlyCost,
+					AccountID:              accountID,
+					AccountName:            accountName,
+					Region:                 region,
+					PublicIP:               publicIP,
+					InstanceID:             instanceID,
+					InstanceName:           instanceName,
+					NetworkInterfaceID:     eniID,
+					AssociatedResourceType: "ec2",
+					AssociatedResourceID:   instanceID,
+					HourlyCost:             hourlyCost,
+					CostComponents:         flatRateComponents("in-use address", hourlyCost),
+					PriceSource:            freshness.Source(),
+					PriceAsOf:              freshness.AsOf(),
 				})
 			}
 		}
@@ -1574,6 +2534,371 @@ func (d *Discovery) discoverPublicIPv4s(ctx context.Context, cfg aws.Config, acc
 	return publicIPs, nil
 }
 
+// discoverCapacityReservations discovers active On-Demand Capacity
+// Reservations owned by the account. A reservation is billed at the
+// on-demand rate for every reserved instance; the instances running in it
+// are priced with EC2, so the reservation's cost is its unused capacity.
+// Capacity Blocks are paid up front and are skipped.
+func (d *Discovery) discoverCapacityReservations(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.CapacityReservation, error) {
+	client := ec2.NewFromConfig(cfg)
+
+	var reservations []types.CapacityReservation
+	paginator := ec2.NewDescribeCapacityReservationsPaginator(client, &ec2.DescribeCapacityReservationsInput{
+		Filters: []ec2types.Filter{{Name: aws.String("state"), Values: []string{"active"}}},
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing capacity reservations: %w", err)
+		}
+
+		for _, cr := range page.CapacityReservations {
+			if cr.ReservationType == ec2types.CapacityReservationTypeCapacityBlock {
+				continue
+			}
+			// Reservations shared with this account are billed to their owner
+			if accountID != "" && cr.OwnerId != nil && *cr.OwnerId != accountID {
+				continue
+			}
+
+			id := aws.ToString(cr.CapacityReservationId)
+			instanceType := aws.ToString(cr.InstanceType)
+			tenancy := string(cr.Tenancy)
+			total := aws.ToInt32(cr.TotalInstanceCount)
+			available := aws.ToInt32(cr.AvailableInstanceCount)
+
+			var utilization float64
+			if total > 0 {
+				utilization = float64(total-available) / float64(total)
+			}
+
+			var hourlyCost types.CostValue
+			var components []types.CostComponent
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
+			if available > 0 {
+				price, err := d.prices.HourlyPrice(priceCtx, cloud.PriceQuery{Service: "ec2", Region: region, SKU: instanceType, Attributes: map[string]string{"tenancy": tenancy}})
+				if err != nil {
+					d.logger.Warn("failed to get capacity reservation price",
+						"id", id,
+						"instanceType", instanceType,
+						"region", region,
+						"error", err)
+					recordDiagnostic(ctx, newDiagnostic("warning", "capacityreservation", accountID, accountName, region, "pricing", id, err))
+				} else {
+					components = []types.CostComponent{types.HourlyComponent("unused capacity", "instance-hour", float64(available), price)}
+					hourlyCost = types.SumComponents(components)
+				}
+			}
+
+			var endDate string
+			if cr.EndDate != nil {
+				endDate = cr.EndDate.UTC().Format(time.RFC3339)
+			}
+
+			createdAt, ageDays := creationAge(cr.CreateDate, time.Now())
+			reservations = append(reservations, types.CapacityReservation{
+				AccountID:        accountID,
+				AccountName:      accountName,
+				Region:           region,
+				ID:               id,
+				ARN:              aws.ToString(cr.CapacityReservationArn),
+				Name:             getEC2Name(cr.Tags),
+				InstanceType:     instanceType,
+				Platform:         string(cr.InstancePlatform),
+				Tenancy:          tenancy,
+				AvailabilityZone: aws.ToString(cr.AvailabilityZone),
+				State:            string(cr.State),
+				TotalCount:       total,
+				AvailableCount:   available,
+				Utilization:      utilization,
+				EndDate:          endDate,
+				CreatedAt:        createdAt,
+				AgeDays:          ageDays,
+				HourlyCost:       hourlyCost,
+				CostComponents:   components,
+				PriceSource:      freshness.Source(),
+				PriceAsOf:        freshness.AsOf(),
+				Tags:             getEC2Tags(cr.Tags),
+			})
+		}
+	}
+
+	return reservations, nil
+}
+
+// discoverDedicatedHosts discovers allocated Dedicated Hosts. A host is
+// billed by the hour from allocation to release whether or not instances
+// run on it.
+func (d *Discovery) discoverDedicatedHosts(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.DedicatedHost, error) {
+	client := ec2.NewFromConfig(cfg)
+
+	var hosts []types.DedicatedHost
+	paginator := ec2.NewDescribeHostsPaginator(client, &ec2.DescribeHostsInput{
+		Filter: []ec2types.Filter{{Name: aws.String("state"), Values: []string{"available", "under-assessment"}}},
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing dedicated hosts: %w", err)
+		}
+
+		for _, h := range page.Hosts {
+			id := aws.ToString(h.HostId)
+			var family, instanceType string
+			if h.HostProperties != nil {
+				family = aws.ToString(h.HostProperties.InstanceFamily)
+				instanceType = aws.ToString(h.HostProperties.InstanceType)
+			}
+			if family == "" {
+				family, _, _ = strings.Cut(instanceType, ".")
+			}
+
+			instanceIDs := make([]string, 0, len(h.Instances))
+			for _, inst := range h.Instances {
+				instanceIDs = append(instanceIDs, aws.ToString(inst.InstanceId))
+			}
+			capacity, utilization := hostUtilization(h)
+
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
+			price, err := d.pricingProvider.GetDedicatedHostPrice(priceCtx, region, family)
+			if err != nil {
+				d.logger.Warn("failed to get Dedicated Host price",
+					"id", id,
+					"family", family,
+					"region", region,
+					"error", err)
+				recordDiagnostic(ctx, newDiagnostic("warning", "dedicatedhost", accountID, accountName, region, "pricing", id, err))
+			}
+
+			createdAt, ageDays := creationAge(h.AllocationTime, time.Now())
+			hosts = append(hosts, types.DedicatedHost{
+				AccountID:        accountID,
+				AccountName:      accountName,
+				Region:           region,
+				HostID:           id,
+				ARN:              resourceARN("ec2", region, accountID, "dedicated-host/"+id),
+				Name:             getEC2Name(h.Tags),
+				InstanceFamily:   family,
+				InstanceType:     instanceType,
+				AvailabilityZone: aws.ToString(h.AvailabilityZone),
+				State:            string(h.State),
+				InstanceIDs:      instanceIDs,
+				Capacity:         capacity,
+				Utilization:      utilization,
+				CreatedAt:        createdAt,
+				AgeDays:          ageDays,
+				HourlyCost:       price,
+				CostComponents:   flatRateComponents("host", price),
+				PriceSource:      freshness.Source(),
+				PriceAsOf:        freshness.AsOf(),
+				Tags:             getEC2Tags(h.Tags),
+			})
+		}
+	}
+
+	return hosts, nil
+}
+
+// hostUtilization returns how many instances of its supported type a
+// Dedicated Host can run and the fraction of its capacity in use. Hosts that
+// support several instance types report capacity in vCPUs, so their
+// capacity is zero and utilization is the fraction of vCPUs in use.
+func hostUtilization(h ec2types.Host) (int32, float64) {
+	if h.AvailableCapacity == nil || h.HostProperties == nil {
+		return 0, 0
+	}
+	if instanceType := aws.ToString(h.HostProperties.InstanceType); instanceType != "" {
+		for _, c := range h.AvailableCapacity.AvailableInstanceCapacity {
+			total := aws.ToInt32(c.TotalCapacity)
+			if aws.ToString(c.InstanceType) == instanceType && total > 0 {
+				return total, float64(total-aws.ToInt32(c.AvailableCapacity)) / float64(total)
+			}
+		}
+	}
+	if total := aws.ToInt32(h.HostProperties.TotalVCpus); total > 0 {
+		return 0, float64(total-aws.ToInt32(h.AvailableCapacity.AvailableVCpus)) / float64(total)
+	}
+	return 0, 0
+}
+
+// discoverKinesisStreams discovers Kinesis data streams in the specified region
+func (d *Discovery) discoverKinesisStreams(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.KinesisStream, error) {
+	names, err := listKinesisStreams(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("listing Kinesis streams: %w", err)
+	}
+
+	var streams []types.KinesisStream
+	for _, name := range names {
+		s, err := describeKinesisStream(ctx, cfg, name)
+		if err != nil {
+			d.logger.Warn("failed to describe Kinesis stream",
+				"stream", name,
+				"region", region,
+				"error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "kinesis", accountID, accountName, region, "DescribeStreamSummary", name, err))
+			continue
+		}
+		if s.StreamStatus == kinesistypes.StreamStatusDeleting {
+			continue
+		}
+
+		mode := kinesisStreamMode(s)
+		onDemand := mode == kinesisModeOnDemand
+		var components []types.CostComponent
+		priceCtx, freshness := pricing.TrackFreshness(ctx)
+		price, err := d.pricingProvider.GetKinesisStreamPrice(priceCtx, region, onDemand)
+		if err != nil {
+			d.logger.Warn("failed to get Kinesis price",
+				"stream", name,
+				"mode", mode,
+				"region", region,
+				"error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "kinesis", accountID, accountName, region, "pricing", aws.ToString(s.StreamARN), err))
+		} else if onDemand {
+			components = []types.CostComponent{types.HourlyComponent("stream", "stream-hour", 1, price)}
+		} else {
+			components = []types.CostComponent{types.HourlyComponent("shards", "shard-hour", float64(aws.ToInt32(s.OpenShardCount)), price)}
+		}
+
+		createdAt, ageDays := creationAge(s.StreamCreationTimestamp, time.Now())
+		streams = append(streams, types.KinesisStream{
+			AccountID:      accountID,
+			AccountName:    accountName,
+			Region:         region,
+			StreamName:     aws.ToString(s.StreamName),
+			StreamARN:      aws.ToString(s.StreamARN),
+			Status:         string(s.StreamStatus),
+			Mode:           mode,
+			ShardCount:     int(aws.ToInt32(s.OpenShardCount)),
+			RetentionHours: int(aws.ToInt32(s.RetentionPeriodHours)),
+			ConsumerCount:  int(aws.ToInt32(s.ConsumerCount)),
+			CreatedAt:      createdAt,
+			AgeDays:        ageDays,
+			HourlyCost:     types.SumComponents(components),
+			CostComponents: components,
+			PriceSource:    freshness.Source(),
+			PriceAsOf:      freshness.AsOf(),
+		})
+	}
+
+	return streams, nil
+}
+
+// firehoseUsageWindow is how far back Firehose ingestion is averaged
+const firehoseUsageWindow = 24 * time.Hour
+
+// discoverFirehoseStreams discovers Firehose delivery streams in the
+// specified region. Firehose bills per GB ingested, so the hourly cost is
+// the last day's ingestion from CloudWatch averaged to an hour.
+func (d *Discovery) discoverFirehoseStreams(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.FirehoseStream, error) {
+	names, err := listDeliveryStreams(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("listing Firehose delivery streams: %w", err)
+	}
+
+	cwClient := cloudwatch.NewFromConfig(cfg)
+	var streams []types.FirehoseStream
+	for _, name := range names {
+		s, err := describeDeliveryStream(ctx, cfg, name)
+		if err != nil {
+			d.logger.Warn("failed to describe Firehose delivery stream",
+				"stream", name,
+				"region", region,
+				"error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "firehose", accountID, accountName, region, "DescribeDeliveryStream", name, err))
+			continue
+		}
+		if s.DeliveryStreamStatus == fhtypes.DeliveryStreamStatusDeleting {
+			continue
+		}
+
+		incomingBytes, usageStatus, usageErr := d.fetchFirehoseIncomingBytes(ctx, cwClient, name)
+		var components []types.CostComponent
+		priceCtx, freshness := pricing.TrackFreshness(ctx)
+		if incomingBytes > 0 {
+			price, err := d.pricingProvider.GetFirehosePrice(priceCtx, region)
+			if err != nil {
+				d.logger.Warn("failed to get Firehose price",
+					"stream", name,
+					"region", region,
+					"error", err)
+				recordDiagnostic(ctx, newDiagnostic("warning", "firehose", accountID, accountName, region, "pricing", aws.ToString(s.DeliveryStreamARN), err))
+			} else {
+				gbPerHour := incomingBytes / (1 << 30) / firehoseUsageWindow.Hours()
+				components = []types.CostComponent{types.HourlyComponent("ingestion", "GB", gbPerHour, price)}
+			}
+		}
+
+		createdAt, ageDays := creationAge(s.CreateTimestamp, time.Now())
+		streams = append(streams, types.FirehoseStream{
+			AccountID:      accountID,
+			AccountName:    accountName,
+			Region:         region,
+			Name:           aws.ToString(s.DeliveryStreamName),
+			ARN:            aws.ToString(s.DeliveryStreamARN),
+			Status:         string(s.DeliveryStreamStatus),
+			SourceType:     string(s.DeliveryStreamType),
+			Destinations:   firehoseDestinations(s),
+			IncomingBytes:  incomingBytes,
+			UsageWindow:    "24h",
+			UsageStatus:    usageStatus,
+			UsageError:     usageErr,
+			CreatedAt:      createdAt,
+			AgeDays:        ageDays,
+			HourlyCost:     types.SumComponents(components),
+			CostComponents: components,
+			PriceSource:    freshness.Source(),
+			PriceAsOf:      freshness.AsOf(),
+		})
+	}
+
+	return streams, nil
+}
+
+// fetchFirehoseIncomingBytes returns the bytes ingested by a delivery stream
+// over the usage window
+func (d *Discovery) fetchFirehoseIncomingBytes(ctx context.Context, client *cloudwatch.Client, name string) (float64, string, string) {
+	end := time.Now().UTC()
+	output, err := client.GetMetricData(ctx, &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(end.Add(-firehoseUsageWindow)),
+		EndTime:   aws.Time(end),
+		MetricDataQueries: []cwtypes.MetricDataQuery{{
+			Id: aws.String("incoming"),
+			MetricStat: &cwtypes.MetricStat{
+				Metric: &cwtypes.Metric{
+					Namespace:  aws.String("AWS/Firehose"),
+					MetricName: aws.String("IncomingBytes"),
+					Dimensions: []cwtypes.Dimension{
+						{Name: aws.String("DeliveryStreamName"), Value: aws.String(name)},
+					},
+				},
+				Period: aws.Int32(int32(firehoseUsageWindow.Seconds())),
+				Stat:   aws.String("Sum"),
+			},
+		}},
+	})
+	if err != nil {
+		d.logger.Debug("failed to fetch Firehose usage", "stream", name, "error", err)
+		return 0, types.UsageStatusUnavailable, err.Error()
+	}
+
+	var total float64
+	hasData := false
+	for _, result := range output.MetricDataResults {
+		for _, v := range result.Values {
+			total += v
+			hasData = true
+		}
+	}
+	if !hasData {
+		return 0, types.UsageStatusPartial, "no datapoints in window"
+	}
+	return total, types.UsageStatusOK, ""
+}
+
 // discoverLambdas discovers Lambda functions and computes cost from the last hour of usage.
 func (d *Discovery) discoverLambdas(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.LambdaFunction, error) {
 	client := lambda.NewFromConfig(cfg)
@@ -1598,7 +2923,9 @@ func (d *Discovery) discoverLambdas(ctx context.Context, cfg aws.Config, account
 			invocations, avgDurationMS, usageStatus, usageErr := d.fetchLambdaUsage(ctx, cwClient, functionName, usageStart, usageEnd)
 
 			var requestCost, computeCost, hourlyCost types.CostValue
-			requestPrice, gbSecondPrice, err := d.pricingProvider.GetLambdaPrice(ctx, region, architecture)
+			var components []types.CostComponent
+			priceCtx, freshness := pricing.TrackFreshness(ctx)
+			requestPrice, gbSecondPrice, err := d.pricingProvider.GetLambdaPrice(priceCtx, region, architecture)
 			if err != nil {
 				d.logger.Warn("failed to get Lambda price",
 					"function", functionName,
@@ -1609,9 +2936,13 @@ func (d *Discovery) discoverLambdas(ctx context.Context, cfg aws.Config, account
 			} else {
 				memoryGB := float64(aws.ToInt32(fn.MemorySize)) / 1024.0
 				durationSeconds := avgDurationMS / 1000.0
-				requestCost = types.CostValue(invocations) * requestPrice
-				computeCost = types.CostValue(invocations*durationSeconds*memoryGB) * gbSecondPrice
-				hourlyCost = requestCost + computeCost
+				components = []types.CostComponent{
+					types.HourlyComponent("requests", "request", invocations, requestPrice),
+					types.HourlyComponent("compute", "GB-second", invocations*durationSeconds*memoryGB, gbSecondPrice),
+				}
+				requestCost = components[0].HourlyCost
+				computeCost = components[1].HourlyCost
+				hourlyCost = requestCost.Add(computeCost)
 			}
 
 			ephemeralStorage := int32(512)
@@ -1635,6 +2966,9 @@ func (d *Discovery) discoverLambdas(ctx context.Context, cfg aws.Config, account
 				HourlyCost:        hourlyCost,
 				RequestHourlyCost: requestCost,
 				ComputeHourlyCost: computeCost,
+				CostComponents:    components,
+				PriceSource:       freshness.Source(),
+				PriceAsOf:         freshness.AsOf(),
 				Invocations:       invocations,
 				AverageDurationMS: avgDurationMS,
 				UsageWindow:       "1h",
@@ -1741,6 +3075,34 @@ func lambdaArchitectures(architectures []lambdatypes.Architecture) []string {
 	return result
 }
 
+// creationAge formats a resource's creation time as RFC 3339, with its age in
+// whole days at now. Resources that don't report one get neither.
+func creationAge(created *time.Time, now time.Time) (string, int) {
+	if created == nil || created.IsZero() {
+		return "", 0
+	}
+	return created.UTC().Format(time.RFC3339), int(now.Sub(*created).Hours() / 24)
+}
+
+// stopTimePattern matches the time in a stopped instance's state transition
+// reason, e.g. "User initiated (2024-01-15 10:22:33 GMT)"
+var stopTimePattern = regexp.MustCompile(`\((\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) GMT\)`)
+
+// stopTime returns when an instance was stopped (RFC 3339) and the whole days
+// since, from its state transition reason. Both are empty if the reason has
+// no time.
+func stopTime(reason string, now time.Time) (string, int) {
+	m := stopTimePattern.FindStringSubmatch(reason)
+	if m == nil {
+		return "", 0
+	}
+	stopped, err := time.Parse(time.DateTime, m[1])
+	if err != nil {
+		return "", 0
+	}
+	return creationAge(&stopped, now)
+}
+
 // getEC2Name extracts the Name tag from EC2 instance tags
 func getEC2Name(tags []ec2types.Tag) string {
 	for _, tag := range tags {
@@ -1781,6 +3143,60 @@ func getElasticIPName(tags []ec2types.Tag) string {
 	return ""
 }
 
+// flatRateComponents returns the breakdown for a resource billed at a single
+// hourly rate, or nil if it costs nothing
+func flatRateComponents(name string, hourlyCost types.CostValue) []types.CostComponent {
+	if hourlyCost == 0 {
+		return nil
+	}
+	return []types.CostComponent{types.HourlyComponent(name, "hour", 1, hourlyCost)}
+}
+
+// elbCostComponents returns the fixed hourly charge and capacity unit usage of a load balancer
+func elbCostComponents(base types.CostValue, consumedLCUs float64, perLCU types.CostValue) []types.CostComponent {
+	components := flatRateComponents("load balancer", base)
+	if consumedLCUs > 0 && perLCU > 0 {
+		components = append(components, types.HourlyComponent("capacity units", "LCU-hour", consumedLCUs, perLCU))
+	}
+	return components
+}
+
+// getEC2Tags converts EC2 tags to a map, or nil if there are none
+func getEC2Tags(tags []ec2types.Tag) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
+
+// getRDSTags converts RDS tags to a map, or nil if there are none
+func getRDSTags(tags []rdstypes.Tag) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
+
+// getSecretTags converts Secrets Manager tags to a map, or nil if there are none
+func getSecretTags(tags []smtypes.Tag) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
+
 // isRDSNonBillableState returns true if the RDS instance state is non-billable
 func isRDSNonBillableState(state string) bool {
 	switch state {
@@ -1806,6 +3222,7 @@ func getOrDiscoverResource[T any](d *Discovery, ctx context.Context, cfg aws.Con
 	if entry, ok := d.resourceCache[cacheKey]; ok && time.Now().Before(entry.expiresAt) {
 		d.resourceCacheMu.RUnlock()
 		d.logger.Debug("cache hit", "key", cacheKey)
+		recordCellScan(ctx, true)
 		return entry.value.(T)
 	}
 	d.resourceCacheMu.RUnlock()
@@ -1816,17 +3233,20 @@ func getOrDiscoverResource[T any](d *Discovery, ctx context.Context, cfg aws.Con
 		d.resourceCacheMu.RLock()
 		if entry, ok := d.resourceCache[cacheKey]; ok && time.Now().Before(entry.expiresAt) {
 			d.resourceCacheMu.RUnlock()
+			recordCellScan(ctx, true)
 			return entry.value.(T), nil
 		}
 		d.resourceCacheMu.RUnlock()
 
+		recordCellScan(ctx, false)
 		result, err := discover(ctx, cfg, accountID, accountName, region)
 		if err != nil {
 			return nil, err
 		}
 
+		now := time.Now()
 		d.resourceCacheMu.Lock()
-		d.resourceCache[cacheKey] = cacheEntry[any]{value: result, expiresAt: time.Now().Add(d.resourceTTL)}
+		d.resourceCache[cacheKey] = cacheEntry[any]{value: result, scannedAt: now, expiresAt: now.Add(d.resourceTTL)}
 		d.resourceCacheMu.Unlock()
 		d.logger.Debug("cached", "key", cacheKey)
 
@@ -1842,6 +3262,49 @@ func getOrDiscoverResource[T any](d *Discovery, ctx context.Context, cfg aws.Con
 	return v.(T)
 }
 
+// Telemetry returns the AWS API calls made and time spent scanning each
+// account since the server started
+func (d *Discovery) Telemetry() *types.TelemetryResponse {
+	return d.telemetry.snapshot()
+}
+
+// ScanCells returns the cache state of every account, region, and resource
+// type that has been scanned, sorted by account, region, and type. Expired
+// cells are rescanned the next time a request covers them.
+func (d *Discovery) ScanCells() []types.ScanCell {
+	now := time.Now()
+
+	d.resourceCacheMu.RLock()
+	cells := make([]types.ScanCell, 0, len(d.resourceCache))
+	for key, entry := range d.resourceCache {
+		parts := strings.SplitN(key, "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		cells = append(cells, types.ScanCell{
+			AccountID:    parts[0],
+			Region:       parts[1],
+			ResourceType: parts[2],
+			ScannedAt:    entry.scannedAt,
+			ExpiresAt:    entry.expiresAt,
+			Expired:      !now.Before(entry.expiresAt),
+		})
+	}
+	d.resourceCacheMu.RUnlock()
+
+	sort.Slice(cells, func(i, j int) bool {
+		a, b := cells[i], cells[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.ResourceType < b.ResourceType
+	})
+	return cells
+}
+
 // getOrDiscoverEC2 returns cached EC2 instances or discovers them
 func (d *Discovery) getOrDiscoverEC2(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.EC2Instance {
 	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "ec2", d.discoverEC2)
@@ -1892,262 +3355,29 @@ func (d *Discovery) getOrDiscoverPublicIPv4s(ctx context.Context, cfg aws.Config
 	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "publicipv4", d.discoverPublicIPv4s)
 }
 
-// getOrDiscoverLambdas returns cached Lambda functions or discovers them
-func (d *Discovery) getOrDiscoverLambdas(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.LambdaFunction {
-	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "lambda", d.discoverLambdas)
+// getOrDiscoverCapacityReservations returns cached capacity reservations or discovers them
+func (d *Discovery) getOrDiscoverCapacityReservations(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.CapacityReservation {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "capacityreservation", d.discoverCapacityReservations)
 }
 
-// buildAccountSummaries builds account-level cost summaries
-func (d *Discovery) buildAccountSummaries(ec2 []types.EC2Instance, ebs []types.EBSVolume, ecs []types.ECSService, rds []types.RDSInstance, eks []types.EKSCluster, elb []types.LoadBalancer, nat []types.NATGateway, eip []types.ElasticIP, secrets []types.Secret, publicIPv4 []types.PublicIPv4, lambdas []types.LambdaFunction) []types.AccountSummary {
-	summaries := make(map[string]*types.AccountSummary)
-
-	for _, inst := range ec2 {
-		key := inst.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   inst.AccountID,
-				AccountName: inst.AccountName,
-			}
-		}
-		summaries[key].EC2Count++
-		summaries[key].TotalCost += inst.HourlyCost
-	}
-
-	for _, vol := range ebs {
-		key := vol.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   vol.AccountID,
-				AccountName: vol.AccountName,
-			}
-		}
-		summaries[key].EBSCount++
-		summaries[key].TotalCost += vol.HourlyCost
-	}
-
-	for _, svc := range ecs {
-		key := svc.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   svc.AccountID,
-				AccountName: svc.AccountName,
-			}
-		}
-		summaries[key].ECSCount++
-		summaries[key].TotalCost += svc.HourlyCost
-	}
-
-	for _, inst := range rds {
-		key := inst.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   inst.AccountID,
-				AccountName: inst.AccountName,
-			}
-		}
-		summaries[key].RDSCount++
-		summaries[key].TotalCost += inst.HourlyCost
-	}
-
-	for _, cluster := range eks {
-		key := cluster.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   cluster.AccountID,
-				AccountName: cluster.AccountName,
-			}
-		}
-		summaries[key].EKSCount++
-		summaries[key].TotalCost += cluster.HourlyCost
-	}
-
-	for _, lb := range elb {
-		key := lb.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   lb.AccountID,
-				AccountName: lb.AccountName,
-			}
-		}
-		summaries[key].ELBCount++
-		summaries[key].TotalCost += lb.HourlyCost
-	}
-
-	for _, gw := range nat {
-		key := gw.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   gw.AccountID,
-				AccountName: gw.AccountName,
-			}
-		}
-		summaries[key].NATCount++
-		summaries[key].TotalCost += gw.HourlyCost
-	}
-
-	for _, ip := range eip {
-		key := ip.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   ip.AccountID,
-				AccountName: ip.AccountName,
-			}
-		}
-		summaries[key].EIPCount++
-		summaries[key].TotalCost += ip.HourlyCost
-	}
-
-	for _, secret := range secrets {
-		key := secret.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   secret.AccountID,
-				AccountName: secret.AccountName,
-			}
-		}
-		summaries[key].SecretCount++
-		summaries[key].TotalCost += secret.HourlyCost
-	}
-
-	for _, pip := range publicIPv4 {
-		key := pip.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   pip.AccountID,
-				AccountName: pip.AccountName,
-			}
-		}
-		summaries[key].PublicIPv4Count++
-		summaries[key].TotalCost += pip.HourlyCost
-	}
-
-	for _, fn := range lambdas {
-		key := fn.AccountID
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.AccountSummary{
-				AccountID:   fn.AccountID,
-				AccountName: fn.AccountName,
-			}
-		}
-		summaries[key].LambdaCount++
-		summaries[key].TotalCost += fn.HourlyCost
-	}
-
-	result := make([]types.AccountSummary, 0, len(summaries))
-	for _, s := range summaries {
-		result = append(result, *s)
-	}
-	return result
+// getOrDiscoverDedicatedHosts returns cached Dedicated Hosts or discovers them
+func (d *Discovery) getOrDiscoverDedicatedHosts(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.DedicatedHost {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "dedicatedhost", d.discoverDedicatedHosts)
 }
 
-// buildRegionSummaries builds region-level cost summaries
-func (d *Discovery) buildRegionSummaries(ec2 []types.EC2Instance, ebs []types.EBSVolume, ecs []types.ECSService, rds []types.RDSInstance, eks []types.EKSCluster, elb []types.LoadBalancer, nat []types.NATGateway, eip []types.ElasticIP, secrets []types.Secret, publicIPv4 []types.PublicIPv4, lambdas []types.LambdaFunction) []types.RegionSummary {
-	summaries := make(map[string]*types.RegionSummary)
-
-	for _, inst := range ec2 {
-		key := inst.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].EC2Count++
-		summaries[key].TotalCost += inst.HourlyCost
-	}
-
-	for _, vol := range ebs {
-		key := vol.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].EBSCount++
-		summaries[key].TotalCost += vol.HourlyCost
-	}
-
-	for _, svc := range ecs {
-		key := svc.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].ECSCount++
-		summaries[key].TotalCost += svc.HourlyCost
-	}
-
-	for _, inst := range rds {
-		key := inst.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].RDSCount++
-		summaries[key].TotalCost += inst.HourlyCost
-	}
-
-	for _, cluster := range eks {
-		key := cluster.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].EKSCount++
-		summaries[key].TotalCost += cluster.HourlyCost
-	}
-
-	for _, lb := range elb {
-		key := lb.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].ELBCount++
-		summaries[key].TotalCost += lb.HourlyCost
-	}
-
-	for _, gw := range nat {
-		key := gw.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].NATCount++
-		summaries[key].TotalCost += gw.HourlyCost
-	}
-
-	for _, ip := range eip {
-		key := ip.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].EIPCount++
-		summaries[key].TotalCost += ip.HourlyCost
-	}
-
-	for _, secret := range secrets {
-		key := secret.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].SecretCount++
-		summaries[key].TotalCost += secret.HourlyCost
-	}
-
-	for _, pip := range publicIPv4 {
-		key := pip.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].PublicIPv4Count++
-		summaries[key].TotalCost += pip.HourlyCost
-	}
+// getOrDiscoverKinesisStreams returns cached Kinesis data streams or discovers them
+func (d *Discovery) getOrDiscoverKinesisStreams(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.KinesisStream {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "kinesis", d.discoverKinesisStreams)
+}
 
-	for _, fn := range lambdas {
-		key := fn.Region
-		if _, ok := summaries[key]; !ok {
-			summaries[key] = &types.RegionSummary{Region: key}
-		}
-		summaries[key].LambdaCount++
-		summaries[key].TotalCost += fn.HourlyCost
-	}
+// getOrDiscoverFirehoseStreams returns cached Firehose delivery streams or discovers them
+func (d *Discovery) getOrDiscoverFirehoseStreams(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.FirehoseStream {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "firehose", d.discoverFirehoseStreams)
+}
 
-	result := make([]types.RegionSummary, 0, len(summaries))
-	for _, s := range summaries {
-		result = append(result, *s)
-	}
-	return result
+// getOrDiscoverLambdas returns cached Lambda functions or discovers them
+func (d *Discovery) getOrDiscoverLambdas(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.LambdaFunction {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "lambda", d.discoverLambdas)
 }
 
 // elbMetricMeta holds CloudWatch metric metadata for a load balancer type
@@ -2363,7 +3593,8 @@ func (d *Discovery) EnrichELBUsage(ctx context.Context, loadBalancers []types.Lo
 								"error", err)
 						} else {
 							lb.LCUHourlyCost = types.CostValue(usage.AvgConsumedLCUs) * perLCU
-							lb.HourlyCost = lb.BaseHourlyCost + lb.LCUHourlyCost
+							lb.HourlyCost = lb.BaseHourlyCost.Add(lb.LCUHourlyCost)
+							lb.CostComponents = elbCostComponents(lb.BaseHourlyCost, usage.AvgConsumedLCUs, perLCU)
 						}
 					}
 					lb.UsageStatus = usage.Status
@@ -2448,7 +3679,8 @@ func (d *Discovery) EnrichELBUsage(ctx context.Context, loadBalancers []types.Lo
 							"perLCU", perLCU,
 							"lcuCost", types.CostValue(usage.AvgConsumedLCUs)*perLCU)
 						loadBalancers[i].LCUHourlyCost = types.CostValue(usage.AvgConsumedLCUs) * perLCU
-						loadBalancers[i].HourlyCost = loadBalancers[i].BaseHourlyCost + loadBalancers[i].LCUHourlyCost
+						loadBalancers[i].HourlyCost = loadBalancers[i].BaseHourlyCost.Add(loadBalancers[i].LCUHourlyCost)
+						loadBalancers[i].CostComponents = elbCostComponents(loadBalancers[i].BaseHourlyCost, usage.AvgConsumedLCUs, perLCU)
 					}
 				}
 				loadBalancers[i].UsageStatus = usage.Status
@@ -1,6 +1,19 @@
 package aws
 
-import "testing"
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
 
 func TestDefaultAccountsForRegionsUsesRegionPartitions(t *testing.T) {
 	accounts := defaultAccountsForRegions([]string{"us-gov-west-1", "us-east-1", "us-gov-east-1"})
@@ -23,3 +36,179 @@ func TestAccountPartitionDerivesFromRoleARN(t *testing.T) {
 		t.Fatalf("AccountPartition() = %q", got)
 	}
 }
+
+func TestResourceARNUsesRegionPartition(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":     "arn:aws:ec2:us-east-1:123456789012:instance/i-0abc",
+		"us-gov-west-1": "arn:aws-us-gov:ec2:us-gov-west-1:123456789012:instance/i-0abc",
+		"cn-north-1":    "arn:aws-cn:ec2:cn-north-1:123456789012:instance/i-0abc",
+	}
+	for region, want := range tests {
+		if got := resourceARN("ec2", region, "123456789012", "instance/i-0abc"); got != want {
+			t.Errorf("resourceARN in %s = %q, want %q", region, got, want)
+		}
+	}
+}
+
+func TestGetOrDiscoverResourceOnlyRescansExpiredCells(t *testing.T) {
+	d := NewDiscovery(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60)
+
+	calls := map[string]int{}
+	discover := func(_ context.Context, _ aws.Config, accountID, _, region string) ([]string, error) {
+		calls[accountID+"|"+region]++
+		return []string{accountID + "/" + region}, nil
+	}
+
+	stats := &scanStatsCollector{}
+	ctx := contextWithScanStats(context.Background(), stats)
+	getOrDiscoverResource(d, ctx, aws.Config{}, "111", "prod", "us-east-1", "test", discover)
+	getOrDiscoverResource(d, ctx, aws.Config{}, "222", "dev", "us-east-1", "test", discover)
+
+	// A later request for one account is served from the cache
+	stats = &scanStatsCollector{}
+	ctx = contextWithScanStats(context.Background(), stats)
+	got := getOrDiscoverResource(d, ctx, aws.Config{}, "111", "prod", "us-east-1", "test", discover)
+	if len(got) != 1 || got[0] != "111/us-east-1" || calls["111|us-east-1"] != 1 {
+		t.Fatalf("expected cached result without rescanning, got %v after %d calls", got, calls["111|us-east-1"])
+	}
+	if s := stats.stats(); s.Cells != 1 || s.Cached != 1 || s.Rescanned != 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+
+	// Expire one cell; only it is rescanned
+	key := resourceCacheKey("222", "us-east-1", "test")
+	d.resourceCacheMu.Lock()
+	entry := d.resourceCache[key]
+	entry.expiresAt = entry.scannedAt
+	d.resourceCache[key] = entry
+	d.resourceCacheMu.Unlock()
+
+	cells := d.ScanCells()
+	if len(cells) != 2 || cells[0].AccountID != "111" || cells[0].Expired || !cells[1].Expired {
+		t.Fatalf("cells = %+v", cells)
+	}
+
+	getOrDiscoverResource(d, ctx, aws.Config{}, "111", "prod", "us-east-1", "test", discover)
+	getOrDiscoverResource(d, ctx, aws.Config{}, "222", "dev", "us-east-1", "test", discover)
+	if calls["111|us-east-1"] != 1 || calls["222|us-east-1"] != 2 {
+		t.Fatalf("calls = %v", calls)
+	}
+	if s := stats.stats(); s.Cached != 2 || s.Rescanned != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestAssociatedResource(t *testing.T) {
+	tests := []struct {
+		instanceID, eniID string
+		eni               networkInterface
+		wantType, wantID  string
+	}{
+		{"i-1", "eni-1", networkInterface{}, "ec2", "i-1"},
+		{"", "eni-2", networkInterface{interfaceType: "natGateway", description: "Interface for NAT Gateway nat-0abc"}, "nat", "nat-0abc"},
+		{"", "eni-3", networkInterface{interfaceType: "network_load_balancer", description: "ELB net/my-nlb/50dc6c495c0c9188"}, "elb", "my-nlb"},
+		{"", "eni-4", networkInterface{interfaceType: "interface", description: "custom"}, "eni", "eni-4"},
+		{"", "", networkInterface{}, "", ""},
+	}
+	for _, tt := range tests {
+		gotType, gotID := associatedResource(tt.instanceID, tt.eniID, tt.eni)
+		if gotType != tt.wantType || gotID != tt.wantID {
+			t.Fatalf("associatedResource(%q, %q) = %q, %q; want %q, %q", tt.instanceID, tt.eniID, gotType, gotID, tt.wantType, tt.wantID)
+		}
+	}
+}
+
+func TestBurstableFamily(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		family       string
+		ok           bool
+	}{
+		{"t3.micro", "t3", true},
+		{"t4g.large", "t4g", true},
+		{"t2.nano", "t2", true},
+		{"m5.large", "m5", false},
+		{"trn1.2xlarge", "trn1", false},
+	}
+	for _, tt := range tests {
+		family, ok := burstableFamily(tt.instanceType)
+		if family != tt.family || ok != tt.ok {
+			t.Fatalf("burstableFamily(%q) = %q, %t; want %q, %t", tt.instanceType, family, ok, tt.family, tt.ok)
+		}
+	}
+}
+
+func TestLoadBalancerWarnings(t *testing.T) {
+	zero, two := 0, 2
+	tests := []struct {
+		name    string
+		lb      types.LoadBalancer
+		want    []string
+		savings types.CostValue
+	}{
+		{"healthy", types.LoadBalancer{Type: "application", HourlyCost: 0.1, RegisteredTargets: &two, HealthyTargets: &two}, nil, 0},
+		{"unknown targets", types.LoadBalancer{Type: "application", HourlyCost: 0.1}, nil, 0},
+		{"no targets", types.LoadBalancer{Type: "network", HourlyCost: 0.1, RegisteredTargets: &zero, HealthyTargets: &zero}, []string{types.WarningNoTargets}, 73},
+		{"unhealthy", types.LoadBalancer{Type: "application", HourlyCost: 0.1, RegisteredTargets: &two, HealthyTargets: &zero}, []string{types.WarningNoHealthyTargets}, 73},
+		{"classic", types.LoadBalancer{Type: "classic", HourlyCost: 0.1, RegisteredTargets: &two, HealthyTargets: &two}, []string{types.WarningClassicLoadBalancer}, 0},
+		{"idle classic", types.LoadBalancer{Type: "classic", HourlyCost: 0.1, RegisteredTargets: &zero, HealthyTargets: &zero}, []string{types.WarningClassicLoadBalancer, types.WarningNoTargets}, 73},
+	}
+	for _, tt := range tests {
+		warnings := loadBalancerWarnings(tt.lb)
+		var codes []string
+		var savings types.CostValue
+		for _, w := range warnings {
+			codes = append(codes, w.Code)
+			savings = savings.Add(w.MonthlySavings)
+		}
+		if !slices.Equal(codes, tt.want) || math.Abs(float64(savings-tt.savings)) > 1e-9 {
+			t.Fatalf("%s: warnings = %v saving %v, want %v saving %v", tt.name, codes, savings, tt.want, tt.savings)
+		}
+	}
+}
+
+func TestHostUtilization(t *testing.T) {
+	single := ec2types.Host{
+		HostProperties: &ec2types.HostProperties{InstanceFamily: aws.String("m5"), InstanceType: aws.String("m5.large"), TotalVCpus: aws.Int32(96)},
+		AvailableCapacity: &ec2types.AvailableCapacity{
+			AvailableInstanceCapacity: []ec2types.InstanceCapacity{{InstanceType: aws.String("m5.large"), TotalCapacity: aws.Int32(48), AvailableCapacity: aws.Int32(36)}},
+			AvailableVCpus:            aws.Int32(72),
+		},
+	}
+	if capacity, utilization := hostUtilization(single); capacity != 48 || utilization != 0.25 {
+		t.Fatalf("single type host: got capacity %d, utilization %v", capacity, utilization)
+	}
+
+	mixed := ec2types.Host{
+		HostProperties:    &ec2types.HostProperties{InstanceFamily: aws.String("m5"), TotalVCpus: aws.Int32(96)},
+		AvailableCapacity: &ec2types.AvailableCapacity{AvailableVCpus: aws.Int32(24)},
+	}
+	if capacity, utilization := hostUtilization(mixed); capacity != 0 || utilization != 0.75 {
+		t.Fatalf("mixed type host: got capacity %d, utilization %v", capacity, utilization)
+	}
+}
+
+func TestCreationAge(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	created := time.Date(2025, 3, 10, 18, 0, 0, 0, time.FixedZone("EST", -5*3600))
+
+	createdAt, age := creationAge(&created, now)
+	if createdAt != "2025-03-10T23:00:00Z" || age != 364 {
+		t.Errorf("got %s, %d days", createdAt, age)
+	}
+	if createdAt, age := creationAge(nil, now); createdAt != "" || age != 0 {
+		t.Errorf("expected no age for a missing time, got %s, %d", createdAt, age)
+	}
+}
+
+func TestStopTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	stoppedAt, days := stopTime("User initiated (2026-02-01 09:30:00 GMT)", now)
+	if stoppedAt != "2026-02-01T09:30:00Z" || days != 37 {
+		t.Errorf("got %s, %d days", stoppedAt, days)
+	}
+	if stoppedAt, days := stopTime("Server.ScheduledStop: Stopped due to scheduled retirement", now); stoppedAt != "" || days != 0 {
+		t.Errorf("expected no stop time without a timestamp, got %s, %d", stoppedAt, days)
+	}
+}
@@ -0,0 +1,165 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// ecsDescribeMaxInstances is the most container instances
+// DescribeContainerInstances accepts per call
+const ecsDescribeMaxInstances = 100
+
+// ecsCapacity is the EC2 capacity registered to an ECS cluster
+type ecsCapacity struct {
+	cpu        int32 // CPU units, 1024 per vCPU
+	memory     int32 // MiB
+	hourlyCost types.CostValue
+}
+
+// share returns the part of the capacity's cost attributed to tasks
+// reserving cpu units and memory MiB: the average of their CPU and memory
+// shares, or just one if the cluster registers none of the other
+func (c ecsCapacity) share(cpu, memory int32) types.CostValue {
+	var shares []float64
+	if c.cpu > 0 {
+		shares = append(shares, min(float64(cpu)/float64(c.cpu), 1))
+	}
+	if c.memory > 0 {
+		shares = append(shares, min(float64(memory)/float64(c.memory), 1))
+	}
+	if len(shares) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range shares {
+		sum += s
+	}
+	return c.hourlyCost * types.CostValue(sum/float64(len(shares)))
+}
+
+// ecsClusterCapacity returns the CPU, memory, and on-demand EC2 cost of a
+// cluster's active container instances. Instance types that can't be priced
+// are recorded as diagnostics and left out of the cost.
+func (d *Discovery) ecsClusterCapacity(ctx context.Context, client *ecs.Client, clusterArn, clusterName, accountID, accountName, region string) (ecsCapacity, error) {
+	var capacity ecsCapacity
+	paginator := ecs.NewListContainerInstancesPaginator(client, &ecs.ListContainerInstancesInput{
+		Cluster: aws.String(clusterArn),
+		Status:  ecstypes.ContainerInstanceStatusActive,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return capacity, fmt.Errorf("listing container instances: %w", err)
+		}
+		for start := 0; start < len(page.ContainerInstanceArns); start += ecsDescribeMaxInstances {
+			batch := page.ContainerInstanceArns[start:min(start+ecsDescribeMaxInstances, len(page.ContainerInstanceArns))]
+			output, err := client.DescribeContainerInstances(ctx, &ecs.DescribeContainerInstancesInput{
+				Cluster:            aws.String(clusterArn),
+				ContainerInstances: batch,
+			})
+			if err != nil {
+				return capacity, fmt.Errorf("describing container instances: %w", err)
+			}
+			for _, ci := range output.ContainerInstances {
+				for _, r := range ci.RegisteredResources {
+					switch aws.ToString(r.Name) {
+					case "CPU":
+						capacity.cpu += r.IntegerValue
+					case "MEMORY":
+						capacity.memory += r.IntegerValue
+					}
+				}
+				instanceType := containerInstanceType(ci.Attributes)
+				if instanceType == "" {
+					continue
+				}
+				price, err := d.prices.HourlyPrice(ctx, cloud.PriceQuery{Service: "ec2", Region: region, SKU: instanceType})
+				if err != nil {
+					recordDiagnostic(ctx, newDiagnostic("warning", "ecs", accountID, accountName, region, "pricing", clusterName+"/"+aws.ToString(ci.Ec2InstanceId), err))
+					continue
+				}
+				capacity.hourlyCost = capacity.hourlyCost.Add(price)
+			}
+		}
+	}
+	return capacity, nil
+}
+
+// containerInstanceType returns the EC2 instance type the ECS agent reports
+// for a container instance
+func containerInstanceType(attributes []ecstypes.Attribute) string {
+	for _, a := range attributes {
+		if aws.ToString(a.Name) == "ecs.instance-type" {
+			return aws.ToString(a.Value)
+		}
+	}
+	return ""
+}
+
+// taskReservation returns the CPU units and memory MiB one task of a task
+// definition reserves: the task-level sizes if set, otherwise the sum of its
+// containers' sizes, using the soft memory reservation where there's no hard
+// limit
+func taskReservation(td *ecstypes.TaskDefinition) (cpu, memory int32) {
+	if td == nil {
+		return 0, 0
+	}
+	var containerCPU, containerMemory int32
+	for _, c := range td.ContainerDefinitions {
+		containerCPU += c.Cpu
+		if m := aws.ToInt32(c.Memory); m > 0 {
+			containerMemory += m
+		} else {
+			containerMemory += aws.ToInt32(c.MemoryReservation)
+		}
+	}
+	cpu, memory = containerCPU, containerMemory
+	if n, err := strconv.Atoi(aws.ToString(td.Cpu)); err == nil && n > 0 {
+		cpu = int32(n)
+	}
+	if n, err := strconv.Atoi(aws.ToString(td.Memory)); err == nil && n > 0 {
+		memory = int32(n)
+	}
+	return cpu, memory
+}
+
+// describeTaskDefinition returns the task definition with arn, from cache if
+// another service in the cluster uses it
+func describeTaskDefinition(ctx context.Context, client *ecs.Client, cache map[string]*ecstypes.TaskDefinition, arn string) (*ecstypes.TaskDefinition, error) {
+	if td, ok := cache[arn]; ok {
+		return td, nil
+	}
+	output, err := client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: aws.String(arn)})
+	if err != nil {
+		return nil, err
+	}
+	cache[arn] = output.TaskDefinition
+	return output.TaskDefinition, nil
+}
+
+// ecsLaunchType returns a service's launch type. Services using a capacity
+// provider strategy have none; they run on Fargate if every provider is a
+// Fargate one and on EC2 otherwise.
+func ecsLaunchType(svc ecstypes.Service) string {
+	if svc.LaunchType != "" {
+		return string(svc.LaunchType)
+	}
+	if len(svc.CapacityProviderStrategy) == 0 {
+		return "EC2"
+	}
+	for _, s := range svc.CapacityProviderStrategy {
+		if !strings.HasPrefix(aws.ToString(s.CapacityProvider), "FARGATE") {
+			return "EC2"
+		}
+	}
+	return "FARGATE"
+}
@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"math"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+)
+
+func TestTaskReservation(t *testing.T) {
+	tests := []struct {
+		name        string
+		td          *ecstypes.TaskDefinition
+		cpu, memory int32
+	}{
+		{"task level", &ecstypes.TaskDefinition{Cpu: aws.String("512"), Memory: aws.String("1024"), ContainerDefinitions: []ecstypes.ContainerDefinition{{Cpu: 128, Memory: aws.Int32(256)}}}, 512, 1024},
+		{"containers", &ecstypes.TaskDefinition{ContainerDefinitions: []ecstypes.ContainerDefinition{
+			{Cpu: 256, Memory: aws.Int32(512)},
+			{Cpu: 128, MemoryReservation: aws.Int32(128)},
+		}}, 384, 640},
+		{"nil", nil, 0, 0},
+	}
+	for _, tt := range tests {
+		cpu, memory := taskReservation(tt.td)
+		if cpu != tt.cpu || memory != tt.memory {
+			t.Errorf("%s: taskReservation = %d, %d, want %d, %d", tt.name, cpu, memory, tt.cpu, tt.memory)
+		}
+	}
+}
+
+func TestECSCapacityShare(t *testing.T) {
+	// Two m5.large: 2048 CPU units and 7,500 MiB each
+	capacity := ecsCapacity{cpu: 4096, memory: 15000, hourlyCost: 0.192}
+	// A quarter of the CPU and half the memory
+	if got := capacity.share(1024, 7500); math.Abs(float64(got)-0.072) > 1e-9 {
+		t.Errorf("share = %v, want 0.072", got)
+	}
+	if got := (ecsCapacity{memory: 1000, hourlyCost: 1}).share(0, 250); got != 0.25 {
+		t.Errorf("memory-only share = %v, want 0.25", got)
+	}
+	if got := (ecsCapacity{cpu: 1024, memory: 1024, hourlyCost: 1}).share(4096, 4096); got != 1 {
+		t.Errorf("oversubscribed share = %v, want the whole cost", got)
+	}
+}
+
+func TestECSLaunchType(t *testing.T) {
+	strategy := func(providers ...string) []ecstypes.CapacityProviderStrategyItem {
+		var items []ecstypes.CapacityProviderStrategyItem
+		for _, p := range providers {
+			items = append(items, ecstypes.CapacityProviderStrategyItem{CapacityProvider: aws.String(p)})
+		}
+		return items
+	}
+	tests := []struct {
+		svc  ecstypes.Service
+		want string
+	}{
+		{ecstypes.Service{LaunchType: ecstypes.LaunchTypeFargate}, "FARGATE"},
+		{ecstypes.Service{}, "EC2"},
+		{ecstypes.Service{CapacityProviderStrategy: strategy("FARGATE", "FARGATE_SPOT")}, "FARGATE"},
+		{ecstypes.Service{CapacityProviderStrategy: strategy("FARGATE", "asg-provider")}, "EC2"},
+	}
+	for _, tt := range tests {
+		if got := ecsLaunchType(tt.svc); got != tt.want {
+			t.Errorf("ecsLaunchType(%v) = %s, want %s", tt.svc.CapacityProviderStrategy, got, tt.want)
+		}
+	}
+}
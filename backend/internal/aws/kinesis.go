@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	fhtypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+// Kinesis Data Streams capacity modes
+const (
+	kinesisModeProvisioned = string(kinesistypes.StreamModeProvisioned)
+	kinesisModeOnDemand    = string(kinesistypes.StreamModeOnDemand)
+)
+
+// kinesisStreamMode returns a stream's capacity mode. Streams created before
+// on-demand mode existed report no mode and are provisioned.
+func kinesisStreamMode(s *kinesistypes.StreamDescriptionSummary) string {
+	if s.StreamModeDetails == nil || s.StreamModeDetails.StreamMode == "" {
+		return kinesisModeProvisioned
+	}
+	return string(s.StreamModeDetails.StreamMode)
+}
+
+// listKinesisStreams returns the names of the Kinesis data streams in cfg's
+// region
+func listKinesisStreams(ctx context.Context, cfg aws.Config) ([]string, error) {
+	client := kinesis.NewFromConfig(cfg)
+	var names []string
+	input := &kinesis.ListStreamsInput{Limit: aws.Int32(100)}
+	for {
+		output, err := client.ListStreams(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, output.StreamNames...)
+		if !aws.ToBool(output.HasMoreStreams) || len(output.StreamNames) == 0 {
+			return names, nil
+		}
+		// NextToken can't be combined with other parameters; older endpoints
+		// only page by the last stream name
+		if output.NextToken != nil {
+			input = &kinesis.ListStreamsInput{NextToken: output.NextToken}
+		} else {
+			input = &kinesis.ListStreamsInput{Limit: aws.Int32(100), ExclusiveStartStreamName: aws.String(names[len(names)-1])}
+		}
+	}
+}
+
+// describeKinesisStream returns the shard count and capacity mode of a stream
+func describeKinesisStream(ctx context.Context, cfg aws.Config, name string) (*kinesistypes.StreamDescriptionSummary, error) {
+	output, err := kinesis.NewFromConfig(cfg).DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{StreamName: aws.String(name)})
+	if err != nil {
+		return nil, err
+	}
+	return output.StreamDescriptionSummary, nil
+}
+
+// firehoseDestinations returns a delivery stream's destination types, such
+// as "ExtendedS3" or "Redshift", from the names of the destination
+// descriptions it has
+func firehoseDestinations(s *fhtypes.DeliveryStreamDescription) []string {
+	var out []string
+	for _, dest := range s.Destinations {
+		v := reflect.ValueOf(dest)
+		for i := 0; i < v.NumField(); i++ {
+			name, ok := strings.CutSuffix(v.Type().Field(i).Name, "DestinationDescription")
+			if ok && !v.Field(i).IsNil() && !slices.Contains(out, name) {
+				out = append(out, name)
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// listDeliveryStreams returns the names of the Firehose delivery streams in
+// cfg's region
+func listDeliveryStreams(ctx context.Context, cfg aws.Config) ([]string, error) {
+	client := firehose.NewFromConfig(cfg)
+	var names []string
+	input := &firehose.ListDeliveryStreamsInput{Limit: aws.Int32(10000)}
+	for {
+		output, err := client.ListDeliveryStreams(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, output.DeliveryStreamNames...)
+		if !aws.ToBool(output.HasMoreDeliveryStreams) || len(output.DeliveryStreamNames) == 0 {
+			return names, nil
+		}
+		input.ExclusiveStartDeliveryStreamName = aws.String(names[len(names)-1])
+	}
+}
+
+// describeDeliveryStream returns a Firehose delivery stream's source and
+// destinations
+func describeDeliveryStream(ctx context.Context, cfg aws.Config, name string) (*fhtypes.DeliveryStreamDescription, error) {
+	output, err := firehose.NewFromConfig(cfg).DescribeDeliveryStream(ctx, &firehose.DescribeDeliveryStreamInput{DeliveryStreamName: aws.String(name)})
+	if err != nil {
+		return nil, err
+	}
+	return output.DeliveryStreamDescription, nil
+}
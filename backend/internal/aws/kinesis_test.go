@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	fhtypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestListKinesisStreams(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Amz-Target") != "Kinesis_20131202.ListStreams" || r.Header.Get("Content-Type") != "application/x-amz-json-1.1" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		var input map[string]any
+		json.NewDecoder(r.Body).Decode(&input)
+		if input["NextToken"] == nil {
+			io.WriteString(w, `{"StreamNames":["clicks"],"HasMoreStreams":true,"NextToken":"page2"}`)
+			return
+		}
+		if _, ok := input["Limit"]; ok {
+			t.Errorf("NextToken sent with Limit: %v", input)
+		}
+		io.WriteString(w, `{"StreamNames":["orders"],"HasMoreStreams":false}`)
+	}))
+	defer srv.Close()
+
+	names, err := listKinesisStreams(t.Context(), apiTestConfig(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || !slices.Equal(names, []string{"clicks", "orders"}) {
+		t.Fatalf("calls = %d, names = %v", calls, names)
+	}
+}
+
+func TestDescribeDeliveryStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"DeliveryStreamDescription":{
+			"DeliveryStreamName":"logs","DeliveryStreamStatus":"ACTIVE","DeliveryStreamType":"DirectPut",
+			"Destinations":[{"DestinationId":"d-1","ExtendedS3DestinationDescription":{},"S3DestinationDescription":{}}]}}`)
+	}))
+	defer srv.Close()
+
+	s, err := describeDeliveryStream(t.Context(), apiTestConfig(srv.URL), "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.DeliveryStreamType != fhtypes.DeliveryStreamTypeDirectPut || !slices.Equal(firehoseDestinations(s), []string{"ExtendedS3", "S3"}) {
+		t.Fatalf("stream = %+v, destinations = %v", s, firehoseDestinations(s))
+	}
+}
+
+func TestKinesisStreamMode(t *testing.T) {
+	s := &kinesistypes.StreamDescriptionSummary{}
+	if mode := kinesisStreamMode(s); mode != kinesisModeProvisioned {
+		t.Fatalf("mode without details = %q, want provisioned", mode)
+	}
+	s.StreamModeDetails = &kinesistypes.StreamModeDetails{StreamMode: kinesistypes.StreamModeOnDemand}
+	if mode := kinesisStreamMode(s); mode != kinesisModeOnDemand {
+		t.Fatalf("mode = %q, want on-demand", mode)
+	}
+}
+
+func TestKinesisAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"__type":"com.amazonaws.kinesis#AccessDeniedException","message":"not allowed"}`)
+	}))
+	defer srv.Close()
+
+	_, err := listKinesisStreams(t.Context(), apiTestConfig(srv.URL))
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDeniedException" || apiErr.ErrorMessage() != "not allowed" {
+		t.Fatalf("err = %v, want AccessDeniedException", err)
+	}
+}
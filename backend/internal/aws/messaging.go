@@ -0,0 +1,368 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// sqsRequestMetrics are the SQS metrics that each count billed requests.
+// Received messages stand in for ReceiveMessage calls, so batched receives
+// are overestimated.
+var sqsRequestMetrics = []string{"NumberOfMessagesSent", "NumberOfMessagesReceived", "NumberOfMessagesDeleted", "NumberOfEmptyReceives"}
+
+// defaultEventBus is the event bus AWS services send their events to.
+// Those events are free, so the default bus isn't priced.
+const defaultEventBus = "default"
+
+// SetMessaging sets the request rates SQS, SNS, and EventBridge costs are
+// estimated with
+func (d *Discovery) SetMessaging(cfg config.MessagingConfig) {
+	d.messaging = cfg
+}
+
+// getOrDiscoverSQSQueues returns cached SQS queues or discovers them
+func (d *Discovery) getOrDiscoverSQSQueues(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.SQSQueue {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "sqs", d.discoverSQSQueues)
+}
+
+// getOrDiscoverSNSTopics returns cached SNS topics or discovers them
+func (d *Discovery) getOrDiscoverSNSTopics(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.SNSTopic {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "sns", d.discoverSNSTopics)
+}
+
+// getOrDiscoverEventBuses returns cached EventBridge buses or discovers them
+func (d *Discovery) getOrDiscoverEventBuses(ctx context.Context, cfg aws.Config, accountID, accountName, region string) []types.EventBus {
+	return getOrDiscoverResource(d, ctx, cfg, accountID, accountName, region, "eventbridge", d.discoverEventBuses)
+}
+
+// requestComponent returns the cost of a day's requests at a rate per
+// million, averaged to an hour, or nil if there were none
+func requestComponent(name, unit string, perDay, perMillion float64) []types.CostComponent {
+	if perDay <= 0 {
+		return nil
+	}
+	return []types.CostComponent{types.HourlyComponent(name, unit, perDay/24/1e6, types.CostValue(perMillion))}
+}
+
+// dailyUsage fetches the last day's sums of metrics. If CloudWatch can't be
+// read, the sums are zero and the status says why.
+func (d *Discovery) dailyUsage(ctx context.Context, cfg aws.Config, resourceType, accountID, accountName, region string, metrics []cwtypes.Metric) ([]float64, string, string) {
+	if len(metrics) == 0 {
+		return nil, types.UsageStatusOK, ""
+	}
+	sums, err := fetchDailySums(ctx, cloudwatch.NewFromConfig(cfg), metrics)
+	if err != nil {
+		d.logger.Debug("failed to fetch usage", "type", resourceType, "region", region, "error", err)
+		recordDiagnostic(ctx, newDiagnostic("warning", resourceType, accountID, accountName, region, "GetMetricData", "", err))
+		return make([]float64, len(metrics)), types.UsageStatusUnavailable, err.Error()
+	}
+	return sums, types.UsageStatusOK, ""
+}
+
+func metric(namespace, name, dimension, value string) cwtypes.Metric {
+	return cwtypes.Metric{
+		Namespace:  aws.String(namespace),
+		MetricName: aws.String(name),
+		Dimensions: []cwtypes.Dimension{{Name: aws.String(dimension), Value: aws.String(value)}},
+	}
+}
+
+// sqsQueue is the part of a GetQueueAttributes response awsCOGS reads. SQS
+// returns every attribute as a string.
+type sqsQueue struct {
+	URL        string
+	Attributes map[string]string
+}
+
+// name returns the queue's name, the last segment of its URL
+func (q sqsQueue) name() string {
+	return q.URL[strings.LastIndex(q.URL, "/")+1:]
+}
+
+func (q sqsQueue) fifo() bool {
+	return q.Attributes["FifoQueue"] == "true"
+}
+
+func (q sqsQueue) messages() int {
+	n, _ := strconv.Atoi(q.Attributes["ApproximateNumberOfMessages"])
+	return n
+}
+
+// createdAt returns when the queue was created, from its CreatedTimestamp in
+// Unix seconds
+func (q sqsQueue) createdAt() *time.Time {
+	seconds, err := strconv.ParseInt(q.Attributes["CreatedTimestamp"], 10, 64)
+	if err != nil || seconds == 0 {
+		return nil
+	}
+	t := time.Unix(seconds, 0)
+	return &t
+}
+
+// listSQSQueues returns the URLs of the SQS queues in cfg's region
+func listSQSQueues(ctx context.Context, cfg aws.Config) ([]string, error) {
+	var urls []string
+	paginator := sqs.NewListQueuesPaginator(sqs.NewFromConfig(cfg), &sqs.ListQueuesInput{MaxResults: aws.Int32(1000)})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, output.QueueUrls...)
+	}
+	return urls, nil
+}
+
+// describeSQSQueue returns the attributes of a queue
+func describeSQSQueue(ctx context.Context, client *sqs.Client, url string) (sqsQueue, error) {
+	output, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(url),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameAll},
+	})
+	if err != nil {
+		return sqsQueue{URL: url}, err
+	}
+	return sqsQueue{URL: url, Attributes: output.Attributes}, nil
+}
+
+// discoverSQSQueues discovers SQS queues in the specified region. SQS bills
+// per request, so the hourly cost is the last day's requests from
+// CloudWatch averaged to an hour.
+func (d *Discovery) discoverSQSQueues(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.SQSQueue, error) {
+	urls, err := listSQSQueues(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("listing SQS queues: %w", err)
+	}
+
+	client := sqs.NewFromConfig(cfg)
+	var (
+		queues  []sqsQueue
+		metrics []cwtypes.Metric
+	)
+	for _, url := range urls {
+		q, err := describeSQSQueue(ctx, client, url)
+		if err != nil {
+			d.logger.Warn("failed to describe SQS queue",
+				"queue", url,
+				"region", region,
+				"error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "sqs", accountID, accountName, region, "GetQueueAttributes", url, err))
+			continue
+		}
+		queues = append(queues, q)
+		for _, name := range sqsRequestMetrics {
+			metrics = append(metrics, metric("AWS/SQS", name, "QueueName", q.name()))
+		}
+	}
+	sums, usageStatus, usageErr := d.dailyUsage(ctx, cfg, "sqs", accountID, accountName, region, metrics)
+
+	out := make([]types.SQSQueue, 0, len(queues))
+	for i, q := range queues {
+		var requests float64
+		for _, v := range sums[i*len(sqsRequestMetrics) : (i+1)*len(sqsRequestMetrics)] {
+			requests += v
+		}
+		rate := d.messaging.SQSPerMillion
+		if q.fifo() {
+			rate = d.messaging.SQSFIFOPerMillion
+		}
+		components := requestComponent("requests", "million requests", requests, rate)
+
+		createdAt, ageDays := creationAge(q.createdAt(), time.Now())
+		out = append(out, types.SQSQueue{
+			AccountID:       accountID,
+			AccountName:     accountName,
+			Region:          region,
+			Name:            q.name(),
+			URL:             q.URL,
+			ARN:             q.Attributes["QueueArn"],
+			FIFO:            q.fifo(),
+			MessagesVisible: q.messages(),
+			Requests:        requests,
+			UsageWindow:     "24h",
+			UsageStatus:     usageStatus,
+			UsageError:      usageErr,
+			CreatedAt:       createdAt,
+			AgeDays:         ageDays,
+			HourlyCost:      types.SumComponents(components),
+			CostComponents:  components,
+			PriceSource:     pricing.PriceSourceConfig,
+		})
+	}
+	return out, nil
+}
+
+// topicName returns an SNS topic's name, the last segment of its ARN
+func topicName(arn string) string {
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
+
+// discoverSNSTopics discovers SNS topics in the specified region, with the
+// hourly cost of the last day's publishes. Deliveries to subscribers are
+// billed by protocol and aren't estimated.
+func (d *Discovery) discoverSNSTopics(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.SNSTopic, error) {
+	client := sns.NewFromConfig(cfg)
+	var topics []types.SNSTopic
+	paginator := sns.NewListTopicsPaginator(client, &sns.ListTopicsInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing SNS topics: %w", err)
+		}
+		for _, t := range output.Topics {
+			arn := aws.ToString(t.TopicArn)
+			topic := types.SNSTopic{
+				AccountID:   accountID,
+				AccountName: accountName,
+				Region:      region,
+				Name:        topicName(arn),
+				ARN:         arn,
+				UsageWindow: "24h",
+				PriceSource: pricing.PriceSourceConfig,
+			}
+			attrs, err := client.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: t.TopicArn})
+			if err != nil {
+				d.logger.Warn("failed to get SNS topic attributes",
+					"topic", arn,
+					"region", region,
+					"error", err)
+				recordDiagnostic(ctx, newDiagnostic("warning", "sns", accountID, accountName, region, "GetTopicAttributes", arn, err))
+			} else {
+				topic.FIFO = attrs.Attributes["FifoTopic"] == "true"
+				topic.Subscriptions, _ = strconv.Atoi(attrs.Attributes["SubscriptionsConfirmed"])
+			}
+			topics = append(topics, topic)
+		}
+	}
+
+	metrics := make([]cwtypes.Metric, len(topics))
+	for i, t := range topics {
+		metrics[i] = metric("AWS/SNS", "NumberOfMessagesPublished", "TopicName", t.Name)
+	}
+	sums, usageStatus, usageErr := d.dailyUsage(ctx, cfg, "sns", accountID, accountName, region, metrics)
+	for i := range topics {
+		t := &topics[i]
+		t.Publishes = sums[i]
+		t.UsageStatus, t.UsageError = usageStatus, usageErr
+		t.CostComponents = requestComponent("publishes", "million requests", t.Publishes, d.messaging.SNSPerMillion)
+		t.HourlyCost = types.SumComponents(t.CostComponents)
+	}
+	return topics, nil
+}
+
+// listEventBuses returns the EventBridge event buses in cfg's region
+func listEventBuses(ctx context.Context, client *eventbridge.Client) ([]ebtypes.EventBus, error) {
+	var buses []ebtypes.EventBus
+	input := &eventbridge.ListEventBusesInput{Limit: aws.Int32(100)}
+	for {
+		output, err := client.ListEventBuses(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		buses = append(buses, output.EventBuses...)
+		if output.NextToken == nil {
+			return buses, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// listEventRules returns the rules on an event bus
+func listEventRules(ctx context.Context, client *eventbridge.Client, bus string) ([]types.EventBridgeRule, error) {
+	var rules []types.EventBridgeRule
+	input := &eventbridge.ListRulesInput{EventBusName: aws.String(bus), Limit: aws.Int32(100)}
+	for {
+		output, err := client.ListRules(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range output.Rules {
+			rules = append(rules, types.EventBridgeRule{
+				Name:               aws.ToString(r.Name),
+				State:              string(r.State),
+				ScheduleExpression: aws.ToString(r.ScheduleExpression),
+			})
+		}
+		if output.NextToken == nil {
+			return rules, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// publishedEvents returns the billed events from a bus's PutEvents entry
+// counts. Entries that failed aren't billed.
+func publishedEvents(entries, failed float64) float64 {
+	return max(entries-failed, 0)
+}
+
+// discoverEventBuses discovers EventBridge event buses and their rules in the
+// specified region. Custom buses are priced at the last day's published
+// events averaged to an hour, since EventBridge bills for each event put on a
+// bus whether or not a rule matches it; the default bus mostly carries free
+// AWS service events.
+func (d *Discovery) discoverEventBuses(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.EventBus, error) {
+	client := eventbridge.NewFromConfig(cfg)
+	list, err := listEventBuses(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("listing EventBridge buses: %w", err)
+	}
+
+	buses := make([]types.EventBus, 0, len(list))
+	metrics := make([]cwtypes.Metric, 0, len(list))
+	for _, b := range list {
+		name, arn := aws.ToString(b.Name), aws.ToString(b.Arn)
+		rules, err := listEventRules(ctx, client, name)
+		if err != nil {
+			d.logger.Warn("failed to list EventBridge rules",
+				"bus", name,
+				"region", region,
+				"error", err)
+			recordDiagnostic(ctx, newDiagnostic("warning", "eventbridge", accountID, accountName, region, "ListRules", arn, err))
+		}
+		createdAt, ageDays := creationAge(b.CreationTime, time.Now())
+		buses = append(buses, types.EventBus{
+			AccountID:   accountID,
+			AccountName: accountName,
+			Region:      region,
+			Name:        name,
+			ARN:         arn,
+			Rules:       rules,
+			UsageWindow: "24h",
+			CreatedAt:   createdAt,
+			AgeDays:     ageDays,
+			PriceSource: pricing.PriceSourceConfig,
+		})
+		metrics = append(metrics,
+			metric("AWS/Events", "PutEventsEntriesCount", "EventBusName", name),
+			metric("AWS/Events", "PutEventsFailedEntriesCount", "EventBusName", name))
+	}
+
+	sums, usageStatus, usageErr := d.dailyUsage(ctx, cfg, "eventbridge", accountID, accountName, region, metrics)
+	for i := range buses {
+		b := &buses[i]
+		b.Events = publishedEvents(sums[2*i], sums[2*i+1])
+		b.UsageStatus, b.UsageError = usageStatus, usageErr
+		if b.Name != defaultEventBus {
+			b.CostComponents = requestComponent("events", "million events", b.Events, d.messaging.EventBridgePerMillion)
+		}
+		b.HourlyCost = types.SumComponents(b.CostComponents)
+	}
+	return buses, nil
+}
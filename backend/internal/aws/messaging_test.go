@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+)
+
+func TestListSQSQueues(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Amz-Target") != "AmazonSQS.ListQueues" || r.Header.Get("Content-Type") != "application/x-amz-json-1.0" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		var input map[string]any
+		json.NewDecoder(r.Body).Decode(&input)
+		if input["NextToken"] == nil {
+			io.WriteString(w, `{"QueueUrls":["https://sqs.us-east-1.amazonaws.com/123456789012/jobs"],"NextToken":"page2"}`)
+			return
+		}
+		io.WriteString(w, `{"QueueUrls":["https://sqs.us-east-1.amazonaws.com/123456789012/orders.fifo"]}`)
+	}))
+	defer srv.Close()
+
+	urls, err := listSQSQueues(t.Context(), apiTestConfig(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || len(urls) != 2 {
+		t.Fatalf("calls = %d, urls = %v", calls, urls)
+	}
+}
+
+func TestSQSQueueAttributes(t *testing.T) {
+	q := sqsQueue{
+		URL: "https://sqs.us-east-1.amazonaws.com/123456789012/orders.fifo",
+		Attributes: map[string]string{
+			"FifoQueue":                   "true",
+			"ApproximateNumberOfMessages": "42",
+			"CreatedTimestamp":            "1700000000",
+		},
+	}
+	if q.name() != "orders.fifo" || !q.fifo() || q.messages() != 42 {
+		t.Fatalf("name = %q, fifo = %v, messages = %d", q.name(), q.fifo(), q.messages())
+	}
+	if created := q.createdAt(); created == nil || created.Unix() != 1700000000 {
+		t.Fatalf("createdAt = %v", created)
+	}
+	if (sqsQueue{URL: "https://example/q"}).createdAt() != nil {
+		t.Error("expected no creation time without CreatedTimestamp")
+	}
+}
+
+func TestListEventRules(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]any
+		json.NewDecoder(r.Body).Decode(&input)
+		if input["EventBusName"] != "orders" {
+			t.Errorf("unexpected input: %v", input)
+		}
+		if input["NextToken"] == nil {
+			io.WriteString(w, `{"Rules":[{"Name":"nightly","State":"ENABLED","ScheduleExpression":"cron(0 2 * * ? *)"}],"NextToken":"page2"}`)
+			return
+		}
+		io.WriteString(w, `{"Rules":[{"Name":"on-order","State":"DISABLED"}]}`)
+	}))
+	defer srv.Close()
+
+	rules, err := listEventRules(t.Context(), eventbridge.NewFromConfig(apiTestConfig(srv.URL)), "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range rules {
+		names = append(names, r.Name)
+	}
+	if !slices.Equal(names, []string{"nightly", "on-order"}) || rules[0].ScheduleExpression == "" || rules[1].State != "DISABLED" {
+		t.Fatalf("rules = %+v", rules)
+	}
+}
+
+func TestRequestComponent(t *testing.T) {
+	if c := requestComponent("requests", "million requests", 0, 0.40); c != nil {
+		t.Fatalf("expected no component without requests, got %+v", c)
+	}
+	// 2.4 million requests a day is 0.1 million an hour
+	c := requestComponent("requests", "million requests", 2.4e6, 0.40)
+	if len(c) != 1 || c[0].HourlyCost.Round() != 0.04 {
+		t.Fatalf("components = %+v", c)
+	}
+	if topicName("arn:aws:sns:us-east-1:123456789012:alerts") != "alerts" {
+		t.Error("expected the topic name from its ARN")
+	}
+}
+
+func TestPublishedEvents(t *testing.T) {
+	if got := publishedEvents(1200, 200); got != 1000 {
+		t.Errorf("got %v, want failed entries left out", got)
+	}
+	if got := publishedEvents(0, 5); got != 0 {
+		t.Errorf("got %v, want no negative count", got)
+	}
+}
@@ -0,0 +1,270 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/smithy-go"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// permissionProbe is a lightweight call that fails the same way a scan would
+// if the action is not allowed
+type permissionProbe struct {
+	action string
+	call   func(ctx context.Context, cfg aws.Config) error
+}
+
+// permissionProbes lists the probes for each resource type
+var permissionProbes = map[string][]permissionProbe{
+	"ec2": {probeDescribeInstances, {"ec2:DescribeInstanceCreditSpecifications", func(ctx context.Context, cfg aws.Config) error {
+		_, err := ec2.NewFromConfig(cfg).DescribeInstanceCreditSpecifications(ctx, &ec2.DescribeInstanceCreditSpecificationsInput{MaxResults: aws.Int32(5)})
+		return err
+	}}},
+	"ebs": {{"ec2:DescribeVolumes", func(ctx context.Context, cfg aws.Config) error {
+		_, err := ec2.NewFromConfig(cfg).DescribeVolumes(ctx, &ec2.DescribeVolumesInput{MaxResults: aws.Int32(5)})
+		return err
+	}}},
+	"ecs": {{"ecs:ListClusters", func(ctx context.Context, cfg aws.Config) error {
+		_, err := ecs.NewFromConfig(cfg).ListClusters(ctx, &ecs.ListClustersInput{MaxResults: aws.Int32(1)})
+		return err
+	}}},
+	"rds": {{"rds:DescribeDBInstances", func(ctx context.Context, cfg aws.Config) error {
+		_, err := rds.NewFromConfig(cfg).DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{MaxRecords: aws.Int32(20)})
+		return err
+	}}},
+	"eks": {{"eks:ListClusters", func(ctx context.Context, cfg aws.Config) error {
+		_, err := eks.NewFromConfig(cfg).ListClusters(ctx, &eks.ListClustersInput{MaxResults: aws.Int32(1)})
+		return err
+	}}},
+	"elb": {{"elasticloadbalancing:DescribeLoadBalancers", func(ctx context.Context, cfg aws.Config) error {
+		_, err := elasticloadbalancingv2.NewFromConfig(cfg).DescribeLoadBalancers(ctx, &elasticloadbalancingv2.DescribeLoadBalancersInput{PageSize: aws.Int32(1)})
+		return err
+	}}},
+	"nat": {probeDescribeNatGateways},
+	"eip": {probeDescribeAddresses, probeDescribeInstances, {"ec2:DescribeNetworkInterfaces", func(ctx context.Context, cfg aws.Config) error {
+		_, err := ec2.NewFromConfig(cfg).DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{MaxResults: aws.Int32(5)})
+		return err
+	}}},
+	"publicipv4": {probeDescribeInstances, probeDescribeAddresses},
+	"secrets": {{"secretsmanager:ListSecrets", func(ctx context.Context, cfg aws.Config) error {
+		_, err := secretsmanager.NewFromConfig(cfg).ListSecrets(ctx, &secretsmanager.ListSecretsInput{MaxResults: aws.Int32(1)})
+		return err
+	}}},
+	"lambda": {{"lambda:ListFunctions", func(ctx context.Context, cfg aws.Config) error {
+		_, err := lambda.NewFromConfig(cfg).ListFunctions(ctx, &lambda.ListFunctionsInput{MaxItems: aws.Int32(1)})
+		return err
+	}}},
+	"capacityreservation": {{"ec2:DescribeCapacityReservations", func(ctx context.Context, cfg aws.Config) error {
+		_, err := ec2.NewFromConfig(cfg).DescribeCapacityReservations(ctx, &ec2.DescribeCapacityReservationsInput{MaxResults: aws.Int32(5)})
+		return err
+	}}},
+	"dedicatedhost": {{"ec2:DescribeHosts", func(ctx context.Context, cfg aws.Config) error {
+		_, err := ec2.NewFromConfig(cfg).DescribeHosts(ctx, &ec2.DescribeHostsInput{MaxResults: aws.Int32(5)})
+		return err
+	}}},
+	"kinesis": {{"kinesis:ListStreams", func(ctx context.Context, cfg aws.Config) error {
+		_, err := kinesis.NewFromConfig(cfg).ListStreams(ctx, &kinesis.ListStreamsInput{Limit: aws.Int32(1)})
+		return err
+	}}},
+	"firehose": {{"firehose:ListDeliveryStreams", func(ctx context.Context, cfg aws.Config) error {
+		_, err := firehose.NewFromConfig(cfg).ListDeliveryStreams(ctx, &firehose.ListDeliveryStreamsInput{Limit: aws.Int32(1)})
+		return err
+	}}},
+	"sqs": {{"sqs:ListQueues", func(ctx context.Context, cfg aws.Config) error {
+		_, err := sqs.NewFromConfig(cfg).ListQueues(ctx, &sqs.ListQueuesInput{MaxResults: aws.Int32(1)})
+		return err
+	}}},
+	"sns": {{"sns:ListTopics", func(ctx context.Context, cfg aws.Config) error {
+		_, err := sns.NewFromConfig(cfg).ListTopics(ctx, &sns.ListTopicsInput{})
+		return err
+	}}},
+	"alarm": {{"cloudwatch:DescribeAlarms", func(ctx context.Context, cfg aws.Config) error {
+		_, err := cloudwatch.NewFromConfig(cfg).DescribeAlarms(ctx, &cloudwatch.DescribeAlarmsInput{MaxRecords: aws.Int32(1)})
+		return err
+	}}},
+	"dashboard": {{"cloudwatch:ListDashboards", func(ctx context.Context, cfg aws.Config) error {
+		_, err := cloudwatch.NewFromConfig(cfg).ListDashboards(ctx, &cloudwatch.ListDashboardsInput{})
+		return err
+	}}},
+	"custommetrics": {{"cloudwatch:ListMetrics", func(ctx context.Context, cfg aws.Config) error {
+		_, err := cloudwatch.NewFromConfig(cfg).ListMetrics(ctx, &cloudwatch.ListMetricsInput{RecentlyActive: cwtypes.RecentlyActivePt3h})
+		return err
+	}}},
+	"eventbridge": {{"events:ListEventBuses", func(ctx context.Context, cfg aws.Config) error {
+		_, err := eventbridge.NewFromConfig(cfg).ListEventBuses(ctx, &eventbridge.ListEventBusesInput{Limit: aws.Int32(1)})
+		return err
+	}}},
+	"datatransfer": {probeDescribeInstances, probeDescribeNatGateways},
+}
+
+var probeDescribeInstances = permissionProbe{"ec2:DescribeInstances", func(ctx context.Context, cfg aws.Config) error {
+	_, err := ec2.NewFromConfig(cfg).DescribeInstances(ctx, &ec2.DescribeInstancesInput{MaxResults: aws.Int32(5)})
+	return err
+}}
+
+var probeDescribeNatGateways = permissionProbe{"ec2:DescribeNatGateways", func(ctx context.Context, cfg aws.Config) error {
+	_, err := ec2.NewFromConfig(cfg).DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{MaxResults: aws.Int32(5)})
+	return err
+}}
+
+var probeDescribeAddresses = permissionProbe{"ec2:DescribeAddresses", func(ctx context.Context, cfg aws.Config) error {
+	_, err := ec2.NewFromConfig(cfg).DescribeAddresses(ctx, &ec2.DescribeAddressesInput{})
+	return err
+}}
+
+// deniedErrorCodes are API error codes that mean the caller lacks permission
+var deniedErrorCodes = map[string]bool{
+	"AccessDenied":          true,
+	"AccessDeniedException": true,
+	"UnauthorizedOperation": true,
+	"UnauthorizedAccess":    true,
+	"AuthorizationError":    true,
+	"NotAuthorized":         true,
+}
+
+// permissionStatus classifies a probe error
+func permissionStatus(err error) string {
+	if err == nil {
+		return types.PermissionStatusOK
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && deniedErrorCodes[apiErr.ErrorCode()] {
+		return types.PermissionStatusDenied
+	}
+	return types.PermissionStatusError
+}
+
+// CheckPermissions makes one lightweight call per action needed to scan each
+// resource type in every account and region, without discovering or pricing
+// anything. Role assumption failures are reported as an sts:AssumeRole check.
+func (d *Discovery) CheckPermissions(ctx context.Context, accounts []Account, regions []string, resourceTypes []string) *types.PermissionsCheckResponse {
+	if len(accounts) == 0 {
+		accounts = defaultAccountsForRegions(regions)
+	}
+	if len(resourceTypes) == 0 {
+		resourceTypes = ResourceTypes
+	}
+
+	var (
+		checks []types.PermissionCheck
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+
+	for _, account := range accounts {
+		for _, region := range regions {
+			if account.AccountPartition() != PartitionForRegion(region) {
+				continue
+			}
+
+			wg.Add(1)
+			go func(acc Account, reg string) {
+				defer wg.Done()
+				results := d.checkAccountRegion(ctx, acc, reg, resourceTypes)
+
+				mu.Lock()
+				checks = append(checks, results...)
+				mu.Unlock()
+			}(account, region)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(checks, func(i, j int) bool {
+		a, b := checks[i], checks[j]
+		if a.AccountName != b.AccountName {
+			return a.AccountName < b.AccountName
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.Action < b.Action
+	})
+
+	response := &types.PermissionsCheckResponse{
+		Status:  types.ResponseStatusOK,
+		Checked: len(checks),
+		Checks:  checks,
+	}
+	for _, check := range checks {
+		if check.Status != types.PermissionStatusOK {
+			response.Failed++
+		}
+	}
+	if response.Failed > 0 {
+		response.Status = types.ResponseStatusFailed
+	}
+	return response
+}
+
+// checkAccountRegion runs every probe for resourceTypes in one account and region
+func (d *Discovery) checkAccountRegion(ctx context.Context, acc Account, region string, resourceTypes []string) []types.PermissionCheck {
+	base := types.PermissionCheck{AccountID: acc.ID, AccountName: acc.Name, Region: region}
+
+	cfg, err := d.getConfigForAccount(ctx, acc, region)
+	if err == nil {
+		// Resolve the account ID up front so a role that can't be assumed
+		// fails here rather than on every probe
+		var accountID string
+		accountID, err = d.getAccountID(ctx, cfg)
+		if base.AccountID == "" {
+			base.AccountID = accountID
+		}
+	}
+	if err != nil {
+		check := base
+		check.Action = "sts:AssumeRole"
+		if acc.RoleARN == "" {
+			check.Action = "sts:GetCallerIdentity"
+		}
+		check.Status = permissionStatus(err)
+		check.Message = err.Error()
+		return []types.PermissionCheck{check}
+	}
+	if base.AccountName == "" {
+		base.AccountName = base.AccountID
+	}
+
+	var checks []types.PermissionCheck
+	for _, rt := range resourceTypes {
+		for _, probe := range permissionProbes[rt] {
+			check := base
+			check.ResourceType = rt
+			check.Action = probe.action
+			err := probe.call(ctx, cfg)
+			check.Status = permissionStatus(err)
+			if err != nil {
+				check.Message = err.Error()
+				d.logger.Debug("permission check failed",
+					"account", check.AccountName,
+					"region", region,
+					"action", probe.action,
+					"error", err)
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
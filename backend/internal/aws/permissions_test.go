@@ -0,0 +1,48 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestPermissionStatusClassifiesAPIErrors(t *testing.T) {
+	denied := fmt.Errorf("operation error EC2: DescribeVolumes: %w", &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+	throttled := &smithy.GenericAPIError{Code: "Throttling"}
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, types.PermissionStatusOK},
+		{denied, types.PermissionStatusDenied},
+		{throttled, types.PermissionStatusError},
+		{errors.New("dial tcp: timeout"), types.PermissionStatusError},
+	}
+	for _, tt := range tests {
+		if got := permissionStatus(tt.err); got != tt.want {
+			t.Fatalf("permissionStatus(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestPermissionProbesCoverResourceTypes(t *testing.T) {
+	for _, rt := range ResourceTypes {
+		if len(permissionProbes[rt]) == 0 {
+			t.Fatalf("no permission probe for %s", rt)
+		}
+		for _, probe := range permissionProbes[rt] {
+			found := false
+			for _, action := range resourceActions[rt] {
+				found = found || action == probe.action
+			}
+			if !found {
+				t.Fatalf("%s probe %s is not in the generated IAM policy", rt, probe.action)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"plugin"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// PluginFunc is the signature of the Discover symbol a Go plugin exports. It
+// returns the target's resources of the plugin's type.
+type PluginFunc func(ctx context.Context, target Target) ([]types.PluginResource, error)
+
+// defaultPluginTimeout bounds a sidecar request when the plugin sets no timeout
+const defaultPluginTimeout = 60 * time.Second
+
+// pluginDiscoverer runs an external discoverer from the aws.plugins config.
+// Its resources are cached like the built-in types'.
+type pluginDiscoverer struct {
+	d        *Discovery
+	config   config.PluginConfig
+	discover PluginFunc
+}
+
+func (p *pluginDiscoverer) ResourceType() string { return p.config.Name }
+func (p *pluginDiscoverer) DisplayName() string  { return p.config.Name }
+func (p *pluginDiscoverer) IAMActions() []string { return p.config.IAMActions }
+
+func (p *pluginDiscoverer) Scope() string {
+	if p.config.Scope == "" {
+		return ScopeRegional
+	}
+	return p.config.Scope
+}
+
+func (p *pluginDiscoverer) Discover(ctx context.Context, target Target, out *types.CostResponse) {
+	// Every plugin in a task adds to the same response
+	out.PluginResources = append(out.PluginResources, getOrDiscoverResource(p.d, ctx, target.Config, target.AccountID, target.AccountName, target.Region, p.config.Name,
+		func(ctx context.Context, _ aws.Config, _, _, _ string) ([]types.PluginResource, error) {
+			resources, err := p.discover(ctx, target)
+			if err != nil {
+				return nil, err
+			}
+
+			// The plugin only has to report what it knows; fill in the rest
+			defaultRegion := target.Region
+			if p.Scope() == ScopeGlobal {
+				defaultRegion = GlobalRegion
+			}
+			for i := range resources {
+				r := &resources[i]
+				r.Type = p.config.Name
+				r.AccountID, r.AccountName = target.AccountID, target.AccountName
+				if r.Region == "" {
+					r.Region = defaultRegion
+				}
+			}
+			return resources, nil
+		})...)
+}
+
+// LoadPlugins registers a discoverer for each configured plugin
+func (d *Discovery) LoadPlugins(plugins []config.PluginConfig) error {
+	for _, pc := range plugins {
+		var discover PluginFunc
+		if pc.Path != "" {
+			var err error
+			if discover, err = openGoPlugin(pc.Path); err != nil {
+				return fmt.Errorf("loading plugin %q: %w", pc.Name, err)
+			}
+		} else {
+			discover = sidecarFunc(pc)
+		}
+		if err := d.RegisterDiscoverer(&pluginDiscoverer{d: d, config: pc, discover: discover}); err != nil {
+			return err
+		}
+		d.logger.Info("loaded discoverer plugin", "type", pc.Name)
+	}
+	return nil
+}
+
+// openGoPlugin loads a Go plugin built with -buildmode=plugin and returns its
+// Discover function
+func openGoPlugin(path string) (PluginFunc, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Discover")
+	if err != nil {
+		return nil, err
+	}
+	switch discover := sym.(type) {
+	case func(context.Context, Target) ([]types.PluginResource, error):
+		return discover, nil
+	case *PluginFunc:
+		return *discover, nil
+	}
+	return nil, fmt.Errorf("Discover has type %T, want aws.PluginFunc", sym)
+}
+
+// sidecarRequest is the body POSTed to a sidecar plugin for each account and
+// region it scans
+type sidecarRequest struct {
+	ResourceType string              `json:"resourceType"`
+	AccountID    string              `json:"accountId"`
+	AccountName  string              `json:"accountName"`
+	Region       string              `json:"region"`
+	Credentials  *sidecarCredentials `json:"credentials,omitempty"` // Only with sendCredentials
+}
+
+// sidecarCredentials are the account's temporary credentials
+type sidecarCredentials struct {
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+	Expires         string `json:"expires,omitempty"` // RFC 3339
+}
+
+// sidecarResponse is a sidecar plugin's reply
+type sidecarResponse struct {
+	Resources []types.PluginResource `json:"resources"`
+}
+
+// sidecarFunc returns a PluginFunc that asks the sidecar at pc.URL for each
+// target's resources
+func sidecarFunc(pc config.PluginConfig) PluginFunc {
+	timeout := time.Duration(pc.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = defaultPluginTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	return func(ctx context.Context, target Target) ([]types.PluginResource, error) {
+		req := sidecarRequest{
+			ResourceType: pc.Name,
+			AccountID:    target.AccountID,
+			AccountName:  target.AccountName,
+			Region:       target.Region,
+		}
+		if pc.SendCredentials {
+			if target.Config.Credentials == nil {
+				return nil, fmt.Errorf("no credentials to send to the sidecar")
+			}
+			creds, err := target.Config.Credentials.Retrieve(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("retrieving credentials: %w", err)
+			}
+			req.Credentials = &sidecarCredentials{
+				AccessKeyID:     creds.AccessKeyID,
+				SecretAccessKey: creds.SecretAccessKey,
+				SessionToken:    creds.SessionToken,
+			}
+			if creds.CanExpire {
+				req.Credentials.Expires = creds.Expires.UTC().Format(time.RFC3339)
+			}
+		}
+
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, pc.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return nil, fmt.Errorf("sidecar returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+		}
+		var out sidecarResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("decoding sidecar response: %w", err)
+		}
+		return out.Resources, nil
+	}
+}
@@ -0,0 +1,295 @@
+package aws
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// PolicyDocument is an IAM policy document
+type PolicyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// PolicyStatement is a single IAM policy statement
+type PolicyStatement struct {
+	Sid      string   `json:"Sid"`
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// IAMPolicies describes the permissions awsCOGS needs for a configuration
+type IAMPolicies struct {
+	// ServicePolicy is attached to the credentials awsCOGS runs with
+	ServicePolicy PolicyDocument `json:"servicePolicy"`
+	// ScanRolePolicy is attached to the role assumed in each scanned account.
+	// It is omitted when no roles are assumed.
+	ScanRolePolicy *PolicyDocument `json:"scanRolePolicy,omitempty"`
+	// ScanRoles lists the role ARNs (or ARN patterns) that need ScanRolePolicy
+	ScanRoles []string `json:"scanRoles,omitempty"`
+	// HubRolePolicy is attached to the hub role when roles are assumed through
+	// role chaining. It is omitted when no hub role is configured.
+	HubRolePolicy *PolicyDocument `json:"hubRolePolicy,omitempty"`
+	// HubRole is the ARN of the hub role that needs HubRolePolicy
+	HubRole string `json:"hubRole,omitempty"`
+}
+
+// resourceActions lists the read-only actions each resource type needs
+var resourceActions = map[string][]string{
+	"ec2":                 {"ec2:DescribeInstances", "ec2:DescribeInstanceCreditSpecifications", "ec2:DescribeReservedInstances", "cloudwatch:GetMetricData", "autoscaling:DescribeAutoScalingGroups"},
+	"ebs":                 {"ec2:DescribeVolumes"},
+	"ecs":                 {"ecs:ListClusters", "ecs:ListServices", "ecs:DescribeServices", "ecs:ListContainerInstances", "ecs:DescribeContainerInstances", "ecs:DescribeTaskDefinition"},
+	"rds":                 {"rds:DescribeDBInstances", "rds:DescribeDBClusters", "cloudwatch:GetMetricData"},
+	"eks":                 {"eks:ListClusters", "eks:DescribeCluster", "eks:DescribeClusterVersions"},
+	"elb":                 {"elasticloadbalancing:DescribeLoadBalancers", "elasticloadbalancing:DescribeTargetGroups", "elasticloadbalancing:DescribeTargetHealth", "elasticloadbalancing:DescribeInstanceHealth", "cloudwatch:GetMetricData"},
+	"nat":                 {"ec2:DescribeNatGateways"},
+	"eip":                 {"ec2:DescribeAddresses", "ec2:DescribeNetworkInterfaces", "ec2:DescribeInstances"},
+	"secrets":             {"secretsmanager:ListSecrets"},
+	"publicipv4":          {"ec2:DescribeInstances", "ec2:DescribeAddresses"},
+	"lambda":              {"lambda:ListFunctions", "cloudwatch:GetMetricData"},
+	"capacityreservation": {"ec2:DescribeCapacityReservations"},
+	"dedicatedhost":       {"ec2:DescribeHosts"},
+	"kinesis":             {"kinesis:ListStreams", "kinesis:DescribeStreamSummary"},
+	"firehose":            {"firehose:ListDeliveryStreams", "firehose:DescribeDeliveryStream", "cloudwatch:GetMetricData"},
+	"sqs":                 {"sqs:ListQueues", "sqs:GetQueueAttributes", "cloudwatch:GetMetricData"},
+	"sns":                 {"sns:ListTopics", "sns:GetTopicAttributes", "cloudwatch:GetMetricData"},
+	"eventbridge":         {"events:ListEventBuses", "events:ListRules", "cloudwatch:GetMetricData"},
+	"alarm":               {"cloudwatch:DescribeAlarms"},
+	"dashboard":           {"cloudwatch:ListDashboards"},
+	"custommetrics":       {"cloudwatch:ListMetrics"},
+	"datatransfer":        {"ec2:DescribeInstances", "ec2:DescribeNatGateways", "cloudwatch:GetMetricData"},
+}
+
+// RequiredPolicies returns the minimal IAM policies needed to scan the given
+// resource types (all enabled services if empty) with cfg.
+func RequiredPolicies(cfg *config.Config, resourceTypes []string) IAMPolicies {
+	if len(resourceTypes) == 0 {
+		resourceTypes = cfg.AWS.EnabledServices()
+	}
+
+	// Actions needed in every scanned account
+	scan := []string{"iam:ListAccountAliases"}
+	for _, rt := range resourceTypes {
+		scan = append(scan, resourceActions[rt]...)
+	}
+	for _, p := range cfg.AWS.Plugins {
+		if slices.Contains(resourceTypes, p.Name) {
+			scan = append(scan, p.IAMActions...)
+		}
+	}
+	if cfg.AWS.CloudTrail.LookupOwners {
+		scan = append(scan, "cloudtrail:LookupEvents")
+	}
+	if cfg.AWS.ProbeExcludedRegions {
+		scan = append(scan, "ec2:DescribeInstances", "ec2:DescribeVolumes", "ec2:DescribeNatGateways")
+	}
+	govCloudRegions := cfg.AWS.GovCloud.Enabled && cfg.AWS.GovCloud.DiscoverRegions && len(cfg.AWS.GovCloud.Regions) == 0
+	if govCloudRegions {
+		// GovCloud regions are discovered with the first GovCloud account's credentials
+		scan = append(scan, "ec2:DescribeRegions")
+	}
+	scan = sortedUnique(scan)
+
+	var statements []PolicyStatement
+	if scansWithServiceCredentials(cfg) {
+		statements = append(statements, allowAll("ReadResources", scan))
+	}
+	statements = append(statements, allowAll("ReadPricing", []string{"pricing:DescribeServices", "pricing:GetProducts"}))
+	if (cfg.AWS.DiscoverRegions && len(cfg.AWS.Regions) == 0) || cfg.AWS.ProbeExcludedRegions {
+		statements = append(statements, allowAll("DiscoverRegions", []string{"ec2:DescribeRegions"}))
+	}
+	if cfg.AWS.DiscoverAccounts || (cfg.AWS.GovCloud.Enabled && cfg.AWS.GovCloud.DiscoverAccounts) {
+		statements = append(statements, allowAll("DiscoverAccounts", []string{"organizations:ListAccounts"}))
+	}
+
+	roles := assumedRoles(cfg)
+	direct, chained := splitChainedRoles(roles, cfg.AWS.STS.HubRoleARN)
+	if len(chained) > 0 {
+		direct = sortedUnique(append(direct, cfg.AWS.STS.HubRoleARN))
+	}
+	if len(direct) > 0 {
+		statements = append(statements, PolicyStatement{
+			Sid:      "AssumeScanRoles",
+			Effect:   "Allow",
+			Action:   []string{"sts:AssumeRole"},
+			Resource: direct,
+		})
+	}
+
+	var topics []string
+	for _, sink := range cfg.Notify.Sinks {
+		if sink.Type == "sns" && sink.TopicARN != "" {
+			topics = append(topics, sink.TopicARN)
+		}
+	}
+	if len(topics) > 0 {
+		statements = append(statements, PolicyStatement{
+			Sid:      "PublishNotifications",
+			Effect:   "Allow",
+			Action:   []string{"sns:Publish"},
+			Resource: sortedUnique(topics),
+		})
+	}
+
+	if topic := cfg.Export.Publish.SNSTopicARN; topic != "" {
+		statements = append(statements, PolicyStatement{
+			Sid:      "PublishSnapshots",
+			Effect:   "Allow",
+			Action:   []string{"sns:Publish"},
+			Resource: []string{topic},
+		})
+	}
+	if queue := sqsQueueARN(cfg.Export.Publish.SQSQueueURL); queue != "" {
+		statements = append(statements, PolicyStatement{
+			Sid:      "SendSnapshots",
+			Effect:   "Allow",
+			Action:   []string{"sqs:SendMessage"},
+			Resource: []string{queue},
+		})
+	}
+
+	if archive := cfg.Export.S3; archive.Bucket != "" {
+		statements = append(statements, PolicyStatement{
+			Sid:      "ArchiveSnapshots",
+			Effect:   "Allow",
+			Action:   []string{"s3:PutObject"},
+			Resource: []string{"arn:" + PartitionForRegion(archive.Region) + ":s3:::" + path.Join(archive.Bucket, archive.Prefix, "*")},
+		})
+	}
+
+	policies := IAMPolicies{
+		ServicePolicy: PolicyDocument{Version: "2012-10-17", Statement: statements},
+	}
+	if len(roles) > 0 {
+		policies.ScanRolePolicy = &PolicyDocument{
+			Version:   "2012-10-17",
+			Statement: []PolicyStatement{allowAll("ReadResources", scan)},
+		}
+		policies.ScanRoles = roles
+	}
+	if len(chained) > 0 {
+		policies.HubRolePolicy = &PolicyDocument{
+			Version: "2012-10-17",
+			Statement: []PolicyStatement{{
+				Sid:      "AssumeScanRoles",
+				Effect:   "Allow",
+				Action:   []string{"sts:AssumeRole"},
+				Resource: chained,
+			}},
+		}
+		policies.HubRole = cfg.AWS.STS.HubRoleARN
+	}
+	return policies
+}
+
+// scansWithServiceCredentials reports whether any account is scanned without
+// assuming a role. The Organizations management account is always scanned
+// with the service credentials.
+func scansWithServiceCredentials(cfg *config.Config) bool {
+	if cfg.AWS.DiscoverAccounts {
+		return true
+	}
+	for _, acc := range cfg.AWS.Accounts {
+		if acc.RoleARN == "" {
+			return true
+		}
+	}
+
+	gov := cfg.AWS.GovCloud
+	if !gov.Enabled {
+		return false
+	}
+	if gov.DiscoverAccounts || len(gov.Accounts) == 0 {
+		return true
+	}
+	for _, acc := range gov.Accounts {
+		if acc.RoleARN == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// assumedRoles returns the role ARNs (or ARN patterns) awsCOGS assumes
+func assumedRoles(cfg *config.Config) []string {
+	var roles []string
+	if cfg.AWS.DiscoverAccounts && cfg.AWS.AssumeRoleName != "" {
+		roles = append(roles, fmt.Sprintf("%s:iam::*:role/%s", arnPrefix("aws"), cfg.AWS.AssumeRoleName))
+	}
+	if !cfg.AWS.DiscoverAccounts {
+		for _, acc := range cfg.AWS.Accounts {
+			if acc.RoleARN != "" {
+				roles = append(roles, acc.RoleARN)
+			}
+		}
+	}
+
+	gov := cfg.AWS.GovCloud
+	if gov.Enabled {
+		if gov.DiscoverAccounts && gov.AssumeRoleName != "" {
+			roles = append(roles, fmt.Sprintf("%s:iam::*:role/%s", arnPrefix("aws-us-gov"), gov.AssumeRoleName))
+		}
+		if !gov.DiscoverAccounts {
+			for _, acc := range gov.Accounts {
+				if acc.RoleARN != "" {
+					roles = append(roles, acc.RoleARN)
+				}
+			}
+		}
+	}
+	return sortedUnique(roles)
+}
+
+// splitChainedRoles separates roles assumed directly from roles assumed
+// through hub. Only roles in the hub role's partition are chained.
+func splitChainedRoles(roles []string, hub string) (direct, chained []string) {
+	for _, role := range roles {
+		if hub != "" && role != hub && partitionFromARN(role) == partitionFromARN(hub) {
+			chained = append(chained, role)
+		} else {
+			direct = append(direct, role)
+		}
+	}
+	return direct, chained
+}
+
+func allowAll(sid string, actions []string) PolicyStatement {
+	return PolicyStatement{
+		Sid:      sid,
+		Effect:   "Allow",
+		Action:   actions,
+		Resource: []string{"*"},
+	}
+}
+
+func sortedUnique(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
+
+// sqsQueueARN converts a queue URL such as
+// https://sqs.us-east-1.amazonaws.com/123456789012/awscogs to its ARN
+func sqsQueueARN(queueURL string) string {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.Split(u.Host, ".")
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(host) < 2 || len(path) != 2 {
+		return ""
+	}
+	region := host[1]
+	partition := "aws"
+	if strings.HasPrefix(region, "us-gov-") {
+		partition = "aws-us-gov"
+	}
+	return fmt.Sprintf("arn:%s:sqs:%s:%s:%s", partition, region, path[0], path[1])
+}
@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func findStatement(doc PolicyDocument, sid string) *PolicyStatement {
+	for i := range doc.Statement {
+		if doc.Statement[i].Sid == sid {
+			return &doc.Statement[i]
+		}
+	}
+	return nil
+}
+
+func TestRequiredPoliciesForOrganizationDiscovery(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Notify.Sinks = []config.SinkConfig{{Type: "sns", TopicARN: "arn:aws:sns:us-east-1:123456789012:awscogs"}}
+	cfg.Export.Publish.SQSQueueURL = "https://sqs.us-west-2.amazonaws.com/123456789012/awscogs-snapshots"
+	cfg.Export.S3.Bucket = "cost-history"
+
+	policies := RequiredPolicies(cfg, []string{"ec2", "lambda"})
+
+	for _, sid := range []string{"ReadResources", "ReadPricing", "DiscoverRegions", "DiscoverAccounts", "AssumeScanRoles", "PublishNotifications", "SendSnapshots"} {
+		if findStatement(policies.ServicePolicy, sid) == nil {
+			t.Fatalf("service policy missing %s statement", sid)
+		}
+	}
+	if send := findStatement(policies.ServicePolicy, "SendSnapshots"); send.Resource[0] != "arn:aws:sqs:us-west-2:123456789012:awscogs-snapshots" {
+		t.Fatalf("send snapshot resources = %v", send.Resource)
+	}
+	if archive := findStatement(policies.ServicePolicy, "ArchiveSnapshots"); archive == nil || archive.Resource[0] != "arn:aws:s3:::cost-history/awscogs/*" {
+		t.Fatalf("archive statement = %+v", archive)
+	}
+	cfg.Export.S3.Region = "us-gov-west-1"
+	if archive := findStatement(RequiredPolicies(cfg, nil).ServicePolicy, "ArchiveSnapshots"); archive.Resource[0] != "arn:aws-us-gov:s3:::cost-history/awscogs/*" {
+		t.Fatalf("GovCloud archive resources = %v", archive.Resource)
+	}
+	assume := findStatement(policies.ServicePolicy, "AssumeScanRoles")
+	if len(assume.Resource) != 1 || assume.Resource[0] != "arn:aws:iam::*:role/OrganizationAccountAccessRole" {
+		t.Fatalf("assume role resources = %v", assume.Resource)
+	}
+
+	if policies.ScanRolePolicy == nil {
+		t.Fatal("expected a scan role policy")
+	}
+	read := findStatement(*policies.ScanRolePolicy, "ReadResources")
+	want := []string{"autoscaling:DescribeAutoScalingGroups", "cloudwatch:GetMetricData", "ec2:DescribeInstanceCreditSpecifications", "ec2:DescribeInstances", "ec2:DescribeReservedInstances", "iam:ListAccountAliases", "lambda:ListFunctions"}
+	if !slices.Equal(read.Action, want) {
+		t.Fatalf("scan actions = %v, want %v", read.Action, want)
+	}
+}
+
+func TestRequiredPoliciesForOwnerLookup(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AWS.CloudTrail.LookupOwners = true
+
+	read := findStatement(*RequiredPolicies(cfg, []string{"ebs"}).ScanRolePolicy, "ReadResources")
+	if !slices.Contains(read.Action, "cloudtrail:LookupEvents") {
+		t.Fatalf("scan actions = %v, want cloudtrail:LookupEvents", read.Action)
+	}
+}
+
+func TestRequiredPoliciesForExcludedRegionProbe(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AWS.Regions = []string{"us-east-1"}
+	cfg.AWS.ProbeExcludedRegions = true
+
+	policies := RequiredPolicies(cfg, []string{"lambda"})
+	read := findStatement(*policies.ScanRolePolicy, "ReadResources")
+	if !slices.Contains(read.Action, "ec2:DescribeVolumes") || !slices.Contains(read.Action, "ec2:DescribeNatGateways") {
+		t.Fatalf("scan actions = %v, want the probe's EC2 actions", read.Action)
+	}
+	if findStatement(policies.ServicePolicy, "DiscoverRegions") == nil {
+		t.Fatal("expected the service policy to allow listing enabled regions")
+	}
+}
+
+func TestRequiredPoliciesForPlugins(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AWS.Plugins = []config.PluginConfig{{Name: "mwaa", URL: "http://localhost:9000", IAMActions: []string{"airflow:ListEnvironments", "airflow:GetEnvironment"}}}
+
+	read := findStatement(*RequiredPolicies(cfg, nil).ScanRolePolicy, "ReadResources")
+	if !slices.Contains(read.Action, "airflow:ListEnvironments") || !slices.Contains(read.Action, "airflow:GetEnvironment") {
+		t.Fatalf("scan actions = %v, want the plugin's actions", read.Action)
+	}
+
+	read = findStatement(*RequiredPolicies(cfg, []string{"ebs"}).ScanRolePolicy, "ReadResources")
+	if slices.Contains(read.Action, "airflow:ListEnvironments") {
+		t.Fatalf("scan actions = %v, want none of the unrequested plugin's", read.Action)
+	}
+}
+
+func TestRequiredPoliciesForExplicitRoles(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AWS.DiscoverAccounts = false
+	cfg.AWS.DiscoverRegions = false
+	cfg.AWS.Regions = []string{"us-east-1"}
+	cfg.AWS.Accounts = []config.AccountConfig{{Name: "prod", RoleARN: "arn:aws:iam::123456789012:role/Audit"}}
+
+	policies := RequiredPolicies(cfg, nil)
+
+	for _, sid := range []string{"ReadResources", "DiscoverRegions", "DiscoverAccounts"} {
+		if findStatement(policies.ServicePolicy, sid) != nil {
+			t.Fatalf("service policy should not include %s", sid)
+		}
+	}
+	if !slices.Equal(policies.ScanRoles, []string{"arn:aws:iam::123456789012:role/Audit"}) {
+		t.Fatalf("scan roles = %v", policies.ScanRoles)
+	}
+}
+
+func TestRequiredPoliciesForHubRole(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AWS.DiscoverAccounts = false
+	cfg.AWS.Accounts = []config.AccountConfig{{Name: "prod", RoleARN: "arn:aws:iam::123456789012:role/Audit"}}
+	cfg.AWS.STS.HubRoleARN = "arn:aws:iam::999999999999:role/Hub"
+
+	policies := RequiredPolicies(cfg, nil)
+
+	assume := findStatement(policies.ServicePolicy, "AssumeScanRoles")
+	if assume == nil || !slices.Equal(assume.Resource, []string{"arn:aws:iam::999999999999:role/Hub"}) {
+		t.Fatalf("service policy should only assume the hub role, got %+v", assume)
+	}
+	if policies.HubRolePolicy == nil || policies.HubRole != cfg.AWS.STS.HubRoleARN {
+		t.Fatal("expected a hub role policy")
+	}
+	chained := findStatement(*policies.HubRolePolicy, "AssumeScanRoles")
+	if !slices.Equal(chained.Resource, []string{"arn:aws:iam::123456789012:role/Audit"}) {
+		t.Fatalf("hub role resources = %v", chained.Resource)
+	}
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// tokenBucket allows burst calls at once and refills at rate tokens per second
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// reserve takes a token at now and returns how long the caller must wait
+// before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// unreserve returns a token taken by reserve that was never used
+func (b *tokenBucket) unreserve() {
+	b.mu.Lock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+	b.mu.Unlock()
+}
+
+// wait blocks until a token is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve(time.Now())
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.unreserve()
+		return ctx.Err()
+	}
+}
+
+// apiRateLimiter keeps one token bucket per account and region, shared by
+// every AWS service client created for it
+type apiRateLimiter struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// newAPIRateLimiter returns a limiter allowing rate calls per second, or nil
+// if rate is 0. A burst of 0 defaults to the rate, with a minimum of 1.
+func newAPIRateLimiter(rate float64, burst int) *apiRateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = max(1, int(math.Ceil(rate)))
+	}
+	return &apiRateLimiter{rate: rate, burst: burst, buckets: make(map[string]*tokenBucket)}
+}
+
+func (l *apiRateLimiter) bucket(key string) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newTokenBucket(l.rate, l.burst)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// apply makes every client created from cfg take a token from key's bucket
+// before each API call. Retries of a call don't take another token.
+func (l *apiRateLimiter) apply(cfg *aws.Config, key string) {
+	if l == nil {
+		return
+	}
+	b := l.bucket(key)
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AWSCOGSRateLimit",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				if err := b.wait(ctx); err != nil {
+					return middleware.InitializeOutput{}, middleware.Metadata{}, err
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.Before)
+	})
+}
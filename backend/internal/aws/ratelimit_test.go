@@ -0,0 +1,41 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	b := newTokenBucket(2, 2)
+	start := time.Now()
+
+	for i := range 2 {
+		if delay := b.reserve(start); delay != 0 {
+			t.Fatalf("call %d within burst waited %v", i, delay)
+		}
+	}
+	if delay := b.reserve(start); delay != 500*time.Millisecond {
+		t.Fatalf("expected 500ms wait after burst, got %v", delay)
+	}
+
+	// One second later the debt is repaid and one more token has refilled
+	if delay := b.reserve(start.Add(time.Second)); delay != 0 {
+		t.Fatalf("expected refilled token, got wait %v", delay)
+	}
+}
+
+func TestNewAPIRateLimiter(t *testing.T) {
+	if l := newAPIRateLimiter(0, 10); l != nil {
+		t.Fatalf("expected no limiter for rate 0")
+	}
+	if l := newAPIRateLimiter(2.5, 0); l.burst != 3 {
+		t.Fatalf("expected burst to default to the rate, got %d", l.burst)
+	}
+	l := newAPIRateLimiter(5, 1)
+	if l.bucket("a|us-east-1") != l.bucket("a|us-east-1") {
+		t.Fatalf("expected one bucket per key")
+	}
+	if l.bucket("a|us-east-1") == l.bucket("a|us-west-2") {
+		t.Fatalf("expected separate buckets per region")
+	}
+}
@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// ProbeRegions counts the running EC2 instances, EBS volumes, and available
+// NAT gateways in each account and region, without pricing anything. It's
+// meant for regions scans leave out, to find spend they would hide.
+func (d *Discovery) ProbeRegions(ctx context.Context, accounts []Account, regions []string) *types.RegionActivityResponse {
+	if len(accounts) == 0 {
+		accounts = defaultAccountsForRegions(regions)
+	}
+
+	var (
+		results []types.RegionActivity
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	for _, account := range accounts {
+		for _, region := range regions {
+			if account.AccountPartition() != PartitionForRegion(region) {
+				continue
+			}
+
+			wg.Add(1)
+			go func(acc Account, reg string) {
+				defer wg.Done()
+				result := d.probeRegion(ctx, acc, reg)
+
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}(account, region)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.AccountName != b.AccountName {
+			return a.AccountName < b.AccountName
+		}
+		return a.Region < b.Region
+	})
+
+	response := &types.RegionActivityResponse{
+		Status:          types.ResponseStatusOK,
+		ExcludedRegions: regions,
+		Regions:         results,
+	}
+	for _, r := range results {
+		if r.Active {
+			response.Active++
+		}
+	}
+	if response.Active > 0 {
+		response.Status = types.ResponseStatusPartial
+	}
+	return response
+}
+
+// probeRegion counts the chargeable resources in one account and region
+func (d *Discovery) probeRegion(ctx context.Context, acc Account, region string) types.RegionActivity {
+	result := types.RegionActivity{AccountID: acc.ID, AccountName: acc.Name, Region: region}
+
+	cfg, err := d.getConfigForAccount(ctx, acc, region)
+	if err == nil && result.AccountID == "" {
+		result.AccountID, err = d.getAccountID(ctx, cfg)
+	}
+	if err == nil {
+		result.RunningInstances, result.Volumes, result.NATGateways, err = countChargeable(ctx, ec2.NewFromConfig(cfg))
+	}
+	if err != nil {
+		d.logger.Debug("region activity probe failed", "account", acc.Name, "region", region, "error", err)
+		result.Error = err.Error()
+	}
+	if result.AccountName == "" {
+		result.AccountName = result.AccountID
+	}
+	result.Active = result.RunningInstances > 0 || result.Volumes > 0 || result.NATGateways > 0
+	return result
+}
+
+// countChargeable counts running instances, volumes, and available NAT
+// gateways with the fewest calls the pages allow
+func countChargeable(ctx context.Context, client *ec2.Client) (instances, volumes, natGateways int, err error) {
+	running := ec2types.Filter{Name: aws.String("instance-state-name"), Values: []string{"running"}}
+	instancePages := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{Filters: []ec2types.Filter{running}, MaxResults: aws.Int32(1000)})
+	for instancePages.HasMorePages() {
+		page, err := instancePages.NextPage(ctx)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("describing instances: %w", err)
+		}
+		for _, r := range page.Reservations {
+			instances += len(r.Instances)
+		}
+	}
+
+	volumePages := ec2.NewDescribeVolumesPaginator(client, &ec2.DescribeVolumesInput{MaxResults: aws.Int32(1000)})
+	for volumePages.HasMorePages() {
+		page, err := volumePages.NextPage(ctx)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("describing volumes: %w", err)
+		}
+		volumes += len(page.Volumes)
+	}
+
+	available := ec2types.Filter{Name: aws.String("state"), Values: []string{"available"}}
+	natPages := ec2.NewDescribeNatGatewaysPaginator(client, &ec2.DescribeNatGatewaysInput{Filter: []ec2types.Filter{available}, MaxResults: aws.Int32(1000)})
+	for natPages.HasMorePages() {
+		page, err := natPages.NextPage(ctx)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("describing NAT gateways: %w", err)
+		}
+		natGateways += len(page.NatGateways)
+	}
+	return instances, volumes, natGateways, nil
+}
@@ -0,0 +1,75 @@
+package aws
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/awsmock"
+)
+
+func TestDiscoverAccountsAndRegionsReplay(t *testing.T) {
+	mock := awsmock.New(t, "testdata/awsmock/accounts_and_regions.json")
+	d := NewDiscoveryWithConfigFactory(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60, mock.Config)
+
+	regions, err := d.DiscoverRegions(t.Context())
+	if err != nil {
+		t.Fatalf("DiscoverRegions() error = %v", err)
+	}
+	if !slices.Equal(regions, []string{"us-east-1", "us-west-2"}) {
+		t.Fatalf("regions = %v", regions)
+	}
+
+	accounts, err := d.DiscoverAccounts(t.Context(), "AWSCOGSRole")
+	if err != nil {
+		t.Fatalf("DiscoverAccounts() error = %v", err)
+	}
+	want := []Account{
+		{ID: "111111111111", Name: "management", Partition: "aws"},
+		{ID: "222222222222", Name: "prod", Partition: "aws", RoleARN: "arn:aws:iam::222222222222:role/AWSCOGSRole"},
+	}
+	if len(accounts) != len(want) {
+		t.Fatalf("accounts = %+v", accounts)
+	}
+	for i := range want {
+		if accounts[i].ID != want[i].ID || accounts[i].Name != want[i].Name || accounts[i].RoleARN != want[i].RoleARN {
+			t.Fatalf("account %d = %+v, want %+v", i, accounts[i], want[i])
+		}
+	}
+
+	// Both are cached, so asking again replays nothing
+	if _, err := d.DiscoverRegions(t.Context()); err != nil {
+		t.Fatalf("cached DiscoverRegions() error = %v", err)
+	}
+}
+
+func TestEndpointConfigFactory(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	var action string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		action = form.Get("Action")
+		io.WriteString(w, `<DescribeRegionsResponse><regionInfo><item><regionName>us-east-1</regionName></item></regionInfo></DescribeRegionsResponse>`)
+	}))
+	defer srv.Close()
+
+	// Discovery calls go to the endpoint, as they would to LocalStack
+	d := NewDiscoveryWithConfigFactory(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), 5, 60, EndpointConfigFactory(srv.URL))
+	regions, err := d.DiscoverRegions(t.Context())
+	if err != nil {
+		t.Fatalf("DiscoverRegions() error = %v", err)
+	}
+	if action != "DescribeRegions" || !slices.Equal(regions, []string{"us-east-1"}) {
+		t.Fatalf("endpoint got %q, regions = %v", action, regions)
+	}
+}
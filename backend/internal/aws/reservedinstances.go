@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// DiscoverReservedInstances returns the active EC2 Reserved Instances in each
+// account and region, and diagnostics for the ones that couldn't be listed.
+// Results are cached like resources, as the "reservedinstance" type.
+func (d *Discovery) DiscoverReservedInstances(ctx context.Context, accounts []Account, regions []string) ([]types.ReservedInstance, []types.Diagnostic) {
+	diagnostics := newDiagnosticCollector()
+	ctx = contextWithDiagnostics(ctx, diagnostics)
+	ctx = contextWithDiscoveryRun(ctx)
+	if len(accounts) == 0 {
+		accounts = defaultAccountsForRegions(regions)
+	}
+
+	var (
+		all []types.ReservedInstance
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+	)
+	for _, account := range accounts {
+		for _, region := range regions {
+			if account.AccountPartition() != PartitionForRegion(region) {
+				continue
+			}
+
+			wg.Add(1)
+			go func(acc Account, reg string) {
+				defer wg.Done()
+
+				cfg, err := d.getConfigForAccount(ctx, acc, reg)
+				if err != nil {
+					recordDiagnostic(ctx, newDiagnostic("error", "account", acc.ID, acc.Name, reg, "getConfig", "", err))
+					return
+				}
+				identity, err := d.resolveAccountIdentity(ctx, acc, cfg)
+				if err != nil {
+					recordDiagnostic(ctx, newDiagnostic("warning", "account", "", acc.Name, reg, "getAccountID", "", err))
+				}
+
+				reservations := getOrDiscoverResource(d, ctx, cfg, identity.id, identity.name, reg, "reservedinstance", d.discoverReservedInstances)
+				mu.Lock()
+				all = append(all, reservations...)
+				mu.Unlock()
+			}(account, region)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.InstanceType != b.InstanceType {
+			return a.InstanceType < b.InstanceType
+		}
+		return a.ID < b.ID
+	})
+	return all, diagnostics.snapshot()
+}
+
+// discoverReservedInstances lists the active Reserved Instances bought in one
+// account and region
+func (d *Discovery) discoverReservedInstances(ctx context.Context, cfg aws.Config, accountID, accountName, region string) ([]types.ReservedInstance, error) {
+	client := ec2.NewFromConfig(cfg)
+
+	// DescribeReservedInstances isn't paginated
+	output, err := client.DescribeReservedInstances(ctx, &ec2.DescribeReservedInstancesInput{
+		Filters: []ec2types.Filter{{Name: aws.String("state"), Values: []string{"active"}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing reserved instances: %w", err)
+	}
+
+	reservations := make([]types.ReservedInstance, 0, len(output.ReservedInstances))
+	for _, ri := range output.ReservedInstances {
+		var hourly float64
+		for _, charge := range ri.RecurringCharges {
+			if charge.Frequency == ec2types.RecurringChargeFrequencyHourly {
+				hourly += aws.ToFloat64(charge.Amount)
+			}
+		}
+		reservation := types.ReservedInstance{
+			AccountID:        accountID,
+			AccountName:      accountName,
+			Region:           region,
+			ID:               aws.ToString(ri.ReservedInstancesId),
+			InstanceType:     string(ri.InstanceType),
+			InstanceCount:    aws.ToInt32(ri.InstanceCount),
+			Platform:         string(ri.ProductDescription),
+			Tenancy:          string(ri.InstanceTenancy),
+			Scope:            string(ri.Scope),
+			AvailabilityZone: aws.ToString(ri.AvailabilityZone),
+			OfferingClass:    string(ri.OfferingClass),
+			OfferingType:     string(ri.OfferingType),
+			RecurringHourly:  hourly,
+		}
+		if ri.Start != nil {
+			reservation.Start = ri.Start.UTC().Format(time.RFC3339)
+		}
+		if ri.End != nil {
+			reservation.End = ri.End.UTC().Format(time.RFC3339)
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, nil
+}
@@ -0,0 +1,180 @@
+package aws
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// ScopeResolver turns account and region filters into the accounts and
+// regions to scan, using discovery or the configured lists.
+type ScopeResolver struct {
+	config    *config.Config
+	discovery *Discovery
+	logger    *slog.Logger
+}
+
+// NewScopeResolver creates a new scope resolver
+func NewScopeResolver(cfg *config.Config, discovery *Discovery, logger *slog.Logger) *ScopeResolver {
+	return &ScopeResolver{
+		config:    cfg,
+		discovery: discovery,
+		logger:    logger,
+	}
+}
+
+// Regions returns regions to query - either from filter, discovery, or config
+func (s *ScopeResolver) Regions(ctx context.Context, filter []string) ([]string, error) {
+	// If filter specified, use that
+	if len(filter) > 0 {
+		return filter, nil
+	}
+
+	var regions []string
+
+	// Commercial regions
+	if s.config.AWS.DiscoverRegions {
+		discovered, err := s.discovery.DiscoverRegions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, discovered...)
+	} else if len(s.config.AWS.Regions) > 0 {
+		regions = append(regions, s.config.AWS.Regions...)
+	}
+
+	// GovCloud regions
+	if s.config.AWS.GovCloud.Enabled {
+		govRegions, err := s.govCloudRegions(ctx)
+		if err != nil {
+			if len(regions) == 0 {
+				return nil, err
+			}
+			// Log but don't fail if commercial regions can still work.
+			s.logger.Error("failed to get govcloud regions", "error", err)
+		} else {
+			regions = append(regions, govRegions...)
+		}
+	}
+
+	if len(regions) == 0 {
+		if s.config.AWS.GovCloud.Enabled {
+			return []string{"us-gov-west-1"}, nil
+		}
+		return []string{"us-east-1"}, nil
+	}
+
+	return regions, nil
+}
+
+// ExcludedRegions returns the enabled commercial regions a full scan leaves
+// out, because scanned regions are configured rather than discovered
+func (s *ScopeResolver) ExcludedRegions(ctx context.Context) ([]string, error) {
+	if s.config.AWS.DiscoverRegions {
+		return nil, nil
+	}
+	scanned, err := s.Regions(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.discovery.DiscoverRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var excluded []string
+	for _, region := range enabled {
+		if !slices.Contains(scanned, region) {
+			excluded = append(excluded, region)
+		}
+	}
+	slices.Sort(excluded)
+	return excluded, nil
+}
+
+// govCloudRegions returns GovCloud regions from config or discovery
+func (s *ScopeResolver) govCloudRegions(ctx context.Context) ([]string, error) {
+	if s.config.AWS.GovCloud.DiscoverRegions {
+		account := Account{Partition: "aws-us-gov"}
+		if len(s.config.AWS.GovCloud.Accounts) > 0 {
+			// Use first configured GovCloud account's credentials to discover regions.
+			account.Name = s.config.AWS.GovCloud.Accounts[0].Name
+			account.RoleARN = s.config.AWS.GovCloud.Accounts[0].RoleARN
+		}
+		regions, err := s.discovery.DiscoverGovCloudRegions(ctx, account)
+		if err == nil || len(s.config.AWS.GovCloud.Regions) == 0 {
+			return regions, err
+		}
+		s.logger.Warn("falling back to configured govcloud regions after discovery failed", "error", err)
+	}
+
+	if len(s.config.AWS.GovCloud.Regions) > 0 {
+		return s.config.AWS.GovCloud.Regions, nil
+	}
+
+	return []string{"us-gov-west-1"}, nil
+}
+
+// Accounts returns accounts to query - either from filter, discovery, or config
+func (s *ScopeResolver) Accounts(ctx context.Context, filter []string) ([]Account, error) {
+	var accounts []Account
+
+	// Commercial accounts
+	if s.config.AWS.DiscoverAccounts {
+		discovered, err := s.discovery.DiscoverAccounts(ctx, s.config.AWS.AssumeRoleName)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, discovered...)
+	} else if len(s.config.AWS.Accounts) > 0 {
+		for _, acc := range s.config.AWS.Accounts {
+			accounts = append(accounts, Account{
+				Name:    acc.Name,
+				RoleARN: acc.RoleARN,
+			})
+		}
+	}
+
+	// GovCloud accounts
+	if s.config.AWS.GovCloud.Enabled {
+		if s.config.AWS.GovCloud.DiscoverAccounts {
+			discovered, err := s.discovery.DiscoverGovCloudAccounts(ctx, s.config.AWS.GovCloud.AssumeRoleName)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, discovered...)
+		} else if len(s.config.AWS.GovCloud.Accounts) > 0 {
+			for _, acc := range s.config.AWS.GovCloud.Accounts {
+				accounts = append(accounts, Account{
+					Name:      acc.Name,
+					RoleARN:   acc.RoleARN,
+					Partition: "aws-us-gov",
+				})
+			}
+		} else {
+			accounts = append(accounts, Account{
+				Partition: "aws-us-gov",
+			})
+		}
+	}
+
+	// If filter specified, filter all accounts
+	if len(filter) > 0 {
+		filterSet := make(map[string]bool)
+		for _, name := range filter {
+			filterSet[name] = true
+		}
+
+		var filtered []Account
+		for _, acc := range accounts {
+			if filterSet[acc.Name] || filterSet[acc.ID] {
+				filtered = append(filtered, acc)
+			}
+		}
+		return filtered, nil
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,36 @@
+package aws
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestExcludedRegions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	d := NewDiscovery(nil, logger, 5, 60)
+	d.regionCache = &cacheEntry[[]string]{
+		value:     []string{"us-east-1", "us-west-2", "eu-west-1", "ap-south-1"},
+		expiresAt: time.Now().Add(time.Hour),
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.AWS.DiscoverRegions = false
+	cfg.AWS.Regions = []string{"us-east-1", "eu-west-1"}
+	excluded, err := NewScopeResolver(cfg, d, logger).ExcludedRegions(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(excluded, []string{"ap-south-1", "us-west-2"}) {
+		t.Fatalf("excluded = %v", excluded)
+	}
+
+	cfg.AWS.DiscoverRegions = true
+	if excluded, _ := NewScopeResolver(cfg, d, logger).ExcludedRegions(t.Context()); len(excluded) != 0 {
+		t.Fatalf("expected no excluded regions when regions are discovered, got %v", excluded)
+	}
+}
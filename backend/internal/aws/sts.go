@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// stsGlobalRegion makes the STS client use the global sts.amazonaws.com endpoint
+const stsGlobalRegion = "aws-global"
+
+// roleAssumer assumes account roles through STS, optionally chaining through
+// a hub role
+type roleAssumer struct {
+	config config.STSConfig
+
+	// Hub role credentials, keyed by STS region, shared by every account so
+	// the hub role is assumed once per credential lifetime
+	hubCreds   map[string]aws.CredentialsProvider
+	hubCredsMu sync.Mutex
+}
+
+// SetSTSConfig sets the STS endpoint and role chaining settings used to
+// assume roles into accounts
+func (d *Discovery) SetSTSConfig(cfg config.STSConfig) {
+	d.roles = &roleAssumer{config: cfg, hubCreds: make(map[string]aws.CredentialsProvider)}
+}
+
+// stsRegion returns the region whose STS endpoint assumes roles in partition
+// while scanning region. Settings for another partition are ignored.
+func (r *roleAssumer) stsRegion(partition, region string) string {
+	if r == nil {
+		return region
+	}
+	if r.config.GlobalEndpoint && partition == "aws" {
+		return stsGlobalRegion
+	}
+	if r.config.Region != "" && PartitionForRegion(r.config.Region) == partition {
+		return r.config.Region
+	}
+	return region
+}
+
+// hubRoleFor returns the hub role to chain through for account, or "" if
+// the account's role is assumed directly
+func (r *roleAssumer) hubRoleFor(account Account) string {
+	if r == nil || r.config.HubRoleARN == "" || r.config.HubRoleARN == account.RoleARN {
+		return ""
+	}
+	if partitionFromARN(r.config.HubRoleARN) != account.AccountPartition() {
+		return ""
+	}
+	return r.config.HubRoleARN
+}
+
+// credentials returns credentials for account's role, starting from the
+// credentials in cfg
+func (r *roleAssumer) credentials(cfg aws.Config, account Account, region string) aws.CredentialsProvider {
+	stsRegion := r.stsRegion(account.AccountPartition(), region)
+	if hub := r.hubRoleFor(account); hub != "" {
+		cfg.Credentials = r.hubCredentials(cfg, hub, stsRegion)
+	}
+	client := sts.NewFromConfig(cfg, func(o *sts.Options) { o.Region = stsRegion })
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(client, account.RoleARN))
+}
+
+func (r *roleAssumer) hubCredentials(cfg aws.Config, hub, stsRegion string) aws.CredentialsProvider {
+	r.hubCredsMu.Lock()
+	defer r.hubCredsMu.Unlock()
+
+	if creds, ok := r.hubCreds[stsRegion]; ok {
+		return creds
+	}
+	client := sts.NewFromConfig(cfg, func(o *sts.Options) { o.Region = stsRegion })
+	creds := aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(client, hub))
+	r.hubCreds[stsRegion] = creds
+	return creds
+}
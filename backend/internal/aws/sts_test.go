@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestRoleAssumerSTSRegion(t *testing.T) {
+	var direct *roleAssumer
+	if got := direct.stsRegion("aws", "eu-west-1"); got != "eu-west-1" {
+		t.Fatalf("default STS region = %q, want scanned region", got)
+	}
+
+	r := &roleAssumer{config: config.STSConfig{Region: "us-east-2"}}
+	if got := r.stsRegion("aws", "eu-west-1"); got != "us-east-2" {
+		t.Fatalf("configured STS region = %q", got)
+	}
+	if got := r.stsRegion("aws-us-gov", "us-gov-west-1"); got != "us-gov-west-1" {
+		t.Fatalf("STS region from another partition should be ignored, got %q", got)
+	}
+
+	r.config.GlobalEndpoint = true
+	if got := r.stsRegion("aws", "eu-west-1"); got != stsGlobalRegion {
+		t.Fatalf("global endpoint region = %q", got)
+	}
+	if got := r.stsRegion("aws-us-gov", "us-gov-east-1"); got != "us-gov-east-1" {
+		t.Fatalf("GovCloud has no global endpoint, got %q", got)
+	}
+}
+
+func TestRoleAssumerHubRoleFor(t *testing.T) {
+	r := &roleAssumer{config: config.STSConfig{HubRoleARN: "arn:aws:iam::999999999999:role/Hub"}}
+
+	if got := r.hubRoleFor(Account{RoleARN: "arn:aws:iam::123456789012:role/Audit"}); got != r.config.HubRoleARN {
+		t.Fatalf("expected spoke role to chain through the hub, got %q", got)
+	}
+	if got := r.hubRoleFor(Account{RoleARN: r.config.HubRoleARN}); got != "" {
+		t.Fatalf("hub role should be assumed directly, got %q", got)
+	}
+	if got := r.hubRoleFor(Account{RoleARN: "arn:aws-us-gov:iam::123456789012:role/Audit"}); got != "" {
+		t.Fatalf("roles in another partition should not chain, got %q", got)
+	}
+}
@@ -0,0 +1,260 @@
+package aws
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+var throttleChecker = retry.IsErrorThrottles(retry.DefaultThrottles)
+
+// apiTelemetry counts the AWS API calls made while scanning each account
+// and how long the scans take, so accounts close to their API quotas can be
+// found. Only calls made with a context from withScope are counted.
+type apiTelemetry struct {
+	since time.Time
+
+	mu       sync.Mutex
+	accounts map[string]*accountTelemetry
+}
+
+// accountTelemetry is the telemetry of one account, keyed by accountIdentityKey
+type accountTelemetry struct {
+	id, name string
+	scans    int
+	total    time.Duration
+	max      time.Duration
+	last     time.Duration
+	services map[string]*serviceTelemetry // keyed by "region|service"
+}
+
+// serviceTelemetry is the telemetry of one service in one account and region
+type serviceTelemetry struct {
+	region, service string
+	calls           int
+	retries         int
+	errors          int
+	throttles       int
+	latency         time.Duration
+	maxLatency      time.Duration
+
+	// Calls started in the current second, and the most in any second
+	second     int64
+	thisSecond int
+	peak       int
+}
+
+// telemetryScope is the account and region a scan goroutine is working on
+type telemetryScope struct {
+	telemetry *apiTelemetry
+	key       string
+	region    string
+}
+
+type telemetryContextKey struct{}
+
+func newAPITelemetry() *apiTelemetry {
+	return &apiTelemetry{since: time.Now(), accounts: make(map[string]*accountTelemetry)}
+}
+
+// withScope returns a context whose AWS API calls are counted against acc
+// in region
+func (t *apiTelemetry) withScope(ctx context.Context, acc Account, region string) context.Context {
+	if t == nil {
+		return ctx
+	}
+	key := accountIdentityKey(acc)
+	t.mu.Lock()
+	t.account(key, acc.ID, acc.Name)
+	t.mu.Unlock()
+	return context.WithValue(ctx, telemetryContextKey{}, &telemetryScope{telemetry: t, key: key, region: region})
+}
+
+// telemetryScopeFrom returns ctx's scope, or nil if its calls aren't counted
+func telemetryScopeFrom(ctx context.Context) *telemetryScope {
+	s, _ := ctx.Value(telemetryContextKey{}).(*telemetryScope)
+	return s
+}
+
+// account returns key's telemetry, creating it if needed. t.mu must be held.
+func (t *apiTelemetry) account(key, id, name string) *accountTelemetry {
+	a, ok := t.accounts[key]
+	if !ok {
+		a = &accountTelemetry{id: id, name: name, services: make(map[string]*serviceTelemetry)}
+		t.accounts[key] = a
+	}
+	return a
+}
+
+// identify records the ID and name resolved for acc, which may not have been
+// configured
+func (t *apiTelemetry) identify(acc Account, identity accountIdentity) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.account(accountIdentityKey(acc), acc.ID, acc.Name)
+	if identity.id != "" {
+		a.id = identity.id
+	}
+	if identity.name != "" {
+		a.name = identity.name
+	}
+}
+
+// recordScan records how long scanning one of acc's regions took
+func (t *apiTelemetry) recordScan(acc Account, elapsed time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.account(accountIdentityKey(acc), acc.ID, acc.Name)
+	a.scans++
+	a.total += elapsed
+	a.max = max(a.max, elapsed)
+	a.last = elapsed
+}
+
+// record counts one API call to service that started at start and was
+// attempted attempts times, throttles of them throttled
+func (s *telemetryScope) record(service string, start time.Time, attempts, throttles int, err error) {
+	if s == nil {
+		return
+	}
+	elapsed := time.Since(start)
+
+	t := s.telemetry
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.accounts[s.key]
+	key := s.region + "|" + service
+	st, ok := a.services[key]
+	if !ok {
+		st = &serviceTelemetry{region: s.region, service: service}
+		a.services[key] = st
+	}
+
+	st.calls++
+	st.retries += max(0, attempts-1)
+	st.throttles += throttles
+	if err != nil {
+		st.errors++
+	}
+	st.latency += elapsed
+	st.maxLatency = max(st.maxLatency, elapsed)
+
+	if second := start.Unix(); second != st.second {
+		st.second, st.thisSecond = second, 0
+	}
+	st.thisSecond++
+	st.peak = max(st.peak, st.thisSecond)
+}
+
+// apply makes every client created from cfg count its calls against the
+// scope of the call's context
+func (t *apiTelemetry) apply(cfg *aws.Config) {
+	if t == nil {
+		return
+	}
+	cfg.APIOptions = append(cfg.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AWSCOGSTelemetry",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				scope := telemetryScopeFrom(ctx)
+				if scope == nil {
+					return next.HandleInitialize(ctx, in)
+				}
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+
+				attempts, throttles := 1, 0
+				if results, ok := retry.GetAttemptResults(metadata); ok && len(results.Results) > 0 {
+					attempts = len(results.Results)
+					for _, r := range results.Results {
+						if r.Err != nil && throttleChecker.IsErrorThrottle(r.Err).Bool() {
+							throttles++
+						}
+					}
+				} else if err != nil && throttleChecker.IsErrorThrottle(err).Bool() {
+					throttles = 1
+				}
+				scope.record(awsmiddleware.GetServiceID(ctx), start, attempts, throttles, err)
+				return out, metadata, err
+			}), middleware.After)
+	})
+}
+
+// snapshot returns the telemetry of every account, sorted by API calls, most
+// first
+func (t *apiTelemetry) snapshot() *types.TelemetryResponse {
+	response := &types.TelemetryResponse{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Accounts:  []types.AccountTelemetry{},
+	}
+	if t == nil {
+		return response
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	response.Since = t.since.UTC().Format(time.RFC3339)
+	for _, a := range t.accounts {
+		account := types.AccountTelemetry{
+			AccountID:     a.id,
+			AccountName:   a.name,
+			Scans:         a.scans,
+			TotalScanSecs: a.total.Seconds(),
+			MaxScanSecs:   a.max.Seconds(),
+			LastScanSecs:  a.last.Seconds(),
+			Services:      make([]types.APICallStats, 0, len(a.services)),
+		}
+		for _, s := range a.services {
+			account.Calls += s.calls
+			account.Throttles += s.throttles
+			account.Services = append(account.Services, types.APICallStats{
+				Region:        s.region,
+				Service:       s.service,
+				Calls:         s.calls,
+				Retries:       s.retries,
+				Errors:        s.errors,
+				Throttles:     s.throttles,
+				AvgLatencyMs:  milliseconds(s.latency / time.Duration(s.calls)),
+				MaxLatencyMs:  milliseconds(s.maxLatency),
+				PeakPerSecond: s.peak,
+			})
+		}
+		sort.Slice(account.Services, func(i, j int) bool {
+			a, b := account.Services[i], account.Services[j]
+			if a.Calls != b.Calls {
+				return a.Calls > b.Calls
+			}
+			if a.Region != b.Region {
+				return a.Region < b.Region
+			}
+			return a.Service < b.Service
+		})
+		response.Accounts = append(response.Accounts, account)
+	}
+	sort.Slice(response.Accounts, func(i, j int) bool {
+		a, b := response.Accounts[i], response.Accounts[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.AccountID+a.AccountName < b.AccountID+b.AccountName
+	})
+	return response
+}
+
+// milliseconds returns d in milliseconds, rounded to 0.1ms
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Round(100*time.Microsecond)) / float64(time.Millisecond)
+}
@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func TestTelemetryCountsCallsRetriesAndThrottles(t *testing.T) {
+	var stsCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"__type":"LimitExceededException","message":"Rate exceeded"}`)
+			return
+		}
+		stsCalls++
+		w.Header().Set("Content-Type", "text/xml")
+		if stsCalls == 2 { // the first scoped call
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code><Message>Rate exceeded</Message></Error></ErrorResponse>`)
+			return
+		}
+		io.WriteString(w, `<GetCallerIdentityResponse><GetCallerIdentityResult><Account>123456789012</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`)
+	}))
+	defer srv.Close()
+
+	telemetry := newAPITelemetry()
+	acc := Account{Name: "prod"}
+	cfg := apiTestConfig(srv.URL)
+	cfg.Retryer = func() aws.Retryer {
+		return retry.NewStandard(func(o *retry.StandardOptions) {
+			o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+		})
+	}
+	telemetry.apply(&cfg)
+
+	// Calls without a scope aren't counted
+	if _, err := sts.NewFromConfig(cfg).GetCallerIdentity(t.Context(), &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := telemetry.withScope(t.Context(), acc, "us-east-1")
+	if _, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listKinesisStreams(ctx, cfg); err == nil {
+		t.Fatal("expected the throttled Kinesis call to fail")
+	}
+	telemetry.identify(acc, accountIdentity{id: "123456789012", name: "prod"})
+	telemetry.recordScan(acc, 2*time.Second)
+
+	accounts := telemetry.snapshot().Accounts
+	if len(accounts) != 1 {
+		t.Fatalf("accounts = %+v, want one", accounts)
+	}
+	got := accounts[0]
+	if got.AccountID != "123456789012" || got.Scans != 1 || got.MaxScanSecs != 2 || got.Calls != 2 || got.Throttles != 4 {
+		t.Errorf("account = %+v", got)
+	}
+	if len(got.Services) != 2 {
+		t.Fatalf("services = %+v, want STS and Kinesis", got.Services)
+	}
+	for _, s := range got.Services {
+		switch s.Service {
+		case "STS":
+			if s.Calls != 1 || s.Retries != 1 || s.Throttles != 1 || s.Errors != 0 {
+				t.Errorf("STS = %+v, want one call retried once after a throttle", s)
+			}
+		case "Kinesis":
+			if s.Calls != 1 || s.Retries != 2 || s.Throttles != 3 || s.Errors != 1 || s.PeakPerSecond != 1 {
+				t.Errorf("Kinesis = %+v, want one call throttled on all three attempts", s)
+			}
+		default:
+			t.Errorf("unexpected service %+v", s)
+		}
+	}
+}
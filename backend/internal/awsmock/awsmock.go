@@ -0,0 +1,302 @@
+// Package awsmock records AWS API responses to golden files and replays them,
+// so discovery can be tested without live AWS. It works at the HTTP layer:
+// a Transport is installed as the HTTP client of the aws.Config clients are
+// built from, and every SDK client made from that config is mocked.
+//
+// Tests replay by default. Run them with AWSCOGS_AWSMOCK_RECORD=1 and AWS
+// credentials to record fresh fixtures from a real account instead. Recorded
+// fixtures contain the account's real IDs and resource names, so review and
+// scrub them before committing.
+package awsmock
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// EnvRecord switches New from replaying fixtures to recording them
+const EnvRecord = "AWSCOGS_AWSMOCK_RECORD"
+
+// Interaction is one recorded API call
+type Interaction struct {
+	Host      string `json:"host"`              // e.g. ec2.us-east-1.amazonaws.com
+	Operation string `json:"operation"`         // e.g. DescribeInstances
+	Request   string `json:"request,omitempty"` // Request body, used to tell calls of one operation apart
+
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"` // Content-Type and X-Amz* response headers
+	Body       string            `json:"body,omitempty"`
+	BodyBase64 string            `json:"bodyBase64,omitempty"` // Set instead of Body for binary (CBOR) responses
+}
+
+// Fixture is the golden file a Transport replays or records
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Transport is an http.RoundTripper that replays the interactions in a
+// fixture or, when recording, sends requests on and records the responses
+type Transport struct {
+	mu        sync.Mutex
+	path      string
+	fixture   Fixture
+	used      []bool
+	recording bool
+	next      http.RoundTripper
+}
+
+// Replay returns a Transport that serves the interactions in the fixture at
+// path
+func Replay(path string) (*Transport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parsing fixture %s: %w", path, err)
+	}
+	return &Transport{path: path, fixture: fixture, used: make([]bool, len(fixture.Interactions))}, nil
+}
+
+// Record returns a Transport that sends requests with next (the default
+// transport if nil) and records them for Save to write to path
+func Record(path string, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{path: path, recording: true, next: next}
+}
+
+// New returns a Transport for a test. It replays the fixture at path, or
+// records it when AWSCOGS_AWSMOCK_RECORD=1, saving it when the test ends.
+// When replaying, the test fails if any interaction wasn't used.
+func New(t testing.TB, path string) *Transport {
+	t.Helper()
+	if os.Getenv(EnvRecord) == "1" {
+		tr := Record(path, nil)
+		t.Cleanup(func() {
+			if err := tr.Save(); err != nil {
+				t.Errorf("saving fixture: %v", err)
+			}
+		})
+		return tr
+	}
+
+	tr, err := Replay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if unused := tr.Unused(); len(unused) > 0 && !t.Failed() {
+			t.Errorf("%d interactions in %s were not used, first %s %s", len(unused), path, unused[0].Host, unused[0].Operation)
+		}
+	})
+	return tr
+}
+
+// Config returns an aws.Config for region whose clients use the transport.
+// Replayed configs have static credentials and don't retry. Its signature
+// matches aws.ConfigFactory, so it can be passed to
+// aws.NewDiscoveryWithConfigFactory.
+func (t *Transport) Config(ctx context.Context, region string) (aws.Config, error) {
+	client := &http.Client{Transport: t}
+	if t.recording {
+		return awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region), awsconfig.WithHTTPClient(client))
+	}
+	return aws.Config{
+		Region:           region,
+		Credentials:      credentials.NewStaticCredentialsProvider("AKIDAWSMOCK", "awsmock", ""),
+		HTTPClient:       client,
+		RetryMaxAttempts: 1,
+	}, nil
+}
+
+// RoundTrip replays or records one API call
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	host, op, reqBody := req.URL.Host, operation(req, body), encodeBody(body)
+
+	if t.recording {
+		return t.record(req, host, op, reqBody)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Prefer a call with the same request body. Bodies that include the
+	// current time, like CloudWatch queries, fall back to the next unused
+	// call of the operation.
+	match := -1
+	for i, in := range t.fixture.Interactions {
+		if t.used[i] || in.Host != host || in.Operation != op {
+			continue
+		}
+		if in.Request == reqBody {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("awsmock: no recorded response for %s %s in %s", host, op, t.path)
+	}
+	t.used[match] = true
+	return t.fixture.Interactions[match].response(req)
+}
+
+func (t *Transport) record(req *http.Request, host, op, reqBody string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	in := Interaction{Host: host, Operation: op, Request: reqBody, Status: resp.StatusCode, Headers: make(map[string]string)}
+	for name := range resp.Header {
+		if recordHeader(name) {
+			in.Headers[name] = resp.Header.Get(name)
+		}
+	}
+	if utf8.Valid(data) {
+		in.Body = string(data)
+	} else {
+		in.BodyBase64 = base64.StdEncoding.EncodeToString(data)
+	}
+
+	t.mu.Lock()
+	t.fixture.Interactions = append(t.fixture.Interactions, in)
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// Save writes the recorded interactions to the fixture path
+func (t *Transport) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, err := json.MarshalIndent(t.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, append(data, '\n'), 0o644)
+}
+
+// Unused returns the interactions that haven't been replayed
+func (t *Transport) Unused() []Interaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var unused []Interaction
+	for i, in := range t.fixture.Interactions {
+		if !t.used[i] {
+			unused = append(unused, in)
+		}
+	}
+	return unused
+}
+
+// response builds the recorded HTTP response for req
+func (in Interaction) response(req *http.Request) (*http.Response, error) {
+	body := []byte(in.Body)
+	if in.BodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(in.BodyBase64); err != nil {
+			return nil, fmt.Errorf("awsmock: decoding %s %s body: %w", in.Host, in.Operation, err)
+		}
+	}
+	header := make(http.Header)
+	for name, value := range in.Headers {
+		header.Set(name, value)
+	}
+	status := in.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// operation names the API operation a request calls, for each AWS protocol:
+// the Action of query requests (EC2, STS, IAM), the target of JSON requests
+// (ECS, Organizations), the path of RPC v2 CBOR requests (CloudWatch), and
+// the method and path of REST requests (Lambda, EKS)
+func operation(req *http.Request, body []byte) string {
+	if target := req.Header.Get("X-Amz-Target"); target != "" {
+		if i := strings.LastIndex(target, "."); i >= 0 {
+			return target[i+1:]
+		}
+		return target
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil && form.Get("Action") != "" {
+			return form.Get("Action")
+		}
+	}
+	if i := strings.LastIndex(req.URL.Path, "/operation/"); i >= 0 {
+		return req.URL.Path[i+len("/operation/"):]
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+// recordHeader reports whether a response header is recorded: the content
+// type and the X-Amz headers some protocols read errors from, but not
+// request IDs, which change on every call
+func recordHeader(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "request-id") || strings.HasSuffix(lower, "requestid") {
+		return false
+	}
+	return lower == "content-type" || strings.HasPrefix(lower, "x-amz")
+}
+
+// encodeBody returns a request body as a string, base64-encoding binary ones
+func encodeBody(body []byte) string {
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	return base64.StdEncoding.EncodeToString(body)
+}
@@ -0,0 +1,61 @@
+package awsmock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.Header().Set("X-Amzn-Requestid", "abc")
+		io.WriteString(w, `<GetCallerIdentityResponse><GetCallerIdentityResult><Account>123456789012</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`)
+	}))
+	path := filepath.Join(t.TempDir(), "sts.json")
+	config := func(tr *Transport) aws.Config {
+		return aws.Config{
+			Region:           "us-east-1",
+			Credentials:      credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+			BaseEndpoint:     aws.String(srv.URL),
+			HTTPClient:       &http.Client{Transport: tr},
+			RetryMaxAttempts: 1,
+		}
+	}
+
+	rec := Record(path, nil)
+	if _, err := sts.NewFromConfig(config(rec)).GetCallerIdentity(t.Context(), &sts.GetCallerIdentityInput{}); err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	srv.Close()
+
+	replay, err := Replay(path)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	in := replay.fixture.Interactions
+	if len(in) != 1 || in[0].Operation != "GetCallerIdentity" || in[0].Headers["X-Amzn-Requestid"] != "" {
+		t.Fatalf("unexpected fixture: %+v", in)
+	}
+	out, err := sts.NewFromConfig(config(replay)).GetCallerIdentity(t.Context(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		t.Fatalf("replaying: %v", err)
+	}
+	if aws.ToString(out.Account) != "123456789012" {
+		t.Fatalf("Account = %q, want 123456789012", aws.ToString(out.Account))
+	}
+
+	// Each interaction is replayed once
+	if _, err := sts.NewFromConfig(config(replay)).GetCallerIdentity(t.Context(), &sts.GetCallerIdentityInput{}); err == nil {
+		t.Fatal("expected an error once the fixture is used up")
+	}
+}
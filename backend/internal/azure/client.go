@@ -0,0 +1,148 @@
+// Package azure provides EXPERIMENTAL Azure virtual machine discovery and
+// pricing using the Azure Resource Manager and Retail Prices REST APIs.
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLoginURL      = "https://login.microsoftonline.com"
+	defaultManagementURL = "https://management.azure.com"
+	defaultPricesURL     = "https://prices.azure.com/api/retail/prices"
+)
+
+// Credentials is a service principal used to call Azure Resource Manager
+type Credentials struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+// CredentialsFromEnv reads AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET
+func CredentialsFromEnv() (Credentials, error) {
+	creds := Credentials{
+		TenantID:     os.Getenv("AZURE_TENANT_ID"),
+		ClientID:     os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret: os.Getenv("AZURE_CLIENT_SECRET"),
+	}
+	if creds.TenantID == "" || creds.ClientID == "" || creds.ClientSecret == "" {
+		return creds, fmt.Errorf("AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET must be set")
+	}
+	return creds, nil
+}
+
+// client is a minimal Azure Resource Manager REST client
+type client struct {
+	creds         Credentials
+	http          *http.Client
+	loginURL      string
+	managementURL string
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newClient(creds Credentials) *client {
+	return &client{
+		creds:         creds,
+		http:          &http.Client{Timeout: 30 * time.Second},
+		loginURL:      defaultLoginURL,
+		managementURL: defaultManagementURL,
+	}
+}
+
+// accessToken returns a cached client-credentials token for ARM
+func (c *client) accessToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.creds.ClientID},
+		"client_secret": {c.creds.ClientSecret},
+		"scope":         {c.managementURL + "/.default"},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", c.loginURL, url.PathEscape(c.creds.TenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(c.http, req, &body); err != nil {
+		return "", fmt.Errorf("getting access token: %w", err)
+	}
+
+	c.token = body.AccessToken
+	// Refresh a minute early to avoid using a token as it expires
+	c.tokenExpiry = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+// list follows nextLink paging for an ARM list endpoint, calling fn with each page's raw values
+func (c *client) list(ctx context.Context, path string, fn func(json.RawMessage) error) error {
+	next := c.managementURL + path
+	for next != "" {
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		var page struct {
+			Value    []json.RawMessage `json:"value"`
+			NextLink string            `json:"nextLink"`
+		}
+		if err := doJSON(c.http, req, &page); err != nil {
+			return err
+		}
+		for _, v := range page.Value {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		next = page.NextLink
+	}
+	return nil
+}
+
+// doJSON sends req and decodes a successful JSON response into out
+func doJSON(hc *http.Client, req *http.Request, out any) error {
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+const (
+	subscriptionsAPIVersion = "2022-12-01"
+	computeAPIVersion       = "2024-07-01"
+)
+
+// regionPattern matches Azure region names such as eastus or westeurope2
+var regionPattern = regexp.MustCompile(`^[a-z]+[0-9]*$`)
+
+// Subscription is an Azure subscription to scan
+type Subscription struct {
+	ID   string
+	Name string
+}
+
+// Discoverer is an EXPERIMENTAL cloud.ResourceDiscoverer for Azure virtual machines
+type Discoverer struct {
+	client        *client
+	prices        cloud.PriceSource
+	subscriptions []string // Configured subscription IDs; empty = all visible
+	logger        *slog.Logger
+}
+
+// NewDiscoverer creates an Azure VM discoverer
+func NewDiscoverer(creds Credentials, prices cloud.PriceSource, subscriptions []string, logger *slog.Logger) *Discoverer {
+	return &Discoverer{
+		client:        newClient(creds),
+		prices:        prices,
+		subscriptions: subscriptions,
+		logger:        logger,
+	}
+}
+
+// Provider implements cloud.ResourceDiscoverer
+func (d *Discoverer) Provider() string {
+	return "azure"
+}
+
+// ResourceTypes implements cloud.ResourceDiscoverer
+func (d *Discoverer) ResourceTypes() []string {
+	return []string{"vm"}
+}
+
+// HandlesRegion implements cloud.ResourceDiscoverer
+func (d *Discoverer) HandlesRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// Discover implements cloud.ResourceDiscoverer
+func (d *Discoverer) Discover(ctx context.Context, scope cloud.Scope) (*types.CostResponse, error) {
+	subs, err := d.listSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(scope.Accounts) > 0 {
+		subs = slices.DeleteFunc(subs, func(s Subscription) bool {
+			return !slices.Contains(scope.Accounts, s.ID) && !slices.Contains(scope.Accounts, s.Name)
+		})
+	}
+
+	var (
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+		vms         []types.VirtualMachine
+		diagnostics []types.Diagnostic
+	)
+	progress := cloud.ProgressFrom(ctx)
+	progress.Add(len(subs))
+	for _, sub := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer progress.Done()
+			found, diags, err := d.discoverVMs(ctx, sub, scope.Regions)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				d.logger.Error("failed to discover Azure VMs", "subscription", sub.ID, "error", err)
+				diagnostics = append(diagnostics, types.Diagnostic{
+					Level:        "error",
+					ResourceType: "vm",
+					AccountID:    sub.ID,
+					AccountName:  sub.Name,
+					Operation:    "ListVirtualMachines",
+					Message:      err.Error(),
+				})
+				return
+			}
+			vms = append(vms, found...)
+			diagnostics = append(diagnostics, diags...)
+		}()
+	}
+	wg.Wait()
+
+	response := &types.CostResponse{
+		Status:          types.ResponseStatusOK,
+		Diagnostics:     diagnostics,
+		Currency:        "USD",
+		VirtualMachines: vms,
+	}
+	if len(diagnostics) > 0 {
+		response.Status = types.ResponseStatusPartial
+	}
+
+	for _, vm := range vms {
+		response.TotalCost = response.TotalCost.Add(vm.HourlyCost)
+	}
+	resources := snapshot.Resources(response)
+	response.Accounts = aggregate.AccountSummaries(resources)
+	response.Regions = aggregate.RegionSummaries(resources)
+
+	return response, nil
+}
+
+func (d *Discoverer) listSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := d.client.list(ctx, "/subscriptions?api-version="+subscriptionsAPIVersion, func(raw json.RawMessage) error {
+		var s struct {
+			SubscriptionID string `json:"subscriptionId"`
+			DisplayName    string `json:"displayName"`
+			State          string `json:"state"`
+		}
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("decoding subscription: %w", err)
+		}
+		if s.State != "" && s.State != "Enabled" {
+			return nil
+		}
+		if len(d.subscriptions) > 0 && !slices.Contains(d.subscriptions, s.SubscriptionID) {
+			return nil
+		}
+		subs = append(subs, Subscription{ID: s.SubscriptionID, Name: s.DisplayName})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing Azure subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+type armVM struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Location   string `json:"location"`
+	Properties struct {
+		HardwareProfile struct {
+			VMSize string `json:"vmSize"`
+		} `json:"hardwareProfile"`
+		StorageProfile struct {
+			OSDisk struct {
+				OSType string `json:"osType"`
+			} `json:"osDisk"`
+		} `json:"storageProfile"`
+		InstanceView struct {
+			Statuses []struct {
+				Code string `json:"code"`
+			} `json:"statuses"`
+		} `json:"instanceView"`
+	} `json:"properties"`
+}
+
+// powerState returns the VM power state, e.g. running or deallocated
+func (vm armVM) powerState() string {
+	for _, s := range vm.Properties.InstanceView.Statuses {
+		if state, ok := strings.CutPrefix(s.Code, "PowerState/"); ok {
+			return state
+		}
+	}
+	return "unknown"
+}
+
+func (d *Discoverer) discoverVMs(ctx context.Context, sub Subscription, regions []string) ([]types.VirtualMachine, []types.Diagnostic, error) {
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Compute/virtualMachines?api-version=%s&statusOnly=true",
+		url.PathEscape(sub.ID), computeAPIVersion)
+
+	var (
+		vms         []types.VirtualMachine
+		diagnostics []types.Diagnostic
+	)
+	err := d.client.list(ctx, path, func(raw json.RawMessage) error {
+		var vm armVM
+		if err := json.Unmarshal(raw, &vm); err != nil {
+			return fmt.Errorf("decoding virtual machine: %w", err)
+		}
+		if len(regions) > 0 && !slices.Contains(regions, vm.Location) {
+			return nil
+		}
+
+		result := types.VirtualMachine{
+			Provider:    "azure",
+			AccountID:   sub.ID,
+			AccountName: sub.Name,
+			Region:      vm.Location,
+			ID:          vm.ID,
+			Name:        vm.Name,
+			Size:        vm.Properties.HardwareProfile.VMSize,
+			OSType:      vm.Properties.StorageProfile.OSDisk.OSType,
+			State:       vm.powerState(),
+		}
+
+		// Deallocated VMs don't accrue compute charges; stopped (but allocated) VMs do
+		if result.State != "deallocated" && result.State != "deallocating" {
+			price, err := d.prices.HourlyPrice(ctx, cloud.PriceQuery{
+				Service:    "vm",
+				Region:     result.Region,
+				SKU:        result.Size,
+				Attributes: map[string]string{"osType": result.OSType},
+			})
+			if err != nil {
+				d.logger.Warn("failed to get Azure VM price", "size", result.Size, "region", result.Region, "error", err)
+				diagnostics = append(diagnostics, types.Diagnostic{
+					Level:        "warning",
+					ResourceType: "vm",
+					AccountID:    sub.ID,
+					AccountName:  sub.Name,
+					Region:       result.Region,
+					Operation:    "GetVMPrice",
+					ResourceID:   result.ID,
+					Message:      err.Error(),
+				})
+			}
+			result.HourlyCost = price
+			if price > 0 {
+				result.CostComponents = []types.CostComponent{types.HourlyComponent("compute", "hour", 1, price)}
+			}
+		}
+
+		vms = append(vms, result)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return vms, diagnostics, nil
+}
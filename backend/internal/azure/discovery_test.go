@@ -0,0 +1,75 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+)
+
+func TestDiscoverPricesRunningVMs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+		case r.URL.Path == "/subscriptions":
+			json.NewEncoder(w).Encode(map[string]any{"value": []map[string]string{
+				{"subscriptionId": "sub-1", "displayName": "Production", "state": "Enabled"},
+				{"subscriptionId": "sub-2", "displayName": "Old", "state": "Disabled"},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/virtualMachines"):
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `{"value": [
+				{"id": "/vm/web", "name": "web", "location": "eastus", "properties": {
+					"hardwareProfile": {"vmSize": "Standard_D2s_v3"},
+					"storageProfile": {"osDisk": {"osType": "Linux"}},
+					"instanceView": {"statuses": [{"code": "ProvisioningState/succeeded"}, {"code": "PowerState/running"}]}}},
+				{"id": "/vm/idle", "name": "idle", "location": "eastus", "properties": {
+					"hardwareProfile": {"vmSize": "Standard_D2s_v3"},
+					"storageProfile": {"osDisk": {"osType": "Linux"}},
+					"instanceView": {"statuses": [{"code": "PowerState/deallocated"}]}}}
+			]}`)
+		case r.URL.Path == "/prices":
+			io.WriteString(w, `{"Items": [
+				{"retailPrice": 0.02, "unitOfMeasure": "1 Hour", "type": "Consumption", "skuName": "D2s v3 Spot", "productName": "Virtual Machines DSv3 Series"},
+				{"retailPrice": 0.188, "unitOfMeasure": "1 Hour", "type": "Consumption", "skuName": "D2s v3", "productName": "Virtual Machines DSv3 Series Windows"},
+				{"retailPrice": 0.096, "unitOfMeasure": "1 Hour", "type": "Consumption", "skuName": "D2s v3", "productName": "Virtual Machines DSv3 Series"}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	prices := NewPriceSource(time.Hour)
+	prices.pricesURL = server.URL + "/prices"
+
+	d := NewDiscoverer(Credentials{TenantID: "tenant", ClientID: "id", ClientSecret: "secret"}, prices, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	d.client.loginURL = server.URL
+	d.client.managementURL = server.URL
+
+	response, err := d.Discover(context.Background(), cloud.Scope{})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if len(response.VirtualMachines) != 2 {
+		t.Fatalf("expected 2 VMs, got %+v", response.VirtualMachines)
+	}
+	if response.TotalCost != 0.096 {
+		t.Fatalf("TotalCost = %v, want 0.096 (running Linux VM only)", response.TotalCost)
+	}
+	if len(response.Accounts) != 1 || response.Accounts[0].AccountName != "Production" || response.Accounts[0].Counts["vm"] != 2 {
+		t.Fatalf("Accounts = %+v", response.Accounts)
+	}
+}
@@ -0,0 +1,126 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// PriceSource looks up pay-as-you-go VM prices from the public Azure Retail
+// Prices API. No credentials are required.
+type PriceSource struct {
+	http      *http.Client
+	pricesURL string
+	ttl       time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]cachedPrice
+}
+
+type cachedPrice struct {
+	price     types.CostValue
+	expiresAt time.Time
+}
+
+type retailPrice struct {
+	RetailPrice   float64 `json:"retailPrice"`
+	UnitOfMeasure string  `json:"unitOfMeasure"`
+	Type          string  `json:"type"`
+	SkuName       string  `json:"skuName"`
+	ProductName   string  `json:"productName"`
+}
+
+// NewPriceSource creates an Azure price source that caches prices for ttl
+func NewPriceSource(ttl time.Duration) *PriceSource {
+	return &PriceSource{
+		http:      &http.Client{Timeout: 30 * time.Second},
+		pricesURL: defaultPricesURL,
+		ttl:       ttl,
+		cache:     make(map[string]cachedPrice),
+	}
+}
+
+// Provider implements cloud.PriceSource
+func (p *PriceSource) Provider() string {
+	return "azure"
+}
+
+// HourlyPrice implements cloud.PriceSource. Only the "vm" service is
+// supported; SKU is the ARM VM size and the "osType" attribute selects
+// Linux or Windows pricing.
+func (p *PriceSource) HourlyPrice(ctx context.Context, q cloud.PriceQuery) (types.CostValue, error) {
+	if q.Service != "vm" {
+		return 0, fmt.Errorf("unsupported Azure price service: %s", q.Service)
+	}
+	windows := strings.EqualFold(q.Attributes["osType"], "windows")
+
+	key := fmt.Sprintf("%s|%s|%t", q.Region, q.SKU, windows)
+	p.mu.RLock()
+	entry, ok := p.cache[key]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.price, nil
+	}
+
+	price, err := p.fetchVMPrice(ctx, q.Region, q.SKU, windows)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	p.cache[key] = cachedPrice{price: price, expiresAt: time.Now().Add(p.ttl)}
+	p.mu.Unlock()
+	return price, nil
+}
+
+func (p *PriceSource) fetchVMPrice(ctx context.Context, region, size string, windows bool) (types.CostValue, error) {
+	filter := fmt.Sprintf("serviceName eq 'Virtual Machines' and armRegionName eq '%s' and armSkuName eq '%s' and priceType eq 'Consumption'",
+		odataEscape(region), odataEscape(size))
+	next := p.pricesURL + "?$filter=" + url.QueryEscape(filter)
+
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return 0, fmt.Errorf("creating price request: %w", err)
+		}
+
+		var page struct {
+			Items        []retailPrice `json:"Items"`
+			NextPageLink string        `json:"NextPageLink"`
+		}
+		if err := doJSON(p.http, req, &page); err != nil {
+			return 0, fmt.Errorf("fetching Azure price for %s in %s: %w", size, region, err)
+		}
+
+		for _, item := range page.Items {
+			if matchesVMPrice(item, windows) {
+				return types.CostValue(item.RetailPrice), nil
+			}
+		}
+		next = page.NextPageLink
+	}
+
+	return 0, fmt.Errorf("no Azure price found for %s in %s", size, region)
+}
+
+// matchesVMPrice selects the standard pay-as-you-go hourly meter for the OS
+func matchesVMPrice(item retailPrice, windows bool) bool {
+	if item.Type != "Consumption" || item.UnitOfMeasure != "1 Hour" {
+		return false
+	}
+	if strings.Contains(item.SkuName, "Spot") || strings.Contains(item.SkuName, "Low Priority") {
+		return false
+	}
+	return strings.Contains(item.ProductName, "Windows") == windows
+}
+
+func odataEscape(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
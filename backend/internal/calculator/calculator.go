@@ -0,0 +1,275 @@
+// Package calculator prices hypothetical resources with the same pricing
+// provider scans use, so a design can be costed before it's deployed.
+package calculator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// MaxItems is the most items one request can price, since each distinct
+// item may need a Pricing API call
+const MaxItems = 100
+
+// ItemTypes are the resource types the calculator can price
+var ItemTypes = []string{"ec2", "ebs", "rds", "ecs", "eks", "elb", "nat", "eip", "publicipv4", "secrets", "lambda"}
+
+// Request is a set of hypothetical resources to price
+type Request struct {
+	Region string `json:"region,omitempty"` // Region for items that don't set their own
+	Items  []Item `json:"items"`
+}
+
+// Item is one hypothetical resource, or Count identical ones. Which fields
+// apply depends on Type.
+type Item struct {
+	Type   string `json:"type"`             // One of ItemTypes
+	Region string `json:"region,omitempty"` // Defaults to the request's region
+	Count  int    `json:"count,omitempty"`  // Number of resources, or Fargate tasks for ecs (default 1)
+
+	InstanceType string   `json:"instanceType,omitempty"` // EC2 instance type or RDS instance class
+	Tenancy      string   `json:"tenancy,omitempty"`      // EC2: default, dedicated, or host
+	Volumes      []Volume `json:"volumes,omitempty"`      // EC2: EBS volumes attached to each instance
+
+	Volume // EBS volume, or RDS storage when VolumeType is set
+
+	Engine   string `json:"engine,omitempty"`  // RDS engine, e.g. postgres
+	MultiAZ  bool   `json:"multiAz,omitempty"` // RDS
+	Extended bool   `json:"extendedSupport,omitempty"`
+
+	LoadBalancerType string `json:"loadBalancerType,omitempty"` // application, network, gateway, or classic
+
+	Architecture     string  `json:"architecture,omitempty"` // Lambda: x86_64 or arm64
+	RequestsPerMonth float64 `json:"requestsPerMonth,omitempty"`
+	DurationMS       float64 `json:"durationMs,omitempty"` // Lambda: average duration
+	MemoryMB         int32   `json:"memoryMb,omitempty"`   // Lambda: configured memory
+}
+
+// Volume is an EBS volume, or RDS storage
+type Volume struct {
+	VolumeType string `json:"volumeType,omitempty"` // e.g. gp3, or an RDS storage type
+	SizeGiB    int32  `json:"sizeGiB,omitempty"`
+	IOPS       int32  `json:"iops,omitempty"`
+	Throughput int32  `json:"throughput,omitempty"` // MiB/s
+}
+
+// ItemCost is the cost of one item, for all Count resources
+type ItemCost struct {
+	Item
+	HourlyCost     types.CostValue       `json:"hourlyCost"`
+	MonthlyCost    types.CostValue       `json:"monthlyCost"`
+	CostComponents []types.CostComponent `json:"costComponents"`
+	Error          string                `json:"error,omitempty"` // Why the item couldn't be priced
+}
+
+// Result is the API response for the calculator
+type Result struct {
+	Timestamp        string          `json:"timestamp"`
+	Status           string          `json:"status"` // partial if any item couldn't be priced
+	Currency         string          `json:"currency"`
+	TotalHourlyCost  types.CostValue `json:"totalHourlyCost"`
+	TotalMonthlyCost types.CostValue `json:"totalMonthlyCost"`
+	Items            []ItemCost      `json:"items"`
+}
+
+// Validate fills in defaults and reports the first invalid item
+func (r *Request) Validate() error {
+	if len(r.Items) == 0 {
+		return errors.New("at least one item is required")
+	}
+	if len(r.Items) > MaxItems {
+		return fmt.Errorf("at most %d items can be priced at once", MaxItems)
+	}
+	for i := range r.Items {
+		item := &r.Items[i]
+		if item.Region == "" {
+			item.Region = r.Region
+		}
+		if item.Count == 0 {
+			item.Count = 1
+		}
+		if err := item.validate(); err != nil {
+			return fmt.Errorf("items[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (item *Item) validate() error {
+	if !slices.Contains(ItemTypes, item.Type) {
+		return fmt.Errorf("unknown type %q (valid: %s)", item.Type, strings.Join(ItemTypes, ", "))
+	}
+	if item.Region == "" {
+		return errors.New("region is required")
+	}
+	if item.Count < 1 {
+		return errors.New("count must be at least 1")
+	}
+	switch item.Type {
+	case "ec2", "rds":
+		if item.InstanceType == "" {
+			return errors.New("instanceType is required")
+		}
+	case "ebs":
+		if item.VolumeType == "" || item.SizeGiB < 1 {
+			return errors.New("volumeType and sizeGiB are required")
+		}
+	case "elb":
+		if item.LoadBalancerType == "" {
+			return errors.New("loadBalancerType is required")
+		}
+	case "lambda":
+		if item.RequestsPerMonth < 0 || item.DurationMS < 0 || item.MemoryMB < 0 {
+			return errors.New("requestsPerMonth, durationMs, and memoryMb cannot be negative")
+		}
+	}
+	if item.Type == "rds" && item.Engine == "" {
+		return errors.New("engine is required")
+	}
+	for _, v := range item.Volumes {
+		if v.VolumeType == "" || v.SizeGiB < 1 {
+			return errors.New("each volume needs a volumeType and sizeGiB")
+		}
+	}
+	return nil
+}
+
+// Price prices each item in a validated request. Items that can't be priced,
+// such as an unknown instance type, report an error and count as zero.
+func Price(ctx context.Context, provider pricing.Provider, req Request) *Result {
+	result := &Result{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    types.ResponseStatusOK,
+		Currency:  "USD",
+		Items:     make([]ItemCost, 0, len(req.Items)),
+	}
+	for _, item := range req.Items {
+		cost := ItemCost{Item: item, CostComponents: []types.CostComponent{}}
+		components, err := priceItem(ctx, provider, item)
+		if err != nil {
+			cost.Error = err.Error()
+			result.Status = types.ResponseStatusPartial
+		} else {
+			cost.CostComponents = scale(components, item.Count)
+			cost.HourlyCost = types.SumComponents(cost.CostComponents)
+			cost.MonthlyCost = cost.HourlyCost * types.HoursPerMonth
+		}
+		result.Items = append(result.Items, cost)
+		result.TotalHourlyCost = result.TotalHourlyCost.Add(cost.HourlyCost)
+		result.TotalMonthlyCost = result.TotalMonthlyCost.Add(cost.MonthlyCost)
+	}
+	return result
+}
+
+// priceItem returns the cost components of one of item's resources
+func priceItem(ctx context.Context, provider pricing.Provider, item Item) ([]types.CostComponent, error) {
+	region := item.Region
+	flat := func(name string, price types.CostValue, err error) ([]types.CostComponent, error) {
+		if err != nil {
+			return nil, err
+		}
+		return []types.CostComponent{types.HourlyComponent(name, "hour", 1, price)}, nil
+	}
+
+	switch item.Type {
+	case "ec2":
+		tenancy := item.Tenancy
+		if tenancy == "" {
+			tenancy = "default"
+		}
+		price, err := provider.GetEC2TenancyPrice(ctx, region, item.InstanceType, tenancy)
+		if err != nil {
+			return nil, err
+		}
+		components := []types.CostComponent{types.HourlyComponent("compute", "hour", 1, price)}
+		for _, v := range item.Volumes {
+			volume, err := provider.GetEBSCostComponents(ctx, region, v.VolumeType, v.SizeGiB, v.IOPS, v.Throughput)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range volume {
+				c.Name = v.VolumeType + " " + c.Name
+				components = append(components, c)
+			}
+		}
+		return components, nil
+	case "ebs":
+		return provider.GetEBSCostComponents(ctx, region, item.VolumeType, item.SizeGiB, item.IOPS, item.Throughput)
+	case "rds":
+		price, err := provider.GetRDSPrice(ctx, region, item.InstanceType, item.Engine, item.MultiAZ)
+		if err != nil {
+			return nil, err
+		}
+		components := []types.CostComponent{types.HourlyComponent("compute", "hour", 1, price)}
+		if item.VolumeType != "" && item.SizeGiB > 0 {
+			storage, err := provider.GetRDSStoragePrice(ctx, region, item.VolumeType, item.MultiAZ)
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, types.MonthlyComponent("storage", "GB-month", float64(item.SizeGiB), storage))
+		}
+		return components, nil
+	case "ecs":
+		price, err := provider.GetECSPrice(ctx, region, "FARGATE", 1)
+		if err != nil {
+			return nil, err
+		}
+		return []types.CostComponent{types.HourlyComponent("fargate tasks", "task-hour", 1, price)}, nil
+	case "eks":
+		name := "control plane"
+		if item.Extended {
+			name = "control plane (extended support)"
+		}
+		price, err := provider.GetEKSPrice(ctx, region, item.Extended)
+		return flat(name, price, err)
+	case "elb":
+		base, _, err := provider.GetELBPrice(ctx, region, item.LoadBalancerType)
+		return flat("load balancer", base, err)
+	case "nat":
+		price, err := provider.GetNATGatewayPrice(ctx, region)
+		return flat("gateway", price, err)
+	case "eip":
+		price, err := provider.GetElasticIPPrice(ctx, region, true)
+		return flat("in-use address", price, err)
+	case "publicipv4":
+		price, err := provider.GetPublicIPv4Price(ctx, region)
+		return flat("in-use address", price, err)
+	case "secrets":
+		price, err := provider.GetSecretPrice(ctx, region)
+		return flat("secret", price, err)
+	case "lambda":
+		architecture := item.Architecture
+		if architecture == "" {
+			architecture = "x86_64"
+		}
+		requestPrice, gbSecondPrice, err := provider.GetLambdaPrice(ctx, region, architecture)
+		if err != nil {
+			return nil, err
+		}
+		requestsPerHour := item.RequestsPerMonth / types.HoursPerMonth
+		gbSeconds := requestsPerHour * item.DurationMS / 1000 * float64(item.MemoryMB) / 1024
+		return []types.CostComponent{
+			types.HourlyComponent("requests", "request", requestsPerHour, requestPrice),
+			types.HourlyComponent("compute", "GB-second", gbSeconds, gbSecondPrice),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", item.Type)
+}
+
+// scale multiplies components for one resource by count
+func scale(components []types.CostComponent, count int) []types.CostComponent {
+	out := make([]types.CostComponent, len(components))
+	for i, c := range components {
+		c.Quantity *= float64(count)
+		c.HourlyCost *= types.CostValue(count)
+		out[i] = c
+	}
+	return out
+}
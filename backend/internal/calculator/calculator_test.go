@@ -0,0 +1,95 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// fakeProvider prices m5.large at $0.096/hour, gp3 at $0.08/GB-month, and
+// postgres db.m5.large at $0.178/hour with gp3 storage at $0.115/GB-month
+type fakeProvider struct {
+	pricing.Provider
+}
+
+func (fakeProvider) GetEC2TenancyPrice(_ context.Context, _, instanceType, _ string) (types.CostValue, error) {
+	if instanceType != "m5.large" {
+		return 0, errors.New("no price found")
+	}
+	return 0.096, nil
+}
+
+func (fakeProvider) GetEBSCostComponents(_ context.Context, _, _ string, sizeGiB, _, _ int32) ([]types.CostComponent, error) {
+	return []types.CostComponent{types.MonthlyComponent("storage", "GB-month", float64(sizeGiB), 0.08)}, nil
+}
+
+func (fakeProvider) GetRDSPrice(context.Context, string, string, string, bool) (types.CostValue, error) {
+	return 0.178, nil
+}
+
+func (fakeProvider) GetRDSStoragePrice(context.Context, string, string, bool) (types.CostValue, error) {
+	return 0.115, nil
+}
+
+func TestPrice(t *testing.T) {
+	req := Request{
+		Region: "us-east-1",
+		Items: []Item{
+			{Type: "ec2", Count: 3, InstanceType: "m5.large", Volumes: []Volume{{VolumeType: "gp3", SizeGiB: 100}}},
+			{Type: "rds", InstanceType: "db.m5.large", Engine: "postgres", Volume: Volume{VolumeType: "gp3", SizeGiB: 200}},
+			{Type: "ec2", InstanceType: "m5.nonexistent"},
+		},
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	result := Price(t.Context(), fakeProvider{}, req)
+	if result.Status != types.ResponseStatusPartial || len(result.Items) != 3 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	// Three instances with a 100 GiB volume each
+	ec2 := result.Items[0]
+	if want := 3 * (0.096 + 100*0.08/types.HoursPerMonth); math.Abs(float64(ec2.HourlyCost)-want) > 1e-9 {
+		t.Fatalf("ec2 hourly = %v, want %v", ec2.HourlyCost, want)
+	}
+	if len(ec2.CostComponents) != 2 || ec2.CostComponents[0].Quantity != 3 || ec2.CostComponents[1].Name != "gp3 storage" {
+		t.Fatalf("ec2 components = %+v", ec2.CostComponents)
+	}
+
+	rds := result.Items[1]
+	if want := 0.178 + 200*0.115/types.HoursPerMonth; math.Abs(float64(rds.HourlyCost)-want) > 1e-9 || rds.Region != "us-east-1" || rds.Count != 1 {
+		t.Fatalf("rds = %+v, want hourly %v", rds, want)
+	}
+
+	if missing := result.Items[2]; missing.Error == "" || missing.HourlyCost != 0 {
+		t.Fatalf("unpriceable item = %+v", missing)
+	}
+	if math.Abs(float64(result.TotalMonthlyCost-(ec2.MonthlyCost+rds.MonthlyCost))) > 1e-9 {
+		t.Fatalf("TotalMonthlyCost = %v", result.TotalMonthlyCost)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+	}{
+		{"no items", Request{Region: "us-east-1"}},
+		{"unknown type", Request{Region: "us-east-1", Items: []Item{{Type: "s3"}}}},
+		{"no region", Request{Items: []Item{{Type: "nat"}}}},
+		{"no instance type", Request{Region: "us-east-1", Items: []Item{{Type: "ec2"}}}},
+		{"negative count", Request{Region: "us-east-1", Items: []Item{{Type: "nat", Count: -1}}}},
+		{"rds without engine", Request{Region: "us-east-1", Items: []Item{{Type: "rds", InstanceType: "db.t3.micro"}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.req.Validate(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
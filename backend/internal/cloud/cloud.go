@@ -0,0 +1,293 @@
+// Package cloud defines provider-agnostic interfaces for resource discovery
+// and pricing, and a registry that runs every registered provider.
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/johnjeffers/awscogs/backend/internal/selfcost"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Scope selects what to discover. Empty fields mean everything.
+type Scope struct {
+	Accounts      []string // Account, subscription, or project names or IDs
+	Regions       []string
+	ResourceTypes []string
+}
+
+// ResourceDiscoverer discovers costed resources for one cloud provider
+type ResourceDiscoverer interface {
+	// Provider returns the provider name, e.g. "aws" or "azure"
+	Provider() string
+
+	// ResourceTypes returns the resource type filter names this discoverer handles
+	ResourceTypes() []string
+
+	// HandlesRegion reports whether region belongs to this provider
+	HandlesRegion(region string) bool
+
+	// Discover returns resources for the scope. Regions and resource types
+	// are pre-filtered to the ones this discoverer handles.
+	Discover(ctx context.Context, scope Scope) (*types.CostResponse, error)
+}
+
+// PriceQuery identifies a priced unit. Service and SKU meanings are provider
+// specific, e.g. Service "ec2" and SKU "m5.large" for AWS.
+type PriceQuery struct {
+	Service    string
+	Region     string
+	SKU        string
+	Attributes map[string]string
+}
+
+// PriceSource looks up unit prices for one cloud provider. A provider's
+// discoverer prices resources through its own price source.
+type PriceSource interface {
+	// Provider returns the provider name, e.g. "aws" or "azure"
+	Provider() string
+
+	// HourlyPrice returns the on-demand hourly price for the query
+	HourlyPrice(ctx context.Context, query PriceQuery) (types.CostValue, error)
+}
+
+// Registry holds the discoverers for each provider
+type Registry struct {
+	mu          sync.RWMutex
+	discoverers []ResourceDiscoverer
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// RegisterDiscoverer adds a resource discoverer
+func (r *Registry) RegisterDiscoverer(d ResourceDiscoverer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.discoverers = append(r.discoverers, d)
+}
+
+// Discoverers returns the registered discoverers in registration order
+func (r *Registry) Discoverers() []ResourceDiscoverer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.discoverers)
+}
+
+// ResourceTypes returns every resource type the registered discoverers handle
+func (r *Registry) ResourceTypes() []string {
+	var resourceTypes []string
+	for _, d := range r.Discoverers() {
+		for _, rt := range d.ResourceTypes() {
+			if !slices.Contains(resourceTypes, rt) {
+				resourceTypes = append(resourceTypes, rt)
+			}
+		}
+	}
+	return resourceTypes
+}
+
+// HandlesRegion reports whether any registered discoverer handles region
+func (r *Registry) HandlesRegion(region string) bool {
+	for _, d := range r.Discoverers() {
+		if d.HandlesRegion(region) {
+			return true
+		}
+	}
+	return false
+}
+
+// Discover runs every discoverer that applies to the scope concurrently and
+// merges their results. A failing provider is reported as a diagnostic so
+// the others still return data; an error is returned only if all fail.
+func (r *Registry) Discover(ctx context.Context, scope Scope) (*types.CostResponse, error) {
+	type result struct {
+		provider string
+		response *types.CostResponse
+		err      error
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results []result
+		mu      sync.Mutex
+	)
+	for _, d := range r.Discoverers() {
+		sub, ok := scopeFor(d, scope)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := d.Discover(ctx, sub)
+			mu.Lock()
+			results = append(results, result{d.Provider(), response, err})
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	merged := &types.CostResponse{Status: types.ResponseStatusOK, Currency: "USD"}
+	var errs []error
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.provider, res.err))
+			merged.Diagnostics = append(merged.Diagnostics, types.Diagnostic{
+				Level:     "error",
+				Operation: "discover",
+				Message:   fmt.Sprintf("%s: %v", res.provider, res.err),
+			})
+			continue
+		}
+		Merge(merged, res.response)
+	}
+
+	if len(results) > 0 && len(errs) == len(results) {
+		return nil, errors.Join(errs...)
+	}
+	if len(merged.Diagnostics) > 0 && merged.Status == types.ResponseStatusOK {
+		merged.Status = types.ResponseStatusPartial
+	}
+	return merged, nil
+}
+
+// scopeFor narrows scope to the regions and resource types d handles. It
+// returns false if d has nothing to do.
+func scopeFor(d ResourceDiscoverer, scope Scope) (Scope, bool) {
+	sub := Scope{Accounts: scope.Accounts}
+
+	if len(scope.Regions) > 0 {
+		for _, region := range scope.Regions {
+			if d.HandlesRegion(region) {
+				sub.Regions = append(sub.Regions, region)
+			}
+		}
+		if len(sub.Regions) == 0 {
+			return sub, false
+		}
+	}
+
+	if len(scope.ResourceTypes) > 0 {
+		handled := d.ResourceTypes()
+		for _, rt := range scope.ResourceTypes {
+			if slices.Contains(handled, rt) {
+				sub.ResourceTypes = append(sub.ResourceTypes, rt)
+			}
+		}
+		if len(sub.ResourceTypes) == 0 {
+			return sub, false
+		}
+	}
+
+	return sub, true
+}
+
+// Merge adds src's resources, summaries, totals, and diagnostics into dst
+func Merge(dst, src *types.CostResponse) {
+	if src == nil {
+		return
+	}
+
+	dst.TotalCost = dst.TotalCost.Add(src.TotalCost)
+	dst.Diagnostics = append(dst.Diagnostics, src.Diagnostics...)
+	if src.Status == types.ResponseStatusFailed || (src.Status == types.ResponseStatusPartial && dst.Status != types.ResponseStatusFailed) {
+		dst.Status = src.Status
+	}
+
+	dst.EC2Instances = append(dst.EC2Instances, src.EC2Instances...)
+	dst.EBSVolumes = append(dst.EBSVolumes, src.EBSVolumes...)
+	dst.ECSServices = append(dst.ECSServices, src.ECSServices...)
+	dst.RDSInstances = append(dst.RDSInstances, src.RDSInstances...)
+	dst.EKSClusters = append(dst.EKSClusters, src.EKSClusters...)
+	dst.LoadBalancers = append(dst.LoadBalancers, src.LoadBalancers...)
+	dst.NATGateways = append(dst.NATGateways, src.NATGateways...)
+	dst.ElasticIPs = append(dst.ElasticIPs, src.ElasticIPs...)
+	dst.Secrets = append(dst.Secrets, src.Secrets...)
+	dst.PublicIPv4s = append(dst.PublicIPv4s, src.PublicIPv4s...)
+	dst.Lambdas = append(dst.Lambdas, src.Lambdas...)
+	dst.CapacityReservations = append(dst.CapacityReservations, src.CapacityReservations...)
+	dst.DedicatedHosts = append(dst.DedicatedHosts, src.DedicatedHosts...)
+	dst.KinesisStreams = append(dst.KinesisStreams, src.KinesisStreams...)
+	dst.FirehoseStreams = append(dst.FirehoseStreams, src.FirehoseStreams...)
+	dst.SQSQueues = append(dst.SQSQueues, src.SQSQueues...)
+	dst.SNSTopics = append(dst.SNSTopics, src.SNSTopics...)
+	dst.EventBuses = append(dst.EventBuses, src.EventBuses...)
+	dst.Alarms = append(dst.Alarms, src.Alarms...)
+	dst.Dashboards = append(dst.Dashboards, src.Dashboards...)
+	dst.CustomMetrics = append(dst.CustomMetrics, src.CustomMetrics...)
+	dst.DataTransfer = append(dst.DataTransfer, src.DataTransfer...)
+	dst.PluginResources = append(dst.PluginResources, src.PluginResources...)
+	dst.VirtualMachines = append(dst.VirtualMachines, src.VirtualMachines...)
+
+	if src.Scan != nil {
+		if dst.Scan == nil {
+			dst.Scan = &types.ScanStats{}
+		}
+		dst.Scan.Cells += src.Scan.Cells
+		dst.Scan.Cached += src.Scan.Cached
+		dst.Scan.Rescanned += src.Scan.Rescanned
+		dst.Scan.SelfCost = selfcost.Merge(dst.Scan.SelfCost, src.Scan.SelfCost)
+	}
+
+	for _, acc := range src.Accounts {
+		i := slices.IndexFunc(dst.Accounts, func(a types.AccountSummary) bool { return a.AccountID == acc.AccountID })
+		if i < 0 {
+			dst.Accounts = append(dst.Accounts, acc)
+			continue
+		}
+		mergeAccountSummary(&dst.Accounts[i], acc)
+	}
+	for _, reg := range src.Regions {
+		i := slices.IndexFunc(dst.Regions, func(r types.RegionSummary) bool { return r.Region == reg.Region })
+		if i < 0 {
+			dst.Regions = append(dst.Regions, reg)
+			continue
+		}
+		mergeRegionSummary(&dst.Regions[i], reg)
+	}
+}
+
+func mergeAccountSummary(dst *types.AccountSummary, src types.AccountSummary) {
+	dst.Counts = mergeCounts(dst.Counts, src.Counts)
+	dst.Services = mergeCosts(dst.Services, src.Services)
+	dst.Regions = mergeCosts(dst.Regions, src.Regions)
+	dst.TotalCost = dst.TotalCost.Add(src.TotalCost)
+}
+
+func mergeRegionSummary(dst *types.RegionSummary, src types.RegionSummary) {
+	dst.Counts = mergeCounts(dst.Counts, src.Counts)
+	dst.Accounts = mergeCosts(dst.Accounts, src.Accounts)
+	dst.TotalCost = dst.TotalCost.Add(src.TotalCost)
+}
+
+// mergeCosts adds the costs in src to dst's, allocating dst if needed
+func mergeCosts(dst, src map[string]types.CostValue) map[string]types.CostValue {
+	if len(src) > 0 && dst == nil {
+		dst = make(map[string]types.CostValue, len(src))
+	}
+	for key, cost := range src {
+		dst[key] = dst[key].Add(cost)
+	}
+	return dst
+}
+
+// mergeCounts adds the counts in src to dst's, allocating dst if needed
+func mergeCounts(dst, src map[string]int) map[string]int {
+	if len(src) > 0 && dst == nil {
+		dst = make(map[string]int, len(src))
+	}
+	for key, n := range src {
+		dst[key] += n
+	}
+	return dst
+}
@@ -0,0 +1,92 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+type fakeDiscoverer struct {
+	provider string
+	prefix   string
+	scope    *Scope
+	err      error
+}
+
+func (f *fakeDiscoverer) Provider() string        { return f.provider }
+func (f *fakeDiscoverer) ResourceTypes() []string { return []string{f.provider + "-type"} }
+func (f *fakeDiscoverer) HandlesRegion(region string) bool {
+	return len(region) >= len(f.prefix) && region[:len(f.prefix)] == f.prefix
+}
+
+func (f *fakeDiscoverer) Discover(ctx context.Context, scope Scope) (*types.CostResponse, error) {
+	f.scope = &scope
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &types.CostResponse{
+		Status:    types.ResponseStatusOK,
+		TotalCost: 1,
+		Accounts:  []types.AccountSummary{{AccountID: "shared", TotalCost: 1}},
+	}, nil
+}
+
+func TestRegistryDiscoverNarrowsScopeAndMerges(t *testing.T) {
+	a := &fakeDiscoverer{provider: "a", prefix: "a-"}
+	b := &fakeDiscoverer{provider: "b", prefix: "b-"}
+	c := &fakeDiscoverer{provider: "c", prefix: "c-"}
+	r := NewRegistry()
+	r.RegisterDiscoverer(a)
+	r.RegisterDiscoverer(b)
+	r.RegisterDiscoverer(c)
+
+	response, err := r.Discover(context.Background(), Scope{Regions: []string{"a-1", "b-1"}})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	if c.scope != nil {
+		t.Fatal("discoverer without matching regions should not run")
+	}
+	if len(a.scope.Regions) != 1 || a.scope.Regions[0] != "a-1" {
+		t.Fatalf("a scope = %+v", a.scope)
+	}
+	if response.TotalCost != 2 || len(response.Accounts) != 1 || response.Accounts[0].TotalCost != 2 {
+		t.Fatalf("merged response = %+v", response)
+	}
+}
+
+func TestRegistryDiscoverReportsProviderFailures(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterDiscoverer(&fakeDiscoverer{provider: "a", prefix: "a-"})
+	r.RegisterDiscoverer(&fakeDiscoverer{provider: "b", prefix: "b-", err: errors.New("boom")})
+
+	response, err := r.Discover(context.Background(), Scope{})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if response.Status != types.ResponseStatusPartial || len(response.Diagnostics) != 1 {
+		t.Fatalf("expected partial response with one diagnostic, got %+v", response)
+	}
+
+	failing := NewRegistry()
+	failing.RegisterDiscoverer(&fakeDiscoverer{provider: "b", prefix: "b-", err: errors.New("boom")})
+	if _, err := failing.Discover(context.Background(), Scope{}); err == nil {
+		t.Fatal("expected error when every provider fails")
+	}
+}
+
+func TestRegistryHandlesRegion(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterDiscoverer(&fakeDiscoverer{provider: "a", prefix: "a-"})
+	r.RegisterDiscoverer(&fakeDiscoverer{provider: "b", prefix: "b-"})
+
+	if !r.HandlesRegion("b-1") {
+		t.Error("expected b-1 to be handled")
+	}
+	if r.HandlesRegion("c-1") {
+		t.Error("expected c-1 not to be handled")
+	}
+}
@@ -0,0 +1,48 @@
+package cloud
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Progress counts the units of work in a scan, such as account and region
+// pairs, as discoverers start and finish them. A nil Progress ignores updates.
+type Progress struct {
+	total atomic.Int64
+	done  atomic.Int64
+}
+
+type progressContextKey struct{}
+
+// WithProgress returns a context whose scans report to p
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressContextKey{}, p)
+}
+
+// ProgressFrom returns the progress a scan should report to, or nil
+func ProgressFrom(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressContextKey{}).(*Progress)
+	return p
+}
+
+// Add adds n units of work to the total
+func (p *Progress) Add(n int) {
+	if p != nil {
+		p.total.Add(int64(n))
+	}
+}
+
+// Done marks one unit of work finished
+func (p *Progress) Done() {
+	if p != nil {
+		p.done.Add(1)
+	}
+}
+
+// Counts returns the finished and total units of work
+func (p *Progress) Counts() (done, total int) {
+	if p == nil {
+		return 0, 0
+	}
+	return int(p.done.Load()), int(p.total.Load())
+}
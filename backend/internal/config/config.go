@@ -1,41 +1,392 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Server  ServerConfig  `yaml:"server"`
-	AWS     AWSConfig     `yaml:"aws"`
-	Pricing PricingConfig `yaml:"pricing"`
-	Cache   CacheConfig   `yaml:"cache"`
-	Log     LogConfig     `yaml:"log"`
+	Server          ServerConfig            `yaml:"server"`
+	AWS             AWSConfig               `yaml:"aws"`
+	Azure           AzureConfig             `yaml:"azure"`
+	Pricing         PricingConfig           `yaml:"pricing"`
+	Cache           CacheConfig             `yaml:"cache"`
+	Snapshots       SnapshotConfig          `yaml:"snapshots"`
+	Budget          BudgetConfig            `yaml:"budget"`
+	Billing         BillingConfig           `yaml:"billing"`
+	AccountMetadata []AccountMetadataConfig `yaml:"accountMetadata"` // Display names and labels for account IDs
+	CostCenters     CostCenterConfig        `yaml:"costCenters"`
+	Environments    EnvironmentConfig       `yaml:"environments"`
+	Recommend       RecommendConfig         `yaml:"recommendations"`
+	Waste           WasteConfig             `yaml:"waste"`
+	Ignore          IgnoreConfig            `yaml:"ignore"`
+	CostThresholds  CostThresholdConfig     `yaml:"costThresholds"`
+	Notify          NotifyConfig            `yaml:"notifications"`
+	Export          ExportConfig            `yaml:"export"`
+	Log             LogConfig               `yaml:"log"`
+	Profiles        []ProfileConfig         `yaml:"profiles"`
+
+	Profile string `yaml:"-"` // Name of the profile this config was derived for (empty = default)
+}
+
+// ProfileConfig is a named scan profile, e.g. one AWS organization, with its
+// own accounts, regions, and role settings. Settings not given in its aws
+// section are inherited from the top-level aws section.
+type ProfileConfig struct {
+	Name string    `yaml:"name"`
+	AWS  AWSConfig `yaml:"aws"`
+
+	node *yaml.Node // Raw profile, re-decoded over the top-level aws section
+}
+
+// UnmarshalYAML keeps the raw profile so it can be applied over the top-level
+// aws section once that is fully loaded
+func (p *ProfileConfig) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		Name string `yaml:"name"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	p.Name = raw.Name
+	p.node = node
+	return nil
 }
 
 // ServerConfig holds HTTP server settings
 type ServerConfig struct {
-	Port int `yaml:"port"`
+	Port      int                   `yaml:"port"`
+	BasePath  string                `yaml:"basePath"`  // URL prefix when served behind a reverse proxy path (e.g. /awscogs)
+	DebugAddr string                `yaml:"debugAddr"` // Address for the pprof and runtime stats server, e.g. localhost:6060 (empty = disabled)
+	CORS      CORSConfig            `yaml:"cors"`
+	Headers   SecurityHeadersConfig `yaml:"securityHeaders"`
+	RateLimit APIRateLimitConfig    `yaml:"rateLimit"`
+
+	// BindAddress is a comma-separated list of addresses to listen on: IP
+	// addresses, host names, or network interface names such as eth0, each
+	// with an optional port (default Port), e.g. "127.0.0.1" or
+	// "::1,10.0.0.5:9090". Empty listens on Port on all interfaces.
+	BindAddress string `yaml:"bindAddress"`
+
+	// SocketPath serves the API on a Unix domain socket at this path instead
+	// of TCP, with SocketMode permissions (octal, default 0660)
+	SocketPath string `yaml:"socketPath"`
+	SocketMode string `yaml:"socketMode"`
+
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For or X-Real-IP,
+	// for logging and rate limiting. Only enable behind a proxy that sets them.
+	TrustProxyHeaders bool `yaml:"trustProxyHeaders"`
+
+	// ScanOnStartup runs a full scan in the background as soon as the server
+	// starts, so the first dashboard load after a deploy is served from cache.
+	// BlockUntilFirstScan makes /health/ready return 503 until a snapshot
+	// exists, so a rollout waits for it. It requires ScanOnStartup.
+	ScanOnStartup       bool `yaml:"scanOnStartup"`
+	BlockUntilFirstScan bool `yaml:"blockUntilFirstScan"`
+
+	ScanJobs ScanJobsConfig `yaml:"scanJobs"`
+
+	// ShutdownDrainSeconds is how long shutdown waits for scans in progress
+	// to finish before cancelling them. Cancelled scans still record what
+	// they found so far as a partial snapshot. 0 cancels them right away.
+	ShutdownDrainSeconds int `yaml:"shutdownDrainSeconds"`
 }
 
+// Scan job overlap policies
+const (
+	ScanOverlapReject   = "reject"   // Refuse the new scan
+	ScanOverlapQueue    = "queue"    // Start the new scan once the running one finishes
+	ScanOverlapCoalesce = "coalesce" // Return the running job if it has the same scope, otherwise queue
+)
+
+// ScanJobsConfig controls scans started with the job API while others are
+// running
+type ScanJobsConfig struct {
+	// OverlapPolicy is what happens to a scan requested while another with
+	// an overlapping scope (a shared account, region, and resource type) is
+	// queued or running: reject, queue, or coalesce
+	OverlapPolicy string `yaml:"overlapPolicy"`
+	MaxConcurrent int    `yaml:"maxConcurrent"` // Scans run at once; more are queued (0 = unlimited)
+	MaxQueued     int    `yaml:"maxQueued"`     // Scans waiting to run; more are rejected
+}
+
+func (c ScanJobsConfig) validate() error {
+	switch c.OverlapPolicy {
+	case ScanOverlapReject, ScanOverlapQueue, ScanOverlapCoalesce:
+	default:
+		return fmt.Errorf("invalid scan overlap policy %q (valid: reject, queue, coalesce)", c.OverlapPolicy)
+	}
+	if c.MaxConcurrent < 0 {
+		return fmt.Errorf("max concurrent scans cannot be negative")
+	}
+	if c.MaxQueued < 1 {
+		return fmt.Errorf("max queued scans must be at least 1")
+	}
+	return nil
+}
+
+// APIRateLimitConfig holds a token-bucket limit on API requests from each
+// client, identified by IP address or by KeyHeader. Clients choose what they
+// send in KeyHeader, so its value is only used if it's one of Keys or
+// TrustKeyHeader is set; otherwise the client is identified by IP address.
+type APIRateLimitConfig struct {
+	RequestsPerMinute int      `yaml:"requestsPerMinute"` // Sustained requests per minute per client (0 = unlimited)
+	Burst             int      `yaml:"burst"`             // Requests allowed at once before limiting (0 = requestsPerMinute)
+	KeyHeader         string   `yaml:"keyHeader"`         // Header identifying the client, e.g. X-API-Key set by an auth proxy (empty = client IP)
+	Keys              []string `yaml:"keys"`              // KeyHeader values that identify a client
+	TrustKeyHeader    bool     `yaml:"trustKeyHeader"`    // Use any KeyHeader value; only set behind a proxy that sets the header itself
+}
+
+// CORSConfig controls which browser origins may call the API from another
+// site. The embedded frontend is same-origin and doesn't need CORS.
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowedOrigins"`   // e.g. https://finops.example.com or https://*.example.com ("*" = any, empty = same origin only)
+	AllowedHeaders   []string `yaml:"allowedHeaders"`   // Request headers cross-origin callers may send
+	AllowCredentials bool     `yaml:"allowCredentials"` // Allow cookies and Authorization on cross-origin requests (not with "*")
+}
+
+// SecurityHeadersConfig holds the security headers added to every response
+type SecurityHeadersConfig struct {
+	Enabled               bool   `yaml:"enabled"`
+	HSTSMaxAgeSeconds     int    `yaml:"hstsMaxAgeSeconds"`     // Strict-Transport-Security max-age (0 = not sent; only set when served over HTTPS)
+	ContentSecurityPolicy string `yaml:"contentSecurityPolicy"` // Policy for the frontend (empty = not sent)
+}
+
+// DefaultContentSecurityPolicy allows the frontend to load only its own
+// scripts and call only its own API
+const DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'; base-uri 'self'; form-action 'self'"
+
 // AWSConfig holds AWS account and region settings
 type AWSConfig struct {
-	DiscoverAccounts bool            `yaml:"discoverAccounts"` // Auto-discover accounts from Organizations
-	DiscoverRegions  bool            `yaml:"discoverRegions"`  // Auto-discover enabled regions
-	AssumeRoleName   string          `yaml:"assumeRoleName"`   // Role name to assume into each account
-	Accounts         []AccountConfig `yaml:"accounts"`         // Manual account list (used if discoverAccounts is false)
-	Regions          []string        `yaml:"regions"`          // Manual region list (used if discoverRegions is false)
-	GovCloud         GovCloudConfig  `yaml:"govcloud"`         // GovCloud partition settings
+	DiscoverAccounts bool               `yaml:"discoverAccounts"` // Auto-discover accounts from Organizations
+	DiscoverRegions  bool               `yaml:"discoverRegions"`  // Auto-discover enabled regions
+	AssumeRoleName   string             `yaml:"assumeRoleName"`   // Role name to assume into each account
+	Accounts         []AccountConfig    `yaml:"accounts"`         // Manual account list (used if discoverAccounts is false)
+	Regions          []string           `yaml:"regions"`          // Manual region list (used if discoverRegions is false)
+	Services         []string           `yaml:"services"`         // Resource types to discover (empty = all)
+	GovCloud         GovCloudConfig     `yaml:"govcloud"`         // GovCloud partition settings
+	RateLimit        RateLimitConfig    `yaml:"rateLimit"`        // Limits on AWS API calls per account and region
+	STS              STSConfig          `yaml:"sts"`              // How roles are assumed into each account
+	QuickScan        bool               `yaml:"quickScan"`        // Scan only the caller's account in the default region; see EnableQuickScan
+	CloudTrail       CloudTrailConfig   `yaml:"cloudTrail"`       // Who created each resource
+	Plugins          []PluginConfig     `yaml:"plugins"`          // External discoverers for resource types awsCOGS doesn't cover
+	DataTransfer     DataTransferConfig `yaml:"dataTransfer"`     // Estimated EC2 and NAT gateway data transfer costs
+	Messaging        MessagingConfig    `yaml:"messaging"`        // Request rates for SQS, SNS, and EventBridge cost estimates
+	CloudWatch       CloudWatchConfig   `yaml:"cloudWatch"`       // Monthly rates for CloudWatch alarms, dashboards, and custom metrics
+	EndpointURL      string             `yaml:"endpointUrl"`      // Send every discovery API call here, e.g. LocalStack (empty = AWS)
+	// StoppedInstanceStorage sets each stopped EC2 instance's
+	// attachedStorageCost to the cost of its attached EBS volumes
+	StoppedInstanceStorage bool `yaml:"stoppedInstanceStorage"`
+	// ProbeExcludedRegions checks the enabled regions a full scan leaves out
+	// for chargeable resources, and warns about the ones that have any
+	ProbeExcludedRegions bool `yaml:"probeExcludedRegions"`
+}
+
+// DataTransferResourceType is the resource type of estimated data transfer
+// costs, discovered only when DataTransferConfig.Enabled is set
+const DataTransferResourceType = "datatransfer"
+
+// DataTransferConfig holds the assumptions data transfer costs are estimated
+// with. CloudWatch reports how much an instance sends, but not where to, so
+// the share that leaves AWS or crosses Availability Zones is assumed. Rates
+// are per GB and the same in every region.
+type DataTransferConfig struct {
+	Enabled         bool    `yaml:"enabled"`
+	InternetPercent float64 `yaml:"internetPercent"` // Share of EC2 NetworkOut sent to the internet
+	InterAZPercent  float64 `yaml:"interAzPercent"`  // Share of EC2 NetworkOut sent to another Availability Zone
+	InternetPerGB   float64 `yaml:"internetPerGb"`   // Internet egress rate
+	InterAZPerGB    float64 `yaml:"interAzPerGb"`    // Inter-AZ rate, charged on both sides, so twice the per-direction rate
+	NATPerGB        float64 `yaml:"natPerGb"`        // NAT gateway data processing rate
+}
+
+// PluginConfig is an external discoverer for one resource type, either a
+// sidecar HTTP service or a Go plugin
+type PluginConfig struct {
+	Name            string   `yaml:"name"`            // Resource type, used in services filters and reports, e.g. mwaa
+	Scope           string   `yaml:"scope"`           // "regional" (default) or "global"
+	URL             string   `yaml:"url"`             // Sidecar endpoint that scans an account and region
+	Path            string   `yaml:"path"`            // Go plugin (.so) exporting Discover
+	IAMActions      []string `yaml:"iamActions"`      // Actions the plugin needs, added to the generated IAM policy
+	TimeoutSeconds  int      `yaml:"timeoutSeconds"`  // Sidecar request timeout (0 = 60)
+	SendCredentials bool     `yaml:"sendCredentials"` // Send the account's temporary credentials to the sidecar
+}
+
+// CloudTrailConfig holds settings for looking up who created each resource
+// in CloudTrail event history
+type CloudTrailConfig struct {
+	LookupOwners bool `yaml:"lookupOwners"` // Set each resource's owner from its creation event
+	LookbackDays int  `yaml:"lookbackDays"` // How far back to search, at most CloudTrail's 90 days
+}
+
+// STSConfig holds settings for assuming roles into scanned accounts
+type STSConfig struct {
+	Region         string `yaml:"region"`         // Region whose STS endpoint assumes roles (empty = the region being scanned)
+	GlobalEndpoint bool   `yaml:"globalEndpoint"` // Use the global sts.amazonaws.com endpoint (commercial partition only)
+	HubRoleARN     string `yaml:"hubRoleArn"`     // Role assumed first, then used to assume each account's role (empty = no chaining)
+}
+
+// RateLimitConfig holds a token-bucket limit on AWS API calls made in each
+// account and region
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"` // Sustained calls per second (0 = unlimited)
+	Burst             int     `yaml:"burst"`             // Calls allowed at once before throttling (0 = requestsPerSecond, at least 1)
+}
+
+// AWSResourceTypes lists every AWS resource type awsCOGS can discover
+var AWSResourceTypes = []string{"ec2", "ebs", "ecs", "rds", "eks", "elb", "nat", "eip", "secrets", "publicipv4", "lambda", "capacityreservation", "dedicatedhost", "kinesis", "firehose", "sqs", "sns", "eventbridge", "alarm", "dashboard", "custommetrics"}
+
+// ResourceTypes returns the built-in resource types, data transfer if it's
+// enabled, and the plugins' resource types
+func (a AWSConfig) ResourceTypes() []string {
+	types := slices.Clone(AWSResourceTypes)
+	if a.DataTransfer.Enabled {
+		types = append(types, DataTransferResourceType)
+	}
+	for _, p := range a.Plugins {
+		types = append(types, p.Name)
+	}
+	return types
+}
+
+// EnabledServices returns the resource types to discover
+func (a AWSConfig) EnabledServices() []string {
+	if len(a.Services) == 0 {
+		return a.ResourceTypes()
+	}
+	return a.Services
+}
+
+var pluginNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+// validateEndpointURL checks that a custom endpoint is an absolute HTTP URL
+func (a AWSConfig) validateEndpointURL() error {
+	if a.EndpointURL == "" {
+		return nil
+	}
+	u, err := url.Parse(a.EndpointURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid AWS endpoint URL %q: must be an http or https URL", a.EndpointURL)
+	}
+	return nil
+}
+
+// validatePlugins checks the plugin discoverers in a
+func (a AWSConfig) validatePlugins() error {
+	seen := make(map[string]bool)
+	for i, p := range a.Plugins {
+		if !pluginNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("plugin %d: invalid name %q: use lowercase letters and digits", i+1, p.Name)
+		}
+		if slices.Contains(AWSResourceTypes, p.Name) || p.Name == DataTransferResourceType || p.Name == "vm" {
+			return fmt.Errorf("plugin %q: name is a built-in resource type", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate plugin %q", p.Name)
+		}
+		seen[p.Name] = true
+		if (p.URL == "") == (p.Path == "") {
+			return fmt.Errorf("plugin %q needs exactly one of url or path", p.Name)
+		}
+		if p.URL != "" && !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://") {
+			return fmt.Errorf("plugin %q: invalid url %q", p.Name, p.URL)
+		}
+		if p.SendCredentials && p.URL != "" && !secureSidecarURL(p.URL) {
+			return fmt.Errorf("plugin %q: sendCredentials needs an https url unless the sidecar is on a loopback address", p.Name)
+		}
+		if p.Scope != "" && p.Scope != "regional" && p.Scope != "global" {
+			return fmt.Errorf("plugin %q: invalid scope %q (valid: regional, global)", p.Name, p.Scope)
+		}
+		if p.TimeoutSeconds < 0 {
+			return fmt.Errorf("plugin %q: timeout cannot be negative", p.Name)
+		}
+	}
+	return nil
+}
+
+// secureSidecarURL reports whether credentials can be sent to rawURL: it's
+// https, or http to localhost or a loopback address
+func secureSidecarURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "https" {
+		return true
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// MessagingConfig holds the request rates SQS queue, SNS topic, and
+// EventBridge bus costs are estimated with, in USD per million. The free
+// tiers aren't deducted, since they're shared by the whole account.
+type MessagingConfig struct {
+	SQSPerMillion         float64 `yaml:"sqsPerMillion"`         // Standard queue requests
+	SQSFIFOPerMillion     float64 `yaml:"sqsFifoPerMillion"`     // FIFO queue requests
+	SNSPerMillion         float64 `yaml:"snsPerMillion"`         // Topic publishes
+	EventBridgePerMillion float64 `yaml:"eventBridgePerMillion"` // Custom events put on an event bus
+}
+
+// validate checks the messaging request rates
+func (m MessagingConfig) validate() error {
+	if m.SQSPerMillion < 0 || m.SQSFIFOPerMillion < 0 || m.SNSPerMillion < 0 || m.EventBridgePerMillion < 0 {
+		return fmt.Errorf("messaging rates cannot be negative")
+	}
+	return nil
+}
+
+// CloudWatchConfig holds CloudWatch's monthly rates, in USD. Alarms are
+// billed per metric they watch. Custom metrics are at the first volume tier,
+// and the free tier isn't deducted.
+type CloudWatchConfig struct {
+	AlarmPerMonth               float64 `yaml:"alarmPerMonth"`               // Standard resolution alarm metric
+	HighResolutionAlarmPerMonth float64 `yaml:"highResolutionAlarmPerMonth"` // High resolution (under a minute) alarm metric
+	CompositeAlarmPerMonth      float64 `yaml:"compositeAlarmPerMonth"`
+	DashboardPerMonth           float64 `yaml:"dashboardPerMonth"`
+	MetricPerMonth              float64 `yaml:"metricPerMonth"` // Custom metric
+}
+
+// validate checks the CloudWatch rates
+func (c CloudWatchConfig) validate() error {
+	if c.AlarmPerMonth < 0 || c.HighResolutionAlarmPerMonth < 0 || c.CompositeAlarmPerMonth < 0 || c.DashboardPerMonth < 0 || c.MetricPerMonth < 0 {
+		return fmt.Errorf("cloudWatch rates cannot be negative")
+	}
+	return nil
+}
+
+// validate checks the data transfer assumptions
+func (d DataTransferConfig) validate() error {
+	if d.InternetPercent < 0 || d.InterAZPercent < 0 || d.InternetPercent+d.InterAZPercent > 100 {
+		return fmt.Errorf("data transfer internetPercent and interAzPercent must be positive and add up to at most 100")
+	}
+	if d.InternetPerGB < 0 || d.InterAZPerGB < 0 || d.NATPerGB < 0 {
+		return fmt.Errorf("data transfer rates cannot be negative")
+	}
+	return nil
 }
 
 // GovCloudConfig holds settings for the AWS GovCloud partition
 type GovCloudConfig struct {
-	Enabled          bool            `yaml:"enabled"`          // Effective GovCloud flag; requires AWSCOGS_ENABLE_GOVCLOUD
+	Enabled          bool            `yaml:"enabled" env:"-"`  // Effective GovCloud flag; requires AWSCOGS_ENABLE_GOVCLOUD
 	DiscoverAccounts bool            `yaml:"discoverAccounts"` // Auto-discover GovCloud accounts from Organizations
 	DiscoverRegions  bool            `yaml:"discoverRegions"`  // Auto-discover enabled GovCloud regions
 	Regions          []string        `yaml:"regions"`          // Explicit GovCloud region list
@@ -43,6 +394,13 @@ type GovCloudConfig struct {
 	AssumeRoleName   string          `yaml:"assumeRoleName"`   // Role name for GovCloud Organizations assume role
 }
 
+// AzureConfig holds settings for EXPERIMENTAL Azure VM discovery.
+// Credentials come from AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET.
+type AzureConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	Subscriptions []string `yaml:"subscriptions"` // Subscription IDs to scan (empty = all visible)
+}
+
 // AccountConfig defines how to connect to a specific AWS account
 type AccountConfig struct {
 	Name    string `yaml:"name"`
@@ -51,19 +409,506 @@ type AccountConfig struct {
 
 // PricingConfig holds AWS pricing settings
 type PricingConfig struct {
-	RefreshIntervalMinutes int `yaml:"refreshIntervalMinutes"`
-	RateLimitPerSecond     int `yaml:"rateLimitPerSecond"` // Max pricing API calls per second (0 = unlimited)
+	RefreshIntervalMinutes int                 `yaml:"refreshIntervalMinutes"`
+	RateLimitPerSecond     int                 `yaml:"rateLimitPerSecond"` // Max pricing API calls per second (0 = unlimited)
+	WarmFile               string              `yaml:"warmFile"`           // File to persist seen price lookups in for pre-warming (empty = memory only)
+	CacheMaxEntries        int                 `yaml:"cacheMaxEntries"`    // Price lookups to cache before evicting the least recently used (0 = unlimited)
+	Overrides              PriceOverrideConfig `yaml:"overrides"`          // Negotiated prices applied over the Pricing API's list prices
+	MissingPrice           string              `yaml:"missingPrice"`       // How resources whose price lookup failed are costed
+	Retry                  PriceRetryConfig    `yaml:"retry"`              // Background retries of failed price lookups
+}
+
+// PriceRetryConfig controls how failed price lookups, e.g. throttled ones,
+// are retried in the background. Each retry waits twice as long as the one
+// before, up to an hour.
+type PriceRetryConfig struct {
+	IntervalSeconds int `yaml:"intervalSeconds"` // Wait before the first retry
+	MaxAttempts     int `yaml:"maxAttempts"`     // Retries before giving up on a lookup (0 = never retry)
+}
+
+// Ways resources whose price lookup failed are costed
+const (
+	MissingPriceZero     = "zero"                 // Counted at $0
+	MissingPriceOmit     = "omit"                 // Left out of the response and its totals
+	MissingPriceEstimate = "estimate-from-family" // EC2 and RDS instances priced from other sizes of their family; others at $0
+	MissingPriceUnknown  = "mark-unknown"         // Counted at $0, with a diagnostic saying the total is understated
+)
+
+// MissingPricePolicies lists the valid pricing.missingPrice values
+var MissingPricePolicies = []string{MissingPriceZero, MissingPriceOmit, MissingPriceEstimate, MissingPriceUnknown}
+
+// PriceOverrideConfig holds prices an operator pins over the Pricing API's
+// list prices
+type PriceOverrideConfig struct {
+	DiscountPercent float64      `yaml:"discountPercent"` // Discount off every list price, e.g. an EDP discount (0 = none)
+	Rates           []PinnedRate `yaml:"rates"`           // Hourly rates used instead of the list price, and not discounted
+}
+
+// PinnedRate is a negotiated hourly rate for an instance type or class
+type PinnedRate struct {
+	Service string  `yaml:"service"` // ec2 or rds
+	SKU     string  `yaml:"sku"`     // EC2 instance type or RDS instance class, e.g. m5.large or db.r6g.xlarge
+	Region  string  `yaml:"region"`  // Region the rate applies in (empty = every region)
+	Hourly  float64 `yaml:"hourly"`  // Price per hour; for RDS, per single-AZ instance
 }
 
+// PinnedRateServices lists the services rates can be pinned for
+var PinnedRateServices = []string{"ec2", "rds"}
+
 // CacheConfig holds cache settings
 type CacheConfig struct {
 	ResourceTTLMinutes int `yaml:"resourceTTLMinutes"` // TTL for resource discovery cache
 	AccountTTLMinutes  int `yaml:"accountTTLMinutes"`  // TTL for account/region discovery cache
+	ResultTTLMinutes   int `yaml:"resultTTLMinutes"`   // TTL for complete scan results per filter set (0 = disabled)
+}
+
+// SnapshotConfig holds settings for the cost snapshot history
+type SnapshotConfig struct {
+	Dir         string `yaml:"dir"`         // Directory to persist snapshots in (empty = memory only)
+	MaxCount    int    `yaml:"maxCount"`    // Maximum number of snapshots to keep
+	MaxImportMB int    `yaml:"maxImportMB"` // Largest archive to import, compressed or uncompressed
+
+	Retention RetentionConfig `yaml:"retention"`
 }
 
+// RetentionConfig downsamples the snapshot history as it ages. Each snapshot
+// is kept for RawHours, then only the last one of each hour for HourlyDays,
+// of each day for DailyDays, and of each month for MonthlyMonths, counting
+// from when it was taken. Windows left at 0 are skipped, and snapshots older
+// than the longest window are deleted. With every window at 0, snapshots are
+// only limited by MaxCount.
+type RetentionConfig struct {
+	RawHours      int `yaml:"rawHours"`      // Keep every snapshot this long
+	HourlyDays    int `yaml:"hourlyDays"`    // Then one per hour
+	DailyDays     int `yaml:"dailyDays"`     // Then one per day
+	MonthlyMonths int `yaml:"monthlyMonths"` // Then one per month
+}
+
+// Enabled reports whether any retention window is set
+func (r RetentionConfig) Enabled() bool {
+	return r.RawHours > 0 || r.HourlyDays > 0 || r.DailyDays > 0 || r.MonthlyMonths > 0
+}
+
+func (r RetentionConfig) validate() error {
+	if r.RawHours < 0 || r.HourlyDays < 0 || r.DailyDays < 0 || r.MonthlyMonths < 0 {
+		return fmt.Errorf("snapshot retention windows cannot be negative")
+	}
+	// Each window must outlast the ones before it, or it would keep nothing
+	windows := []struct {
+		name  string
+		hours int
+	}{
+		{"rawHours", r.RawHours},
+		{"hourlyDays", r.HourlyDays * 24},
+		{"dailyDays", r.DailyDays * 24},
+		{"monthlyMonths", r.MonthlyMonths * 24 * 31},
+	}
+	var prev string
+	var prevHours int
+	for _, w := range windows {
+		if w.hours == 0 {
+			continue
+		}
+		if w.hours <= prevHours {
+			return fmt.Errorf("snapshot retention %s must be longer than %s", w.name, prev)
+		}
+		prev, prevHours = w.name, w.hours
+	}
+	return nil
+}
+
+// BudgetConfig holds monthly cost budgets used in digests and alerts
+type BudgetConfig struct {
+	Monthly     float64            `yaml:"monthly"`     // Total monthly budget in USD (0 = no budget)
+	Accounts    map[string]float64 `yaml:"accounts"`    // Monthly budgets keyed by account name or ID
+	WarnPercent float64            `yaml:"warnPercent"` // Percent of budget at which status becomes "warning"
+}
+
+// BillingConfig holds account-level discounts and credits. They're applied to
+// totals, so resources keep their list prices.
+type BillingConfig struct {
+	DiscountPercent float64 `yaml:"discountPercent"` // Blanket discount off the bill, e.g. an EDP (0 = none)
+	MonthlyCredits  float64 `yaml:"monthlyCredits"`  // Credits applied to each month's bill, in USD (0 = none)
+}
+
+// AccountMetadataConfig labels an account for display. Entries saved
+// through the API replace the configured entry for the same account.
+type AccountMetadataConfig struct {
+	ID          string `yaml:"id"`          // 12-digit AWS account ID
+	DisplayName string `yaml:"displayName"` // Shown instead of the account name or alias
+	Environment string `yaml:"environment"` // e.g. prod, staging, dev, sandbox
+	OwnerEmail  string `yaml:"ownerEmail"`
+	Color       string `yaml:"color"` // #rrggbb, used for the account in charts
+}
+
+var (
+	accountIDPattern   = regexp.MustCompile(`^[0-9]{12}$`)
+	environmentPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	colorPattern       = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// Validate checks an account's ID and labels
+func (m AccountMetadataConfig) Validate() error {
+	if !accountIDPattern.MatchString(m.ID) {
+		return fmt.Errorf("account ID %q must be 12 digits", m.ID)
+	}
+	if len(m.DisplayName) > 100 {
+		return fmt.Errorf("account %s: display name must be at most 100 characters", m.ID)
+	}
+	if m.Environment != "" && !environmentPattern.MatchString(m.Environment) {
+		return fmt.Errorf("account %s: environment %q must be lowercase letters, digits, and hyphens", m.ID, m.Environment)
+	}
+	if m.OwnerEmail != "" {
+		if _, err := mail.ParseAddress(m.OwnerEmail); err != nil || strings.ContainsAny(m.OwnerEmail, "<> ") {
+			return fmt.Errorf("account %s: invalid owner email %q", m.ID, m.OwnerEmail)
+		}
+	}
+	if m.Color != "" && !colorPattern.MatchString(m.Color) {
+		return fmt.Errorf("account %s: color %q must be #rrggbb", m.ID, m.Color)
+	}
+	return nil
+}
+
+// CostCenterConfig maps resources to cost centers. Rules are tried in order
+// and the first match wins; resources no rule matches are unallocated.
+type CostCenterConfig struct {
+	Rules       []CostCenterRule `yaml:"rules"`
+	SharedCosts []SharedCostRule `yaml:"sharedCosts"` // Shared resources whose cost chargeback reports split
+}
+
+// CostCenterRule assigns a cost center to resources in any of its accounts
+// that carry all of its tags. An empty list or map matches everything.
+type CostCenterRule struct {
+	CostCenter string            `yaml:"costCenter"`
+	Accounts   []string          `yaml:"accounts"` // Account or subscription IDs or names
+	Tags       map[string]string `yaml:"tags"`     // Keys and values are case-insensitive; "*" matches any value
+}
+
+// Ways a shared cost can be split among cost centers
+const (
+	SplitProportional = "proportional" // By each cost center's direct cost
+	SplitEven         = "even"
+)
+
+// SharedCostRule marks resources of some types as shared, such as NAT
+// gateways or EKS control planes, so chargeback reports split their cost
+// among the cost centers that use them instead of charging one owner
+type SharedCostRule struct {
+	ResourceTypes []string `yaml:"resourceTypes"` // e.g. nat, eks
+	CostCenters   []string `yaml:"costCenters"`   // Only resources in these cost centers (empty = any)
+	Split         string   `yaml:"split"`         // proportional (default) or even
+}
+
+func (c CostCenterConfig) validate() error {
+	for i, rule := range c.Rules {
+		if strings.TrimSpace(rule.CostCenter) == "" {
+			return fmt.Errorf("cost center rule %d has no costCenter", i+1)
+		}
+		if len(rule.Accounts) == 0 && len(rule.Tags) == 0 {
+			return fmt.Errorf("cost center rule %d (%s) needs accounts or tags", i+1, rule.CostCenter)
+		}
+	}
+	for i, rule := range c.SharedCosts {
+		if len(rule.ResourceTypes) == 0 {
+			return fmt.Errorf("shared cost rule %d needs resourceTypes", i+1)
+		}
+		for _, rt := range rule.ResourceTypes {
+			if !slices.Contains(AWSResourceTypes, rt) && rt != "vm" {
+				return fmt.Errorf("shared cost rule %d: unknown resource type %q", i+1, rt)
+			}
+		}
+		if rule.Split != "" && rule.Split != SplitProportional && rule.Split != SplitEven {
+			return fmt.Errorf("invalid shared cost split %q (valid: %s, %s)", rule.Split, SplitProportional, SplitEven)
+		}
+	}
+	return nil
+}
+
+// EnvironmentConfig classifies resources into environments such as prod and
+// dev. A resource's environment comes from the first rule it matches, then
+// its environment tags, then its account's metadata.
+type EnvironmentConfig struct {
+	Rules      []EnvironmentRule `yaml:"rules"`
+	Tags       []string          `yaml:"tags"`       // Tag keys naming a resource's environment, case-insensitive
+	Production []string          `yaml:"production"` // Environments counted as production
+}
+
+// EnvironmentRule assigns an environment to resources in any of its accounts
+// that carry all of its tags, like a cost center rule
+type EnvironmentRule struct {
+	Environment string            `yaml:"environment"`
+	Accounts    []string          `yaml:"accounts"` // Account or subscription IDs or names
+	Tags        map[string]string `yaml:"tags"`     // Keys and values are case-insensitive; "*" matches any value
+}
+
+func (c EnvironmentConfig) validate() error {
+	for i, rule := range c.Rules {
+		if !environmentPattern.MatchString(rule.Environment) {
+			return fmt.Errorf("environment rule %d: environment %q must be lowercase letters, digits, and dashes", i+1, rule.Environment)
+		}
+		if len(rule.Accounts) == 0 && len(rule.Tags) == 0 {
+			return fmt.Errorf("environment rule %d (%s) needs accounts or tags", i+1, rule.Environment)
+		}
+	}
+	for _, env := range c.Production {
+		if !environmentPattern.MatchString(env) {
+			return fmt.Errorf("production environment %q must be lowercase letters, digits, and dashes", env)
+		}
+	}
+	return nil
+}
+
+// WasteConfig holds thresholds for the waste report
+type WasteConfig struct {
+	UnusedSecretDays    int `yaml:"unusedSecretDays"`    // Days without a read before a secret is flagged (0 = never flag)
+	StoppedInstanceDays int `yaml:"stoppedInstanceDays"` // Days stopped before an EC2 instance is flagged (0 = never flag)
+}
+
+// IgnoreConfig excludes known exceptions, such as standby databases kept for
+// disaster recovery, from totals and the waste report. Their cost is
+// reported separately.
+type IgnoreConfig struct {
+	Tag   string       `yaml:"tag"` // Resources with this tag set to "true" are ignored (empty = no tag)
+	Rules []IgnoreRule `yaml:"rules"`
+}
+
+// IgnoreRule ignores resources that match any of its resource patterns, are
+// of any of its types, are in any of its accounts, and carry all of its tags.
+// An empty list or map matches everything.
+type IgnoreRule struct {
+	Reason        string            `yaml:"reason"`        // Why the resources are ignored, e.g. "DR standby"
+	Resources     []string          `yaml:"resources"`     // Resource IDs or names; "*" matches any characters
+	ResourceTypes []string          `yaml:"resourceTypes"` // e.g. rds, ec2
+	Accounts      []string          `yaml:"accounts"`      // Account or subscription IDs or names
+	Tags          map[string]string `yaml:"tags"`          // Keys and values are case-insensitive; "*" matches any value
+}
+
+// Validate checks that the rule says why it ignores resources and which ones
+func (r IgnoreRule) Validate() error {
+	if strings.TrimSpace(r.Reason) == "" {
+		return fmt.Errorf("needs a reason")
+	}
+	if len(r.Resources) == 0 && len(r.Tags) == 0 {
+		return fmt.Errorf("needs resources or tags")
+	}
+	for _, rt := range r.ResourceTypes {
+		if !slices.Contains(AWSResourceTypes, rt) && rt != "vm" {
+			return fmt.Errorf("unknown resource type %q", rt)
+		}
+	}
+	return nil
+}
+
+func (c IgnoreConfig) validate() error {
+	for i, rule := range c.Rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("ignore rule %d %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// CostThresholdConfig flags unusually expensive resources as high cost
+type CostThresholdConfig struct {
+	Hourly        float64            `yaml:"hourly"`        // Hourly cost over which any resource is high cost (0 = none)
+	ResourceTypes map[string]float64 `yaml:"resourceTypes"` // Limits by resource type, e.g. rds: 2, in place of hourly (0 = none)
+}
+
+// Limit returns the hourly cost over which a resource of resourceType is high
+// cost, or 0 if none is
+func (c CostThresholdConfig) Limit(resourceType string) float64 {
+	if limit, ok := c.ResourceTypes[resourceType]; ok {
+		return limit
+	}
+	return c.Hourly
+}
+
+func (c CostThresholdConfig) validate() error {
+	if c.Hourly < 0 {
+		return fmt.Errorf("costThresholds.hourly cannot be negative")
+	}
+	for rt, limit := range c.ResourceTypes {
+		if !slices.Contains(AWSResourceTypes, rt) && rt != "vm" {
+			return fmt.Errorf("costThresholds.resourceTypes: unknown resource type %q", rt)
+		}
+		if limit < 0 {
+			return fmt.Errorf("costThresholds.resourceTypes.%s cannot be negative", rt)
+		}
+	}
+	return nil
+}
+
+// RecommendConfig holds settings for savings recommendations
+type RecommendConfig struct {
+	OffHours OffHoursConfig `yaml:"offHours"`
+}
+
+// OffHoursConfig holds the schedule non-production instances and databases
+// would run on, and the tags that mark a resource as non-production
+type OffHoursConfig struct {
+	Weekdays            []string `yaml:"weekdays"`            // Days resources run, e.g. monday
+	StartHour           int      `yaml:"startHour"`           // Hour resources start, 0-23
+	EndHour             int      `yaml:"endHour"`             // Hour resources stop, 1-24
+	EnvironmentTags     []string `yaml:"environmentTags"`     // Tag keys naming a resource's environment, case-insensitive
+	NonProductionValues []string `yaml:"nonProductionValues"` // Environment tag values that can be stopped, case-insensitive
+}
+
+// ScheduledHoursPerWeek returns how many hours a week resources run on the
+// schedule
+func (o OffHoursConfig) ScheduledHoursPerWeek() int {
+	return len(o.Weekdays) * (o.EndHour - o.StartHour)
+}
+
+func (o OffHoursConfig) validate() error {
+	if o.StartHour < 0 || o.EndHour > 24 || o.StartHour >= o.EndHour {
+		return fmt.Errorf("invalid off-hours schedule: start hour %d must be before end hour %d, within 0-24", o.StartHour, o.EndHour)
+	}
+	seen := make(map[string]bool)
+	for _, day := range o.Weekdays {
+		day = strings.ToLower(day)
+		if _, ok := Weekdays[day]; !ok {
+			return fmt.Errorf("invalid off-hours weekday: %s", day)
+		}
+		if seen[day] {
+			return fmt.Errorf("duplicate off-hours weekday: %s", day)
+		}
+		seen[day] = true
+	}
+	return nil
+}
+
+// NotifyConfig holds notification sink and event settings
+type NotifyConfig struct {
+	Sinks                   []SinkConfig       `yaml:"sinks"`
+	ExpensiveResourceHourly float64            `yaml:"expensiveResourceHourly"` // Notify when a new resource costs more than this per hour (0 = disabled)
+	AnomalyPercent          float64            `yaml:"anomalyPercent"`          // Notify when total cost rises more than this percent between scans (0 = disabled)
+	WeeklyDigest            WeeklyDigestConfig `yaml:"weeklyDigest"`
+	MaxAttempts             int                `yaml:"maxAttempts"`         // Delivery attempts per notification
+	RetryBackoffSeconds     int                `yaml:"retryBackoffSeconds"` // Initial retry delay, doubled after each attempt
+}
+
+// SinkConfig defines a notification destination
+type SinkConfig struct {
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"`     // webhook, slack, sns
+	URL      string            `yaml:"url"`      // Webhook or Slack incoming webhook URL
+	TopicARN string            `yaml:"topicArn"` // SNS topic ARN
+	Headers  map[string]string `yaml:"headers"`  // Extra HTTP headers for webhooks
+	Events   []string          `yaml:"events"`   // Event types to deliver (empty = all)
+	Template string            `yaml:"template"` // Go text/template for the message payload
+}
+
+// WeeklyDigestConfig controls scheduled delivery of the weekly digest
+type WeeklyDigestConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Weekday string `yaml:"weekday"` // Day to send on, e.g. monday
+	Hour    int    `yaml:"hour"`    // Hour (UTC) to send at
+}
+
+// ExportConfig holds settings for pushing cost data to other systems
+type ExportConfig struct {
+	Datadog DatadogConfig `yaml:"datadog"`
+	Publish PublishConfig `yaml:"publish"`
+	S3      S3Config      `yaml:"s3"`
+}
+
+// DatadogConfig holds settings for pushing cost metrics to Datadog after each
+// full scan
+type DatadogConfig struct {
+	APIKey       string   `yaml:"apiKey"`       // Datadog API key (empty = disabled)
+	Site         string   `yaml:"site"`         // Datadog site, e.g. datadoghq.com or datadoghq.eu
+	MetricPrefix string   `yaml:"metricPrefix"` // Prefix for metric names
+	Tags         []string `yaml:"tags"`         // Tags added to every metric, e.g. env:prod
+}
+
+// Snapshot publish formats
+const (
+	PublishFormatFull    = "full"    // The full cost response, or a summary if it is too large
+	PublishFormatSummary = "summary" // Totals by service and account only
+)
+
+// PublishConfig holds settings for publishing each full scan to SNS or SQS,
+// for data pipelines that ingest awsCOGS results
+type PublishConfig struct {
+	SNSTopicARN string `yaml:"snsTopicArn"` // SNS topic to publish to (empty = disabled)
+	SQSQueueURL string `yaml:"sqsQueueUrl"` // SQS queue to send to (empty = disabled)
+	Format      string `yaml:"format"`      // full or summary
+}
+
+// S3 archive formats
+const (
+	ArchiveFormatJSON      = "json"  // The snapshot as one JSON document
+	ArchiveFormatJSONLines = "jsonl" // One JSON object per resource, for Athena
+)
+
+// S3Config holds settings for archiving every snapshot to S3 as gzipped JSON
+type S3Config struct {
+	Bucket string `yaml:"bucket"` // Bucket to write to (empty = disabled)
+	Prefix string `yaml:"prefix"` // Key prefix
+	Region string `yaml:"region"` // Bucket region (empty = default AWS region)
+	Format string `yaml:"format"` // json or jsonl
+}
+
+// Log components whose level can be set separately from the default
+const (
+	LogComponentAPI       = "api"
+	LogComponentDiscovery = "discovery"
+	LogComponentPricing   = "pricing"
+)
+
+// LogComponents lists the log components
+var LogComponents = []string{LogComponentAPI, LogComponentDiscovery, LogComponentPricing}
+
+// Access log formats
+const (
+	AccessLogJSON = "json" // slog records from the api component
+	AccessLogCLF  = "clf"  // Common Log Format
+	AccessLogOff  = "off"
+)
+
 // LogConfig holds logging settings
 type LogConfig struct {
-	Level string `yaml:"level"`
+	Level      string            `yaml:"level"`      // debug, info, warn, or error
+	Components map[string]string `yaml:"components"` // Level per component, e.g. discovery: debug (unset = level)
+	Access     AccessLogConfig   `yaml:"access"`
+}
+
+// AccessLogConfig holds settings for the /api/v1 access log
+type AccessLogConfig struct {
+	Format     string `yaml:"format"`     // json, clf, or off
+	BodySizes  bool   `yaml:"bodySizes"`  // Log request and response body sizes in JSON records (CLF always has the response size)
+	UserHeader string `yaml:"userHeader"` // Header an authenticating proxy puts the user in, e.g. X-Forwarded-User (empty = HTTP basic auth user)
+}
+
+func (c LogConfig) validate() error {
+	if err := validLogLevel(c.Level); err != nil {
+		return err
+	}
+	switch c.Access.Format {
+	case AccessLogJSON, AccessLogCLF, AccessLogOff:
+	default:
+		return fmt.Errorf("invalid access log format %q (valid: json, clf, off)", c.Access.Format)
+	}
+	for component, level := range c.Components {
+		if !slices.Contains(LogComponents, component) {
+			return fmt.Errorf("unknown log component %q (valid: %s)", component, strings.Join(LogComponents, ", "))
+		}
+		if err := validLogLevel(level); err != nil {
+			return fmt.Errorf("%s: %w", component, err)
+		}
+	}
+	return nil
+}
+
+func validLogLevel(level string) error {
+	var l slog.Level
+	if level == "" {
+		return nil // info
+	}
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q (valid: debug, info, warn, error)", level)
+	}
+	return nil
 }
 
 // DefaultConfig returns configuration with sensible defaults
@@ -71,6 +916,19 @@ func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
 			Port: 8080,
+			CORS: CORSConfig{
+				AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
+			},
+			Headers: SecurityHeadersConfig{
+				Enabled:               true,
+				ContentSecurityPolicy: DefaultContentSecurityPolicy,
+			},
+			ScanJobs: ScanJobsConfig{
+				OverlapPolicy: ScanOverlapCoalesce,
+				MaxConcurrent: 2,
+				MaxQueued:     20,
+			},
+			ShutdownDrainSeconds: 20,
 		},
 		AWS: AWSConfig{
 			DiscoverAccounts: true,
@@ -80,17 +938,96 @@ func DefaultConfig() *Config {
 				DiscoverRegions: true,
 				AssumeRoleName:  "OrganizationAccountAccessRole",
 			},
+			CloudTrail: CloudTrailConfig{
+				LookbackDays: 90,
+			},
+			DataTransfer: DataTransferConfig{
+				InternetPercent: 10,
+				InterAZPercent:  30,
+				InternetPerGB:   0.09,
+				InterAZPerGB:    0.02,
+				NATPerGB:        0.045,
+			},
+			Messaging: MessagingConfig{
+				SQSPerMillion:         0.40,
+				SQSFIFOPerMillion:     0.50,
+				SNSPerMillion:         0.50,
+				EventBridgePerMillion: 1.00,
+			},
+			CloudWatch: CloudWatchConfig{
+				AlarmPerMonth:               0.10,
+				HighResolutionAlarmPerMonth: 0.30,
+				CompositeAlarmPerMonth:      0.50,
+				DashboardPerMonth:           3.00,
+				MetricPerMonth:              0.30,
+			},
 		},
 		Pricing: PricingConfig{
 			RefreshIntervalMinutes: 60,
 			RateLimitPerSecond:     5, // Conservative default to avoid AWS throttling
+			CacheMaxEntries:        10000,
+			MissingPrice:           MissingPriceZero,
+			Retry: PriceRetryConfig{
+				IntervalSeconds: 30,
+				MaxAttempts:     5,
+			},
 		},
 		Cache: CacheConfig{
 			ResourceTTLMinutes: 5,  // Resource discovery cache TTL
 			AccountTTLMinutes:  60, // Account/region discovery cache TTL
+			ResultTTLMinutes:   5,  // Scan result cache TTL
+		},
+		Snapshots: SnapshotConfig{
+			MaxCount:    500,
+			MaxImportMB: 1024,
+		},
+		Budget: BudgetConfig{
+			WarnPercent: 80,
+		},
+		Recommend: RecommendConfig{
+			OffHours: OffHoursConfig{
+				Weekdays:            []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+				StartHour:           8,
+				EndHour:             19,
+				EnvironmentTags:     []string{"Environment", "Env", "Stage"},
+				NonProductionValues: []string{"dev", "development", "test", "testing", "qa", "staging", "stage", "sandbox"},
+			},
+		},
+		Environments: EnvironmentConfig{
+			Tags:       []string{"Environment", "Env", "Stage"},
+			Production: []string{"prod"},
+		},
+		Waste: WasteConfig{
+			UnusedSecretDays:    90,
+			StoppedInstanceDays: 30,
+		},
+		Ignore: IgnoreConfig{
+			Tag: "awscogs:ignore",
+		},
+		Notify: NotifyConfig{
+			WeeklyDigest: WeeklyDigestConfig{
+				Weekday: "monday",
+				Hour:    9,
+			},
+			MaxAttempts:         3,
+			RetryBackoffSeconds: 2,
+		},
+		Export: ExportConfig{
+			Datadog: DatadogConfig{
+				Site:         "datadoghq.com",
+				MetricPrefix: "awscogs",
+			},
+			Publish: PublishConfig{
+				Format: PublishFormatFull,
+			},
+			S3: S3Config{
+				Prefix: "awscogs",
+				Format: ArchiveFormatJSON,
+			},
 		},
 		Log: LogConfig{
-			Level: "info",
+			Level:  "info",
+			Access: AccessLogConfig{Format: AccessLogJSON},
 		},
 	}
 }
@@ -100,20 +1037,37 @@ func Load(path string) (*Config, error) {
 	cfg := DefaultConfig()
 
 	// Load from file if provided
+	var doc *yaml.Node
 	if path != "" {
 		data, err := os.ReadFile(path)
 		if err != nil {
 			return nil, fmt.Errorf("reading config file: %w", err)
 		}
-		if err := yaml.Unmarshal(data, cfg); err != nil {
-			return nil, fmt.Errorf("parsing config file: %w", err)
+		if doc, err = parseFile(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config file %s:\n%w", path, err)
 		}
 	}
 
-	// Override with environment variables
-	cfg.loadFromEnv()
+	// Override with environment variables: first the one for every setting,
+	// then the deprecated shorter names, which win where both are set
+	if err := cfg.applyEnvVars(); err != nil {
+		return nil, fmt.Errorf("parsing environment: %w", err)
+	}
+	if err := cfg.loadFromEnv(); err != nil {
+		return nil, fmt.Errorf("parsing environment: %w", err)
+	}
+	cfg.Server.BasePath = NormalizeBasePath(cfg.Server.BasePath)
+	if cfg.AWS.QuickScan {
+		cfg.EnableQuickScan()
+	}
+	if err := cfg.resolveProfiles(); err != nil {
+		return nil, fmt.Errorf("parsing profiles: %w", err)
+	}
 
 	// Validate
+	if err := cfg.checkExclusive(doc); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
@@ -121,111 +1075,389 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
-// loadFromEnv overrides config values from environment variables
-func (c *Config) loadFromEnv() {
-	if port := os.Getenv("AWSCOGS_PORT"); port != "" {
-		if p, err := strconv.Atoi(port); err == nil {
-			c.Server.Port = p
+// EnableQuickScan limits scans to the account of the default credentials, with
+// no Organizations or region discovery, GovCloud, or profiles. Regions are
+// kept if set explicitly; otherwise the caller fills in the default
+// profile's region.
+func (c *Config) EnableQuickScan() {
+	c.AWS.QuickScan = true
+	c.AWS.DiscoverAccounts = false
+	c.AWS.DiscoverRegions = false
+	c.AWS.Accounts = nil
+	c.AWS.GovCloud.Enabled = false
+	c.Profiles = nil
+}
+
+// resolveProfiles applies each profile's aws section over the top-level one
+func (c *Config) resolveProfiles() error {
+	for i := range c.Profiles {
+		p := &c.Profiles[i]
+		if p.node == nil {
+			continue
+		}
+		overrides := struct {
+			AWS *AWSConfig `yaml:"aws"`
+		}{AWS: &p.AWS}
+		p.AWS = c.AWS
+		if err := p.node.Decode(&overrides); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
 		}
+		// GovCloud stays gated by AWSCOGS_ENABLE_GOVCLOUD
+		p.AWS.GovCloud.Enabled = p.AWS.GovCloud.Enabled && c.AWS.GovCloud.Enabled
+		p.node = nil
 	}
+	return nil
+}
 
-	if level := os.Getenv("AWSCOGS_LOG_LEVEL"); level != "" {
-		c.Log.Level = level
+// ProfileNames returns the names of the configured profiles
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for _, p := range c.Profiles {
+		names = append(names, p.Name)
 	}
+	return names
+}
 
-	if regions := os.Getenv("AWSCOGS_REGIONS"); regions != "" {
-		c.AWS.Regions = strings.Split(regions, ",")
-		c.AWS.DiscoverRegions = false // Disable discovery if explicit regions set
+// ForProfile returns the configuration for the named profile: this config
+// with the profile's AWS settings and its own snapshot directory under
+// profiles/<name>. The empty name is the default profile.
+func (c *Config) ForProfile(name string) (*Config, bool) {
+	if name == "" {
+		return c, true
+	}
+	for _, p := range c.Profiles {
+		if p.Name != name {
+			continue
+		}
+		cfg := *c
+		cfg.Profile = name
+		cfg.AWS = p.AWS
+		if cfg.Snapshots.Dir != "" {
+			cfg.Snapshots.Dir = filepath.Join(cfg.Snapshots.Dir, "profiles", name)
+		}
+		return &cfg, true
+	}
+	return nil, false
+}
+
+// loadFromEnv applies the deprecated environment variable names and the
+// variables that don't set a single setting
+func (c *Config) loadFromEnv() error {
+	// Explicit regions turn off discovery unless it's set as well
+	if os.Getenv("AWSCOGS_REGIONS") != "" {
+		c.AWS.DiscoverRegions = false
+	}
+	if os.Getenv("AWSCOGS_GOVCLOUD_REGIONS") != "" {
+		c.AWS.GovCloud.DiscoverRegions = false
+	}
+	if os.Getenv("AWSCOGS_GOVCLOUD_ACCOUNTS") != "" {
+		c.AWS.GovCloud.DiscoverAccounts = false
 	}
 
-	discoverRegionsSet := false
-	if discoverRegions, ok := boolEnv("AWSCOGS_DISCOVER_REGIONS"); ok {
-		c.AWS.DiscoverRegions = discoverRegions
-		discoverRegionsSet = true
+	errs := []error{c.applyEnvAliases()}
+
+	if url := os.Getenv("AWSCOGS_WEBHOOK_URL"); url != "" {
+		c.Notify.Sinks = append(c.Notify.Sinks, SinkConfig{Name: "webhook", Type: "webhook", URL: url})
 	}
 
-	discoverAccountsSet := false
-	if discoverAccounts, ok := boolEnv("AWSCOGS_DISCOVER_ACCOUNTS"); ok {
-		c.AWS.DiscoverAccounts = discoverAccounts
-		discoverAccountsSet = true
+	if url := os.Getenv("AWSCOGS_SLACK_WEBHOOK_URL"); url != "" {
+		c.Notify.Sinks = append(c.Notify.Sinks, SinkConfig{Name: "slack", Type: "slack", URL: url})
 	}
 
-	if assumeRole := os.Getenv("AWSCOGS_ASSUME_ROLE_NAME"); assumeRole != "" {
-		c.AWS.AssumeRoleName = assumeRole
+	if topicARN := os.Getenv("AWSCOGS_SNS_TOPIC_ARN"); topicARN != "" {
+		c.Notify.Sinks = append(c.Notify.Sinks, SinkConfig{Name: "sns", Type: "sns", TopicARN: topicARN})
 	}
 
-	if interval := os.Getenv("AWSCOGS_PRICING_REFRESH_MINUTES"); interval != "" {
-		if i, err := strconv.Atoi(interval); err == nil {
-			c.Pricing.RefreshIntervalMinutes = i
+	// GovCloud config is inert unless explicitly enabled by environment
+	govEnabled, ok, err := boolEnv("AWSCOGS_ENABLE_GOVCLOUD")
+	errs = append(errs, err)
+	c.AWS.GovCloud.Enabled = ok && govEnabled
+
+	if c.AWS.GovCloud.Enabled && len(c.AWS.Accounts) == 0 && len(c.AWS.Regions) == 0 {
+		if !envSet("AWSCOGS_AWS_DISCOVER_ACCOUNTS", "AWSCOGS_DISCOVER_ACCOUNTS") {
+			c.AWS.DiscoverAccounts = false
+		}
+		if !envSet("AWSCOGS_AWS_DISCOVER_REGIONS", "AWSCOGS_DISCOVER_REGIONS") {
+			c.AWS.DiscoverRegions = false
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the configuration for errors
+func (c *Config) Validate() error {
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", c.Server.Port)
+	}
+
+	// Without a startup scan, nothing guarantees a snapshot is ever taken
+	if c.Server.BlockUntilFirstScan && !c.Server.ScanOnStartup {
+		return fmt.Errorf("server.blockUntilFirstScan requires server.scanOnStartup")
+	}
+
+	if c.Pricing.RefreshIntervalMinutes < 1 {
+		return fmt.Errorf("pricing refresh interval must be at least 1 minute")
+	}
 
-	if rateLimit := os.Getenv("AWSCOGS_PRICING_RATE_LIMIT"); rateLimit != "" {
-		if r, err := strconv.Atoi(rateLimit); err == nil {
-			c.Pricing.RateLimitPerSecond = r
+	if c.Pricing.CacheMaxEntries < 0 {
+		return fmt.Errorf("pricing cache max entries cannot be negative")
+	}
+
+	if r := c.Pricing.Retry; r.MaxAttempts < 0 || (r.MaxAttempts > 0 && r.IntervalSeconds < 1) {
+		return fmt.Errorf("pricing retries need maxAttempts of at least 0 and an interval of at least 1 second")
+	}
+
+	if !slices.Contains(MissingPricePolicies, c.Pricing.MissingPrice) {
+		return fmt.Errorf("invalid pricing.missingPrice %q (valid: %s)", c.Pricing.MissingPrice, strings.Join(MissingPricePolicies, ", "))
+	}
+
+	if d := c.Billing.DiscountPercent; d < 0 || d >= 100 {
+		return fmt.Errorf("billing discount percent must be at least 0 and less than 100, got %v", d)
+	}
+	if c.Billing.MonthlyCredits < 0 {
+		return fmt.Errorf("billing monthly credits cannot be negative")
+	}
+
+	seenAccounts := make(map[string]bool, len(c.AccountMetadata))
+	for _, m := range c.AccountMetadata {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("accountMetadata: %w", err)
 		}
+		if seenAccounts[m.ID] {
+			return fmt.Errorf("accountMetadata: account %s is listed more than once", m.ID)
+		}
+		seenAccounts[m.ID] = true
 	}
 
-	if resourceTTL := os.Getenv("AWSCOGS_CACHE_RESOURCE_TTL_MINUTES"); resourceTTL != "" {
-		if t, err := strconv.Atoi(resourceTTL); err == nil {
-			c.Cache.ResourceTTLMinutes = t
+	if d := c.Pricing.Overrides.DiscountPercent; d < 0 || d >= 100 {
+		return fmt.Errorf("pricing discount percent must be at least 0 and less than 100, got %v", d)
+	}
+	// Both model the same negotiated discount, which would then be taken once
+	// per resource and again off the total
+	if c.Pricing.Overrides.DiscountPercent > 0 && c.Billing.DiscountPercent > 0 {
+		return fmt.Errorf("set either billing.discountPercent or pricing.overrides.discountPercent, not both")
+	}
+	seenRates := make(map[string]bool)
+	for i, rate := range c.Pricing.Overrides.Rates {
+		if !slices.Contains(PinnedRateServices, rate.Service) {
+			return fmt.Errorf("pinned rate %d: unknown service %q (valid: %s)", i+1, rate.Service, strings.Join(PinnedRateServices, ", "))
+		}
+		if rate.SKU == "" {
+			return fmt.Errorf("pinned rate %d needs a sku", i+1)
+		}
+		if rate.Hourly < 0 {
+			return fmt.Errorf("pinned rate %d (%s): hourly cannot be negative", i+1, rate.SKU)
 		}
+		key := rate.Service + "|" + rate.Region + "|" + rate.SKU
+		if seenRates[key] {
+			return fmt.Errorf("duplicate pinned rate for %s %s in %q", rate.Service, rate.SKU, rate.Region)
+		}
+		seenRates[key] = true
+	}
+
+	if c.AWS.RateLimit.RequestsPerSecond < 0 {
+		return fmt.Errorf("AWS rate limit cannot be negative")
+	}
+
+	if c.AWS.RateLimit.Burst < 0 {
+		return fmt.Errorf("AWS rate limit burst cannot be negative")
+	}
+
+	if hub := c.AWS.STS.HubRoleARN; hub != "" && !strings.HasPrefix(hub, "arn:") {
+		return fmt.Errorf("invalid STS hub role ARN: %s", hub)
 	}
 
-	if accountTTL := os.Getenv("AWSCOGS_CACHE_ACCOUNT_TTL_MINUTES"); accountTTL != "" {
-		if t, err := strconv.Atoi(accountTTL); err == nil {
-			c.Cache.AccountTTLMinutes = t
+	if ct := c.AWS.CloudTrail; ct.LookupOwners && (ct.LookbackDays < 1 || ct.LookbackDays > 90) {
+		return fmt.Errorf("CloudTrail lookback must be 1 to 90 days, got %d", ct.LookbackDays)
+	}
+
+	if c.Cache.ResultTTLMinutes < 0 {
+		return fmt.Errorf("result cache TTL cannot be negative")
+	}
+
+	for _, addr := range c.Server.BindAddresses() {
+		host, port := SplitBindAddress(addr, c.Server.Port)
+		if host == "" {
+			return fmt.Errorf("invalid bind address %q: missing host", addr)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid bind address %q: bad port %q", addr, port)
 		}
 	}
 
-	// GovCloud environment variables
-	if govEnabled, ok := boolEnv("AWSCOGS_ENABLE_GOVCLOUD"); ok {
-		c.AWS.GovCloud.Enabled = govEnabled
-	} else {
-		// GovCloud config is inert unless explicitly enabled by environment.
-		c.AWS.GovCloud.Enabled = false
+	if _, err := c.Server.SocketFileMode(); err != nil {
+		return err
 	}
 
-	if govRegions := os.Getenv("AWSCOGS_GOVCLOUD_REGIONS"); govRegions != "" {
-		c.AWS.GovCloud.Regions = splitCSV(govRegions)
-		c.AWS.GovCloud.DiscoverRegions = false
+	if addr := c.Server.DebugAddr; addr != "" {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return fmt.Errorf("invalid debug address %q: %w", addr, err)
+		}
+		if port == strconv.Itoa(c.Server.Port) {
+			return fmt.Errorf("debug address %q must use a different port than the API", addr)
+		}
 	}
 
-	if govAccounts := os.Getenv("AWSCOGS_GOVCLOUD_ACCOUNTS"); govAccounts != "" {
-		c.AWS.GovCloud.Accounts = parseAccountList(govAccounts)
-		c.AWS.GovCloud.DiscoverAccounts = false
+	if strings.ContainsAny(c.Server.BasePath, "?#*{}<>\"' ") {
+		return fmt.Errorf("invalid base path: %q", c.Server.BasePath)
 	}
 
-	if govDiscoverAccounts, ok := boolEnv("AWSCOGS_GOVCLOUD_DISCOVER_ACCOUNTS"); ok {
-		c.AWS.GovCloud.DiscoverAccounts = govDiscoverAccounts
+	if err := c.AWS.validateEndpointURL(); err != nil {
+		return err
+	}
+	if err := c.AWS.validatePlugins(); err != nil {
+		return err
+	}
+	if err := c.AWS.DataTransfer.validate(); err != nil {
+		return err
+	}
+	if err := c.AWS.Messaging.validate(); err != nil {
+		return err
+	}
+	if err := c.AWS.CloudWatch.validate(); err != nil {
+		return err
+	}
+	for _, service := range c.AWS.Services {
+		if valid := c.AWS.ResourceTypes(); !slices.Contains(valid, service) {
+			return fmt.Errorf("unknown AWS service %q (valid: %s)", service, strings.Join(valid, ", "))
+		}
 	}
 
-	if govDiscoverRegions, ok := boolEnv("AWSCOGS_GOVCLOUD_DISCOVER_REGIONS"); ok {
-		c.AWS.GovCloud.DiscoverRegions = govDiscoverRegions
+	if c.Snapshots.MaxCount < 1 {
+		return fmt.Errorf("snapshot max count must be at least 1")
+	}
+	if c.Snapshots.MaxImportMB < 1 {
+		return fmt.Errorf("snapshot max import size must be at least 1 MB")
+	}
+	if err := c.Snapshots.Retention.validate(); err != nil {
+		return err
 	}
 
-	if govAssumeRole := os.Getenv("AWSCOGS_GOVCLOUD_ASSUME_ROLE_NAME"); govAssumeRole != "" {
-		c.AWS.GovCloud.AssumeRoleName = govAssumeRole
+	if c.Budget.Monthly < 0 {
+		return fmt.Errorf("monthly budget cannot be negative")
+	}
+	for account, budget := range c.Budget.Accounts {
+		if budget < 0 {
+			return fmt.Errorf("monthly budget for account %s cannot be negative", account)
+		}
 	}
 
-	if c.AWS.GovCloud.Enabled && len(c.AWS.Accounts) == 0 && len(c.AWS.Regions) == 0 {
-		if !discoverAccountsSet {
-			c.AWS.DiscoverAccounts = false
+	if err := c.Log.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Server.ScanJobs.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Server.CORS.validate(); err != nil {
+		return err
+	}
+
+	if c.Server.Headers.HSTSMaxAgeSeconds < 0 {
+		return fmt.Errorf("HSTS max age cannot be negative")
+	}
+
+	if c.Server.ShutdownDrainSeconds < 0 {
+		return fmt.Errorf("shutdown drain seconds cannot be negative")
+	}
+
+	if c.Server.RateLimit.RequestsPerMinute < 0 || c.Server.RateLimit.Burst < 0 {
+		return fmt.Errorf("API rate limit cannot be negative")
+	}
+	if rl := c.Server.RateLimit; rl.KeyHeader == "" && (len(rl.Keys) > 0 || rl.TrustKeyHeader) {
+		return fmt.Errorf("server.rateLimit.keys and trustKeyHeader need a keyHeader")
+	}
+
+	if err := c.CostCenters.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Environments.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Recommend.OffHours.validate(); err != nil {
+		return err
+	}
+
+	if c.Waste.UnusedSecretDays < 0 {
+		return fmt.Errorf("waste.unusedSecretDays cannot be negative")
+	}
+	if c.Waste.StoppedInstanceDays < 0 {
+		return fmt.Errorf("waste.stoppedInstanceDays cannot be negative")
+	}
+
+	if err := c.Ignore.validate(); err != nil {
+		return err
+	}
+
+	if err := c.CostThresholds.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Notify.validate(); err != nil {
+		return err
+	}
+
+	if dd := c.Export.Datadog; dd.APIKey != "" {
+		if dd.Site == "" || strings.ContainsAny(dd.Site, "/:") {
+			return fmt.Errorf("invalid Datadog site: %q", dd.Site)
 		}
-		if !discoverRegionsSet {
-			c.AWS.DiscoverRegions = false
+		if dd.MetricPrefix == "" {
+			return fmt.Errorf("a metric prefix is required for Datadog export")
 		}
 	}
-}
 
-// Validate checks the configuration for errors
-func (c *Config) Validate() error {
-	if c.Server.Port < 1 || c.Server.Port > 65535 {
-		return fmt.Errorf("invalid port: %d", c.Server.Port)
+	if err := c.Export.Publish.validate(); err != nil {
+		return err
 	}
 
-	if c.Pricing.RefreshIntervalMinutes < 1 {
-		return fmt.Errorf("pricing refresh interval must be at least 1 minute")
+	if s3 := c.Export.S3; s3.Bucket != "" {
+		if !bucketNamePattern.MatchString(s3.Bucket) {
+			return fmt.Errorf("invalid export S3 bucket: %q", s3.Bucket)
+		}
+		if s3.Format != ArchiveFormatJSON && s3.Format != ArchiveFormatJSONLines {
+			return fmt.Errorf("invalid export S3 format: %q (use %s or %s)", s3.Format, ArchiveFormatJSON, ArchiveFormatJSONLines)
+		}
+	}
+
+	seenProfiles := make(map[string]bool)
+	for _, p := range c.Profiles {
+		if !profileNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("invalid profile name %q: use letters, digits, '-', and '_'", p.Name)
+		}
+		if seenProfiles[p.Name] {
+			return fmt.Errorf("duplicate profile %q", p.Name)
+		}
+		seenProfiles[p.Name] = true
+		if err := p.AWS.validateEndpointURL(); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		if err := p.AWS.validatePlugins(); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		if err := p.AWS.DataTransfer.validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		if err := p.AWS.Messaging.validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		if err := p.AWS.CloudWatch.validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", p.Name, err)
+		}
+		for _, service := range p.AWS.Services {
+			if !slices.Contains(p.AWS.ResourceTypes(), service) {
+				return fmt.Errorf("profile %q: unknown AWS service %q", p.Name, service)
+			}
+		}
+		if hub := p.AWS.STS.HubRoleARN; hub != "" && !strings.HasPrefix(hub, "arn:") {
+			return fmt.Errorf("profile %q: invalid STS hub role ARN: %s", p.Name, hub)
+		}
+		if p.AWS.RateLimit.RequestsPerSecond < 0 || p.AWS.RateLimit.Burst < 0 {
+			return fmt.Errorf("profile %q: AWS rate limit cannot be negative", p.Name)
+		}
 	}
 
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
@@ -236,18 +1468,155 @@ func (c *Config) Validate() error {
 	return nil
 }
 
-func boolEnv(name string) (bool, bool) {
+func (c CORSConfig) validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("CORS credentials cannot be allowed for every origin")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("invalid CORS origin %q: must be \"*\" or a scheme and host, e.g. https://finops.example.com", origin)
+		}
+	}
+	return nil
+}
+
+func (n NotifyConfig) validate() error {
+	for i, sink := range n.Sinks {
+		if sink.Name == "" {
+			sink.Name = fmt.Sprintf("%s-%d", sink.Type, i)
+		}
+		switch sink.Type {
+		case "webhook", "slack":
+			if sink.URL == "" {
+				return fmt.Errorf("notification sink %q requires a url", sink.Name)
+			}
+		case "sns":
+			if !strings.HasPrefix(sink.TopicARN, "arn:") {
+				return fmt.Errorf("notification sink %q requires a topicArn", sink.Name)
+			}
+		default:
+			return fmt.Errorf("notification sink %q has unknown type %q", sink.Name, sink.Type)
+		}
+	}
+
+	if n.MaxAttempts < 1 {
+		return fmt.Errorf("notification max attempts must be at least 1")
+	}
+
+	if n.WeeklyDigest.Enabled {
+		if _, ok := Weekdays[strings.ToLower(n.WeeklyDigest.Weekday)]; !ok {
+			return fmt.Errorf("invalid weekly digest weekday: %s", n.WeeklyDigest.Weekday)
+		}
+		if n.WeeklyDigest.Hour < 0 || n.WeeklyDigest.Hour > 23 {
+			return fmt.Errorf("invalid weekly digest hour: %d", n.WeeklyDigest.Hour)
+		}
+	}
+	return nil
+}
+
+func (p PublishConfig) validate() error {
+	if p.SNSTopicARN == "" && p.SQSQueueURL == "" {
+		return nil
+	}
+	if p.Format != PublishFormatFull && p.Format != PublishFormatSummary {
+		return fmt.Errorf("invalid export format: %q (use %s or %s)", p.Format, PublishFormatFull, PublishFormatSummary)
+	}
+	if p.SNSTopicARN != "" && !strings.HasPrefix(p.SNSTopicARN, "arn:") {
+		return fmt.Errorf("invalid export SNS topic ARN: %s", p.SNSTopicARN)
+	}
+	if p.SQSQueueURL != "" {
+		u, err := url.Parse(p.SQSQueueURL)
+		if err != nil || u.Scheme != "https" || !strings.HasPrefix(u.Host, "sqs.") {
+			return fmt.Errorf("invalid export SQS queue URL: %s", p.SQSQueueURL)
+		}
+	}
+	return nil
+}
+
+// profileNamePattern matches valid profile names, which are also used as
+// snapshot directory names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// bucketNamePattern matches valid S3 bucket names without dots, which can be
+// addressed with virtual-hosted-style HTTPS URLs
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// Weekdays maps lowercase weekday names to time.Weekday values
+var Weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// NormalizeBasePath returns the base path with a leading slash and no trailing
+// slash. An empty or root path normalizes to "".
+func NormalizeBasePath(basePath string) string {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+func boolEnv(name string) (bool, bool, error) {
 	value, ok := os.LookupEnv(name)
-	if !ok {
-		return false, false
+	if !ok || strings.TrimSpace(value) == "" {
+		return false, false, nil
 	}
+	b, err := parseBool(value)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", name, err)
+	}
+	return b, true, nil
+}
 
-	switch strings.ToLower(strings.TrimSpace(value)) {
-	case "1", "true", "yes", "on":
-		return true, true
-	default:
-		return false, true
+// envSet reports whether any of the named environment variables is set
+func envSet(names ...string) bool {
+	for _, name := range names {
+		if _, ok := os.LookupEnv(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// BindAddresses returns the entries of BindAddress
+func (s ServerConfig) BindAddresses() []string {
+	return splitCSV(s.BindAddress)
+}
+
+// SocketFileMode returns the permissions of the Unix socket: SocketMode, or
+// 0660 if it's empty
+func (s ServerConfig) SocketFileMode() (os.FileMode, error) {
+	if s.SocketMode == "" {
+		return 0o660, nil
+	}
+	mode, err := strconv.ParseUint(s.SocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q: want octal permissions such as 0660", s.SocketMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// SplitBindAddress splits a server.bindAddress entry into its host and port,
+// using defaultPort if it has none. Bare IPv6 addresses such as "::1" need no
+// brackets.
+func SplitBindAddress(addr string, defaultPort int) (host, port string) {
+	if net.ParseIP(addr) != nil {
+		return addr, strconv.Itoa(defaultPort)
+	}
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		return host, port
 	}
+	return strings.Trim(addr, "[]"), strconv.Itoa(defaultPort)
 }
 
 func splitCSV(value string) []string {
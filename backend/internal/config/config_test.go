@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -69,3 +73,925 @@ func TestGovCloudAccountsFromEnv(t *testing.T) {
 		t.Fatalf("bare ARN account name = %q", cfg.AWS.GovCloud.Accounts[1].Name)
 	}
 }
+
+func TestBasePathNormalization(t *testing.T) {
+	t.Setenv("AWSCOGS_BASE_PATH", "awscogs/")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Server.BasePath != "/awscogs" {
+		t.Fatalf("BasePath = %q", cfg.Server.BasePath)
+	}
+	if got := NormalizeBasePath("/"); got != "" {
+		t.Fatalf("NormalizeBasePath(\"/\") = %q", got)
+	}
+}
+
+func TestServicesFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_SERVICES", "EC2, rds")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.AWS.EnabledServices(); len(got) != 2 || got[0] != "ec2" || got[1] != "rds" {
+		t.Fatalf("EnabledServices() = %v", got)
+	}
+
+	t.Setenv("AWSCOGS_SERVICES", "ec2,s3")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an unknown service")
+	}
+}
+
+func TestRateLimitFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_AWS_RATE_LIMIT", "2.5")
+	t.Setenv("AWSCOGS_AWS_RATE_BURST", "10")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.AWS.RateLimit; got.RequestsPerSecond != 2.5 || got.Burst != 10 {
+		t.Fatalf("RateLimit = %+v", got)
+	}
+
+	t.Setenv("AWSCOGS_AWS_RATE_LIMIT", "-1")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for a negative rate limit")
+	}
+}
+
+func TestPublishFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_EXPORT_SQS_QUEUE_URL", "https://sqs.us-east-1.amazonaws.com/123456789012/awscogs")
+	t.Setenv("AWSCOGS_EXPORT_FORMAT", "Summary")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.Export.Publish; got.SQSQueueURL != "https://sqs.us-east-1.amazonaws.com/123456789012/awscogs" || got.Format != PublishFormatSummary {
+		t.Fatalf("Publish = %+v", got)
+	}
+
+	t.Setenv("AWSCOGS_EXPORT_FORMAT", "parquet")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an unknown export format")
+	}
+
+	t.Setenv("AWSCOGS_EXPORT_FORMAT", "")
+	t.Setenv("AWSCOGS_EXPORT_SQS_QUEUE_URL", "awscogs-queue")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an invalid queue URL")
+	}
+}
+
+func TestS3ArchiveFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_EXPORT_S3_BUCKET", "cost-history")
+	t.Setenv("AWSCOGS_EXPORT_S3_FORMAT", "jsonl")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := cfg.Export.S3; got.Bucket != "cost-history" || got.Prefix != "awscogs" || got.Format != ArchiveFormatJSONLines {
+		t.Fatalf("S3 = %+v", got)
+	}
+
+	t.Setenv("AWSCOGS_EXPORT_S3_FORMAT", "parquet")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an unsupported archive format")
+	}
+
+	t.Setenv("AWSCOGS_EXPORT_S3_FORMAT", "")
+	t.Setenv("AWSCOGS_EXPORT_S3_BUCKET", "Cost_History")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an invalid bucket name")
+	}
+}
+
+func TestDebugAddrValidation(t *testing.T) {
+	t.Setenv("AWSCOGS_DEBUG_ADDR", "localhost:6060")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.DebugAddr != "localhost:6060" {
+		t.Fatalf("DebugAddr = %q", cfg.Server.DebugAddr)
+	}
+
+	t.Setenv("AWSCOGS_DEBUG_ADDR", ":8080")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error when the debug server shares the API port")
+	}
+
+	t.Setenv("AWSCOGS_DEBUG_ADDR", "6060")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an address without a port")
+	}
+}
+
+func TestCORSFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_CORS_ALLOWED_ORIGINS", "https://finops.example.com, https://*.example.com")
+	t.Setenv("AWSCOGS_CORS_ALLOW_CREDENTIALS", "true")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Server.CORS.AllowedOrigins) != 2 || !cfg.Server.CORS.AllowCredentials {
+		t.Fatalf("CORS = %+v", cfg.Server.CORS)
+	}
+
+	t.Setenv("AWSCOGS_CORS_ALLOWED_ORIGINS", "*")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error when credentials are allowed for every origin")
+	}
+
+	t.Setenv("AWSCOGS_CORS_ALLOW_CREDENTIALS", "false")
+	t.Setenv("AWSCOGS_CORS_ALLOWED_ORIGINS", "finops.example.com")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an origin without a scheme")
+	}
+}
+
+func TestSecurityHeadersFromEnv(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Server.Headers.Enabled || cfg.Server.Headers.ContentSecurityPolicy != DefaultContentSecurityPolicy {
+		t.Fatalf("expected security headers on by default, got %+v", cfg.Server.Headers)
+	}
+
+	t.Setenv("AWSCOGS_HSTS_MAX_AGE_SECONDS", "31536000")
+	t.Setenv("AWSCOGS_CONTENT_SECURITY_POLICY", "")
+	cfg, err = Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Headers.HSTSMaxAgeSeconds != 31536000 || cfg.Server.Headers.ContentSecurityPolicy != "" {
+		t.Fatalf("Headers = %+v", cfg.Server.Headers)
+	}
+}
+
+func TestAPIRateLimitFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_API_RATE_LIMIT", "120")
+	t.Setenv("AWSCOGS_API_RATE_LIMIT_BURST", "20")
+	t.Setenv("AWSCOGS_API_RATE_LIMIT_KEY_HEADER", "X-API-Key")
+	t.Setenv("AWSCOGS_TRUST_PROXY_HEADERS", "true")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := APIRateLimitConfig{RequestsPerMinute: 120, Burst: 20, KeyHeader: "X-API-Key"}
+	if !reflect.DeepEqual(cfg.Server.RateLimit, want) || !cfg.Server.TrustProxyHeaders {
+		t.Fatalf("RateLimit = %+v, TrustProxyHeaders = %v", cfg.Server.RateLimit, cfg.Server.TrustProxyHeaders)
+	}
+
+	t.Setenv("AWSCOGS_SERVER_RATE_LIMIT_KEYS", "dashboard,reports")
+	if cfg, err = Load(""); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !slices.Equal(cfg.Server.RateLimit.Keys, []string{"dashboard", "reports"}) {
+		t.Fatalf("Keys = %v", cfg.Server.RateLimit.Keys)
+	}
+
+	t.Setenv("AWSCOGS_API_RATE_LIMIT_KEY_HEADER", "")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for keys without a key header")
+	}
+	t.Setenv("AWSCOGS_API_RATE_LIMIT_KEY_HEADER", "X-API-Key")
+
+	t.Setenv("AWSCOGS_API_RATE_LIMIT", "-1")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for a negative rate limit")
+	}
+}
+
+func TestProfilesInheritTopLevelAWS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+aws:
+  assumeRoleName: ScanRole
+  regions: [us-east-1]
+  discoverRegions: false
+snapshots:
+  dir: /var/lib/awscogs
+profiles:
+  - name: dev-org
+    aws:
+      discoverAccounts: false
+      accounts:
+        - name: dev
+          roleArn: arn:aws:iam::222222222222:role/ScanRole
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	dev, ok := cfg.ForProfile("dev-org")
+	if !ok {
+		t.Fatal("expected dev-org profile")
+	}
+	if dev.Profile != "dev-org" || dev.AWS.AssumeRoleName != "ScanRole" || len(dev.AWS.Regions) != 1 || dev.AWS.DiscoverRegions {
+		t.Fatalf("expected profile to inherit top-level aws settings, got %+v", dev.AWS)
+	}
+	if dev.AWS.DiscoverAccounts || len(dev.AWS.Accounts) != 1 {
+		t.Fatalf("expected profile accounts to override, got %+v", dev.AWS)
+	}
+	if !cfg.AWS.DiscoverAccounts || len(cfg.AWS.Accounts) != 0 {
+		t.Fatalf("expected top-level aws settings to be unchanged, got %+v", cfg.AWS)
+	}
+	if dev.Snapshots.Dir != filepath.Join("/var/lib/awscogs", "profiles", "dev-org") {
+		t.Fatalf("expected isolated snapshot dir, got %q", dev.Snapshots.Dir)
+	}
+	if _, ok := cfg.ForProfile("prod-org"); ok {
+		t.Fatal("expected unknown profile to be rejected")
+	}
+
+	if err := os.WriteFile(path, []byte("profiles:\n  - name: a\n  - name: a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected an error for a duplicate profile")
+	}
+}
+
+func TestQuickScanFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := "aws:\n  accounts:\n    - name: prod\n      roleArn: arn:aws:iam::123456789012:role/Audit\nprofiles:\n  - name: other\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("AWSCOGS_QUICK_SCAN", "true")
+	t.Setenv("AWSCOGS_ENABLE_GOVCLOUD", "true")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.AWS.QuickScan || cfg.AWS.DiscoverAccounts || cfg.AWS.DiscoverRegions {
+		t.Fatalf("quick scan should disable discovery: %+v", cfg.AWS)
+	}
+	if len(cfg.AWS.Accounts) != 0 || cfg.AWS.GovCloud.Enabled || len(cfg.Profiles) != 0 {
+		t.Fatalf("quick scan should drop accounts, GovCloud, and profiles: %+v", cfg)
+	}
+}
+
+func TestScanOnStartupFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_SCAN_ON_STARTUP", "true")
+	t.Setenv("AWSCOGS_BLOCK_UNTIL_FIRST_SCAN", "true")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Server.ScanOnStartup || !cfg.Server.BlockUntilFirstScan {
+		t.Fatalf("server config = %+v", cfg.Server)
+	}
+
+	t.Setenv("AWSCOGS_SCAN_ON_STARTUP", "false")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected blocking until a first scan that never starts to be rejected")
+	}
+}
+
+func TestShutdownDrainFromEnv(t *testing.T) {
+	if got := DefaultConfig().Server.ShutdownDrainSeconds; got != 20 {
+		t.Errorf("default drain = %d, want 20", got)
+	}
+
+	t.Setenv("AWSCOGS_SHUTDOWN_DRAIN_SECONDS", "0")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.ShutdownDrainSeconds != 0 {
+		t.Errorf("drain = %d, want 0 from the environment", cfg.Server.ShutdownDrainSeconds)
+	}
+
+	cfg.Server.ShutdownDrainSeconds = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative drain to be rejected")
+	}
+}
+
+func TestScanJobsFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_SCAN_OVERLAP_POLICY", " Reject")
+	t.Setenv("AWSCOGS_MAX_CONCURRENT_SCANS", "4")
+	t.Setenv("AWSCOGS_MAX_QUEUED_SCANS", "8")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := ScanJobsConfig{OverlapPolicy: ScanOverlapReject, MaxConcurrent: 4, MaxQueued: 8}
+	if cfg.Server.ScanJobs != want {
+		t.Fatalf("ScanJobs = %+v, want %+v", cfg.Server.ScanJobs, want)
+	}
+
+	t.Setenv("AWSCOGS_SCAN_OVERLAP_POLICY", "drop")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an unknown overlap policy")
+	}
+}
+
+func TestLogLevelsFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_LOG_LEVELS", "discovery=debug, pricing = WARN")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Log.Components["discovery"] != "debug" || cfg.Log.Components["pricing"] != "WARN" {
+		t.Fatalf("Components = %v", cfg.Log.Components)
+	}
+
+	t.Setenv("AWSCOGS_LOG_LEVELS", "scheduler=debug")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an unknown log component")
+	}
+	t.Setenv("AWSCOGS_LOG_LEVELS", "")
+	t.Setenv("AWSCOGS_LOG_LEVEL", "verbose")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an unknown log level")
+	}
+}
+
+func TestAccessLogFromEnv(t *testing.T) {
+	t.Setenv("AWSCOGS_ACCESS_LOG_FORMAT", " CLF")
+	t.Setenv("AWSCOGS_ACCESS_LOG_BODY_SIZES", "true")
+	t.Setenv("AWSCOGS_ACCESS_LOG_USER_HEADER", "X-Forwarded-User")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := AccessLogConfig{Format: AccessLogCLF, BodySizes: true, UserHeader: "X-Forwarded-User"}
+	if cfg.Log.Access != want {
+		t.Fatalf("Access = %+v, want %+v", cfg.Log.Access, want)
+	}
+
+	t.Setenv("AWSCOGS_ACCESS_LOG_FORMAT", "apache")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for an unknown access log format")
+	}
+}
+
+func TestBindAddress(t *testing.T) {
+	t.Setenv("AWSCOGS_BIND_ADDRESS", " 127.0.0.1, ::1 ,[fe80::1]:9090")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	tests := []struct{ host, port string }{{"127.0.0.1", "8080"}, {"::1", "8080"}, {"fe80::1", "9090"}}
+	addrs := cfg.Server.BindAddresses()
+	if len(addrs) != len(tests) {
+		t.Fatalf("BindAddresses() = %q", addrs)
+	}
+	for i, addr := range addrs {
+		if host, port := SplitBindAddress(addr, cfg.Server.Port); host != tests[i].host || port != tests[i].port {
+			t.Errorf("SplitBindAddress(%q) = %q, %q, want %q, %q", addr, host, port, tests[i].host, tests[i].port)
+		}
+	}
+
+	for _, bad := range []string{":9090", "localhost:http", "127.0.0.1:70000"} {
+		t.Setenv("AWSCOGS_BIND_ADDRESS", bad)
+		if _, err := Load(""); err == nil {
+			t.Errorf("expected an error for bind address %q", bad)
+		}
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("AWSCOGS_SOCKET_PATH", "/run/awscogs/api.sock")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if mode, _ := cfg.Server.SocketFileMode(); cfg.Server.SocketPath != "/run/awscogs/api.sock" || mode != 0o660 {
+		t.Fatalf("socket = %s mode %v, want /run/awscogs/api.sock mode 0660", cfg.Server.SocketPath, mode)
+	}
+
+	t.Setenv("AWSCOGS_SOCKET_MODE", "0666")
+	if cfg, err = Load(""); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if mode, _ := cfg.Server.SocketFileMode(); mode != 0o666 {
+		t.Fatalf("mode = %v, want 0666", mode)
+	}
+
+	t.Setenv("AWSCOGS_SOCKET_MODE", "rw-rw----")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for a non-octal socket mode")
+	}
+
+	t.Setenv("AWSCOGS_SOCKET_MODE", "")
+	t.Setenv("AWSCOGS_BIND_ADDRESS", "127.0.0.1")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an error for a socket path with a bind address")
+	}
+}
+
+func TestEnvVarNames(t *testing.T) {
+	seen := make(map[string]string)
+	for _, v := range EnvVars() {
+		if other, ok := seen[v.Name]; ok {
+			t.Errorf("%s is used by both %s and %s", v.Name, other, v.Path)
+		}
+		seen[v.Name] = v.Path
+	}
+
+	for path, want := range map[string]string{
+		"cache.resourceTTLMinutes":              "AWSCOGS_CACHE_RESOURCE_TTL_MINUTES",
+		"aws.sts.hubRoleArn":                    "AWSCOGS_AWS_STS_HUB_ROLE_ARN",
+		"server.rateLimit.keyHeader":            "AWSCOGS_SERVER_RATE_LIMIT_KEY_HEADER",
+		"pricing.rateLimitPerSecond":            "AWSCOGS_PRICING_RATE_LIMIT_PER_SECOND",
+		"notifications.weeklyDigest.enabled":    "AWSCOGS_NOTIFICATIONS_WEEKLY_DIGEST_ENABLED",
+		"notifications.expensiveResourceHourly": "AWSCOGS_NOTIFICATIONS_EXPENSIVE_RESOURCE_HOURLY",
+	} {
+		if got := envName(strings.Split(path, ".")); got != want {
+			t.Errorf("envName(%s) = %s, want %s", path, got, want)
+		}
+	}
+	if _, ok := seen["AWSCOGS_AWS_GOVCLOUD_ENABLED"]; ok {
+		t.Error("aws.govcloud.enabled should have no variable")
+	}
+}
+
+func TestApplyEnvVars(t *testing.T) {
+	t.Setenv("AWSCOGS_CACHE_RESOURCE_TTL_MINUTES", "45")
+	t.Setenv("AWSCOGS_AWS_SERVICES", "ec2, rds")
+	t.Setenv("AWSCOGS_AWS_DISCOVER_ACCOUNTS", "false")
+	t.Setenv("AWSCOGS_AWS_ACCOUNTS", "prod=arn:aws:iam::111111111111:role/Reader,dev")
+	t.Setenv("AWSCOGS_BUDGET_ACCOUNTS", "prod=1000, dev=250.5")
+	t.Setenv("AWSCOGS_PROFILES", `[{"name": "staging", "aws": {"discoverRegions": false, "regions": ["eu-west-1"]}}]`)
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Cache.ResourceTTLMinutes != 45 {
+		t.Errorf("ResourceTTLMinutes = %d, want 45", cfg.Cache.ResourceTTLMinutes)
+	}
+	if !slices.Equal(cfg.AWS.Services, []string{"ec2", "rds"}) {
+		t.Errorf("Services = %v, want [ec2 rds]", cfg.AWS.Services)
+	}
+	if cfg.AWS.DiscoverAccounts || len(cfg.AWS.Accounts) != 2 || cfg.AWS.Accounts[0].RoleARN != "arn:aws:iam::111111111111:role/Reader" || cfg.AWS.Accounts[1].Name != "dev" {
+		t.Errorf("accounts = %v %+v", cfg.AWS.DiscoverAccounts, cfg.AWS.Accounts)
+	}
+	if cfg.Budget.Accounts["prod"] != 1000 || cfg.Budget.Accounts["dev"] != 250.5 {
+		t.Errorf("budget accounts = %v", cfg.Budget.Accounts)
+	}
+	staging, ok := cfg.ForProfile("staging")
+	if !ok || !slices.Equal(staging.AWS.Regions, []string{"eu-west-1"}) {
+		t.Errorf("staging profile = %v %+v", ok, staging.AWS)
+	}
+
+	// Accounts can also be given as JSON
+	t.Setenv("AWSCOGS_AWS_ACCOUNTS", `[{"name": "prod", "roleArn": "arn:aws:iam::111111111111:role/Reader"}]`)
+	if cfg, err = Load(""); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.AWS.Accounts) != 1 || cfg.AWS.Accounts[0].Name != "prod" {
+		t.Errorf("accounts = %+v", cfg.AWS.Accounts)
+	}
+
+	t.Setenv("AWSCOGS_CACHE_RESOURCE_TTL_MINUTES", "soon")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "AWSCOGS_CACHE_RESOURCE_TTL_MINUTES") {
+		t.Fatalf("Load() error = %v, want one naming the variable", err)
+	}
+}
+
+func TestLegacyEnvVarsWin(t *testing.T) {
+	t.Setenv("AWSCOGS_AWS_SERVICES", "ec2")
+	t.Setenv("AWSCOGS_SERVICES", "rds")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !slices.Equal(cfg.AWS.Services, []string{"rds"}) {
+		t.Fatalf("Services = %v, want [rds]", cfg.AWS.Services)
+	}
+}
+
+func TestEnvAliases(t *testing.T) {
+	vars := make(map[string]bool)
+	for _, v := range EnvVars() {
+		vars[v.Name] = true
+	}
+	for _, alias := range EnvAliases {
+		if !vars[alias.Replacement] {
+			t.Errorf("%s is replaced by %s, which sets no setting", alias.Name, alias.Replacement)
+		}
+		if vars[alias.Name] {
+			t.Errorf("%s is already the variable of a setting", alias.Name)
+		}
+	}
+
+	for name, value := range map[string]string{
+		"AWSCOGS_PORT":                   "abc",
+		"AWSCOGS_SCAN_ON_STARTUP":        "sometimes",
+		"AWSCOGS_NOTIFY_ANOMALY_PERCENT": "ten",
+		"AWSCOGS_LOG_LEVELS":             "discovery",
+		"AWSCOGS_ENABLE_GOVCLOUD":        "maybe",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := Load(""); err == nil || !strings.Contains(err.Error(), name) {
+				t.Fatalf("Load() error = %v, want one naming %s", err, name)
+			}
+		})
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `server:
+  port: eighty
+aws:
+  regons: [us-east-1]
+  rateLimit:
+    burts: 5
+profiles:
+  - name: dev
+    aws:
+      discoverAcounts: false
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected errors for unknown keys and a bad port")
+	}
+	for _, want := range []string{
+		`line 2: cannot unmarshal !!str ` + "`eighty`",
+		`line 4: aws.regons: unknown key "regons" (did you mean "regions"?)`,
+		`line 6: aws.rateLimit.burts: unknown key "burts" (did you mean "burst"?)`,
+		`line 10: profiles[0].aws.discoverAcounts: unknown key "discoverAcounts" (did you mean "discoverAccounts"?)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q\nis missing %q", err, want)
+		}
+	}
+
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) || fileErr.Line == 0 {
+		t.Errorf("expected a FileError with a line, got %v", fileErr)
+	}
+}
+
+func TestExclusiveSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `aws:
+  discoverAccounts: true
+  accounts:
+    - name: prod
+profiles:
+  - name: dev
+    aws:
+      discoverRegions: true
+      regions: [us-east-1]
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected errors for accounts and regions that discovery ignores")
+	}
+	for _, want := range []string{"line 3: aws.accounts:", "line 9: profiles[0].aws.regions:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q\nis missing %q", err, want)
+		}
+	}
+
+	// Turning discovery off in the environment resolves both
+	t.Setenv("AWSCOGS_DISCOVER_ACCOUNTS", "false")
+	t.Setenv("AWSCOGS_DISCOVER_REGIONS", "false")
+	if _, err := Load(path); err == nil || strings.Contains(err.Error(), "aws.accounts") {
+		t.Fatalf("Load() error = %v, want only the profile's regions", err)
+	}
+}
+
+func TestOffHoursScheduleValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.Recommend.OffHours.ScheduledHoursPerWeek(); got != 55 {
+		t.Fatalf("default scheduled hours = %d, want 55", got)
+	}
+
+	cfg.Recommend.OffHours.StartHour, cfg.Recommend.OffHours.EndHour = 18, 8
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a schedule that ends before it starts")
+	}
+
+	cfg = DefaultConfig()
+	cfg.Recommend.OffHours.Weekdays = []string{"monday", "funday"}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for an unknown weekday")
+	}
+}
+
+func TestCostCenterRuleValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CostCenters.Rules = []CostCenterRule{{CostCenter: "platform", Tags: map[string]string{"team": "platform"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a valid rule, got %v", err)
+	}
+
+	cfg.CostCenters.Rules = append(cfg.CostCenters.Rules, CostCenterRule{CostCenter: "everything"})
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a rule with no accounts or tags")
+	}
+
+	cfg.CostCenters.Rules = []CostCenterRule{{Accounts: []string{"prod"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a rule with no cost center")
+	}
+
+	cfg.CostCenters.Rules = nil
+	cfg.CostCenters.SharedCosts = []SharedCostRule{{ResourceTypes: []string{"nat", "eks"}, Split: "weighted"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for an unknown shared cost split")
+	}
+
+	cfg.CostCenters.SharedCosts = []SharedCostRule{{ResourceTypes: []string{"natgw"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for an unknown shared resource type")
+	}
+}
+
+func TestIgnoreRuleValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Ignore.Rules = []IgnoreRule{{Reason: "DR standby", Resources: []string{"dr-*"}, ResourceTypes: []string{"rds"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a valid rule, got %v", err)
+	}
+
+	cfg.Ignore.Rules = []IgnoreRule{{Reason: "everything in prod", Accounts: []string{"prod"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a rule with no resources or tags")
+	}
+
+	cfg.Ignore.Rules = []IgnoreRule{{Tags: map[string]string{"team": "dr"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a rule with no reason")
+	}
+
+	cfg.Ignore.Rules = []IgnoreRule{{Reason: "standby", Resources: []string{"dr-*"}, ResourceTypes: []string{"database"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for an unknown resource type")
+	}
+}
+
+func TestCostThresholds(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CostThresholds = CostThresholdConfig{Hourly: 1, ResourceTypes: map[string]float64{"rds": 2, "eip": 0}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid thresholds, got %v", err)
+	}
+	if cfg.CostThresholds.Limit("ec2") != 1 || cfg.CostThresholds.Limit("rds") != 2 || cfg.CostThresholds.Limit("eip") != 0 {
+		t.Errorf("limits = %v, %v, %v; want 1, 2, 0", cfg.CostThresholds.Limit("ec2"), cfg.CostThresholds.Limit("rds"), cfg.CostThresholds.Limit("eip"))
+	}
+
+	cfg.CostThresholds.ResourceTypes = map[string]float64{"database": 2}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for an unknown resource type")
+	}
+	cfg.CostThresholds = CostThresholdConfig{Hourly: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a negative threshold")
+	}
+}
+
+func TestPluginValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.Plugins = []PluginConfig{{Name: "mwaa", URL: "http://localhost:9000"}}
+	cfg.AWS.Services = []string{"ec2", "mwaa"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a valid plugin, got %v", err)
+	}
+	if got := cfg.AWS.EnabledServices(); !slices.Equal(got, []string{"ec2", "mwaa"}) {
+		t.Fatalf("enabled services = %v", got)
+	}
+	cfg.AWS.Services = nil
+	if got := cfg.AWS.EnabledServices(); got[len(got)-1] != "mwaa" {
+		t.Fatalf("enabled services = %v, want the plugin's type last", got)
+	}
+
+	for _, p := range []PluginConfig{
+		{Name: "ec2", URL: "http://localhost:9000"},
+		{Name: "Mwaa", URL: "http://localhost:9000"},
+		{Name: "mwaa"},
+		{Name: "mwaa", URL: "http://localhost:9000", Path: "mwaa.so"},
+		{Name: "mwaa", URL: "localhost:9000"},
+		{Name: "mwaa", Path: "mwaa.so", Scope: "zonal"},
+		{Name: "mwaa", Path: "mwaa.so", TimeoutSeconds: -1},
+		{Name: "mwaa", URL: "http://mwaa.internal:9000", SendCredentials: true},
+	} {
+		cfg := DefaultConfig()
+		cfg.AWS.Plugins = []PluginConfig{p}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for plugin %+v", p)
+		}
+	}
+
+	for _, url := range []string{"https://mwaa.internal", "http://localhost:9000", "http://127.0.0.1:9000", "http://[::1]:9000"} {
+		cfg := DefaultConfig()
+		cfg.AWS.Plugins = []PluginConfig{{Name: "mwaa", URL: url, SendCredentials: true}}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected credentials to be allowed for %s, got %v", url, err)
+		}
+	}
+
+	cfg = DefaultConfig()
+	cfg.AWS.Plugins = []PluginConfig{{Name: "mwaa", Path: "a.so"}, {Name: "mwaa", Path: "b.so"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for duplicate plugins")
+	}
+}
+
+func TestPriceOverrideValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Pricing.Overrides = PriceOverrideConfig{
+		DiscountPercent: 12,
+		Rates:           []PinnedRate{{Service: "ec2", SKU: "m5.large", Hourly: 0.07}, {Service: "ec2", SKU: "m5.large", Region: "us-east-1", Hourly: 0.06}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid overrides, got %v", err)
+	}
+
+	for _, o := range []PriceOverrideConfig{
+		{DiscountPercent: -1},
+		{DiscountPercent: 100},
+		{Rates: []PinnedRate{{Service: "lambda", SKU: "arm64", Hourly: 1}}},
+		{Rates: []PinnedRate{{Service: "ec2", Hourly: 1}}},
+		{Rates: []PinnedRate{{Service: "rds", SKU: "db.t3.micro", Hourly: -1}}},
+		{Rates: []PinnedRate{{Service: "ec2", SKU: "m5.large", Hourly: 1}, {Service: "ec2", SKU: "m5.large", Hourly: 2}}},
+	} {
+		cfg := DefaultConfig()
+		cfg.Pricing.Overrides = o
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for overrides %+v", o)
+		}
+	}
+}
+
+func TestBillingValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Billing = BillingConfig{DiscountPercent: 8, MonthlyCredits: 5000}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid billing settings, got %v", err)
+	}
+
+	cfg.Billing = BillingConfig{DiscountPercent: 100}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a 100% billing discount")
+	}
+	cfg.Billing = BillingConfig{MonthlyCredits: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for negative credits")
+	}
+
+	cfg.Billing = BillingConfig{DiscountPercent: 8}
+	cfg.Pricing.Overrides.DiscountPercent = 8
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a discount set both on the bill and on list prices")
+	}
+}
+
+func TestDataTransferValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.DataTransfer.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected the default data transfer assumptions to be valid, got %v", err)
+	}
+	if !slices.Contains(cfg.AWS.ResourceTypes(), DataTransferResourceType) {
+		t.Fatalf("expected %q in resource types when enabled, got %v", DataTransferResourceType, cfg.AWS.ResourceTypes())
+	}
+
+	cfg.AWS.DataTransfer.InternetPercent = 80
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for percentages over 100")
+	}
+	cfg.AWS.DataTransfer.InternetPercent = 10
+	cfg.AWS.DataTransfer.NATPerGB = -0.01
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a negative rate")
+	}
+}
+
+func TestMessagingValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.Messaging.SNSPerMillion = -0.5
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a negative messaging rate")
+	}
+}
+
+func TestCloudWatchValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.CloudWatch.DashboardPerMonth = -3
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a negative CloudWatch rate")
+	}
+}
+
+func TestSnapshotRetentionValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Snapshots.Retention = RetentionConfig{HourlyDays: 7, DailyDays: 90, MonthlyMonths: 24}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid retention, got %v", err)
+	}
+
+	cfg.Snapshots.Retention = RetentionConfig{HourlyDays: 30, DailyDays: 7}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a daily window shorter than the hourly one")
+	}
+	cfg.Snapshots.Retention = RetentionConfig{RawHours: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a negative window")
+	}
+}
+
+func TestEndpointURLValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AWS.EndpointURL = "http://localhost:4566"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a LocalStack endpoint to be valid, got %v", err)
+	}
+	for _, endpoint := range []string{"localhost:4566", "ftp://localhost", "http://"} {
+		cfg.AWS.EndpointURL = endpoint
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for endpoint %q", endpoint)
+		}
+	}
+}
+
+func TestAccountMetadataValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccountMetadata = []AccountMetadataConfig{
+		{ID: "111111111111", DisplayName: "Payments prod", Environment: "prod", OwnerEmail: "payments@example.com", Color: "#1F77B4"},
+		{ID: "222222222222", Environment: "dev"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid account metadata, got %v", err)
+	}
+
+	for _, m := range []AccountMetadataConfig{
+		{ID: "12345", DisplayName: "short ID"},
+		{ID: "111111111111", Environment: "Prod"},
+		{ID: "111111111111", OwnerEmail: "not an email"},
+		{ID: "111111111111", Color: "blue"},
+	} {
+		if err := m.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", m)
+		}
+	}
+
+	cfg.AccountMetadata = append(cfg.AccountMetadata, AccountMetadataConfig{ID: "111111111111", DisplayName: "again"})
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a duplicate account ID")
+	}
+}
+
+func TestEnvironmentValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Environments.Rules = []EnvironmentRule{{Environment: "sandbox", Accounts: []string{"333333333333"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid environment rules, got %v", err)
+	}
+
+	cfg.Environments.Rules = []EnvironmentRule{{Environment: "sandbox"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for a rule without accounts or tags")
+	}
+	cfg.Environments.Rules = []EnvironmentRule{{Environment: "Prod", Tags: map[string]string{"team": "*"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for an uppercase environment")
+	}
+}
+
+func TestMissingPriceValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, policy := range MissingPricePolicies {
+		cfg.Pricing.MissingPrice = policy
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected %q to be valid, got %v", policy, err)
+		}
+	}
+	cfg.Pricing.MissingPrice = "guess"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the name of every environment variable awsCOGS reads
+const EnvPrefix = "AWSCOGS_"
+
+// EnvVar is an environment variable that sets one config file setting. Its
+// name is derived from the setting's YAML path: server.rateLimit.burst is
+// AWSCOGS_SERVER_RATE_LIMIT_BURST.
+type EnvVar struct {
+	Name   string // e.g. AWSCOGS_SERVER_RATE_LIMIT_BURST
+	Path   string // YAML path, e.g. server.rateLimit.burst
+	Format string // How the value is written: string, int, float, bool, list, accounts, map, or yaml
+
+	index []int // Field index within Config
+}
+
+// EnvVars returns the environment variable for every config setting, in the
+// order the settings are declared. Settings tagged env:"-" have none.
+func EnvVars() []EnvVar {
+	return envVarsOf(reflect.TypeFor[Config](), nil, nil)
+}
+
+func envVarsOf(t reflect.Type, path []string, index []int) []EnvVar {
+	var vars []EnvVar
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || key == "-" || key == "" || field.Tag.Get("env") == "-" {
+			continue
+		}
+		fieldPath := append(slices.Clone(path), key)
+		fieldIndex := append(slices.Clone(index), i)
+		if field.Type.Kind() == reflect.Struct {
+			vars = append(vars, envVarsOf(field.Type, fieldPath, fieldIndex)...)
+			continue
+		}
+		vars = append(vars, EnvVar{
+			Name:   envName(fieldPath),
+			Path:   strings.Join(fieldPath, "."),
+			Format: envFormat(field.Type),
+			index:  fieldIndex,
+		})
+	}
+	return vars
+}
+
+// envName returns the environment variable for a YAML path, with each
+// camelCase key in upper snake case
+func envName(path []string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, key := range path {
+		if i > 0 {
+			b.WriteByte('_')
+		}
+		runes := []rune(key)
+		for j, r := range runes {
+			// Split before an upper case letter that starts a word: after a
+			// lower case letter or digit, or ending an acronym as in TTLMinutes
+			if j > 0 && unicode.IsUpper(r) {
+				prev := runes[j-1]
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (j+1 < len(runes) && unicode.IsLower(runes[j+1])) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// envFormat describes how a setting of type t is written in its variable
+func envFormat(t reflect.Type) string {
+	switch {
+	case t == reflect.TypeFor[[]AccountConfig]():
+		return "accounts"
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String:
+		return "list"
+	case t.Kind() == reflect.Map && t.Elem().Kind() != reflect.Struct && t.Elem().Kind() != reflect.Slice:
+		return "map"
+	case t.Kind() == reflect.String:
+		return "string"
+	case t.Kind() == reflect.Bool:
+		return "bool"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return "int"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "float"
+	}
+	return "yaml"
+}
+
+// applyEnvVars sets each setting whose environment variable is set. Lists take
+// comma-separated values, maps comma-separated key=value pairs, and accounts
+// the AWSCOGS_ACCOUNTS format; any of them, and every other structured
+// setting, can also be given as JSON or YAML.
+func (c *Config) applyEnvVars() error {
+	var errs []error
+	for _, v := range EnvVars() {
+		errs = append(errs, c.applyEnvVar(v.Name, v, false))
+	}
+	return errors.Join(errs...)
+}
+
+// EnvAlias is a deprecated environment variable name from before every
+// setting had one. It sets the same setting as Replacement, parsed the same
+// way, and wins where both are set.
+type EnvAlias struct {
+	Name        string // e.g. AWSCOGS_PORT
+	Replacement string // e.g. AWSCOGS_SERVER_PORT
+
+	lower bool // The value is lower-cased first, as it always was
+}
+
+// EnvAliases lists the deprecated names in the order they're applied
+var EnvAliases = []EnvAlias{
+	{Name: "AWSCOGS_PORT", Replacement: "AWSCOGS_SERVER_PORT"},
+	{Name: "AWSCOGS_BIND_ADDRESS", Replacement: "AWSCOGS_SERVER_BIND_ADDRESS"},
+	{Name: "AWSCOGS_SOCKET_PATH", Replacement: "AWSCOGS_SERVER_SOCKET_PATH"},
+	{Name: "AWSCOGS_SOCKET_MODE", Replacement: "AWSCOGS_SERVER_SOCKET_MODE"},
+	{Name: "AWSCOGS_BASE_PATH", Replacement: "AWSCOGS_SERVER_BASE_PATH"},
+	{Name: "AWSCOGS_DEBUG_ADDR", Replacement: "AWSCOGS_SERVER_DEBUG_ADDR"},
+	{Name: "AWSCOGS_SCAN_ON_STARTUP", Replacement: "AWSCOGS_SERVER_SCAN_ON_STARTUP"},
+	{Name: "AWSCOGS_BLOCK_UNTIL_FIRST_SCAN", Replacement: "AWSCOGS_SERVER_BLOCK_UNTIL_FIRST_SCAN"},
+	{Name: "AWSCOGS_SCAN_OVERLAP_POLICY", Replacement: "AWSCOGS_SERVER_SCAN_JOBS_OVERLAP_POLICY", lower: true},
+	{Name: "AWSCOGS_SHUTDOWN_DRAIN_SECONDS", Replacement: "AWSCOGS_SERVER_SHUTDOWN_DRAIN_SECONDS"},
+	{Name: "AWSCOGS_MAX_CONCURRENT_SCANS", Replacement: "AWSCOGS_SERVER_SCAN_JOBS_MAX_CONCURRENT"},
+	{Name: "AWSCOGS_MAX_QUEUED_SCANS", Replacement: "AWSCOGS_SERVER_SCAN_JOBS_MAX_QUEUED"},
+	{Name: "AWSCOGS_CORS_ALLOWED_ORIGINS", Replacement: "AWSCOGS_SERVER_CORS_ALLOWED_ORIGINS"},
+	{Name: "AWSCOGS_CORS_ALLOWED_HEADERS", Replacement: "AWSCOGS_SERVER_CORS_ALLOWED_HEADERS"},
+	{Name: "AWSCOGS_CORS_ALLOW_CREDENTIALS", Replacement: "AWSCOGS_SERVER_CORS_ALLOW_CREDENTIALS"},
+	{Name: "AWSCOGS_SECURITY_HEADERS", Replacement: "AWSCOGS_SERVER_SECURITY_HEADERS_ENABLED"},
+	{Name: "AWSCOGS_HSTS_MAX_AGE_SECONDS", Replacement: "AWSCOGS_SERVER_SECURITY_HEADERS_HSTS_MAX_AGE_SECONDS"},
+	{Name: "AWSCOGS_CONTENT_SECURITY_POLICY", Replacement: "AWSCOGS_SERVER_SECURITY_HEADERS_CONTENT_SECURITY_POLICY"},
+	{Name: "AWSCOGS_API_RATE_LIMIT", Replacement: "AWSCOGS_SERVER_RATE_LIMIT_REQUESTS_PER_MINUTE"},
+	{Name: "AWSCOGS_API_RATE_LIMIT_BURST", Replacement: "AWSCOGS_SERVER_RATE_LIMIT_BURST"},
+	{Name: "AWSCOGS_API_RATE_LIMIT_KEY_HEADER", Replacement: "AWSCOGS_SERVER_RATE_LIMIT_KEY_HEADER"},
+	{Name: "AWSCOGS_TRUST_PROXY_HEADERS", Replacement: "AWSCOGS_SERVER_TRUST_PROXY_HEADERS"},
+	{Name: "AWSCOGS_ACCESS_LOG_FORMAT", Replacement: "AWSCOGS_LOG_ACCESS_FORMAT", lower: true},
+	{Name: "AWSCOGS_ACCESS_LOG_BODY_SIZES", Replacement: "AWSCOGS_LOG_ACCESS_BODY_SIZES"},
+	{Name: "AWSCOGS_ACCESS_LOG_USER_HEADER", Replacement: "AWSCOGS_LOG_ACCESS_USER_HEADER"},
+	{Name: "AWSCOGS_LOG_LEVELS", Replacement: "AWSCOGS_LOG_COMPONENTS"},
+	{Name: "AWSCOGS_QUICK_SCAN", Replacement: "AWSCOGS_AWS_QUICK_SCAN"},
+	{Name: "AWSCOGS_REGIONS", Replacement: "AWSCOGS_AWS_REGIONS"},
+	{Name: "AWSCOGS_SERVICES", Replacement: "AWSCOGS_AWS_SERVICES", lower: true},
+	{Name: "AWSCOGS_STS_REGION", Replacement: "AWSCOGS_AWS_STS_REGION"},
+	{Name: "AWSCOGS_STS_GLOBAL_ENDPOINT", Replacement: "AWSCOGS_AWS_STS_GLOBAL_ENDPOINT"},
+	{Name: "AWSCOGS_STS_HUB_ROLE_ARN", Replacement: "AWSCOGS_AWS_STS_HUB_ROLE_ARN"},
+	{Name: "AWSCOGS_AWS_RATE_LIMIT", Replacement: "AWSCOGS_AWS_RATE_LIMIT_REQUESTS_PER_SECOND"},
+	{Name: "AWSCOGS_AWS_RATE_BURST", Replacement: "AWSCOGS_AWS_RATE_LIMIT_BURST"},
+	{Name: "AWSCOGS_CLOUDTRAIL_LOOKUP_OWNERS", Replacement: "AWSCOGS_AWS_CLOUD_TRAIL_LOOKUP_OWNERS"},
+	{Name: "AWSCOGS_CLOUDTRAIL_LOOKBACK_DAYS", Replacement: "AWSCOGS_AWS_CLOUD_TRAIL_LOOKBACK_DAYS"},
+	{Name: "AWSCOGS_DISCOVER_REGIONS", Replacement: "AWSCOGS_AWS_DISCOVER_REGIONS"},
+	{Name: "AWSCOGS_DISCOVER_ACCOUNTS", Replacement: "AWSCOGS_AWS_DISCOVER_ACCOUNTS"},
+	{Name: "AWSCOGS_ASSUME_ROLE_NAME", Replacement: "AWSCOGS_AWS_ASSUME_ROLE_NAME"},
+	{Name: "AWSCOGS_PRICING_REFRESH_MINUTES", Replacement: "AWSCOGS_PRICING_REFRESH_INTERVAL_MINUTES"},
+	{Name: "AWSCOGS_PRICING_RATE_LIMIT", Replacement: "AWSCOGS_PRICING_RATE_LIMIT_PER_SECOND"},
+	{Name: "AWSCOGS_SNAPSHOT_DIR", Replacement: "AWSCOGS_SNAPSHOTS_DIR"},
+	{Name: "AWSCOGS_SNAPSHOT_MAX_COUNT", Replacement: "AWSCOGS_SNAPSHOTS_MAX_COUNT"},
+	{Name: "AWSCOGS_NOTIFY_EXPENSIVE_HOURLY", Replacement: "AWSCOGS_NOTIFICATIONS_EXPENSIVE_RESOURCE_HOURLY"},
+	{Name: "AWSCOGS_NOTIFY_ANOMALY_PERCENT", Replacement: "AWSCOGS_NOTIFICATIONS_ANOMALY_PERCENT"},
+	{Name: "AWSCOGS_NOTIFY_WEEKLY_DIGEST", Replacement: "AWSCOGS_NOTIFICATIONS_WEEKLY_DIGEST_ENABLED"},
+	{Name: "AWSCOGS_DATADOG_API_KEY", Replacement: "AWSCOGS_EXPORT_DATADOG_API_KEY"},
+	{Name: "AWSCOGS_DATADOG_SITE", Replacement: "AWSCOGS_EXPORT_DATADOG_SITE"},
+	{Name: "AWSCOGS_DATADOG_TAGS", Replacement: "AWSCOGS_EXPORT_DATADOG_TAGS"},
+	{Name: "AWSCOGS_EXPORT_SNS_TOPIC_ARN", Replacement: "AWSCOGS_EXPORT_PUBLISH_SNS_TOPIC_ARN"},
+	{Name: "AWSCOGS_EXPORT_SQS_QUEUE_URL", Replacement: "AWSCOGS_EXPORT_PUBLISH_SQS_QUEUE_URL"},
+	{Name: "AWSCOGS_EXPORT_FORMAT", Replacement: "AWSCOGS_EXPORT_PUBLISH_FORMAT", lower: true},
+	{Name: "AWSCOGS_ENABLE_AZURE", Replacement: "AWSCOGS_AZURE_ENABLED"},
+	{Name: "AWSCOGS_GOVCLOUD_REGIONS", Replacement: "AWSCOGS_AWS_GOVCLOUD_REGIONS"},
+	{Name: "AWSCOGS_GOVCLOUD_ACCOUNTS", Replacement: "AWSCOGS_AWS_GOVCLOUD_ACCOUNTS"},
+	{Name: "AWSCOGS_GOVCLOUD_DISCOVER_ACCOUNTS", Replacement: "AWSCOGS_AWS_GOVCLOUD_DISCOVER_ACCOUNTS"},
+	{Name: "AWSCOGS_GOVCLOUD_DISCOVER_REGIONS", Replacement: "AWSCOGS_AWS_GOVCLOUD_DISCOVER_REGIONS"},
+	{Name: "AWSCOGS_GOVCLOUD_ASSUME_ROLE_NAME", Replacement: "AWSCOGS_AWS_GOVCLOUD_ASSUME_ROLE_NAME"},
+}
+
+// applyEnvAliases sets each setting whose deprecated name is set
+func (c *Config) applyEnvAliases() error {
+	vars := make(map[string]EnvVar)
+	for _, v := range EnvVars() {
+		vars[v.Name] = v
+	}
+	var errs []error
+	for _, alias := range EnvAliases {
+		errs = append(errs, c.applyEnvVar(alias.Name, vars[alias.Replacement], alias.lower))
+	}
+	return errors.Join(errs...)
+}
+
+// applyEnvVar sets v's setting from the environment variable name, if it's
+// set
+func (c *Config) applyEnvVar(name string, v EnvVar, lower bool) error {
+	value, ok := os.LookupEnv(name)
+	value = strings.TrimSpace(value)
+	// An empty number or flag is treated as unset; an empty string or list
+	// clears the setting
+	if !ok || (value == "" && (v.Format == "int" || v.Format == "float" || v.Format == "bool")) {
+		return nil
+	}
+	if lower {
+		value = strings.ToLower(value)
+	}
+	if err := setEnvValue(reflect.ValueOf(c).Elem().FieldByIndex(v.index), v.Format, value); err != nil {
+		return fmt.Errorf("%s (%s): %w", name, v.Path, err)
+	}
+	return nil
+}
+
+// setEnvValue parses value into field
+func setEnvValue(field reflect.Value, format, value string) error {
+	structured := strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{")
+	switch {
+	case format == "string":
+		field.SetString(value)
+		return nil
+	case format == "bool":
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+		return nil
+	case format == "list" && !structured:
+		field.Set(reflect.ValueOf(splitCSV(value)).Convert(field.Type()))
+		return nil
+	case format == "accounts" && !structured:
+		field.Set(reflect.ValueOf(parseAccountList(value)))
+		return nil
+	case format == "map" && !structured:
+		// key=value pairs become a YAML mapping, so values are typed like the
+		// config file's
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, pair := range splitCSV(value) {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q isn't key=value", pair)
+			}
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(k)},
+				&yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(v)})
+		}
+		return decodeInto(field, func(out any) error { return node.Decode(out) })
+	}
+	return decodeInto(field, func(out any) error { return yaml.Unmarshal([]byte(value), out) })
+}
+
+// decodeInto decodes into a new value of field's type and sets field to it,
+// so a failed decode leaves field unchanged
+func decodeInto(field reflect.Value, decode func(any) error) error {
+	out := reflect.New(field.Type())
+	if err := decode(out.Interface()); err != nil {
+		return err
+	}
+	field.Set(out.Elem())
+	return nil
+}
+
+// parseBool parses a flag written as true/false, yes/no, on/off, or 1/0
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q isn't true or false", value)
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileError is a problem with a setting in the config file
+type FileError struct {
+	Line    int    // Line of the setting in the file, or 0 if it came from the environment
+	Path    string // Setting path, e.g. aws.regions
+	Message string
+}
+
+func (e *FileError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Path, e.Message)
+}
+
+// parseFile decodes a config file over cfg. Unknown keys and values of the
+// wrong type are all reported together, rather than stopping at the first.
+func parseFile(data []byte, cfg *Config) (*yaml.Node, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil // Empty file
+	}
+	doc := root.Content[0]
+
+	errs := checkKeys(doc, reflect.TypeFor[Config](), "")
+	if err := doc.Decode(cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+	return doc, errors.Join(errs...)
+}
+
+// checkKeys reports keys in node that no field of t has, suggesting the
+// nearest known key for likely typos
+func checkKeys(node *yaml.Node, t reflect.Type, path string) []error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var errs []error
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue // Merge key; the merged mapping is checked where it's defined
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown key %q", key.Value)
+				if s := suggestKey(key.Value, fields); s != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				errs = append(errs, &FileError{Line: key.Line, Path: joinPath(path, key.Value), Message: msg})
+				continue
+			}
+			errs = append(errs, checkKeys(value, field.Type, joinPath(path, key.Value))...)
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			errs = append(errs, checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))...)
+		}
+	}
+	return errs
+}
+
+// yamlFields returns t's fields by YAML key, including those of inlined
+// structs
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || key == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for k, f := range yamlFields(field.Type) {
+				fields[k] = f
+			}
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		fields[key] = field
+	}
+	return fields
+}
+
+// suggestKey returns the known key closest to key, if it's close enough to
+// be a typo
+func suggestKey(key string, fields map[string]reflect.StructField) string {
+	best, bestDistance := "", 3 // Suggest at most two edits away
+	for known := range fields {
+		d := editDistance(strings.ToLower(key), strings.ToLower(known))
+		if d < bestDistance || (d == bestDistance && best != "" && known < best) {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// checkExclusive reports settings that cancel each other out once the file
+// and environment are applied, with the line of the setting in the file
+// where there is one. doc is the config file, or nil if there is none.
+func (c *Config) checkExclusive(doc *yaml.Node) error {
+	var errs []error
+	check := func(node *yaml.Node, path string, aws AWSConfig) {
+		if aws.DiscoverAccounts && len(aws.Accounts) > 0 {
+			errs = append(errs, &FileError{
+				Line:    keyLine(node, "aws", "accounts"),
+				Path:    joinPath(path, "aws.accounts"),
+				Message: "accounts are ignored while discoverAccounts is true; set discoverAccounts: false to scan only these accounts",
+			})
+		}
+		if aws.DiscoverRegions && len(aws.Regions) > 0 {
+			errs = append(errs, &FileError{
+				Line:    keyLine(node, "aws", "regions"),
+				Path:    joinPath(path, "aws.regions"),
+				Message: "regions are ignored while discoverRegions is true; set discoverRegions: false to scan only these regions",
+			})
+		}
+	}
+	check(doc, "", c.AWS)
+	for i, p := range c.Profiles {
+		check(profileNode(doc, p.Name), fmt.Sprintf("profiles[%d]", i), p.AWS)
+	}
+
+	if c.Server.SocketPath != "" && c.Server.BindAddress != "" {
+		errs = append(errs, &FileError{
+			Line:    keyLine(doc, "server", "socketPath"),
+			Path:    "server.socketPath",
+			Message: "can't be combined with server.bindAddress: the API listens on one or the other",
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// profileNode returns the config file's profile named name, or nil if the
+// file has none, e.g. because profiles were set from the environment
+func profileNode(doc *yaml.Node, name string) *yaml.Node {
+	if doc == nil || doc.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "profiles" || doc.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, item := range doc.Content[i+1].Content {
+			var p struct {
+				Name string `yaml:"name"`
+			}
+			if item.Decode(&p) == nil && p.Name == name {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// keyLine returns the line of the key at path under node, or 0 if node is
+// nil or has no such key
+func keyLine(node *yaml.Node, path ...string) int {
+	line := 0
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return 0
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				line, next = node.Content[i].Line, node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return 0
+		}
+		node = next
+	}
+	return line
+}
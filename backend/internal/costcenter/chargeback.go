@@ -0,0 +1,173 @@
+package costcenter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// PeriodHours are the chargeback periods and the hours each is charged for.
+// A month is the 730 hours used for monthly costs everywhere else.
+var PeriodHours = map[string]float64{
+	"hour":  1,
+	"day":   24,
+	"month": types.HoursPerMonth,
+}
+
+// ChargebackLine is what one cost center is charged for a period
+type ChargebackLine struct {
+	CostCenter string          `json:"costCenter"`
+	Resources  int             `json:"resources"`  // Resources charged directly
+	DirectCost types.CostValue `json:"directCost"` // Cost of its own resources
+	SharedCost types.CostValue `json:"sharedCost"` // Its share of shared resources
+	TotalCost  types.CostValue `json:"totalCost"`
+	Percent    float64         `json:"percent"` // Share of the report's total cost
+}
+
+// ChargebackReport is the API response for the chargeback report
+type ChargebackReport struct {
+	Timestamp   string               `json:"timestamp"`
+	Status      string               `json:"status"`
+	Diagnostics []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Currency    string               `json:"currency"`
+	Period      string               `json:"period"`
+	Hours       float64              `json:"hours"`
+	TotalCost   types.CostValue      `json:"totalCost"`
+	SharedCost  types.CostValue      `json:"sharedCost"` // Cost of shared resources split among the lines
+	Lines       []ChargebackLine     `json:"lines"`
+	Filters     types.AppliedFilters `json:"filters"`
+}
+
+// Chargeback charges each cost center for its resources over hours, and
+// splits the cost of resources matching a shared cost rule among the other
+// cost centers with direct costs. Shared costs with no one to charge stay with
+// the unallocated line. Unallocated resources of the UntaggedTypes are charged
+// to their own UnallocatedUntagged line, since tag rules can't match them.
+// Lines are sorted by total cost, highest first.
+func Chargeback(resources []snapshot.Resource, shared []config.SharedCostRule, hours float64) ([]ChargebackLine, types.CostValue) {
+	index := make(map[string]int)
+	lines := []ChargebackLine{}
+	line := func(costCenter string) *ChargebackLine {
+		i, ok := index[costCenter]
+		if !ok {
+			i = len(lines)
+			index[costCenter] = i
+			lines = append(lines, ChargebackLine{CostCenter: costCenter})
+		}
+		return &lines[i]
+	}
+
+	pools := make([]types.CostValue, len(shared))
+	for _, r := range resources {
+		costCenter := r.CostCenter
+		if costCenter == "" {
+			costCenter = Unallocated
+		}
+		cost := r.HourlyCost * types.CostValue(hours)
+		if i := sharedRule(shared, r.Type, costCenter); i >= 0 {
+			pools[i] = pools[i].Add(cost)
+			continue
+		}
+		if costCenter == Unallocated && slices.Contains(UntaggedTypes, r.Type) {
+			costCenter = UnallocatedUntagged
+		}
+		l := line(costCenter)
+		l.Resources++
+		l.DirectCost = l.DirectCost.Add(cost)
+	}
+
+	var sharedTotal types.CostValue
+	for i, pool := range pools {
+		if pool == 0 {
+			continue
+		}
+		sharedTotal = sharedTotal.Add(pool)
+		rule := shared[i]
+
+		var recipients []int
+		var direct types.CostValue
+		for j, l := range lines {
+			if l.DirectCost > 0 && !slices.Contains(rule.CostCenters, l.CostCenter) {
+				recipients = append(recipients, j)
+				direct = direct.Add(l.DirectCost)
+			}
+		}
+		if len(recipients) == 0 {
+			line(Unallocated).SharedCost = line(Unallocated).SharedCost.Add(pool)
+			continue
+		}
+		// The last recipient gets what's left, so the shares add up to the pool
+		var allocated types.CostValue
+		for k, j := range recipients {
+			share := pool.Add(-allocated)
+			if k < len(recipients)-1 {
+				if rule.Split == config.SplitEven {
+					share = (pool / types.CostValue(len(recipients))).Round()
+				} else {
+					share = (pool * lines[j].DirectCost / direct).Round()
+				}
+			}
+			allocated = allocated.Add(share)
+			lines[j].SharedCost = lines[j].SharedCost.Add(share)
+		}
+	}
+
+	var total types.CostValue
+	for i := range lines {
+		lines[i].TotalCost = lines[i].DirectCost.Add(lines[i].SharedCost)
+		total = total.Add(lines[i].TotalCost)
+	}
+	for i := range lines {
+		if total > 0 {
+			lines[i].Percent = float64(lines[i].TotalCost / total * 100)
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].TotalCost != lines[j].TotalCost {
+			return lines[i].TotalCost > lines[j].TotalCost
+		}
+		return lines[i].CostCenter < lines[j].CostCenter
+	})
+	return lines, sharedTotal
+}
+
+// sharedRule returns the index of the first shared cost rule covering a
+// resource, or -1
+func sharedRule(shared []config.SharedCostRule, resourceType, costCenter string) int {
+	for i, rule := range shared {
+		if !slices.Contains(rule.ResourceTypes, resourceType) {
+			continue
+		}
+		if len(rule.CostCenters) == 0 || slices.Contains(rule.CostCenters, costCenter) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ChargebackColumns are the CSV header of a chargeback report
+var ChargebackColumns = []string{"costCenter", "resources", "directCost", "sharedCost", "totalCost", "percent"}
+
+// WriteChargebackCSV writes a chargeback report's lines as CSV with a header
+func WriteChargebackCSV(w io.Writer, lines []ChargebackLine) error {
+	cost := func(v types.CostValue) string { return strconv.FormatFloat(float64(v), 'f', 2, 64) }
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ChargebackColumns); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	for _, l := range lines {
+		row := []string{l.CostCenter, strconv.Itoa(l.Resources), cost(l.DirectCost), cost(l.SharedCost), cost(l.TotalCost), strconv.FormatFloat(l.Percent, 'f', 2, 64)}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("writing row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,96 @@
+package costcenter
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+var chargebackResources = []snapshot.Resource{
+	{Type: "ec2", ID: "i-1", HourlyCost: 3, CostCenter: "payments"},
+	{Type: "ec2", ID: "i-2", HourlyCost: 1, CostCenter: "search"},
+	{Type: "nat", ID: "nat-1", HourlyCost: 2, CostCenter: "network"},
+	{Type: "eks", ID: "shared", HourlyCost: 1, CostCenter: "network"},
+	{Type: "ebs", ID: "vol-1", HourlyCost: 0.5},
+}
+
+func TestChargebackSplitsSharedCosts(t *testing.T) {
+	shared := []config.SharedCostRule{
+		{ResourceTypes: []string{"nat"}},
+		{ResourceTypes: []string{"eks"}, CostCenters: []string{"network"}, Split: config.SplitEven},
+	}
+
+	lines, sharedTotal := Chargeback(chargebackResources, shared, 1)
+	if sharedTotal != 3 {
+		t.Fatalf("shared total = %v, want 3", sharedTotal)
+	}
+
+	// NAT's 2 splits 3:1:0.5 by direct cost; the EKS cluster's 1 splits evenly
+	want := map[string]float64{
+		"payments":  3 + 2*3/4.5 + 1.0/3,
+		"search":    1 + 2*1/4.5 + 1.0/3,
+		Unallocated: 0.5 + 2*0.5/4.5 + 1.0/3,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %+v", len(want), lines)
+	}
+	// Lines are rounded to nano-dollars, with the remainder on the last
+	// recipient, so they add up to exactly 7.5
+	var total types.CostValue
+	for _, l := range lines {
+		if math.Abs(float64(l.TotalCost)-want[l.CostCenter]) > 1e-8 {
+			t.Errorf("%s total = %v, want %v", l.CostCenter, l.TotalCost, want[l.CostCenter])
+		}
+		total = total.Add(l.TotalCost)
+	}
+	if total != 7.5 || lines[0].CostCenter != "payments" {
+		t.Errorf("expected lines to add up to 7.5 with payments first, got %+v", lines)
+	}
+}
+
+func TestChargebackSeparatesUntaggedTypes(t *testing.T) {
+	resources := []snapshot.Resource{
+		{Type: "ec2", ID: "i-1", HourlyCost: 1},
+		{Type: "lambda", ID: "fn-1", HourlyCost: 2},
+		{Type: "elb", ID: "lb-1", HourlyCost: 3, CostCenter: "payments"},
+	}
+
+	lines, _ := Chargeback(resources, nil, 1)
+	got := map[string]types.CostValue{}
+	for _, l := range lines {
+		got[l.CostCenter] = l.DirectCost
+	}
+	if len(got) != 3 || got[Unallocated] != 1 || got[UnallocatedUntagged] != 2 || got["payments"] != 3 {
+		t.Fatalf("lines = %+v, want the untagged Lambda function apart from the unallocated instance", lines)
+	}
+}
+
+func TestChargebackWithoutRecipients(t *testing.T) {
+	resources := []snapshot.Resource{{Type: "nat", ID: "nat-1", HourlyCost: 2, CostCenter: "network"}}
+
+	lines, _ := Chargeback(resources, []config.SharedCostRule{{ResourceTypes: []string{"nat"}}}, 24)
+	if len(lines) != 1 || lines[0].CostCenter != Unallocated || lines[0].SharedCost != 48 || lines[0].Percent != 100 {
+		t.Fatalf("expected the shared cost to stay unallocated, got %+v", lines)
+	}
+}
+
+func TestWriteChargebackCSV(t *testing.T) {
+	lines, _ := Chargeback(chargebackResources, nil, 730)
+
+	var buf bytes.Buffer
+	if err := WriteChargebackCSV(&buf, lines); err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(rows) != 5 || rows[0] != "costCenter,resources,directCost,sharedCost,totalCost,percent" {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+	if rows[1] != "network,2,2190.00,0.00,2190.00,40.00" {
+		t.Errorf("unexpected first row: %s", rows[1])
+	}
+}
@@ -0,0 +1,179 @@
+// Package costcenter assigns resources to cost centers with the tag and
+// account rules in the costCenters config section.
+package costcenter
+
+import (
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Unallocated is the cost center of resources no rule matches
+const Unallocated = "unallocated"
+
+// UnallocatedUntagged is the chargeback line for unallocated resources of the
+// UntaggedTypes, which tag rules can't match
+const UnallocatedUntagged = "unallocated-untagged"
+
+// UntaggedTypes are the resource types scans don't read tags for, so only
+// account rules can assign them a cost center
+var UntaggedTypes = []string{"ecs", "elb", "publicipv4", "lambda", "kinesis", "firehose", "sqs", "sns", "eventbridge", "alarm", "dashboard", "custommetrics", "vm"}
+
+// Resolve returns the cost center of the first rule matching a resource, or
+// Unallocated
+func Resolve(rules []config.CostCenterRule, accountID, accountName string, tags map[string]string) string {
+	for _, rule := range rules {
+		if Matches(rule.Accounts, rule.Tags, accountID, accountName, tags) {
+			return rule.CostCenter
+		}
+	}
+	return Unallocated
+}
+
+// Matches reports whether a resource is in one of accounts and carries all of
+// want's tags, as cost center rules match them. Other rules that select
+// resources by account and tag, such as environment rules, share it.
+func Matches(accounts []string, want map[string]string, accountID, accountName string, tags map[string]string) bool {
+	if len(accounts) > 0 {
+		found := false
+		for _, account := range accounts {
+			if account == accountID || strings.EqualFold(account, accountName) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for key, wantValue := range want {
+		value, ok := TagValue(tags, key)
+		if !ok || (wantValue != "*" && !strings.EqualFold(strings.TrimSpace(value), wantValue)) {
+			return false
+		}
+	}
+	return true
+}
+
+// TagValue looks up a tag with a case-insensitive key
+func TagValue(tags map[string]string, key string) (string, bool) {
+	if value, ok := tags[key]; ok {
+		return value, true
+	}
+	for k, v := range tags {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Annotate sets the cost center of every resource in resp. Resources of the
+// UntaggedTypes, such as ECS services and load balancers, only match rules by
+// account. It does nothing when no rules are configured.
+func Annotate(resp *types.CostResponse, rules []config.CostCenterRule) {
+	if len(rules) == 0 {
+		return
+	}
+	resolve := func(accountID, accountName string, tags map[string]string) string {
+		return Resolve(rules, accountID, accountName, tags)
+	}
+
+	for i := range resp.EC2Instances {
+		r := &resp.EC2Instances[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.EBSVolumes {
+		r := &resp.EBSVolumes[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.ECSServices {
+		r := &resp.ECSServices[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.RDSInstances {
+		r := &resp.RDSInstances[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.EKSClusters {
+		r := &resp.EKSClusters[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.LoadBalancers {
+		r := &resp.LoadBalancers[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.NATGateways {
+		r := &resp.NATGateways[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.ElasticIPs {
+		r := &resp.ElasticIPs[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.Secrets {
+		r := &resp.Secrets[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.PublicIPv4s {
+		r := &resp.PublicIPv4s[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.Lambdas {
+		r := &resp.Lambdas[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.CapacityReservations {
+		r := &resp.CapacityReservations[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.DedicatedHosts {
+		r := &resp.DedicatedHosts[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.KinesisStreams {
+		r := &resp.KinesisStreams[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.FirehoseStreams {
+		r := &resp.FirehoseStreams[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.SQSQueues {
+		r := &resp.SQSQueues[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.SNSTopics {
+		r := &resp.SNSTopics[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.EventBuses {
+		r := &resp.EventBuses[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.Alarms {
+		r := &resp.Alarms[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.Dashboards {
+		r := &resp.Dashboards[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.CustomMetrics {
+		r := &resp.CustomMetrics[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+	for i := range resp.DataTransfer {
+		r := &resp.DataTransfer[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.PluginResources {
+		r := &resp.PluginResources[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, r.Tags)
+	}
+	for i := range resp.VirtualMachines {
+		r := &resp.VirtualMachines[i]
+		r.CostCenter = resolve(r.AccountID, r.AccountName, nil)
+	}
+}
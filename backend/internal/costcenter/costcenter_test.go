@@ -0,0 +1,55 @@
+package costcenter
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+var testRules = []config.CostCenterRule{
+	{CostCenter: "data", Accounts: []string{"analytics"}, Tags: map[string]string{"team": "*"}},
+	{CostCenter: "platform", Tags: map[string]string{"Team": "Platform"}},
+	{CostCenter: "shared", Accounts: []string{"111111111111"}},
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		accountID   string
+		accountName string
+		tags        map[string]string
+		want        string
+	}{
+		{"account name and any tag value", "222222222222", "Analytics", map[string]string{"team": "etl"}, "data"},
+		{"tag keys and values ignore case", "333333333333", "dev", map[string]string{"team": " platform"}, "platform"},
+		{"first match wins", "111111111111", "prod", map[string]string{"TEAM": "platform"}, "platform"},
+		{"account only", "111111111111", "prod", nil, "shared"},
+		{"missing tag", "222222222222", "analytics", nil, Unallocated},
+		{"no match", "444444444444", "sandbox", map[string]string{"team": "web"}, Unallocated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(testRules, tt.accountID, tt.accountName, tt.tags); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	resp := &types.CostResponse{
+		EC2Instances:  []types.EC2Instance{{AccountID: "5", Tags: map[string]string{"Team": "platform"}}},
+		LoadBalancers: []types.LoadBalancer{{AccountID: "111111111111"}},
+	}
+
+	Annotate(resp, nil)
+	if resp.EC2Instances[0].CostCenter != "" {
+		t.Fatal("expected no annotation without rules")
+	}
+
+	Annotate(resp, testRules)
+	if resp.EC2Instances[0].CostCenter != "platform" || resp.LoadBalancers[0].CostCenter != "shared" {
+		t.Errorf("got %q and %q", resp.EC2Instances[0].CostCenter, resp.LoadBalancers[0].CostCenter)
+	}
+}
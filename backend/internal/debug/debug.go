@@ -0,0 +1,84 @@
+// Package debug serves pprof profiles and runtime stats on a separate
+// address, for profiling memory and CPU use during large scans.
+package debug
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// Scan counters, published with the runtime stats
+var (
+	ScansInFlight = expvar.NewInt("scansInFlight") // Scans currently running
+	ScansTotal    = expvar.NewInt("scansTotal")    // Scans started since startup
+)
+
+func init() {
+	expvar.Publish("runtime", expvar.Func(runtimeStats))
+}
+
+// runtimeStats returns goroutine and heap statistics
+func runtimeStats() any {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return map[string]any{
+		"goroutines":     runtime.NumGoroutine(),
+		"heapAllocBytes": m.HeapAlloc,
+		"heapInuseBytes": m.HeapInuse,
+		"heapSysBytes":   m.HeapSys,
+		"heapObjects":    m.HeapObjects,
+		"totalAlloc":     m.TotalAlloc,
+		"numGC":          m.NumGC,
+		"gcPauseTotalNs": m.PauseTotalNs,
+	}
+}
+
+// Handler returns the debug endpoints: /debug/pprof/ and /debug/vars
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// Server is the debug HTTP server
+type Server struct {
+	server *http.Server
+	logger *slog.Logger
+}
+
+// NewServer creates a debug server listening on addr
+func NewServer(addr string, logger *slog.Logger) *Server {
+	return &Server{
+		server: &http.Server{
+			Addr:        addr,
+			Handler:     Handler(),
+			ReadTimeout: 30 * time.Second,
+			// CPU profiles and traces stream for the requested duration
+			// (default 30s), so the write timeout leaves room for long ones
+			WriteTimeout: 5 * time.Minute,
+			IdleTimeout:  60 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// Start begins listening for requests
+func (s *Server) Start() error {
+	s.logger.Warn("starting debug server; pprof and runtime stats are exposed without authentication", "addr", s.server.Addr)
+	return s.server.ListenAndServe()
+}
+
+// Shutdown gracefully stops the server
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
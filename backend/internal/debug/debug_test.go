@@ -0,0 +1,39 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandlerVars(t *testing.T) {
+	ScansInFlight.Add(1)
+	defer ScansInFlight.Add(-1)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	var vars struct {
+		ScansInFlight int64          `json:"scansInFlight"`
+		Runtime       map[string]any `json:"runtime"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("decoding vars: %v", err)
+	}
+	if vars.ScansInFlight != 1 {
+		t.Fatalf("scansInFlight = %d", vars.ScansInFlight)
+	}
+	if g, ok := vars.Runtime["goroutines"].(float64); !ok || g < 1 {
+		t.Fatalf("runtime = %v", vars.Runtime)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pprof index status = %d", rec.Code)
+	}
+}
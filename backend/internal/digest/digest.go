@@ -0,0 +1,159 @@
+package digest
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// HoursPerMonth is used to project hourly costs to monthly costs
+const HoursPerMonth = 730
+
+// maxListed limits how many resources each digest section lists
+const maxListed = 10
+
+// Budget status constants
+const (
+	BudgetStatusOK       = "ok"
+	BudgetStatusWarning  = "warning"
+	BudgetStatusExceeded = "exceeded"
+)
+
+// Digest summarizes how costs changed over a period
+type Digest struct {
+	GeneratedAt         string              `json:"generatedAt"`
+	PeriodStart         string              `json:"periodStart"`
+	PeriodEnd           string              `json:"periodEnd"`
+	Partial             bool                `json:"partial"` // Baseline is newer than the period start
+	CurrentSnapshotID   string              `json:"currentSnapshotId"`
+	BaselineSnapshotID  string              `json:"baselineSnapshotId"`
+	Currency            string              `json:"currency"`
+	CurrentHourlyCost   types.CostValue     `json:"currentHourlyCost"`
+	PreviousHourlyCost  types.CostValue     `json:"previousHourlyCost"`
+	HourlyCostDelta     types.CostValue     `json:"hourlyCostDelta"`
+	CurrentMonthlyCost  types.CostValue     `json:"currentMonthlyCost"`
+	PreviousMonthlyCost types.CostValue     `json:"previousMonthlyCost"`
+	NewResourceCount    int                 `json:"newResourceCount"`
+	NewResources        []snapshot.Resource `json:"newResources"`
+	RemovedCount        int                 `json:"removedResourceCount"`
+	RemovedResources    []snapshot.Resource `json:"removedResources"`
+	LargestIncreases    []snapshot.Change   `json:"largestIncreases"`
+	LargestDecreases    []snapshot.Change   `json:"largestDecreases"`
+	Budgets             []BudgetStatus      `json:"budgets"`
+}
+
+// BudgetStatus compares projected monthly cost against a configured budget
+type BudgetStatus struct {
+	Scope                string          `json:"scope"` // "total" or "account"
+	Name                 string          `json:"name"`
+	MonthlyBudget        float64         `json:"monthlyBudget"`
+	ProjectedMonthlyCost types.CostValue `json:"projectedMonthlyCost"`
+	PercentUsed          float64         `json:"percentUsed"`
+	Status               string          `json:"status"`
+}
+
+// Build generates a digest comparing the current snapshot to a baseline.
+// periodStart is the intended start of the period; if the baseline was taken
+// after it, the digest is marked partial.
+func Build(current, baseline *snapshot.Snapshot, periodStart time.Time, budget config.BudgetConfig, now time.Time) *Digest {
+	diff := snapshot.Compare(snapshot.Resources(baseline.Response), snapshot.Resources(current.Response))
+
+	d := &Digest{
+		GeneratedAt:         now.UTC().Format(time.RFC3339),
+		PeriodStart:         periodStart.UTC().Format(time.RFC3339),
+		PeriodEnd:           current.Timestamp.Format(time.RFC3339),
+		Partial:             baseline.Timestamp.After(periodStart),
+		CurrentSnapshotID:   current.ID,
+		BaselineSnapshotID:  baseline.ID,
+		Currency:            "USD",
+		CurrentHourlyCost:   current.Response.TotalCost,
+		PreviousHourlyCost:  baseline.Response.TotalCost,
+		HourlyCostDelta:     current.Response.TotalCost - baseline.Response.TotalCost,
+		CurrentMonthlyCost:  current.Response.TotalCost * HoursPerMonth,
+		PreviousMonthlyCost: baseline.Response.TotalCost * HoursPerMonth,
+		NewResourceCount:    len(diff.Added),
+		NewResources:        limit(diff.Added),
+		RemovedCount:        len(diff.Removed),
+		RemovedResources:    limit(diff.Removed),
+		LargestIncreases:    []snapshot.Change{},
+		LargestDecreases:    []snapshot.Change{},
+		Budgets:             BudgetStatuses(current.Response, budget),
+	}
+
+	for _, c := range diff.Changed {
+		if c.HourlyCostDelta > 0 {
+			d.LargestIncreases = append(d.LargestIncreases, c)
+		} else if c.HourlyCostDelta < 0 {
+			d.LargestDecreases = append(d.LargestDecreases, c)
+		}
+	}
+	sort.SliceStable(d.LargestIncreases, func(i, j int) bool {
+		return d.LargestIncreases[i].HourlyCostDelta > d.LargestIncreases[j].HourlyCostDelta
+	})
+	sort.SliceStable(d.LargestDecreases, func(i, j int) bool {
+		return d.LargestDecreases[i].HourlyCostDelta < d.LargestDecreases[j].HourlyCostDelta
+	})
+	d.LargestIncreases = limit(d.LargestIncreases)
+	d.LargestDecreases = limit(d.LargestDecreases)
+
+	return d
+}
+
+// BudgetStatuses evaluates the configured budgets against a scan
+func BudgetStatuses(response *types.CostResponse, budget config.BudgetConfig) []BudgetStatus {
+	statuses := []BudgetStatus{}
+	if budget.Monthly > 0 {
+		statuses = append(statuses, newBudgetStatus("total", "total", budget.Monthly, response.TotalCost*HoursPerMonth, budget.WarnPercent))
+	}
+
+	names := make([]string, 0, len(budget.Accounts))
+	for name := range budget.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		limit := budget.Accounts[name]
+		if limit <= 0 {
+			continue
+		}
+		var cost types.CostValue
+		for _, acc := range response.Accounts {
+			if acc.AccountID == name || strings.EqualFold(acc.AccountName, name) {
+				cost = cost.Add(acc.TotalCost)
+			}
+		}
+		statuses = append(statuses, newBudgetStatus("account", name, limit, cost*HoursPerMonth, budget.WarnPercent))
+	}
+	return statuses
+}
+
+func newBudgetStatus(scope, name string, budget float64, projected types.CostValue, warnPercent float64) BudgetStatus {
+	percent := float64(projected) / budget * 100
+	status := BudgetStatusOK
+	switch {
+	case percent >= 100:
+		status = BudgetStatusExceeded
+	case warnPercent > 0 && percent >= warnPercent:
+		status = BudgetStatusWarning
+	}
+	return BudgetStatus{
+		Scope:                scope,
+		Name:                 name,
+		MonthlyBudget:        budget,
+		ProjectedMonthlyCost: projected,
+		PercentUsed:          percent,
+		Status:               status,
+	}
+}
+
+func limit[T any](items []T) []T {
+	if len(items) > maxListed {
+		return items[:maxListed]
+	}
+	return items
+}
@@ -0,0 +1,72 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestWeeklyUsesSnapshotFromAWeekEarlier(t *testing.T) {
+	store, err := snapshot.NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	now := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	add := func(ts time.Time, instances ...types.EC2Instance) {
+		var total types.CostValue
+		for _, inst := range instances {
+			total = total.Add(inst.HourlyCost)
+		}
+		if _, err := store.Add(ts, &types.CostResponse{TotalCost: total, EC2Instances: instances}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	add(now.AddDate(0, 0, -8),
+		types.EC2Instance{InstanceID: "i-old", InstanceType: "m5.large", HourlyCost: 0.096},
+		types.EC2Instance{InstanceID: "i-grow", InstanceType: "t3.micro", HourlyCost: 0.0104},
+	)
+	add(now.AddDate(0, 0, -3),
+		types.EC2Instance{InstanceID: "i-grow", InstanceType: "t3.micro", HourlyCost: 0.0104},
+	)
+	add(now,
+		types.EC2Instance{InstanceID: "i-grow", InstanceType: "t3.large", HourlyCost: 0.0832},
+		types.EC2Instance{InstanceID: "i-new", InstanceType: "c5.xlarge", HourlyCost: 0.17},
+	)
+
+	d, err := Weekly(store, config.BudgetConfig{Monthly: 100, WarnPercent: 80}, now)
+	if err != nil {
+		t.Fatalf("Weekly() error = %v", err)
+	}
+
+	if d.Partial {
+		t.Fatal("digest should not be partial with more than a week of history")
+	}
+	if d.NewResourceCount != 1 || d.NewResources[0].ID != "i-new" {
+		t.Fatalf("NewResources = %+v", d.NewResources)
+	}
+	if d.RemovedCount != 1 || d.RemovedResources[0].ID != "i-old" {
+		t.Fatalf("RemovedResources = %+v", d.RemovedResources)
+	}
+	if len(d.LargestIncreases) != 1 || d.LargestIncreases[0].ID != "i-grow" {
+		t.Fatalf("LargestIncreases = %+v", d.LargestIncreases)
+	}
+	if len(d.Budgets) != 1 || d.Budgets[0].Status != BudgetStatusExceeded {
+		t.Fatalf("Budgets = %+v", d.Budgets)
+	}
+}
+
+func TestWeeklyWithoutSnapshots(t *testing.T) {
+	store, err := snapshot.NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	if _, err := Weekly(store, config.BudgetConfig{}, time.Now()); err != ErrNoSnapshots {
+		t.Fatalf("Weekly() error = %v, want ErrNoSnapshots", err)
+	}
+}
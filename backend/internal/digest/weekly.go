@@ -0,0 +1,36 @@
+package digest
+
+import (
+	"errors"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Week is the period covered by the weekly digest
+const Week = 7 * 24 * time.Hour
+
+// ErrNoSnapshots is returned when there is no unfiltered snapshot to build a digest from
+var ErrNoSnapshots = errors.New("no unfiltered snapshots available")
+
+// Weekly builds a digest from the latest unfiltered snapshot and the latest
+// one taken at least a week before it. If history is shorter than a week, the
+// oldest available snapshot is used and the digest is marked partial.
+func Weekly(store *snapshot.Store, budget config.BudgetConfig, now time.Time) (*Digest, error) {
+	all := types.AppliedFilters{}
+
+	current := store.Latest(all)
+	if current == nil {
+		return nil, ErrNoSnapshots
+	}
+
+	periodStart := current.Timestamp.Add(-Week)
+	baseline := store.AtOrBefore(periodStart, all)
+	if baseline == nil {
+		baseline = store.Oldest(all)
+	}
+
+	return Build(current, baseline, periodStart, budget, now), nil
+}
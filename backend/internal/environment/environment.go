@@ -0,0 +1,84 @@
+// Package environment classifies resources into environments such as prod,
+// staging, dev, and sandbox with the environments config section and the
+// environments set in account metadata.
+package environment
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/costcenter"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+)
+
+// Unclassified is the environment of resources nothing classifies
+const Unclassified = "unclassified"
+
+// aliases maps common spellings of environment tag values to the names
+// environments are reported as
+var aliases = map[string]string{
+	"production":  "prod",
+	"prd":         "prod",
+	"live":        "prod",
+	"stage":       "staging",
+	"stg":         "staging",
+	"development": "dev",
+	"develop":     "dev",
+	"sbx":         "sandbox",
+}
+
+// Classifier assigns resources to environments
+type Classifier struct {
+	cfg      config.EnvironmentConfig
+	accounts map[string]string // Environment by account ID, from account metadata
+}
+
+// New returns a Classifier for cfg. accounts maps account IDs to the
+// environment in their metadata.
+func New(cfg config.EnvironmentConfig, accounts map[string]string) *Classifier {
+	return &Classifier{cfg: cfg, accounts: accounts}
+}
+
+// Environment returns the environment of a resource: the first rule it
+// matches, then the value of its first environment tag, then its account's
+// environment, or Unclassified
+func (c *Classifier) Environment(accountID, accountName string, tags map[string]string) string {
+	for _, rule := range c.cfg.Rules {
+		if costcenter.Matches(rule.Accounts, rule.Tags, accountID, accountName, tags) {
+			return rule.Environment
+		}
+	}
+	for _, key := range c.cfg.Tags {
+		if value, ok := costcenter.TagValue(tags, key); ok && strings.TrimSpace(value) != "" {
+			return Normalize(value)
+		}
+	}
+	if env := c.accounts[accountID]; env != "" {
+		return env
+	}
+	return Unclassified
+}
+
+// Production reports whether env is a production environment
+func (c *Classifier) Production(env string) bool {
+	return slices.Contains(c.cfg.Production, env)
+}
+
+// Annotate sets the environment of every resource
+func (c *Classifier) Annotate(resources []snapshot.Resource) {
+	for i := range resources {
+		r := &resources[i]
+		r.Environment = c.Environment(r.AccountID, r.AccountName, r.Tags)
+	}
+}
+
+// Normalize lowercases an environment tag value and maps common spellings
+// such as Production and development to prod and dev
+func Normalize(value string) string {
+	env := strings.ToLower(strings.TrimSpace(value))
+	if alias, ok := aliases[env]; ok {
+		return alias
+	}
+	return env
+}
@@ -0,0 +1,40 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestEnvironment(t *testing.T) {
+	c := New(config.EnvironmentConfig{
+		Rules:      []config.EnvironmentRule{{Environment: "sandbox", Accounts: []string{"333333333333"}}},
+		Tags:       []string{"Environment", "Env"},
+		Production: []string{"prod"},
+	}, map[string]string{"111111111111": "prod", "333333333333": "prod"})
+
+	tests := []struct {
+		name      string
+		accountID string
+		tags      map[string]string
+		want      string
+	}{
+		{"rule wins over tags and metadata", "333333333333", map[string]string{"Environment": "prod"}, "sandbox"},
+		{"tag wins over metadata", "111111111111", map[string]string{"env": "Development"}, "dev"},
+		{"first tag key wins", "222222222222", map[string]string{"Env": "qa", "Environment": "Staging"}, "staging"},
+		{"account metadata", "111111111111", nil, "prod"},
+		{"blank tag falls through", "111111111111", map[string]string{"Environment": " "}, "prod"},
+		{"unclassified", "222222222222", map[string]string{"team": "payments"}, Unclassified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Environment(tt.accountID, "", tt.tags); got != tt.want {
+				t.Errorf("Environment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !c.Production("prod") || c.Production("staging") {
+		t.Error("expected only prod to be production")
+	}
+}
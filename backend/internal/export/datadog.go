@@ -0,0 +1,143 @@
+// Package export pushes cost data from completed scans to other systems.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// datadogGauge is the Datadog v2 series type for gauges
+const datadogGauge = 3
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// Datadog pushes hourly cost metrics to Datadog after each full scan
+type Datadog struct {
+	url     string
+	apiKey  string
+	prefix  string
+	tags    []string
+	logger  *slog.Logger
+	pending sync.WaitGroup
+}
+
+// NewDatadog creates a Datadog exporter. profile is added as a tag when set.
+func NewDatadog(cfg config.DatadogConfig, profile string, logger *slog.Logger) *Datadog {
+	tags := slices.Clone(cfg.Tags)
+	if profile != "" {
+		tags = append(tags, "profile:"+profile)
+	}
+	return &Datadog{
+		url:    "https://api." + cfg.Site + "/api/v2/series",
+		apiKey: cfg.APIKey,
+		prefix: cfg.MetricPrefix,
+		tags:   tags,
+		logger: logger,
+	}
+}
+
+type datadogSeries struct {
+	Metric string         `json:"metric"`
+	Type   int            `json:"type"`
+	Points []datadogPoint `json:"points"`
+	Tags   []string       `json:"tags,omitempty"`
+}
+
+type datadogPoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// series returns the metrics for a snapshot: total hourly cost, hourly cost
+// per service, and hourly cost per account
+func (d *Datadog) series(snap *snapshot.Snapshot) []datadogSeries {
+	ts := snap.Timestamp.Unix()
+	gauge := func(name string, value types.CostValue, tags ...string) datadogSeries {
+		return datadogSeries{
+			Metric: d.prefix + "." + name,
+			Type:   datadogGauge,
+			Points: []datadogPoint{{Timestamp: ts, Value: float64(value)}},
+			Tags:   append(slices.Clone(d.tags), tags...),
+		}
+	}
+
+	resources := snapshot.Resources(snap.Response)
+	series := []datadogSeries{gauge("cost.hourly", snap.Response.TotalCost)}
+	for _, g := range aggregate.GroupBy(resources, []string{aggregate.DimService}) {
+		series = append(series, gauge("cost.hourly.by_service", g.TotalCost, "service:"+g.Values[0]))
+	}
+	for _, g := range aggregate.GroupBy(resources, []string{aggregate.DimAccount, aggregate.DimAccountName}) {
+		series = append(series, gauge("cost.hourly.by_account", g.TotalCost, "account_id:"+g.Values[0], "account_name:"+g.Values[1]))
+	}
+	return series
+}
+
+// SnapshotAdded pushes metrics for a new snapshot in the background. Only
+// unfiltered scans are pushed, since per-service and per-account totals from
+// a filtered scan would be partial. It is meant to be registered with
+// snapshot.Store.Subscribe.
+func (d *Datadog) SnapshotAdded(snap *snapshot.Snapshot) {
+	if !isUnfiltered(snap.Response.Filters) {
+		return
+	}
+	d.pending.Add(1)
+	go func() {
+		defer d.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := d.Push(ctx, snap); err != nil {
+			d.logger.Warn("failed to push cost metrics to Datadog", "snapshot", snap.ID, "error", err)
+		}
+	}()
+}
+
+// Push sends metrics for a snapshot
+func (d *Datadog) Push(ctx context.Context, snap *snapshot.Snapshot) error {
+	body, err := json.Marshal(map[string]any{"series": d.series(snap)})
+	if err != nil {
+		return fmt.Errorf("encoding series: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", d.apiKey)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting series: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("datadog returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Wait blocks until pushes in flight have finished
+func (d *Datadog) Wait() {
+	d.pending.Wait()
+}
+
+// isUnfiltered reports whether a scan covered every account, region, and
+// resource type
+func isUnfiltered(filters types.AppliedFilters) bool {
+	return len(filters.Accounts) == 0 && len(filters.Regions) == 0 && len(filters.ResourceTypes) == 0
+}
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestDatadogPush(t *testing.T) {
+	var got struct {
+		Series []datadogSeries `json:"series"`
+	}
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("DD-API-KEY")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	d := NewDatadog(config.DatadogConfig{APIKey: "key", Site: "datadoghq.com", MetricPrefix: "awscogs", Tags: []string{"env:test"}}, "prod-org", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	d.url = server.URL
+
+	snap := &snapshot.Snapshot{
+		ID:        "s1",
+		Timestamp: time.Unix(1700000000, 0),
+		Response: &types.CostResponse{
+			TotalCost: 0.3,
+			EC2Instances: []types.EC2Instance{
+				{AccountID: "111", AccountName: "prod", Region: "us-east-1", InstanceID: "i-1", HourlyCost: 0.2},
+			},
+			EBSVolumes: []types.EBSVolume{
+				{AccountID: "222", AccountName: "dev", Region: "us-east-1", VolumeID: "vol-1", HourlyCost: 0.1},
+			},
+		},
+	}
+	if err := d.Push(context.Background(), snap); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if apiKey != "key" {
+		t.Fatalf("DD-API-KEY = %q", apiKey)
+	}
+	if len(got.Series) != 5 {
+		t.Fatalf("expected total, 2 service, and 2 account series, got %+v", got.Series)
+	}
+	total := got.Series[0]
+	if total.Metric != "awscogs.cost.hourly" || total.Points[0].Value != 0.3 || total.Points[0].Timestamp != 1700000000 {
+		t.Fatalf("unexpected total series: %+v", total)
+	}
+	if !slices.Equal(total.Tags, []string{"env:test", "profile:prod-org"}) {
+		t.Fatalf("unexpected tags: %v", total.Tags)
+	}
+	account := got.Series[3]
+	if account.Metric != "awscogs.cost.hourly.by_account" || !slices.Contains(account.Tags, "account_name:prod") {
+		t.Fatalf("unexpected account series: %+v", account)
+	}
+}
+
+func TestDatadogSkipsFilteredScans(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	d := NewDatadog(config.DatadogConfig{APIKey: "key", Site: "datadoghq.com", MetricPrefix: "awscogs"}, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	d.url = server.URL
+
+	d.SnapshotAdded(&snapshot.Snapshot{Response: &types.CostResponse{Filters: types.AppliedFilters{Regions: []string{"us-east-1"}}}})
+	d.Wait()
+	if requests != 0 {
+		t.Fatalf("expected filtered scan to be skipped, got %d requests", requests)
+	}
+
+	d.SnapshotAdded(&snapshot.Snapshot{Response: &types.CostResponse{}})
+	d.Wait()
+	if requests != 1 {
+		t.Fatalf("expected full scan to be pushed, got %d requests", requests)
+	}
+}
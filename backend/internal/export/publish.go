@@ -0,0 +1,240 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// maxMessageBytes is the SNS and SQS message size limit
+const maxMessageBytes = 256 * 1024
+
+// Message is what the publisher sends for each snapshot
+type Message struct {
+	SnapshotID      string               `json:"snapshotId"`
+	Profile         string               `json:"profile,omitempty"`
+	Timestamp       string               `json:"timestamp"`
+	Format          string               `json:"format"` // full or summary
+	Status          string               `json:"status"`
+	Currency        string               `json:"currency"`
+	TotalHourlyCost types.CostValue      `json:"totalHourlyCost"`
+	ResourceCount   int                  `json:"resourceCount"`
+	Services        []types.CostGroup    `json:"services"`
+	Accounts        []types.CostGroup    `json:"accounts"`
+	Filters         types.AppliedFilters `json:"filters"`
+
+	// Response is the full cost response, set for the full format
+	Response *types.CostResponse `json:"response,omitempty"`
+}
+
+// destination delivers an encoded message
+type destination interface {
+	name() string
+	send(ctx context.Context, body string) error
+}
+
+// Publisher publishes each full scan to an SNS topic and/or SQS queue
+type Publisher struct {
+	destinations []destination
+	format       string
+	profile      string
+	logger       *slog.Logger
+	pending      sync.WaitGroup
+}
+
+// NewPublisher creates a publisher for the configured topic and queue.
+// profile is included in each message when set.
+func NewPublisher(cfg config.PublishConfig, profile string, logger *slog.Logger) *Publisher {
+	p := &Publisher{format: cfg.Format, profile: profile, logger: logger}
+	if cfg.SNSTopicARN != "" {
+		p.destinations = append(p.destinations, &snsTopic{arn: cfg.SNSTopicARN})
+	}
+	if cfg.SQSQueueURL != "" {
+		p.destinations = append(p.destinations, newSQSQueue(cfg.SQSQueueURL))
+	}
+	return p
+}
+
+// message builds the message for a snapshot. The full format falls back to
+// a summary when the response doesn't fit in one message.
+func (p *Publisher) message(snap *snapshot.Snapshot) ([]byte, error) {
+	resources := snapshot.Resources(snap.Response)
+	msg := Message{
+		SnapshotID:      snap.ID,
+		Profile:         p.profile,
+		Timestamp:       snap.Timestamp.UTC().Format(time.RFC3339),
+		Format:          config.PublishFormatSummary,
+		Status:          snap.Response.Status,
+		Currency:        snap.Response.Currency,
+		TotalHourlyCost: snap.Response.TotalCost,
+		ResourceCount:   len(resources),
+		Services:        aggregate.GroupBy(resources, []string{aggregate.DimService}),
+		Accounts:        aggregate.GroupBy(resources, []string{aggregate.DimAccount, aggregate.DimAccountName}),
+		Filters:         snap.Response.Filters,
+	}
+
+	if p.format == config.PublishFormatFull {
+		full := msg
+		full.Format = config.PublishFormatFull
+		full.Response = snap.Response
+		body, err := json.Marshal(full)
+		if err != nil {
+			return nil, fmt.Errorf("encoding message: %w", err)
+		}
+		if len(body) <= maxMessageBytes {
+			return body, nil
+		}
+		p.logger.Info("cost response is too large to publish in full, publishing a summary", "snapshot", snap.ID, "bytes", len(body))
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("encoding message: %w", err)
+	}
+	if len(body) > maxMessageBytes {
+		return nil, fmt.Errorf("summary is %d bytes, over the %d byte message limit", len(body), maxMessageBytes)
+	}
+	return body, nil
+}
+
+// SnapshotAdded publishes a new snapshot in the background. Only unfiltered
+// scans are published, so downstream totals are never partial. It is meant to
+// be registered with snapshot.Store.Subscribe.
+func (p *Publisher) SnapshotAdded(snap *snapshot.Snapshot) {
+	if !isUnfiltered(snap.Response.Filters) {
+		return
+	}
+	p.pending.Add(1)
+	go func() {
+		defer p.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		p.Publish(ctx, snap)
+	}()
+}
+
+// Publish sends a snapshot to every destination, logging failures
+func (p *Publisher) Publish(ctx context.Context, snap *snapshot.Snapshot) {
+	body, err := p.message(snap)
+	if err != nil {
+		p.logger.Warn("failed to build snapshot message", "snapshot", snap.ID, "error", err)
+		return
+	}
+	for _, d := range p.destinations {
+		if err := d.send(ctx, string(body)); err != nil {
+			p.logger.Warn("failed to publish snapshot", "snapshot", snap.ID, "destination", d.name(), "error", err)
+		}
+	}
+}
+
+// Wait blocks until publishes in flight have finished
+func (p *Publisher) Wait() {
+	p.pending.Wait()
+}
+
+// snsTopic publishes to an SNS topic using the default credential chain
+type snsTopic struct {
+	arn string
+
+	once   sync.Once
+	client *sns.Client
+	err    error
+}
+
+func (t *snsTopic) name() string { return t.arn }
+
+func (t *snsTopic) send(ctx context.Context, body string) error {
+	t.once.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(regionFromARN(t.arn)))
+		if err != nil {
+			t.err = fmt.Errorf("loading AWS config: %w", err)
+			return
+		}
+		t.client = sns.NewFromConfig(cfg)
+	})
+	if t.err != nil {
+		return t.err
+	}
+
+	_, err := t.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(t.arn),
+		Message:  aws.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("publishing to SNS: %w", err)
+	}
+	return nil
+}
+
+// sqsQueue sends to an SQS queue using the default credential chain
+type sqsQueue struct {
+	url    string
+	region string // From the queue URL, e.g. us-east-1
+
+	once   sync.Once
+	client *sqs.Client
+	err    error
+}
+
+// newSQSQueue creates a queue from a URL such as
+// https://sqs.us-east-1.amazonaws.com/123456789012/awscogs
+func newSQSQueue(queueURL string) *sqsQueue {
+	q := &sqsQueue{url: queueURL}
+	if u, err := url.Parse(queueURL); err == nil {
+		if parts := strings.Split(u.Host, "."); len(parts) > 1 {
+			q.region = parts[1]
+		}
+	}
+	return q
+}
+
+func (q *sqsQueue) name() string { return q.url }
+
+func (q *sqsQueue) send(ctx context.Context, body string) error {
+	q.once.Do(func() {
+		if q.client != nil {
+			return
+		}
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(q.region))
+		if err != nil {
+			q.err = fmt.Errorf("loading AWS config: %w", err)
+			return
+		}
+		q.client = sqs.NewFromConfig(cfg)
+	})
+	if q.err != nil {
+		return q.err
+	}
+
+	_, err := q.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.url),
+		MessageBody: aws.String(body),
+	})
+	if err != nil {
+		return fmt.Errorf("sending to SQS: %w", err)
+	}
+	return nil
+}
+
+func regionFromARN(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) > 3 {
+		return parts[3]
+	}
+	return ""
+}
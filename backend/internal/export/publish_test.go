@@ -0,0 +1,103 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func testSnapshot(instances int) *snapshot.Snapshot {
+	resp := &types.CostResponse{Status: types.ResponseStatusOK, Currency: "USD"}
+	for i := 0; i < instances; i++ {
+		resp.EC2Instances = append(resp.EC2Instances, types.EC2Instance{
+			AccountID: "111", AccountName: "prod", Region: "us-east-1",
+			InstanceID: "i-" + strings.Repeat("0", 16), Name: strings.Repeat("web", 20), HourlyCost: 0.1,
+		})
+		resp.TotalCost = resp.TotalCost.Add(0.1)
+	}
+	return &snapshot.Snapshot{ID: "s1", Timestamp: time.Unix(1700000000, 0), Response: resp}
+}
+
+func TestPublisherMessage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	p := NewPublisher(config.PublishConfig{Format: config.PublishFormatFull}, "prod-org", logger)
+
+	body, err := p.message(testSnapshot(2))
+	if err != nil {
+		t.Fatalf("message() error = %v", err)
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatalf("decoding message: %v", err)
+	}
+	if msg.Format != config.PublishFormatFull || msg.Response == nil || len(msg.Response.EC2Instances) != 2 {
+		t.Fatalf("expected the full response, got %+v", msg)
+	}
+	if msg.Profile != "prod-org" || msg.ResourceCount != 2 || len(msg.Services) != 1 || msg.Timestamp != "2023-11-14T22:13:20Z" {
+		t.Fatalf("unexpected summary fields: %+v", msg)
+	}
+
+	// A response over the message limit falls back to a summary
+	body, err = p.message(testSnapshot(5000))
+	if err != nil {
+		t.Fatalf("message() error = %v", err)
+	}
+	msg = Message{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatalf("decoding message: %v", err)
+	}
+	if msg.Format != config.PublishFormatSummary || msg.Response != nil || msg.ResourceCount != 5000 {
+		t.Fatalf("expected a summary, got format %q with %d resources", msg.Format, msg.ResourceCount)
+	}
+}
+
+func TestSQSQueueSend(t *testing.T) {
+	var target, auth string
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.Header.Get("X-Amz-Target")
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		w.Write([]byte(`{"MessageId":"m1"}`))
+	}))
+	defer server.Close()
+
+	q := newSQSQueue("https://sqs.us-west-2.amazonaws.com/123456789012/awscogs")
+	if q.region != "us-west-2" {
+		t.Fatalf("region = %q", q.region)
+	}
+	q.client = sqs.NewFromConfig(aws.Config{
+		Region:       q.region,
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+		BaseEndpoint: aws.String(server.URL),
+	})
+
+	if err := q.send(context.Background(), `{"snapshotId":"s1"}`); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	if target != "AmazonSQS.SendMessage" {
+		t.Fatalf("X-Amz-Target = %q", target)
+	}
+	if !strings.Contains(auth, "Credential=AKID/") || !strings.Contains(auth, "/us-west-2/sqs/aws4_request") {
+		t.Fatalf("Authorization = %q", auth)
+	}
+	if got["QueueUrl"] != "https://sqs.us-west-2.amazonaws.com/123456789012/awscogs" || got["MessageBody"] != `{"snapshotId":"s1"}` {
+		t.Fatalf("unexpected request body: %v", got)
+	}
+}
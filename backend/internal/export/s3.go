@@ -0,0 +1,157 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+)
+
+// archiveRow is one resource in the jsonl archive format, flattened with the
+// snapshot it came from so each line stands alone in Athena
+type archiveRow struct {
+	SnapshotID string `json:"snapshotId"`
+	Timestamp  string `json:"timestamp"`
+	Profile    string `json:"profile,omitempty"`
+	Filtered   bool   `json:"filtered"` // The scan was limited by account, region, or resource type
+	snapshot.Resource
+}
+
+// Archiver writes every snapshot to S3 as a gzip-compressed JSON object.
+// Keys are partitioned by date, e.g.
+// awscogs/date=2024-05-01/20240501T120000.000000000Z.json.gz, so Athena can
+// prune by partition.
+type Archiver struct {
+	bucket  string
+	region  string // Empty = the region from the default config
+	prefix  string
+	format  string
+	profile string
+	logger  *slog.Logger
+	pending sync.WaitGroup
+
+	once   sync.Once
+	client *s3.Client
+	err    error
+}
+
+// NewArchiver creates an S3 archiver. Snapshots from a named profile are
+// written under profile=<name>/.
+func NewArchiver(cfg config.S3Config, profile string, logger *slog.Logger) *Archiver {
+	return &Archiver{
+		bucket:  cfg.Bucket,
+		prefix:  cfg.Prefix,
+		format:  cfg.Format,
+		region:  cfg.Region,
+		profile: profile,
+		logger:  logger,
+	}
+}
+
+// key returns the object key for a snapshot
+func (a *Archiver) key(snap *snapshot.Snapshot) string {
+	ext := ".json.gz"
+	if a.format == config.ArchiveFormatJSONLines {
+		ext = ".jsonl.gz"
+	}
+	parts := []string{a.prefix}
+	if a.profile != "" {
+		parts = append(parts, "profile="+a.profile)
+	}
+	parts = append(parts, "date="+snap.Timestamp.UTC().Format("2006-01-02"), snap.ID+ext)
+	return path.Join(parts...)
+}
+
+// encode returns the gzipped object body for a snapshot
+func (a *Archiver) encode(snap *snapshot.Snapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+
+	if a.format == config.ArchiveFormatJSONLines {
+		filtered := !isUnfiltered(snap.Response.Filters)
+		ts := snap.Timestamp.UTC().Format(time.RFC3339)
+		for _, r := range snapshot.Resources(snap.Response) {
+			row := archiveRow{SnapshotID: snap.ID, Timestamp: ts, Profile: a.profile, Filtered: filtered, Resource: r}
+			if err := enc.Encode(row); err != nil {
+				return nil, fmt.Errorf("encoding resource: %w", err)
+			}
+		}
+	} else if err := enc.Encode(snap); err != nil {
+		return nil, fmt.Errorf("encoding snapshot: %w", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("compressing snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SnapshotAdded archives a new snapshot in the background. It is meant to be
+// registered with snapshot.Store.Subscribe.
+func (a *Archiver) SnapshotAdded(snap *snapshot.Snapshot) {
+	a.pending.Add(1)
+	go func() {
+		defer a.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := a.Archive(ctx, snap); err != nil {
+			a.logger.Warn("failed to archive snapshot to S3", "snapshot", snap.ID, "error", err)
+		}
+	}()
+}
+
+// Archive writes a snapshot to S3
+func (a *Archiver) Archive(ctx context.Context, snap *snapshot.Snapshot) error {
+	body, err := a.encode(snap)
+	if err != nil {
+		return err
+	}
+	// The SDK resolves the bucket's endpoint for the region's partition
+	a.once.Do(func() {
+		if a.client != nil {
+			return
+		}
+		var opts []func(*awsconfig.LoadOptions) error
+		if a.region != "" {
+			opts = append(opts, awsconfig.WithRegion(a.region))
+		}
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			a.err = fmt.Errorf("loading AWS config: %w", err)
+			return
+		}
+		a.client = s3.NewFromConfig(cfg)
+	})
+	if a.err != nil {
+		return a.err
+	}
+
+	_, err = a.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(a.bucket),
+		Key:         aws.String(a.key(snap)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/gzip"),
+	})
+	if err != nil {
+		return fmt.Errorf("writing to S3: %w", err)
+	}
+	return nil
+}
+
+// Wait blocks until uploads in flight have finished
+func (a *Archiver) Wait() {
+	a.pending.Wait()
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+func TestArchiverArchive(t *testing.T) {
+	var method, path, contentHash string
+	var rows []archiveRow
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, contentHash = r.Method, r.URL.Path, r.Header.Get("X-Amz-Content-Sha256")
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("reading gzip body: %v", err)
+			return
+		}
+		scanner := bufio.NewScanner(gz)
+		for scanner.Scan() {
+			var row archiveRow
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Errorf("decoding row: %v", err)
+			}
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	a := NewArchiver(config.S3Config{Bucket: "cost-history", Prefix: "awscogs", Region: "us-east-1", Format: config.ArchiveFormatJSONLines}, "prod-org", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	a.client = s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+	})
+
+	snap := testSnapshot(3)
+	if err := a.Archive(context.Background(), snap); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	if method != http.MethodPut || path != "/cost-history/awscogs/profile=prod-org/date=2023-11-14/s1.jsonl.gz" {
+		t.Fatalf("request = %s %s", method, path)
+	}
+	if len(contentHash) != 64 {
+		t.Fatalf("X-Amz-Content-Sha256 = %q", contentHash)
+	}
+	if len(rows) != 3 || rows[0].SnapshotID != "s1" || rows[0].Profile != "prod-org" || rows[0].Type != "ec2" || rows[0].Filtered {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+}
+
+func TestArchiverKey(t *testing.T) {
+	a := NewArchiver(config.S3Config{Bucket: "cost-history", Prefix: "history/awscogs/", Format: config.ArchiveFormatJSON}, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if got := a.key(testSnapshot(0)); got != "history/awscogs/date=2023-11-14/s1.json.gz" {
+		t.Fatalf("key() = %q", got)
+	}
+
+	body, err := a.encode(testSnapshot(1))
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+	gz, err := gzip.NewReader(strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatalf("reading gzip body: %v", err)
+	}
+	var snap struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(gz).Decode(&snap); err != nil || snap.ID != "s1" {
+		t.Fatalf("decoded snapshot id %q, error %v", snap.ID, err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package focus converts cost estimates to FinOps FOCUS 1.0 rows, so they can
+// be combined with other FOCUS datasets. See https://focus.finops.org.
+package focus
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Charge periods a row can cover
+const (
+	PeriodHour  = "hour"
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// Row is one FOCUS cost row. Columns that awsCOGS can't know, such as
+// invoice and commitment discount details, are left out. Costs are on-demand
+// estimates, so billed, effective, list, and contracted cost are all equal.
+type Row struct {
+	BilledCost           types.CostValue   `json:"BilledCost"`
+	EffectiveCost        types.CostValue   `json:"EffectiveCost"`
+	ListCost             types.CostValue   `json:"ListCost"`
+	ContractedCost       types.CostValue   `json:"ContractedCost"`
+	BillingCurrency      string            `json:"BillingCurrency"`
+	BillingAccountId     string            `json:"BillingAccountId"`
+	BillingAccountName   string            `json:"BillingAccountName"`
+	SubAccountId         string            `json:"SubAccountId"`
+	SubAccountName       string            `json:"SubAccountName"`
+	BillingPeriodStart   string            `json:"BillingPeriodStart"`
+	BillingPeriodEnd     string            `json:"BillingPeriodEnd"`
+	ChargePeriodStart    string            `json:"ChargePeriodStart"`
+	ChargePeriodEnd      string            `json:"ChargePeriodEnd"`
+	ChargeCategory       string            `json:"ChargeCategory"`
+	ChargeDescription    string            `json:"ChargeDescription"`
+	ChargeFrequency      string            `json:"ChargeFrequency"`
+	ProviderName         string            `json:"ProviderName"`
+	PublisherName        string            `json:"PublisherName"`
+	InvoiceIssuerName    string            `json:"InvoiceIssuerName"`
+	RegionId             string            `json:"RegionId"`
+	RegionName           string            `json:"RegionName"`
+	ResourceId           string            `json:"ResourceId"`
+	ResourceName         string            `json:"ResourceName"`
+	ResourceType         string            `json:"ResourceType"`
+	ServiceCategory      string            `json:"ServiceCategory"`
+	ServiceName          string            `json:"ServiceName"`
+	Tags                 map[string]string `json:"Tags"`
+	XAwscogsResourceType string            `json:"x_AwscogsResourceType"` // awsCOGS resource filter name
+	XAwscogsSize         string            `json:"x_AwscogsSize"`
+	XAwscogsHourlyCost   types.CostValue   `json:"x_AwscogsHourlyCost"`
+}
+
+// Columns are the CSV header, in the order Row.values returns them
+var Columns = []string{
+	"BilledCost", "EffectiveCost", "ListCost", "ContractedCost", "BillingCurrency",
+	"BillingAccountId", "BillingAccountName", "SubAccountId", "SubAccountName",
+	"BillingPeriodStart", "BillingPeriodEnd", "ChargePeriodStart", "ChargePeriodEnd",
+	"ChargeCategory", "ChargeDescription", "ChargeFrequency",
+	"ProviderName", "PublisherName", "InvoiceIssuerName",
+	"RegionId", "RegionName", "ResourceId", "ResourceName", "ResourceType",
+	"ServiceCategory", "ServiceName", "Tags",
+	"x_AwscogsResourceType", "x_AwscogsSize", "x_AwscogsHourlyCost",
+}
+
+func (r Row) values() []string {
+	cost := func(v types.CostValue) string { return strconv.FormatFloat(float64(v), 'f', -1, 64) }
+	tags := "{}"
+	if len(r.Tags) > 0 {
+		if data, err := json.Marshal(r.Tags); err == nil {
+			tags = string(data)
+		}
+	}
+	return []string{
+		cost(r.BilledCost), cost(r.EffectiveCost), cost(r.ListCost), cost(r.ContractedCost), r.BillingCurrency,
+		r.BillingAccountId, r.BillingAccountName, r.SubAccountId, r.SubAccountName,
+		r.BillingPeriodStart, r.BillingPeriodEnd, r.ChargePeriodStart, r.ChargePeriodEnd,
+		r.ChargeCategory, r.ChargeDescription, r.ChargeFrequency,
+		r.ProviderName, r.PublisherName, r.InvoiceIssuerName,
+		r.RegionId, r.RegionName, r.ResourceId, r.ResourceName, r.ResourceType,
+		r.ServiceCategory, r.ServiceName, tags,
+		r.XAwscogsResourceType, r.XAwscogsSize, cost(r.XAwscogsHourlyCost),
+	}
+}
+
+// service describes how a resource type is billed
+type service struct {
+	provider, invoiceIssuer string
+	name, category          string
+	resourceType            string
+}
+
+const (
+	providerAWS   = "AWS"
+	issuerAWS     = "Amazon Web Services, Inc."
+	providerAzure = "Microsoft"
+	issuerAzure   = "Microsoft"
+)
+
+// services maps awsCOGS resource types to FOCUS service names and categories
+var services = map[string]service{
+	"ec2":                 {providerAWS, issuerAWS, "Amazon Elastic Compute Cloud", "Compute", "Instance"},
+	"ebs":                 {providerAWS, issuerAWS, "Amazon Elastic Block Store", "Storage", "Volume"},
+	"ecs":                 {providerAWS, issuerAWS, "Amazon Elastic Container Service", "Compute", "Service"},
+	"rds":                 {providerAWS, issuerAWS, "Amazon Relational Database Service", "Databases", "DB Instance"},
+	"eks":                 {providerAWS, issuerAWS, "Amazon Elastic Kubernetes Service", "Compute", "Cluster"},
+	"elb":                 {providerAWS, issuerAWS, "Elastic Load Balancing", "Networking", "Load Balancer"},
+	"nat":                 {providerAWS, issuerAWS, "Amazon Elastic Compute Cloud", "Networking", "NAT Gateway"},
+	"eip":                 {providerAWS, issuerAWS, "Amazon Virtual Private Cloud", "Networking", "Elastic IP"},
+	"secrets":             {providerAWS, issuerAWS, "AWS Secrets Manager", "Security", "Secret"},
+	"publicipv4":          {providerAWS, issuerAWS, "Amazon Virtual Private Cloud", "Networking", "Public IPv4 Address"},
+	"lambda":              {providerAWS, issuerAWS, "AWS Lambda", "Compute", "Function"},
+	"capacityreservation": {providerAWS, issuerAWS, "Amazon Elastic Compute Cloud", "Compute", "Capacity Reservation"},
+	"dedicatedhost":       {providerAWS, issuerAWS, "Amazon Elastic Compute Cloud", "Compute", "Dedicated Host"},
+	"kinesis":             {providerAWS, issuerAWS, "Amazon Kinesis", "Analytics", "Data Stream"},
+	"firehose":            {providerAWS, issuerAWS, "Amazon Data Firehose", "Analytics", "Delivery Stream"},
+	"sqs":                 {providerAWS, issuerAWS, "Amazon Simple Queue Service", "Integration", "Queue"},
+	"sns":                 {providerAWS, issuerAWS, "Amazon Simple Notification Service", "Integration", "Topic"},
+	"eventbridge":         {providerAWS, issuerAWS, "Amazon EventBridge", "Integration", "Event Bus"},
+	"alarm":               {providerAWS, issuerAWS, "Amazon CloudWatch", "Management and Governance", "Alarm"},
+	"dashboard":           {providerAWS, issuerAWS, "Amazon CloudWatch", "Management and Governance", "Dashboard"},
+	"custommetrics":       {providerAWS, issuerAWS, "Amazon CloudWatch", "Management and Governance", "Metric"},
+	"datatransfer":        {providerAWS, issuerAWS, "AWS Data Transfer", "Networking", "Data Transfer"},
+	"vm":                  {providerAzure, issuerAzure, "Virtual Machines", "Compute", "Virtual Machine"},
+}
+
+// ValidPeriod reports whether period is a supported charge period
+func ValidPeriod(period string) bool {
+	return period == PeriodHour || period == PeriodDay || period == PeriodMonth
+}
+
+// chargePeriod returns the UTC period of the given length containing now
+func chargePeriod(period string, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	switch period {
+	case PeriodDay:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	case PeriodMonth:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := now.Truncate(time.Hour)
+		return start, start.Add(time.Hour)
+	}
+}
+
+// Rows converts every resource in resp to a FOCUS row charged for the period
+// containing now, assuming it runs for the whole period at its current
+// hourly cost. Accounts are reported as both billing and sub account, since
+// the paying account isn't known.
+func Rows(resp *types.CostResponse, period string, now time.Time) []Row {
+	start, end := chargePeriod(period, now)
+	hours := types.CostValue(end.Sub(start).Hours())
+	billingStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	billingEnd := billingStart.AddDate(0, 1, 0)
+
+	currency := resp.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	rows := []Row{}
+	for _, r := range snapshot.Resources(resp) {
+		svc, ok := services[r.Type]
+		if !ok {
+			svc = service{providerAWS, issuerAWS, r.Type, "Other", r.Type}
+		}
+		name := r.Name
+		if name == "" {
+			name = r.ID
+		}
+		description := svc.resourceType
+		if r.Size != "" {
+			description += " " + r.Size
+		}
+		cost := r.HourlyCost * hours
+
+		rows = append(rows, Row{
+			BilledCost:           cost,
+			EffectiveCost:        cost,
+			ListCost:             cost,
+			ContractedCost:       cost,
+			BillingCurrency:      currency,
+			BillingAccountId:     r.AccountID,
+			BillingAccountName:   r.AccountName,
+			SubAccountId:         r.AccountID,
+			SubAccountName:       r.AccountName,
+			BillingPeriodStart:   billingStart.Format(time.RFC3339),
+			BillingPeriodEnd:     billingEnd.Format(time.RFC3339),
+			ChargePeriodStart:    start.Format(time.RFC3339),
+			ChargePeriodEnd:      end.Format(time.RFC3339),
+			ChargeCategory:       "Usage",
+			ChargeDescription:    description,
+			ChargeFrequency:      "Usage-Based",
+			ProviderName:         svc.provider,
+			PublisherName:        svc.provider,
+			InvoiceIssuerName:    svc.invoiceIssuer,
+			RegionId:             r.Region,
+			RegionName:           r.Region,
+			ResourceId:           r.ID,
+			ResourceName:         name,
+			ResourceType:         svc.resourceType,
+			ServiceCategory:      svc.category,
+			ServiceName:          svc.name,
+			Tags:                 r.Tags,
+			XAwscogsResourceType: r.Type,
+			XAwscogsSize:         r.Size,
+			XAwscogsHourlyCost:   r.HourlyCost,
+		})
+	}
+	return rows
+}
+
+// WriteCSV writes rows as CSV with a header
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	for _, r := range rows {
+		if err := cw.Write(r.values()); err != nil {
+			return fmt.Errorf("writing row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,76 @@
+package focus
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestRows(t *testing.T) {
+	resp := &types.CostResponse{
+		Currency: "USD",
+		EC2Instances: []types.EC2Instance{{
+			AccountID: "111111111111", AccountName: "prod", Region: "us-east-1",
+			InstanceID: "i-1", Name: "web", InstanceType: "m5.large", HourlyCost: 0.096,
+			Tags: map[string]string{"team": "web"},
+		}},
+		Secrets: []types.Secret{{AccountID: "111111111111", AccountName: "prod", Region: "us-east-1", ARN: "arn:aws:secretsmanager:us-east-1:111111111111:secret:db", HourlyCost: 0.0005}},
+	}
+	now := time.Date(2024, 2, 10, 15, 30, 0, 0, time.UTC)
+
+	rows := Rows(resp, PeriodMonth, now)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	ec2 := rows[0]
+	// February 2024 has 29 days
+	if got, want := float64(ec2.BilledCost), 0.096*29*24; got < want-1e-9 || got > want+1e-9 {
+		t.Fatalf("BilledCost = %v, want %v", got, want)
+	}
+	if ec2.ChargePeriodStart != "2024-02-01T00:00:00Z" || ec2.ChargePeriodEnd != "2024-03-01T00:00:00Z" {
+		t.Fatalf("charge period = %s - %s", ec2.ChargePeriodStart, ec2.ChargePeriodEnd)
+	}
+	if ec2.ServiceName != "Amazon Elastic Compute Cloud" || ec2.ServiceCategory != "Compute" || ec2.ProviderName != "AWS" || ec2.SubAccountId != "111111111111" {
+		t.Fatalf("unexpected row: %+v", ec2)
+	}
+	if ec2.ChargeDescription != "Instance m5.large" || ec2.ResourceName != "web" {
+		t.Fatalf("description = %q, name = %q", ec2.ChargeDescription, ec2.ResourceName)
+	}
+	if rows[1].ResourceName != rows[1].ResourceId || rows[1].ServiceName != "AWS Secrets Manager" {
+		t.Fatalf("unexpected secret row: %+v", rows[1])
+	}
+
+	hour := Rows(resp, PeriodHour, now)[0]
+	if hour.BilledCost != 0.096 || hour.ChargePeriodStart != "2024-02-10T15:00:00Z" || hour.BillingPeriodStart != "2024-02-01T00:00:00Z" {
+		t.Fatalf("unexpected hourly row: %+v", hour)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	resp := &types.CostResponse{EC2Instances: []types.EC2Instance{{
+		AccountID: "111111111111", Region: "us-east-1", InstanceID: "i-1", HourlyCost: 0.5,
+		Tags: map[string]string{"team": "web"},
+	}}}
+
+	var buf strings.Builder
+	if err := WriteCSV(&buf, Rows(resp, PeriodDay, time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC))); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(records) != 2 || len(records[1]) != len(Columns) {
+		t.Fatalf("unexpected CSV: %v", records)
+	}
+	row := make(map[string]string)
+	for i, col := range Columns {
+		row[col] = records[1][i]
+	}
+	if row["BilledCost"] != "12" || row["BillingCurrency"] != "USD" || row["Tags"] != `{"team":"web"}` || row["x_AwscogsResourceType"] != "ec2" {
+		t.Fatalf("unexpected CSV row: %v", row)
+	}
+}
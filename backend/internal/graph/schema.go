@@ -0,0 +1,255 @@
+// Package graph exposes the cost model over GraphQL. Object types are derived
+// from the Go response types via reflection, so field names match the JSON API.
+package graph
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Resolvers supplies the data behind the schema
+type Resolvers struct {
+	// Scan discovers and prices resources matching filters
+	Scan      func(ctx context.Context, filters types.AppliedFilters) (*types.CostResponse, error)
+	Snapshots *snapshot.Store
+}
+
+// JSON is an opaque scalar for maps and provider-specific records
+var JSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "JSON",
+	Description:  "Arbitrary JSON value",
+	Serialize:    func(value any) any { return value },
+	ParseValue:   func(value any) any { return value },
+	ParseLiteral: func(ast.Value) any { return nil },
+})
+
+// NewSchema builds the GraphQL schema
+func NewSchema(r Resolvers) (graphql.Schema, error) {
+	b := &builder{objects: map[reflect.Type]*graphql.Object{}}
+	stringList := graphql.NewList(graphql.NewNonNull(graphql.String))
+	filterArgs := graphql.FieldConfigArgument{
+		"account":  {Type: stringList, Description: "Account names or IDs"},
+		"region":   {Type: stringList, Description: "Regions"},
+		"resource": {Type: stringList, Description: "Resource types (ec2, ebs, rds, ...)"},
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"costs": {
+				Type:        b.output(reflect.TypeFor[types.CostResponse]()),
+				Description: "Scan resources and return their costs. Every scan is recorded as a snapshot.",
+				Args:        filterArgs,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return r.Scan(p.Context, filtersArg(p.Args))
+				},
+			},
+			"snapshots": {
+				Type:        graphql.NewList(b.output(reflect.TypeFor[snapshot.Snapshot]())),
+				Description: "Recorded scans covering the filters, newest first",
+				Args: graphql.FieldConfigArgument{
+					"account":  filterArgs["account"],
+					"region":   filterArgs["region"],
+					"resource": filterArgs["resource"],
+					"limit":    {Type: graphql.Int, Description: "Maximum number of snapshots to return"},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					filters := filtersArg(p.Args)
+					limit, _ := p.Args["limit"].(int)
+
+					list := r.Snapshots.List()
+					var out []*snapshot.Snapshot
+					for i := len(list) - 1; i >= 0; i-- {
+						if limit > 0 && len(out) == limit {
+							break
+						}
+						if snapshot.Covers(list[i].Response.Filters, filters) {
+							out = append(out, list[i])
+						}
+					}
+					return out, nil
+				},
+			},
+			"snapshot": {
+				Type:        b.output(reflect.TypeFor[snapshot.Snapshot]()),
+				Description: "A recorded scan by ID",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, _ := p.Args["id"].(string)
+					for _, snap := range r.Snapshots.List() {
+						if snap.ID == id {
+							return snap, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+			"resource": {
+				Type:        b.output(reflect.TypeFor[snapshot.Detail]()),
+				Description: "Full detail for one resource from the latest snapshot that contains it",
+				Args: graphql.FieldConfigArgument{
+					"type":    {Type: graphql.NewNonNull(graphql.String)},
+					"id":      {Type: graphql.NewNonNull(graphql.String)},
+					"account": filterArgs["account"],
+					"region":  filterArgs["region"],
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					resourceType, _ := p.Args["type"].(string)
+					id, _ := p.Args["id"].(string)
+					if detail := r.Snapshots.FindResource(resourceType, id, filtersArg(p.Args)); detail != nil {
+						return detail, nil
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// filtersArg reads the account, region, and resource list arguments
+func filtersArg(args map[string]any) types.AppliedFilters {
+	list := func(name string) []string {
+		values, _ := args[name].([]any)
+		var out []string
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return types.AppliedFilters{
+		Accounts:      list("account"),
+		Regions:       list("region"),
+		ResourceTypes: list("resource"),
+	}
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// builder converts Go types to GraphQL output types
+type builder struct {
+	objects map[reflect.Type]*graphql.Object
+}
+
+func (b *builder) output(t reflect.Type) graphql.Output {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return graphql.DateTime
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return graphql.String
+	case reflect.Bool:
+		return graphql.Boolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return graphql.Int
+	case reflect.Float32, reflect.Float64:
+		return graphql.Float
+	case reflect.Slice, reflect.Array:
+		return graphql.NewList(b.output(t.Elem()))
+	case reflect.Struct:
+		return b.object(t)
+	default:
+		return JSON
+	}
+}
+
+// object returns the object type for a struct, creating it on first use.
+// Embedded structs without a JSON name are flattened, as encoding/json does.
+func (b *builder) object(t reflect.Type) *graphql.Object {
+	if obj, ok := b.objects[t]; ok {
+		return obj
+	}
+
+	obj := graphql.NewObject(graphql.ObjectConfig{
+		Name: t.Name(),
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			fields := graphql.Fields{}
+			b.addFields(fields, t, nil)
+			return fields
+		}),
+	})
+	b.objects[t] = obj
+	return obj
+}
+
+func (b *builder) addFields(fields graphql.Fields, t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			b.addFields(fields, f.Type, fieldIndex)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = &graphql.Field{
+			Type:    b.output(f.Type),
+			Resolve: resolveField(fieldIndex),
+		}
+	}
+}
+
+// resolveField reads a struct field, converting named basic types (such as
+// CostValue) to the built-in types the GraphQL scalars expect
+func resolveField(index []int) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		v := reflect.ValueOf(p.Source)
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, nil
+		}
+
+		f := v.FieldByIndex(index)
+		switch f.Kind() {
+		case reflect.String:
+			return f.String(), nil
+		case reflect.Bool:
+			return f.Bool(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int(f.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			return int(f.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return f.Float(), nil
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			if f.IsNil() {
+				return nil, nil
+			}
+		}
+		return f.Interface(), nil
+	}
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestSchemaResolvesCostsSnapshotsAndResources(t *testing.T) {
+	store, err := snapshot.NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := store.Add(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), &types.CostResponse{
+		TotalCost: 0.5,
+		EC2Instances: []types.EC2Instance{{
+			AccountID: "111", Region: "us-east-1", InstanceID: "i-1", InstanceType: "t3.large", HourlyCost: 0.5,
+			Tags:           map[string]string{"team": "api"},
+			CostComponents: []types.CostComponent{types.HourlyComponent("compute", "hour", 1, 0.5)},
+		}},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	var scanned types.AppliedFilters
+	schema, err := NewSchema(Resolvers{
+		Scan: func(_ context.Context, filters types.AppliedFilters) (*types.CostResponse, error) {
+			scanned = filters
+			return &types.CostResponse{
+				TotalCost: 1.25,
+				Accounts:  []types.AccountSummary{{AccountID: "111", Counts: map[string]int{"ec2": 1}, TotalCost: 1.25}},
+			}, nil
+		},
+		Snapshots: store,
+	})
+	if err != nil {
+		t.Fatalf("NewSchema() error = %v", err)
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `{
+			costs(region: ["us-east-1"]) { totalCost accounts { accountId counts } }
+			snapshots(limit: 1) { id response { totalCost } }
+			resource(type: "ec2", id: "i-1") { id size tags snapshotId pricing { name hourlyCost } }
+		}`,
+		Context: context.Background(),
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("query errors: %v", result.Errors)
+	}
+	if !slices.Equal(scanned.Regions, []string{"us-east-1"}) {
+		t.Fatalf("scan filters = %+v", scanned)
+	}
+
+	data, _ := json.Marshal(result.Data)
+	var got struct {
+		Costs struct {
+			TotalCost float64 `json:"totalCost"`
+			Accounts  []struct {
+				AccountID string         `json:"accountId"`
+				Counts    map[string]int `json:"counts"`
+			} `json:"accounts"`
+		} `json:"costs"`
+		Snapshots []struct {
+			ID       string `json:"id"`
+			Response struct {
+				TotalCost float64 `json:"totalCost"`
+			} `json:"response"`
+		} `json:"snapshots"`
+		Resource struct {
+			ID      string            `json:"id"`
+			Size    string            `json:"size"`
+			Tags    map[string]string `json:"tags"`
+			Pricing []struct {
+				Name       string  `json:"name"`
+				HourlyCost float64 `json:"hourlyCost"`
+			} `json:"pricing"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+
+	if got.Costs.TotalCost != 1.25 || len(got.Costs.Accounts) != 1 || got.Costs.Accounts[0].Counts["ec2"] != 1 {
+		t.Fatalf("costs = %+v", got.Costs)
+	}
+	if len(got.Snapshots) != 1 || got.Snapshots[0].Response.TotalCost != 0.5 {
+		t.Fatalf("snapshots = %+v", got.Snapshots)
+	}
+	r := got.Resource
+	if r.ID != "i-1" || r.Size != "t3.large" || r.Tags["team"] != "api" || len(r.Pricing) != 1 || r.Pricing[0].Name != "compute" {
+		t.Fatalf("resource = %+v", r)
+	}
+}
@@ -0,0 +1,104 @@
+// Package ignore excludes known exceptions, such as standby databases kept
+// for disaster recovery, from totals and the waste report with the rules in
+// the ignore config section and the ones created through the API.
+package ignore
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/costcenter"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+)
+
+// TagRule is the rule ID reported for resources ignored because they carry
+// the ignore tag
+const TagRule = "tag"
+
+// Matcher finds the rule that ignores a resource
+type Matcher struct {
+	tag   string
+	rules []rule
+}
+
+type rule struct {
+	snapshot.IgnoreRule
+	patterns []*regexp.Regexp
+}
+
+// New returns a Matcher for rules. Resources whose tag is set to "true" are
+// ignored too, unless tag is empty.
+func New(tag string, rules []snapshot.IgnoreRule) *Matcher {
+	m := &Matcher{tag: tag}
+	for _, r := range rules {
+		compiled := rule{IgnoreRule: r}
+		for _, p := range r.Resources {
+			compiled.patterns = append(compiled.patterns, pattern(p))
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	return m
+}
+
+// pattern compiles a resource pattern, in which "*" matches any characters,
+// to a case-insensitive regular expression matching the whole ID or name
+func pattern(p string) *regexp.Regexp {
+	parts := strings.Split(strings.TrimSpace(p), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+}
+
+// Empty reports whether the matcher can't ignore anything
+func (m *Matcher) Empty() bool {
+	return m.tag == "" && len(m.rules) == 0
+}
+
+// Match returns the first rule that ignores r. A resource carrying the ignore
+// tag is ignored by a rule with the ID TagRule.
+func (m *Matcher) Match(r snapshot.Resource) (snapshot.IgnoreRule, bool) {
+	if m.tag != "" {
+		if value, ok := costcenter.TagValue(r.Tags, m.tag); ok && strings.EqualFold(strings.TrimSpace(value), "true") {
+			return snapshot.IgnoreRule{
+				ID:             TagRule,
+				IgnoreRuleSpec: snapshot.IgnoreRuleSpec{Reason: "tagged " + m.tag + "=true"},
+				Source:         snapshot.IgnoreRuleFromConfig,
+			}, true
+		}
+	}
+	for _, rule := range m.rules {
+		if rule.matches(r) {
+			return rule.IgnoreRule, true
+		}
+	}
+	return snapshot.IgnoreRule{}, false
+}
+
+func (rule rule) matches(r snapshot.Resource) bool {
+	if len(rule.ResourceTypes) > 0 && !containsFold(rule.ResourceTypes, r.Type) {
+		return false
+	}
+	if len(rule.patterns) > 0 {
+		found := false
+		for _, p := range rule.patterns {
+			if p.MatchString(r.ID) || (r.Name != "" && p.MatchString(r.Name)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return costcenter.Matches(rule.Accounts, rule.Tags, r.AccountID, r.AccountName, r.Tags)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
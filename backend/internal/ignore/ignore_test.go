@@ -0,0 +1,42 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+)
+
+func TestMatch(t *testing.T) {
+	m := New("awscogs:ignore", []snapshot.IgnoreRule{
+		{ID: "dr-standby", IgnoreRuleSpec: snapshot.IgnoreRuleSpec{Reason: "DR standby", Resources: []string{"dr-*"}, ResourceTypes: []string{"rds"}}},
+		{ID: "load-test", IgnoreRuleSpec: snapshot.IgnoreRuleSpec{Reason: "Load test", Resources: []string{"arn:aws:lambda:*:function:loadtest-*"}}},
+		{ID: "sandbox-spare", IgnoreRuleSpec: snapshot.IgnoreRuleSpec{Reason: "Spare", Accounts: []string{"sandbox"}, Tags: map[string]string{"spare": "*"}}},
+	})
+
+	tests := []struct {
+		name     string
+		resource snapshot.Resource
+		want     string
+	}{
+		{"ID pattern and type", snapshot.Resource{Type: "rds", ID: "DR-orders-db"}, "dr-standby"},
+		{"wrong type", snapshot.Resource{Type: "ec2", ID: "dr-orders-db"}, ""},
+		{"ARN pattern", snapshot.Resource{Type: "lambda", ID: "arn:aws:lambda:us-east-1:111111111111:function:loadtest-api", Name: "loadtest-api"}, "load-test"},
+		{"pattern matches the whole ID", snapshot.Resource{Type: "rds", ID: "orders-dr-db"}, ""},
+		{"ignore tag", snapshot.Resource{Type: "ec2", ID: "i-1", Tags: map[string]string{"AWSCOGS:IGNORE": "True"}}, TagRule},
+		{"ignore tag not true", snapshot.Resource{Type: "ec2", ID: "i-1", Tags: map[string]string{"awscogs:ignore": "no"}}, ""},
+		{"account and tag", snapshot.Resource{Type: "ec2", ID: "i-2", AccountName: "Sandbox", Tags: map[string]string{"spare": "yes"}}, "sandbox-spare"},
+		{"tag in another account", snapshot.Resource{Type: "ec2", ID: "i-2", AccountName: "prod", Tags: map[string]string{"spare": "yes"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := m.Match(tt.resource)
+			if ok != (tt.want != "") || rule.ID != tt.want {
+				t.Errorf("Match() = %q, %v; want %q", rule.ID, ok, tt.want)
+			}
+		})
+	}
+
+	if !New("", nil).Empty() || m.Empty() {
+		t.Error("expected only a matcher without a tag or rules to be empty")
+	}
+}
@@ -0,0 +1,170 @@
+// Package logging builds the slog loggers used by each part of awsCOGS. Each
+// component (api, discovery, pricing) has its own level, and levels can be
+// changed while the server runs.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// LevelState is the log levels in effect
+type LevelState struct {
+	Level      string            `json:"level"`      // Default level
+	Components map[string]string `json:"components"` // Level of each component
+	Debug      bool              `json:"debug"`      // Debug logging forced on for everything, e.g. by SIGUSR1
+}
+
+// LevelSpec sets log levels at runtime. Components not listed use Level.
+type LevelSpec struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// Levels holds the level of the default logger and each component's logger
+type Levels struct {
+	handler    slog.Handler
+	configured config.LogConfig
+
+	vars map[string]*slog.LevelVar // keyed by component, "" = default
+
+	mu        sync.Mutex
+	level     slog.Level
+	overrides map[string]slog.Level
+	debug     bool
+}
+
+// New returns levels set from cfg, logging JSON to w
+func New(w io.Writer, cfg config.LogConfig) (*Levels, error) {
+	l := &Levels{
+		handler:    slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		configured: cfg,
+		vars:       map[string]*slog.LevelVar{"": {}},
+	}
+	for _, component := range config.LogComponents {
+		l.vars[component] = &slog.LevelVar{}
+	}
+	if err := l.Set(LevelSpec{Level: cfg.Level, Components: cfg.Components}); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Logger returns component's logger, or the default logger for "". Records
+// from a component's logger have a component attribute.
+func (l *Levels) Logger(component string) *slog.Logger {
+	level, ok := l.vars[component]
+	if !ok {
+		panic(fmt.Sprintf("unknown log component %q", component))
+	}
+	logger := slog.New(&levelHandler{Handler: l.handler, level: level})
+	if component != "" {
+		logger = logger.With("component", component)
+	}
+	return logger
+}
+
+// Set replaces the runtime levels, turning off forced debug logging
+func (l *Levels) Set(spec LevelSpec) error {
+	level, err := parseLevel(spec.Level)
+	if err != nil {
+		return err
+	}
+	overrides := make(map[string]slog.Level, len(spec.Components))
+	for component, s := range spec.Components {
+		if _, ok := l.vars[component]; !ok || component == "" {
+			return fmt.Errorf("unknown log component %q (valid: %s)", component, strings.Join(config.LogComponents, ", "))
+		}
+		if overrides[component], err = parseLevel(s); err != nil {
+			return fmt.Errorf("%s: %w", component, err)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level, l.overrides, l.debug = level, overrides, false
+	l.apply()
+	return nil
+}
+
+// Reset restores the configured levels
+func (l *Levels) Reset() {
+	l.Set(LevelSpec{Level: l.configured.Level, Components: l.configured.Components})
+}
+
+// ToggleDebug forces debug logging on for every component, or back off to
+// the levels set before, and reports whether it's now on
+func (l *Levels) ToggleDebug() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debug = !l.debug
+	l.apply()
+	return l.debug
+}
+
+// apply sets each logger's level. l.mu must be held.
+func (l *Levels) apply() {
+	for component, v := range l.vars {
+		level, ok := l.overrides[component]
+		if !ok {
+			level = l.level
+		}
+		if l.debug {
+			level = slog.LevelDebug
+		}
+		v.Set(level)
+	}
+}
+
+// State returns the levels in effect
+func (l *Levels) State() LevelState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := LevelState{Level: levelName(l.vars[""].Level()), Components: make(map[string]string, len(config.LogComponents)), Debug: l.debug}
+	for _, component := range config.LogComponents {
+		state.Components[component] = levelName(l.vars[component].Level())
+	}
+	return state
+}
+
+// levelName returns level as it's written in config, e.g. "debug"
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// parseLevel parses debug, info, warn, or error, in any case. Empty is info.
+func parseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("invalid log level %q (valid: debug, info, warn, error)", s)
+	}
+	return level, nil
+}
+
+// levelHandler drops records below its level before they reach the shared
+// handler, so each component can have its own level
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
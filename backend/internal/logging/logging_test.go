@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// records returns the messages and components of the JSON records in buf
+func records(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record struct{ Msg, Component string }
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		got = append(got, record.Component+":"+record.Msg)
+	}
+	buf.Reset()
+	return got
+}
+
+func TestComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	levels, err := New(&buf, config.LogConfig{Level: "info", Components: map[string]string{"discovery": "debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, discovery, pricing := levels.Logger(""), levels.Logger(config.LogComponentDiscovery), levels.Logger(config.LogComponentPricing)
+
+	root.Debug("hidden")
+	discovery.Debug("scanning")
+	pricing.Debug("hidden")
+	pricing.Info("priced")
+	if got := strings.Join(records(t, &buf), ","); got != "discovery:scanning,pricing:priced" {
+		t.Errorf("records = %s", got)
+	}
+
+	if err := levels.Set(LevelSpec{Level: "warn", Components: map[string]string{"pricing": "debug"}}); err != nil {
+		t.Fatal(err)
+	}
+	discovery.Info("hidden")
+	pricing.Debug("lookup")
+	if got := strings.Join(records(t, &buf), ","); got != "pricing:lookup" {
+		t.Errorf("records after Set = %s", got)
+	}
+	if err := levels.Set(LevelSpec{Level: "info", Components: map[string]string{"scheduler": "debug"}}); err == nil {
+		t.Error("Set() accepted an unknown component")
+	}
+
+	levels.Reset()
+	if state := levels.State(); state.Level != "info" || state.Components["discovery"] != "debug" || state.Components["api"] != "info" {
+		t.Errorf("State() after Reset = %+v", state)
+	}
+}
+
+func TestToggleDebug(t *testing.T) {
+	var buf bytes.Buffer
+	levels, err := New(&buf, config.LogConfig{Level: "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := levels.Logger(config.LogComponentAPI)
+
+	if !levels.ToggleDebug() {
+		t.Fatal("ToggleDebug() = false, want debug on")
+	}
+	api.Debug("request")
+	if got := records(t, &buf); len(got) != 1 {
+		t.Errorf("records with debug on = %v", got)
+	}
+	if levels.ToggleDebug() {
+		t.Fatal("ToggleDebug() = true, want debug off")
+	}
+	api.Warn("hidden")
+	if got := records(t, &buf); len(got) != 0 {
+		t.Errorf("records with debug off = %v", got)
+	}
+	if state := levels.State(); state.Debug || state.Level != "error" {
+		t.Errorf("State() = %+v", state)
+	}
+}
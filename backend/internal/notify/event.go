@@ -0,0 +1,22 @@
+package notify
+
+import "time"
+
+// Event types
+const (
+	EventScanCompleted     = "scan.completed"
+	EventBudgetExceeded    = "budget.exceeded"
+	EventAnomalyDetected   = "anomaly.detected"
+	EventExpensiveResource = "resource.expensive"
+	EventHighCostResource  = "resource.highcost"
+	EventWeeklyDigest      = "digest.weekly"
+)
+
+// Event is a notification delivered to sinks
+type Event struct {
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Summary   string    `json:"summary"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data,omitempty"`
+}
@@ -0,0 +1,261 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/digest"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Monitor turns new snapshots into notification events and sends the weekly digest
+type Monitor struct {
+	config    *config.Config
+	snapshots *snapshot.Store
+	notifier  *Notifier
+	logger    *slog.Logger
+}
+
+// NewMonitor creates a new monitor
+func NewMonitor(cfg *config.Config, snapshots *snapshot.Store, notifier *Notifier, logger *slog.Logger) *Monitor {
+	return &Monitor{
+		config:    cfg,
+		snapshots: snapshots,
+		notifier:  notifier,
+		logger:    logger,
+	}
+}
+
+// SnapshotAdded evaluates a new snapshot against the previous one. It is
+// meant to be registered with snapshot.Store.Subscribe.
+func (m *Monitor) SnapshotAdded(snap *snapshot.Snapshot) {
+	for _, event := range m.Evaluate(snap) {
+		m.notifier.Notify(event)
+	}
+}
+
+// Evaluate returns the events triggered by a new snapshot
+func (m *Monitor) Evaluate(snap *snapshot.Snapshot) []Event {
+	filters := snap.Response.Filters
+	current := snapshot.Resources(snap.Response)
+	currentTotal := sumCost(current)
+
+	events := []Event{{
+		Type:  EventScanCompleted,
+		Title: "awsCOGS scan completed",
+		Summary: fmt.Sprintf("%d resources, %s/hr (%s/mo), status %s",
+			len(current), money(currentTotal), money(currentTotal*digest.HoursPerMonth), snap.Response.Status),
+		Timestamp: snap.Timestamp,
+		Data: map[string]any{
+			"snapshotId":    snap.ID,
+			"resourceCount": len(current),
+			"hourlyCost":    currentTotal,
+			"status":        snap.Response.Status,
+			"filters":       filters,
+		},
+	}}
+
+	prev := m.snapshots.AtOrBefore(snap.Timestamp.Add(-time.Nanosecond), filters)
+	if prev == nil {
+		// Nothing to compare against yet; avoid flagging every resource as new
+		return append(events, m.budgetEvents(snap, nil)...)
+	}
+
+	previous := snapshot.FilterResources(snapshot.Resources(prev.Response), filters)
+	previousTotal := sumCost(previous)
+	diff := snapshot.Compare(previous, current)
+
+	notified := make(map[string]bool)
+	if threshold := m.config.Notify.ExpensiveResourceHourly; threshold > 0 {
+		for _, r := range diff.Added {
+			if float64(r.HourlyCost) < threshold {
+				continue
+			}
+			notified[r.Key()] = true
+			events = append(events, Event{
+				Type:  EventExpensiveResource,
+				Title: fmt.Sprintf("New expensive %s resource: %s", r.Type, displayName(r)),
+				Summary: fmt.Sprintf("%s %s in %s (%s) costs %s/hr (%s/mo)",
+					r.Type, displayName(r), accountLabel(r), r.Region, money(r.HourlyCost), money(r.HourlyCost*digest.HoursPerMonth)),
+				Timestamp: snap.Timestamp,
+				Data:      r,
+			})
+		}
+	}
+
+	// Resources that became high cost, whether new or grown, except those
+	// just reported as new expensive resources
+	wasHighCost := make(map[string]bool)
+	for _, r := range previous {
+		if r.HighCost {
+			wasHighCost[r.Key()] = true
+		}
+	}
+	for _, r := range current {
+		if !r.HighCost || wasHighCost[r.Key()] || notified[r.Key()] {
+			continue
+		}
+		events = append(events, Event{
+			Type:  EventHighCostResource,
+			Title: fmt.Sprintf("High-cost %s resource: %s", r.Type, displayName(r)),
+			Summary: fmt.Sprintf("%s %s in %s (%s) costs %s/hr (%s/mo), over the %s/hr threshold",
+				r.Type, displayName(r), accountLabel(r), r.Region, money(r.HourlyCost), money(r.HourlyCost*digest.HoursPerMonth),
+				money(types.CostValue(m.config.CostThresholds.Limit(r.Type)))),
+			Timestamp: snap.Timestamp,
+			Data:      r,
+		})
+	}
+
+	if pct := m.config.Notify.AnomalyPercent; pct > 0 && previousTotal > 0 {
+		change := float64(currentTotal-previousTotal) / float64(previousTotal) * 100
+		if change >= pct {
+			events = append(events, Event{
+				Type:  EventAnomalyDetected,
+				Title: fmt.Sprintf("Hourly cost up %.1f%% since last scan", change),
+				Summary: fmt.Sprintf("Hourly cost rose from %s to %s since %s (%d added, %d removed, %d changed)",
+					money(previousTotal), money(currentTotal), prev.Timestamp.Format(time.RFC3339),
+					len(diff.Added), len(diff.Removed), len(diff.Changed)),
+				Timestamp: snap.Timestamp,
+				Data: map[string]any{
+					"snapshotId":         snap.ID,
+					"previousSnapshotId": prev.ID,
+					"percentChange":      change,
+					"diff":               diff,
+				},
+			})
+		}
+	}
+
+	return append(events, m.budgetEvents(snap, prev)...)
+}
+
+// budgetEvents reports budgets that moved into the exceeded state. Budgets
+// apply to the whole estate, so only unfiltered scans are evaluated.
+func (m *Monitor) budgetEvents(snap, prev *snapshot.Snapshot) []Event {
+	if !isUnfiltered(snap.Response.Filters) {
+		return nil
+	}
+
+	wasExceeded := map[string]bool{}
+	if prev != nil && isUnfiltered(prev.Response.Filters) {
+		for _, status := range digest.BudgetStatuses(prev.Response, m.config.Budget) {
+			wasExceeded[status.Scope+"/"+status.Name] = status.Status == digest.BudgetStatusExceeded
+		}
+	}
+
+	var events []Event
+	for _, status := range digest.BudgetStatuses(snap.Response, m.config.Budget) {
+		if status.Status != digest.BudgetStatusExceeded || wasExceeded[status.Scope+"/"+status.Name] {
+			continue
+		}
+		events = append(events, Event{
+			Type:  EventBudgetExceeded,
+			Title: fmt.Sprintf("Budget exceeded: %s", status.Name),
+			Summary: fmt.Sprintf("Projected monthly cost %s is %.0f%% of the %s budget for %s",
+				money(status.ProjectedMonthlyCost), status.PercentUsed, money(types.CostValue(status.MonthlyBudget)), status.Name),
+			Timestamp: snap.Timestamp,
+			Data:      status,
+		})
+	}
+	return events
+}
+
+// Run sends the weekly digest on the configured weekday and hour until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	cfg := m.config.Notify.WeeklyDigest
+	if !cfg.Enabled || !m.notifier.Enabled() {
+		return
+	}
+	weekday := config.Weekdays[strings.ToLower(cfg.Weekday)]
+
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	var lastSent time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			now = now.UTC()
+			if now.Weekday() != weekday || now.Hour() != cfg.Hour || now.Sub(lastSent) < 24*time.Hour {
+				continue
+			}
+			lastSent = now
+			if err := m.SendWeeklyDigest(ctx); err != nil {
+				m.logger.Error("failed to send weekly digest", "error", err)
+			}
+		}
+	}
+}
+
+// SendWeeklyDigest builds the weekly digest and delivers it to all sinks
+func (m *Monitor) SendWeeklyDigest(ctx context.Context) error {
+	d, err := digest.Weekly(m.snapshots, m.config.Budget, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return m.notifier.Send(ctx, DigestEvent(d))
+}
+
+// DigestEvent wraps a digest in a notification event
+func DigestEvent(d *digest.Digest) Event {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hourly cost %s → %s (%s/mo)\n", money(d.PreviousHourlyCost), money(d.CurrentHourlyCost), money(d.CurrentMonthlyCost))
+	fmt.Fprintf(&b, "%d new resources, %d removed\n", d.NewResourceCount, d.RemovedCount)
+	for _, c := range d.LargestIncreases {
+		fmt.Fprintf(&b, "▲ %s %s: %s/hr\n", c.Type, displayName(c.Resource), money(c.HourlyCostDelta))
+	}
+	for _, c := range d.LargestDecreases {
+		fmt.Fprintf(&b, "▼ %s %s: %s/hr\n", c.Type, displayName(c.Resource), money(c.HourlyCostDelta))
+	}
+	for _, status := range d.Budgets {
+		fmt.Fprintf(&b, "Budget %s: %.0f%% (%s)\n", status.Name, status.PercentUsed, status.Status)
+	}
+	if d.Partial {
+		b.WriteString("Less than a week of history is available.\n")
+	}
+
+	return Event{
+		Type:      EventWeeklyDigest,
+		Title:     "awsCOGS weekly digest",
+		Summary:   strings.TrimSpace(b.String()),
+		Timestamp: time.Now().UTC(),
+		Data:      d,
+	}
+}
+
+func isUnfiltered(f types.AppliedFilters) bool {
+	return len(f.Accounts) == 0 && len(f.Regions) == 0 && len(f.ResourceTypes) == 0
+}
+
+func sumCost(resources []snapshot.Resource) types.CostValue {
+	var total types.CostValue
+	for _, r := range resources {
+		total = total.Add(r.HourlyCost)
+	}
+	return total
+}
+
+func money(v types.CostValue) string {
+	return fmt.Sprintf("$%.2f", float64(v))
+}
+
+func displayName(r snapshot.Resource) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.ID
+}
+
+func accountLabel(r snapshot.Resource) string {
+	if r.AccountName != "" {
+		return r.AccountName
+	}
+	return r.AccountID
+}
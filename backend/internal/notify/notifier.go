@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// sendTimeout bounds a single delivery, including retries
+const sendTimeout = 2 * time.Minute
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"money": func(v any) string {
+		return fmt.Sprintf("$%.2f", v)
+	},
+}
+
+type sink struct {
+	name     string
+	sender   Sender
+	events   map[string]bool // nil = all events
+	template *template.Template
+}
+
+// Notifier fans events out to the configured sinks with retry
+type Notifier struct {
+	sinks       []*sink
+	maxAttempts int
+	backoff     time.Duration
+	logger      *slog.Logger
+	wg          sync.WaitGroup
+}
+
+// NewNotifier creates a notifier from config
+func NewNotifier(cfg config.NotifyConfig, logger *slog.Logger) (*Notifier, error) {
+	n := &Notifier{
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     time.Duration(cfg.RetryBackoffSeconds) * time.Second,
+		logger:      logger,
+	}
+
+	for i, sc := range cfg.Sinks {
+		name := sc.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", sc.Type, i)
+		}
+
+		var sender Sender
+		switch sc.Type {
+		case "webhook":
+			sender = &WebhookSender{URL: sc.URL, Headers: sc.Headers}
+		case "slack":
+			sender = &SlackSender{URL: sc.URL}
+		case "sns":
+			sender = &SNSSender{TopicARN: sc.TopicARN}
+		default:
+			return nil, fmt.Errorf("sink %s: unknown type %q", name, sc.Type)
+		}
+
+		if err := n.AddSink(name, sender, sc.Events, sc.Template); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// AddSink registers a sender for the given event types (empty = all events)
+// with an optional message template.
+func (n *Notifier) AddSink(name string, sender Sender, events []string, tmpl string) error {
+	s := &sink{name: name, sender: sender}
+	if len(events) > 0 {
+		s.events = make(map[string]bool, len(events))
+		for _, e := range events {
+			s.events[e] = true
+		}
+	}
+	if tmpl != "" {
+		t, err := template.New(name).Funcs(templateFuncs).Parse(tmpl)
+		if err != nil {
+			return fmt.Errorf("sink %s: parsing template: %w", name, err)
+		}
+		s.template = t
+	}
+	n.sinks = append(n.sinks, s)
+	return nil
+}
+
+// Enabled reports whether any sinks are configured
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.sinks) > 0
+}
+
+// Notify delivers an event to all subscribed sinks in the background
+func (n *Notifier) Notify(event Event) {
+	if !n.Enabled() {
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		defer cancel()
+		if err := n.Send(ctx, event); err != nil {
+			n.logger.Error("failed to deliver notification", "event", event.Type, "error", err)
+		}
+	}()
+}
+
+// Send delivers an event to all subscribed sinks and waits for completion
+func (n *Notifier) Send(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	var errs []error
+	for _, s := range n.sinks {
+		if s.events != nil && !s.events[event.Type] {
+			continue
+		}
+
+		message, err := s.render(event)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := n.sendWithRetry(ctx, s, event, message); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", s.name, err))
+			continue
+		}
+		n.logger.Debug("notification delivered", "sink", s.name, "event", event.Type)
+	}
+	return errors.Join(errs...)
+}
+
+// Wait blocks until background deliveries finish
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+func (s *sink) render(event Event) (string, error) {
+	if s.template == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := s.template.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("sink %s: rendering template: %w", s.name, err)
+	}
+	return buf.String(), nil
+}
+
+func (n *Notifier) sendWithRetry(ctx context.Context, s *sink, event Event, message string) error {
+	backoff := n.backoff
+	var err error
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		if err = s.sender.Send(ctx, event, message); err == nil {
+			return nil
+		}
+		if attempt == n.maxAttempts {
+			break
+		}
+
+		n.logger.Warn("notification delivery failed, retrying", "sink", s.name, "event", event.Type, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestWebhookRetriesAndRendersTemplate(t *testing.T) {
+	var calls atomic.Int32
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	n, err := NewNotifier(config.NotifyConfig{
+		Sinks: []config.SinkConfig{{
+			Type:     "webhook",
+			URL:      server.URL,
+			Events:   []string{EventScanCompleted},
+			Template: `{"msg":"{{.Title}} {{money .Data}}"}`,
+		}},
+		MaxAttempts: 2,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewNotifier() error = %v", err)
+	}
+
+	if err := n.Send(context.Background(), Event{Type: EventAnomalyDetected}); err != nil {
+		t.Fatalf("Send() unsubscribed event error = %v", err)
+	}
+	if err := n.Send(context.Background(), Event{Type: EventScanCompleted, Title: "done", Data: 1.5}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+	if body != `{"msg":"done $1.50"}` {
+		t.Fatalf("body = %s", body)
+	}
+}
+
+func TestMonitorEvaluate(t *testing.T) {
+	store, err := snapshot.NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Notify.ExpensiveResourceHourly = 1
+	cfg.Notify.AnomalyPercent = 50
+	cfg.Budget.Monthly = 1000
+	monitor := NewMonitor(cfg, store, &Notifier{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	now := time.Now().UTC()
+	if _, err := store.Add(now.Add(-time.Hour), &types.CostResponse{
+		TotalCost:    0.5,
+		EC2Instances: []types.EC2Instance{{InstanceID: "i-1", HourlyCost: 0.5}},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	snap, err := store.Add(now, &types.CostResponse{
+		TotalCost: 3.5,
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-1", HourlyCost: 0.5},
+			{InstanceID: "i-2", InstanceType: "p3.2xlarge", HourlyCost: 3.0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	seen := map[string]int{}
+	for _, e := range monitor.Evaluate(snap) {
+		seen[e.Type]++
+	}
+
+	for _, eventType := range []string{EventScanCompleted, EventExpensiveResource, EventAnomalyDetected, EventBudgetExceeded} {
+		if seen[eventType] != 1 {
+			t.Fatalf("expected one %s event, got %v", eventType, seen)
+		}
+	}
+}
+
+func TestMonitorHighCostEvents(t *testing.T) {
+	store, err := snapshot.NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Notify.ExpensiveResourceHourly = 2.5
+	cfg.CostThresholds.Hourly = 1
+	monitor := NewMonitor(cfg, store, &Notifier{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	now := time.Now().UTC()
+	if _, err := store.Add(now.Add(-time.Hour), &types.CostResponse{
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-1", HourlyCost: 0.5},
+			{InstanceID: "i-3", HourlyCost: 1.5, HighCost: true},
+		},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	snap, err := store.Add(now, &types.CostResponse{
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-1", HourlyCost: 2, HighCost: true},
+			{InstanceID: "i-2", HourlyCost: 3, HighCost: true},
+			{InstanceID: "i-3", HourlyCost: 1.5, HighCost: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	var highCost []string
+	for _, e := range monitor.Evaluate(snap) {
+		if e.Type == EventHighCostResource {
+			highCost = append(highCost, e.Data.(snapshot.Resource).ID)
+		}
+	}
+	// i-2 is reported as a new expensive resource and i-3 was already high cost
+	if len(highCost) != 1 || highCost[0] != "i-1" {
+		t.Fatalf("high-cost events for %v, want only i-1", highCost)
+	}
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// Sender delivers a rendered message to a destination. message is the
+// rendered sink template, or empty if the sink has no template.
+type Sender interface {
+	Send(ctx context.Context, event Event, message string) error
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// WebhookSender posts the event as JSON, or the rendered template if set
+type WebhookSender struct {
+	URL     string
+	Headers map[string]string
+}
+
+// Send implements Sender
+func (s *WebhookSender) Send(ctx context.Context, event Event, message string) error {
+	body := []byte(message)
+	if message == "" {
+		var err error
+		if body, err = json.Marshal(event); err != nil {
+			return fmt.Errorf("encoding event: %w", err)
+		}
+	}
+	return postJSON(ctx, s.URL, s.Headers, body)
+}
+
+// SlackSender posts to a Slack incoming webhook
+type SlackSender struct {
+	URL string
+}
+
+// Send implements Sender
+func (s *SlackSender) Send(ctx context.Context, event Event, message string) error {
+	text := message
+	if text == "" {
+		text = fmt.Sprintf("*%s*\n%s", event.Title, event.Summary)
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("encoding slack message: %w", err)
+	}
+	return postJSON(ctx, s.URL, nil, body)
+}
+
+func postJSON(ctx context.Context, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// SNSSender publishes to an SNS topic using the default credential chain
+type SNSSender struct {
+	TopicARN string
+
+	once   sync.Once
+	client *sns.Client
+	err    error
+}
+
+// Send implements Sender
+func (s *SNSSender) Send(ctx context.Context, event Event, message string) error {
+	s.once.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(regionFromARN(s.TopicARN)))
+		if err != nil {
+			s.err = fmt.Errorf("loading AWS config: %w", err)
+			return
+		}
+		s.client = sns.NewFromConfig(cfg)
+	})
+	if s.err != nil {
+		return s.err
+	}
+
+	if message == "" {
+		message = event.Summary
+	}
+	subject := event.Title
+	if len(subject) > 100 {
+		subject = subject[:100] // SNS subject limit
+	}
+
+	_, err := s.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(s.TopicARN),
+		Subject:  aws.String(subject),
+		Message:  aws.String(message),
+	})
+	if err != nil {
+		return fmt.Errorf("publishing to SNS: %w", err)
+	}
+	return nil
+}
+
+func regionFromARN(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) > 3 {
+		return parts[3]
+	}
+	return ""
+}
@@ -0,0 +1,279 @@
+// Package openapi builds an OpenAPI 3 document from route definitions,
+// deriving response schemas from Go types via reflection.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI specification version emitted
+const Version = "3.0.3"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the API is served from
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Components holds reusable schemas
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation describes a single API operation. Response and Body are sample
+// values whose types are converted to schemas; they are not serialized.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	Response any `json:"-"`
+	Status   int `json:"-"` // Status code of a successful response, 200 if unset
+	Body     any `json:"-"`
+}
+
+// Parameter is a query or path parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes an operation response
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType wraps a schema for a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema subset
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Query returns an optional string query parameter
+func Query(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Path returns a required string path parameter
+func Path(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Required: true, Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Builder accumulates operations into a document
+type Builder struct {
+	doc       *Document
+	errorBody any // JSON body of error responses (nil = plain text)
+}
+
+// NewBuilder creates a builder for an API served from serverURL
+func NewBuilder(info Info, serverURL string) *Builder {
+	return &Builder{doc: &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Servers:    []Server{{URL: serverURL}},
+		Paths:      map[string]map[string]*Operation{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}}
+}
+
+// SetErrorBody documents v as the JSON body of error responses for operations
+// added afterwards
+func (b *Builder) SetErrorBody(v any) {
+	b.errorBody = v
+}
+
+// Add registers an operation for method and path. Chi-style path parameters
+// ({name}) are already OpenAPI compatible.
+func (b *Builder) Add(method, path string, op Operation) {
+	if op.Responses == nil {
+		op.Responses = map[string]*Response{}
+	}
+	status := http.StatusOK
+	if op.Status != 0 {
+		status = op.Status
+	}
+	code := strconv.Itoa(status)
+	if op.Response != nil {
+		op.Responses[code] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{"application/json": {Schema: b.SchemaFor(op.Response)}},
+		}
+	} else if _, ok := op.Responses[code]; !ok {
+		op.Responses[code] = &Response{Description: http.StatusText(status)}
+	}
+	if op.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: b.SchemaFor(op.Body)}},
+		}
+	}
+	if _, ok := op.Responses["default"]; !ok {
+		errorContent := map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}}
+		if b.errorBody != nil {
+			errorContent = map[string]*MediaType{"application/json": {Schema: b.SchemaFor(b.errorBody)}}
+		}
+		op.Responses["default"] = &Response{Description: "Error", Content: errorContent}
+	}
+
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = map[string]*Operation{}
+	}
+	b.doc.Paths[path][strings.ToLower(method)] = &op
+}
+
+// Document returns the built document with tags collected from operations
+func (b *Builder) Document() *Document {
+	seen := map[string]bool{}
+	for _, item := range b.doc.Paths {
+		for _, op := range item {
+			for _, tag := range op.Tags {
+				seen[tag] = true
+			}
+		}
+	}
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	b.doc.Tags = nil
+	for _, tag := range tags {
+		b.doc.Tags = append(b.doc.Tags, Tag{Name: tag})
+	}
+	return b.doc
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// SchemaFor returns a schema for the type of v, registering named struct
+// types as components.
+func (b *Builder) SchemaFor(v any) *Schema {
+	return b.schema(reflect.TypeOf(v))
+}
+
+func (b *Builder) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			// Reserve the name before recursing so self-references terminate
+			b.doc.Components.Schemas[name] = &Schema{}
+			*b.doc.Components.Schemas[name] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(s, t)
+	return s
+}
+
+func (b *Builder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(s, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = b.schema(field.Type)
+	}
+}
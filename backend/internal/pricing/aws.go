@@ -15,32 +15,25 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/johnjeffers/awscogs/backend/internal/selfcost"
 	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
 )
 
 // AWSProvider implements Provider using the AWS Price List API
 type AWSProvider struct {
 	client          *pricing.Client
-	ec2Cache        map[string]cogtypes.CostValue // key: "region:instanceType"
-	ebsCache        map[string]cogtypes.CostValue // key: "region:volumeType"
-	ecsCache        map[string]cogtypes.CostValue // key: "region:launchType"
-	rdsCache        map[string]cogtypes.CostValue // key: "region:instanceClass:engine:multiAZ"
-	eksCache        map[string]cogtypes.CostValue // key: "region"
-	elbCache        map[string]cogtypes.CostValue // key: "region:lbType" (base hourly)
-	elbLCUCache     map[string]cogtypes.CostValue // key: "region:lbType" (per-LCU rate)
-	natCache        map[string]cogtypes.CostValue // key: "region"
-	eipCache        map[string]cogtypes.CostValue // key: "region:associated"
-	secretCache     map[string]cogtypes.CostValue // key: "region"
-	publicIPv4Cache map[string]cogtypes.CostValue // key: "region"
-	lambdaReqCache  map[string]cogtypes.CostValue // key: "region:architecture"
-	lambdaGBCache   map[string]cogtypes.CostValue // key: "region:architecture"
-	cacheMu         sync.RWMutex
-	cacheExpiry     time.Time
-	cacheDuration   time.Duration
+	cache           *priceCache
 	sfGroup         singleflight.Group // Prevents concurrent duplicate pricing API calls
 	rateLimitMu     sync.Mutex         // Protects rate limiting
 	lastAPICall     time.Time          // Time of last API call
 	minCallInterval time.Duration      // Minimum time between API calls
+	seenMu          sync.Mutex
+	seen            map[PriceKey]struct{} // Price lookups made so far, for pre-warming
+	seenChanged     bool                  // New lookups seen since they were last saved
+	statsMu         sync.Mutex
+	counts          map[string]*cacheCounts // Cache hits and misses by price lookup kind
+	retryMu         sync.Mutex
+	retries         map[PriceKey]*pendingRetry // Failed lookups for the Retrier (nil without one)
 }
 
 // LambdaPriceDetails exposes the matched Pricing API products for live validation.
@@ -57,7 +50,8 @@ type LambdaPriceDetails struct {
 	MatchedProductCount int
 }
 
-// NewAWSProvider creates a new AWS pricing provider
+// NewAWSProvider creates a new AWS pricing provider. Each price is cached for
+// cacheDurationMinutes after it is fetched.
 func NewAWSProvider(ctx context.Context, cacheDurationMinutes, rateLimitPerSecond int) (*AWSProvider, error) {
 	// AWS Pricing API is only available in us-east-1 and ap-south-1
 	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"))
@@ -65,6 +59,7 @@ func NewAWSProvider(ctx context.Context, cacheDurationMinutes, rateLimitPerSecon
 		return nil, fmt.Errorf("loading AWS config: %w", err)
 	}
 
+	cfg.APIOptions = append(cfg.APIOptions, selfcost.APIOption)
 	client := pricing.NewFromConfig(cfg)
 
 	// Validate credentials by making a test API call
@@ -81,24 +76,22 @@ func NewAWSProvider(ctx context.Context, cacheDurationMinutes, rateLimitPerSecon
 
 	return &AWSProvider{
 		client:          client,
-		ec2Cache:        make(map[string]cogtypes.CostValue),
-		ebsCache:        make(map[string]cogtypes.CostValue),
-		ecsCache:        make(map[string]cogtypes.CostValue),
-		rdsCache:        make(map[string]cogtypes.CostValue),
-		eksCache:        make(map[string]cogtypes.CostValue),
-		elbCache:        make(map[string]cogtypes.CostValue),
-		elbLCUCache:     make(map[string]cogtypes.CostValue),
-		natCache:        make(map[string]cogtypes.CostValue),
-		eipCache:        make(map[string]cogtypes.CostValue),
-		secretCache:     make(map[string]cogtypes.CostValue),
-		publicIPv4Cache: make(map[string]cogtypes.CostValue),
-		lambdaReqCache:  make(map[string]cogtypes.CostValue),
-		lambdaGBCache:   make(map[string]cogtypes.CostValue),
-		cacheDuration:   time.Duration(cacheDurationMinutes) * time.Minute,
+		cache:           newPriceCache(time.Duration(cacheDurationMinutes)*time.Minute, DefaultMaxCacheEntries),
 		minCallInterval: minInterval,
+		seen:            make(map[PriceKey]struct{}),
 	}, nil
 }
 
+// DefaultMaxCacheEntries is the default number of price lookups cached. A
+// lookup is one instance type, volume type, etc. in one region.
+const DefaultMaxCacheEntries = 10000
+
+// SetMaxCacheEntries limits the number of price lookups cached, evicting the
+// least recently used when full. 0 means unlimited.
+func (p *AWSProvider) SetMaxCacheEntries(n int) {
+	p.cache.setMaxEntries(n)
+}
+
 // waitForRateLimit waits until enough time has passed since the last API call
 // This enforces a maximum of N calls per second by spacing out requests
 func (p *AWSProvider) waitForRateLimit(ctx context.Context) error {
@@ -145,142 +138,247 @@ func termFilter(field, value string) types.Filter {
 	}
 }
 
-// getCachedPrice checks the cache for a price, and on miss uses singleflight to
-// fetch it exactly once, preventing thundering herd on concurrent requests.
-func (p *AWSProvider) getCachedPrice(cache map[string]cogtypes.CostValue, cacheKey, sfKey string, fetch func() (cogtypes.CostValue, error)) (cogtypes.CostValue, error) {
-	p.cacheMu.RLock()
-	if price, ok := cache[cacheKey]; ok && time.Now().Before(p.cacheExpiry) {
-		p.cacheMu.RUnlock()
-		return price, nil
-	}
-	p.cacheMu.RUnlock()
-
-	v, err, _ := p.sfGroup.Do(sfKey, func() (any, error) {
-		// Double-check cache after acquiring singleflight
-		p.cacheMu.RLock()
-		if price, ok := cache[cacheKey]; ok && time.Now().Before(p.cacheExpiry) {
-			p.cacheMu.RUnlock()
-			return price, nil
+// lookup returns the prices for key from the cache, or on miss fetches them
+// with singleflight, so concurrent lookups of the same key make one API call.
+// Only lookups that found a price are remembered for pre-warming, so keys
+// with no price aren't fetched again on every warm cycle.
+func (p *AWSProvider) lookup(ctx context.Context, key PriceKey) ([]cogtypes.CostValue, error) {
+	id := key.id()
+	if prices, fetched, ok := p.cache.get(id); ok {
+		p.remember(key)
+		p.record(key.Kind, true)
+		observeFreshness(ctx, PriceSourceCache, fetched)
+		return prices, nil
+	}
+	p.record(key.Kind, false)
+
+	v, err, _ := p.sfGroup.Do(id, func() (any, error) {
+		// Another lookup may have filled the cache while this one waited
+		if prices, _, ok := p.cache.get(id); ok {
+			return prices, nil
 		}
-		p.cacheMu.RUnlock()
-
-		price, err := fetch()
+		prices, err := p.fetch(ctx, key)
 		if err != nil {
-			return cogtypes.CostValue(0), err
+			p.queueRetry(key, err)
+			return nil, err
 		}
-
-		p.cacheMu.Lock()
-		cache[cacheKey] = price
-		if p.cacheExpiry.IsZero() || time.Now().After(p.cacheExpiry) {
-			p.cacheExpiry = time.Now().Add(p.cacheDuration)
-		}
-		p.cacheMu.Unlock()
-
-		return price, nil
+		p.cache.set(id, prices)
+		p.dequeueRetry(key)
+		return prices, nil
 	})
+	if err != nil {
+		observeMissing(ctx)
+		return nil, err
+	}
+	p.remember(key)
+	observeFreshness(ctx, PriceSourceAPI, time.Now())
+	return v.([]cogtypes.CostValue), nil
+}
+
+// lookupOne returns the single price for key
+func (p *AWSProvider) lookupOne(ctx context.Context, key PriceKey) (cogtypes.CostValue, error) {
+	prices, err := p.lookup(ctx, key)
 	if err != nil {
 		return 0, err
 	}
+	return prices[0], nil
+}
 
-	return v.(cogtypes.CostValue), nil
+// fetch queries the Pricing API for key's prices
+func (p *AWSProvider) fetch(ctx context.Context, key PriceKey) ([]cogtypes.CostValue, error) {
+	one := func(price cogtypes.CostValue, err error) ([]cogtypes.CostValue, error) {
+		return []cogtypes.CostValue{price}, err
+	}
+	switch key.Kind {
+	case KindEC2:
+		return one(p.fetchEC2Price(ctx, key.Region, key.Type, key.Tenancy))
+	case KindEBS:
+		base, iops, throughput, err := p.fetchEBSPrices(ctx, key.Region, key.Type)
+		return []cogtypes.CostValue{base, iops, throughput}, err
+	case KindECS:
+		return one(p.fetchECSFargatePrice(ctx, key.Region))
+	case KindRDS:
+		return one(p.fetchRDSPrice(ctx, key.Region, key.Type, key.Engine, key.MultiAZ))
+	case KindRDSStorage:
+		return one(p.fetchRDSStoragePrice(ctx, key.Region, key.Type, key.MultiAZ))
+	case KindAurora:
+		storage, ioOptimized, perMillion, err := p.fetchAuroraPrices(ctx, key.Region)
+		return []cogtypes.CostValue{storage, ioOptimized, perMillion}, err
+	case KindEKS:
+		return one(p.fetchEKSPrice(ctx, key.Region, key.Type == eksExtendedSupport))
+	case KindELB:
+		base, perLCU, err := p.fetchELBPrice(ctx, key.Region, key.Type)
+		return []cogtypes.CostValue{base, perLCU}, err
+	case KindNAT:
+		return one(p.fetchNATGatewayPrice(ctx, key.Region))
+	case KindEIP:
+		return one(p.fetchElasticIPPrice(ctx, key.Region))
+	case KindSecret:
+		return one(p.fetchSecretPrice(ctx, key.Region))
+	case KindPublicIPv4:
+		return one(p.fetchPublicIPv4Price(ctx, key.Region))
+	case KindLambda:
+		request, gbSecond, err := p.fetchLambdaPrice(ctx, key.Region, key.Type)
+		return []cogtypes.CostValue{request, gbSecond}, err
+	case KindCPUCredits:
+		return one(p.fetchCPUCreditPrice(ctx, key.Region, key.Type))
+	case KindMonitoring:
+		return one(p.fetchMetricPrice(ctx, key.Region))
+	case KindEBSOptimized:
+		return one(p.fetchEBSOptimizedPrice(ctx, key.Region, key.Type))
+	case KindHost:
+		return one(p.fetchDedicatedHostPrice(ctx, key.Region, key.Type))
+	case KindKinesis:
+		return one(p.fetchKinesisPrice(ctx, key.Region, key.Type == kinesisOnDemand))
+	case KindFirehose:
+		return one(p.fetchFirehosePrice(ctx, key.Region))
+	}
+	return nil, fmt.Errorf("unknown price kind: %s", key.Kind)
 }
 
 // GetEC2Price returns the hourly on-demand price for an EC2 instance type
 func (p *AWSProvider) GetEC2Price(ctx context.Context, region, instanceType string) (cogtypes.CostValue, error) {
-	cacheKey := fmt.Sprintf("%s:%s", region, instanceType)
-	return p.getCachedPrice(p.ec2Cache, cacheKey, "ec2:"+cacheKey, func() (cogtypes.CostValue, error) {
-		return p.fetchEC2Price(ctx, region, instanceType)
-	})
+	return p.lookupOne(ctx, PriceKey{Kind: KindEC2, Region: region, Type: instanceType})
 }
 
-// GetEBSPrice returns the hourly price for an EBS volume
-func (p *AWSProvider) GetEBSPrice(ctx context.Context, region, volumeType string, sizeGiB, iops, throughput int32) (cogtypes.CostValue, error) {
-	// EBS pricing is per GB-month, we convert to hourly
-	// Also factor in IOPS and throughput for gp3/io1/io2
-
-	baseCacheKey := fmt.Sprintf("%s:%s", region, volumeType)
-
-	p.cacheMu.RLock()
-	basePrice, hasBase := p.ebsCache[baseCacheKey]
-	iopsPrice := p.ebsCache[baseCacheKey+":iops"]
-	tpPrice := p.ebsCache[baseCacheKey+":throughput"]
-	cacheValid := time.Now().Before(p.cacheExpiry)
-	p.cacheMu.RUnlock()
-
-	if !hasBase || !cacheValid {
-		// Use singleflight to prevent concurrent duplicate API calls
-		v, err, _ := p.sfGroup.Do("ebs:"+baseCacheKey, func() (any, error) {
-			// Double-check cache
-			p.cacheMu.RLock()
-			bp, ok := p.ebsCache[baseCacheKey]
-			ip := p.ebsCache[baseCacheKey+":iops"]
-			tp := p.ebsCache[baseCacheKey+":throughput"]
-			valid := time.Now().Before(p.cacheExpiry)
-			p.cacheMu.RUnlock()
-
-			if ok && valid {
-				return [3]cogtypes.CostValue{bp, ip, tp}, nil
-			}
+// GetEC2TenancyPrice returns the hourly on-demand price for an EC2 instance
+// type with the instance's placement tenancy: "default", "dedicated", or
+// "host". Instances on a Dedicated Host cost nothing themselves; the host is
+// billed instead.
+func (p *AWSProvider) GetEC2TenancyPrice(ctx context.Context, region, instanceType, tenancy string) (cogtypes.CostValue, error) {
+	pricingTenancy, err := ec2PricingTenancy(tenancy)
+	if err != nil {
+		return 0, err
+	}
+	if pricingTenancy == "Shared" {
+		return p.GetEC2Price(ctx, region, instanceType)
+	}
+	return p.lookupOne(ctx, PriceKey{Kind: KindEC2, Region: region, Type: instanceType, Tenancy: pricingTenancy})
+}
 
-			bp, ip, tp, err := p.fetchEBSPrices(ctx, region, volumeType)
-			if err != nil {
-				return [3]cogtypes.CostValue{}, err
-			}
+// ec2PricingTenancy maps an EC2 placement tenancy to the Pricing API's
+// tenancy attribute
+func ec2PricingTenancy(tenancy string) (string, error) {
+	switch tenancy {
+	case "", "default":
+		return "Shared", nil
+	case "dedicated":
+		return "Dedicated", nil
+	case "host":
+		return "Host", nil
+	}
+	return "", fmt.Errorf("unknown EC2 tenancy: %s", tenancy)
+}
 
-			p.cacheMu.Lock()
-			p.ebsCache[baseCacheKey] = bp
-			p.ebsCache[baseCacheKey+":iops"] = ip
-			p.ebsCache[baseCacheKey+":throughput"] = tp
-			if p.cacheExpiry.IsZero() || time.Now().After(p.cacheExpiry) {
-				p.cacheExpiry = time.Now().Add(p.cacheDuration)
-			}
-			p.cacheMu.Unlock()
+// GetCPUCreditPrice returns the price per vCPU-hour of surplus CPU credits
+// for a burstable instance family such as "t3", charged to instances in
+// unlimited mode that burst beyond their earned credits
+func (p *AWSProvider) GetCPUCreditPrice(ctx context.Context, region, family string) (cogtypes.CostValue, error) {
+	return p.lookupOne(ctx, PriceKey{Kind: KindCPUCredits, Region: region, Type: family})
+}
 
-			return [3]cogtypes.CostValue{bp, ip, tp}, nil
-		})
-		if err != nil {
-			return 0, err
-		}
-		prices := v.([3]cogtypes.CostValue)
-		basePrice = prices[0]
-		iopsPrice = prices[1]
-		tpPrice = prices[2]
+// GetDetailedMonitoringPrice returns the monthly price of one CloudWatch
+// metric at the first volume tier. Detailed monitoring publishes seven
+// metrics per instance.
+func (p *AWSProvider) GetDetailedMonitoringPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	return p.lookupOne(ctx, PriceKey{Kind: KindMonitoring, Region: region})
+}
+
+// GetEBSOptimizedPrice returns the hourly EBS-optimized surcharge for an
+// older instance type such as m3.xlarge. Current generation types are EBS
+// optimized at no extra charge.
+func (p *AWSProvider) GetEBSOptimizedPrice(ctx context.Context, region, instanceType string) (cogtypes.CostValue, error) {
+	return p.lookupOne(ctx, PriceKey{Kind: KindEBSOptimized, Region: region, Type: instanceType})
+}
+
+// GetDedicatedHostPrice returns the hourly on-demand price of a Dedicated
+// Host of an instance family such as "m5". The host is billed whether or not
+// instances run on it.
+func (p *AWSProvider) GetDedicatedHostPrice(ctx context.Context, region, family string) (cogtypes.CostValue, error) {
+	return p.lookupOne(ctx, PriceKey{Kind: KindHost, Region: region, Type: family})
+}
+
+// kinesisOnDemand is the PriceKey type of on-demand Kinesis streams
+const kinesisOnDemand = "on-demand"
+
+// GetKinesisStreamPrice returns the hourly price of a provisioned Kinesis
+// data stream shard, or of an on-demand stream. On-demand streams are also
+// billed per GB written and read, which this doesn't include.
+func (p *AWSProvider) GetKinesisStreamPrice(ctx context.Context, region string, onDemand bool) (cogtypes.CostValue, error) {
+	key := PriceKey{Kind: KindKinesis, Region: region, Type: "provisioned"}
+	if onDemand {
+		key.Type = kinesisOnDemand
 	}
+	return p.lookupOne(ctx, key)
+}
 
-	// Calculate total monthly cost, then convert to hourly
-	// Base storage cost (per GB-month)
-	monthlyCost := float64(basePrice) * float64(sizeGiB)
+// GetFirehosePrice returns the price per GB ingested into a Firehose
+// delivery stream at the first volume tier
+func (p *AWSProvider) GetFirehosePrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	return p.lookupOne(ctx, PriceKey{Kind: KindFirehose, Region: region})
+}
+
+// GetEBSPrice returns the hourly price for an EBS volume
+func (p *AWSProvider) GetEBSPrice(ctx context.Context, region, volumeType string, sizeGiB, iops, throughput int32) (cogtypes.CostValue, error) {
+	components, err := p.GetEBSCostComponents(ctx, region, volumeType, sizeGiB, iops, throughput)
+	if err != nil {
+		return 0, err
+	}
+	return cogtypes.SumComponents(components), nil
+}
+
+// GetEBSCostComponents returns the storage, IOPS, and throughput costs of an EBS volume
+func (p *AWSProvider) GetEBSCostComponents(ctx context.Context, region, volumeType string, sizeGiB, iops, throughput int32) ([]cogtypes.CostComponent, error) {
+	prices, err := p.lookup(ctx, PriceKey{Kind: KindEBS, Region: region, Type: volumeType})
+	if err != nil {
+		return nil, err
+	}
+	return ebsCostComponents(volumeType, sizeGiB, iops, throughput, prices[0], prices[1], prices[2]), nil
+}
+
+// ebsCostComponents applies EBS monthly rates to a volume's provisioned size, IOPS, and throughput
+func ebsCostComponents(volumeType string, sizeGiB, iops, throughput int32, basePrice, iopsPrice, tpPrice cogtypes.CostValue) []cogtypes.CostComponent {
+	// EBS rates are per GB-month, IOPS-month, and MiBps-month
+	components := []cogtypes.CostComponent{
+		cogtypes.MonthlyComponent("storage", "GB-month", float64(sizeGiB), basePrice),
+	}
 
 	// Add IOPS cost for io1/io2/gp3
 	if volumeType == "gp3" && iops > 3000 {
 		// gp3 includes 3000 IOPS free
-		monthlyCost += float64(iopsPrice) * float64(iops-3000)
+		components = append(components, cogtypes.MonthlyComponent("provisioned IOPS", "IOPS-month", float64(iops-3000), iopsPrice))
 	} else if volumeType == "io1" || volumeType == "io2" {
-		monthlyCost += float64(iopsPrice) * float64(iops)
+		components = append(components, cogtypes.MonthlyComponent("provisioned IOPS", "IOPS-month", float64(iops), iopsPrice))
 	}
 
 	// Add throughput cost for gp3
 	if volumeType == "gp3" && throughput > 125 {
 		// gp3 includes 125 MiB/s free
-		monthlyCost += float64(tpPrice) * float64(throughput-125)
+		components = append(components, cogtypes.MonthlyComponent("provisioned throughput", "MiBps-month", float64(throughput-125), tpPrice))
 	}
 
-	// Convert monthly to hourly (730 hours per month)
-	hourlyCost := monthlyCost / 730.0
-
-	return cogtypes.CostValue(hourlyCost), nil
+	return components
 }
 
 // GetRDSPrice returns the hourly on-demand price for an RDS instance
 func (p *AWSProvider) GetRDSPrice(ctx context.Context, region, instanceClass, engine string, multiAZ bool) (cogtypes.CostValue, error) {
-	multiAZStr := "false"
-	if multiAZ {
-		multiAZStr = "true"
+	return p.lookupOne(ctx, PriceKey{Kind: KindRDS, Region: region, Type: instanceClass, Engine: engine, MultiAZ: multiAZ})
+}
+
+// GetRDSStoragePrice returns the per GB-month price of RDS instance storage
+func (p *AWSProvider) GetRDSStoragePrice(ctx context.Context, region, storageType string, multiAZ bool) (cogtypes.CostValue, error) {
+	return p.lookupOne(ctx, PriceKey{Kind: KindRDSStorage, Region: region, Type: storageType, MultiAZ: multiAZ})
+}
+
+// GetAuroraPrices returns the per GB-month price of Aurora Standard and
+// I/O-Optimized cluster storage, and the Aurora Standard price per million
+// I/O requests. I/O-Optimized clusters aren't billed for I/O.
+func (p *AWSProvider) GetAuroraPrices(ctx context.Context, region string) (storage, ioOptimizedStorage, perMillionIOs cogtypes.CostValue, err error) {
+	prices, err := p.lookup(ctx, PriceKey{Kind: KindAurora, Region: region})
+	if err != nil {
+		return 0, 0, 0, err
 	}
-	cacheKey := fmt.Sprintf("%s:%s:%s:%s", region, instanceClass, engine, multiAZStr)
-	return p.getCachedPrice(p.rdsCache, cacheKey, "rds:"+cacheKey, func() (cogtypes.CostValue, error) {
-		return p.fetchRDSPrice(ctx, region, instanceClass, engine, multiAZ)
-	})
+	return prices[0], prices[1], prices[2], nil
 }
 
 // GetECSPrice returns the hourly price for an ECS Fargate service
@@ -296,10 +394,7 @@ func (p *AWSProvider) GetECSPrice(ctx context.Context, region, launchType string
 		return 0, nil
 	}
 
-	cacheKey := fmt.Sprintf("%s:%s", region, launchType)
-	perTaskPrice, err := p.getCachedPrice(p.ecsCache, cacheKey, "ecs:"+cacheKey, func() (cogtypes.CostValue, error) {
-		return p.fetchECSFargatePrice(ctx, region)
-	})
+	perTaskPrice, err := p.lookupOne(ctx, PriceKey{Kind: KindECS, Region: region, Type: launchType})
 	if err != nil {
 		return 0, err
 	}
@@ -307,121 +402,60 @@ func (p *AWSProvider) GetECSPrice(ctx context.Context, region, launchType string
 	return perTaskPrice * cogtypes.CostValue(runningCount), nil
 }
 
-// GetEKSPrice returns the hourly price for an EKS cluster control plane
-func (p *AWSProvider) GetEKSPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
-	return p.getCachedPrice(p.eksCache, region, "eks:"+region, func() (cogtypes.CostValue, error) {
-		return p.fetchEKSPrice(ctx, region)
-	})
+// GetEKSPrice returns the hourly price for an EKS cluster control plane.
+// Clusters on a Kubernetes version past the end of standard support are
+// billed at the higher extended support rate.
+func (p *AWSProvider) GetEKSPrice(ctx context.Context, region string, extendedSupport bool) (cogtypes.CostValue, error) {
+	key := PriceKey{Kind: KindEKS, Region: region}
+	if extendedSupport {
+		key.Type = eksExtendedSupport
+	}
+	return p.lookupOne(ctx, key)
 }
 
+// eksExtendedSupport is the PriceKey type of the EKS extended support rate
+const eksExtendedSupport = "extended"
+
 // GetELBPrice returns the base hourly price and per-LCU/NLCU price for a load balancer
 func (p *AWSProvider) GetELBPrice(ctx context.Context, region, lbType string) (base, perLCU cogtypes.CostValue, err error) {
-	cacheKey := fmt.Sprintf("%s:%s", region, lbType)
-
-	// Use singleflight to fetch both prices together
-	v, err, _ := p.sfGroup.Do("elb:"+cacheKey, func() (any, error) {
-		// Check cache
-		p.cacheMu.RLock()
-		b, hasBase := p.elbCache[cacheKey]
-		l := p.elbLCUCache[cacheKey]
-		valid := time.Now().Before(p.cacheExpiry)
-		p.cacheMu.RUnlock()
-
-		if hasBase && valid {
-			return [2]cogtypes.CostValue{b, l}, nil
-		}
-
-		b, l, err := p.fetchELBPrice(ctx, region, lbType)
-		if err != nil {
-			return [2]cogtypes.CostValue{}, err
-		}
-
-		p.cacheMu.Lock()
-		p.elbCache[cacheKey] = b
-		p.elbLCUCache[cacheKey] = l
-		if p.cacheExpiry.IsZero() || time.Now().After(p.cacheExpiry) {
-			p.cacheExpiry = time.Now().Add(p.cacheDuration)
-		}
-		p.cacheMu.Unlock()
-
-		return [2]cogtypes.CostValue{b, l}, nil
-	})
+	prices, err := p.lookup(ctx, PriceKey{Kind: KindELB, Region: region, Type: lbType})
 	if err != nil {
 		return 0, 0, err
 	}
-
-	prices := v.([2]cogtypes.CostValue)
 	return prices[0], prices[1], nil
 }
 
 // GetNATGatewayPrice returns the hourly price for a NAT Gateway
 func (p *AWSProvider) GetNATGatewayPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
-	return p.getCachedPrice(p.natCache, region, "nat:"+region, func() (cogtypes.CostValue, error) {
-		return p.fetchNATGatewayPrice(ctx, region)
-	})
+	return p.lookupOne(ctx, PriceKey{Kind: KindNAT, Region: region})
 }
 
-// GetElasticIPPrice returns the hourly price for an Elastic IP
-// Associated EIPs attached to running instances are free (billing rule, not API-sourced)
-func (p *AWSProvider) GetElasticIPPrice(ctx context.Context, region string, isAssociated bool) (cogtypes.CostValue, error) {
-	if isAssociated {
-		return 0, nil
+// GetElasticIPPrice returns the hourly price for an Elastic IP. Since February
+// 2024 in-use addresses are billed like any other public IPv4 address, and
+// idle addresses at the idle rate.
+func (p *AWSProvider) GetElasticIPPrice(ctx context.Context, region string, inUse bool) (cogtypes.CostValue, error) {
+	if inUse {
+		return p.GetPublicIPv4Price(ctx, region)
 	}
-
-	return p.getCachedPrice(p.eipCache, region, "eip:"+region, func() (cogtypes.CostValue, error) {
-		return p.fetchElasticIPPrice(ctx, region)
-	})
+	return p.lookupOne(ctx, PriceKey{Kind: KindEIP, Region: region})
 }
 
 // GetSecretPrice returns the hourly price for a Secrets Manager secret
 func (p *AWSProvider) GetSecretPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
-	return p.getCachedPrice(p.secretCache, region, "secret:"+region, func() (cogtypes.CostValue, error) {
-		return p.fetchSecretPrice(ctx, region)
-	})
+	return p.lookupOne(ctx, PriceKey{Kind: KindSecret, Region: region})
 }
 
 // GetPublicIPv4Price returns the hourly price for a public IPv4 address
 func (p *AWSProvider) GetPublicIPv4Price(ctx context.Context, region string) (cogtypes.CostValue, error) {
-	return p.getCachedPrice(p.publicIPv4Cache, region, "publicipv4:"+region, func() (cogtypes.CostValue, error) {
-		return p.fetchPublicIPv4Price(ctx, region)
-	})
+	return p.lookupOne(ctx, PriceKey{Kind: KindPublicIPv4, Region: region})
 }
 
 // GetLambdaPrice returns per-request and per-GB-second prices for Lambda.
 func (p *AWSProvider) GetLambdaPrice(ctx context.Context, region, architecture string) (request, gbSecond cogtypes.CostValue, err error) {
-	cacheKey := fmt.Sprintf("%s:%s", region, normalizeLambdaArchitecture(architecture))
-
-	v, err, _ := p.sfGroup.Do("lambda:"+cacheKey, func() (any, error) {
-		p.cacheMu.RLock()
-		req, hasReq := p.lambdaReqCache[cacheKey]
-		gb := p.lambdaGBCache[cacheKey]
-		valid := time.Now().Before(p.cacheExpiry)
-		p.cacheMu.RUnlock()
-
-		if hasReq && valid {
-			return [2]cogtypes.CostValue{req, gb}, nil
-		}
-
-		req, gb, err := p.fetchLambdaPrice(ctx, region, architecture)
-		if err != nil {
-			return [2]cogtypes.CostValue{}, err
-		}
-
-		p.cacheMu.Lock()
-		p.lambdaReqCache[cacheKey] = req
-		p.lambdaGBCache[cacheKey] = gb
-		if p.cacheExpiry.IsZero() || time.Now().After(p.cacheExpiry) {
-			p.cacheExpiry = time.Now().Add(p.cacheDuration)
-		}
-		p.cacheMu.Unlock()
-
-		return [2]cogtypes.CostValue{req, gb}, nil
-	})
+	prices, err := p.lookup(ctx, PriceKey{Kind: KindLambda, Region: region, Type: normalizeLambdaArchitecture(architecture)})
 	if err != nil {
 		return 0, 0, err
 	}
-
-	prices := v.([2]cogtypes.CostValue)
 	return prices[0], prices[1], nil
 }
 
@@ -432,29 +466,114 @@ func (p *AWSProvider) GetLambdaPriceDetails(ctx context.Context, region, archite
 
 // RefreshCache forces a refresh of the pricing cache
 func (p *AWSProvider) RefreshCache(ctx context.Context) error {
-	p.cacheMu.Lock()
-	p.ec2Cache = make(map[string]cogtypes.CostValue)
-	p.ebsCache = make(map[string]cogtypes.CostValue)
-	p.ecsCache = make(map[string]cogtypes.CostValue)
-	p.rdsCache = make(map[string]cogtypes.CostValue)
-	p.eksCache = make(map[string]cogtypes.CostValue)
-	p.elbCache = make(map[string]cogtypes.CostValue)
-	p.elbLCUCache = make(map[string]cogtypes.CostValue)
-	p.natCache = make(map[string]cogtypes.CostValue)
-	p.eipCache = make(map[string]cogtypes.CostValue)
-	p.secretCache = make(map[string]cogtypes.CostValue)
-	p.publicIPv4Cache = make(map[string]cogtypes.CostValue)
-	p.lambdaReqCache = make(map[string]cogtypes.CostValue)
-	p.lambdaGBCache = make(map[string]cogtypes.CostValue)
-	p.cacheExpiry = time.Time{}
-	p.cacheMu.Unlock()
+	p.cache.clear()
 	return nil
 }
 
 // ---- Fetch functions: each queries the AWS Pricing API for a specific resource type ----
 
-// fetchEC2Price queries the AWS Price List API for EC2 pricing
-func (p *AWSProvider) fetchEC2Price(ctx context.Context, region, instanceType string) (cogtypes.CostValue, error) {
+// fetchEC2Price queries the AWS Price List API for EC2 pricing. An empty
+// tenancy means Shared. Products some previous-generation and bare metal
+// types are listed under don't match the usual filters, so each query from
+// ec2PriceQueries is tried in turn. Mac instances only run on Dedicated
+// Hosts, so without a Dedicated Host tenancy they're priced as their host.
+func (p *AWSProvider) fetchEC2Price(ctx context.Context, region, instanceType, tenancy string) (cogtypes.CostValue, error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	if tenancy == "" {
+		tenancy = "Shared"
+	}
+
+	for _, query := range ec2PriceQueries(instanceType, tenancy) {
+		if err := p.waitForRateLimit(ctx); err != nil {
+			return 0, fmt.Errorf("rate limit: %w", err)
+		}
+
+		filters := []types.Filter{
+			termFilter("instanceType", instanceType),
+			termFilter("location", locationName),
+			termFilter("operatingSystem", "Linux"),
+			termFilter("tenancy", query.tenancy),
+			termFilter("preInstalledSw", "NA"),
+		}
+		if query.capacityStatus != "" {
+			filters = append(filters, termFilter("capacitystatus", query.capacityStatus))
+		}
+		output, err := p.client.GetProducts(ctx, &pricing.GetProductsInput{
+			ServiceCode: aws.String("AmazonEC2"),
+			Filters:     filters,
+			MaxResults:  aws.Int32(100),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("GetProducts for EC2: %w", err)
+		}
+
+		for _, item := range output.PriceList {
+			if isOnDemandCapacity(getProductAttribute(item, "capacitystatus")) {
+				return parsePriceFromProduct(item)
+			}
+		}
+	}
+
+	if family, ok := macHostFamily(instanceType); ok && tenancy != "Host" {
+		return p.fetchDedicatedHostPrice(ctx, region, family)
+	}
+
+	return 0, fmt.Errorf("no pricing found for EC2 %s (%s tenancy) in %s", instanceType, tenancy, region)
+}
+
+// ec2PriceQuery is one set of GetProducts filters tried for an EC2 price
+type ec2PriceQuery struct {
+	tenancy        string
+	capacityStatus string // Empty leaves out the capacitystatus filter
+}
+
+// ec2PriceQueries lists the queries tried, in order, for the on-demand price
+// of instanceType with a Pricing API tenancy. Instances running in a
+// capacity reservation are billed at the same on-demand rate as "Used"
+// capacity, so one price covers both. Products of some previous-generation
+// families, such as m1 and c3, have no capacitystatus, so they're looked up
+// without it. A bare metal instance occupies its whole server, so one with
+// dedicated tenancy costs the same as a shared one, which is the only way
+// some metal sizes are listed.
+func ec2PriceQueries(instanceType, tenancy string) []ec2PriceQuery {
+	queries := []ec2PriceQuery{
+		{tenancy: tenancy, capacityStatus: "Used"},
+		{tenancy: tenancy},
+	}
+	if tenancy == "Dedicated" && strings.HasSuffix(instanceType, ".metal") {
+		queries = append(queries,
+			ec2PriceQuery{tenancy: "Shared", capacityStatus: "Used"},
+			ec2PriceQuery{tenancy: "Shared"},
+		)
+	}
+	return queries
+}
+
+// isOnDemandCapacity reports whether a product's capacitystatus is the
+// on-demand price rather than an unused reservation or allocated host.
+// Products without a capacitystatus are on-demand.
+func isOnDemandCapacity(capacityStatus string) bool {
+	return capacityStatus == "" || capacityStatus == "Used"
+}
+
+// macHostFamily returns the Dedicated Host family of a Mac instance type,
+// such as "mac2-m2pro" for "mac2-m2pro.metal"
+func macHostFamily(instanceType string) (string, bool) {
+	if !strings.HasPrefix(instanceType, "mac") {
+		return "", false
+	}
+	family, _, _ := strings.Cut(instanceType, ".")
+	return family, true
+}
+
+// fetchCPUCreditPrice queries the Pricing API for the surplus CPU credit
+// price of a burstable instance family. Usage types look like
+// "USE1-CPUCredits:t3", or "CPUCredits:t3" in us-east-1.
+func (p *AWSProvider) fetchCPUCreditPrice(ctx context.Context, region, family string) (cogtypes.CostValue, error) {
 	locationName, ok := regionToLocation[region]
 	if !ok {
 		return 0, fmt.Errorf("unknown region: %s", region)
@@ -467,24 +586,237 @@ func (p *AWSProvider) fetchEC2Price(ctx context.Context, region, instanceType st
 	output, err := p.client.GetProducts(ctx, &pricing.GetProductsInput{
 		ServiceCode: aws.String("AmazonEC2"),
 		Filters: []types.Filter{
-			termFilter("instanceType", instanceType),
+			termFilter("productFamily", "CPU Credits"),
 			termFilter("location", locationName),
 			termFilter("operatingSystem", "Linux"),
-			termFilter("tenancy", "Shared"),
-			termFilter("preInstalledSw", "NA"),
-			termFilter("capacitystatus", "Used"),
 		},
-		MaxResults: aws.Int32(1),
+		MaxResults: aws.Int32(100),
 	})
 	if err != nil {
-		return 0, fmt.Errorf("GetProducts for EC2: %w", err)
+		return 0, fmt.Errorf("GetProducts for CPU credits: %w", err)
 	}
 
-	if len(output.PriceList) == 0 {
-		return 0, fmt.Errorf("no pricing found for EC2 %s in %s", instanceType, region)
+	for _, item := range output.PriceList {
+		if isCPUCreditUsage(getProductAttribute(item, "usagetype"), family) {
+			return parsePriceFromProduct(item)
+		}
 	}
 
-	return parsePriceFromProduct(output.PriceList[0])
+	return 0, fmt.Errorf("no CPU credit pricing found for %s in %s", family, region)
+}
+
+// fetchMetricPrice queries the Pricing API for the monthly price of a
+// CloudWatch metric at the first tier. Usage types look like
+// "USW2-CW:MetricMonitorUsage", or "CW:MetricMonitorUsage" in us-east-1.
+func (p *AWSProvider) fetchMetricPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	if err := p.waitForRateLimit(ctx); err != nil {
+		return 0, fmt.Errorf("rate limit: %w", err)
+	}
+
+	output, err := p.client.GetProducts(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonCloudWatch"),
+		Filters: []types.Filter{
+			termFilter("productFamily", "Metric"),
+			termFilter("location", locationName),
+		},
+		MaxResults: aws.Int32(20),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("GetProducts for CloudWatch metrics: %w", err)
+	}
+
+	for _, item := range output.PriceList {
+		usagetype := getProductAttribute(item, "usagetype")
+		if usagetype == "CW:MetricMonitorUsage" || strings.HasSuffix(usagetype, "-CW:MetricMonitorUsage") {
+			return parseFirstTierPrice(item)
+		}
+	}
+
+	return 0, fmt.Errorf("no CloudWatch metric pricing found in %s", region)
+}
+
+// fetchEBSOptimizedPrice queries the Pricing API for the hourly EBS-optimized
+// surcharge of an instance type. Usage types look like
+// "USW2-EBSOptimized:m3.xlarge", or "EBSOptimized:m3.xlarge" in us-east-1.
+func (p *AWSProvider) fetchEBSOptimizedPrice(ctx context.Context, region, instanceType string) (cogtypes.CostValue, error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	input := &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []types.Filter{
+			termFilter("instanceType", instanceType),
+			termFilter("location", locationName),
+		},
+		MaxResults: aws.Int32(100),
+	}
+	// Each instance type has a product per operating system, tenancy, and
+	// license, so the surcharge may not be on the first page
+	for {
+		if err := p.waitForRateLimit(ctx); err != nil {
+			return 0, fmt.Errorf("rate limit: %w", err)
+		}
+
+		output, err := p.client.GetProducts(ctx, input)
+		if err != nil {
+			return 0, fmt.Errorf("GetProducts for EBS-optimized surcharge: %w", err)
+		}
+
+		for _, item := range output.PriceList {
+			usagetype := getProductAttribute(item, "usagetype")
+			if usagetype == "EBSOptimized:"+instanceType || strings.HasSuffix(usagetype, "-EBSOptimized:"+instanceType) {
+				return parsePriceFromProduct(item)
+			}
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return 0, fmt.Errorf("no EBS-optimized pricing found for %s in %s", instanceType, region)
+}
+
+// fetchDedicatedHostPrice queries the Pricing API for the hourly price of a
+// Dedicated Host. Usage types look like "USW2-HostUsage:m5", or
+// "HostUsage:m5" in us-east-1.
+func (p *AWSProvider) fetchDedicatedHostPrice(ctx context.Context, region, family string) (cogtypes.CostValue, error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	if err := p.waitForRateLimit(ctx); err != nil {
+		return 0, fmt.Errorf("rate limit: %w", err)
+	}
+
+	output, err := p.client.GetProducts(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonEC2"),
+		Filters: []types.Filter{
+			termFilter("productFamily", "Dedicated Host"),
+			termFilter("instanceType", family),
+			termFilter("location", locationName),
+		},
+		MaxResults: aws.Int32(100),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("GetProducts for Dedicated Hosts: %w", err)
+	}
+
+	for _, item := range output.PriceList {
+		if isDedicatedHostUsage(getProductAttribute(item, "usagetype"), family) {
+			return parsePriceFromProduct(item)
+		}
+	}
+
+	return 0, fmt.Errorf("no Dedicated Host pricing found for %s in %s", family, region)
+}
+
+// fetchKinesisPrice queries the Pricing API for the hourly price of a
+// provisioned shard or an on-demand stream. Usage types look like
+// "USW2-Storage-ShardHour" and "USW2-OnDemand-StreamHour", without the
+// prefix in us-east-1.
+func (p *AWSProvider) fetchKinesisPrice(ctx context.Context, region string, onDemand bool) (cogtypes.CostValue, error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	want := "Storage-ShardHour"
+	if onDemand {
+		want = "OnDemand-StreamHour"
+	}
+
+	input := &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonKinesis"),
+		Filters: []types.Filter{
+			termFilter("location", locationName),
+		},
+		MaxResults: aws.Int32(100),
+	}
+	for {
+		if err := p.waitForRateLimit(ctx); err != nil {
+			return 0, fmt.Errorf("rate limit: %w", err)
+		}
+
+		output, err := p.client.GetProducts(ctx, input)
+		if err != nil {
+			return 0, fmt.Errorf("GetProducts for Kinesis: %w", err)
+		}
+
+		for _, item := range output.PriceList {
+			if isRegionalUsage(getProductAttribute(item, "usagetype"), want) {
+				return parsePriceFromProduct(item)
+			}
+		}
+
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	return 0, fmt.Errorf("no Kinesis %s pricing found in %s", want, region)
+}
+
+// fetchFirehosePrice queries the Pricing API for the first tier price per GB
+// ingested into Firehose. Usage types look like "USW2-BilledBytes", or
+// "BilledBytes" in us-east-1.
+func (p *AWSProvider) fetchFirehosePrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	if err := p.waitForRateLimit(ctx); err != nil {
+		return 0, fmt.Errorf("rate limit: %w", err)
+	}
+
+	output, err := p.client.GetProducts(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonKinesisFirehose"),
+		Filters: []types.Filter{
+			termFilter("location", locationName),
+		},
+		MaxResults: aws.Int32(100),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("GetProducts for Firehose: %w", err)
+	}
+
+	for _, item := range output.PriceList {
+		if isRegionalUsage(getProductAttribute(item, "usagetype"), "BilledBytes") {
+			return parseFirstTierPrice(item)
+		}
+	}
+
+	return 0, fmt.Errorf("no Firehose ingestion pricing found in %s", region)
+}
+
+// isRegionalUsage reports whether usagetype is name, optionally prefixed
+// with a region code such as "USW2-"
+func isRegionalUsage(usagetype, name string) bool {
+	prefix, ok := strings.CutSuffix(usagetype, name)
+	return ok && (prefix == "" || (strings.HasSuffix(prefix, "-") && !strings.Contains(strings.TrimSuffix(prefix, "-"), "-")))
+}
+
+// isDedicatedHostUsage reports whether usagetype is the on-demand Dedicated
+// Host usage type for family
+func isDedicatedHostUsage(usagetype, family string) bool {
+	return usagetype == "HostUsage:"+family || strings.HasSuffix(usagetype, "-HostUsage:"+family)
+}
+
+// isCPUCreditUsage reports whether usagetype is the CPU credit usage type
+// for family
+func isCPUCreditUsage(usagetype, family string) bool {
+	return usagetype == "CPUCredits:"+family || strings.HasSuffix(usagetype, "-CPUCredits:"+family)
 }
 
 // fetchEBSPrices queries the AWS Price List API for EBS storage, IOPS, and throughput pricing
@@ -642,6 +974,125 @@ func (p *AWSProvider) fetchRDSPrice(ctx context.Context, region, instanceClass,
 	return parsePriceFromProduct(output.PriceList[0])
 }
 
+// rdsStorageVolumeTypes maps RDS storage types to Pricing API volumeType values
+var rdsStorageVolumeTypes = map[string]string{
+	"gp2":      "General Purpose",
+	"gp3":      "General Purpose-GP3",
+	"io1":      "Provisioned IOPS",
+	"io2":      "Provisioned IOPS-IO2",
+	"standard": "Magnetic",
+}
+
+// fetchRDSStoragePrice queries the Pricing API for RDS storage pricing
+func (p *AWSProvider) fetchRDSStoragePrice(ctx context.Context, region, storageType string, multiAZ bool) (cogtypes.CostValue, error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	volumeType, ok := rdsStorageVolumeTypes[storageType]
+	if !ok {
+		return 0, fmt.Errorf("unknown RDS storage type: %s", storageType)
+	}
+
+	if err := p.waitForRateLimit(ctx); err != nil {
+		return 0, fmt.Errorf("rate limit: %w", err)
+	}
+
+	deploymentOption := "Single-AZ"
+	if multiAZ {
+		deploymentOption = "Multi-AZ"
+	}
+
+	output, err := p.client.GetProducts(ctx, &pricing.GetProductsInput{
+		ServiceCode: aws.String("AmazonRDS"),
+		Filters: []types.Filter{
+			termFilter("productFamily", "Database Storage"),
+			termFilter("location", locationName),
+			termFilter("volumeType", volumeType),
+			termFilter("deploymentOption", deploymentOption),
+		},
+		MaxResults: aws.Int32(10),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("GetProducts for RDS storage: %w", err)
+	}
+
+	if len(output.PriceList) == 0 {
+		return 0, fmt.Errorf("no pricing found for RDS %s storage in %s", storageType, region)
+	}
+
+	return parsePriceFromProduct(output.PriceList[0])
+}
+
+// fetchAuroraPrices queries the Pricing API for Aurora cluster storage and
+// I/O pricing. Verified from AmazonRDS bulk pricing:
+//   - Standard storage: productFamily=Database Storage, usagetype ends with Aurora:StorageUsage
+//   - I/O-Optimized storage: productFamily=Database Storage, usagetype ends with Aurora:IO-OptimizedStorageUsage
+//   - I/O: productFamily=System Operation, usagetype ends with Aurora:StorageIOUsage, priced per request
+func (p *AWSProvider) fetchAuroraPrices(ctx context.Context, region string) (storage, ioOptimizedStorage, perMillionIOs cogtypes.CostValue, err error) {
+	locationName, ok := regionToLocation[region]
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("unknown region: %s", region)
+	}
+
+	var foundStorage, foundIOOptimized, foundIO bool
+	for _, family := range []string{"Database Storage", "System Operation"} {
+		input := &pricing.GetProductsInput{
+			ServiceCode: aws.String("AmazonRDS"),
+			Filters: []types.Filter{
+				termFilter("productFamily", family),
+				termFilter("location", locationName),
+			},
+			MaxResults: aws.Int32(100),
+		}
+		// Each family has products for every engine and deployment, so the
+		// Aurora ones may not be on the first page
+		for !foundStorage || !foundIOOptimized || !foundIO {
+			if err := p.waitForRateLimit(ctx); err != nil {
+				return 0, 0, 0, fmt.Errorf("rate limit: %w", err)
+			}
+			output, err := p.client.GetProducts(ctx, input)
+			if err != nil {
+				return 0, 0, 0, fmt.Errorf("GetProducts for Aurora %s: %w", strings.ToLower(family), err)
+			}
+
+			for _, item := range output.PriceList {
+				usagetype := getProductAttribute(item, "usagetype")
+				switch {
+				case !foundStorage && isRegionalUsage(usagetype, "Aurora:StorageUsage"):
+					if storage, err = parsePriceFromProduct(item); err != nil {
+						return 0, 0, 0, err
+					}
+					foundStorage = true
+				case !foundIOOptimized && isRegionalUsage(usagetype, "Aurora:IO-OptimizedStorageUsage"):
+					if ioOptimizedStorage, err = parsePriceFromProduct(item); err != nil {
+						return 0, 0, 0, err
+					}
+					foundIOOptimized = true
+				case !foundIO && isRegionalUsage(usagetype, "Aurora:StorageIOUsage"):
+					perRequest, err := parsePriceFromProduct(item)
+					if err != nil {
+						return 0, 0, 0, err
+					}
+					perMillionIOs = perRequest * 1_000_000
+					foundIO = true
+				}
+			}
+
+			if output.NextToken == nil {
+				break
+			}
+			input.NextToken = output.NextToken
+		}
+	}
+
+	if !foundStorage || !foundIOOptimized || !foundIO {
+		return 0, 0, 0, fmt.Errorf("no Aurora storage and I/O pricing found in %s", region)
+	}
+	return storage, ioOptimizedStorage, perMillionIOs, nil
+}
+
 // fetchECSFargatePrice queries the Pricing API for Fargate vCPU and memory rates,
 // then computes an estimated per-task cost using 0.5 vCPU + 1GB memory.
 // Verified from AmazonECS bulk pricing:
@@ -698,15 +1149,16 @@ func (p *AWSProvider) fetchECSFargatePrice(ctx context.Context, region string) (
 	}
 
 	// Estimate per-task cost: 0.5 vCPU + 1GB memory
-	perTaskPrice := cogtypes.CostValue(0.5)*vcpuPrice + memPrice
+	perTaskPrice := (cogtypes.CostValue(0.5) * vcpuPrice).Add(memPrice)
 	return perTaskPrice, nil
 }
 
 // fetchEKSPrice queries the Pricing API for EKS control plane pricing
 // Verified from AmazonEKS bulk pricing:
 //   - Standard control plane: operation=CreateOperation, tiertype=HAStandard, locationType=AWS Region
-//   - Other products: ExtendedSupport, Outposts, Provisioned, AutoMode, Fargate — must be excluded
-func (p *AWSProvider) fetchEKSPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+//   - Extended support: usagetype ends in "AmazonEKS-Hours:extendedSupport"
+//   - Other products: Outposts, Provisioned, AutoMode, Fargate — must be excluded
+func (p *AWSProvider) fetchEKSPrice(ctx context.Context, region string, extendedSupport bool) (cogtypes.CostValue, error) {
 	locationName, ok := regionToLocation[region]
 	if !ok {
 		return 0, fmt.Errorf("unknown region: %s", region)
@@ -721,19 +1173,33 @@ func (p *AWSProvider) fetchEKSPrice(ctx context.Context, region string) (cogtype
 		Filters: []types.Filter{
 			termFilter("productFamily", "Compute"),
 			termFilter("location", locationName),
-			termFilter("operation", "CreateOperation"),
+			termFilter("locationType", "AWS Region"),
 		},
-		MaxResults: aws.Int32(10),
+		MaxResults: aws.Int32(100),
 	})
 	if err != nil {
 		return 0, fmt.Errorf("GetProducts for EKS: %w", err)
 	}
 
-	if len(output.PriceList) == 0 {
-		return 0, fmt.Errorf("no pricing found for EKS in %s", region)
+	for _, item := range output.PriceList {
+		if isEKSControlPlaneProduct(getProductAttribute(item, "operation"), getProductAttribute(item, "tiertype"), getProductAttribute(item, "usagetype"), extendedSupport) {
+			return parsePriceFromProduct(item)
+		}
 	}
 
-	return parsePriceFromProduct(output.PriceList[0])
+	if extendedSupport {
+		return 0, fmt.Errorf("no extended support pricing found for EKS in %s", region)
+	}
+	return 0, fmt.Errorf("no pricing found for EKS in %s", region)
+}
+
+// isEKSControlPlaneProduct reports whether a product is the standard or
+// extended support control plane rate
+func isEKSControlPlaneProduct(operation, tiertype, usagetype string, extendedSupport bool) bool {
+	if extendedSupport {
+		return strings.HasSuffix(usagetype, "AmazonEKS-Hours:extendedSupport")
+	}
+	return operation == "CreateOperation" && tiertype == "HAStandard"
 }
 
 // fetchELBPrice queries the Pricing API for load balancer base hourly and per-LCU pricing
@@ -1167,6 +1633,39 @@ func getProductSKU(priceListJSON string) string {
 	return sku
 }
 
+// parseFirstTierPrice extracts the USD price of the lowest volume tier (the
+// dimension starting at 0) from a tiered price list product
+func parseFirstTierPrice(priceListJSON string) (cogtypes.CostValue, error) {
+	var product struct {
+		Terms struct {
+			OnDemand map[string]struct {
+				PriceDimensions map[string]struct {
+					BeginRange   string            `json:"beginRange"`
+					PricePerUnit map[string]string `json:"pricePerUnit"`
+				} `json:"priceDimensions"`
+			} `json:"OnDemand"`
+		} `json:"terms"`
+	}
+	if err := json.Unmarshal([]byte(priceListJSON), &product); err != nil {
+		return 0, fmt.Errorf("parsing price list JSON: %w", err)
+	}
+
+	for _, offer := range product.Terms.OnDemand {
+		for _, dim := range offer.PriceDimensions {
+			if dim.BeginRange != "0" {
+				continue
+			}
+			price, err := strconv.ParseFloat(dim.PricePerUnit["USD"], 64)
+			if err != nil {
+				return 0, fmt.Errorf("parsing USD price: %w", err)
+			}
+			return cogtypes.CostValue(price), nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not extract first tier price from product")
+}
+
 // parsePriceFromProduct extracts the hourly on-demand price from the AWS pricing JSON
 func parsePriceFromProduct(priceListJSON string) (cogtypes.CostValue, error) {
 	var product map[string]any
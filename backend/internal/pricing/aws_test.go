@@ -1,6 +1,11 @@
 package pricing
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
 
 func TestLambdaUsageTypeClassification(t *testing.T) {
 	if !isLambdaRequestUsage("Request") {
@@ -37,3 +42,151 @@ func TestLambdaUsageTypeClassification(t *testing.T) {
 		t.Fatal("expected managed instance request usage type to be skipped")
 	}
 }
+
+func TestEC2PricingTenancy(t *testing.T) {
+	tests := map[string]string{"": "Shared", "default": "Shared", "dedicated": "Dedicated", "host": "Host"}
+	for tenancy, want := range tests {
+		if got, err := ec2PricingTenancy(tenancy); err != nil || got != want {
+			t.Fatalf("ec2PricingTenancy(%q) = %q, %v; want %q", tenancy, got, err, want)
+		}
+	}
+	if _, err := ec2PricingTenancy("shared"); err == nil {
+		t.Fatal("expected an error for an unknown tenancy")
+	}
+}
+
+func TestEC2PriceQueries(t *testing.T) {
+	tests := []struct {
+		instanceType, tenancy string
+		want                  []ec2PriceQuery
+	}{
+		{"m1.large", "Shared", []ec2PriceQuery{{"Shared", "Used"}, {"Shared", ""}}},
+		{"c5.metal", "Shared", []ec2PriceQuery{{"Shared", "Used"}, {"Shared", ""}}},
+		{"m5.xlarge", "Dedicated", []ec2PriceQuery{{"Dedicated", "Used"}, {"Dedicated", ""}}},
+		{"i3.metal", "Dedicated", []ec2PriceQuery{{"Dedicated", "Used"}, {"Dedicated", ""}, {"Shared", "Used"}, {"Shared", ""}}},
+	}
+	for _, tt := range tests {
+		if got := ec2PriceQueries(tt.instanceType, tt.tenancy); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ec2PriceQueries(%q, %q) = %+v, want %+v", tt.instanceType, tt.tenancy, got, tt.want)
+		}
+	}
+}
+
+func TestOnDemandCapacity(t *testing.T) {
+	for status, want := range map[string]bool{
+		"":                             true,
+		"Used":                         true,
+		"UnusedCapacityReservation":    false,
+		"AllocatedCapacityReservation": false,
+		"AllocatedHost":                false,
+	} {
+		if got := isOnDemandCapacity(status); got != want {
+			t.Errorf("isOnDemandCapacity(%q) = %t, want %t", status, got, want)
+		}
+	}
+}
+
+func TestMacHostFamily(t *testing.T) {
+	for instanceType, want := range map[string]string{
+		"mac1.metal":       "mac1",
+		"mac2-m2pro.metal": "mac2-m2pro",
+		"m5.metal":         "",
+		"c3.large":         "",
+	} {
+		got, ok := macHostFamily(instanceType)
+		if got != want || ok != (want != "") {
+			t.Errorf("macHostFamily(%q) = %q, %t; want %q", instanceType, got, ok, want)
+		}
+	}
+}
+
+func TestCPUCreditUsageType(t *testing.T) {
+	if !isCPUCreditUsage("CPUCredits:t3", "t3") {
+		t.Fatal("expected us-east-1 usage type to match")
+	}
+	if !isCPUCreditUsage("EUW1-CPUCredits:t4g", "t4g") {
+		t.Fatal("expected regional usage type to match")
+	}
+	if isCPUCreditUsage("USE1-CPUCredits:t3a", "t3") {
+		t.Fatal("t3a usage type should not match t3")
+	}
+}
+
+func TestDedicatedHostUsageType(t *testing.T) {
+	if !isDedicatedHostUsage("HostUsage:m5", "m5") {
+		t.Fatal("expected us-east-1 usage type to match")
+	}
+	if !isDedicatedHostUsage("USW2-HostUsage:c6g", "c6g") {
+		t.Fatal("expected regional usage type to match")
+	}
+	if isDedicatedHostUsage("USE1-HostUsage:m5d", "m5") {
+		t.Fatal("m5d usage type should not match m5")
+	}
+}
+
+func TestRegionalUsageType(t *testing.T) {
+	for _, tc := range []struct {
+		usagetype, name string
+		want            bool
+	}{
+		{"Storage-ShardHour", "Storage-ShardHour", true},
+		{"USW2-Storage-ShardHour", "Storage-ShardHour", true},
+		{"USW2-Extended-ShardHour", "Storage-ShardHour", false},
+		{"EUC1-BilledBytes", "BilledBytes", true},
+		{"EUC1-VPC-BilledBytes", "BilledBytes", false},
+	} {
+		if got := isRegionalUsage(tc.usagetype, tc.name); got != tc.want {
+			t.Errorf("isRegionalUsage(%q, %q) = %t, want %t", tc.usagetype, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEKSControlPlaneProduct(t *testing.T) {
+	if !isEKSControlPlaneProduct("CreateOperation", "HAStandard", "USE1-AmazonEKS-Hours:perCluster", false) {
+		t.Fatal("expected standard control plane to match")
+	}
+	if isEKSControlPlaneProduct("CreateOperation", "HAStandard", "USE1-AmazonEKS-Hours:perCluster", true) {
+		t.Fatal("standard control plane should not match extended support")
+	}
+	if !isEKSControlPlaneProduct("CreateOperation", "", "USE1-AmazonEKS-Hours:extendedSupport", true) {
+		t.Fatal("expected extended support to match")
+	}
+	if isEKSControlPlaneProduct("CreateOperation", "", "USE1-AmazonEKS-Hours:extendedSupport", false) {
+		t.Fatal("extended support should not match the standard rate")
+	}
+}
+
+func TestParseFirstTierPrice(t *testing.T) {
+	product := `{"terms":{"OnDemand":{"ABC.JRTCKXETXF":{"priceDimensions":{
+		"ABC.JRTCKXETXF.1":{"beginRange":"10000","pricePerUnit":{"USD":"0.1000000000"}},
+		"ABC.JRTCKXETXF.2":{"beginRange":"0","pricePerUnit":{"USD":"0.3000000000"}}
+	}}}}}`
+	price, err := parseFirstTierPrice(product)
+	if err != nil || price != 0.3 {
+		t.Fatalf("parseFirstTierPrice() = %v, %v; want 0.3", price, err)
+	}
+	if _, err := parseFirstTierPrice(`{"terms":{"OnDemand":{}}}`); err == nil {
+		t.Fatal("expected an error without a first tier")
+	}
+}
+
+func TestEBSCostComponents(t *testing.T) {
+	components := ebsCostComponents("gp3", 100, 4000, 250, 0.08, 0.005, 0.04)
+	if len(components) != 3 {
+		t.Fatalf("expected storage, IOPS, and throughput components, got %+v", components)
+	}
+	if components[1].Quantity != 1000 || components[2].Quantity != 125 {
+		t.Fatalf("expected only IOPS and throughput above the gp3 baseline to be billed, got %+v", components)
+	}
+	want := cogtypes.CostValue((100*0.08 + 1000*0.005 + 125*0.04) / cogtypes.HoursPerMonth)
+	if got := cogtypes.SumComponents(components); got-want > 1e-9 || want-got > 1e-9 {
+		t.Fatalf("expected hourly cost %v, got %v", want, got)
+	}
+
+	if components := ebsCostComponents("gp3", 100, 3000, 125, 0.08, 0.005, 0.04); len(components) != 1 {
+		t.Fatalf("expected baseline gp3 volume to have only storage, got %+v", components)
+	}
+	if components := ebsCostComponents("io2", 100, 2000, 0, 0.125, 0.065, 0); len(components) != 2 || components[1].Quantity != 2000 {
+		t.Fatalf("expected io2 volume to bill all provisioned IOPS, got %+v", components)
+	}
+}
@@ -0,0 +1,129 @@
+package pricing
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// priceCache holds fetched prices, each expiring ttl after it was fetched.
+// When full, the least recently used price is evicted.
+type priceCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int                      // 0 = unlimited
+	entries    map[string]*list.Element // Values are *priceEntry
+	lru        *list.List               // Most recently used at the front
+	evictions  int64
+	now        func() time.Time
+}
+
+// priceEntry is the prices fetched for one lookup. Most lookups have one
+// price; EBS, ELB, and Lambda lookups have one per billed dimension.
+type priceEntry struct {
+	key     string
+	prices  []cogtypes.CostValue
+	fetched time.Time
+	expires time.Time
+}
+
+func newPriceCache(ttl time.Duration, maxEntries int) *priceCache {
+	return &priceCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// get returns the unexpired prices cached under key and when they were
+// fetched
+func (c *priceCache) get(key string) ([]cogtypes.CostValue, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	entry := el.Value.(*priceEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(el)
+		return nil, time.Time{}, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.prices, entry.fetched, true
+}
+
+// set caches prices under key for the cache TTL, evicting the least recently
+// used entries if the cache is full
+func (c *priceCache) set(key string, prices []cogtypes.CostValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fetched := c.now()
+	expires := fetched.Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*priceEntry)
+		entry.prices, entry.fetched, entry.expires = prices, fetched, expires
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&priceEntry{key: key, prices: prices, fetched: fetched, expires: expires})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// setMaxEntries changes the entry limit, evicting entries if needed
+func (c *priceCache) setMaxEntries(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxEntries = n
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// clear removes every entry
+func (c *priceCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// remove deletes an entry. The caller must hold c.mu.
+func (c *priceCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*priceEntry).key)
+}
+
+// snapshot returns a copy of the unexpired entries, the eviction count, and
+// the entry limit, dropping expired entries
+func (c *priceCache) snapshot() ([]priceEntry, int64, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	entries := make([]priceEntry, 0, c.lru.Len())
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		entry := el.Value.(*priceEntry)
+		if now.Before(entry.expires) {
+			entries = append(entries, *entry)
+		} else {
+			c.remove(el)
+		}
+		el = next
+	}
+	return entries, c.evictions, c.maxEntries
+}
@@ -0,0 +1,53 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestPriceCacheExpiresEachEntry(t *testing.T) {
+	now := time.Now()
+	c := newPriceCache(time.Hour, 0)
+	c.now = func() time.Time { return now }
+
+	c.set("ec2:us-east-1:m5.large", []cogtypes.CostValue{0.096})
+	now = now.Add(30 * time.Minute)
+	c.set("ec2:us-east-1:m5.xlarge", []cogtypes.CostValue{0.192})
+
+	// A new entry doesn't extend older ones
+	now = now.Add(31 * time.Minute)
+	if _, _, ok := c.get("ec2:us-east-1:m5.large"); ok {
+		t.Fatal("expected the first entry to expire an hour after it was set")
+	}
+	if _, _, ok := c.get("ec2:us-east-1:m5.xlarge"); !ok {
+		t.Fatal("expected the second entry to still be cached")
+	}
+	if len(c.entries) != 1 {
+		t.Fatalf("expected the expired entry to be removed, got %d entries", len(c.entries))
+	}
+}
+
+func TestPriceCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newPriceCache(time.Hour, 2)
+	c.set("a", []cogtypes.CostValue{1})
+	c.set("b", []cogtypes.CostValue{2})
+	c.get("a")
+	c.set("c", []cogtypes.CostValue{3})
+
+	if _, _, ok := c.get("b"); ok {
+		t.Fatal("expected b, the least recently used entry, to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, _, ok := c.get(key); !ok {
+			t.Fatalf("expected %s to be cached", key)
+		}
+	}
+
+	c.setMaxEntries(1)
+	entries, evictions, maxEntries := c.snapshot()
+	if len(entries) != 1 || entries[0].key != "c" || evictions != 2 || maxEntries != 1 {
+		t.Fatalf("snapshot = %+v, %d, %d", entries, evictions, maxEntries)
+	}
+}
@@ -0,0 +1,90 @@
+package pricing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Where a resource's prices came from
+const (
+	PriceSourceAPI   = "api"   // Fetched from the Pricing API for this scan
+	PriceSourceCache = "cache" // Fetched by an earlier scan or the warmer and served from the price cache
+
+	// PriceSourceConfig marks a resource priced at rates set in the config,
+	// such as aws.messaging, rather than looked up. It has no fetch time.
+	PriceSourceConfig = "config"
+
+	// PriceSourceMissing marks a resource with a price lookup that failed, so
+	// its cost is understated. It takes precedence over the other sources.
+	PriceSourceMissing = "missing"
+	// PriceSourceEstimate marks a resource priced from other sizes of its
+	// family after a lookup failed, under pricing.missingPrice
+	PriceSourceEstimate = "estimate"
+)
+
+type freshnessKey struct{}
+
+// Freshness records where the prices looked up for one resource came from.
+// When a resource uses several prices it reports the oldest, since that's the
+// one most likely to be out of date.
+type Freshness struct {
+	mu      sync.Mutex
+	source  string
+	asOf    time.Time
+	missing bool // A lookup failed
+}
+
+// TrackFreshness returns a context whose price lookups are recorded in the
+// returned Freshness
+func TrackFreshness(ctx context.Context) (context.Context, *Freshness) {
+	f := &Freshness{}
+	return context.WithValue(ctx, freshnessKey{}, f), f
+}
+
+// observeFreshness records a price lookup in ctx's Freshness, if it has one
+func observeFreshness(ctx context.Context, source string, asOf time.Time) {
+	f, ok := ctx.Value(freshnessKey{}).(*Freshness)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.source == "" || asOf.Before(f.asOf) {
+		f.source, f.asOf = source, asOf
+	}
+}
+
+// observeMissing records a failed price lookup in ctx's Freshness, if it has
+// one
+func observeMissing(ctx context.Context) {
+	f, ok := ctx.Value(freshnessKey{}).(*Freshness)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.missing = true
+}
+
+// Source returns the source of the oldest price looked up, PriceSourceMissing
+// if any lookup failed, or "" if none was made
+func (f *Freshness) Source() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.missing {
+		return PriceSourceMissing
+	}
+	return f.source
+}
+
+// AsOf returns when the oldest price looked up was fetched, in RFC 3339, or
+// "" if none was
+func (f *Freshness) AsOf() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.asOf.IsZero() {
+		return ""
+	}
+	return f.asOf.UTC().Format(time.RFC3339)
+}
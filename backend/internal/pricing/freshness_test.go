@@ -0,0 +1,41 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestFreshnessReportsOldestCachedPrice(t *testing.T) {
+	p := &AWSProvider{cache: newPriceCache(time.Hour, 100), seen: make(map[PriceKey]struct{})}
+	fetched := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	p.cache.now = func() time.Time { return fetched }
+	p.cache.set(PriceKey{Kind: KindRDS, Region: "us-east-1", Type: "db.m5.large", Engine: "postgres"}.id(), []cogtypes.CostValue{0.178})
+	p.cache.now = func() time.Time { return fetched.Add(30 * time.Minute) }
+	p.cache.set(PriceKey{Kind: KindRDSStorage, Region: "us-east-1", Type: "gp3"}.id(), []cogtypes.CostValue{0.115})
+
+	ctx, freshness := TrackFreshness(t.Context())
+	if freshness.Source() != "" || freshness.AsOf() != "" {
+		t.Fatalf("freshness before any lookup = %q, %q", freshness.Source(), freshness.AsOf())
+	}
+	if _, err := p.GetRDSStoragePrice(ctx, "us-east-1", "gp3", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetRDSPrice(ctx, "us-east-1", "db.m5.large", "postgres", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if freshness.Source() != PriceSourceCache || freshness.AsOf() != "2026-03-01T12:00:00Z" {
+		t.Fatalf("freshness = %q, %q", freshness.Source(), freshness.AsOf())
+	}
+}
+
+func TestFreshnessReportsFailedLookups(t *testing.T) {
+	ctx, freshness := TrackFreshness(t.Context())
+	observeFreshness(ctx, PriceSourceCache, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	observeMissing(ctx)
+	if freshness.Source() != PriceSourceMissing || freshness.AsOf() != "2026-03-01T12:00:00Z" {
+		t.Fatalf("freshness after a failed lookup = %q, %q; want missing with the cached price's time", freshness.Source(), freshness.AsOf())
+	}
+}
@@ -0,0 +1,288 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Component overrides noted in cost components
+const (
+	OverridePinnedRate = "pinned rate"
+)
+
+// Overrides applies an operator's negotiated prices over a Provider's list
+// prices: pinned EC2 and RDS rates are used as-is, and every other price is
+// discounted by the configured percentage
+type Overrides struct {
+	Provider
+	discount float64                       // Fraction off list prices, e.g. 0.12
+	rates    map[string]cogtypes.CostValue // Keyed by rateKey; region "" matches every region
+}
+
+// WithOverrides returns p with cfg's overrides applied, or p itself if cfg
+// overrides nothing
+func WithOverrides(p Provider, cfg config.PriceOverrideConfig) Provider {
+	if cfg.DiscountPercent == 0 && len(cfg.Rates) == 0 {
+		return p
+	}
+	o := &Overrides{
+		Provider: p,
+		discount: cfg.DiscountPercent / 100,
+		rates:    make(map[string]cogtypes.CostValue, len(cfg.Rates)),
+	}
+	for _, rate := range cfg.Rates {
+		o.rates[rateKey(rate.Service, rate.Region, rate.SKU)] = cogtypes.CostValue(rate.Hourly)
+	}
+	return o
+}
+
+func rateKey(service, region, sku string) string {
+	return service + "|" + region + "|" + sku
+}
+
+// pinned returns the pinned rate for sku in region, preferring one for the
+// region over one for every region
+func (o *Overrides) pinned(service, region, sku string) (cogtypes.CostValue, bool) {
+	if rate, ok := o.rates[rateKey(service, region, sku)]; ok {
+		return rate, true
+	}
+	rate, ok := o.rates[rateKey(service, "", sku)]
+	return rate, ok
+}
+
+// discounted applies the discount to a list price
+func (o *Overrides) discounted(price cogtypes.CostValue) cogtypes.CostValue {
+	return price * cogtypes.CostValue(1-o.discount)
+}
+
+// discountNote describes the discount in cost components
+func (o *Overrides) discountNote() string {
+	return fmt.Sprintf("%g%% discount", o.discount*100)
+}
+
+// GetEC2Price implements Provider
+func (o *Overrides) GetEC2Price(ctx context.Context, region, instanceType string) (cogtypes.CostValue, error) {
+	if rate, ok := o.pinned("ec2", region, instanceType); ok {
+		return rate, nil
+	}
+	price, err := o.Provider.GetEC2Price(ctx, region, instanceType)
+	return o.discounted(price), err
+}
+
+// GetEC2TenancyPrice implements Provider. Rates are pinned for default
+// tenancy only.
+func (o *Overrides) GetEC2TenancyPrice(ctx context.Context, region, instanceType, tenancy string) (cogtypes.CostValue, error) {
+	if tenancy == "" || tenancy == "default" {
+		return o.GetEC2Price(ctx, region, instanceType)
+	}
+	price, err := o.Provider.GetEC2TenancyPrice(ctx, region, instanceType, tenancy)
+	return o.discounted(price), err
+}
+
+// GetCPUCreditPrice implements Provider
+func (o *Overrides) GetCPUCreditPrice(ctx context.Context, region, family string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetCPUCreditPrice(ctx, region, family)
+	return o.discounted(price), err
+}
+
+// GetDetailedMonitoringPrice implements Provider
+func (o *Overrides) GetDetailedMonitoringPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetDetailedMonitoringPrice(ctx, region)
+	return o.discounted(price), err
+}
+
+// GetEBSOptimizedPrice implements Provider
+func (o *Overrides) GetEBSOptimizedPrice(ctx context.Context, region, instanceType string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetEBSOptimizedPrice(ctx, region, instanceType)
+	return o.discounted(price), err
+}
+
+// GetDedicatedHostPrice implements Provider
+func (o *Overrides) GetDedicatedHostPrice(ctx context.Context, region, family string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetDedicatedHostPrice(ctx, region, family)
+	return o.discounted(price), err
+}
+
+// GetKinesisStreamPrice implements Provider
+func (o *Overrides) GetKinesisStreamPrice(ctx context.Context, region string, onDemand bool) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetKinesisStreamPrice(ctx, region, onDemand)
+	return o.discounted(price), err
+}
+
+// GetFirehosePrice implements Provider
+func (o *Overrides) GetFirehosePrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetFirehosePrice(ctx, region)
+	return o.discounted(price), err
+}
+
+// GetEBSPrice implements Provider
+func (o *Overrides) GetEBSPrice(ctx context.Context, region, volumeType string, sizeGiB, iops, throughput int32) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetEBSPrice(ctx, region, volumeType, sizeGiB, iops, throughput)
+	return o.discounted(price), err
+}
+
+// GetEBSCostComponents implements Provider
+func (o *Overrides) GetEBSCostComponents(ctx context.Context, region, volumeType string, sizeGiB, iops, throughput int32) ([]cogtypes.CostComponent, error) {
+	components, err := o.Provider.GetEBSCostComponents(ctx, region, volumeType, sizeGiB, iops, throughput)
+	discounted := make([]cogtypes.CostComponent, len(components))
+	for i, c := range components {
+		c.Rate = o.discounted(c.Rate)
+		c.HourlyCost = o.discounted(c.HourlyCost)
+		discounted[i] = c
+	}
+	return discounted, err
+}
+
+// GetRDSPrice implements Provider. A pinned rate is the single-AZ price;
+// Multi-AZ instances are billed for the standby too, at twice the rate.
+func (o *Overrides) GetRDSPrice(ctx context.Context, region, instanceClass, engine string, multiAZ bool) (cogtypes.CostValue, error) {
+	if rate, ok := o.pinned("rds", region, instanceClass); ok {
+		if multiAZ {
+			return 2 * rate, nil
+		}
+		return rate, nil
+	}
+	price, err := o.Provider.GetRDSPrice(ctx, region, instanceClass, engine, multiAZ)
+	return o.discounted(price), err
+}
+
+// GetRDSStoragePrice implements Provider
+func (o *Overrides) GetRDSStoragePrice(ctx context.Context, region, storageType string, multiAZ bool) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetRDSStoragePrice(ctx, region, storageType, multiAZ)
+	return o.discounted(price), err
+}
+
+// GetAuroraPrices implements Provider
+func (o *Overrides) GetAuroraPrices(ctx context.Context, region string) (storage, ioOptimizedStorage, perMillionIOs cogtypes.CostValue, err error) {
+	storage, ioOptimizedStorage, perMillionIOs, err = o.Provider.GetAuroraPrices(ctx, region)
+	return o.discounted(storage), o.discounted(ioOptimizedStorage), o.discounted(perMillionIOs), err
+}
+
+// GetECSPrice implements Provider
+func (o *Overrides) GetECSPrice(ctx context.Context, region, launchType string, runningCount int32) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetECSPrice(ctx, region, launchType, runningCount)
+	return o.discounted(price), err
+}
+
+// GetEKSPrice implements Provider
+func (o *Overrides) GetEKSPrice(ctx context.Context, region string, extendedSupport bool) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetEKSPrice(ctx, region, extendedSupport)
+	return o.discounted(price), err
+}
+
+// GetELBPrice implements Provider
+func (o *Overrides) GetELBPrice(ctx context.Context, region, lbType string) (base, perLCU cogtypes.CostValue, err error) {
+	base, perLCU, err = o.Provider.GetELBPrice(ctx, region, lbType)
+	return o.discounted(base), o.discounted(perLCU), err
+}
+
+// GetNATGatewayPrice implements Provider
+func (o *Overrides) GetNATGatewayPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetNATGatewayPrice(ctx, region)
+	return o.discounted(price), err
+}
+
+// GetElasticIPPrice implements Provider
+func (o *Overrides) GetElasticIPPrice(ctx context.Context, region string, inUse bool) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetElasticIPPrice(ctx, region, inUse)
+	return o.discounted(price), err
+}
+
+// GetSecretPrice implements Provider
+func (o *Overrides) GetSecretPrice(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetSecretPrice(ctx, region)
+	return o.discounted(price), err
+}
+
+// GetPublicIPv4Price implements Provider
+func (o *Overrides) GetPublicIPv4Price(ctx context.Context, region string) (cogtypes.CostValue, error) {
+	price, err := o.Provider.GetPublicIPv4Price(ctx, region)
+	return o.discounted(price), err
+}
+
+// GetLambdaPrice implements Provider
+func (o *Overrides) GetLambdaPrice(ctx context.Context, region, architecture string) (request, gbSecond cogtypes.CostValue, err error) {
+	request, gbSecond, err = o.Provider.GetLambdaPrice(ctx, region, architecture)
+	return o.discounted(request), o.discounted(gbSecond), err
+}
+
+// Annotate notes in the cost components of resp's AWS resources which were
+// priced at a pinned rate and which were discounted. Components are copied,
+// so cached resources aren't changed.
+func (o *Overrides) Annotate(resp *cogtypes.CostResponse) {
+	note := func(components []cogtypes.CostComponent, pinned func(cogtypes.CostComponent) bool) []cogtypes.CostComponent {
+		if len(components) == 0 {
+			return components
+		}
+		noted := make([]cogtypes.CostComponent, len(components))
+		for i, c := range components {
+			switch {
+			case pinned != nil && pinned(c):
+				c.Override = OverridePinnedRate
+			case o.discount > 0:
+				c.Override = o.discountNote()
+			}
+			noted[i] = c
+		}
+		return noted
+	}
+
+	for i := range resp.EC2Instances {
+		r := &resp.EC2Instances[i]
+		_, pinned := o.pinned("ec2", r.Region, r.InstanceType)
+		pinned = pinned && r.Tenancy == "default"
+		r.CostComponents = note(r.CostComponents, func(c cogtypes.CostComponent) bool { return pinned && c.Name == "compute" })
+	}
+	for i := range resp.RDSInstances {
+		r := &resp.RDSInstances[i]
+		_, pinned := o.pinned("rds", r.Region, r.InstanceClass)
+		r.CostComponents = note(r.CostComponents, func(c cogtypes.CostComponent) bool {
+			return pinned && (c.Name == "compute" || c.Name == "multi-az standby")
+		})
+	}
+	for i := range resp.CapacityReservations {
+		r := &resp.CapacityReservations[i]
+		_, pinned := o.pinned("ec2", r.Region, r.InstanceType)
+		pinned = pinned && r.Tenancy == "default"
+		r.CostComponents = note(r.CostComponents, func(cogtypes.CostComponent) bool { return pinned })
+	}
+	for i := range resp.EBSVolumes {
+		resp.EBSVolumes[i].CostComponents = note(resp.EBSVolumes[i].CostComponents, nil)
+	}
+	for i := range resp.ECSServices {
+		resp.ECSServices[i].CostComponents = note(resp.ECSServices[i].CostComponents, nil)
+	}
+	for i := range resp.EKSClusters {
+		resp.EKSClusters[i].CostComponents = note(resp.EKSClusters[i].CostComponents, nil)
+	}
+	for i := range resp.LoadBalancers {
+		resp.LoadBalancers[i].CostComponents = note(resp.LoadBalancers[i].CostComponents, nil)
+	}
+	for i := range resp.NATGateways {
+		resp.NATGateways[i].CostComponents = note(resp.NATGateways[i].CostComponents, nil)
+	}
+	for i := range resp.ElasticIPs {
+		resp.ElasticIPs[i].CostComponents = note(resp.ElasticIPs[i].CostComponents, nil)
+	}
+	for i := range resp.Secrets {
+		resp.Secrets[i].CostComponents = note(resp.Secrets[i].CostComponents, nil)
+	}
+	for i := range resp.PublicIPv4s {
+		resp.PublicIPv4s[i].CostComponents = note(resp.PublicIPv4s[i].CostComponents, nil)
+	}
+	for i := range resp.Lambdas {
+		resp.Lambdas[i].CostComponents = note(resp.Lambdas[i].CostComponents, nil)
+	}
+	for i := range resp.DedicatedHosts {
+		resp.DedicatedHosts[i].CostComponents = note(resp.DedicatedHosts[i].CostComponents, nil)
+	}
+	for i := range resp.KinesisStreams {
+		resp.KinesisStreams[i].CostComponents = note(resp.KinesisStreams[i].CostComponents, nil)
+	}
+	for i := range resp.FirehoseStreams {
+		resp.FirehoseStreams[i].CostComponents = note(resp.FirehoseStreams[i].CostComponents, nil)
+	}
+}
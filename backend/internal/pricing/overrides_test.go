@@ -0,0 +1,69 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestOverrides(t *testing.T) {
+	p := &AWSProvider{cache: newPriceCache(time.Hour, 100), seen: make(map[PriceKey]struct{})}
+	p.cache.set(PriceKey{Kind: KindEC2, Region: "us-east-1", Type: "m5.large"}.id(), []cogtypes.CostValue{0.096})
+	p.cache.set(PriceKey{Kind: KindEC2, Region: "us-east-1", Type: "c5.large"}.id(), []cogtypes.CostValue{0.085})
+	p.cache.set(PriceKey{Kind: KindNAT, Region: "us-east-1"}.id(), []cogtypes.CostValue{0.045})
+
+	if got := WithOverrides(p, config.PriceOverrideConfig{}); got != Provider(p) {
+		t.Fatal("empty overrides should return the provider unchanged")
+	}
+
+	prices := WithOverrides(p, config.PriceOverrideConfig{
+		DiscountPercent: 20,
+		Rates: []config.PinnedRate{
+			{Service: "ec2", SKU: "m5.large", Hourly: 0.07},
+			{Service: "ec2", SKU: "m5.large", Region: "us-east-1", Hourly: 0.06},
+			{Service: "rds", SKU: "db.r6g.large", Hourly: 0.2},
+		},
+	})
+	ctx := t.Context()
+	for _, tc := range []struct {
+		name  string
+		price func() (cogtypes.CostValue, error)
+		want  cogtypes.CostValue
+	}{
+		{"regional pinned rate", func() (cogtypes.CostValue, error) { return prices.GetEC2Price(ctx, "us-east-1", "m5.large") }, 0.06},
+		{"pinned rate in any region", func() (cogtypes.CostValue, error) {
+			return prices.GetEC2TenancyPrice(ctx, "eu-west-1", "m5.large", "default")
+		}, 0.07},
+		{"discounted list price", func() (cogtypes.CostValue, error) { return prices.GetEC2Price(ctx, "us-east-1", "c5.large") }, 0.068},
+		{"discounted other service", func() (cogtypes.CostValue, error) { return prices.GetNATGatewayPrice(ctx, "us-east-1") }, 0.036},
+		{"pinned Multi-AZ RDS", func() (cogtypes.CostValue, error) {
+			return prices.GetRDSPrice(ctx, "us-west-2", "db.r6g.large", "postgres", true)
+		}, 0.4},
+	} {
+		got, err := tc.price()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if diff := got - tc.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	cached := []cogtypes.CostComponent{cogtypes.HourlyComponent("compute", "hour", 1, 0.06), cogtypes.HourlyComponent("EBS optimization", "hour", 1, 0.02)}
+	resp := &cogtypes.CostResponse{
+		EC2Instances: []cogtypes.EC2Instance{{Region: "us-east-1", InstanceType: "m5.large", Tenancy: "default", CostComponents: cached}},
+		NATGateways:  []cogtypes.NATGateway{{Region: "us-east-1", CostComponents: []cogtypes.CostComponent{cogtypes.HourlyComponent("gateway", "hour", 1, 0.036)}}},
+	}
+	prices.(*Overrides).Annotate(resp)
+	if got := resp.EC2Instances[0].CostComponents; got[0].Override != OverridePinnedRate || got[1].Override != "20% discount" {
+		t.Fatalf("EC2 components = %+v", got)
+	}
+	if got := resp.NATGateways[0].CostComponents[0].Override; got != "20% discount" {
+		t.Fatalf("NAT gateway override = %q", got)
+	}
+	if cached[0].Override != "" {
+		t.Fatal("Annotate changed the cached components")
+	}
+}
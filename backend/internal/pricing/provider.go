@@ -11,17 +11,56 @@ type Provider interface {
 	// GetEC2Price returns the hourly on-demand price for an EC2 instance type in a region
 	GetEC2Price(ctx context.Context, region, instanceType string) (types.CostValue, error)
 
+	// GetEC2TenancyPrice returns the hourly on-demand price for an EC2 instance
+	// type with a placement tenancy of "default", "dedicated", or "host"
+	GetEC2TenancyPrice(ctx context.Context, region, instanceType, tenancy string) (types.CostValue, error)
+
+	// GetCPUCreditPrice returns the price per vCPU-hour of surplus CPU credits
+	// for a burstable instance family such as "t3"
+	GetCPUCreditPrice(ctx context.Context, region, family string) (types.CostValue, error)
+
+	// GetDetailedMonitoringPrice returns the monthly price of one CloudWatch
+	// metric, which EC2 detailed monitoring is billed by
+	GetDetailedMonitoringPrice(ctx context.Context, region string) (types.CostValue, error)
+
+	// GetEBSOptimizedPrice returns the hourly EBS-optimized surcharge for an
+	// instance type where EBS optimization is optional and billed
+	GetEBSOptimizedPrice(ctx context.Context, region, instanceType string) (types.CostValue, error)
+
+	// GetDedicatedHostPrice returns the hourly on-demand price of a Dedicated
+	// Host of an instance family such as "m5"
+	GetDedicatedHostPrice(ctx context.Context, region, family string) (types.CostValue, error)
+
+	// GetKinesisStreamPrice returns the hourly price of a provisioned Kinesis
+	// shard, or of an on-demand stream
+	GetKinesisStreamPrice(ctx context.Context, region string, onDemand bool) (types.CostValue, error)
+
+	// GetFirehosePrice returns the price per GB ingested into Firehose
+	GetFirehosePrice(ctx context.Context, region string) (types.CostValue, error)
+
 	// GetEBSPrice returns the hourly price for an EBS volume
 	GetEBSPrice(ctx context.Context, region, volumeType string, sizeGiB, iops, throughput int32) (types.CostValue, error)
 
+	// GetEBSCostComponents returns the storage, IOPS, and throughput costs of an EBS volume
+	GetEBSCostComponents(ctx context.Context, region, volumeType string, sizeGiB, iops, throughput int32) ([]types.CostComponent, error)
+
 	// GetRDSPrice returns the hourly on-demand price for an RDS instance
 	GetRDSPrice(ctx context.Context, region, instanceClass, engine string, multiAZ bool) (types.CostValue, error)
 
+	// GetRDSStoragePrice returns the per GB-month price of RDS instance storage
+	GetRDSStoragePrice(ctx context.Context, region, storageType string, multiAZ bool) (types.CostValue, error)
+
+	// GetAuroraPrices returns the per GB-month price of Aurora Standard and
+	// I/O-Optimized cluster storage, and the Aurora Standard price per million
+	// I/O requests
+	GetAuroraPrices(ctx context.Context, region string) (storage, ioOptimizedStorage, perMillionIOs types.CostValue, err error)
+
 	// GetECSPrice returns the hourly price for an ECS Fargate service
 	GetECSPrice(ctx context.Context, region, launchType string, runningCount int32) (types.CostValue, error)
 
-	// GetEKSPrice returns the hourly price for an EKS cluster control plane
-	GetEKSPrice(ctx context.Context, region string) (types.CostValue, error)
+	// GetEKSPrice returns the hourly price for an EKS cluster control plane at
+	// the standard or extended support rate
+	GetEKSPrice(ctx context.Context, region string, extendedSupport bool) (types.CostValue, error)
 
 	// GetELBPrice returns the base hourly price and per-LCU/NLCU price for a load balancer
 	GetELBPrice(ctx context.Context, region, lbType string) (base, perLCU types.CostValue, err error)
@@ -29,9 +68,9 @@ type Provider interface {
 	// GetNATGatewayPrice returns the hourly price for a NAT Gateway
 	GetNATGatewayPrice(ctx context.Context, region string) (types.CostValue, error)
 
-	// GetElasticIPPrice returns the hourly price for an Elastic IP
-	// isAssociated indicates if the EIP is attached to a running instance
-	GetElasticIPPrice(ctx context.Context, region string, isAssociated bool) (types.CostValue, error)
+	// GetElasticIPPrice returns the hourly price for an Elastic IP at the in-use
+	// or idle public IPv4 rate
+	GetElasticIPPrice(ctx context.Context, region string, inUse bool) (types.CostValue, error)
 
 	// GetSecretPrice returns the hourly price for a Secrets Manager secret
 	GetSecretPrice(ctx context.Context, region string) (types.CostValue, error)
@@ -44,4 +83,7 @@ type Provider interface {
 
 	// RefreshCache forces a refresh of the pricing cache
 	RefreshCache(ctx context.Context) error
+
+	// CacheStats returns price cache hit, miss, and entry counts
+	CacheStats() CacheStats
 }
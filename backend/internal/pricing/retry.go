@@ -0,0 +1,165 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// maxRetryBackoff caps the wait between retries of a failed price lookup
+const maxRetryBackoff = time.Hour
+
+// pendingRetry is a failed price lookup waiting to be retried
+type pendingRetry struct {
+	attempts int       // Retries made so far
+	next     time.Time // When the next retry is due
+}
+
+// throttleChecker recognizes the error codes AWS throttles requests with
+var throttleChecker = retry.IsErrorThrottles(retry.DefaultThrottles)
+
+// retryable reports whether a failed fetch may succeed later: the Pricing
+// API throttled it or failed with a server error. Lookups with no matching
+// price, and cancelled ones, aren't retried.
+func retryable(err error) bool {
+	if throttleChecker.IsErrorThrottle(err).Bool() {
+		return true
+	}
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() >= 500
+}
+
+// queueRetry records a lookup that failed with err so the Retrier fetches it
+// again, if there is a Retrier and err is retryable. Lookups already queued
+// keep their schedule.
+func (p *AWSProvider) queueRetry(key PriceKey, err error) {
+	if !retryable(err) {
+		return
+	}
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	if p.retries == nil {
+		return
+	}
+	if _, ok := p.retries[key]; !ok {
+		p.retries[key] = &pendingRetry{next: time.Now()}
+	}
+}
+
+// dequeueRetry forgets a queued lookup once its price has been fetched
+func (p *AWSProvider) dequeueRetry(key PriceKey) {
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	delete(p.retries, key)
+}
+
+// RetryFailed fetches every queued lookup that's due again, unless the
+// warmer already has. A lookup that fails waits interval, doubling with each
+// attempt up to an hour, and is dropped after maxAttempts retries until a
+// scan's lookup fails again. It returns the lookups that now have a price.
+func (p *AWSProvider) RetryFailed(ctx context.Context, interval time.Duration, maxAttempts int) []PriceKey {
+	now := time.Now()
+	p.retryMu.Lock()
+	var due []PriceKey
+	for key, r := range p.retries {
+		switch {
+		case r.attempts >= maxAttempts:
+			delete(p.retries, key)
+		case !now.Before(r.next):
+			due = append(due, key)
+		}
+	}
+	p.retryMu.Unlock()
+
+	var resolved []PriceKey
+	for _, key := range due {
+		var err error
+		if _, _, cached := p.cache.get(key.id()); !cached {
+			err = p.refresh(ctx, key)
+		}
+		if ctx.Err() != nil {
+			return resolved
+		}
+		p.retryMu.Lock()
+		r, ok := p.retries[key]
+		switch {
+		case !ok:
+		case err == nil:
+			delete(p.retries, key)
+			resolved = append(resolved, key)
+		case r.attempts+1 >= maxAttempts || !retryable(err):
+			delete(p.retries, key)
+		default:
+			r.attempts++
+			r.next = time.Now().Add(min(interval<<(r.attempts-1), maxRetryBackoff))
+		}
+		p.retryMu.Unlock()
+	}
+	return resolved
+}
+
+// Retrier retries failed price lookups in the background, so resources
+// priced at zero because the Pricing API was throttled or unavailable get
+// their prices without waiting for prices to be looked up again
+type Retrier struct {
+	provider    *AWSProvider
+	interval    time.Duration
+	maxAttempts int
+	logger      *slog.Logger
+
+	mu        sync.Mutex
+	listeners []func([]PriceKey)
+}
+
+// NewRetrier creates a retrier that checks for due lookups every interval and
+// retries each one up to maxAttempts times
+func NewRetrier(provider *AWSProvider, interval time.Duration, maxAttempts int, logger *slog.Logger) *Retrier {
+	provider.retryMu.Lock()
+	if provider.retries == nil {
+		provider.retries = make(map[PriceKey]*pendingRetry)
+	}
+	provider.retryMu.Unlock()
+	return &Retrier{provider: provider, interval: interval, maxAttempts: maxAttempts, logger: logger}
+}
+
+// OnResolved registers fn to be called with the lookups each round of
+// retries resolved
+func (r *Retrier) OnResolved(fn func([]PriceKey)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Run retries due lookups every interval until ctx is cancelled
+func (r *Retrier) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.retry(ctx)
+	}
+}
+
+func (r *Retrier) retry(ctx context.Context) {
+	resolved := r.provider.RetryFailed(ctx, r.interval, r.maxAttempts)
+	if len(resolved) == 0 {
+		return
+	}
+	r.logger.Info("resolved failed price lookups", "prices", len(resolved))
+
+	r.mu.Lock()
+	listeners := r.listeners
+	r.mu.Unlock()
+	for _, fn := range listeners {
+		fn(resolved)
+	}
+}
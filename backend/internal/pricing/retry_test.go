@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// errThrottled is a fetch error the Retrier retries
+var errThrottled = &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+
+func TestRetryFailedResolvesCachedLookups(t *testing.T) {
+	p := &AWSProvider{cache: newPriceCache(time.Hour, 100), seen: make(map[PriceKey]struct{})}
+	NewRetrier(p, time.Minute, 3, nil)
+	warmed := PriceKey{Kind: KindEC2, Region: "us-east-1", Type: "m5.large"}
+	fetched := PriceKey{Kind: KindEBS, Region: "us-east-1", Type: "gp3"}
+	p.queueRetry(warmed, errThrottled)
+	p.queueRetry(fetched, errThrottled)
+	p.queueRetry(warmed, errThrottled)
+
+	// A scan fetched one price, and the warmer the other, before the retry
+	p.cache.set(fetched.id(), []cogtypes.CostValue{0.08})
+	p.dequeueRetry(fetched)
+	p.cache.set(warmed.id(), []cogtypes.CostValue{0.096})
+
+	resolved := p.RetryFailed(context.Background(), time.Minute, 3)
+	if len(resolved) != 1 || resolved[0] != warmed {
+		t.Fatalf("resolved = %+v, want only %+v", resolved, warmed)
+	}
+	if len(p.retries) != 0 {
+		t.Fatalf("expected the queue to be empty, got %+v", p.retries)
+	}
+	if again := p.RetryFailed(context.Background(), time.Minute, 3); len(again) != 0 {
+		t.Fatalf("expected nothing left to retry, got %+v", again)
+	}
+}
+
+func TestRetryFailedSkipsLookupsNotDue(t *testing.T) {
+	p := &AWSProvider{cache: newPriceCache(time.Hour, 100), seen: make(map[PriceKey]struct{})}
+	waiting := PriceKey{Kind: KindNAT, Region: "eu-west-1"}
+	exhausted := PriceKey{Kind: KindEIP, Region: "eu-west-1"}
+	p.retries = map[PriceKey]*pendingRetry{
+		waiting:   {attempts: 1, next: time.Now().Add(time.Hour)},
+		exhausted: {attempts: 3, next: time.Now()},
+	}
+	p.cache.set(waiting.id(), []cogtypes.CostValue{0.045})
+	p.cache.set(exhausted.id(), []cogtypes.CostValue{0.045})
+
+	if resolved := p.RetryFailed(context.Background(), time.Minute, 3); len(resolved) != 0 {
+		t.Fatalf("resolved = %+v, want none", resolved)
+	}
+	if _, ok := p.retries[waiting]; !ok || len(p.retries) != 1 {
+		t.Fatalf("expected only the lookup that's not due to stay queued, got %+v", p.retries)
+	}
+}
+
+func TestQueueRetryOnlyQueuesRetryableErrors(t *testing.T) {
+	key := PriceKey{Kind: KindNAT, Region: "eu-west-1"}
+	serverError := &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+		Err:      errors.New("unavailable"),
+	}}
+
+	p := &AWSProvider{}
+	p.queueRetry(key, errThrottled)
+	if p.retries != nil {
+		t.Fatalf("expected nothing queued without a Retrier, got %+v", p.retries)
+	}
+
+	NewRetrier(p, time.Minute, 3, nil)
+	for _, err := range []error{
+		errors.New("no NAT Gateway pricing found in eu-west-1"),
+		fmt.Errorf("rate limit: %w", context.Canceled),
+		&smithy.GenericAPIError{Code: "InvalidParameterException"},
+	} {
+		p.queueRetry(key, err)
+		if len(p.retries) != 0 {
+			t.Fatalf("queued a lookup that failed with %v", err)
+		}
+	}
+	for _, err := range []error{errThrottled, fmt.Errorf("GetProducts for NAT: %w", serverError)} {
+		p.retries = make(map[PriceKey]*pendingRetry)
+		p.queueRetry(key, err)
+		if len(p.retries) != 1 {
+			t.Fatalf("didn't queue a lookup that failed with %v", err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// AWSPriceSource adapts a Provider to the cloud.PriceSource interface. AWS
+// discovery looks up on-demand hourly prices through it.
+type AWSPriceSource struct {
+	provider Provider
+}
+
+// NewAWSPriceSource creates a cloud.PriceSource backed by provider
+func NewAWSPriceSource(provider Provider) *AWSPriceSource {
+	return &AWSPriceSource{provider: provider}
+}
+
+// Provider implements cloud.PriceSource
+func (s *AWSPriceSource) Provider() string {
+	return "aws"
+}
+
+// HourlyPrice implements cloud.PriceSource. Service is a resource type filter
+// name; SKU is the instance type or class where one applies. EC2 reads the
+// "tenancy" attribute, EKS reads "extendedSupport", RDS reads the
+// "engine" and "multiAz" attributes, ELB reads SKU as the load balancer type
+// and returns its base hourly price.
+func (s *AWSPriceSource) HourlyPrice(ctx context.Context, q cloud.PriceQuery) (types.CostValue, error) {
+	switch q.Service {
+	case "ec2":
+		if tenancy := q.Attributes["tenancy"]; tenancy != "" {
+			return s.provider.GetEC2TenancyPrice(ctx, q.Region, q.SKU, tenancy)
+		}
+		return s.provider.GetEC2Price(ctx, q.Region, q.SKU)
+	case "rds":
+		return s.provider.GetRDSPrice(ctx, q.Region, q.SKU, q.Attributes["engine"], q.Attributes["multiAz"] == "true")
+	case "eks":
+		return s.provider.GetEKSPrice(ctx, q.Region, q.Attributes["extendedSupport"] == "true")
+	case "elb":
+		base, _, err := s.provider.GetELBPrice(ctx, q.Region, q.SKU)
+		return base, err
+	case "nat":
+		return s.provider.GetNATGatewayPrice(ctx, q.Region)
+	case "secrets":
+		return s.provider.GetSecretPrice(ctx, q.Region)
+	case "publicipv4":
+		return s.provider.GetPublicIPv4Price(ctx, q.Region)
+	default:
+		return 0, fmt.Errorf("unsupported AWS price service: %s", q.Service)
+	}
+}
@@ -0,0 +1,33 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/cloud"
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestAWSPriceSource(t *testing.T) {
+	p := &AWSProvider{cache: newPriceCache(time.Hour, 100), seen: make(map[PriceKey]struct{})}
+	p.cache.set(PriceKey{Kind: KindEC2, Region: "us-east-1", Type: "m5.large"}.id(), []cogtypes.CostValue{0.096})
+	p.cache.set(PriceKey{Kind: KindNAT, Region: "us-east-1"}.id(), []cogtypes.CostValue{0.045})
+	source := NewAWSPriceSource(p)
+
+	ctx := t.Context()
+	for _, tc := range []struct {
+		query cloud.PriceQuery
+		want  cogtypes.CostValue
+	}{
+		{cloud.PriceQuery{Service: "ec2", Region: "us-east-1", SKU: "m5.large", Attributes: map[string]string{"tenancy": "default"}}, 0.096},
+		{cloud.PriceQuery{Service: "nat", Region: "us-east-1"}, 0.045},
+	} {
+		got, err := source.HourlyPrice(ctx, tc.query)
+		if err != nil || got != tc.want {
+			t.Errorf("%s = %v, %v; want %v", tc.query.Service, got, err, tc.want)
+		}
+	}
+	if _, err := source.HourlyPrice(ctx, cloud.PriceQuery{Service: "sqs"}); err == nil {
+		t.Error("expected an error for a service without an hourly price")
+	}
+}
@@ -0,0 +1,111 @@
+package pricing
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheStats describes how well the price cache is working
+type CacheStats struct {
+	Hits       int64               `json:"hits"`
+	Misses     int64               `json:"misses"`
+	HitRate    float64             `json:"hitRate"` // Hits as a fraction of lookups (0 before any lookup)
+	Entries    int                 `json:"entries"`
+	MaxEntries int                 `json:"maxEntries"` // 0 = unlimited
+	Evictions  int64               `json:"evictions"`  // Prices dropped because the cache was full
+	TTLMinutes int                 `json:"ttlMinutes"`
+	ExpiresAt  string              `json:"expiresAt,omitempty"` // When the next cached price expires (empty = nothing cached)
+	Services   []ServiceCacheStats `json:"services"`
+}
+
+// ServiceCacheStats is the cache usage of one price lookup kind
+type ServiceCacheStats struct {
+	Service   string `json:"service"` // Price lookup kind, e.g. ec2 or rds-storage
+	Hits      int64  `json:"hits"`
+	Misses    int64  `json:"misses"`
+	Entries   int    `json:"entries"`
+	ExpiresAt string `json:"expiresAt,omitempty"` // When the kind's next cached price expires
+}
+
+// cacheCounts are the hits and misses of one price lookup kind
+type cacheCounts struct {
+	hits, misses int64
+}
+
+// record counts a price lookup served from the cache (hit) or fetched from
+// the Pricing API (miss)
+func (p *AWSProvider) record(kind string, hit bool) {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+
+	if p.counts == nil {
+		p.counts = make(map[string]*cacheCounts)
+	}
+	c, ok := p.counts[kind]
+	if !ok {
+		c = &cacheCounts{}
+		p.counts[kind] = c
+	}
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// CacheStats returns hit and miss counts since startup, and the number of
+// cached prices and next expiry for each price lookup kind
+func (p *AWSProvider) CacheStats() CacheStats {
+	entries, evictions, maxEntries := p.cache.snapshot()
+	stats := CacheStats{
+		Entries:    len(entries),
+		MaxEntries: maxEntries,
+		Evictions:  evictions,
+		TTLMinutes: int(p.cache.ttl / time.Minute),
+		Services:   []ServiceCacheStats{},
+	}
+
+	services := make(map[string]*ServiceCacheStats)
+	service := func(kind string) *ServiceCacheStats {
+		svc, ok := services[kind]
+		if !ok {
+			svc = &ServiceCacheStats{Service: kind}
+			services[kind] = svc
+		}
+		return svc
+	}
+	var next time.Time
+	for _, e := range entries {
+		kind, _, _ := strings.Cut(e.key, ":")
+		svc := service(kind)
+		svc.Entries++
+		if expires := e.expires.UTC().Format(time.RFC3339); svc.ExpiresAt == "" || expires < svc.ExpiresAt {
+			svc.ExpiresAt = expires
+		}
+		if next.IsZero() || e.expires.Before(next) {
+			next = e.expires
+		}
+	}
+	if !next.IsZero() {
+		stats.ExpiresAt = next.UTC().Format(time.RFC3339)
+	}
+
+	p.statsMu.Lock()
+	for kind, c := range p.counts {
+		svc := service(kind)
+		svc.Hits, svc.Misses = c.hits, c.misses
+		stats.Hits += c.hits
+		stats.Misses += c.misses
+	}
+	p.statsMu.Unlock()
+
+	for _, svc := range services {
+		stats.Services = append(stats.Services, *svc)
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	sort.Slice(stats.Services, func(i, j int) bool { return stats.Services[i].Service < stats.Services[j].Service })
+	return stats
+}
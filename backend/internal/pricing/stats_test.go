@@ -0,0 +1,38 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestCacheStatsCountsHitsAndMisses(t *testing.T) {
+	p := &AWSProvider{cache: newPriceCache(time.Hour, 100), seen: make(map[PriceKey]struct{})}
+	ec2 := PriceKey{Kind: KindEC2, Region: "us-east-1", Type: "m5.large"}
+	p.cache.set(ec2.id(), []cogtypes.CostValue{0.096})
+	p.cache.set(PriceKey{Kind: KindEBS, Region: "us-east-1", Type: "gp3"}.id(), []cogtypes.CostValue{0.08, 0.005, 0.04})
+	p.record(KindEC2, false)
+
+	for range 2 {
+		if price, err := p.GetEC2Price(t.Context(), "us-east-1", "m5.large"); err != nil || price != 0.096 {
+			t.Fatalf("GetEC2Price() = %v, %v", price, err)
+		}
+	}
+
+	stats := p.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 2 || stats.MaxEntries != 100 || stats.TTLMinutes != 60 || stats.ExpiresAt == "" {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if len(stats.Services) != 2 || stats.Services[0].Service != KindEBS || stats.Services[0].Entries != 1 || stats.Services[1].Hits != 2 {
+		t.Fatalf("services = %+v", stats.Services)
+	}
+
+	if err := p.RefreshCache(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	stats = p.CacheStats()
+	if stats.Entries != 0 || stats.ExpiresAt != "" || stats.Hits != 2 {
+		t.Fatalf("expected refresh to empty the cache but keep counts, got %+v", stats)
+	}
+}
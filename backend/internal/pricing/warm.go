@@ -0,0 +1,248 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Price lookup kinds recorded for pre-warming
+const (
+	KindEC2          = "ec2"
+	KindEBS          = "ebs"
+	KindECS          = "ecs"
+	KindRDS          = "rds"
+	KindRDSStorage   = "rds-storage"
+	KindAurora       = "aurora"
+	KindEKS          = "eks"
+	KindELB          = "elb"
+	KindNAT          = "nat"
+	KindEIP          = "eip"
+	KindSecret       = "secret"
+	KindPublicIPv4   = "publicipv4"
+	KindLambda       = "lambda"
+	KindCPUCredits   = "cpu-credits"
+	KindMonitoring   = "ec2-monitoring"
+	KindEBSOptimized = "ebs-optimized"
+	KindHost         = "dedicated-host"
+	KindKinesis      = "kinesis"
+	KindFirehose     = "firehose"
+)
+
+// PriceKey identifies one price lookup, e.g. an instance type in a region
+type PriceKey struct {
+	Kind   string `json:"kind"`
+	Region string `json:"region"`
+	// Type is the instance type, volume type, instance class, storage type,
+	// launch type, load balancer type, Lambda architecture, burstable
+	// instance family, Dedicated Host family, or Kinesis capacity mode
+	Type    string `json:"type,omitempty"`
+	Engine  string `json:"engine,omitempty"`
+	MultiAZ bool   `json:"multiAZ,omitempty"`
+	// Tenancy is the EC2 Pricing API tenancy, empty for shared
+	Tenancy string `json:"tenancy,omitempty"`
+}
+
+// cacheKey returns the key the price is cached under
+func (k PriceKey) cacheKey() string {
+	switch k.Kind {
+	case KindRDS:
+		return fmt.Sprintf("%s:%s:%s:%t", k.Region, k.Type, k.Engine, k.MultiAZ)
+	case KindRDSStorage:
+		return fmt.Sprintf("%s:%s:%t", k.Region, k.Type, k.MultiAZ)
+	case KindEC2:
+		if k.Tenancy != "" {
+			return k.Region + ":" + k.Type + ":" + k.Tenancy
+		}
+	}
+	if k.Type == "" {
+		return k.Region
+	}
+	return k.Region + ":" + k.Type
+}
+
+// id returns the key the lookup's prices are cached under, e.g.
+// "ec2:us-east-1:m5.large"
+func (k PriceKey) id() string {
+	return k.Kind + ":" + k.cacheKey()
+}
+
+// remember records a price lookup so it can be pre-warmed
+func (p *AWSProvider) remember(key PriceKey) {
+	p.seenMu.Lock()
+	defer p.seenMu.Unlock()
+	if _, ok := p.seen[key]; !ok {
+		p.seen[key] = struct{}{}
+		p.seenChanged = true
+	}
+}
+
+// WarmKeys returns every price lookup seen so far, sorted
+func (p *AWSProvider) WarmKeys() []PriceKey {
+	p.seenMu.Lock()
+	keys := make([]PriceKey, 0, len(p.seen))
+	for key := range p.seen {
+		keys = append(keys, key)
+	}
+	p.seenMu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.cacheKey() < b.cacheKey()
+	})
+	return keys
+}
+
+// Prewarm fetches current prices for every price lookup seen so far,
+// restarting each one's expiry. Prices that fail to refresh keep their
+// previous value until it expires. Scans keep reading the existing cache
+// while it runs.
+func (p *AWSProvider) Prewarm(ctx context.Context) error {
+	var errs []error
+	for _, key := range p.WarmKeys() {
+		if err := p.refresh(ctx, key); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s %s: %w", key.Kind, key.cacheKey(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// refresh fetches the prices for key and stores them in the cache
+func (p *AWSProvider) refresh(ctx context.Context, key PriceKey) error {
+	prices, err := p.fetch(ctx, key)
+	if err != nil {
+		return err
+	}
+	p.cache.set(key.id(), prices)
+	return nil
+}
+
+// LoadWarmKeys reads price lookups saved by SaveWarmKeys. A missing file
+// yields no keys.
+func LoadWarmKeys(path string) ([]PriceKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var keys []PriceKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// SaveWarmKeys writes price lookups to path, replacing it atomically
+func SaveWarmKeys(path string, keys []PriceKey) error {
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding price keys: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("renaming %s: %w", tmp, err)
+	}
+	return nil
+}
+
+// AddWarmKeys records previously seen price lookups, e.g. loaded from disk
+func (p *AWSProvider) AddWarmKeys(keys []PriceKey) {
+	p.seenMu.Lock()
+	defer p.seenMu.Unlock()
+	for _, key := range keys {
+		p.seen[key] = struct{}{}
+	}
+}
+
+// takeWarmKeysChanged reports whether new price lookups were seen since the
+// last call
+func (p *AWSProvider) takeWarmKeysChanged() bool {
+	p.seenMu.Lock()
+	defer p.seenMu.Unlock()
+	changed := p.seenChanged
+	p.seenChanged = false
+	return changed
+}
+
+// Warmer keeps the pricing cache warm for every price lookup awsCOGS has made,
+// so scans don't wait on the Pricing API
+type Warmer struct {
+	provider *AWSProvider
+	path     string // Where seen price lookups are persisted (empty = memory only)
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewWarmer creates a warmer that re-fetches prices every interval
+func NewWarmer(provider *AWSProvider, path string, interval time.Duration, logger *slog.Logger) *Warmer {
+	return &Warmer{provider: provider, path: path, interval: interval, logger: logger}
+}
+
+// Run loads persisted price lookups, pre-warms them, and then re-warms every
+// interval until ctx is cancelled
+func (w *Warmer) Run(ctx context.Context) {
+	if w.path != "" {
+		keys, err := LoadWarmKeys(w.path)
+		if err != nil {
+			w.logger.Warn("failed to load pricing warm keys", "error", err)
+		}
+		w.provider.AddWarmKeys(keys)
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.warm(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Warmer) warm(ctx context.Context) {
+	keys := len(w.provider.WarmKeys())
+	if keys == 0 {
+		return
+	}
+	start := time.Now()
+	if err := w.provider.Prewarm(ctx); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		w.logger.Warn("some prices failed to pre-warm", "error", err)
+	}
+	w.logger.Info("pricing cache pre-warmed", "prices", keys, "duration", time.Since(start).Round(time.Millisecond))
+}
+
+// Save persists newly seen price lookups. It is meant to be registered with
+// snapshot.Store.Subscribe, so lookups are saved after each scan.
+func (w *Warmer) Save() {
+	if w.path == "" || !w.provider.takeWarmKeysChanged() {
+		return
+	}
+	if err := SaveWarmKeys(w.path, w.provider.WarmKeys()); err != nil {
+		w.logger.Warn("failed to save pricing warm keys", "error", err)
+	}
+}
@@ -0,0 +1,93 @@
+package pricing
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	cogtypes "github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestPriceKeyCacheKey(t *testing.T) {
+	tests := []struct {
+		key  PriceKey
+		want string
+	}{
+		{PriceKey{Kind: KindEC2, Region: "us-east-1", Type: "m5.large"}, "us-east-1:m5.large"},
+		{PriceKey{Kind: KindEC2, Region: "us-east-1", Type: "m5.large", Tenancy: "Dedicated"}, "us-east-1:m5.large:Dedicated"},
+		{PriceKey{Kind: KindCPUCredits, Region: "us-east-1", Type: "t3"}, "us-east-1:t3"},
+		{PriceKey{Kind: KindRDS, Region: "us-east-1", Type: "db.r6g.large", Engine: "postgres", MultiAZ: true}, "us-east-1:db.r6g.large:postgres:true"},
+		{PriceKey{Kind: KindRDSStorage, Region: "us-east-1", Type: "gp3"}, "us-east-1:gp3:false"},
+		{PriceKey{Kind: KindNAT, Region: "eu-west-1"}, "eu-west-1"},
+	}
+	for _, tt := range tests {
+		if got := tt.key.cacheKey(); got != tt.want {
+			t.Fatalf("cacheKey(%+v) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestWarmKeysRoundTrip(t *testing.T) {
+	p := &AWSProvider{seen: make(map[PriceKey]struct{})}
+	p.remember(PriceKey{Kind: KindEC2, Region: "us-west-2", Type: "t3.micro"})
+	p.remember(PriceKey{Kind: KindEBS, Region: "us-east-1", Type: "gp3"})
+	p.remember(PriceKey{Kind: KindEC2, Region: "us-west-2", Type: "t3.micro"})
+
+	if !p.takeWarmKeysChanged() {
+		t.Fatalf("expected new keys to be reported")
+	}
+	if p.takeWarmKeysChanged() {
+		t.Fatalf("expected change flag to reset")
+	}
+
+	keys := p.WarmKeys()
+	if len(keys) != 2 || keys[0].Kind != KindEBS || keys[1].Kind != KindEC2 {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	path := filepath.Join(t.TempDir(), "pricing", "warm.json")
+	if err := SaveWarmKeys(path, keys); err != nil {
+		t.Fatalf("SaveWarmKeys: %v", err)
+	}
+	loaded, err := LoadWarmKeys(path)
+	if err != nil {
+		t.Fatalf("LoadWarmKeys: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, keys) {
+		t.Fatalf("loaded %+v, want %+v", loaded, keys)
+	}
+
+	restored := &AWSProvider{seen: make(map[PriceKey]struct{})}
+	restored.AddWarmKeys(loaded)
+	if !reflect.DeepEqual(restored.WarmKeys(), keys) {
+		t.Fatalf("restored keys %+v, want %+v", restored.WarmKeys(), keys)
+	}
+	if restored.takeWarmKeysChanged() {
+		t.Fatalf("loaded keys should not need saving")
+	}
+}
+
+func TestLoadWarmKeysMissingFile(t *testing.T) {
+	keys, err := LoadWarmKeys(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || keys != nil {
+		t.Fatalf("LoadWarmKeys = %v, %v; want nil, nil", keys, err)
+	}
+}
+
+func TestLookupRemembersOnlyPricedKeys(t *testing.T) {
+	p := &AWSProvider{cache: newPriceCache(time.Hour, 100), seen: make(map[PriceKey]struct{})}
+	cached := PriceKey{Kind: KindNAT, Region: "eu-west-1"}
+	p.cache.set(cached.id(), []cogtypes.CostValue{0.045})
+
+	if _, err := p.lookup(context.Background(), cached); err != nil {
+		t.Fatalf("lookup() error = %v", err)
+	}
+	if _, err := p.lookup(context.Background(), PriceKey{Kind: KindNAT, Region: "nowhere-1"}); err == nil {
+		t.Fatal("expected an error for an unknown region")
+	}
+	if keys := p.WarmKeys(); len(keys) != 1 || keys[0] != cached {
+		t.Fatalf("warm keys = %+v, want only the priced lookup", keys)
+	}
+}
@@ -0,0 +1,185 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Aurora storage configurations, as DescribeDBClusters reports them
+const (
+	AuroraStandard    = "aurora"
+	AuroraIOOptimized = "aurora-iopt1"
+)
+
+// auroraIOOptimizedComputeFactor is how much more Aurora instances cost in an
+// I/O-Optimized cluster than in an Aurora Standard one
+const auroraIOOptimizedComputeFactor = 1.3
+
+// AuroraConfigCost is the estimated monthly cost of an Aurora cluster in one
+// storage configuration
+type AuroraConfigCost struct {
+	Compute types.CostValue `json:"compute"`
+	Storage types.CostValue `json:"storage"`
+	IO      types.CostValue `json:"io"`
+	Total   types.CostValue `json:"total"`
+}
+
+// AuroraIOComparison compares an Aurora cluster's cost under Aurora Standard
+// and I/O-Optimized storage
+type AuroraIOComparison struct {
+	AccountID      string           `json:"accountId"`
+	AccountName    string           `json:"accountName"`
+	Region         string           `json:"region"`
+	ClusterID      string           `json:"clusterId"`
+	Engine         string           `json:"engine"`
+	StorageType    string           `json:"storageType"` // Current configuration
+	Instances      int              `json:"instances"`
+	StorageGiB     float64          `json:"storageGiB"`
+	MonthlyIOs     float64          `json:"monthlyIOs"`     // Read and write I/Os projected from the usage window
+	IOSharePercent float64          `json:"ioSharePercent"` // I/O share of the Aurora Standard cost
+	Standard       AuroraConfigCost `json:"standard"`
+	IOOptimized    AuroraConfigCost `json:"ioOptimized"`
+	Recommended    string           `json:"recommended"` // The cheaper configuration
+	UsageWindow    string           `json:"usageWindow"`
+	UsageStatus    string           `json:"usageStatus,omitempty"`
+	Savings
+}
+
+// AuroraIOReport is the API response for the Aurora storage configuration
+// comparison
+type AuroraIOReport struct {
+	Timestamp           string               `json:"timestamp"`
+	Status              string               `json:"status"`
+	Diagnostics         []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Currency            string               `json:"currency"`
+	TotalHourlySavings  types.CostValue      `json:"totalHourlySavings"`
+	TotalMonthlySavings types.CostValue      `json:"totalMonthlySavings"`
+	Clusters            []AuroraIOComparison `json:"clusters"`
+	Filters             types.AppliedFilters `json:"filters"`
+}
+
+// AuroraIOSavings prices each Aurora cluster under both storage
+// configurations, from its recent I/O and storage usage and the compute cost
+// of its instances, and returns the clusters with the highest savings first.
+// Scanned instance prices are taken as Aurora Standard prices. Clusters whose
+// usage couldn't be read or whose storage couldn't be priced are reported as
+// diagnostics.
+func AuroraIOSavings(ctx context.Context, provider pricing.Provider, clusters []types.AuroraCluster, instances []types.RDSInstance) ([]AuroraIOComparison, []types.Diagnostic) {
+	type instanceKey struct{ accountID, region, id string }
+	compute := make(map[instanceKey]types.CostValue, len(instances))
+	for _, inst := range instances {
+		compute[instanceKey{inst.AccountID, inst.Region, inst.DBInstanceID}] = inst.HourlyCost
+	}
+
+	out := []AuroraIOComparison{}
+	var diagnostics []types.Diagnostic
+	for _, c := range clusters {
+		if c.UsageStatus == types.UsageStatusUnavailable {
+			diagnostics = append(diagnostics, types.Diagnostic{
+				Level:        "warning",
+				ResourceType: "rds",
+				AccountID:    c.AccountID,
+				AccountName:  c.AccountName,
+				Region:       c.Region,
+				Operation:    "usage",
+				ResourceID:   c.ClusterID,
+				Message:      c.UsageError,
+			})
+			continue
+		}
+		windowHours, err := usageWindowHours(c.UsageWindow)
+		if err != nil {
+			diagnostics = append(diagnostics, pricingDiagnostic("rds", c.AccountID, c.AccountName, c.Region, c.ClusterID, err))
+			continue
+		}
+		storagePrice, ioOptimizedStoragePrice, perMillionIOs, err := provider.GetAuroraPrices(ctx, c.Region)
+		if err != nil {
+			diagnostics = append(diagnostics, pricingDiagnostic("rds", c.AccountID, c.AccountName, c.Region, c.ClusterID, err))
+			continue
+		}
+
+		var hourlyCompute types.CostValue
+		for _, id := range c.Members {
+			hourlyCompute = hourlyCompute.Add(compute[instanceKey{c.AccountID, c.Region, id}])
+		}
+		monthlyIOs := (c.ReadIOs + c.WriteIOs) / windowHours * types.HoursPerMonth
+
+		standard := newAuroraConfigCost(hourlyCompute*types.HoursPerMonth, storagePrice*types.CostValue(c.StorageGiB), perMillionIOs*types.CostValue(monthlyIOs/1_000_000))
+		ioOptimized := newAuroraConfigCost(hourlyCompute*types.HoursPerMonth*auroraIOOptimizedComputeFactor, ioOptimizedStoragePrice*types.CostValue(c.StorageGiB), 0)
+
+		current, recommended := standard, AuroraStandard
+		if c.StorageType == AuroraIOOptimized {
+			current = ioOptimized
+		}
+		if ioOptimized.Total < standard.Total {
+			recommended = AuroraIOOptimized
+		}
+		proposed := standard
+		if recommended == AuroraIOOptimized {
+			proposed = ioOptimized
+		}
+
+		out = append(out, AuroraIOComparison{
+			AccountID:      c.AccountID,
+			AccountName:    c.AccountName,
+			Region:         c.Region,
+			ClusterID:      c.ClusterID,
+			Engine:         c.Engine,
+			StorageType:    c.StorageType,
+			Instances:      len(c.Members),
+			StorageGiB:     c.StorageGiB,
+			MonthlyIOs:     monthlyIOs,
+			IOSharePercent: Percent(float64(standard.IO), float64(standard.Total)),
+			Standard:       standard,
+			IOOptimized:    ioOptimized,
+			Recommended:    recommended,
+			UsageWindow:    c.UsageWindow,
+			UsageStatus:    c.UsageStatus,
+			Savings:        newSavings(current.Total/types.HoursPerMonth, proposed.Total/types.HoursPerMonth),
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].HourlySavings > out[j].HourlySavings })
+	return out, diagnostics
+}
+
+func newAuroraConfigCost(compute, storage, io types.CostValue) AuroraConfigCost {
+	return AuroraConfigCost{Compute: compute, Storage: storage, IO: io, Total: compute.Add(storage).Add(io)}
+}
+
+// usageWindowHours returns the hours in a usage window such as "14d" or "24h"
+func usageWindowHours(window string) (float64, error) {
+	if days, ok := strings.CutSuffix(window, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return float64(n) * 24, nil
+		}
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return 0, errors.New("invalid usage window: " + window)
+	}
+	return d.Hours(), nil
+}
+
+// NewAuroraIOReport totals the savings of clusters
+func NewAuroraIOReport(clusters []AuroraIOComparison, filters types.AppliedFilters) *AuroraIOReport {
+	report := &AuroraIOReport{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    types.ResponseStatusOK,
+		Currency:  "USD",
+		Clusters:  clusters,
+		Filters:   filters,
+	}
+	for _, c := range clusters {
+		report.TotalHourlySavings = report.TotalHourlySavings.Add(c.HourlySavings)
+		report.TotalMonthlySavings = report.TotalMonthlySavings.Add(c.MonthlySavings)
+	}
+	return report
+}
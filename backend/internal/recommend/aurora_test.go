@@ -0,0 +1,67 @@
+package recommend
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// auroraProvider prices Aurora Standard storage at $0.10/GB-month,
+// I/O-Optimized storage at $0.225/GB-month, and I/O at $0.20 per million
+type auroraProvider struct {
+	pricing.Provider
+}
+
+func (auroraProvider) GetAuroraPrices(context.Context, string) (types.CostValue, types.CostValue, types.CostValue, error) {
+	return 0.10, 0.225, 0.20, nil
+}
+
+func TestAuroraIOSavings(t *testing.T) {
+	instances := []types.RDSInstance{
+		{AccountID: "1", Region: "us-east-1", DBInstanceID: "busy-1", HourlyCost: 0.29},
+		{AccountID: "1", Region: "us-east-1", DBInstanceID: "quiet-1", HourlyCost: 0.29},
+	}
+	clusters := []types.AuroraCluster{
+		// 1M I/Os an hour: $146 a month of I/O outweighs the I/O-Optimized premium
+		{AccountID: "1", Region: "us-east-1", ClusterID: "busy", StorageType: AuroraStandard, Members: []string{"busy-1"},
+			StorageGiB: 100, ReadIOs: 300e6, WriteIOs: 36e6, UsageWindow: "14d", UsageStatus: types.UsageStatusOK},
+		// Already I/O-Optimized, with almost no I/O
+		{AccountID: "1", Region: "us-east-1", ClusterID: "quiet", StorageType: AuroraIOOptimized, Members: []string{"quiet-1"},
+			StorageGiB: 100, ReadIOs: 1e6, UsageWindow: "14d", UsageStatus: types.UsageStatusOK},
+		{AccountID: "1", Region: "us-east-1", ClusterID: "unknown", StorageType: AuroraStandard,
+			UsageWindow: "14d", UsageStatus: types.UsageStatusUnavailable, UsageError: "access denied"},
+	}
+
+	got, diagnostics := AuroraIOSavings(context.Background(), auroraProvider{}, clusters, instances)
+	if len(diagnostics) != 1 || diagnostics[0].ResourceID != "unknown" || diagnostics[0].Operation != "usage" {
+		t.Fatalf("diagnostics = %+v, want the unknown cluster's usage", diagnostics)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d clusters, want 2", len(got))
+	}
+	near := func(a, b types.CostValue) bool { return math.Abs(float64(a-b)) < 0.01 }
+
+	// Moving the quiet cluster back to Standard saves the most
+	quiet, busy := got[0], got[1]
+	if busy.ClusterID != "busy" || busy.Recommended != AuroraIOOptimized {
+		t.Fatalf("second = %s recommending %s, want busy recommending I/O-Optimized", busy.ClusterID, busy.Recommended)
+	}
+	if busy.MonthlyIOs != 730e6 || !near(busy.Standard.IO, 146) || busy.IOOptimized.IO != 0 {
+		t.Errorf("busy I/O = %v I/Os, standard $%v, optimized $%v", busy.MonthlyIOs, busy.Standard.IO, busy.IOOptimized.IO)
+	}
+	if !near(busy.Standard.Total, 367.7) || !near(busy.IOOptimized.Total, 297.71) || !near(busy.MonthlySavings, 69.99) {
+		t.Errorf("busy standard $%v, optimized $%v, saving $%v", busy.Standard.Total, busy.IOOptimized.Total, busy.MonthlySavings)
+	}
+
+	if quiet.ClusterID != "quiet" || quiet.Recommended != AuroraStandard || !near(quiet.MonthlySavings, quiet.IOOptimized.Total-quiet.Standard.Total) {
+		t.Errorf("quiet = %+v, want switching back to Standard", quiet)
+	}
+
+	report := NewAuroraIOReport(got, types.AppliedFilters{})
+	if !near(report.TotalMonthlySavings, busy.MonthlySavings+quiet.MonthlySavings) {
+		t.Errorf("TotalMonthlySavings = %v", report.TotalMonthlySavings)
+	}
+}
@@ -0,0 +1,120 @@
+package recommend
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// gp3 baseline performance, included in the storage price
+const (
+	gp3BaselineIOPS       = 3000
+	gp3BaselineThroughput = 125 // MiB/s
+	gp3MaxIOPS            = 16000
+)
+
+// GP3Volume is a gp2 volume with the cost of an equivalent gp3 volume
+type GP3Volume struct {
+	AccountID     string `json:"accountId"`
+	AccountName   string `json:"accountName"`
+	Region        string `json:"region"`
+	VolumeID      string `json:"volumeId"`
+	Name          string `json:"name"`
+	Size          int32  `json:"size"` // in GiB
+	GP2IOPS       int32  `json:"gp2Iops"`
+	GP2Throughput int32  `json:"gp2Throughput"` // Maximum gp2 throughput for the volume size, in MiB/s
+	GP3IOPS       int32  `json:"gp3Iops"`
+	GP3Throughput int32  `json:"gp3Throughput"` // in MiB/s
+	Savings
+}
+
+// GP3Report is the API response for the gp2 to gp3 migration calculator
+type GP3Report struct {
+	Timestamp           string               `json:"timestamp"`
+	Status              string               `json:"status"`
+	Diagnostics         []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Currency            string               `json:"currency"`
+	TotalHourlySavings  types.CostValue      `json:"totalHourlySavings"`
+	TotalMonthlySavings types.CostValue      `json:"totalMonthlySavings"`
+	Volumes             []GP3Volume          `json:"volumes"`
+	Filters             types.AppliedFilters `json:"filters"`
+}
+
+// gp2Throughput returns the maximum throughput of a gp2 volume in MiB/s.
+// Volumes of 170 GiB or less top out at 128 MiB/s; larger volumes reach
+// 250 MiB/s.
+func gp2Throughput(sizeGiB int32) int32 {
+	if sizeGiB <= 170 {
+		return 128
+	}
+	return 250
+}
+
+// gp3Equivalent returns the IOPS and throughput a gp3 volume needs to match a
+// gp2 volume's baseline performance
+func gp3Equivalent(gp2IOPS, gp2Throughput int32) (iops, throughput int32) {
+	iops = max(gp2IOPS, gp3BaselineIOPS)
+	iops = min(iops, gp3MaxIOPS)
+	throughput = max(gp2Throughput, gp3BaselineThroughput)
+	return iops, throughput
+}
+
+// GP3Savings prices every gp2 volume as gp3 with matching performance and
+// returns the volumes that would cost less, highest savings first. Volumes
+// that can't be priced are reported as diagnostics.
+func GP3Savings(ctx context.Context, provider pricing.Provider, volumes []types.EBSVolume) ([]GP3Volume, []types.Diagnostic) {
+	out := []GP3Volume{}
+	var diagnostics []types.Diagnostic
+	for _, vol := range volumes {
+		if vol.VolumeType != "gp2" {
+			continue
+		}
+		tp := gp2Throughput(vol.Size)
+		iops, throughput := gp3Equivalent(vol.IOPS, tp)
+		components, err := provider.GetEBSCostComponents(ctx, vol.Region, "gp3", vol.Size, iops, throughput)
+		if err != nil {
+			diagnostics = append(diagnostics, pricingDiagnostic("ebs", vol.AccountID, vol.AccountName, vol.Region, vol.VolumeID, err))
+			continue
+		}
+
+		savings := newSavings(vol.HourlyCost, types.SumComponents(components))
+		if savings.HourlySavings <= 0 {
+			continue
+		}
+		out = append(out, GP3Volume{
+			AccountID:     vol.AccountID,
+			AccountName:   vol.AccountName,
+			Region:        vol.Region,
+			VolumeID:      vol.VolumeID,
+			Name:          vol.Name,
+			Size:          vol.Size,
+			GP2IOPS:       vol.IOPS,
+			GP2Throughput: tp,
+			GP3IOPS:       iops,
+			GP3Throughput: throughput,
+			Savings:       savings,
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].HourlySavings > out[j].HourlySavings })
+	return out, diagnostics
+}
+
+// NewGP3Report totals the savings of volumes
+func NewGP3Report(volumes []GP3Volume, filters types.AppliedFilters) *GP3Report {
+	report := &GP3Report{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    types.ResponseStatusOK,
+		Currency:  "USD",
+		Volumes:   volumes,
+		Filters:   filters,
+	}
+	for _, v := range volumes {
+		report.TotalHourlySavings = report.TotalHourlySavings.Add(v.HourlySavings)
+		report.TotalMonthlySavings = report.TotalMonthlySavings.Add(v.MonthlySavings)
+	}
+	return report
+}
@@ -0,0 +1,85 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// fakeProvider prices gp3 storage at $0.08/GB-month plus $0.005 per IOPS and
+// $0.04 per MiB/s above the baseline
+type fakeProvider struct {
+	pricing.Provider
+	fail bool
+}
+
+func (p fakeProvider) GetEBSCostComponents(_ context.Context, _, volumeType string, sizeGiB, iops, throughput int32) ([]types.CostComponent, error) {
+	if p.fail {
+		return nil, errors.New("no price")
+	}
+	components := []types.CostComponent{types.MonthlyComponent("storage", "GB-month", float64(sizeGiB), 0.08)}
+	if iops > gp3BaselineIOPS {
+		components = append(components, types.MonthlyComponent("provisioned IOPS", "IOPS-month", float64(iops-gp3BaselineIOPS), 0.005))
+	}
+	if throughput > gp3BaselineThroughput {
+		components = append(components, types.MonthlyComponent("provisioned throughput", "MiBps-month", float64(throughput-gp3BaselineThroughput), 0.04))
+	}
+	return components, nil
+}
+
+func gp2Cost(sizeGiB int32) types.CostValue {
+	return types.CostValue(float64(sizeGiB) * 0.10 / types.HoursPerMonth)
+}
+
+func TestGP3Equivalent(t *testing.T) {
+	tests := []struct {
+		gp2IOPS, gp2Throughput int32
+		wantIOPS, wantTP       int32
+	}{
+		{300, 128, 3000, 128},
+		{6000, 250, 6000, 250},
+		{16000, 250, 16000, 250},
+	}
+	for _, tt := range tests {
+		iops, tp := gp3Equivalent(tt.gp2IOPS, tt.gp2Throughput)
+		if iops != tt.wantIOPS || tp != tt.wantTP {
+			t.Errorf("gp3Equivalent(%d, %d) = %d, %d; want %d, %d", tt.gp2IOPS, tt.gp2Throughput, iops, tp, tt.wantIOPS, tt.wantTP)
+		}
+	}
+}
+
+func TestGP3Savings(t *testing.T) {
+	volumes := []types.EBSVolume{
+		{AccountID: "111", Region: "us-east-1", VolumeID: "vol-small", VolumeType: "gp2", Size: 100, IOPS: 300, HourlyCost: gp2Cost(100)},
+		{AccountID: "111", Region: "us-east-1", VolumeID: "vol-large", VolumeType: "gp2", Size: 2000, IOPS: 6000, HourlyCost: gp2Cost(2000)},
+		{AccountID: "111", Region: "us-east-1", VolumeID: "vol-gp3", VolumeType: "gp3", Size: 500, IOPS: 3000, HourlyCost: 0.05},
+	}
+
+	got, diagnostics := GP3Savings(context.Background(), fakeProvider{}, volumes)
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diagnostics)
+	}
+	if len(got) != 2 || got[0].VolumeID != "vol-large" || got[1].VolumeID != "vol-small" {
+		t.Fatalf("expected gp2 volumes by savings, got %+v", got)
+	}
+	if got[0].GP3IOPS != 6000 || got[0].GP3Throughput != 250 {
+		t.Fatalf("expected gp3 to match gp2 performance, got %+v", got[0])
+	}
+	want := gp2Cost(2000) - types.CostValue((2000*0.08+3000*0.005+125*0.04)/types.HoursPerMonth)
+	if diff := got[0].HourlySavings - want; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("expected hourly savings %v, got %v", want, got[0].HourlySavings)
+	}
+
+	report := NewGP3Report(got, types.AppliedFilters{})
+	if report.TotalHourlySavings != got[0].HourlySavings.Add(got[1].HourlySavings) {
+		t.Fatalf("unexpected total: %v", report.TotalHourlySavings)
+	}
+
+	got, diagnostics = GP3Savings(context.Background(), fakeProvider{fail: true}, volumes)
+	if len(got) != 0 || len(diagnostics) != 2 {
+		t.Fatalf("expected unpriced volumes as diagnostics, got %+v and %+v", got, diagnostics)
+	}
+}
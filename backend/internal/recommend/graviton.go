@@ -0,0 +1,217 @@
+package recommend
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Compatibility of a Graviton migration
+const (
+	// CompatibilityHigh is a like-for-like family, e.g. m5 to m7g
+	CompatibilityHigh = "high"
+	// CompatibilityMedium is a family whose Graviton equivalent differs in
+	// local storage, networking, or burst behavior
+	CompatibilityMedium = "medium"
+)
+
+// gravitonFamily is the Graviton equivalent of an x86 instance family
+type gravitonFamily struct {
+	family        string
+	compatibility string
+}
+
+// gravitonFamilies maps x86 EC2 instance families to their current Graviton
+// equivalents
+var gravitonFamilies = map[string]gravitonFamily{
+	"m4": {"m7g", CompatibilityMedium}, "m5": {"m7g", CompatibilityHigh}, "m5a": {"m7g", CompatibilityHigh},
+	"m6i": {"m7g", CompatibilityHigh}, "m6a": {"m7g", CompatibilityHigh}, "m7i": {"m7g", CompatibilityHigh}, "m7a": {"m7g", CompatibilityHigh},
+	"m5d": {"m7gd", CompatibilityMedium}, "m6id": {"m7gd", CompatibilityMedium},
+	"c4": {"c7g", CompatibilityMedium}, "c5": {"c7g", CompatibilityHigh}, "c5a": {"c7g", CompatibilityHigh},
+	"c6i": {"c7g", CompatibilityHigh}, "c6a": {"c7g", CompatibilityHigh}, "c7i": {"c7g", CompatibilityHigh}, "c7a": {"c7g", CompatibilityHigh},
+	"c5d": {"c7gd", CompatibilityMedium}, "c6id": {"c7gd", CompatibilityMedium},
+	"c5n": {"c7gn", CompatibilityMedium}, "c6in": {"c7gn", CompatibilityMedium},
+	"r4": {"r7g", CompatibilityMedium}, "r5": {"r7g", CompatibilityHigh}, "r5a": {"r7g", CompatibilityHigh},
+	"r6i": {"r7g", CompatibilityHigh}, "r6a": {"r7g", CompatibilityHigh}, "r7i": {"r7g", CompatibilityHigh}, "r7a": {"r7g", CompatibilityHigh},
+	"r5d": {"r7gd", CompatibilityMedium}, "r6id": {"r7gd", CompatibilityMedium},
+	"t2": {"t4g", CompatibilityMedium}, "t3": {"t4g", CompatibilityHigh}, "t3a": {"t4g", CompatibilityHigh},
+}
+
+// gravitonSizes lists the sizes available in Graviton families. Larger x86
+// sizes such as 24xlarge have no equivalent.
+var gravitonSizes = map[string][]string{
+	"t4g":     {"nano", "micro", "small", "medium", "large", "xlarge", "2xlarge"},
+	"default": {"medium", "large", "xlarge", "2xlarge", "4xlarge", "8xlarge", "12xlarge", "16xlarge", "metal"},
+}
+
+// rdsGravitonFamilies maps x86 RDS instance class families to their Graviton
+// equivalents
+var rdsGravitonFamilies = map[string]gravitonFamily{
+	"db.m4": {"db.m7g", CompatibilityMedium}, "db.m5": {"db.m7g", CompatibilityHigh}, "db.m6i": {"db.m7g", CompatibilityHigh},
+	"db.r4": {"db.r7g", CompatibilityMedium}, "db.r5": {"db.r7g", CompatibilityHigh}, "db.r6i": {"db.r7g", CompatibilityHigh},
+	"db.t2": {"db.t4g", CompatibilityMedium}, "db.t3": {"db.t4g", CompatibilityHigh},
+}
+
+// rdsGravitonEngines are the RDS engines that run on Graviton
+var rdsGravitonEngines = []string{"mysql", "mariadb", "postgres", "aurora-mysql", "aurora-postgresql"}
+
+// GravitonInstance is an x86 instance with the cost of its Graviton equivalent
+type GravitonInstance struct {
+	ResourceType  string `json:"resourceType"` // ec2 or rds
+	AccountID     string `json:"accountId"`
+	AccountName   string `json:"accountName"`
+	Region        string `json:"region"`
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Engine        string `json:"engine,omitempty"` // RDS only
+	CurrentType   string `json:"currentType"`
+	GravitonType  string `json:"gravitonType"`
+	Compatibility string `json:"compatibility"`
+	Savings
+}
+
+// GravitonReport is the API response for the Graviton migration analysis
+type GravitonReport struct {
+	Timestamp           string               `json:"timestamp"`
+	Status              string               `json:"status"`
+	Diagnostics         []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Currency            string               `json:"currency"`
+	TotalHourlySavings  types.CostValue      `json:"totalHourlySavings"`
+	TotalMonthlySavings types.CostValue      `json:"totalMonthlySavings"`
+	Instances           []GravitonInstance   `json:"instances"`
+	Filters             types.AppliedFilters `json:"filters"`
+}
+
+// gravitonType returns the Graviton equivalent of an instance type, e.g.
+// m5.xlarge to m7g.xlarge, using families keyed by the part before the size
+func gravitonType(instanceType string, families map[string]gravitonFamily) (string, string, bool) {
+	i := strings.LastIndex(instanceType, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	family, size := instanceType[:i], instanceType[i+1:]
+	target, ok := families[family]
+	if !ok {
+		return "", "", false
+	}
+	sizes, ok := gravitonSizes[strings.TrimPrefix(target.family, "db.")]
+	if !ok {
+		sizes = gravitonSizes["default"]
+	}
+	if !slices.Contains(sizes, size) {
+		return "", "", false
+	}
+	return target.family + "." + size, target.compatibility, true
+}
+
+// priceChange prices the current and proposed instance types with price
+func priceChange(current, proposed string, price func(instanceType string) (types.CostValue, error)) (Savings, error) {
+	currentPrice, err := price(current)
+	if err != nil {
+		return Savings{}, err
+	}
+	proposedPrice, err := price(proposed)
+	if err != nil {
+		return Savings{}, err
+	}
+	return newSavings(currentPrice, proposedPrice), nil
+}
+
+// GravitonSavings prices running EC2 and RDS instances on their Graviton
+// equivalents and returns the ones that would cost less, highest savings
+// first. Both the current and Graviton types are priced on demand, so RDS
+// storage is left out. Instances that can't be priced are reported as
+// diagnostics.
+func GravitonSavings(ctx context.Context, provider pricing.Provider, resp *types.CostResponse) ([]GravitonInstance, []types.Diagnostic) {
+	out := []GravitonInstance{}
+	var diagnostics []types.Diagnostic
+
+	for _, inst := range resp.EC2Instances {
+		if inst.HourlyCost == 0 {
+			continue
+		}
+		target, compatibility, ok := gravitonType(inst.InstanceType, gravitonFamilies)
+		if !ok {
+			continue
+		}
+		savings, err := priceChange(inst.InstanceType, target, func(instanceType string) (types.CostValue, error) {
+			return provider.GetEC2TenancyPrice(ctx, inst.Region, instanceType, inst.Tenancy)
+		})
+		if err != nil {
+			diagnostics = append(diagnostics, pricingDiagnostic("ec2", inst.AccountID, inst.AccountName, inst.Region, inst.InstanceID, err))
+			continue
+		}
+		if savings.HourlySavings <= 0 {
+			continue
+		}
+		out = append(out, GravitonInstance{
+			ResourceType:  "ec2",
+			AccountID:     inst.AccountID,
+			AccountName:   inst.AccountName,
+			Region:        inst.Region,
+			ID:            inst.InstanceID,
+			Name:          inst.Name,
+			CurrentType:   inst.InstanceType,
+			GravitonType:  target,
+			Compatibility: compatibility,
+			Savings:       savings,
+		})
+	}
+
+	for _, inst := range resp.RDSInstances {
+		if inst.HourlyCost == 0 || !slices.Contains(rdsGravitonEngines, inst.Engine) {
+			continue
+		}
+		target, compatibility, ok := gravitonType(inst.InstanceClass, rdsGravitonFamilies)
+		if !ok {
+			continue
+		}
+		savings, err := priceChange(inst.InstanceClass, target, func(instanceClass string) (types.CostValue, error) {
+			return provider.GetRDSPrice(ctx, inst.Region, instanceClass, inst.Engine, inst.MultiAZ)
+		})
+		if err != nil {
+			diagnostics = append(diagnostics, pricingDiagnostic("rds", inst.AccountID, inst.AccountName, inst.Region, inst.DBInstanceID, err))
+			continue
+		}
+		if savings.HourlySavings <= 0 {
+			continue
+		}
+		out = append(out, GravitonInstance{
+			ResourceType:  "rds",
+			AccountID:     inst.AccountID,
+			AccountName:   inst.AccountName,
+			Region:        inst.Region,
+			ID:            inst.DBInstanceID,
+			Name:          inst.Name,
+			Engine:        inst.Engine,
+			CurrentType:   inst.InstanceClass,
+			GravitonType:  target,
+			Compatibility: compatibility,
+			Savings:       savings,
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].HourlySavings > out[j].HourlySavings })
+	return out, diagnostics
+}
+
+// NewGravitonReport totals the savings of instances
+func NewGravitonReport(instances []GravitonInstance, filters types.AppliedFilters) *GravitonReport {
+	report := &GravitonReport{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    types.ResponseStatusOK,
+		Currency:  "USD",
+		Instances: instances,
+		Filters:   filters,
+	}
+	for _, inst := range instances {
+		report.TotalHourlySavings = report.TotalHourlySavings.Add(inst.HourlySavings)
+		report.TotalMonthlySavings = report.TotalMonthlySavings.Add(inst.MonthlySavings)
+	}
+	return report
+}
@@ -0,0 +1,83 @@
+package recommend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+var ec2Prices = map[string]types.CostValue{
+	"m5.xlarge":  0.192,
+	"m7g.xlarge": 0.1632,
+	"c5.large":   0.085,
+	"c7g.large":  0.0725,
+}
+
+func (p fakeProvider) GetEC2Price(_ context.Context, _, instanceType string) (types.CostValue, error) {
+	return ec2Prices[instanceType], nil
+}
+
+func (p fakeProvider) GetEC2TenancyPrice(ctx context.Context, region, instanceType, _ string) (types.CostValue, error) {
+	return p.GetEC2Price(ctx, region, instanceType)
+}
+
+func (p fakeProvider) GetRDSPrice(_ context.Context, _, instanceClass, _ string, multiAZ bool) (types.CostValue, error) {
+	price := map[string]types.CostValue{"db.r5.large": 0.25, "db.r7g.large": 0.239}[instanceClass]
+	if multiAZ {
+		price *= 2
+	}
+	return price, nil
+}
+
+func TestGravitonType(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		want         string
+		ok           bool
+	}{
+		{"m5.xlarge", "m7g.xlarge", true},
+		{"t3.micro", "t4g.micro", true},
+		{"c5d.2xlarge", "c7gd.2xlarge", true},
+		{"m5.24xlarge", "", false},
+		{"m7g.large", "", false},
+		{"p4d.24xlarge", "", false},
+	}
+	for _, tt := range tests {
+		got, _, ok := gravitonType(tt.instanceType, gravitonFamilies)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("gravitonType(%q) = %q, %v; want %q, %v", tt.instanceType, got, ok, tt.want, tt.ok)
+		}
+	}
+	if got, _, _ := gravitonType("db.r5.large", rdsGravitonFamilies); got != "db.r7g.large" {
+		t.Errorf("gravitonType(db.r5.large) = %q", got)
+	}
+}
+
+func TestGravitonSavings(t *testing.T) {
+	resp := &types.CostResponse{
+		EC2Instances: []types.EC2Instance{
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-m5", InstanceType: "m5.xlarge", HourlyCost: 0.192},
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-c5", InstanceType: "c5.large", HourlyCost: 0.085},
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-stopped", InstanceType: "m5.xlarge"},
+		},
+		RDSInstances: []types.RDSInstance{
+			{AccountID: "111", Region: "us-east-1", DBInstanceID: "pg", Engine: "postgres", InstanceClass: "db.r5.large", MultiAZ: true, HourlyCost: 0.6},
+			{AccountID: "111", Region: "us-east-1", DBInstanceID: "mssql", Engine: "sqlserver-se", InstanceClass: "db.r5.large", HourlyCost: 1},
+		},
+	}
+
+	got, diagnostics := GravitonSavings(context.Background(), fakeProvider{}, resp)
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diagnostics)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 instances, got %+v", got)
+	}
+	if got[0].ID != "i-m5" || got[0].GravitonType != "m7g.xlarge" || got[0].Compatibility != CompatibilityHigh {
+		t.Fatalf("expected m5 instance first, got %+v", got[0])
+	}
+	if got[1].ID != "pg" || got[1].GravitonType != "db.r7g.large" {
+		t.Fatalf("expected Multi-AZ postgres instance second, got %+v", got[1])
+	}
+}
@@ -0,0 +1,233 @@
+package recommend
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/pricing"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Resources that hold public IPv4 addresses
+const (
+	IPv4SourceEIP = "eip" // An Elastic IP that isn't associated with a NAT gateway
+	IPv4SourceEC2 = "ec2" // An address auto-assigned to an EC2 instance
+	IPv4SourceNAT = "nat"
+	IPv4SourceELB = "elb"
+	IPv4SourceRDS = "rds"
+)
+
+// IPv4Address is one or more public IPv4 addresses held by a resource, with
+// their cost and how they could be given up
+type IPv4Address struct {
+	AccountID    string          `json:"accountId"`
+	AccountName  string          `json:"accountName"`
+	Region       string          `json:"region"`
+	Source       string          `json:"source"`     // eip, ec2, nat, elb, or rds
+	ResourceID   string          `json:"resourceId"` // The allocation, instance, gateway, load balancer, or database
+	ResourceName string          `json:"resourceName,omitempty"`
+	PublicIP     string          `json:"publicIp,omitempty"` // Empty for load balancers and databases, whose addresses AWS manages
+	Count        int             `json:"count"`              // Addresses held: one per subnet for a load balancer
+	Idle         bool            `json:"idle"`
+	InTotals     bool            `json:"inTotals"` // Already counted in cost totals, as Elastic IPs and EC2 public IPv4s are
+	HourlyCost   types.CostValue `json:"hourlyCost"`
+	MonthlyCost  types.CostValue `json:"monthlyCost"`
+	// MigrationCandidate is set when the addresses could be released or
+	// replaced by IPv6 or private connectivity, as Suggestion describes
+	MigrationCandidate bool   `json:"migrationCandidate"`
+	Suggestion         string `json:"suggestion,omitempty"`
+}
+
+// IPv4SourceTotal is the public IPv4 addresses held by one kind of resource
+type IPv4SourceTotal struct {
+	Source      string          `json:"source"`
+	Count       int             `json:"count"`
+	MonthlyCost types.CostValue `json:"monthlyCost"`
+}
+
+// IPv4Report is the API response for the public IPv4 address audit
+type IPv4Report struct {
+	Timestamp            string               `json:"timestamp"`
+	Status               string               `json:"status"`
+	Diagnostics          []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Currency             string               `json:"currency"`
+	AddressCount         int                  `json:"addressCount"`
+	TotalHourlyCost      types.CostValue      `json:"totalHourlyCost"`
+	TotalMonthlyCost     types.CostValue      `json:"totalMonthlyCost"`
+	CandidateCount       int                  `json:"candidateCount"`       // Addresses that are migration candidates
+	CandidateMonthlyCost types.CostValue      `json:"candidateMonthlyCost"` // What releasing them would save
+	Sources              []IPv4SourceTotal    `json:"sources"`
+	Addresses            []IPv4Address        `json:"addresses"`
+	Filters              types.AppliedFilters `json:"filters"`
+}
+
+// IPv4Audit lists every public IPv4 address in resp: Elastic IPs, addresses
+// auto-assigned to EC2 instances, and the addresses of public NAT gateways
+// without a scanned Elastic IP, internet-facing load balancers, and publicly
+// accessible RDS instances. Those last three aren't priced by discovery, so
+// they're priced here at the in-use public IPv4 rate. Highest cost first.
+// Addresses that can't be priced are reported as diagnostics.
+func IPv4Audit(ctx context.Context, provider pricing.Provider, resp *types.CostResponse) ([]IPv4Address, []types.Diagnostic) {
+	out := []IPv4Address{}
+	var diagnostics []types.Diagnostic
+
+	natEIPs := make(map[string]bool)
+	for _, eip := range resp.ElasticIPs {
+		addr := IPv4Address{
+			AccountID:    eip.AccountID,
+			AccountName:  eip.AccountName,
+			Region:       eip.Region,
+			Source:       IPv4SourceEIP,
+			ResourceID:   eip.AllocationID,
+			ResourceName: eip.Name,
+			PublicIP:     eip.PublicIP,
+			Count:        1,
+			Idle:         eip.Idle,
+			InTotals:     true,
+			HourlyCost:   eip.HourlyCost,
+		}
+		switch {
+		case eip.AssociatedResourceType == IPv4SourceNAT:
+			natEIPs[eip.AssociatedResourceID] = true
+			addr.Source = IPv4SourceNAT
+			addr.ResourceID = eip.AssociatedResourceID
+		case eip.Idle:
+			addr.MigrationCandidate = true
+			addr.Suggestion = "Release the unused Elastic IP"
+		case eip.AssociatedResourceType == IPv4SourceEC2:
+			addr.MigrationCandidate = true
+			addr.Suggestion = ec2Suggestion
+		}
+		out = append(out, addr)
+	}
+
+	for _, ip := range resp.PublicIPv4s {
+		out = append(out, IPv4Address{
+			AccountID:          ip.AccountID,
+			AccountName:        ip.AccountName,
+			Region:             ip.Region,
+			Source:             IPv4SourceEC2,
+			ResourceID:         ip.InstanceID,
+			ResourceName:       ip.InstanceName,
+			PublicIP:           ip.PublicIP,
+			Count:              1,
+			Idle:               ip.Idle,
+			InTotals:           true,
+			HourlyCost:         ip.HourlyCost,
+			MigrationCandidate: true,
+			Suggestion:         ec2Suggestion,
+		})
+	}
+
+	// price adds addresses priced at the in-use rate
+	price := func(addr IPv4Address) {
+		rate, err := provider.GetPublicIPv4Price(ctx, addr.Region)
+		if err != nil {
+			diagnostics = append(diagnostics, pricingDiagnostic(addr.Source, addr.AccountID, addr.AccountName, addr.Region, addr.ResourceID, err))
+			return
+		}
+		addr.HourlyCost = rate * types.CostValue(addr.Count)
+		out = append(out, addr)
+	}
+
+	for _, nat := range resp.NATGateways {
+		if nat.Type != "public" || nat.State != "available" || natEIPs[nat.ID] {
+			continue
+		}
+		price(IPv4Address{
+			AccountID:    nat.AccountID,
+			AccountName:  nat.AccountName,
+			Region:       nat.Region,
+			Source:       IPv4SourceNAT,
+			ResourceID:   nat.ID,
+			ResourceName: nat.Name,
+			Count:        1,
+		})
+	}
+
+	for _, lb := range resp.LoadBalancers {
+		if lb.Scheme != "internet-facing" || lb.State == "failed" {
+			continue
+		}
+		addr := IPv4Address{
+			AccountID:    lb.AccountID,
+			AccountName:  lb.AccountName,
+			Region:       lb.Region,
+			Source:       IPv4SourceELB,
+			ResourceID:   lb.ARN,
+			ResourceName: lb.Name,
+			Count:        max(len(lb.SubnetIDs), 1),
+		}
+		if lb.Type == "application" {
+			addr.MigrationCandidate = true
+			addr.Suggestion = "Switch to the dualstack-without-public-ipv4 IP address type to serve clients over IPv6"
+		}
+		price(addr)
+	}
+
+	for _, db := range resp.RDSInstances {
+		if !db.PubliclyAccessible || db.State == "stopped" {
+			continue
+		}
+		price(IPv4Address{
+			AccountID:          db.AccountID,
+			AccountName:        db.AccountName,
+			Region:             db.Region,
+			Source:             IPv4SourceRDS,
+			ResourceID:         db.DBInstanceID,
+			ResourceName:       db.Name,
+			Count:              1,
+			MigrationCandidate: true,
+			Suggestion:         "Turn off public accessibility and connect from inside the VPC, over a VPN, or through a bastion",
+		})
+	}
+
+	for i := range out {
+		out[i].MonthlyCost = out[i].HourlyCost * types.HoursPerMonth
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].HourlyCost > out[j].HourlyCost })
+	return out, diagnostics
+}
+
+// ec2Suggestion is how an instance can give up its public IPv4 address
+const ec2Suggestion = "Use IPv6, or reach the instance through a load balancer, NAT gateway, or Session Manager instead of a public address"
+
+// NewIPv4Report totals addresses by source and migration candidacy
+func NewIPv4Report(addresses []IPv4Address, filters types.AppliedFilters) *IPv4Report {
+	report := &IPv4Report{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    types.ResponseStatusOK,
+		Currency:  "USD",
+		Sources:   []IPv4SourceTotal{},
+		Addresses: addresses,
+		Filters:   filters,
+	}
+	bySource := make(map[string]*IPv4SourceTotal)
+	for _, a := range addresses {
+		report.AddressCount += a.Count
+		report.TotalHourlyCost = report.TotalHourlyCost.Add(a.HourlyCost)
+		report.TotalMonthlyCost = report.TotalMonthlyCost.Add(a.MonthlyCost)
+		if a.MigrationCandidate {
+			report.CandidateCount += a.Count
+			report.CandidateMonthlyCost = report.CandidateMonthlyCost.Add(a.MonthlyCost)
+		}
+		total, ok := bySource[a.Source]
+		if !ok {
+			total = &IPv4SourceTotal{Source: a.Source}
+			bySource[a.Source] = total
+		}
+		total.Count += a.Count
+		total.MonthlyCost = total.MonthlyCost.Add(a.MonthlyCost)
+	}
+	for _, total := range bySource {
+		report.Sources = append(report.Sources, *total)
+	}
+	sort.Slice(report.Sources, func(i, j int) bool {
+		if report.Sources[i].MonthlyCost != report.Sources[j].MonthlyCost {
+			return report.Sources[i].MonthlyCost > report.Sources[j].MonthlyCost
+		}
+		return report.Sources[i].Source < report.Sources[j].Source
+	})
+	return report
+}
@@ -0,0 +1,114 @@
+package recommend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// GetPublicIPv4Price prices public IPv4 addresses at $0.005 an hour
+func (p fakeProvider) GetPublicIPv4Price(context.Context, string) (types.CostValue, error) {
+	if p.fail {
+		return 0, errors.New("no price")
+	}
+	return 0.005, nil
+}
+
+func ipv4Response() *types.CostResponse {
+	return &types.CostResponse{
+		ElasticIPs: []types.ElasticIP{
+			{AccountID: "111", Region: "us-east-1", AllocationID: "eipalloc-idle", PublicIP: "3.0.0.1", Idle: true, HourlyCost: 0.005},
+			{AccountID: "111", Region: "us-east-1", AllocationID: "eipalloc-nat", PublicIP: "3.0.0.2", AssociatedResourceType: "nat", AssociatedResourceID: "nat-1", HourlyCost: 0.005},
+		},
+		PublicIPv4s: []types.PublicIPv4{
+			{AccountID: "111", Region: "us-east-1", PublicIP: "3.0.0.3", InstanceID: "i-1", HourlyCost: 0.005},
+		},
+		NATGateways: []types.NATGateway{
+			{AccountID: "111", Region: "us-east-1", ID: "nat-1", Type: "public", State: "available"},
+			{AccountID: "111", Region: "us-east-1", ID: "nat-2", Type: "public", State: "available"},
+			{AccountID: "111", Region: "us-east-1", ID: "nat-private", Type: "private", State: "available"},
+		},
+		LoadBalancers: []types.LoadBalancer{
+			{AccountID: "111", Region: "us-east-1", Name: "web", ARN: "arn:alb/web", Type: "application", Scheme: "internet-facing", SubnetIDs: []string{"subnet-a", "subnet-b", "subnet-c"}},
+			{AccountID: "111", Region: "us-east-1", Name: "nlb", ARN: "arn:nlb/edge", Type: "network", Scheme: "internet-facing", SubnetIDs: []string{"subnet-a"}},
+			{AccountID: "111", Region: "us-east-1", Name: "internal", ARN: "arn:alb/internal", Type: "application", Scheme: "internal", SubnetIDs: []string{"subnet-a", "subnet-b"}},
+		},
+		RDSInstances: []types.RDSInstance{
+			{AccountID: "111", Region: "us-east-1", DBInstanceID: "public-db", State: "available", PubliclyAccessible: true},
+			{AccountID: "111", Region: "us-east-1", DBInstanceID: "private-db", State: "available"},
+		},
+	}
+}
+
+func TestIPv4Audit(t *testing.T) {
+	addresses, diagnostics := IPv4Audit(context.Background(), fakeProvider{}, ipv4Response())
+	if len(diagnostics) != 0 {
+		t.Fatalf("unexpected diagnostics: %+v", diagnostics)
+	}
+
+	byID := make(map[string]IPv4Address)
+	for _, a := range addresses {
+		byID[a.ResourceID] = a
+	}
+	if len(addresses) != 7 {
+		t.Fatalf("got %d addresses, want 7: %+v", len(addresses), addresses)
+	}
+	if addresses[0].ResourceID != "arn:alb/web" {
+		t.Fatalf("expected the three-subnet ALB first, got %+v", addresses[0])
+	}
+
+	tests := []struct {
+		id        string
+		source    string
+		count     int
+		inTotals  bool
+		candidate bool
+	}{
+		{"eipalloc-idle", IPv4SourceEIP, 1, true, true},
+		{"nat-1", IPv4SourceNAT, 1, true, false},
+		{"nat-2", IPv4SourceNAT, 1, false, false},
+		{"i-1", IPv4SourceEC2, 1, true, true},
+		{"arn:alb/web", IPv4SourceELB, 3, false, true},
+		{"arn:nlb/edge", IPv4SourceELB, 1, false, false},
+		{"public-db", IPv4SourceRDS, 1, false, true},
+	}
+	for _, tt := range tests {
+		a, ok := byID[tt.id]
+		if !ok {
+			t.Errorf("%s: missing", tt.id)
+			continue
+		}
+		if a.Source != tt.source || a.Count != tt.count || a.InTotals != tt.inTotals || a.MigrationCandidate != tt.candidate {
+			t.Errorf("%s = %+v, want source %s, count %d, inTotals %t, candidate %t", tt.id, a, tt.source, tt.count, tt.inTotals, tt.candidate)
+		}
+		if want := types.CostValue(0.005 * float64(tt.count)); !closeTo(a.HourlyCost, want) || !closeTo(a.MonthlyCost, want*types.HoursPerMonth) {
+			t.Errorf("%s costs %v/%v, want %v an hour", tt.id, a.HourlyCost, a.MonthlyCost, want)
+		}
+	}
+
+	report := NewIPv4Report(addresses, types.AppliedFilters{})
+	if report.AddressCount != 9 || report.CandidateCount != 6 {
+		t.Fatalf("counts = %d addresses, %d candidates; want 9, 6", report.AddressCount, report.CandidateCount)
+	}
+	if !closeTo(report.TotalMonthlyCost, 9*0.005*types.HoursPerMonth) {
+		t.Fatalf("total monthly cost = %v", report.TotalMonthlyCost)
+	}
+	if report.Sources[0].Source != IPv4SourceELB || report.Sources[0].Count != 4 {
+		t.Fatalf("expected load balancers to lead sources, got %+v", report.Sources)
+	}
+}
+
+func TestIPv4AuditPricingFailure(t *testing.T) {
+	addresses, diagnostics := IPv4Audit(context.Background(), fakeProvider{fail: true}, ipv4Response())
+	// Elastic IPs and EC2 addresses are priced by discovery; the rest can't be
+	if len(addresses) != 3 || len(diagnostics) != 4 {
+		t.Fatalf("got %d addresses and %d diagnostics, want 3 and 4", len(addresses), len(diagnostics))
+	}
+}
+
+func closeTo(a, b types.CostValue) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
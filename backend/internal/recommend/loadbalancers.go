@@ -0,0 +1,85 @@
+package recommend
+
+import (
+	"sort"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// FlaggedLoadBalancer is a load balancer that is deprecated or likely idle
+type FlaggedLoadBalancer struct {
+	AccountID         string                  `json:"accountId"`
+	AccountName       string                  `json:"accountName"`
+	Region            string                  `json:"region"`
+	Name              string                  `json:"name"`
+	ARN               string                  `json:"arn,omitempty"`
+	Type              string                  `json:"type"`
+	RegisteredTargets *int                    `json:"registeredTargets,omitempty"`
+	HealthyTargets    *int                    `json:"healthyTargets,omitempty"`
+	Warnings          []types.ResourceWarning `json:"warnings"`
+	Savings
+}
+
+// LoadBalancerReport is the API response for deprecated and idle load
+// balancers
+type LoadBalancerReport struct {
+	Timestamp           string                `json:"timestamp"`
+	Status              string                `json:"status"`
+	Diagnostics         []types.Diagnostic    `json:"diagnostics,omitempty"`
+	Currency            string                `json:"currency"`
+	TotalHourlySavings  types.CostValue       `json:"totalHourlySavings"`
+	TotalMonthlySavings types.CostValue       `json:"totalMonthlySavings"`
+	LoadBalancers       []FlaggedLoadBalancer `json:"loadBalancers"`
+	Filters             types.AppliedFilters  `json:"filters"`
+}
+
+// LoadBalancerSavings returns the load balancers with warnings, highest
+// savings first. Idle load balancers save their whole cost when deleted;
+// classic load balancers with healthy targets are listed with no savings,
+// since they need migrating rather than deleting.
+func LoadBalancerSavings(lbs []types.LoadBalancer) []FlaggedLoadBalancer {
+	out := []FlaggedLoadBalancer{}
+	for _, lb := range lbs {
+		if len(lb.Warnings) == 0 {
+			continue
+		}
+		proposed := lb.HourlyCost
+		for _, w := range lb.Warnings {
+			if w.MonthlySavings > 0 {
+				proposed = 0
+			}
+		}
+		out = append(out, FlaggedLoadBalancer{
+			AccountID:         lb.AccountID,
+			AccountName:       lb.AccountName,
+			Region:            lb.Region,
+			Name:              lb.Name,
+			ARN:               lb.ARN,
+			Type:              lb.Type,
+			RegisteredTargets: lb.RegisteredTargets,
+			HealthyTargets:    lb.HealthyTargets,
+			Warnings:          lb.Warnings,
+			Savings:           newSavings(lb.HourlyCost, proposed),
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].HourlySavings > out[j].HourlySavings })
+	return out
+}
+
+// NewLoadBalancerReport totals the savings of lbs
+func NewLoadBalancerReport(lbs []FlaggedLoadBalancer, filters types.AppliedFilters) *LoadBalancerReport {
+	report := &LoadBalancerReport{
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		Status:        types.ResponseStatusOK,
+		Currency:      "USD",
+		LoadBalancers: lbs,
+		Filters:       filters,
+	}
+	for _, lb := range lbs {
+		report.TotalHourlySavings = report.TotalHourlySavings.Add(lb.HourlySavings)
+		report.TotalMonthlySavings = report.TotalMonthlySavings.Add(lb.MonthlySavings)
+	}
+	return report
+}
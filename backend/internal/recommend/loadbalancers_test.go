@@ -0,0 +1,35 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestLoadBalancerSavings(t *testing.T) {
+	lbs := []types.LoadBalancer{
+		{Name: "busy", Type: "application", HourlyCost: 0.03},
+		{Name: "legacy", Type: "classic", HourlyCost: 0.025, Warnings: []types.ResourceWarning{
+			{Code: types.WarningClassicLoadBalancer},
+		}},
+		{Name: "idle", Type: "network", HourlyCost: 0.0225, Warnings: []types.ResourceWarning{
+			{Code: types.WarningNoTargets, MonthlySavings: 0.0225 * types.HoursPerMonth},
+		}},
+	}
+
+	got := LoadBalancerSavings(lbs)
+	if len(got) != 2 {
+		t.Fatalf("got %d load balancers, want 2", len(got))
+	}
+	if got[0].Name != "idle" || got[0].HourlySavings != 0.0225 {
+		t.Fatalf("first = %s saving %v, want idle saving 0.0225", got[0].Name, got[0].HourlySavings)
+	}
+	if got[1].Name != "legacy" || got[1].HourlySavings != 0 {
+		t.Fatalf("second = %s saving %v, want legacy saving 0", got[1].Name, got[1].HourlySavings)
+	}
+
+	report := NewLoadBalancerReport(got, types.AppliedFilters{})
+	if report.TotalHourlySavings != 0.0225 {
+		t.Fatalf("TotalHourlySavings = %v, want 0.0225", report.TotalHourlySavings)
+	}
+}
@@ -0,0 +1,147 @@
+package recommend
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// hoursPerWeek is the number of hours a resource left running is billed for
+// each week
+const hoursPerWeek = 7 * 24
+
+// OffHoursResource is a non-production instance or database with the cost of
+// running it only on the off-hours schedule
+type OffHoursResource struct {
+	ResourceType string `json:"resourceType"` // ec2 or rds
+	AccountID    string `json:"accountId"`
+	AccountName  string `json:"accountName"`
+	Region       string `json:"region"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`        // Instance type or class
+	Environment  string `json:"environment"` // Value of the environment tag
+	Savings
+}
+
+// OffHoursSchedule is the schedule non-production resources are assumed to
+// run on
+type OffHoursSchedule struct {
+	Weekdays      []string `json:"weekdays"`
+	StartHour     int      `json:"startHour"`
+	EndHour       int      `json:"endHour"`
+	HoursPerWeek  int      `json:"hoursPerWeek"`
+	RunningFactor float64  `json:"runningFactor"` // Fraction of the week resources run
+}
+
+// OffHoursReport is the API response for the off-hours shutdown estimate
+type OffHoursReport struct {
+	Timestamp           string               `json:"timestamp"`
+	Status              string               `json:"status"`
+	Diagnostics         []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Currency            string               `json:"currency"`
+	Schedule            OffHoursSchedule     `json:"schedule"`
+	TotalHourlySavings  types.CostValue      `json:"totalHourlySavings"`
+	TotalMonthlySavings types.CostValue      `json:"totalMonthlySavings"`
+	Resources           []OffHoursResource   `json:"resources"`
+	Filters             types.AppliedFilters `json:"filters"`
+}
+
+// OffHoursSavings returns the running EC2 instances and available RDS
+// instances tagged as non-production, with the savings from stopping them
+// outside the schedule, highest savings first. RDS storage is billed while a
+// database is stopped, so only its other components are scaled down.
+func OffHoursSavings(resp *types.CostResponse, cfg config.OffHoursConfig) []OffHoursResource {
+	factor := types.CostValue(cfg.ScheduledHoursPerWeek()) / hoursPerWeek
+	out := []OffHoursResource{}
+
+	for _, inst := range resp.EC2Instances {
+		env, ok := nonProductionEnvironment(inst.Tags, cfg)
+		if !ok || inst.State != "running" || inst.HourlyCost == 0 {
+			continue
+		}
+		out = append(out, OffHoursResource{
+			ResourceType: "ec2",
+			AccountID:    inst.AccountID,
+			AccountName:  inst.AccountName,
+			Region:       inst.Region,
+			ID:           inst.InstanceID,
+			Name:         inst.Name,
+			Type:         inst.InstanceType,
+			Environment:  env,
+			Savings:      newSavings(inst.HourlyCost, inst.HourlyCost*factor),
+		})
+	}
+
+	for _, inst := range resp.RDSInstances {
+		env, ok := nonProductionEnvironment(inst.Tags, cfg)
+		if !ok || inst.State != "available" || inst.HourlyCost == 0 {
+			continue
+		}
+		var storage types.CostValue
+		for _, c := range inst.CostComponents {
+			if c.Name == "storage" {
+				storage = storage.Add(c.HourlyCost)
+			}
+		}
+		out = append(out, OffHoursResource{
+			ResourceType: "rds",
+			AccountID:    inst.AccountID,
+			AccountName:  inst.AccountName,
+			Region:       inst.Region,
+			ID:           inst.DBInstanceID,
+			Name:         inst.Name,
+			Type:         inst.InstanceClass,
+			Environment:  env,
+			Savings:      newSavings(inst.HourlyCost, storage.Add((inst.HourlyCost-storage)*factor)),
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].HourlySavings > out[j].HourlySavings })
+	return out
+}
+
+// nonProductionEnvironment returns the value of the first environment tag
+// on a resource, and whether it names a non-production environment
+func nonProductionEnvironment(tags map[string]string, cfg config.OffHoursConfig) (string, bool) {
+	for _, key := range cfg.EnvironmentTags {
+		for k, v := range tags {
+			if !strings.EqualFold(k, key) {
+				continue
+			}
+			nonProd := slices.ContainsFunc(cfg.NonProductionValues, func(value string) bool {
+				return strings.EqualFold(strings.TrimSpace(v), value)
+			})
+			return v, nonProd
+		}
+	}
+	return "", false
+}
+
+// NewOffHoursReport totals the savings of resources on the schedule in cfg
+func NewOffHoursReport(resources []OffHoursResource, cfg config.OffHoursConfig, filters types.AppliedFilters) *OffHoursReport {
+	hours := cfg.ScheduledHoursPerWeek()
+	report := &OffHoursReport{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Status:    types.ResponseStatusOK,
+		Currency:  "USD",
+		Schedule: OffHoursSchedule{
+			Weekdays:      cfg.Weekdays,
+			StartHour:     cfg.StartHour,
+			EndHour:       cfg.EndHour,
+			HoursPerWeek:  hours,
+			RunningFactor: float64(hours) / hoursPerWeek,
+		},
+		Resources: resources,
+		Filters:   filters,
+	}
+	for _, r := range resources {
+		report.TotalHourlySavings = report.TotalHourlySavings.Add(r.HourlySavings)
+		report.TotalMonthlySavings = report.TotalMonthlySavings.Add(r.MonthlySavings)
+	}
+	return report
+}
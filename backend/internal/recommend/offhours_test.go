@@ -0,0 +1,51 @@
+package recommend
+
+import (
+	"math"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestOffHoursSavings(t *testing.T) {
+	cfg := config.DefaultConfig().Recommend.OffHours // 11 hours a day, Monday to Friday
+	resp := &types.CostResponse{
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-prod", State: "running", HourlyCost: 1, Tags: map[string]string{"Environment": "production"}},
+			{InstanceID: "i-dev", State: "running", HourlyCost: 0.168, Tags: map[string]string{"env": "Dev"}},
+			{InstanceID: "i-stopped", State: "stopped", Tags: map[string]string{"Environment": "dev"}},
+			{InstanceID: "i-untagged", State: "running", HourlyCost: 1},
+		},
+		RDSInstances: []types.RDSInstance{
+			{DBInstanceID: "qa-db", State: "available", HourlyCost: 1.1, Tags: map[string]string{"Stage": "qa"}, CostComponents: []types.CostComponent{
+				{Name: "compute", HourlyCost: 1},
+				{Name: "storage", HourlyCost: 0.1},
+			}},
+		},
+	}
+
+	got := OffHoursSavings(resp, cfg)
+	if len(got) != 2 {
+		t.Fatalf("got %d resources, want 2: %+v", len(got), got)
+	}
+
+	db := got[0]
+	if db.ID != "qa-db" || db.Environment != "qa" {
+		t.Fatalf("first = %+v, want qa-db", db)
+	}
+	// Compute runs 55 of 168 hours; storage is billed all week
+	if want := 0.1 + 55.0/168; math.Abs(float64(db.ProposedHourlyCost)-want) > 1e-9 {
+		t.Fatalf("qa-db proposed = %v, want %v", db.ProposedHourlyCost, want)
+	}
+
+	dev := got[1]
+	if dev.ID != "i-dev" || dev.Environment != "Dev" || math.Abs(float64(dev.ProposedHourlyCost)-0.055) > 1e-9 {
+		t.Fatalf("second = %+v, want i-dev at 0.055", dev)
+	}
+
+	report := NewOffHoursReport(got, cfg, types.AppliedFilters{})
+	if report.Schedule.HoursPerWeek != 55 || report.TotalHourlySavings != db.HourlySavings.Add(dev.HourlySavings) {
+		t.Fatalf("report = %+v", report)
+	}
+}
@@ -0,0 +1,40 @@
+// Package recommend estimates savings from changes to how resources are
+// provisioned, such as migrating gp2 volumes to gp3 or x86 instances to
+// Graviton.
+package recommend
+
+import (
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Savings is the difference between a resource's current cost and its cost
+// after a recommended change
+type Savings struct {
+	CurrentHourlyCost  types.CostValue `json:"currentHourlyCost"`
+	ProposedHourlyCost types.CostValue `json:"proposedHourlyCost"`
+	HourlySavings      types.CostValue `json:"hourlySavings"`
+	MonthlySavings     types.CostValue `json:"monthlySavings"`
+}
+
+func newSavings(current, proposed types.CostValue) Savings {
+	return Savings{
+		CurrentHourlyCost:  current,
+		ProposedHourlyCost: proposed,
+		HourlySavings:      current - proposed,
+		MonthlySavings:     (current - proposed) * types.HoursPerMonth,
+	}
+}
+
+// pricingDiagnostic reports a resource whose proposed cost couldn't be priced
+func pricingDiagnostic(resourceType, accountID, accountName, region, resourceID string, err error) types.Diagnostic {
+	return types.Diagnostic{
+		Level:        "warning",
+		ResourceType: resourceType,
+		AccountID:    accountID,
+		AccountName:  accountName,
+		Region:       region,
+		Operation:    "pricing",
+		ResourceID:   resourceID,
+		Message:      err.Error(),
+	}
+}
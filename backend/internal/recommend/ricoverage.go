@@ -0,0 +1,248 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// sizeUnits are the normalization factors AWS uses to apply a regional Linux
+// Reserved Instance to any size in its family. Sizes not listed, such as
+// metal, are only matched by exact instance type.
+var sizeUnits = map[string]float64{
+	"nano": 0.25, "micro": 0.5, "small": 1, "medium": 2, "large": 4, "xlarge": 8,
+}
+
+// RICoverage is the Reserved Instance coverage of the running instances of one
+// instance family in one region. Units are normalized instance-hours per hour,
+// e.g. a large instance is 4 and an xlarge 8.
+type RICoverage struct {
+	Region             string          `json:"region"`
+	Family             string          `json:"family"` // e.g. m5, or the instance type for sizes without a normalization factor
+	RunningInstances   int             `json:"runningInstances"`
+	RunningUnits       float64         `json:"runningUnits"`
+	CoveredUnits       float64         `json:"coveredUnits"`
+	OnDemandUnits      float64         `json:"onDemandUnits"` // Running but not covered: the coverage gap
+	ReservedUnits      float64         `json:"reservedUnits"`
+	UnusedUnits        float64         `json:"unusedUnits"` // Reserved but not used
+	CoveragePercent    float64         `json:"coveragePercent"`
+	UtilizationPercent float64         `json:"utilizationPercent"`
+	OnDemandHourlyCost types.CostValue `json:"onDemandHourlyCost"` // On-demand cost of the uncovered units
+}
+
+// RIUsage is how much of one Reserved Instance purchase is used
+type RIUsage struct {
+	types.ReservedInstance
+	Units            float64         `json:"units"`
+	UsedUnits        float64         `json:"usedUnits"`
+	UnusedUnits      float64         `json:"unusedUnits"`
+	UnusedHourlyCost types.CostValue `json:"unusedHourlyCost"` // Recurring charge for the unused share
+	Matched          bool            `json:"matched"`          // False if awsCOGS can't match it to instances
+	UnmatchedReason  string          `json:"unmatchedReason,omitempty"`
+}
+
+// RICoverageReport is the API response for the Reserved Instance coverage report
+type RICoverageReport struct {
+	Timestamp          string               `json:"timestamp"`
+	Status             string               `json:"status"`
+	Diagnostics        []types.Diagnostic   `json:"diagnostics,omitempty"`
+	Currency           string               `json:"currency"`
+	RunningUnits       float64              `json:"runningUnits"`
+	CoveredUnits       float64              `json:"coveredUnits"`
+	ReservedUnits      float64              `json:"reservedUnits"`
+	UnusedUnits        float64              `json:"unusedUnits"`
+	CoveragePercent    float64              `json:"coveragePercent"`
+	UtilizationPercent float64              `json:"utilizationPercent"`
+	OnDemandHourlyCost types.CostValue      `json:"onDemandHourlyCost"`
+	UnusedHourlyCost   types.CostValue      `json:"unusedHourlyCost"`
+	Coverage           []RICoverage         `json:"coverage"`     // Largest coverage gap first
+	Reservations       []RIUsage            `json:"reservations"` // Most unused first
+	Filters            types.AppliedFilters `json:"filters"`
+}
+
+// InstanceUnits returns an instance type's family and normalized units. Types
+// whose size has no normalization factor are their own family, at 1 unit.
+func InstanceUnits(instanceType string) (family string, units float64, flexible bool) {
+	family, size, ok := strings.Cut(instanceType, ".")
+	if !ok {
+		return instanceType, 1, false
+	}
+	if u, ok := sizeUnits[size]; ok {
+		return family, u, true
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(size, "xlarge")); err == nil && strings.HasSuffix(size, "xlarge") {
+		return family, float64(n) * sizeUnits["xlarge"], true
+	}
+	return instanceType, 1, false
+}
+
+// coverageKey identifies a coverage row
+type coverageKey struct{ region, family string }
+
+// pendingInstance is a running instance's units not yet covered
+type pendingInstance struct {
+	instanceType string
+	tenancy      string
+	units        float64
+	remaining    float64
+	hourlyCost   types.CostValue
+}
+
+// ReservedInstanceCoverage matches active Reserved Instances to running EC2
+// instances the way AWS billing applies them: reservations for an exact
+// instance type (zonal, dedicated tenancy, or sizes without a normalization
+// factor) first, then size-flexible regional Linux/UNIX reservations to any
+// size in their family. Reservations apply across all the scanned accounts,
+// as they do within an organization with RI sharing on. Instances are assumed
+// to run Linux, since their platform isn't discovered, and zonal reservations
+// are assumed to be in the instance's zone.
+func ReservedInstanceCoverage(instances []types.EC2Instance, reservations []types.ReservedInstance) ([]RICoverage, []RIUsage) {
+	rows := make(map[coverageKey]*RICoverage)
+	row := func(region, family string) *RICoverage {
+		key := coverageKey{region, family}
+		if rows[key] == nil {
+			rows[key] = &RICoverage{Region: region, Family: family}
+		}
+		return rows[key]
+	}
+
+	pending := make(map[coverageKey][]*pendingInstance)
+	for _, inst := range instances {
+		if inst.State != "running" {
+			continue
+		}
+		family, units, _ := InstanceUnits(inst.InstanceType)
+		r := row(inst.Region, family)
+		r.RunningInstances++
+		r.RunningUnits += units
+		tenancy := inst.Tenancy
+		if tenancy == "" {
+			tenancy = "default"
+		}
+		key := coverageKey{inst.Region, family}
+		pending[key] = append(pending[key], &pendingInstance{
+			instanceType: inst.InstanceType,
+			tenancy:      tenancy,
+			units:        units,
+			remaining:    units,
+			hourlyCost:   inst.HourlyCost,
+		})
+	}
+
+	// Apply the largest instances first so exact and flexible matches fill
+	// whole instances where they can
+	for _, list := range pending {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].units > list[j].units })
+	}
+
+	usages := make([]RIUsage, 0, len(reservations))
+	for _, ri := range reservations {
+		family, units, flexible := InstanceUnits(ri.InstanceType)
+		usage := RIUsage{ReservedInstance: ri, Units: units * float64(ri.InstanceCount), Matched: true}
+		if !strings.HasPrefix(ri.Platform, "Linux/UNIX") {
+			usage.Matched = false
+			usage.UnmatchedReason = "instance platforms aren't discovered, so only Linux/UNIX reservations are matched"
+		} else {
+			tenancy := ri.Tenancy
+			if tenancy == "" {
+				tenancy = "default"
+			}
+			usage.ReservedInstance.Tenancy = tenancy
+			// Only regional default-tenancy reservations are size-flexible
+			if ri.Scope != "Region" || tenancy != "default" {
+				flexible = false
+			}
+			if !flexible {
+				// Exact matches are applied first
+				usage.UsedUnits = apply(pending[coverageKey{ri.Region, family}], usage.Units, func(p *pendingInstance) bool {
+					return p.instanceType == ri.InstanceType && p.tenancy == tenancy
+				})
+			}
+			r := row(ri.Region, family)
+			r.ReservedUnits += usage.Units
+		}
+		usages = append(usages, usage)
+	}
+	for i := range usages {
+		u := &usages[i]
+		family, _, flexible := InstanceUnits(u.InstanceType)
+		if !u.Matched || !flexible || u.Scope != "Region" || u.Tenancy != "default" {
+			continue
+		}
+		u.UsedUnits = apply(pending[coverageKey{u.Region, family}], u.Units, func(p *pendingInstance) bool {
+			return p.tenancy == "default"
+		})
+	}
+
+	for i := range usages {
+		u := &usages[i]
+		if !u.Matched {
+			continue
+		}
+		u.UnusedUnits = u.Units - u.UsedUnits
+		if u.Units > 0 {
+			u.UnusedHourlyCost = types.CostValue(u.RecurringHourly * float64(u.InstanceCount) * u.UnusedUnits / u.Units)
+		}
+		family, _, _ := InstanceUnits(u.InstanceType)
+		rows[coverageKey{u.Region, family}].UnusedUnits += u.UnusedUnits
+	}
+
+	coverage := make([]RICoverage, 0, len(rows))
+	for key, r := range rows {
+		for _, p := range pending[key] {
+			r.OnDemandUnits += p.remaining
+			if p.units > 0 {
+				r.OnDemandHourlyCost = r.OnDemandHourlyCost.Add(p.hourlyCost * types.CostValue(p.remaining/p.units))
+			}
+		}
+		r.CoveredUnits = r.RunningUnits - r.OnDemandUnits
+		r.CoveragePercent = Percent(r.CoveredUnits, r.RunningUnits)
+		r.UtilizationPercent = Percent(r.ReservedUnits-r.UnusedUnits, r.ReservedUnits)
+		coverage = append(coverage, *r)
+	}
+	sort.Slice(coverage, func(i, j int) bool {
+		a, b := coverage[i], coverage[j]
+		if a.OnDemandUnits != b.OnDemandUnits {
+			return a.OnDemandUnits > b.OnDemandUnits
+		}
+		if a.UnusedUnits != b.UnusedUnits {
+			return a.UnusedUnits > b.UnusedUnits
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.Family < b.Family
+	})
+	sort.SliceStable(usages, func(i, j int) bool { return usages[i].UnusedUnits > usages[j].UnusedUnits })
+	return coverage, usages
+}
+
+// apply covers up to units of the pending instances that match, and returns
+// the units used
+func apply(pending []*pendingInstance, units float64, match func(*pendingInstance) bool) float64 {
+	var used float64
+	for _, p := range pending {
+		if units-used <= 0 {
+			break
+		}
+		if p.remaining <= 0 || !match(p) {
+			continue
+		}
+		take := math.Min(p.remaining, units-used)
+		p.remaining -= take
+		used += take
+	}
+	return used
+}
+
+// percent returns part as a percentage of whole, rounded to 0.1, or 0 if
+// whole is 0
+func Percent(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(part/whole*1000) / 10
+}
@@ -0,0 +1,75 @@
+package recommend
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestInstanceUnits(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		family       string
+		units        float64
+		flexible     bool
+	}{
+		{"t3.nano", "t3", 0.25, true},
+		{"m5.large", "m5", 4, true},
+		{"m5.xlarge", "m5", 8, true},
+		{"m5.12xlarge", "m5", 96, true},
+		{"m5.metal", "m5.metal", 1, false},
+	}
+	for _, tt := range tests {
+		family, units, flexible := InstanceUnits(tt.instanceType)
+		if family != tt.family || units != tt.units || flexible != tt.flexible {
+			t.Errorf("InstanceUnits(%q) = %q, %v, %v", tt.instanceType, family, units, flexible)
+		}
+	}
+}
+
+func TestReservedInstanceCoverage(t *testing.T) {
+	instances := []types.EC2Instance{
+		{Region: "us-east-1", InstanceType: "m5.xlarge", State: "running", HourlyCost: 0.192},
+		{Region: "us-east-1", InstanceType: "m5.large", State: "running", HourlyCost: 0.096},
+		{Region: "us-east-1", InstanceType: "m5.large", State: "stopped"},
+		{Region: "us-east-1", InstanceType: "c5.large", State: "running", HourlyCost: 0.085, Tenancy: "dedicated"},
+	}
+	reservations := []types.ReservedInstance{
+		// Two regional m5.large cover the xlarge through size flexibility
+		{ID: "ri-flex", Region: "us-east-1", InstanceType: "m5.large", InstanceCount: 2, Platform: "Linux/UNIX", Tenancy: "default", Scope: "Region", RecurringHourly: 0.06},
+		// A zonal reservation only covers its exact type, and is applied first
+		{ID: "ri-zonal", Region: "us-east-1", InstanceType: "m5.large", InstanceCount: 1, Platform: "Linux/UNIX", Tenancy: "default", Scope: "Availability Zone"},
+		// The only c5 instance is a dedicated large, so this goes unused
+		{ID: "ri-unused", Region: "us-east-1", InstanceType: "c5.xlarge", InstanceCount: 1, Platform: "Linux/UNIX", Tenancy: "default", Scope: "Availability Zone", RecurringHourly: 0.1},
+		{ID: "ri-windows", Region: "us-east-1", InstanceType: "m5.large", InstanceCount: 1, Platform: "Windows", Scope: "Region"},
+	}
+
+	coverage, usages := ReservedInstanceCoverage(instances, reservations)
+	if len(coverage) != 2 {
+		t.Fatalf("coverage = %+v, want m5 and c5 rows", coverage)
+	}
+	c5, m5 := coverage[0], coverage[1]
+	if c5.Family != "c5" || c5.OnDemandUnits != 4 || c5.UnusedUnits != 8 || c5.CoveragePercent != 0 || c5.OnDemandHourlyCost != 0.085 {
+		t.Errorf("c5 = %+v, want a 4-unit gap and 8 unused units", c5)
+	}
+	if m5.Family != "m5" || m5.RunningInstances != 2 || m5.RunningUnits != 12 || m5.CoveredUnits != 12 || m5.ReservedUnits != 12 || m5.CoveragePercent != 100 || m5.UtilizationPercent != 100 {
+		t.Errorf("m5 = %+v, want fully covered and used", m5)
+	}
+
+	byID := make(map[string]RIUsage)
+	for _, u := range usages {
+		byID[u.ID] = u
+	}
+	if u := byID["ri-zonal"]; u.UsedUnits != 4 {
+		t.Errorf("zonal = %+v, want the m5.large covered", u)
+	}
+	if u := byID["ri-flex"]; u.UsedUnits != 8 || u.UnusedUnits != 0 {
+		t.Errorf("flexible = %+v, want the m5.xlarge covered", u)
+	}
+	if u := byID["ri-unused"]; u.UnusedUnits != 8 || u.UnusedHourlyCost != 0.1 || usages[0].ID != "ri-unused" {
+		t.Errorf("unused = %+v, want it first with its whole recurring charge", u)
+	}
+	if u := byID["ri-windows"]; u.Matched || u.UnmatchedReason == "" {
+		t.Errorf("windows = %+v, want unmatched", u)
+	}
+}
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// US Letter page size and margins, in points
+const (
+	pageWidth  = 612
+	pageHeight = 792
+	margin     = 50
+)
+
+// PDF fonts. Only the standard Type 1 fonts are used, so nothing is embedded.
+const (
+	fontRegular = "F1" // Helvetica
+	fontBold    = "F2" // Helvetica-Bold
+	fontMono    = "F3" // Courier, used for tables so columns line up
+)
+
+var baseFonts = []struct{ name, base string }{
+	{fontRegular, "Helvetica"},
+	{fontBold, "Helvetica-Bold"},
+	{fontMono, "Courier"},
+}
+
+// monoCharWidth is the width of a Courier character as a fraction of the font size
+const monoCharWidth = 0.6
+
+// pdfWriter lays out lines of text top to bottom, starting a new page when
+// the current one is full
+type pdfWriter struct {
+	pages   []*bytes.Buffer
+	y       float64 // Baseline of the next line on the current page
+	footer  string
+	current *bytes.Buffer
+}
+
+func newPDFWriter(footer string) *pdfWriter {
+	w := &pdfWriter{footer: footer}
+	w.newPage()
+	return w
+}
+
+func (w *pdfWriter) newPage() {
+	w.current = &bytes.Buffer{}
+	w.pages = append(w.pages, w.current)
+	w.y = pageHeight - margin
+}
+
+// text writes s in font at size and moves down by the line height
+func (w *pdfWriter) text(font string, size float64, s string) {
+	lineHeight := size * 1.35
+	if w.y-lineHeight < margin {
+		w.newPage()
+	}
+	w.y -= size
+	w.textAt(margin, w.y, font, size, s)
+	w.y -= lineHeight - size
+}
+
+func (w *pdfWriter) textAt(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(w.current, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapePDF(s))
+}
+
+// space moves down by points
+func (w *pdfWriter) space(points float64) {
+	w.y -= points
+}
+
+// rule draws a horizontal line across the page
+func (w *pdfWriter) rule() {
+	if w.y-6 < margin {
+		w.newPage()
+		return
+	}
+	w.y -= 3
+	fmt.Fprintf(w.current, "0.5 w %d %.2f m %d %.2f l S\n", margin, w.y, pageWidth-margin, w.y)
+	w.y -= 3
+}
+
+// bytes assembles the document
+func (w *pdfWriter) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1 and 2 are the catalog and page tree, then the fonts, then a
+	// page and its content stream for each page
+	fontObj := 3
+	pageObj := fontObj + len(baseFonts)
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj+2*i)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	var fonts []string
+	for i, f := range baseFonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", f.name, fontObj+i))
+	}
+	for i, page := range w.pages {
+		content := page.String()
+		footer := fmt.Sprintf("%s - page %d of %d", w.footer, i+1, len(w.pages))
+		content += fmt.Sprintf("BT /%s 8.0 Tf %d %d Td (%s) Tj ET\n", fontRegular, margin, margin/2, escapePDF(footer))
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fonts, " "), pageObj+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// escapePDF escapes a string for a PDF literal. Characters outside printable
+// ASCII are replaced, since the standard fonts only cover WinAnsi.
+func escapePDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,275 @@
+// Package report renders cost reports as PDFs for finance reviews.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// maxListed limits how many rows each resource and change table lists
+const maxListed = 20
+
+// Report is a cost summary with the top resources and the changes since a
+// baseline snapshot
+type Report struct {
+	GeneratedAt     time.Time
+	Filters         types.AppliedFilters
+	Status          string
+	TotalHourlyCost types.CostValue
+	ResourceCount   int
+	Services        []types.CostGroup
+	Accounts        []types.CostGroup
+	Regions         []types.CostGroup
+	TopResources    []snapshot.Resource
+
+	// Baseline is the snapshot changes are measured from (nil = none found)
+	Baseline *snapshot.Snapshot
+	Diff     snapshot.Diff
+}
+
+// New builds a report for a scan, comparing it against baseline if it is set
+func New(response *types.CostResponse, filters types.AppliedFilters, baseline *snapshot.Snapshot, now time.Time) *Report {
+	resources := snapshot.Resources(response)
+	r := &Report{
+		GeneratedAt:     now.UTC(),
+		Filters:         filters,
+		Status:          response.Status,
+		TotalHourlyCost: response.TotalCost,
+		ResourceCount:   len(resources),
+		Services:        aggregate.GroupBy(resources, []string{aggregate.DimService}),
+		Accounts:        aggregate.GroupBy(resources, []string{aggregate.DimAccountName}),
+		Regions:         aggregate.GroupBy(resources, []string{aggregate.DimRegion}),
+		Baseline:        baseline,
+	}
+
+	top := append([]snapshot.Resource(nil), resources...)
+	sort.SliceStable(top, func(i, j int) bool { return top[i].HourlyCost > top[j].HourlyCost })
+	r.TopResources = limit(top)
+
+	if baseline != nil {
+		before := snapshot.FilterResources(snapshot.Resources(baseline.Response), filters)
+		r.Diff = snapshot.Compare(before, resources)
+	}
+	return r
+}
+
+// PDF renders the report
+func (r *Report) PDF() []byte {
+	w := newPDFWriter("awsCOGS cost report, generated " + r.GeneratedAt.Format("2006-01-02 15:04 UTC"))
+
+	w.text(fontBold, 18, "awsCOGS Cost Report")
+	w.text(fontRegular, 10, "Generated "+r.GeneratedAt.Format("January 2, 2006 15:04 UTC"))
+	w.text(fontRegular, 10, "Scope: "+describeFilters(r.Filters))
+	if r.Status != "" && r.Status != types.ResponseStatusOK {
+		w.text(fontRegular, 10, "Scan status: "+r.Status+" (some accounts, regions, or services could not be scanned)")
+	}
+	w.space(8)
+
+	w.text(fontBold, 13, "Summary")
+	w.rule()
+	w.text(fontRegular, 10, fmt.Sprintf("Projected monthly cost: %s (%s per hour)", money(monthly(r.TotalHourlyCost)), money(r.TotalHourlyCost)))
+	w.text(fontRegular, 10, fmt.Sprintf("Resources: %d", r.ResourceCount))
+	if r.Baseline != nil {
+		previous := r.TotalHourlyCost - r.Diff.HourlyCostDelta
+		w.text(fontRegular, 10, fmt.Sprintf("Change since %s: %s per month (%s)",
+			r.Baseline.Timestamp.Format("2006-01-02"), signedMoney(monthly(r.Diff.HourlyCostDelta)), percentChange(previous, r.TotalHourlyCost)))
+	}
+	w.space(8)
+
+	r.groupTable(w, "Cost by Service", "Service", r.Services)
+	r.groupTable(w, "Cost by Account", "Account", r.Accounts)
+	r.groupTable(w, "Cost by Region", "Region", r.Regions)
+
+	w.text(fontBold, 13, fmt.Sprintf("Top %d Resources", len(r.TopResources)))
+	w.rule()
+	rows := make([][]string, len(r.TopResources))
+	for i, res := range r.TopResources {
+		rows[i] = []string{res.Type, displayName(res), res.AccountName, res.Region, res.Size, money(monthly(res.HourlyCost))}
+	}
+	table(w, []column{{"Type", 10, false}, {"Resource", 30, false}, {"Account", 16, false}, {"Region", 14, false}, {"Size", 14, false}, {"Monthly", 12, true}}, rows)
+	w.space(8)
+
+	r.changes(w)
+	return w.bytes()
+}
+
+func (r *Report) groupTable(w *pdfWriter, title, label string, groups []types.CostGroup) {
+	w.text(fontBold, 13, title)
+	w.rule()
+	rows := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		name := g.Values[0]
+		if name == "" {
+			name = "(none)"
+		}
+		rows = append(rows, []string{name, fmt.Sprint(g.Count), money(monthly(g.TotalCost)), share(g.TotalCost, r.TotalHourlyCost)})
+	}
+	table(w, []column{{label, 40, false}, {"Resources", 10, true}, {"Monthly", 14, true}, {"Share", 8, true}}, rows)
+	w.space(8)
+}
+
+// changes lists resources added, removed, and changed since the baseline
+func (r *Report) changes(w *pdfWriter) {
+	w.text(fontBold, 13, "Changes")
+	w.rule()
+	if r.Baseline == nil {
+		w.text(fontRegular, 10, "No earlier snapshot covers this scope, so changes can't be reported yet.")
+		return
+	}
+	w.text(fontRegular, 10, fmt.Sprintf("Compared with the snapshot taken %s.", r.Baseline.Timestamp.Format("2006-01-02 15:04 UTC")))
+	w.text(fontRegular, 10, fmt.Sprintf("%d added (%s per month), %d removed (%s per month), %d changed (%s per month).",
+		len(r.Diff.Added), signedMoney(monthly(r.Diff.AddedCost)),
+		len(r.Diff.Removed), signedMoney(-monthly(r.Diff.RemovedCost)),
+		len(r.Diff.Changed), signedMoney(monthly(r.Diff.ChangedCost))))
+	w.space(6)
+
+	cols := []column{{"Change", 8, false}, {"Type", 10, false}, {"Resource", 30, false}, {"Account", 16, false}, {"Size", 20, false}, {"Monthly", 12, true}}
+	var rows [][]string
+	for _, res := range limit(r.Diff.Added) {
+		rows = append(rows, []string{"added", res.Type, displayName(res), res.AccountName, res.Size, signedMoney(monthly(res.HourlyCost))})
+	}
+	for _, res := range limit(r.Diff.Removed) {
+		rows = append(rows, []string{"removed", res.Type, displayName(res), res.AccountName, res.Size, signedMoney(-monthly(res.HourlyCost))})
+	}
+	for _, c := range limit(r.Diff.Changed) {
+		size := c.Size
+		if c.PreviousSize != "" && c.PreviousSize != c.Size {
+			size = c.PreviousSize + " > " + c.Size
+		}
+		rows = append(rows, []string{"changed", c.Type, displayName(c.Resource), c.AccountName, size, signedMoney(monthly(c.HourlyCostDelta))})
+	}
+	if len(rows) == 0 {
+		w.text(fontRegular, 10, "No resources changed.")
+		return
+	}
+	table(w, cols, rows)
+}
+
+// column is a fixed-width table column, in characters
+type column struct {
+	title string
+	width int
+	right bool
+}
+
+// table writes rows in a monospaced font with a header and rule
+func table(w *pdfWriter, cols []column, rows [][]string) {
+	const size = 8
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.title
+	}
+	w.text(fontMono, size, formatRow(cols, header))
+	w.rule()
+	for _, row := range rows {
+		w.text(fontMono, size, formatRow(cols, row))
+	}
+}
+
+func formatRow(cols []column, values []string) string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		v := truncate(values[i], c.width)
+		if c.right {
+			cells[i] = fmt.Sprintf("%*s", c.width, v)
+		} else {
+			cells[i] = fmt.Sprintf("%-*s", c.width, v)
+		}
+	}
+	return strings.TrimRight(strings.Join(cells, " "), " ")
+}
+
+func truncate(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	return s[:width-2] + ".."
+}
+
+func displayName(r snapshot.Resource) string {
+	if r.Name != "" && r.Name != r.ID {
+		return r.Name + " (" + r.ID + ")"
+	}
+	return r.ID
+}
+
+func describeFilters(f types.AppliedFilters) string {
+	var parts []string
+	if len(f.Accounts) > 0 {
+		parts = append(parts, "accounts "+strings.Join(f.Accounts, ", "))
+	}
+	if len(f.Regions) > 0 {
+		parts = append(parts, "regions "+strings.Join(f.Regions, ", "))
+	}
+	if len(f.ResourceTypes) > 0 {
+		parts = append(parts, "resource types "+strings.Join(f.ResourceTypes, ", "))
+	}
+	if len(parts) == 0 {
+		return "all accounts, regions, and resource types"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func monthly(hourly types.CostValue) types.CostValue {
+	return hourly * types.HoursPerMonth
+}
+
+func money(v types.CostValue) string {
+	return fmt.Sprintf("$%s", commas(fmt.Sprintf("%.2f", float64(v))))
+}
+
+func signedMoney(v types.CostValue) string {
+	if v < 0 {
+		return "-" + money(-v)
+	}
+	return "+" + money(v)
+}
+
+// commas groups the integer digits of a formatted number in thousands
+func commas(s string) string {
+	intPart, frac, _ := strings.Cut(s, ".")
+	neg := strings.HasPrefix(intPart, "-")
+	intPart = strings.TrimPrefix(intPart, "-")
+	var b strings.Builder
+	for i, d := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	out := b.String()
+	if frac != "" {
+		out += "." + frac
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
+
+func share(part, total types.CostValue) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part/total)*100)
+}
+
+func percentChange(before, after types.CostValue) string {
+	if before == 0 {
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", float64((after-before)/before)*100)
+}
+
+func limit[T any](items []T) []T {
+	if len(items) > maxListed {
+		return items[:maxListed]
+	}
+	return items
+}
@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestReportPDF(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	baseline := &snapshot.Snapshot{
+		ID:        "base",
+		Timestamp: now.AddDate(0, -1, 0),
+		Response: &types.CostResponse{
+			TotalCost: 0.2,
+			EC2Instances: []types.EC2Instance{
+				{AccountID: "111", AccountName: "prod", Region: "us-east-1", InstanceID: "i-1", InstanceType: "m5.large", HourlyCost: 0.1},
+				{AccountID: "111", AccountName: "prod", Region: "us-east-1", InstanceID: "i-old", InstanceType: "m5.large", HourlyCost: 0.1},
+			},
+		},
+	}
+	response := &types.CostResponse{
+		TotalCost: 0.5,
+		EC2Instances: []types.EC2Instance{
+			{AccountID: "111", AccountName: "prod", Region: "us-east-1", InstanceID: "i-1", Name: "api (blue)", InstanceType: "m5.xlarge", HourlyCost: 0.2},
+		},
+		EBSVolumes: []types.EBSVolume{
+			{AccountID: "111", AccountName: "prod", Region: "us-west-2", VolumeID: "vol-1", VolumeType: "gp3", Size: 100, HourlyCost: 0.3},
+		},
+	}
+
+	r := New(response, types.AppliedFilters{}, baseline, now)
+	if len(r.Services) != 2 || r.Services[0].Values[0] != "ebs" {
+		t.Fatalf("expected services by cost, got %+v", r.Services)
+	}
+	if len(r.Diff.Added) != 1 || len(r.Diff.Removed) != 1 || len(r.Diff.Changed) != 1 {
+		t.Fatalf("unexpected diff: %+v", r.Diff)
+	}
+
+	pdf := r.PDF()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("expected a PDF header and trailer")
+	}
+	for _, want := range []string{"awsCOGS Cost Report", "Cost by Service", "$365.00", `api \(blue\) \(i-1\)`, "m5.large > m5.xlarge", "removed"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("PDF missing %q", want)
+		}
+	}
+
+	// The xref table must point at each object
+	xref := bytes.LastIndex(pdf, []byte("xref\n"))
+	for i, line := range strings.Split(string(pdf[xref:]), "\n")[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		var offset int
+		if _, err := fmt.Sscan(line, &offset); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(pdf[offset:], []byte(strconv.Itoa(i+1)+" 0 obj")) {
+			t.Fatalf("xref entry %d points at %q", i+1, pdf[offset:offset+10])
+		}
+	}
+}
+
+func TestReportWithoutBaseline(t *testing.T) {
+	r := New(&types.CostResponse{}, types.AppliedFilters{Regions: []string{"us-east-1"}}, nil, time.Now())
+	pdf := r.PDF()
+	if !bytes.Contains(pdf, []byte("No earlier snapshot")) || !bytes.Contains(pdf, []byte("regions us-east-1")) {
+		t.Fatal("expected the report to note the missing baseline and scope")
+	}
+}
+
+func TestCommas(t *testing.T) {
+	for in, want := range map[string]string{"0.50": "0.50", "1234.00": "1,234.00", "1234567.89": "1,234,567.89", "123456.00": "123,456.00"} {
+		if got := commas(in); got != want {
+			t.Errorf("commas(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
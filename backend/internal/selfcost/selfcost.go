@@ -0,0 +1,171 @@
+// Package selfcost estimates what awsCOGS itself costs to run by counting
+// the AWS API calls each scan makes. Most of them are free, but CloudWatch
+// bills GetMetricData per metric requested and some of its other APIs per
+// request.
+package selfcost
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// CloudWatch list prices in USD, before the free tier of one million API
+// requests a month
+const (
+	MetricRate  types.CostValue = 0.01 / 1000 // Per metric requested with GetMetricData
+	RequestRate types.CostValue = 0.01 / 1000 // Per request to the operations in billedRequests
+)
+
+// SDK service IDs of the services counted separately
+const (
+	serviceCloudWatch = "CloudWatch"
+	servicePricing    = "Pricing"
+)
+
+// billedRequests are the CloudWatch operations billed per request
+var billedRequests = map[string]bool{
+	"GetMetricStatistics": true,
+	"ListMetrics":         true,
+	"GetDashboard":        true,
+	"ListDashboards":      true,
+}
+
+// Counter counts the AWS API calls made with a context from WithCounter. It
+// is safe for concurrent use, and a nil Counter ignores calls.
+type Counter struct {
+	mu              sync.Mutex
+	calls           map[string]int // By SDK service ID
+	metricDataCalls int
+	metrics         int
+	billed          int
+}
+
+// NewCounter returns a counter with no calls
+func NewCounter() *Counter {
+	return &Counter{calls: make(map[string]int)}
+}
+
+type counterContextKey struct{}
+
+// WithCounter returns a context whose AWS API calls are counted by c
+func WithCounter(ctx context.Context, c *Counter) context.Context {
+	return context.WithValue(ctx, counterContextKey{}, c)
+}
+
+// From returns the counter of ctx's calls, or nil
+func From(ctx context.Context) *Counter {
+	c, _ := ctx.Value(counterContextKey{}).(*Counter)
+	return c
+}
+
+// Record counts one call to service's operation, which requested metrics
+// CloudWatch metrics
+func (c *Counter) Record(service, operation string, metrics int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls[service]++
+	if service != serviceCloudWatch {
+		return
+	}
+	if operation == "GetMetricData" {
+		c.metricDataCalls++
+		c.metrics += metrics
+	} else if billedRequests[operation] {
+		c.billed++
+	}
+}
+
+// APIOption counts every call made with a context from WithCounter. Add it
+// to a config's APIOptions.
+func APIOption(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AWSCOGSSelfCost",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			if c := From(ctx); c != nil {
+				metrics := 0
+				if input, ok := in.Parameters.(*cloudwatch.GetMetricDataInput); ok {
+					metrics = len(input.MetricDataQueries)
+				}
+				c.Record(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), metrics)
+			}
+			return next.HandleInitialize(ctx, in)
+		}), middleware.After)
+}
+
+// Estimate returns the calls counted so far and what they cost, or nil if
+// c is nil
+func (c *Counter) Estimate() *types.SelfCost {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &types.SelfCost{
+		PricingCalls:     c.calls[servicePricing],
+		MetricDataCalls:  c.metricDataCalls,
+		MetricsRequested: c.metrics,
+		BilledRequests:   c.billed,
+		Services:         make([]types.ServiceCalls, 0, len(c.calls)),
+	}
+	for service, calls := range c.calls {
+		s.APICalls += calls
+		s.Services = append(s.Services, types.ServiceCalls{Service: service, Calls: calls})
+	}
+	sortServices(s.Services)
+	s.Cost = cost(s)
+	return s
+}
+
+// Merge returns the sum of a and b, either of which may be nil
+func Merge(a, b *types.SelfCost) *types.SelfCost {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+
+	calls := make(map[string]int)
+	for _, s := range append(append([]types.ServiceCalls(nil), a.Services...), b.Services...) {
+		calls[s.Service] += s.Calls
+	}
+	out := &types.SelfCost{
+		APICalls:         a.APICalls + b.APICalls,
+		PricingCalls:     a.PricingCalls + b.PricingCalls,
+		MetricDataCalls:  a.MetricDataCalls + b.MetricDataCalls,
+		MetricsRequested: a.MetricsRequested + b.MetricsRequested,
+		BilledRequests:   a.BilledRequests + b.BilledRequests,
+		Services:         make([]types.ServiceCalls, 0, len(calls)),
+	}
+	for service, n := range calls {
+		out.Services = append(out.Services, types.ServiceCalls{Service: service, Calls: n})
+	}
+	sortServices(out.Services)
+	out.Cost = cost(out)
+	return out
+}
+
+func cost(s *types.SelfCost) types.CostValue {
+	return (MetricRate * types.CostValue(s.MetricsRequested)).Add(RequestRate * types.CostValue(s.BilledRequests))
+}
+
+// sortServices sorts services by calls, most first
+func sortServices(services []types.ServiceCalls) {
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Calls != services[j].Calls {
+			return services[i].Calls > services[j].Calls
+		}
+		return services[i].Service < services[j].Service
+	})
+}
@@ -0,0 +1,57 @@
+package selfcost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestEstimate(t *testing.T) {
+	c := NewCounter()
+	ctx := WithCounter(context.Background(), c)
+	From(ctx).Record("EC2", "DescribeInstances", 0)
+	From(ctx).Record("EC2", "DescribeVolumes", 0)
+	From(ctx).Record("Pricing", "GetProducts", 0)
+	From(ctx).Record("CloudWatch", "GetMetricData", 500)
+	From(ctx).Record("CloudWatch", "GetMetricData", 300)
+	From(ctx).Record("CloudWatch", "ListMetrics", 0)
+	From(ctx).Record("CloudWatch", "DescribeAlarms", 0)
+
+	got := c.Estimate()
+	if got.APICalls != 7 || got.PricingCalls != 1 || got.MetricDataCalls != 2 || got.MetricsRequested != 800 || got.BilledRequests != 1 {
+		t.Errorf("Estimate() = %+v", got)
+	}
+	// 800 metrics at $0.01 per 1,000 and one request at $0.01 per 1,000
+	if want := types.CostValue(0.00801); got.Cost != want {
+		t.Errorf("cost = %v, want %v", got.Cost, want)
+	}
+	if got.Services[0] != (types.ServiceCalls{Service: "CloudWatch", Calls: 4}) || got.Services[1].Service != "EC2" {
+		t.Errorf("services = %+v, want most calls first", got.Services)
+	}
+}
+
+func TestNilCounter(t *testing.T) {
+	From(context.Background()).Record("EC2", "DescribeInstances", 0)
+	if got := From(context.Background()).Estimate(); got != nil {
+		t.Errorf("Estimate() = %+v, want nil without a counter", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := NewCounter(), NewCounter()
+	a.Record("EC2", "DescribeInstances", 0)
+	a.Record("CloudWatch", "GetMetricData", 100)
+	b.Record("CloudWatch", "GetMetricData", 100)
+
+	if got := Merge(nil, b.Estimate()); got.APICalls != 1 {
+		t.Errorf("Merge(nil, b) = %+v, want b", got)
+	}
+	got := Merge(a.Estimate(), b.Estimate())
+	if got.APICalls != 3 || got.MetricsRequested != 200 || got.Cost != 0.002 {
+		t.Errorf("Merge() = %+v", got)
+	}
+	if len(got.Services) != 2 || got.Services[0] != (types.ServiceCalls{Service: "CloudWatch", Calls: 2}) {
+		t.Errorf("services = %+v", got.Services)
+	}
+}
@@ -0,0 +1,125 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ErrAccountNotFound is returned when an account has no saved metadata
+var ErrAccountNotFound = errors.New("account metadata not found")
+
+// AccountMetadataSpec is the part of an account's metadata that clients set
+type AccountMetadataSpec struct {
+	DisplayName string `json:"displayName,omitempty"`
+	Environment string `json:"environment,omitempty"` // e.g. prod, staging, dev, sandbox
+	OwnerEmail  string `json:"ownerEmail,omitempty"`
+	Color       string `json:"color,omitempty"` // #rrggbb
+}
+
+// AccountMetadata labels an account ID for display
+type AccountMetadata struct {
+	AccountID string `json:"accountId"`
+	AccountMetadataSpec
+	Source    string `json:"source"`              // "config" or "api"
+	UpdatedAt string `json:"updatedAt,omitempty"` // When it was last saved through the API, RFC 3339
+}
+
+// Sources of account metadata
+const (
+	AccountMetadataFromConfig = "config"
+	AccountMetadataFromAPI    = "api"
+)
+
+// accountsDir is where account metadata is persisted, below the snapshot
+// directory so it isn't loaded as snapshots
+const accountsDir = "accounts"
+
+func (s *Store) loadAccounts() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, accountsDir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("listing account metadata: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading account metadata %s: %w", path, err)
+		}
+		var m AccountMetadata
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("parsing account metadata %s: %w", path, err)
+		}
+		s.accounts[m.AccountID] = &m
+	}
+	return nil
+}
+
+// AccountMetadata returns the metadata saved through the API, sorted by
+// account ID
+func (s *Store) AccountMetadata() []AccountMetadata {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	accounts := make([]AccountMetadata, 0, len(s.accounts))
+	for _, m := range s.accounts {
+		accounts = append(accounts, *m)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+	return accounts
+}
+
+// SetAccountMetadata saves an account's metadata, replacing any saved before
+func (s *Store) SetAccountMetadata(accountID string, spec AccountMetadataSpec) (AccountMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := &AccountMetadata{AccountID: accountID, AccountMetadataSpec: spec, Source: AccountMetadataFromAPI, UpdatedAt: time.Now().UTC().Format(time.RFC3339)}
+	if err := s.saveAccount(m); err != nil {
+		return AccountMetadata{}, err
+	}
+	return *m, nil
+}
+
+// DeleteAccountMetadata removes an account's saved metadata
+func (s *Store) DeleteAccountMetadata(accountID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[accountID]; !ok {
+		return ErrAccountNotFound
+	}
+	if s.dir != "" {
+		if err := os.Remove(s.accountPath(accountID)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing account metadata %s: %w", accountID, err)
+		}
+	}
+	delete(s.accounts, accountID)
+	return nil
+}
+
+// saveAccount persists and stores m. Callers must hold mu.
+func (s *Store) saveAccount(m *AccountMetadata) error {
+	if s.dir != "" {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("encoding account metadata: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Join(s.dir, accountsDir), 0o755); err != nil {
+			return fmt.Errorf("creating accounts directory: %w", err)
+		}
+		if err := os.WriteFile(s.accountPath(m.AccountID), data, 0o644); err != nil {
+			return fmt.Errorf("writing account metadata: %w", err)
+		}
+	}
+	s.accounts[m.AccountID] = m
+	return nil
+}
+
+func (s *Store) accountPath(accountID string) string {
+	return filepath.Join(s.dir, accountsDir, accountID+".json")
+}
@@ -0,0 +1,38 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountMetadataPersists(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	m, err := store.SetAccountMetadata("111111111111", AccountMetadataSpec{DisplayName: "Payments prod", Environment: "prod"})
+	if err != nil {
+		t.Fatalf("SetAccountMetadata() error = %v", err)
+	}
+	if m.Source != AccountMetadataFromAPI || m.UpdatedAt == "" {
+		t.Errorf("SetAccountMetadata() = %+v, want source api with an update time", m)
+	}
+	if _, err := store.SetAccountMetadata("222222222222", AccountMetadataSpec{Color: "#ff0000"}); err != nil {
+		t.Fatalf("SetAccountMetadata() error = %v", err)
+	}
+	if err := store.DeleteAccountMetadata("222222222222"); err != nil {
+		t.Fatalf("DeleteAccountMetadata() error = %v", err)
+	}
+	if err := store.DeleteAccountMetadata("222222222222"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("DeleteAccountMetadata() of a deleted account error = %v, want ErrAccountNotFound", err)
+	}
+
+	reloaded, err := NewStore(store.dir, 10)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	accounts := reloaded.AccountMetadata()
+	if len(accounts) != 1 || accounts[0].AccountID != "111111111111" || accounts[0].DisplayName != "Payments prod" {
+		t.Fatalf("AccountMetadata() after reload = %+v, want only 111111111111", accounts)
+	}
+}
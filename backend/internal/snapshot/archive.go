@@ -0,0 +1,337 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidArchive is returned when an archive to import is corrupt or
+// wasn't written by Export
+var ErrInvalidArchive = errors.New("invalid snapshot archive")
+
+// ErrArchiveTooLarge is returned when an archive to import decompresses to
+// more than the size limit or has more than maxImportEntries entries
+var ErrArchiveTooLarge = errors.New("snapshot archive too large")
+
+// maxImportEntries is the most entries an archive to import may have
+const maxImportEntries = 100000
+
+// archiveFormat is the version of the export archive layout
+const archiveFormat = 1
+
+// archiveManifest describes an export archive. It is the archive's first
+// entry.
+type archiveManifest struct {
+	Format      int       `json:"format"`
+	ExportedAt  time.Time `json:"exportedAt"`
+	Snapshots   int       `json:"snapshots"`
+	Views       int       `json:"views"`
+	Accounts    int       `json:"accounts"`
+	IgnoreRules int       `json:"ignoreRules"`
+	Baselines   int       `json:"baselines"`
+}
+
+// ImportResult counts what an import added to the store
+type ImportResult struct {
+	Snapshots   int `json:"snapshots"`   // Snapshots added
+	Views       int `json:"views"`       // Views added
+	Accounts    int `json:"accounts"`    // Account metadata added
+	IgnoreRules int `json:"ignoreRules"` // Ignore rules added
+	Baselines   int `json:"baselines"`   // Baselines added
+	Skipped     int `json:"skipped"`     // Snapshots, views, account metadata, ignore rules, and baselines the store already had
+}
+
+// Export writes every snapshot, saved view, account's metadata, ignore rule,
+// and baseline to w as a gzipped tar archive, with a manifest.json followed
+// by snapshots/<id>.json, views/<id>.json, accounts/<id>.json,
+// ignore/<id>.json, and baselines/<id>.json. The archive can be imported
+// into another store.
+func (s *Store) Export(w io.Writer) error {
+	// Snapshots and views are never modified in place, so copying the
+	// pointers is enough to write them without holding the lock
+	s.mu.RLock()
+	snapshots := append([]*Snapshot(nil), s.snapshots...)
+	views := make([]*View, 0, len(s.views))
+	for _, v := range s.views {
+		views = append(views, v)
+	}
+	accounts := make([]*AccountMetadata, 0, len(s.accounts))
+	for _, m := range s.accounts {
+		accounts = append(accounts, m)
+	}
+	ignores := make([]*IgnoreRule, 0, len(s.ignores))
+	for _, rule := range s.ignores {
+		ignores = append(ignores, rule)
+	}
+	baselines := make([]*Baseline, 0, len(s.baselines))
+	for _, b := range s.baselines {
+		baselines = append(baselines, b)
+	}
+	s.mu.RUnlock()
+	sort.Slice(views, func(i, j int) bool { return views[i].ID < views[j].ID })
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+	sort.Slice(ignores, func(i, j int) bool { return ignores[i].ID < ignores[j].ID })
+	sort.Slice(baselines, func(i, j int) bool { return baselines[i].ID < baselines[j].ID })
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now().UTC()
+	write := func(name string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("encoding %s: %w", name, err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: now}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+
+	manifest := archiveManifest{Format: archiveFormat, ExportedAt: now, Snapshots: len(snapshots), Views: len(views), Accounts: len(accounts), IgnoreRules: len(ignores), Baselines: len(baselines)}
+	if err := write("manifest.json", manifest); err != nil {
+		return err
+	}
+	for _, snap := range snapshots {
+		if err := write("snapshots/"+snap.ID+".json", snap); err != nil {
+			return err
+		}
+	}
+	for _, view := range views {
+		if err := write(viewsDir+"/"+view.ID+".json", view); err != nil {
+			return err
+		}
+	}
+	for _, m := range accounts {
+		if err := write(accountsDir+"/"+m.AccountID+".json", m); err != nil {
+			return err
+		}
+	}
+	for _, rule := range ignores {
+		if err := write(ignoresDir+"/"+rule.ID+".json", rule); err != nil {
+			return err
+		}
+	}
+	for _, b := range baselines {
+		if err := write(baselinesDir+"/"+b.ID+".json", b); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Import adds the snapshots, views, account metadata, ignore rules, and
+// baselines in an archive written by Export. Those whose IDs the store
+// already has are skipped, and the retention policy and max count are
+// applied afterwards. Observers aren't notified of imported snapshots.
+// Archives that decompress to more than maxBytes are rejected with
+// ErrArchiveTooLarge before anything is added.
+func (s *Store) Import(r io.Reader, maxBytes int64) (ImportResult, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return ImportResult{}, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+	// Reading one byte past the limit tells an archive of exactly maxBytes
+	// from a larger one, which is cut short and fails to parse
+	limited := &io.LimitedReader{R: gz, N: maxBytes + 1}
+	tooLarge := func(err error) error {
+		if limited.N <= 0 {
+			return fmt.Errorf("%w: over %d bytes decompressed", ErrArchiveTooLarge, maxBytes)
+		}
+		return err
+	}
+	tr := tar.NewReader(limited)
+
+	var (
+		snapshots []*Snapshot
+		views     []*View
+		accounts  []*AccountMetadata
+		ignores   []*IgnoreRule
+		baselines []*Baseline
+		manifest  bool
+	)
+	for entries := 0; ; entries++ {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ImportResult{}, tooLarge(fmt.Errorf("%w: %w", ErrInvalidArchive, err))
+		}
+		if entries == maxImportEntries {
+			return ImportResult{}, fmt.Errorf("%w: over %d entries", ErrArchiveTooLarge, maxImportEntries)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		dec := json.NewDecoder(tr)
+		switch dir, name := path.Split(hdr.Name); {
+		case hdr.Name == "manifest.json":
+			var m archiveManifest
+			if err := dec.Decode(&m); err != nil {
+				return ImportResult{}, tooLarge(fmt.Errorf("%w: parsing manifest: %w", ErrInvalidArchive, err))
+			}
+			if m.Format != archiveFormat {
+				return ImportResult{}, fmt.Errorf("%w: unsupported format %d", ErrInvalidArchive, m.Format)
+			}
+			manifest = true
+		case dir == "snapshots/" && strings.HasSuffix(name, ".json"):
+			var snap Snapshot
+			if err := dec.Decode(&snap); err != nil {
+				return ImportResult{}, tooLarge(fmt.Errorf("%w: parsing snapshot %s: %w", ErrInvalidArchive, name, err))
+			}
+			// The ID names the snapshot's file, so it must be what Add would use
+			snap.Timestamp = snap.Timestamp.UTC()
+			if snap.Response == nil || snap.ID != snap.Timestamp.Format(idFormat) {
+				return ImportResult{}, fmt.Errorf("%w: bad snapshot %s", ErrInvalidArchive, name)
+			}
+			snapshots = append(snapshots, &snap)
+		case dir == viewsDir+"/" && strings.HasSuffix(name, ".json"):
+			var view View
+			if err := dec.Decode(&view); err != nil {
+				return ImportResult{}, tooLarge(fmt.Errorf("%w: parsing view %s: %w", ErrInvalidArchive, name, err))
+			}
+			if view.ID == "" || viewID(view.ID) != view.ID {
+				return ImportResult{}, fmt.Errorf("%w: bad view %s", ErrInvalidArchive, name)
+			}
+			views = append(views, &view)
+		case dir == accountsDir+"/" && strings.HasSuffix(name, ".json"):
+			var m AccountMetadata
+			if err := dec.Decode(&m); err != nil {
+				return ImportResult{}, tooLarge(fmt.Errorf("%w: parsing account metadata %s: %w", ErrInvalidArchive, name, err))
+			}
+			if !validAccountID(m.AccountID) {
+				return ImportResult{}, fmt.Errorf("%w: bad account metadata %s", ErrInvalidArchive, name)
+			}
+			accounts = append(accounts, &m)
+		case dir == ignoresDir+"/" && strings.HasSuffix(name, ".json"):
+			var rule IgnoreRule
+			if err := dec.Decode(&rule); err != nil {
+				return ImportResult{}, tooLarge(fmt.Errorf("%w: parsing ignore rule %s: %w", ErrInvalidArchive, name, err))
+			}
+			if rule.ID == "" || ignoreRuleID(rule.ID) != rule.ID {
+				return ImportResult{}, fmt.Errorf("%w: bad ignore rule %s", ErrInvalidArchive, name)
+			}
+			ignores = append(ignores, &rule)
+		case dir == baselinesDir+"/" && strings.HasSuffix(name, ".json"):
+			var b Baseline
+			if err := dec.Decode(&b); err != nil {
+				return ImportResult{}, tooLarge(fmt.Errorf("%w: parsing baseline %s: %w", ErrInvalidArchive, name, err))
+			}
+			if b.ID == "" || baselineID(b.ID) != b.ID || b.SnapshotID == "" {
+				return ImportResult{}, fmt.Errorf("%w: bad baseline %s", ErrInvalidArchive, name)
+			}
+			baselines = append(baselines, &b)
+		}
+	}
+	if err := tooLarge(nil); err != nil {
+		return ImportResult{}, err
+	}
+	if !manifest {
+		return ImportResult{}, fmt.Errorf("%w: no manifest.json", ErrInvalidArchive)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result ImportResult
+	existing := make(map[string]bool, len(s.snapshots))
+	for _, snap := range s.snapshots {
+		existing[snap.ID] = true
+	}
+	for _, snap := range snapshots {
+		if existing[snap.ID] {
+			result.Skipped++
+			continue
+		}
+		if s.dir != "" {
+			data, err := json.Marshal(snap)
+			if err != nil {
+				return result, fmt.Errorf("encoding snapshot: %w", err)
+			}
+			if err := os.WriteFile(s.path(snap.ID), data, 0o644); err != nil {
+				return result, fmt.Errorf("writing snapshot: %w", err)
+			}
+		}
+		existing[snap.ID] = true
+		s.snapshots = append(s.snapshots, snap)
+		result.Snapshots++
+	}
+	for _, view := range views {
+		if _, ok := s.views[view.ID]; ok {
+			result.Skipped++
+			continue
+		}
+		if err := s.saveView(view); err != nil {
+			return result, err
+		}
+		result.Views++
+	}
+	for _, m := range accounts {
+		if _, ok := s.accounts[m.AccountID]; ok {
+			result.Skipped++
+			continue
+		}
+		if err := s.saveAccount(m); err != nil {
+			return result, err
+		}
+		result.Accounts++
+	}
+	for _, rule := range ignores {
+		if _, ok := s.ignores[rule.ID]; ok {
+			result.Skipped++
+			continue
+		}
+		if err := s.saveIgnoreRule(rule); err != nil {
+			return result, err
+		}
+		result.IgnoreRules++
+	}
+	for _, b := range baselines {
+		if _, ok := s.baselines[b.ID]; ok {
+			result.Skipped++
+			continue
+		}
+		if err := s.saveBaseline(b); err != nil {
+			return result, err
+		}
+		result.Baselines++
+	}
+
+	sort.SliceStable(s.snapshots, func(i, j int) bool {
+		return s.snapshots[i].Timestamp.Before(s.snapshots[j].Timestamp)
+	})
+	err = s.prune()
+	if result.Snapshots > 0 {
+		s.bump(time.Now().UTC())
+	}
+	return result, err
+}
+
+// validAccountID reports whether id is a 12-digit AWS account ID, which is
+// safe to use as a file name
+func validAccountID(id string) bool {
+	if len(id) != 12 {
+		return false
+	}
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src, err := NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	now := time.Now().UTC()
+	first, _ := src.Add(now.Add(-time.Hour), &types.CostResponse{TotalCost: 1})
+	if _, err := src.Add(now, &types.CostResponse{TotalCost: 2}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := src.CreateView(ViewSpec{Name: "Prod", Accounts: []string{"111"}}); err != nil {
+		t.Fatalf("CreateView() error = %v", err)
+	}
+	if _, err := src.SetAccountMetadata("111111111111", AccountMetadataSpec{DisplayName: "Production", Environment: "prod"}); err != nil {
+		t.Fatalf("SetAccountMetadata() error = %v", err)
+	}
+
+	var archive bytes.Buffer
+	if err := src.Export(&archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// The destination already has the first snapshot, so it is skipped
+	dst, err := NewStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := dst.Add(first.Timestamp, first.Response); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	result, err := dst.Import(bytes.NewReader(archive.Bytes()), 1<<20)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result != (ImportResult{Snapshots: 1, Views: 1, Accounts: 1, Skipped: 1}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	list := dst.List()
+	if len(list) != 2 || list[1].Response.TotalCost != 2 || !list[0].Timestamp.Before(list[1].Timestamp) {
+		t.Fatalf("unexpected snapshots after import: %+v", list)
+	}
+	if view, ok := dst.View("prod"); !ok || view.Accounts[0] != "111" {
+		t.Fatalf("expected the prod view to be imported, got %+v", view)
+	}
+
+	// Imported snapshots are persisted like added ones
+	reopened, err := NewStore(dst.dir, 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if len(reopened.List()) != 2 || len(reopened.Views()) != 1 || len(reopened.AccountMetadata()) != 1 {
+		t.Fatalf("expected the import to be persisted, got %d snapshots, %d views, and %d accounts", len(reopened.List()), len(reopened.Views()), len(reopened.AccountMetadata()))
+	}
+	if m := reopened.AccountMetadata()[0]; m.DisplayName != "Production" || m.Source != AccountMetadataFromAPI {
+		t.Fatalf("unexpected account metadata %+v", m)
+	}
+}
+
+func TestImportRejectsInvalidArchives(t *testing.T) {
+	store, err := NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := store.Import(strings.NewReader("not an archive"), 1<<20); !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("expected ErrInvalidArchive, got %v", err)
+	}
+}
+
+func TestImportRejectsLargeArchives(t *testing.T) {
+	src, err := NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := src.Add(time.Now(), &types.CostResponse{TotalCost: 1}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	var archive bytes.Buffer
+	if err := src.Export(&archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	dst, err := NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := dst.Import(bytes.NewReader(archive.Bytes()), 512); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("expected ErrArchiveTooLarge, got %v", err)
+	}
+	if len(dst.List()) != 0 {
+		t.Fatalf("expected nothing imported from a rejected archive, got %d snapshots", len(dst.List()))
+	}
+}
@@ -0,0 +1,188 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+var (
+	// ErrBaselineNotFound is returned when a baseline doesn't exist
+	ErrBaselineNotFound = errors.New("baseline not found")
+	// ErrSnapshotNotFound is returned when a baseline names a snapshot the
+	// store doesn't have
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+)
+
+// BaselineSpec is the part of a baseline that clients set
+type BaselineSpec struct {
+	Name        string `json:"name"` // e.g. "pre-migration" or "Q3 budget"
+	Description string `json:"description,omitempty"`
+	SnapshotID  string `json:"snapshotId,omitempty"` // Defaults to the latest unfiltered snapshot
+}
+
+// Baseline pins a snapshot under a name, so costs can be compared against it
+// for as long as it's kept. Pinned snapshots are exempt from retention and
+// the max count.
+type Baseline struct {
+	ID string `json:"id"` // Derived from the name when the baseline is created
+	BaselineSpec
+	SnapshotTimestamp time.Time `json:"snapshotTimestamp"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// baselinesDir is where baselines are persisted, below the snapshot
+// directory so they aren't loaded as snapshots
+const baselinesDir = "baselines"
+
+// baselineID turns a baseline name into its ID, e.g. "Q3 budget" becomes
+// "q3-budget"
+func baselineID(name string) string {
+	return slugID(name, "baseline")
+}
+
+func (s *Store) loadBaselines() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, baselinesDir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("listing baselines: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading baseline %s: %w", path, err)
+		}
+		var b Baseline
+		if err := json.Unmarshal(data, &b); err != nil {
+			return fmt.Errorf("parsing baseline %s: %w", path, err)
+		}
+		s.baselines[b.ID] = &b
+	}
+	return nil
+}
+
+// Baselines returns the baselines, oldest snapshot first
+func (s *Store) Baselines() []Baseline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baselines := make([]Baseline, 0, len(s.baselines))
+	for _, b := range s.baselines {
+		baselines = append(baselines, *b)
+	}
+	sort.Slice(baselines, func(i, j int) bool {
+		if !baselines[i].SnapshotTimestamp.Equal(baselines[j].SnapshotTimestamp) {
+			return baselines[i].SnapshotTimestamp.Before(baselines[j].SnapshotTimestamp)
+		}
+		return baselines[i].ID < baselines[j].ID
+	})
+	return baselines
+}
+
+// Baseline returns the baseline with id and its snapshot
+func (s *Store) Baseline(id string) (Baseline, *Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.baselines[id]
+	if !ok {
+		return Baseline{}, nil, false
+	}
+	return *b, s.find(b.SnapshotID), true
+}
+
+// CreateBaseline pins a snapshot as a new baseline with an ID derived from
+// its name. Without a snapshot ID, the latest unfiltered snapshot is pinned.
+func (s *Store) CreateBaseline(spec BaselineSpec) (Baseline, error) {
+	if spec.SnapshotID == "" {
+		latest := s.Latest(types.AppliedFilters{})
+		if latest == nil {
+			return Baseline{}, ErrSnapshotNotFound
+		}
+		spec.SnapshotID = latest.ID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.find(spec.SnapshotID)
+	if snap == nil {
+		return Baseline{}, ErrSnapshotNotFound
+	}
+	base := baselineID(spec.Name)
+	id := base
+	for n := 2; s.baselines[id] != nil; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	b := &Baseline{ID: id, BaselineSpec: spec, SnapshotTimestamp: snap.Timestamp, CreatedAt: time.Now().UTC()}
+	if err := s.saveBaseline(b); err != nil {
+		return Baseline{}, err
+	}
+	return *b, nil
+}
+
+// DeleteBaseline removes a baseline. Its snapshot is kept until the
+// retention policy or max count drops it.
+func (s *Store) DeleteBaseline(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.baselines[id]; !ok {
+		return ErrBaselineNotFound
+	}
+	if s.dir != "" {
+		if err := os.Remove(s.baselinePath(id)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing baseline %s: %w", id, err)
+		}
+	}
+	delete(s.baselines, id)
+	return s.prune()
+}
+
+// saveBaseline persists and stores b. Callers must hold mu.
+func (s *Store) saveBaseline(b *Baseline) error {
+	if s.dir != "" {
+		data, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Errorf("encoding baseline: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Join(s.dir, baselinesDir), 0o755); err != nil {
+			return fmt.Errorf("creating baselines directory: %w", err)
+		}
+		if err := os.WriteFile(s.baselinePath(b.ID), data, 0o644); err != nil {
+			return fmt.Errorf("writing baseline: %w", err)
+		}
+	}
+	s.baselines[b.ID] = b
+	return nil
+}
+
+func (s *Store) baselinePath(id string) string {
+	return filepath.Join(s.dir, baselinesDir, id+".json")
+}
+
+// find returns the snapshot with id, or nil. Callers must hold mu.
+func (s *Store) find(id string) *Snapshot {
+	i := sort.Search(len(s.snapshots), func(i int) bool { return s.snapshots[i].ID >= id })
+	if i < len(s.snapshots) && s.snapshots[i].ID == id {
+		return s.snapshots[i]
+	}
+	return nil
+}
+
+// pinned returns the IDs of the snapshots baselines pin. Callers must hold
+// mu.
+func (s *Store) pinned() map[string]bool {
+	pinned := make(map[string]bool, len(s.baselines))
+	for _, b := range s.baselines {
+		pinned[b.SnapshotID] = true
+	}
+	return pinned
+}
@@ -0,0 +1,78 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestBaselinesPinSnapshots(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := store.CreateBaseline(BaselineSpec{Name: "empty"}); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("CreateBaseline() without snapshots error = %v, want ErrSnapshotNotFound", err)
+	}
+
+	start := time.Now().UTC().Add(-time.Hour)
+	first, err := store.Add(start, &types.CostResponse{})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	q3, err := store.CreateBaseline(BaselineSpec{Name: "Q3 budget"})
+	if err != nil {
+		t.Fatalf("CreateBaseline() error = %v", err)
+	}
+	if q3.ID != "q3-budget" || q3.SnapshotID != first.ID || !q3.SnapshotTimestamp.Equal(first.Timestamp) {
+		t.Errorf("CreateBaseline() = %+v, want q3-budget pinning the latest snapshot", q3)
+	}
+	if _, err := store.CreateBaseline(BaselineSpec{Name: "missing", SnapshotID: "20000101T000000.000000000Z"}); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("CreateBaseline() of an unknown snapshot error = %v, want ErrSnapshotNotFound", err)
+	}
+
+	// Pinned snapshots don't count towards the max count
+	second, _ := store.Add(start.Add(10*time.Minute), &types.CostResponse{})
+	store.Add(start.Add(20*time.Minute), &types.CostResponse{})
+	store.Add(start.Add(30*time.Minute), &types.CostResponse{})
+	if snaps := store.List(); len(snaps) != 3 || store.find(first.ID) == nil || store.find(second.ID) != nil {
+		t.Fatalf("kept %d snapshots, want the pinned one and the latest two", len(snaps))
+	}
+	if _, snap, ok := store.Baseline(q3.ID); !ok || snap == nil || snap.ID != first.ID {
+		t.Fatalf("Baseline() = %v, %v; want the pinned snapshot", snap, ok)
+	}
+
+	reloaded, err := NewStore(store.dir, 1)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	if baselines := reloaded.Baselines(); len(baselines) != 1 || baselines[0].Name != "Q3 budget" {
+		t.Fatalf("Baselines() after reload = %+v, want Q3 budget", baselines)
+	}
+	if len(reloaded.List()) != 2 || reloaded.find(first.ID) == nil {
+		t.Error("reloading with a lower max count pruned the pinned snapshot")
+	}
+
+	var archive bytes.Buffer
+	if err := reloaded.Export(&archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	imported, _ := NewStore("", 1)
+	result, err := imported.Import(&archive, 1<<20)
+	if err != nil || result.Baselines != 1 || imported.find(first.ID) == nil {
+		t.Errorf("Import() = %+v, %v; want the baseline and its snapshot", result, err)
+	}
+
+	if err := reloaded.DeleteBaseline(q3.ID); err != nil {
+		t.Fatalf("DeleteBaseline() error = %v", err)
+	}
+	if reloaded.find(first.ID) != nil {
+		t.Error("DeleteBaseline() kept the unpinned snapshot beyond the max count")
+	}
+	if err := reloaded.DeleteBaseline(q3.ID); !errors.Is(err, ErrBaselineNotFound) {
+		t.Errorf("DeleteBaseline() of a deleted baseline error = %v, want ErrBaselineNotFound", err)
+	}
+}
@@ -0,0 +1,316 @@
+package snapshot
+
+import (
+	"slices"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Relationship kinds
+const (
+	RelationshipAttachedTo = "attached-to" // this resource is attached to the related one
+	RelationshipAttachment = "attachment"  // the related resource is attached to this one
+)
+
+// Relationship links a resource to another resource from the same scan
+type Relationship struct {
+	Kind         string `json:"kind"`
+	ResourceType string `json:"resourceType"`
+	ID           string `json:"id"`
+	Name         string `json:"name,omitempty"`
+}
+
+// Detail is everything known about a single resource from one snapshot
+type Detail struct {
+	Resource
+	SnapshotID    string                `json:"snapshotId"`
+	DiscoveredAt  time.Time             `json:"discoveredAt"`
+	Tags          map[string]string     `json:"tags,omitempty"`
+	Pricing       []types.CostComponent `json:"pricing"`
+	Relationships []Relationship        `json:"relationships"`
+	Attributes    any                   `json:"attributes"` // The full provider-specific record
+}
+
+// FindResource returns the resource's detail from the most recent snapshot
+// that contains it. Accounts and regions in filters narrow the match when an
+// ID isn't unique. It returns nil if no snapshot contains the resource.
+func (s *Store) FindResource(resourceType, id string, filters types.AppliedFilters) *Detail {
+	snapshots := s.List()
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if detail := FindDetail(snapshots[i], resourceType, id, filters); detail != nil {
+			return detail
+		}
+	}
+	return nil
+}
+
+// FindDetail returns the detail for a resource in snap, or nil if it isn't there
+func FindDetail(snap *Snapshot, resourceType, id string, filters types.AppliedFilters) *Detail {
+	filters.ResourceTypes = []string{resourceType}
+	for _, r := range FilterResources(Resources(snap.Response), filters) {
+		if r.ID != id {
+			continue
+		}
+		detail := &Detail{
+			Resource:      r,
+			SnapshotID:    snap.ID,
+			DiscoveredAt:  snap.Timestamp,
+			Relationships: relationships(snap.Response, r),
+		}
+		detail.Attributes, detail.Tags, detail.Pricing = record(snap.Response, r)
+		return detail
+	}
+	return nil
+}
+
+// record returns the typed record, tags, and cost components for r.
+// Resources without a breakdown report their whole cost as one component.
+func record(resp *types.CostResponse, r Resource) (any, map[string]string, []types.CostComponent) {
+	rec, tags, components := findRecord(resp, r)
+	if len(components) == 0 {
+		components = []types.CostComponent{types.HourlyComponent("total", "hour", 1, r.HourlyCost)}
+	}
+	return rec, tags, components
+}
+
+// findRecord returns the typed record for r along with its tags and cost components
+func findRecord(resp *types.CostResponse, r Resource) (any, map[string]string, []types.CostComponent) {
+	same := func(accountID, region string) bool {
+		return accountID == r.AccountID && region == r.Region
+	}
+
+	switch r.Type {
+	case "ec2":
+		for _, v := range resp.EC2Instances {
+			if v.InstanceID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "ebs":
+		for _, v := range resp.EBSVolumes {
+			if v.VolumeID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "ecs":
+		for _, v := range resp.ECSServices {
+			if v.ClusterName+"/"+v.ServiceName == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "rds":
+		for _, v := range resp.RDSInstances {
+			if v.DBInstanceID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "eks":
+		for _, v := range resp.EKSClusters {
+			if v.ClusterName == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "elb":
+		for _, v := range resp.LoadBalancers {
+			if (v.ARN == r.ID || v.Name == r.ID) && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "nat":
+		for _, v := range resp.NATGateways {
+			if v.ID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "eip":
+		for _, v := range resp.ElasticIPs {
+			if v.AllocationID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "secrets":
+		for _, v := range resp.Secrets {
+			if v.ARN == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "publicipv4":
+		for _, v := range resp.PublicIPv4s {
+			if v.PublicIP == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "lambda":
+		for _, v := range resp.Lambdas {
+			if v.FunctionARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "capacityreservation":
+		for _, v := range resp.CapacityReservations {
+			if v.ID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "dedicatedhost":
+		for _, v := range resp.DedicatedHosts {
+			if v.HostID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	case "kinesis":
+		for _, v := range resp.KinesisStreams {
+			if v.StreamARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "firehose":
+		for _, v := range resp.FirehoseStreams {
+			if v.ARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "sqs":
+		for _, v := range resp.SQSQueues {
+			if v.ARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "sns":
+		for _, v := range resp.SNSTopics {
+			if v.ARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "eventbridge":
+		for _, v := range resp.EventBuses {
+			if v.ARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "alarm":
+		for _, v := range resp.Alarms {
+			if v.ARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "dashboard":
+		for _, v := range resp.Dashboards {
+			if v.ARN == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "custommetrics":
+		for _, v := range resp.CustomMetrics {
+			if v.Namespace == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "vm":
+		for _, v := range resp.VirtualMachines {
+			if v.ID == r.ID && same(v.AccountID, v.Region) {
+				return v, nil, v.CostComponents
+			}
+		}
+	case "datatransfer":
+		for _, v := range resp.DataTransfer {
+			if v.ResourceID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	default:
+		for _, v := range resp.PluginResources {
+			if v.Type == r.Type && v.ID == r.ID && same(v.AccountID, v.Region) {
+				return v, v.Tags, v.CostComponents
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// relationships returns the resources in resp that r is attached to or that
+// are attached to r. Relationships never cross accounts or regions.
+func relationships(resp *types.CostResponse, r Resource) []Relationship {
+	rels := []Relationship{}
+	same := func(accountID, region string) bool {
+		return accountID == r.AccountID && region == r.Region
+	}
+	instanceName := func(instanceID string) string {
+		for _, inst := range resp.EC2Instances {
+			if inst.InstanceID == instanceID && same(inst.AccountID, inst.Region) {
+				return inst.Name
+			}
+		}
+		return ""
+	}
+	attachedTo := func(instanceID string) {
+		if instanceID != "" {
+			rels = append(rels, Relationship{RelationshipAttachedTo, "ec2", instanceID, instanceName(instanceID)})
+		}
+	}
+
+	switch r.Type {
+	case "ec2":
+		for _, v := range resp.EBSVolumes {
+			if same(v.AccountID, v.Region) && slices.Contains(v.AttachedInstanceIDs, r.ID) {
+				rels = append(rels, Relationship{RelationshipAttachment, "ebs", v.VolumeID, v.Name})
+			}
+		}
+		for _, v := range resp.ElasticIPs {
+			if same(v.AccountID, v.Region) && v.InstanceID == r.ID {
+				rels = append(rels, Relationship{RelationshipAttachment, "eip", v.AllocationID, v.Name})
+			}
+		}
+		for _, v := range resp.PublicIPv4s {
+			if same(v.AccountID, v.Region) && v.InstanceID == r.ID {
+				rels = append(rels, Relationship{RelationshipAttachment, "publicipv4", v.PublicIP, ""})
+			}
+		}
+		for _, v := range resp.EC2Instances {
+			if v.InstanceID == r.ID && same(v.AccountID, v.Region) && v.CapacityReservationID != "" {
+				rels = append(rels, Relationship{RelationshipAttachedTo, "capacityreservation", v.CapacityReservationID, ""})
+			}
+		}
+		for _, v := range resp.DedicatedHosts {
+			if same(v.AccountID, v.Region) && slices.Contains(v.InstanceIDs, r.ID) {
+				rels = append(rels, Relationship{RelationshipAttachedTo, "dedicatedhost", v.HostID, v.Name})
+			}
+		}
+	case "ebs":
+		for _, v := range resp.EBSVolumes {
+			if v.VolumeID == r.ID && same(v.AccountID, v.Region) {
+				for _, instanceID := range v.AttachedInstanceIDs {
+					attachedTo(instanceID)
+				}
+			}
+		}
+	case "eip":
+		for _, v := range resp.ElasticIPs {
+			if v.AllocationID == r.ID && same(v.AccountID, v.Region) {
+				attachedTo(v.InstanceID)
+			}
+		}
+	case "publicipv4":
+		for _, v := range resp.PublicIPv4s {
+			if v.PublicIP == r.ID && same(v.AccountID, v.Region) {
+				attachedTo(v.InstanceID)
+			}
+		}
+	case "capacityreservation":
+		for _, v := range resp.EC2Instances {
+			if v.CapacityReservationID == r.ID && same(v.AccountID, v.Region) {
+				rels = append(rels, Relationship{RelationshipAttachment, "ec2", v.InstanceID, v.Name})
+			}
+		}
+	case "dedicatedhost":
+		for _, v := range resp.DedicatedHosts {
+			if v.HostID == r.ID && same(v.AccountID, v.Region) {
+				for _, instanceID := range v.InstanceIDs {
+					rels = append(rels, Relationship{RelationshipAttachment, "ec2", instanceID, instanceName(instanceID)})
+				}
+			}
+		}
+	}
+	return rels
+}
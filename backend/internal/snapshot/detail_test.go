@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestFindResourceUsesNewestSnapshotAndLinksAttachments(t *testing.T) {
+	store, err := NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := store.Add(base, &types.CostResponse{
+		EC2Instances: []types.EC2Instance{{AccountID: "111", Region: "us-east-1", InstanceID: "i-1", InstanceType: "t3.micro"}},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := store.Add(base.Add(time.Hour), &types.CostResponse{
+		EC2Instances: []types.EC2Instance{{AccountID: "111", Region: "us-east-1", InstanceID: "i-1", Name: "web", InstanceType: "t3.large", Tags: map[string]string{"team": "api"}}},
+		EBSVolumes: []types.EBSVolume{
+			{AccountID: "111", Region: "us-east-1", VolumeID: "vol-1", AttachedInstanceIDs: []string{"i-1"}},
+			{AccountID: "222", Region: "us-east-1", VolumeID: "vol-2", AttachedInstanceIDs: []string{"i-1"}},
+		},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	detail := store.FindResource("ec2", "i-1", types.AppliedFilters{})
+	if detail == nil {
+		t.Fatal("expected to find i-1")
+	}
+	if detail.Size != "t3.large" || detail.Tags["team"] != "api" || !detail.DiscoveredAt.Equal(base.Add(time.Hour)) {
+		t.Fatalf("detail = %+v", detail)
+	}
+	if len(detail.Relationships) != 1 || detail.Relationships[0].ID != "vol-1" || detail.Relationships[0].Kind != RelationshipAttachment {
+		t.Fatalf("relationships = %+v", detail.Relationships)
+	}
+
+	volume := store.FindResource("ebs", "vol-1", types.AppliedFilters{})
+	if volume == nil || len(volume.Relationships) != 1 || volume.Relationships[0].Name != "web" {
+		t.Fatalf("volume relationships = %+v", volume)
+	}
+
+	if store.FindResource("ec2", "i-1", types.AppliedFilters{Accounts: []string{"222"}}) != nil {
+		t.Fatal("account filter should exclude i-1")
+	}
+}
@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"math"
+	"sort"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// costEpsilon ignores float noise when comparing hourly costs
+const costEpsilon = 0.000001
+
+// Change describes a resource present in both scans whose size or cost changed
+type Change struct {
+	Resource
+	PreviousSize       string          `json:"previousSize,omitempty"`
+	PreviousState      string          `json:"previousState,omitempty"`
+	PreviousHourlyCost types.CostValue `json:"previousHourlyCost"`
+	HourlyCostDelta    types.CostValue `json:"hourlyCostDelta"`
+}
+
+// Diff is the set of differences between two scans
+type Diff struct {
+	Added           []Resource      `json:"added"`
+	Removed         []Resource      `json:"removed"`
+	Changed         []Change        `json:"changed"`
+	AddedCost       types.CostValue `json:"addedHourlyCost"`
+	RemovedCost     types.CostValue `json:"removedHourlyCost"`
+	ChangedCost     types.CostValue `json:"changedHourlyCost"` // Net delta across changed resources
+	HourlyCostDelta types.CostValue `json:"hourlyCostDelta"`   // Net delta across all resources
+}
+
+// Compare returns the resources added, removed, and changed between before
+// and after. Results are sorted by the size of their cost impact.
+func Compare(before, after []Resource) Diff {
+	previous := make(map[string]Resource, len(before))
+	for _, r := range before {
+		previous[r.Key()] = r
+	}
+
+	diff := Diff{
+		Added:   []Resource{},
+		Removed: []Resource{},
+		Changed: []Change{},
+	}
+
+	seen := make(map[string]bool, len(after))
+	for _, r := range after {
+		key := r.Key()
+		seen[key] = true
+
+		old, ok := previous[key]
+		if !ok {
+			diff.Added = append(diff.Added, r)
+			diff.AddedCost = diff.AddedCost.Add(r.HourlyCost)
+			continue
+		}
+
+		delta := r.HourlyCost - old.HourlyCost
+		if old.Size == r.Size && math.Abs(float64(delta)) < costEpsilon {
+			continue
+		}
+		diff.Changed = append(diff.Changed, Change{
+			Resource:           r,
+			PreviousSize:       old.Size,
+			PreviousState:      old.State,
+			PreviousHourlyCost: old.HourlyCost,
+			HourlyCostDelta:    delta,
+		})
+		diff.ChangedCost = diff.ChangedCost.Add(delta)
+	}
+
+	for _, r := range before {
+		if !seen[r.Key()] {
+			diff.Removed = append(diff.Removed, r)
+			diff.RemovedCost = diff.RemovedCost.Add(r.HourlyCost)
+		}
+	}
+
+	diff.HourlyCostDelta = diff.AddedCost.Add(-diff.RemovedCost).Add(diff.ChangedCost)
+
+	sort.SliceStable(diff.Added, func(i, j int) bool {
+		return diff.Added[i].HourlyCost > diff.Added[j].HourlyCost
+	})
+	sort.SliceStable(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].HourlyCost > diff.Removed[j].HourlyCost
+	})
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		return math.Abs(float64(diff.Changed[i].HourlyCostDelta)) > math.Abs(float64(diff.Changed[j].HourlyCostDelta))
+	})
+
+	return diff
+}
@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestCompare(t *testing.T) {
+	before := []Resource{
+		{Type: "ec2", ID: "i-1", AccountID: "111", Region: "us-east-1", Size: "t3.micro", HourlyCost: 0.0104},
+		{Type: "ec2", ID: "i-2", AccountID: "111", Region: "us-east-1", Size: "m5.large", HourlyCost: 0.096},
+		{Type: "ebs", ID: "vol-1", AccountID: "111", Region: "us-east-1", Size: "gp3 100GiB", HourlyCost: 0.011},
+	}
+	after := []Resource{
+		{Type: "ec2", ID: "i-1", AccountID: "111", Region: "us-east-1", Size: "t3.large", HourlyCost: 0.0832},
+		{Type: "ebs", ID: "vol-1", AccountID: "111", Region: "us-east-1", Size: "gp3 100GiB", HourlyCost: 0.011},
+		{Type: "rds", ID: "db-1", AccountID: "111", Region: "us-east-1", Size: "db.t3.micro 20GiB", HourlyCost: 0.017},
+	}
+
+	diff := Compare(before, after)
+
+	if len(diff.Added) != 1 || diff.Added[0].ID != "db-1" {
+		t.Fatalf("Added = %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != "i-2" {
+		t.Fatalf("Removed = %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].ID != "i-1" || diff.Changed[0].PreviousSize != "t3.micro" {
+		t.Fatalf("Changed = %+v", diff.Changed)
+	}
+
+	want := types.CostValue(0.017 - 0.096 + (0.0832 - 0.0104))
+	if got := diff.HourlyCostDelta; got-want > costEpsilon || want-got > costEpsilon {
+		t.Fatalf("HourlyCostDelta = %v, want %v", got, want)
+	}
+}
+
+func TestStoreAtOrBeforeRespectsFilters(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := store.Add(base, &types.CostResponse{}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := store.Add(base.Add(time.Hour), &types.CostResponse{
+		Filters: types.AppliedFilters{Regions: []string{"us-west-2"}},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	snap := store.AtOrBefore(base.Add(2*time.Hour), types.AppliedFilters{Regions: []string{"us-east-1"}})
+	if snap == nil || !snap.Timestamp.Equal(base) {
+		t.Fatalf("expected unfiltered snapshot, got %+v", snap)
+	}
+
+	reloaded, err := NewStore(store.dir, 1)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	if got := len(reloaded.List()); got != 1 {
+		t.Fatalf("expected reload to prune to 1 snapshot, got %d", got)
+	}
+}
@@ -0,0 +1,148 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// ErrIgnoreRuleNotFound is returned when an ignore rule doesn't exist
+var ErrIgnoreRuleNotFound = errors.New("ignore rule not found")
+
+// IgnoreRuleSpec is the part of an ignore rule that clients set. It matches
+// resources like the rules in the ignore config section.
+type IgnoreRuleSpec struct {
+	Reason        string            `json:"reason"`
+	Resources     []string          `json:"resources,omitempty"` // Resource IDs or names; "*" matches any characters
+	ResourceTypes []string          `json:"resourceTypes,omitempty"`
+	Accounts      []string          `json:"accounts,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+// Config returns the spec as a config file rule
+func (s IgnoreRuleSpec) Config() config.IgnoreRule {
+	return config.IgnoreRule{Reason: s.Reason, Resources: s.Resources, ResourceTypes: s.ResourceTypes, Accounts: s.Accounts, Tags: s.Tags}
+}
+
+// IgnoreRule excludes known exceptions, such as a standby database kept for
+// disaster recovery, from totals and the waste report
+type IgnoreRule struct {
+	ID string `json:"id"` // Derived from the reason when the rule is created
+	IgnoreRuleSpec
+	Source    string `json:"source"`              // "config" or "api"
+	CreatedAt string `json:"createdAt,omitempty"` // When it was created through the API, RFC 3339
+}
+
+// Sources of ignore rules
+const (
+	IgnoreRuleFromConfig = "config"
+	IgnoreRuleFromAPI    = "api"
+)
+
+// ignoresDir is where ignore rules are persisted, below the snapshot
+// directory so they aren't loaded as snapshots
+const ignoresDir = "ignore"
+
+// ignoreRuleID turns a rule's reason into its ID, e.g. "DR standby" becomes
+// "dr-standby"
+func ignoreRuleID(reason string) string {
+	return slugID(reason, "rule")
+}
+
+func (s *Store) loadIgnoreRules() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, ignoresDir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("listing ignore rules: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading ignore rule %s: %w", path, err)
+		}
+		var rule IgnoreRule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return fmt.Errorf("parsing ignore rule %s: %w", path, err)
+		}
+		s.ignores[rule.ID] = &rule
+	}
+	return nil
+}
+
+// IgnoreRules returns the ignore rules created through the API, sorted by
+// ID
+func (s *Store) IgnoreRules() []IgnoreRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]IgnoreRule, 0, len(s.ignores))
+	for _, r := range s.ignores {
+		rules = append(rules, *r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// CreateIgnoreRule saves a new ignore rule with an ID derived from its
+// reason
+func (s *Store) CreateIgnoreRule(spec IgnoreRuleSpec) (IgnoreRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := ignoreRuleID(spec.Reason)
+	id := base
+	for n := 2; s.ignores[id] != nil; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	rule := &IgnoreRule{ID: id, IgnoreRuleSpec: spec, Source: IgnoreRuleFromAPI, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	if err := s.saveIgnoreRule(rule); err != nil {
+		return IgnoreRule{}, err
+	}
+	return *rule, nil
+}
+
+// DeleteIgnoreRule removes an ignore rule created through the API
+func (s *Store) DeleteIgnoreRule(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ignores[id]; !ok {
+		return ErrIgnoreRuleNotFound
+	}
+	if s.dir != "" {
+		if err := os.Remove(s.ignoreRulePath(id)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing ignore rule %s: %w", id, err)
+		}
+	}
+	delete(s.ignores, id)
+	return nil
+}
+
+// saveIgnoreRule persists and stores rule. Callers must hold mu.
+func (s *Store) saveIgnoreRule(rule *IgnoreRule) error {
+	if s.dir != "" {
+		data, err := json.Marshal(rule)
+		if err != nil {
+			return fmt.Errorf("encoding ignore rule: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Join(s.dir, ignoresDir), 0o755); err != nil {
+			return fmt.Errorf("creating ignore rules directory: %w", err)
+		}
+		if err := os.WriteFile(s.ignoreRulePath(rule.ID), data, 0o644); err != nil {
+			return fmt.Errorf("writing ignore rule: %w", err)
+		}
+	}
+	s.ignores[rule.ID] = rule
+	return nil
+}
+
+func (s *Store) ignoreRulePath(id string) string {
+	return filepath.Join(s.dir, ignoresDir, id+".json")
+}
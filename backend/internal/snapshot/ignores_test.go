@@ -0,0 +1,50 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestIgnoreRulesPersist(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	standby, err := store.CreateIgnoreRule(IgnoreRuleSpec{Reason: "DR standby", Resources: []string{"dr-*"}, ResourceTypes: []string{"rds"}})
+	if err != nil {
+		t.Fatalf("CreateIgnoreRule() error = %v", err)
+	}
+	if standby.ID != "dr-standby" || standby.Source != IgnoreRuleFromAPI || standby.CreatedAt == "" {
+		t.Errorf("CreateIgnoreRule() = %+v, want ID dr-standby from the api with a creation time", standby)
+	}
+	dup, err := store.CreateIgnoreRule(IgnoreRuleSpec{Reason: "DR: standby", Tags: map[string]string{"role": "dr"}})
+	if err != nil || dup.ID != "dr-standby-2" {
+		t.Errorf("CreateIgnoreRule() with a taken ID = %q, %v; want dr-standby-2", dup.ID, err)
+	}
+	if err := store.DeleteIgnoreRule(dup.ID); err != nil {
+		t.Fatalf("DeleteIgnoreRule() error = %v", err)
+	}
+	if err := store.DeleteIgnoreRule(dup.ID); !errors.Is(err, ErrIgnoreRuleNotFound) {
+		t.Errorf("DeleteIgnoreRule() of a deleted rule error = %v, want ErrIgnoreRuleNotFound", err)
+	}
+
+	reloaded, err := NewStore(store.dir, 10)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	rules := reloaded.IgnoreRules()
+	if len(rules) != 1 || rules[0].ID != "dr-standby" || rules[0].Resources[0] != "dr-*" {
+		t.Fatalf("IgnoreRules() after reload = %+v, want only dr-standby", rules)
+	}
+
+	var archive bytes.Buffer
+	if err := reloaded.Export(&archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	imported, _ := NewStore("", 10)
+	result, err := imported.Import(&archive, 1<<20)
+	if err != nil || result.IgnoreRules != 1 || len(imported.IgnoreRules()) != 1 {
+		t.Errorf("Import() = %+v, %v; want the ignore rule", result, err)
+	}
+}
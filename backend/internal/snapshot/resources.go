@@ -0,0 +1,364 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Resource is a type-independent view of a single costed resource
+type Resource struct {
+	Type        string                `json:"type"` // Resource filter name: ec2, ebs, rds, ...
+	ID          string                `json:"id"`
+	ARN         string                `json:"arn,omitempty"` // Empty for types without one, such as public IPs
+	Name        string                `json:"name,omitempty"`
+	AccountID   string                `json:"accountId"`
+	AccountName string                `json:"accountName"`
+	Region      string                `json:"region"`
+	Size        string                `json:"size,omitempty"` // Instance type, class, or capacity
+	State       string                `json:"state,omitempty"`
+	VPCID       string                `json:"vpcId,omitempty"`
+	SubnetID    string                `json:"subnetId,omitempty"` // Empty for resources that span subnets
+	HourlyCost  types.CostValue       `json:"hourlyCost"`
+	Components  []types.CostComponent `json:"costComponents,omitempty"`
+	PriceSource string                `json:"priceSource,omitempty"`
+	CreatedAt   string                `json:"createdAt,omitempty"` // RFC 3339, for types that report it
+	CostCenter  string                `json:"costCenter,omitempty"`
+	HighCost    bool                  `json:"highCost,omitempty"`    // Over its type's costThresholds limit
+	Environment string                `json:"environment,omitempty"` // Set by environment.Classifier
+
+	Tags map[string]string `json:"-"` // Used for grouping; detail responses report tags separately
+}
+
+// Key uniquely identifies a resource across snapshots
+func (r Resource) Key() string {
+	return strings.Join([]string{r.Type, r.AccountID, r.Region, r.ID}, "|")
+}
+
+// resourceList is one of a cost response's resource lists, such as its EC2
+// instances
+type resourceList struct {
+	resources func() []Resource              // The list's items as Resources
+	keep      func(keep func(Resource) bool) // Replaces the list with the items keep accepts
+}
+
+// lists returns every resource list in resp. Resources and KeepResources both
+// go through it, so a new resource type only needs adding here.
+func lists(resp *types.CostResponse) []resourceList {
+	return []resourceList{
+		listOf(&resp.EC2Instances, ec2Resource),
+		listOf(&resp.EBSVolumes, ebsResource),
+		listOf(&resp.ECSServices, ecsResource),
+		listOf(&resp.RDSInstances, rdsResource),
+		listOf(&resp.EKSClusters, eksResource),
+		listOf(&resp.LoadBalancers, loadBalancerResource),
+		listOf(&resp.NATGateways, natGatewayResource),
+		listOf(&resp.ElasticIPs, elasticIPResource),
+		listOf(&resp.Secrets, secretResource),
+		listOf(&resp.PublicIPv4s, publicIPv4Resource),
+		listOf(&resp.Lambdas, lambdaResource),
+		listOf(&resp.CapacityReservations, capacityReservationResource),
+		listOf(&resp.DedicatedHosts, dedicatedHostResource),
+		listOf(&resp.KinesisStreams, kinesisStreamResource),
+		listOf(&resp.FirehoseStreams, firehoseStreamResource),
+		listOf(&resp.SQSQueues, sqsQueueResource),
+		listOf(&resp.SNSTopics, snsTopicResource),
+		listOf(&resp.EventBuses, eventBusResource),
+		listOf(&resp.Alarms, alarmResource),
+		listOf(&resp.Dashboards, dashboardResource),
+		listOf(&resp.CustomMetrics, customMetricsResource),
+		listOf(&resp.DataTransfer, dataTransferResource),
+		listOf(&resp.PluginResources, pluginResource),
+		listOf(&resp.VirtualMachines, vmResource),
+	}
+}
+
+func listOf[T any](items *[]T, resource func(T) Resource) resourceList {
+	return resourceList{
+		resources: func() []Resource {
+			out := make([]Resource, len(*items))
+			for i, item := range *items {
+				out[i] = resource(item)
+			}
+			return out
+		},
+		keep: func(keep func(Resource) bool) {
+			var out []T
+			for _, item := range *items {
+				if keep(resource(item)) {
+					out = append(out, item)
+				}
+			}
+			*items = out
+		},
+	}
+}
+
+// Resources flattens every resource in a cost response
+func Resources(resp *types.CostResponse) []Resource {
+	if resp == nil {
+		return nil
+	}
+
+	var out []Resource
+	for _, l := range lists(resp) {
+		out = append(out, l.resources()...)
+	}
+	return out
+}
+
+// KeepResources returns a copy of resp with only the resources keep reports
+// true for. Totals, summaries, and everything else derived from the
+// resources are left as they were.
+func KeepResources(resp *types.CostResponse, keep func(Resource) bool) *types.CostResponse {
+	out := *resp
+	for _, l := range lists(&out) {
+		l.keep(keep)
+	}
+	return &out
+}
+
+func ec2Resource(r types.EC2Instance) Resource {
+	return Resource{
+		Type: "ec2", ID: r.InstanceID, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.InstanceType, State: r.State, VPCID: r.VPCID, SubnetID: r.SubnetID, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func ebsResource(r types.EBSVolume) Resource {
+	return Resource{
+		Type: "ebs", ID: r.VolumeID, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: fmt.Sprintf("%s %dGiB", r.VolumeType, r.Size), State: r.State, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func ecsResource(r types.ECSService) Resource {
+	return Resource{
+		Type: "ecs", ID: r.ClusterName + "/" + r.ServiceName, ARN: r.ARN, Name: r.ServiceName,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: fmt.Sprintf("%s x%d", r.LaunchType, r.DesiredCount), State: r.State, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Components: r.CostComponents,
+	}
+}
+
+func rdsResource(r types.RDSInstance) Resource {
+	size := fmt.Sprintf("%s %dGiB", r.InstanceClass, r.AllocatedStorage)
+	if r.MultiAZ {
+		size += " multi-az"
+	}
+	return Resource{
+		Type: "rds", ID: r.DBInstanceID, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: size, State: r.State, VPCID: r.VPCID, SubnetID: r.SubnetID, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func eksResource(r types.EKSCluster) Resource {
+	return Resource{
+		Type: "eks", ID: r.ClusterName, ARN: r.ARN, Name: r.ClusterName,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.Version, State: r.Status, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func loadBalancerResource(r types.LoadBalancer) Resource {
+	id := r.ARN
+	if id == "" {
+		id = r.Name
+	}
+	return Resource{
+		Type: "elb", ID: id, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.Type, State: r.State, VPCID: r.VPCID, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Components: r.CostComponents,
+	}
+}
+
+func natGatewayResource(r types.NATGateway) Resource {
+	return Resource{
+		Type: "nat", ID: r.ID, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.Type, State: r.State, VPCID: r.VPCID, SubnetID: r.SubnetID, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func elasticIPResource(r types.ElasticIP) Resource {
+	state := "unassociated"
+	if r.IsAssociated {
+		state = "associated"
+	}
+	return Resource{
+		Type: "eip", ID: r.AllocationID, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		State: state, VPCID: r.VPCID, SubnetID: r.SubnetID, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func secretResource(r types.Secret) Resource {
+	state := ""
+	if r.PrimaryRegion != "" {
+		state = "replica"
+	}
+	return Resource{
+		Type: "secrets", ID: r.ARN, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		State: state, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func publicIPv4Resource(r types.PublicIPv4) Resource {
+	return Resource{
+		Type: "publicipv4", ID: r.PublicIP, Name: r.InstanceName,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, Components: r.CostComponents,
+	}
+}
+
+func lambdaResource(r types.LambdaFunction) Resource {
+	return Resource{
+		Type: "lambda", ID: r.FunctionARN, ARN: r.FunctionARN, Name: r.FunctionName,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: fmt.Sprintf("%dMB %s", r.MemorySize, strings.Join(r.Architectures, ",")), State: r.State, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, Components: r.CostComponents,
+	}
+}
+
+func capacityReservationResource(r types.CapacityReservation) Resource {
+	return Resource{
+		Type: "capacityreservation", ID: r.ID, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: fmt.Sprintf("%s x%d", r.InstanceType, r.TotalCount), State: r.State, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func dedicatedHostResource(r types.DedicatedHost) Resource {
+	return Resource{
+		Type: "dedicatedhost", ID: r.HostID, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.InstanceFamily, State: r.State, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func kinesisStreamResource(r types.KinesisStream) Resource {
+	return Resource{
+		Type: "kinesis", ID: r.StreamARN, ARN: r.StreamARN, Name: r.StreamName,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: fmt.Sprintf("%s x%d", r.Mode, r.ShardCount), State: r.Status, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Components: r.CostComponents,
+	}
+}
+
+func firehoseStreamResource(r types.FirehoseStream) Resource {
+	return Resource{
+		Type: "firehose", ID: r.ARN, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.SourceType, State: r.Status, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Components: r.CostComponents,
+	}
+}
+
+func sqsQueueResource(r types.SQSQueue) Resource {
+	return Resource{
+		Type: "sqs", ID: r.ARN, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: queueKind(r.FIFO), HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Components: r.CostComponents,
+	}
+}
+
+func snsTopicResource(r types.SNSTopic) Resource {
+	return Resource{
+		Type: "sns", ID: r.ARN, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: queueKind(r.FIFO), HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, Components: r.CostComponents,
+	}
+}
+
+func eventBusResource(r types.EventBus) Resource {
+	return Resource{
+		Type: "eventbridge", ID: r.ARN, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: fmt.Sprintf("%d rules", len(r.Rules)), HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Components: r.CostComponents,
+	}
+}
+
+func alarmResource(r types.Alarm) Resource {
+	return Resource{
+		Type: "alarm", ID: r.ARN, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.Kind, HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, Components: r.CostComponents,
+	}
+}
+
+func dashboardResource(r types.Dashboard) Resource {
+	return Resource{
+		Type: "dashboard", ID: r.ARN, ARN: r.ARN, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, Components: r.CostComponents,
+	}
+}
+
+func customMetricsResource(r types.CustomMetrics) Resource {
+	return Resource{
+		Type: "custommetrics", ID: r.Namespace, Name: r.Namespace,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: fmt.Sprintf("%d metrics", r.Metrics), HourlyCost: r.HourlyCost, PriceSource: r.PriceSource, CostCenter: r.CostCenter, HighCost: r.HighCost, Components: r.CostComponents,
+	}
+}
+
+func dataTransferResource(r types.DataTransfer) Resource {
+	return Resource{
+		Type: "datatransfer", ID: r.ResourceID, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.ResourceType, HourlyCost: r.HourlyCost, CostCenter: r.CostCenter, HighCost: r.HighCost, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func pluginResource(r types.PluginResource) Resource {
+	return Resource{
+		Type: r.Type, ID: r.ID, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.Size, State: r.State, HourlyCost: r.HourlyCost, CostCenter: r.CostCenter, HighCost: r.HighCost, CreatedAt: r.CreatedAt, Tags: r.Tags, Components: r.CostComponents,
+	}
+}
+
+func vmResource(r types.VirtualMachine) Resource {
+	return Resource{
+		Type: "vm", ID: r.ID, Name: r.Name,
+		AccountID: r.AccountID, AccountName: r.AccountName, Region: r.Region,
+		Size: r.Size, State: r.State, HourlyCost: r.HourlyCost, CostCenter: r.CostCenter, HighCost: r.HighCost, Components: r.CostComponents,
+	}
+}
+
+// FilterResources returns the resources that match the given filters.
+// Accounts match by ID or name; empty filters match everything.
+func FilterResources(resources []Resource, filters types.AppliedFilters) []Resource {
+	var out []Resource
+	for _, r := range resources {
+		if len(filters.Accounts) > 0 && !containsFold(filters.Accounts, r.AccountID) && !containsFold(filters.Accounts, r.AccountName) {
+			continue
+		}
+		if len(filters.Regions) > 0 && !containsFold(filters.Regions, r.Region) {
+			continue
+		}
+		if len(filters.ResourceTypes) > 0 && !containsFold(filters.ResourceTypes, r.Type) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// queueKind returns the size of an SQS queue or SNS topic: fifo or standard
+func queueKind(fifo bool) string {
+	if fifo {
+		return "fifo"
+	}
+	return "standard"
+}
@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// everyResourceType returns a response with one resource in each of its
+// resource lists, found by reflection so a new list can't be missed
+func everyResourceType(t *testing.T) (*types.CostResponse, int) {
+	t.Helper()
+	resp := &types.CostResponse{}
+	v := reflect.ValueOf(resp).Elem()
+	var n int
+	for i := range v.NumField() {
+		f := v.Field(i)
+		if f.Kind() != reflect.Slice || f.Type().Elem().Kind() != reflect.Struct {
+			continue
+		}
+		if _, ok := f.Type().Elem().FieldByName("HourlyCost"); !ok || v.Type().Field(i).Name == "UnpricedResources" || v.Type().Field(i).Name == "IgnoredResources" {
+			continue
+		}
+		f.Set(reflect.MakeSlice(f.Type(), 1, 1))
+		n++
+	}
+	return resp, n
+}
+
+func TestResourcesCoversEveryList(t *testing.T) {
+	resp, n := everyResourceType(t)
+	if got := len(Resources(resp)); got != n {
+		t.Fatalf("got %d resources from %d lists", got, n)
+	}
+
+	kept := KeepResources(resp, func(Resource) bool { return false })
+	if got := len(Resources(kept)); got != 0 {
+		t.Errorf("kept %d resources, want none", got)
+	}
+	if got := len(Resources(resp)); got != n {
+		t.Errorf("KeepResources modified the original, which has %d resources", got)
+	}
+}
@@ -0,0 +1,80 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+)
+
+// SetRetention downsamples the history with r, now and after each snapshot
+// is added
+func (s *Store) SetRetention(r config.RetentionConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.retention = r
+	return s.prune()
+}
+
+// compact drops the snapshots the retention policy no longer keeps at now.
+// Snapshots of differently filtered scans are downsampled separately, so a
+// filtered scan never replaces a full one, and pinned snapshots are kept
+// without standing in for their period. Callers must hold mu or have
+// exclusive access.
+func (s *Store) compact(now time.Time) error {
+	var (
+		kept    []*Snapshot
+		dropped []*Snapshot
+		periods = make(map[string]bool)
+	)
+	pinned := s.pinned()
+	// Newest first, so the last snapshot of each period is the one kept
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		snap := s.snapshots[i]
+		if pinned[snap.ID] {
+			kept = append(kept, snap)
+			continue
+		}
+		period, ok := retentionPeriod(s.retention, snap.Timestamp, now)
+		if ok && period != "" {
+			key := fmt.Sprintf("%s|%v", period, snap.Response.Filters)
+			ok = !periods[key]
+			periods[key] = true
+		}
+		if ok {
+			kept = append(kept, snap)
+		} else {
+			dropped = append(dropped, snap)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	if err := s.remove(dropped); err != nil {
+		return err
+	}
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	s.snapshots = kept
+	return nil
+}
+
+// retentionPeriod returns the period a snapshot taken at ts is downsampled
+// to at now, empty if it is kept as is, and false if it has aged out
+func retentionPeriod(r config.RetentionConfig, ts, now time.Time) (string, bool) {
+	age := now.Sub(ts)
+	switch {
+	case r.RawHours > 0 && age < time.Duration(r.RawHours)*time.Hour:
+		return "", true
+	case r.HourlyDays > 0 && age < time.Duration(r.HourlyDays)*24*time.Hour:
+		return "hour " + ts.Format("2006-01-02T15"), true
+	case r.DailyDays > 0 && age < time.Duration(r.DailyDays)*24*time.Hour:
+		return "day " + ts.Format("2006-01-02"), true
+	case r.MonthlyMonths > 0 && ts.After(now.AddDate(0, -r.MonthlyMonths, 0)):
+		return "month " + ts.Format("2006-01"), true
+	}
+	return "", false
+}
@@ -0,0 +1,66 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestRetentionDownsamples(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, 100)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	now := time.Now().UTC()
+	hour := now.Add(-5 * time.Hour).Truncate(time.Hour)
+	day := now.AddDate(0, 0, -10).Truncate(24 * time.Hour)
+	old := now.AddDate(0, 0, -200)
+	month := time.Date(old.Year(), old.Month(), 1, 0, 0, 0, 0, time.UTC)
+	add := func(ts time.Time, regions ...string) string {
+		snap, err := store.Add(ts, &types.CostResponse{Filters: types.AppliedFilters{Regions: regions}})
+		if err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		return snap.ID
+	}
+	recent1 := add(now.Add(-50 * time.Minute))
+	recent2 := add(now.Add(-30 * time.Minute))
+	add(hour.Add(10 * time.Minute))
+	lastOfHour := add(hour.Add(20 * time.Minute))
+	add(day.Add(time.Hour))
+	lastOfDay := add(day.Add(2 * time.Hour))
+	filtered := add(day.Add(90*time.Minute), "us-east-1")
+	add(month.Add(time.Hour))
+	lastOfMonth := add(month.Add(2 * time.Hour))
+	add(now.AddDate(-3, 0, 0))
+
+	if err := store.SetRetention(config.RetentionConfig{RawHours: 1, HourlyDays: 7, DailyDays: 90, MonthlyMonths: 12}); err != nil {
+		t.Fatalf("SetRetention() error = %v", err)
+	}
+
+	var ids []string
+	for _, snap := range store.List() {
+		ids = append(ids, snap.ID)
+	}
+	want := []string{lastOfMonth, lastOfDay, filtered, lastOfHour, recent1, recent2}
+	slices.Sort(want)
+	slices.Sort(ids)
+	if !slices.Equal(ids, want) {
+		t.Fatalf("kept %v, want %v", ids, want)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != len(want) {
+		t.Fatalf("expected %d snapshot files, got %d", len(want), len(files))
+	}
+	if _, err := os.Stat(filepath.Join(dir, lastOfDay+".json")); err != nil {
+		t.Fatalf("expected the kept snapshot's file to remain: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+const idFormat = "20060102T150405.000000000Z"
+
+// Snapshot is a point-in-time record of a cost scan
+type Snapshot struct {
+	ID        string              `json:"id"`
+	Timestamp time.Time           `json:"timestamp"`
+	Response  *types.CostResponse `json:"response"`
+}
+
+// Store keeps a bounded history of snapshots in memory, optionally
+// persisting each one as a JSON file in a directory. It also keeps the
+// dashboard's saved views, account metadata, ignore rules, and the
+// baselines that pin snapshots.
+type Store struct {
+	mu        sync.RWMutex
+	snapshots []*Snapshot // sorted oldest first
+	dir       string
+	maxCount  int
+	retention config.RetentionConfig
+	observers []func(*Snapshot)
+	views     map[string]*View            // saved views by ID
+	accounts  map[string]*AccountMetadata // account metadata saved through the API, by account ID
+	ignores   map[string]*IgnoreRule      // ignore rules created through the API, by ID
+	baselines map[string]*Baseline        // baselines by ID
+	version   Version
+	changed   chan struct{} // closed and replaced when the version changes
+}
+
+// NewStore creates a snapshot store. If dir is set, existing snapshots and
+// views are loaded from it and new ones are written to it.
+func NewStore(dir string, maxCount int) (*Store, error) {
+	s := &Store{
+		dir:       dir,
+		maxCount:  maxCount,
+		views:     make(map[string]*View),
+		accounts:  make(map[string]*AccountMetadata),
+		ignores:   make(map[string]*IgnoreRule),
+		baselines: make(map[string]*Baseline),
+		changed:   make(chan struct{}),
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating snapshot directory: %w", err)
+	}
+	// Baselines are loaded first so loading snapshots doesn't prune the ones
+	// they pin
+	if err := s.loadBaselines(); err != nil {
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.loadViews(); err != nil {
+		return nil, err
+	}
+	if err := s.loadAccounts(); err != nil {
+		return nil, err
+	}
+	if err := s.loadIgnoreRules(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("listing snapshots: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading snapshot %s: %w", path, err)
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return fmt.Errorf("parsing snapshot %s: %w", path, err)
+		}
+		s.snapshots = append(s.snapshots, &snap)
+	}
+
+	sort.Slice(s.snapshots, func(i, j int) bool {
+		return s.snapshots[i].Timestamp.Before(s.snapshots[j].Timestamp)
+	})
+	if err := s.prune(); err != nil {
+		return err
+	}
+	if n := len(s.snapshots); n > 0 {
+		s.bump(s.snapshots[n-1].Timestamp)
+	}
+	return nil
+}
+
+// Add records a cost response as a new snapshot taken at ts
+func (s *Store) Add(ts time.Time, response *types.CostResponse) (*Snapshot, error) {
+	ts = ts.UTC()
+	snap := &Snapshot{
+		ID:        ts.Format(idFormat),
+		Timestamp: ts,
+		Response:  response,
+	}
+
+	observers, err := s.insert(snap)
+	if err != nil {
+		return nil, err
+	}
+	for _, observe := range observers {
+		observe(snap)
+	}
+	return snap, nil
+}
+
+// insert persists and stores snap, returning the observers to notify
+func (s *Store) insert(snap *Snapshot) ([]func(*Snapshot), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dir != "" {
+		data, err := json.Marshal(snap)
+		if err != nil {
+			return nil, fmt.Errorf("encoding snapshot: %w", err)
+		}
+		if err := os.WriteFile(s.path(snap.ID), data, 0o644); err != nil {
+			return nil, fmt.Errorf("writing snapshot: %w", err)
+		}
+	}
+
+	// Insert in timestamp order; snapshots almost always arrive in order
+	i := sort.Search(len(s.snapshots), func(i int) bool {
+		return s.snapshots[i].Timestamp.After(snap.Timestamp)
+	})
+	s.snapshots = append(s.snapshots, nil)
+	copy(s.snapshots[i+1:], s.snapshots[i:])
+	s.snapshots[i] = snap
+
+	err := s.prune()
+	s.bump(time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(s.observers), nil
+}
+
+// Subscribe registers fn to be called after each snapshot is added
+func (s *Store) Subscribe(fn func(*Snapshot)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.observers = append(s.observers, fn)
+}
+
+// prune applies the retention policy and then drops the oldest snapshots
+// beyond maxCount. Snapshots pinned by baselines are always kept and don't
+// count towards maxCount. Callers must hold mu or have exclusive access.
+func (s *Store) prune() error {
+	if s.retention.Enabled() {
+		if err := s.compact(time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+
+	// Pinned snapshots don't count towards maxCount
+	pinned := s.pinned()
+	excess := -s.maxCount
+	for _, snap := range s.snapshots {
+		if !pinned[snap.ID] {
+			excess++
+		}
+	}
+	if s.maxCount < 1 || excess <= 0 {
+		return nil
+	}
+
+	var kept, dropped []*Snapshot
+	for _, snap := range s.snapshots {
+		if excess > 0 && !pinned[snap.ID] {
+			dropped = append(dropped, snap)
+			excess--
+			continue
+		}
+		kept = append(kept, snap)
+	}
+	if err := s.remove(dropped); err != nil {
+		return err
+	}
+	s.snapshots = kept
+	return nil
+}
+
+// remove deletes the files of persisted snapshots
+func (s *Store) remove(snaps []*Snapshot) error {
+	if s.dir == "" {
+		return nil
+	}
+	for _, snap := range snaps {
+		if err := os.Remove(s.path(snap.ID)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing snapshot %s: %w", snap.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Latest returns the most recent snapshot matching filters, or nil
+func (s *Store) Latest(filters types.AppliedFilters) *Snapshot {
+	return s.AtOrBefore(time.Now().UTC(), filters)
+}
+
+// AtOrBefore returns the most recent snapshot taken at or before ts whose
+// scan covered the requested filters, or nil if there is none.
+func (s *Store) AtOrBefore(ts time.Time, filters types.AppliedFilters) *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		snap := s.snapshots[i]
+		if snap.Timestamp.After(ts) {
+			continue
+		}
+		if Covers(snap.Response.Filters, filters) {
+			return snap
+		}
+	}
+	return nil
+}
+
+// Oldest returns the earliest snapshot whose scan covered the requested
+// filters, or nil if there is none.
+func (s *Store) Oldest(filters types.AppliedFilters) *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, snap := range s.snapshots {
+		if Covers(snap.Response.Filters, filters) {
+			return snap
+		}
+	}
+	return nil
+}
+
+// List returns all snapshots, oldest first
+func (s *Store) List() []*Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]*Snapshot(nil), s.snapshots...)
+}
+
+// Covers reports whether a scan made with the scanned filters includes every
+// resource a scan with the requested filters would return.
+func Covers(scanned, requested types.AppliedFilters) bool {
+	return coversValues(scanned.Accounts, requested.Accounts) &&
+		coversValues(scanned.Regions, requested.Regions) &&
+		coversValues(scanned.ResourceTypes, requested.ResourceTypes)
+}
+
+func coversValues(scanned, requested []string) bool {
+	if len(scanned) == 0 {
+		return true
+	}
+	if len(requested) == 0 {
+		return false
+	}
+	for _, r := range requested {
+		if !containsFold(scanned, r) {
+			return false
+		}
+	}
+	return true
+}
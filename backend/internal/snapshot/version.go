@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+)
+
+// Version identifies the store's latest data. Clients poll it, or wait for it
+// to change, to find out cheaply when a new scan is available instead of
+// refetching cost responses on a timer.
+type Version struct {
+	// Version increases every time snapshots are added. It is the latest
+	// snapshot's time in Unix milliseconds, or one more than the previous
+	// version if that is later, so it keeps increasing across restarts as long
+	// as scans keep being recorded.
+	Version    int64     `json:"version"`
+	SnapshotID string    `json:"snapshotId,omitempty"` // The latest snapshot
+	UpdatedAt  time.Time `json:"updatedAt"`            // When the version last changed
+}
+
+// Version returns the store's current version. It is 0 until the first
+// snapshot is added.
+func (s *Store) Version() Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.version
+}
+
+// WaitNewer blocks until the store's version is greater than after, or ctx is
+// done, and returns the version at that point
+func (s *Store) WaitNewer(ctx context.Context, after int64) Version {
+	for {
+		s.mu.RLock()
+		v, changed := s.version, s.changed
+		s.mu.RUnlock()
+		if v.Version > after {
+			return v
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return v
+		}
+	}
+}
+
+// bump advances the version after snapshots were added, for the latest
+// snapshot the store now has, and wakes WaitNewer callers. Callers must hold
+// mu.
+func (s *Store) bump(now time.Time) {
+	var version int64
+	var id string
+	if n := len(s.snapshots); n > 0 {
+		version, id = s.snapshots[n-1].Timestamp.UnixMilli(), s.snapshots[n-1].ID
+	}
+	if version <= s.version.Version {
+		version = s.version.Version + 1
+	}
+	s.version = Version{Version: version, SnapshotID: id, UpdatedAt: now}
+
+	if s.changed != nil {
+		close(s.changed)
+	}
+	s.changed = make(chan struct{})
+}
@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestVersion(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if v := store.Version(); v.Version != 0 || v.SnapshotID != "" {
+		t.Fatalf("Version() of an empty store = %+v, want 0", v)
+	}
+
+	now := time.Now().UTC()
+	first, _ := store.Add(now, &types.CostResponse{})
+	v1 := store.Version()
+	if v1.Version != now.UnixMilli() || v1.SnapshotID != first.ID {
+		t.Fatalf("Version() = %+v, want the snapshot's time in milliseconds", v1)
+	}
+
+	// A snapshot taken earlier still increases the version
+	store.Add(now.Add(-time.Hour), &types.CostResponse{})
+	if v2 := store.Version(); v2.Version != v1.Version+1 {
+		t.Fatalf("Version() after an older snapshot = %d, want %d", v2.Version, v1.Version+1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if v := store.WaitNewer(ctx, v1.Version+1); v.Version != v1.Version+1 {
+		t.Errorf("WaitNewer() without a new snapshot = %d, want the current version after the timeout", v.Version)
+	}
+
+	done := make(chan Version)
+	go func() { done <- store.WaitNewer(context.Background(), v1.Version+1) }()
+	later, _ := store.Add(now.Add(time.Minute), &types.CostResponse{})
+	select {
+	case v := <-done:
+		if v.SnapshotID != later.ID {
+			t.Errorf("WaitNewer() = %+v, want the new snapshot", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitNewer() didn't return after a snapshot was added")
+	}
+
+	reloaded, err := NewStore(store.dir, 10)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	if v := reloaded.Version(); v.Version != now.Add(time.Minute).UnixMilli() || v.SnapshotID != later.ID {
+		t.Errorf("Version() after reload = %+v, want the latest snapshot's", v)
+	}
+}
+
+func TestVersionAfterImport(t *testing.T) {
+	now := time.Now().UTC()
+	src, err := NewStore("", 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	for i := 3; i > 0; i-- {
+		src.Add(now.Add(-time.Duration(i)*time.Hour), &types.CostResponse{})
+	}
+	var archive bytes.Buffer
+	if err := src.Export(&archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// Only two snapshots are kept, so every imported one is older than the
+	// latest or pruned
+	dst, err := NewStore("", 2)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	latest, _ := dst.Add(now, &types.CostResponse{})
+	before := dst.Version()
+	if _, err := dst.Import(bytes.NewReader(archive.Bytes()), 1<<20); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if v := dst.Version(); v.Version != before.Version+1 || v.SnapshotID != latest.ID {
+		t.Errorf("Version() after import = %+v, want %d for %s", v, before.Version+1, latest.ID)
+	}
+}
@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrViewNotFound is returned when a saved view doesn't exist
+var ErrViewNotFound = errors.New("view not found")
+
+// ViewSpec is the part of a saved view that clients set: a name and the
+// filters and grouping the dashboard applies when the view is opened
+type ViewSpec struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description,omitempty"`
+	Accounts      []string          `json:"accounts,omitempty"`
+	Regions       []string          `json:"regions,omitempty"`
+	ResourceTypes []string          `json:"resourceTypes,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+	GroupBy       []string          `json:"groupBy,omitempty"`
+}
+
+// View is a named filter preset, such as "prod us-east only"
+type View struct {
+	ID string `json:"id"` // Derived from the name when the view is created; stable across renames
+	ViewSpec
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// viewsDir is where views are persisted, below the snapshot directory so
+// they aren't loaded as snapshots
+const viewsDir = "views"
+
+func (s *Store) loadViews() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, viewsDir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("listing views: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading view %s: %w", path, err)
+		}
+		var view View
+		if err := json.Unmarshal(data, &view); err != nil {
+			return fmt.Errorf("parsing view %s: %w", path, err)
+		}
+		s.views[view.ID] = &view
+	}
+	return nil
+}
+
+// Views returns the saved views sorted by name
+func (s *Store) Views() []View {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	views := make([]View, 0, len(s.views))
+	for _, v := range s.views {
+		views = append(views, *v)
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if !strings.EqualFold(views[i].Name, views[j].Name) {
+			return strings.ToLower(views[i].Name) < strings.ToLower(views[j].Name)
+		}
+		return views[i].ID < views[j].ID
+	})
+	return views
+}
+
+// View returns the saved view with id
+func (s *Store) View(id string) (View, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.views[id]
+	if !ok {
+		return View{}, false
+	}
+	return *v, true
+}
+
+// CreateView saves a new view with an ID derived from its name
+func (s *Store) CreateView(spec ViewSpec) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := viewID(spec.Name)
+	id := base
+	for n := 2; s.views[id] != nil; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	now := time.Now().UTC()
+	view := &View{ID: id, ViewSpec: spec, CreatedAt: now, UpdatedAt: now}
+	if err := s.saveView(view); err != nil {
+		return View{}, err
+	}
+	return *view, nil
+}
+
+// UpdateView replaces the name, filters, and grouping of a saved view
+func (s *Store) UpdateView(id string, spec ViewSpec) (View, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.views[id]
+	if !ok {
+		return View{}, ErrViewNotFound
+	}
+	view := &View{ID: id, ViewSpec: spec, CreatedAt: old.CreatedAt, UpdatedAt: time.Now().UTC()}
+	if err := s.saveView(view); err != nil {
+		return View{}, err
+	}
+	return *view, nil
+}
+
+// DeleteView removes a saved view
+func (s *Store) DeleteView(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.views[id]; !ok {
+		return ErrViewNotFound
+	}
+	if s.dir != "" {
+		if err := os.Remove(s.viewPath(id)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing view %s: %w", id, err)
+		}
+	}
+	delete(s.views, id)
+	return nil
+}
+
+// saveView persists and stores view. Callers must hold mu.
+func (s *Store) saveView(view *View) error {
+	if s.dir != "" {
+		data, err := json.Marshal(view)
+		if err != nil {
+			return fmt.Errorf("encoding view: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Join(s.dir, viewsDir), 0o755); err != nil {
+			return fmt.Errorf("creating views directory: %w", err)
+		}
+		if err := os.WriteFile(s.viewPath(view.ID), data, 0o644); err != nil {
+			return fmt.Errorf("writing view: %w", err)
+		}
+	}
+	s.views[view.ID] = view
+	return nil
+}
+
+func (s *Store) viewPath(id string) string {
+	return filepath.Join(s.dir, viewsDir, id+".json")
+}
+
+// viewID turns a view name into a lowercase, hyphenated ID that is safe in
+// URLs and file names, e.g. "Prod us-east only" becomes "prod-us-east-only"
+func viewID(name string) string {
+	return slugID(name, "view")
+}
+
+// slugID turns name into a lowercase, hyphenated ID of at most 64
+// characters, or returns fallback if name has no letters or digits
+func slugID(name, fallback string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, c := range strings.ToLower(name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(c)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+		if b.Len() >= 64 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return fallback
+	}
+	return b.String()
+}
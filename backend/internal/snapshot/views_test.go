@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestViewsPersistAlongsideSnapshots(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 10)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := store.Add(time.Now(), &types.CostResponse{}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	prod, err := store.CreateView(ViewSpec{Name: "Prod us-east only", Regions: []string{"us-east-1"}, GroupBy: []string{"account"}})
+	if err != nil {
+		t.Fatalf("CreateView() error = %v", err)
+	}
+	if prod.ID != "prod-us-east-only" {
+		t.Errorf("ID = %q, want prod-us-east-only", prod.ID)
+	}
+	dup, err := store.CreateView(ViewSpec{Name: "prod: us east only!"})
+	if err != nil || dup.ID != "prod-us-east-only-2" {
+		t.Errorf("CreateView() with a taken ID = %q, %v; want prod-us-east-only-2", dup.ID, err)
+	}
+
+	renamed, err := store.UpdateView(prod.ID, ViewSpec{Name: "Production", Regions: []string{"us-east-1", "us-east-2"}})
+	if err != nil {
+		t.Fatalf("UpdateView() error = %v", err)
+	}
+	if renamed.ID != prod.ID || !renamed.CreatedAt.Equal(prod.CreatedAt) || len(renamed.Regions) != 2 {
+		t.Errorf("UpdateView() = %+v; want the same ID and creation time with new filters", renamed)
+	}
+	if err := store.DeleteView(dup.ID); err != nil {
+		t.Fatalf("DeleteView() error = %v", err)
+	}
+	if _, err := store.UpdateView(dup.ID, ViewSpec{Name: "gone"}); !errors.Is(err, ErrViewNotFound) {
+		t.Errorf("UpdateView() of a deleted view error = %v, want ErrViewNotFound", err)
+	}
+
+	reloaded, err := NewStore(store.dir, 10)
+	if err != nil {
+		t.Fatalf("NewStore() reload error = %v", err)
+	}
+	if got := len(reloaded.List()); got != 1 {
+		t.Errorf("expected 1 snapshot after reload, got %d", got)
+	}
+	views := reloaded.Views()
+	if len(views) != 1 || views[0].Name != "Production" {
+		t.Fatalf("Views() after reload = %+v, want only Production", views)
+	}
+	if _, ok := reloaded.View(prod.ID); !ok {
+		t.Errorf("View(%q) not found after reload", prod.ID)
+	}
+}
+
+func TestViewID(t *testing.T) {
+	tests := map[string]string{
+		"Prod us-east only": "prod-us-east-only",
+		"  Team A / EKS  ":  "team-a-eks",
+		"🚀":                 "view",
+	}
+	for name, want := range tests {
+		if got := viewID(name); got != want {
+			t.Errorf("viewID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
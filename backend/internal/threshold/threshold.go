@@ -0,0 +1,127 @@
+// Package threshold flags resources whose hourly cost is over the limit the
+// costThresholds config section sets for their type, so unusually expensive
+// one-off resources stand out.
+package threshold
+
+import (
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Exceeds reports whether a resource of resourceType costing hourlyCost is
+// over its type's limit
+func Exceeds(cfg config.CostThresholdConfig, resourceType string, hourlyCost types.CostValue) bool {
+	limit := cfg.Limit(resourceType)
+	return limit > 0 && float64(hourlyCost) > limit
+}
+
+// Annotate sets HighCost on every resource in resp over its type's limit and
+// counts them in HighCostCount
+func Annotate(resp *types.CostResponse, cfg config.CostThresholdConfig) {
+	count := 0
+	over := func(resourceType string, hourlyCost types.CostValue) bool {
+		if Exceeds(cfg, resourceType, hourlyCost) {
+			count++
+			return true
+		}
+		return false
+	}
+
+	for i := range resp.EC2Instances {
+		r := &resp.EC2Instances[i]
+		r.HighCost = over("ec2", r.HourlyCost)
+	}
+	for i := range resp.EBSVolumes {
+		r := &resp.EBSVolumes[i]
+		r.HighCost = over("ebs", r.HourlyCost)
+	}
+	for i := range resp.ECSServices {
+		r := &resp.ECSServices[i]
+		r.HighCost = over("ecs", r.HourlyCost)
+	}
+	for i := range resp.RDSInstances {
+		r := &resp.RDSInstances[i]
+		r.HighCost = over("rds", r.HourlyCost)
+	}
+	for i := range resp.EKSClusters {
+		r := &resp.EKSClusters[i]
+		r.HighCost = over("eks", r.HourlyCost)
+	}
+	for i := range resp.LoadBalancers {
+		r := &resp.LoadBalancers[i]
+		r.HighCost = over("elb", r.HourlyCost)
+	}
+	for i := range resp.NATGateways {
+		r := &resp.NATGateways[i]
+		r.HighCost = over("nat", r.HourlyCost)
+	}
+	for i := range resp.ElasticIPs {
+		r := &resp.ElasticIPs[i]
+		r.HighCost = over("eip", r.HourlyCost)
+	}
+	for i := range resp.Secrets {
+		r := &resp.Secrets[i]
+		r.HighCost = over("secrets", r.HourlyCost)
+	}
+	for i := range resp.PublicIPv4s {
+		r := &resp.PublicIPv4s[i]
+		r.HighCost = over("publicipv4", r.HourlyCost)
+	}
+	for i := range resp.Lambdas {
+		r := &resp.Lambdas[i]
+		r.HighCost = over("lambda", r.HourlyCost)
+	}
+	for i := range resp.CapacityReservations {
+		r := &resp.CapacityReservations[i]
+		r.HighCost = over("capacityreservation", r.HourlyCost)
+	}
+	for i := range resp.DedicatedHosts {
+		r := &resp.DedicatedHosts[i]
+		r.HighCost = over("dedicatedhost", r.HourlyCost)
+	}
+	for i := range resp.KinesisStreams {
+		r := &resp.KinesisStreams[i]
+		r.HighCost = over("kinesis", r.HourlyCost)
+	}
+	for i := range resp.FirehoseStreams {
+		r := &resp.FirehoseStreams[i]
+		r.HighCost = over("firehose", r.HourlyCost)
+	}
+	for i := range resp.SQSQueues {
+		r := &resp.SQSQueues[i]
+		r.HighCost = over("sqs", r.HourlyCost)
+	}
+	for i := range resp.SNSTopics {
+		r := &resp.SNSTopics[i]
+		r.HighCost = over("sns", r.HourlyCost)
+	}
+	for i := range resp.EventBuses {
+		r := &resp.EventBuses[i]
+		r.HighCost = over("eventbridge", r.HourlyCost)
+	}
+	for i := range resp.Alarms {
+		r := &resp.Alarms[i]
+		r.HighCost = over("alarm", r.HourlyCost)
+	}
+	for i := range resp.Dashboards {
+		r := &resp.Dashboards[i]
+		r.HighCost = over("dashboard", r.HourlyCost)
+	}
+	for i := range resp.CustomMetrics {
+		r := &resp.CustomMetrics[i]
+		r.HighCost = over("custommetrics", r.HourlyCost)
+	}
+	for i := range resp.DataTransfer {
+		r := &resp.DataTransfer[i]
+		r.HighCost = over("datatransfer", r.HourlyCost)
+	}
+	for i := range resp.PluginResources {
+		r := &resp.PluginResources[i]
+		r.HighCost = over(r.Type, r.HourlyCost)
+	}
+	for i := range resp.VirtualMachines {
+		r := &resp.VirtualMachines[i]
+		r.HighCost = over("vm", r.HourlyCost)
+	}
+	resp.HighCostCount = count
+}
@@ -0,0 +1,41 @@
+package threshold
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/config"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestAnnotate(t *testing.T) {
+	resp := &types.CostResponse{
+		EC2Instances: []types.EC2Instance{
+			{InstanceID: "i-small", HourlyCost: 0.1},
+			{InstanceID: "i-gpu", HourlyCost: 3.06},
+		},
+		RDSInstances: []types.RDSInstance{
+			{DBInstanceID: "orders", HourlyCost: 1.5},
+			{DBInstanceID: "warehouse", HourlyCost: 2.5},
+		},
+		ElasticIPs: []types.ElasticIP{{AllocationID: "eipalloc-1", HourlyCost: 5}},
+	}
+	Annotate(resp, config.CostThresholdConfig{Hourly: 1, ResourceTypes: map[string]float64{"rds": 2, "eip": 0}})
+
+	if resp.EC2Instances[0].HighCost || !resp.EC2Instances[1].HighCost {
+		t.Errorf("EC2 flags = %v, %v; want only the GPU instance", resp.EC2Instances[0].HighCost, resp.EC2Instances[1].HighCost)
+	}
+	if resp.RDSInstances[0].HighCost || !resp.RDSInstances[1].HighCost {
+		t.Error("expected only the database over the RDS limit to be flagged")
+	}
+	if resp.ElasticIPs[0].HighCost {
+		t.Error("expected no flag for a type whose limit is 0")
+	}
+	if resp.HighCostCount != 2 {
+		t.Errorf("HighCostCount = %d, want 2", resp.HighCostCount)
+	}
+
+	Annotate(resp, config.CostThresholdConfig{})
+	if resp.EC2Instances[1].HighCost || resp.HighCostCount != 0 {
+		t.Error("expected no flags without thresholds")
+	}
+}
@@ -0,0 +1,121 @@
+package types
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const modulePath = "github.com/johnjeffers/awscogs/backend"
+
+// moduleImporter type-checks the module's own packages from source. Every
+// other package is stubbed out, so expressions using it don't resolve, but
+// anything typed by this module's declarations, like CostValue, does.
+type moduleImporter struct {
+	root     string
+	fset     *token.FileSet
+	packages map[string]*gotypes.Package
+	infos    map[string]*gotypes.Info
+	files    map[string][]*ast.File
+}
+
+func (m *moduleImporter) Import(importPath string) (*gotypes.Package, error) {
+	if pkg, ok := m.packages[importPath]; ok {
+		return pkg, nil
+	}
+	rel, ok := strings.CutPrefix(importPath, modulePath+"/")
+	if !ok {
+		pkg := gotypes.NewPackage(importPath, path.Base(importPath))
+		pkg.MarkComplete()
+		m.packages[importPath] = pkg
+		return pkg, nil
+	}
+
+	dir := filepath.Join(m.root, filepath.FromSlash(rel))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(m.fset, filepath.Join(dir, e.Name()), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	info := &gotypes.Info{Types: make(map[ast.Expr]gotypes.TypeAndValue)}
+	conf := gotypes.Config{Importer: m, Error: func(error) {}}
+	pkg, _ := conf.Check(importPath, m.fset, files, info)
+	m.packages[importPath] = pkg
+	m.infos[importPath] = info
+	m.files[importPath] = files
+	return pkg, nil
+}
+
+// TestCostValueSumsUseAdd fails on +, +=, and -= with CostValue operands in
+// the module's non-test code. They sum in floating point, which drifts where
+// Add doesn't.
+func TestCostValueSumsUseAdd(t *testing.T) {
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &moduleImporter{
+		root:     root,
+		fset:     token.NewFileSet(),
+		packages: make(map[string]*gotypes.Package),
+		infos:    make(map[string]*gotypes.Info),
+		files:    make(map[string][]*ast.File),
+	}
+	err = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if matches, _ := filepath.Glob(filepath.Join(p, "*.go")); len(matches) == 0 {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		_, err = m.Import(path.Join(modulePath, filepath.ToSlash(rel)))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for importPath, files := range m.files {
+		info := m.infos[importPath]
+		for _, f := range files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.BinaryExpr:
+					if n.Op == token.ADD && isCostValue(info.TypeOf(n)) {
+						t.Errorf("%s: + on CostValues; use Add", m.fset.Position(n.OpPos))
+					}
+				case *ast.AssignStmt:
+					if (n.Tok == token.ADD_ASSIGN || n.Tok == token.SUB_ASSIGN) && isCostValue(info.TypeOf(n.Lhs[0])) {
+						t.Errorf("%s: %s on a CostValue; use Add", m.fset.Position(n.TokPos), n.Tok)
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+func isCostValue(t gotypes.Type) bool {
+	named, ok := t.(*gotypes.Named)
+	return ok && named.Obj().Name() == "CostValue" && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == modulePath+"/internal/types"
+}
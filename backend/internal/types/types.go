@@ -1,76 +1,244 @@
 package types
 
-// CostValue represents a monetary cost value
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// CostValue represents a monetary cost value in dollars. Rates, products, and
+// differences are computed as floats, but sums go through Add so that
+// totaling tens of thousands of resources doesn't drift, and JSON output is
+// rounded to CostPrecision decimal places. TestCostValueSumsUseAdd rejects
+// +, +=, and -= on costs.
 type CostValue float64
 
+// CostPrecision is the number of decimal places costs are summed and
+// serialized with: nano-dollars, fine enough for per-request and per-GB rates
+const CostPrecision = 9
+
+// costScale is the number of fixed-point units in a dollar
+const costScale = 1e9
+
+// Fixed returns c as an integer number of nano-dollars
+func (c CostValue) Fixed() int64 {
+	return int64(math.Round(float64(c) * costScale))
+}
+
+// FromFixed returns the cost of n nano-dollars
+func FromFixed(n int64) CostValue {
+	return CostValue(float64(n) / costScale)
+}
+
+// Add returns c + d, summed in fixed point so that repeated additions are exact
+func (c CostValue) Add(d CostValue) CostValue {
+	return FromFixed(c.Fixed() + d.Fixed())
+}
+
+// Round returns c rounded to CostPrecision decimal places
+func (c CostValue) Round() CostValue {
+	return FromFixed(c.Fixed())
+}
+
+// MarshalJSON writes c as a number of dollars rounded to CostPrecision
+// decimal places, e.g. 0.3 rather than 0.30000000000000004
+func (c CostValue) MarshalJSON() ([]byte, error) {
+	f := float64(c)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("unsupported cost value: %v", f)
+	}
+	if math.Abs(f) >= math.MaxInt64/costScale {
+		return strconv.AppendFloat(nil, f, 'f', CostPrecision, 64), nil
+	}
+	return strconv.AppendFloat(nil, float64(c.Round()), 'f', -1, 64), nil
+}
+
+// HoursPerMonth is the number of hours AWS uses to convert monthly rates to hourly
+const HoursPerMonth = 730
+
+// CostComponent is one line of a resource's cost, such as storage or IOPS
+type CostComponent struct {
+	Name       string    `json:"name"`
+	Unit       string    `json:"unit"`     // Unit the rate is charged per, e.g. hour or GB-month
+	Quantity   float64   `json:"quantity"` // Units billed
+	Rate       CostValue `json:"rate"`     // Price per unit
+	HourlyCost CostValue `json:"hourlyCost"`
+	Override   string    `json:"override,omitempty"` // How a configured price override changed the rate, e.g. "pinned rate"
+}
+
+// HourlyComponent returns a component whose quantity is billed every hour
+func HourlyComponent(name, unit string, quantity float64, rate CostValue) CostComponent {
+	return CostComponent{Name: name, Unit: unit, Quantity: quantity, Rate: rate, HourlyCost: CostValue(quantity) * rate}
+}
+
+// MonthlyComponent returns a component with a monthly rate, prorated to an hour
+func MonthlyComponent(name, unit string, quantity float64, rate CostValue) CostComponent {
+	return CostComponent{Name: name, Unit: unit, Quantity: quantity, Rate: rate, HourlyCost: CostValue(quantity) * rate / HoursPerMonth}
+}
+
+// SumComponents returns the total hourly cost of components
+func SumComponents(components []CostComponent) CostValue {
+	var total CostValue
+	for _, c := range components {
+		total = total.Add(c.HourlyCost)
+	}
+	return total
+}
+
 // EC2Instance represents an EC2 instance with its cost
 type EC2Instance struct {
-	AccountID    string    `json:"accountId"`
-	AccountName  string    `json:"accountName"`
-	Region       string    `json:"region"`
-	InstanceID   string    `json:"instanceId"`
-	Name         string    `json:"name"`
-	InstanceType string    `json:"instanceType"`
-	State        string    `json:"state"`
-	HourlyCost   CostValue `json:"hourlyCost"`
+	AccountID             string               `json:"accountId"`
+	AccountName           string               `json:"accountName"`
+	Region                string               `json:"region"`
+	InstanceID            string               `json:"instanceId"`
+	ARN                   string               `json:"arn"`
+	Name                  string               `json:"name"`
+	InstanceType          string               `json:"instanceType"`
+	State                 string               `json:"state"`
+	Architecture          string               `json:"architecture,omitempty"`          // x86_64 or arm64
+	Tenancy               string               `json:"tenancy,omitempty"`               // default, dedicated, or host
+	CapacityReservationID string               `json:"capacityReservationId,omitempty"` // Billed at the on-demand rate
+	CPUCreditMode         string               `json:"cpuCreditMode,omitempty"`         // standard or unlimited, for burstable types
+	AutoScalingGroup      string               `json:"autoScalingGroup,omitempty"`
+	AutoScalingCapacity   *AutoScalingCapacity `json:"autoScalingCapacity,omitempty"` // The group's capacity, if it could be described
+	VPCID                 string               `json:"vpcId,omitempty"`
+	SubnetID              string               `json:"subnetId,omitempty"`
+	CreatedAt             string               `json:"createdAt,omitempty"` // Last launch time (RFC 3339), reset by a stop and start
+	AgeDays               int                  `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	StoppedAt             string               `json:"stoppedAt,omitempty"` // When a stopped instance was stopped (RFC 3339), from its state transition reason
+	StoppedDays           int                  `json:"stoppedDays,omitempty"`
+	VolumeIDs             []string             `json:"volumeIds,omitempty"` // Attached EBS volumes
+	HourlyCost            CostValue            `json:"hourlyCost"`
+	// AttachedStorageCost is the hourly cost of a stopped instance's attached
+	// EBS volumes, set with aws.stoppedInstanceStorage. The volumes' costs are
+	// already in totals, so it isn't added to them.
+	AttachedStorageCost CostValue         `json:"attachedStorageCost,omitempty"`
+	CostComponents      []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource         string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf           string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags                map[string]string `json:"tags,omitempty"`
+	Owner               string            `json:"owner,omitempty"`      // ARN of the principal that created it, from CloudTrail
+	CostCenter          string            `json:"costCenter,omitempty"` // Set when cost center rules are configured
+	HighCost            bool              `json:"highCost,omitempty"`   // Set when the hourly cost is over its type's costThresholds limit
+}
+
+// AutoScalingCapacity is the configured size of an Auto Scaling group
+type AutoScalingCapacity struct {
+	MinSize         int `json:"minSize"`
+	MaxSize         int `json:"maxSize"`
+	DesiredCapacity int `json:"desiredCapacity"`
 }
 
 // EBSVolume represents an EBS volume with its cost
 type EBSVolume struct {
-	AccountID   string    `json:"accountId"`
-	AccountName string    `json:"accountName"`
-	Region      string    `json:"region"`
-	VolumeID    string    `json:"volumeId"`
-	Name        string    `json:"name"`
-	VolumeType  string    `json:"volumeType"`
-	Size        int32     `json:"size"` // in GiB
-	IOPS        int32     `json:"iops"`
-	Throughput  int32     `json:"throughput"` // in MiB/s for gp3
-	State       string    `json:"state"`
-	HourlyCost  CostValue `json:"hourlyCost"`
+	AccountID           string            `json:"accountId"`
+	AccountName         string            `json:"accountName"`
+	Region              string            `json:"region"`
+	VolumeID            string            `json:"volumeId"`
+	ARN                 string            `json:"arn"`
+	Name                string            `json:"name"`
+	VolumeType          string            `json:"volumeType"`
+	Size                int32             `json:"size"` // in GiB
+	IOPS                int32             `json:"iops"`
+	Throughput          int32             `json:"throughput"` // in MiB/s for gp3
+	State               string            `json:"state"`
+	CreatedAt           string            `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays             int               `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost          CostValue         `json:"hourlyCost"`
+	CostComponents      []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource         string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf           string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags                map[string]string `json:"tags,omitempty"`
+	AttachedInstanceIDs []string          `json:"attachedInstanceIds,omitempty"` // All instances, for Multi-Attach volumes
+	AttachedInstanceID  string            `json:"attachedInstanceId,omitempty"`  // First attached instance; empty when unattached
+	Device              string            `json:"device,omitempty"`              // Device name on the attached instance, e.g. /dev/xvda
+	AttachTime          string            `json:"attachTime,omitempty"`          // RFC 3339
+	Owner               string            `json:"owner,omitempty"`
+	CostCenter          string            `json:"costCenter,omitempty"`
+	HighCost            bool              `json:"highCost,omitempty"`
 }
 
 // RDSInstance represents an RDS instance with its cost
 type RDSInstance struct {
-	AccountID        string    `json:"accountId"`
-	AccountName      string    `json:"accountName"`
-	Region           string    `json:"region"`
-	DBInstanceID     string    `json:"dbInstanceId"`
-	Name             string    `json:"name"`
-	Engine           string    `json:"engine"`
-	EngineVersion    string    `json:"engineVersion"`
-	InstanceClass    string    `json:"instanceClass"`
-	MultiAZ          bool      `json:"multiAz"`
-	StorageType      string    `json:"storageType"`
-	AllocatedStorage int32     `json:"allocatedStorage"` // in GiB
-	State            string    `json:"state"`
-	HourlyCost       CostValue `json:"hourlyCost"`
-}
-
-// ECSService represents an ECS service with its cost
+	AccountID          string            `json:"accountId"`
+	AccountName        string            `json:"accountName"`
+	Region             string            `json:"region"`
+	DBInstanceID       string            `json:"dbInstanceId"`
+	ARN                string            `json:"arn"`
+	Name               string            `json:"name"`
+	Engine             string            `json:"engine"`
+	EngineVersion      string            `json:"engineVersion"`
+	InstanceClass      string            `json:"instanceClass"`
+	MultiAZ            bool              `json:"multiAz"`
+	StorageType        string            `json:"storageType"`
+	AllocatedStorage   int32             `json:"allocatedStorage"` // in GiB
+	State              string            `json:"state"`
+	VPCID              string            `json:"vpcId,omitempty"`
+	SubnetID           string            `json:"subnetId,omitempty"`           // Subnet of the instance's availability zone in its subnet group
+	PubliclyAccessible bool              `json:"publiclyAccessible,omitempty"` // Its endpoint resolves to a public IPv4 address
+	CreatedAt          string            `json:"createdAt,omitempty"`          // RFC 3339
+	AgeDays            int               `json:"ageDays,omitempty"`            // Whole days from CreatedAt to the scan
+	HourlyCost         CostValue         `json:"hourlyCost"`
+	CostComponents     []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource        string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf          string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags               map[string]string `json:"tags,omitempty"`
+	Owner              string            `json:"owner,omitempty"`
+	CostCenter         string            `json:"costCenter,omitempty"`
+	HighCost           bool              `json:"highCost,omitempty"`
+}
+
+// ECSService represents an ECS service with its cost. EC2 services have no
+// cost of their own; AttributedHourlyCost is their share of the cluster's
+// container instances by CPU and memory reservation, and isn't counted in
+// totals, since the instances are counted as EC2 instances.
 type ECSService struct {
-	AccountID    string    `json:"accountId"`
-	AccountName  string    `json:"accountName"`
-	Region       string    `json:"region"`
-	ClusterName  string    `json:"clusterName"`
-	ServiceName  string    `json:"serviceName"`
-	LaunchType   string    `json:"launchType"` // FARGATE, EC2, EXTERNAL
-	DesiredCount int32     `json:"desiredCount"`
-	RunningCount int32     `json:"runningCount"`
-	State        string    `json:"state"` // ACTIVE, DRAINING, INACTIVE
-	HourlyCost   CostValue `json:"hourlyCost"`
+	AccountID            string          `json:"accountId"`
+	AccountName          string          `json:"accountName"`
+	Region               string          `json:"region"`
+	ClusterName          string          `json:"clusterName"`
+	ServiceName          string          `json:"serviceName"`
+	ARN                  string          `json:"arn"`
+	LaunchType           string          `json:"launchType"` // FARGATE, EC2, EXTERNAL
+	DesiredCount         int32           `json:"desiredCount"`
+	RunningCount         int32           `json:"runningCount"`
+	State                string          `json:"state"`                    // ACTIVE, DRAINING, INACTIVE
+	CPUReserved          int32           `json:"cpuReserved,omitempty"`    // CPU units reserved by running tasks, for EC2 services
+	MemoryReserved       int32           `json:"memoryReserved,omitempty"` // MiB reserved by running tasks, for EC2 services
+	AttributedHourlyCost CostValue       `json:"attributedHourlyCost,omitempty"`
+	CreatedAt            string          `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays              int             `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost           CostValue       `json:"hourlyCost"`
+	CostComponents       []CostComponent `json:"costComponents,omitempty"`
+	PriceSource          string          `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf            string          `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Owner                string          `json:"owner,omitempty"`
+	CostCenter           string          `json:"costCenter,omitempty"`
+	HighCost             bool            `json:"highCost,omitempty"`
 }
 
 // EKSCluster represents an EKS cluster with its cost
 type EKSCluster struct {
-	AccountID   string    `json:"accountId"`
-	AccountName string    `json:"accountName"`
-	Region      string    `json:"region"`
-	ClusterName string    `json:"clusterName"`
-	Status      string    `json:"status"`
-	Version     string    `json:"version"`
-	Platform    string    `json:"platform"` // linux, windows
-	HourlyCost  CostValue `json:"hourlyCost"`
+	AccountID       string            `json:"accountId"`
+	AccountName     string            `json:"accountName"`
+	Region          string            `json:"region"`
+	ClusterName     string            `json:"clusterName"`
+	ARN             string            `json:"arn"`
+	Status          string            `json:"status"`
+	Version         string            `json:"version"`
+	ExtendedSupport bool              `json:"extendedSupport,omitempty"` // Version is past standard support and billed at the extended rate
+	Platform        string            `json:"platform"`                  // linux, windows
+	CreatedAt       string            `json:"createdAt,omitempty"`       // RFC 3339
+	AgeDays         int               `json:"ageDays,omitempty"`         // Whole days from CreatedAt to the scan
+	HourlyCost      CostValue         `json:"hourlyCost"`
+	CostComponents  []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource     string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf       string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags            map[string]string `json:"tags,omitempty"`
+	Owner           string            `json:"owner,omitempty"`
+	CostCenter      string            `json:"costCenter,omitempty"`
+	HighCost        bool              `json:"highCost,omitempty"`
 }
 
 // Usage status constants
@@ -99,164 +267,973 @@ type Diagnostic struct {
 	Message      string `json:"message"`
 }
 
+// Permission check status constants.
+const (
+	PermissionStatusOK     = "ok"
+	PermissionStatusDenied = "denied"
+	PermissionStatusError  = "error"
+)
+
+// PermissionCheck is the result of probing one API action in an account and region.
+type PermissionCheck struct {
+	AccountID    string `json:"accountId,omitempty"`
+	AccountName  string `json:"accountName,omitempty"`
+	Region       string `json:"region"`
+	ResourceType string `json:"resourceType,omitempty"`
+	Action       string `json:"action"`
+	Status       string `json:"status"`
+	Message      string `json:"message,omitempty"`
+}
+
+// PermissionsCheckResponse reports which scans will fail before running them.
+type PermissionsCheckResponse struct {
+	Status  string            `json:"status"` // ok if every check passed, otherwise failed
+	Checked int               `json:"checked"`
+	Failed  int               `json:"failed"`
+	Checks  []PermissionCheck `json:"checks"`
+}
+
+// RegionActivity is what an activity probe found in one account and region
+// left out of scans. The counts are of resources that are billed while they
+// exist, so any of them means the region has spend the scans don't show.
+type RegionActivity struct {
+	AccountID        string `json:"accountId,omitempty"`
+	AccountName      string `json:"accountName,omitempty"`
+	Region           string `json:"region"`
+	RunningInstances int    `json:"runningInstances"`
+	Volumes          int    `json:"volumes"`
+	NATGateways      int    `json:"natGateways"`
+	Active           bool   `json:"active"`
+	Error            string `json:"error,omitempty"`
+}
+
+// RegionActivityResponse reports chargeable resources in regions excluded
+// from scans
+type RegionActivityResponse struct {
+	Status          string           `json:"status"` // ok if no excluded region is active, otherwise partial
+	ExcludedRegions []string         `json:"excludedRegions"`
+	Active          int              `json:"active"` // Account and region pairs with chargeable resources
+	Regions         []RegionActivity `json:"regions"`
+}
+
 // LoadBalancer represents an Elastic Load Balancer with its cost
 type LoadBalancer struct {
-	AccountID           string    `json:"accountId"`
-	AccountName         string    `json:"accountName"`
-	Region              string    `json:"region"`
-	Name                string    `json:"name"`
-	ARN                 string    `json:"arn"`
-	Type                string    `json:"type"`   // application, network, classic
-	Scheme              string    `json:"scheme"` // internet-facing, internal
-	State               string    `json:"state"`
-	HourlyCost          CostValue `json:"hourlyCost"`     // Total: base + LCU
-	BaseHourlyCost      CostValue `json:"baseHourlyCost"` // Fixed hourly charge
-	LCUHourlyCost       CostValue `json:"lcuHourlyCost"`  // LCU/NLCU-based hourly charge
-	ConsumedLCUs        float64   `json:"consumedLcus"`   // Average consumed LCUs per hour
-	UsageWindow         string    `json:"usageWindow,omitempty"`
-	UsageStart          string    `json:"usageStart,omitempty"`
-	UsageEnd            string    `json:"usageEnd,omitempty"`
-	RequestVolume       float64   `json:"requestVolume,omitempty"`
-	RequestMetricName   string    `json:"requestMetricName,omitempty"`
-	BandwidthBytes      float64   `json:"bandwidthBytes,omitempty"`
-	BandwidthMetricName string    `json:"bandwidthMetricName,omitempty"`
-	UsageStatus         string    `json:"usageStatus,omitempty"`
-	UsageError          string    `json:"usageError,omitempty"`
+	AccountID           string          `json:"accountId"`
+	AccountName         string          `json:"accountName"`
+	Region              string          `json:"region"`
+	Name                string          `json:"name"`
+	ARN                 string          `json:"arn"`
+	Type                string          `json:"type"`   // application, network, classic
+	Scheme              string          `json:"scheme"` // internet-facing, internal
+	State               string          `json:"state"`
+	VPCID               string          `json:"vpcId,omitempty"`
+	SubnetIDs           []string        `json:"subnetIds,omitempty"`
+	CreatedAt           string          `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays             int             `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost          CostValue       `json:"hourlyCost"`          // Total: base + LCU
+	CostComponents      []CostComponent `json:"costComponents,omitempty"`
+	PriceSource         string          `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf           string          `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	BaseHourlyCost      CostValue       `json:"baseHourlyCost"`        // Fixed hourly charge
+	LCUHourlyCost       CostValue       `json:"lcuHourlyCost"`         // LCU/NLCU-based hourly charge
+	ConsumedLCUs        float64         `json:"consumedLcus"`          // Average consumed LCUs per hour
+	UsageWindow         string          `json:"usageWindow,omitempty"`
+	UsageStart          string          `json:"usageStart,omitempty"`
+	UsageEnd            string          `json:"usageEnd,omitempty"`
+	RequestVolume       float64         `json:"requestVolume,omitempty"`
+	RequestMetricName   string          `json:"requestMetricName,omitempty"`
+	BandwidthBytes      float64         `json:"bandwidthBytes,omitempty"`
+	BandwidthMetricName string          `json:"bandwidthMetricName,omitempty"`
+	UsageStatus         string          `json:"usageStatus,omitempty"`
+	UsageError          string          `json:"usageError,omitempty"`
+	// RegisteredTargets and HealthyTargets count the targets (instances for
+	// classic load balancers) behind the load balancer. They are omitted
+	// when the targets couldn't be described.
+	RegisteredTargets *int              `json:"registeredTargets,omitempty"`
+	HealthyTargets    *int              `json:"healthyTargets,omitempty"`
+	Warnings          []ResourceWarning `json:"warnings,omitempty"`
+	Owner             string            `json:"owner,omitempty"`
+	CostCenter        string            `json:"costCenter,omitempty"`
+	HighCost          bool              `json:"highCost,omitempty"`
+}
+
+// Load balancer warning codes
+const (
+	WarningClassicLoadBalancer = "classic-load-balancer"
+	WarningNoTargets           = "no-targets"
+	WarningNoHealthyTargets    = "no-healthy-targets"
+)
+
+// ResourceWarning flags a resource that is deprecated or likely idle
+type ResourceWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// MonthlySavings is the estimated saving from deleting the resource, or
+	// zero when deleting it isn't the fix
+	MonthlySavings CostValue `json:"monthlySavings"`
 }
 
 // NATGateway represents a NAT Gateway with its cost
 type NATGateway struct {
-	AccountID   string    `json:"accountId"`
-	AccountName string    `json:"accountName"`
-	Region      string    `json:"region"`
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	State       string    `json:"state"`
-	Type        string    `json:"type"` // public, private
-	VPCID       string    `json:"vpcId"`
-	SubnetID    string    `json:"subnetId"`
-	HourlyCost  CostValue `json:"hourlyCost"`
+	AccountID      string            `json:"accountId"`
+	AccountName    string            `json:"accountName"`
+	Region         string            `json:"region"`
+	ID             string            `json:"id"`
+	ARN            string            `json:"arn"`
+	Name           string            `json:"name"`
+	State          string            `json:"state"`
+	Type           string            `json:"type"` // public, private
+	VPCID          string            `json:"vpcId"`
+	SubnetID       string            `json:"subnetId"`
+	CreatedAt      string            `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays        int               `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost     CostValue         `json:"hourlyCost"`
+	CostComponents []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource    string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf      string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags           map[string]string `json:"tags,omitempty"`
+	Owner          string            `json:"owner,omitempty"`
+	CostCenter     string            `json:"costCenter,omitempty"`
+	HighCost       bool              `json:"highCost,omitempty"`
 }
 
 // ElasticIP represents an Elastic IP address with its cost
 type ElasticIP struct {
-	AccountID     string    `json:"accountId"`
-	AccountName   string    `json:"accountName"`
-	Region        string    `json:"region"`
-	AllocationID  string    `json:"allocationId"`
-	PublicIP      string    `json:"publicIp"`
-	Name          string    `json:"name"`
-	AssociationID string    `json:"associationId"`
-	InstanceID    string    `json:"instanceId"`
-	IsAssociated  bool      `json:"isAssociated"`
-	HourlyCost    CostValue `json:"hourlyCost"`
-}
-
-// Secret represents a Secrets Manager secret with its cost
+	AccountID              string            `json:"accountId"`
+	AccountName            string            `json:"accountName"`
+	Region                 string            `json:"region"`
+	AllocationID           string            `json:"allocationId"`
+	ARN                    string            `json:"arn"`
+	PublicIP               string            `json:"publicIp"`
+	Name                   string            `json:"name"`
+	AssociationID          string            `json:"associationId"`
+	InstanceID             string            `json:"instanceId"`
+	NetworkInterfaceID     string            `json:"networkInterfaceId,omitempty"`
+	AssociatedResourceType string            `json:"associatedResourceType,omitempty"` // ec2, nat, elb, or eni (an interface of another service); empty when unassociated
+	AssociatedResourceID   string            `json:"associatedResourceId,omitempty"`
+	IsAssociated           bool              `json:"isAssociated"`
+	Idle                   bool              `json:"idle"`               // Not in use by a running instance or service; billed at the idle rate
+	VPCID                  string            `json:"vpcId,omitempty"`    // VPC of the associated network interface
+	SubnetID               string            `json:"subnetId,omitempty"` // Subnet of the associated network interface
+	HourlyCost             CostValue         `json:"hourlyCost"`
+	CostComponents         []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource            string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf              string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags                   map[string]string `json:"tags,omitempty"`
+	Owner                  string            `json:"owner,omitempty"`
+	CostCenter             string            `json:"costCenter,omitempty"`
+	HighCost               bool              `json:"highCost,omitempty"`
+}
+
+// Secret represents a Secrets Manager secret with its cost. A replica is
+// billed as a secret in its own region, at that region's price, so each
+// replica is reported separately in the region it's replicated to.
 type Secret struct {
-	AccountID   string    `json:"accountId"`
-	AccountName string    `json:"accountName"`
-	Region      string    `json:"region"`
-	Name        string    `json:"name"`
-	ARN         string    `json:"arn"`
-	Description string    `json:"description"`
-	HourlyCost  CostValue `json:"hourlyCost"`
+	AccountID      string            `json:"accountId"`
+	AccountName    string            `json:"accountName"`
+	Region         string            `json:"region"`
+	Name           string            `json:"name"`
+	ARN            string            `json:"arn"`
+	Description    string            `json:"description"`
+	CreatedAt      string            `json:"createdAt,omitempty"`      // RFC 3339
+	AgeDays        int               `json:"ageDays,omitempty"`        // Whole days from CreatedAt to the scan
+	PrimaryRegion  string            `json:"primaryRegion,omitempty"`  // Set on replicas: the region of the secret they replicate
+	LastAccessedAt string            `json:"lastAccessedAt,omitempty"` // RFC 3339 date the secret was last read in this region; empty if never
+	IdleDays       int               `json:"idleDays"`                 // Whole days since LastAccessedAt, or since CreatedAt if never read
+	HourlyCost     CostValue         `json:"hourlyCost"`
+	CostComponents []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource    string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf      string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags           map[string]string `json:"tags,omitempty"`
+	Owner          string            `json:"owner,omitempty"`
+	CostCenter     string            `json:"costCenter,omitempty"`
+	HighCost       bool              `json:"highCost,omitempty"`
 }
 
 // PublicIPv4 represents a public IPv4 address with its cost
 // This tracks auto-assigned public IPs on EC2 instances (not Elastic IPs)
 type PublicIPv4 struct {
-	AccountID    string    `json:"accountId"`
-	AccountName  string    `json:"accountName"`
-	Region       string    `json:"region"`
-	PublicIP     string    `json:"publicIp"`
-	InstanceID   string    `json:"instanceId"`
-	InstanceName string    `json:"instanceName"`
-	HourlyCost   CostValue `json:"hourlyCost"`
+	AccountID              string          `json:"accountId"`
+	AccountName            string          `json:"accountName"`
+	Region                 string          `json:"region"`
+	PublicIP               string          `json:"publicIp"`
+	InstanceID             string          `json:"instanceId"`
+	InstanceName           string          `json:"instanceName"`
+	NetworkInterfaceID     string          `json:"networkInterfaceId,omitempty"`
+	AssociatedResourceType string          `json:"associatedResourceType"` // Always ec2: only running instances' addresses are discovered
+	AssociatedResourceID   string          `json:"associatedResourceId"`
+	Idle                   bool            `json:"idle"`
+	HourlyCost             CostValue       `json:"hourlyCost"`
+	CostComponents         []CostComponent `json:"costComponents,omitempty"`
+	PriceSource            string          `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf              string          `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	CostCenter             string          `json:"costCenter,omitempty"`
+	HighCost               bool            `json:"highCost,omitempty"`
+}
+
+// CapacityReservation represents an On-Demand Capacity Reservation. The
+// reserved instances are billed at the on-demand rate whether or not they
+// run; running instances that use the reservation carry their own cost, so
+// HourlyCost is only the unused capacity.
+type CapacityReservation struct {
+	AccountID        string            `json:"accountId"`
+	AccountName      string            `json:"accountName"`
+	Region           string            `json:"region"`
+	ID               string            `json:"id"`
+	ARN              string            `json:"arn"`
+	Name             string            `json:"name"`
+	InstanceType     string            `json:"instanceType"`
+	Platform         string            `json:"platform"`
+	Tenancy          string            `json:"tenancy"` // default or dedicated
+	AvailabilityZone string            `json:"availabilityZone"`
+	State            string            `json:"state"`
+	TotalCount       int32             `json:"totalCount"`
+	AvailableCount   int32             `json:"availableCount"` // Reserved instances not in use
+	Utilization      float64           `json:"utilization"`    // Fraction of the reserved instances in use, 0 to 1
+	EndDate          string            `json:"endDate,omitempty"`
+	CreatedAt        string            `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays          int               `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost       CostValue         `json:"hourlyCost"`
+	CostComponents   []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource      string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf        string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags             map[string]string `json:"tags,omitempty"`
+	Owner            string            `json:"owner,omitempty"`
+	CostCenter       string            `json:"costCenter,omitempty"`
+	HighCost         bool              `json:"highCost,omitempty"`
+}
+
+// ReservedInstance is an active EC2 Reserved Instance purchase. It isn't a
+// cost resource: its discount applies to matching running instances, which
+// are still priced on demand.
+type ReservedInstance struct {
+	AccountID        string  `json:"accountId"`
+	AccountName      string  `json:"accountName"`
+	Region           string  `json:"region"`
+	ID               string  `json:"id"`
+	InstanceType     string  `json:"instanceType"`
+	InstanceCount    int32   `json:"instanceCount"`
+	Platform         string  `json:"platform"` // Product description, e.g. Linux/UNIX
+	Tenancy          string  `json:"tenancy"`  // default or dedicated
+	Scope            string  `json:"scope"`    // Region or Availability Zone
+	AvailabilityZone string  `json:"availabilityZone,omitempty"`
+	OfferingClass    string  `json:"offeringClass"`   // standard or convertible
+	OfferingType     string  `json:"offeringType"`    // e.g. No Upfront
+	RecurringHourly  float64 `json:"recurringHourly"` // Hourly charge per instance, billed whether or not it's used
+	Start            string  `json:"start,omitempty"` // RFC 3339
+	End              string  `json:"end,omitempty"`   // RFC 3339
+}
+
+// AuroraCluster is an Aurora DB cluster with its storage and I/O usage. It
+// isn't a cost resource: its instances are priced as RDS instances, and the
+// cluster is only used to compare Aurora storage configurations.
+type AuroraCluster struct {
+	AccountID     string   `json:"accountId"`
+	AccountName   string   `json:"accountName"`
+	Region        string   `json:"region"`
+	ClusterID     string   `json:"clusterId"`
+	ARN           string   `json:"arn"`
+	Engine        string   `json:"engine"`
+	EngineVersion string   `json:"engineVersion"`
+	StorageType   string   `json:"storageType"` // aurora (Standard) or aurora-iopt1 (I/O-Optimized)
+	Members       []string `json:"members"`     // DB instance IDs
+	StorageGiB    float64  `json:"storageGiB"`  // Average VolumeBytesUsed over the usage window
+	ReadIOs       float64  `json:"readIOs"`     // Billed read I/Os over the usage window
+	WriteIOs      float64  `json:"writeIOs"`    // Billed write I/Os over the usage window
+	UsageWindow   string   `json:"usageWindow"`
+	UsageStatus   string   `json:"usageStatus,omitempty"`
+	UsageError    string   `json:"usageError,omitempty"`
+}
+
+// DedicatedHost represents an EC2 Dedicated Host. The host is billed whether
+// or not instances run on it, and the instances on it cost nothing extra.
+type DedicatedHost struct {
+	AccountID        string            `json:"accountId"`
+	AccountName      string            `json:"accountName"`
+	Region           string            `json:"region"`
+	HostID           string            `json:"hostId"`
+	ARN              string            `json:"arn"`
+	Name             string            `json:"name"`
+	InstanceFamily   string            `json:"instanceFamily"`
+	InstanceType     string            `json:"instanceType,omitempty"` // Set when the host supports a single instance type
+	AvailabilityZone string            `json:"availabilityZone"`
+	State            string            `json:"state"`
+	InstanceIDs      []string          `json:"instanceIds,omitempty"`
+	Capacity         int32             `json:"capacity,omitempty"`  // Instances of InstanceType the host can run
+	Utilization      float64           `json:"utilization"`         // Fraction of the host's capacity in use, 0 to 1
+	CreatedAt        string            `json:"createdAt,omitempty"` // Allocation time (RFC 3339)
+	AgeDays          int               `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost       CostValue         `json:"hourlyCost"`
+	CostComponents   []CostComponent   `json:"costComponents,omitempty"`
+	PriceSource      string            `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf        string            `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Tags             map[string]string `json:"tags,omitempty"`
+	Owner            string            `json:"owner,omitempty"`
+	CostCenter       string            `json:"costCenter,omitempty"`
+	HighCost         bool              `json:"highCost,omitempty"`
+}
+
+// KinesisStream represents a Kinesis data stream with its cost. Provisioned
+// streams are billed per shard-hour, on-demand streams per stream-hour plus
+// data written and read, which isn't included.
+type KinesisStream struct {
+	AccountID      string          `json:"accountId"`
+	AccountName    string          `json:"accountName"`
+	Region         string          `json:"region"`
+	StreamName     string          `json:"streamName"`
+	StreamARN      string          `json:"streamArn"`
+	Status         string          `json:"status"`
+	Mode           string          `json:"mode"` // PROVISIONED or ON_DEMAND
+	ShardCount     int             `json:"shardCount"`
+	RetentionHours int             `json:"retentionHours"`
+	ConsumerCount  int             `json:"consumerCount"`       // Enhanced fan-out consumers
+	CreatedAt      string          `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays        int             `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	PriceSource    string          `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf      string          `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Owner          string          `json:"owner,omitempty"`
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
+}
+
+// FirehoseStream represents a Firehose delivery stream with its observed
+// ingestion cost
+type FirehoseStream struct {
+	AccountID      string          `json:"accountId"`
+	AccountName    string          `json:"accountName"`
+	Region         string          `json:"region"`
+	Name           string          `json:"name"`
+	ARN            string          `json:"arn"`
+	Status         string          `json:"status"`
+	SourceType     string          `json:"sourceType"`   // DirectPut, KinesisStreamAsSource, or MSKAsSource
+	Destinations   []string        `json:"destinations"` // e.g. ExtendedS3, Redshift
+	IncomingBytes  float64         `json:"incomingBytes"`
+	UsageWindow    string          `json:"usageWindow"`
+	UsageStatus    string          `json:"usageStatus,omitempty"`
+	UsageError     string          `json:"usageError,omitempty"`
+	CreatedAt      string          `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays        int             `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	PriceSource    string          `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf      string          `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	Owner          string          `json:"owner,omitempty"`
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
+}
+
+// DataTransfer is the estimated data transfer cost of one running EC2
+// instance or NAT gateway, from its CloudWatch byte counts over the last 24
+// hours and the configured assumptions
+type DataTransfer struct {
+	AccountID          string            `json:"accountId"`
+	AccountName        string            `json:"accountName"`
+	Region             string            `json:"region"`
+	ResourceType       string            `json:"resourceType"` // ec2 or nat
+	ResourceID         string            `json:"resourceId"`
+	Name               string            `json:"name,omitempty"`
+	InternetGBPerHour  float64           `json:"internetGbPerHour"`
+	InterAZGBPerHour   float64           `json:"interAzGbPerHour"`
+	ProcessedGBPerHour float64           `json:"processedGbPerHour,omitempty"` // Processed by a NAT gateway
+	HourlyCost         CostValue         `json:"hourlyCost"`
+	CostComponents     []CostComponent   `json:"costComponents,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+	CostCenter         string            `json:"costCenter,omitempty"`
+	HighCost           bool              `json:"highCost,omitempty"`
+}
+
+// PluginResource is a resource found by a plugin discoverer, for a resource
+// type awsCOGS doesn't discover itself
+type PluginResource struct {
+	Type           string            `json:"type"` // The plugin's resource type, e.g. mwaa
+	AccountID      string            `json:"accountId"`
+	AccountName    string            `json:"accountName"`
+	Region         string            `json:"region"`
+	ID             string            `json:"id"`
+	Name           string            `json:"name,omitempty"`
+	Size           string            `json:"size,omitempty"` // e.g. an instance class or capacity
+	State          string            `json:"state,omitempty"`
+	CreatedAt      string            `json:"createdAt,omitempty"` // RFC 3339
+	HourlyCost     CostValue         `json:"hourlyCost"`
+	CostComponents []CostComponent   `json:"costComponents,omitempty"`
+	Attributes     map[string]any    `json:"attributes,omitempty"` // Anything else the plugin reports
+	Tags           map[string]string `json:"tags,omitempty"`
+	CostCenter     string            `json:"costCenter,omitempty"`
+	HighCost       bool              `json:"highCost,omitempty"`
+}
+
+// SQSQueue represents an SQS queue with its cost estimated from the last
+// day's requests
+type SQSQueue struct {
+	AccountID       string          `json:"accountId"`
+	AccountName     string          `json:"accountName"`
+	Region          string          `json:"region"`
+	Name            string          `json:"name"`
+	URL             string          `json:"url"`
+	ARN             string          `json:"arn"`
+	FIFO            bool            `json:"fifo"`
+	MessagesVisible int             `json:"messagesVisible"` // Approximate messages waiting
+	Requests        float64         `json:"requests"`        // Messages sent, received, and deleted, and empty receives, in the usage window
+	UsageWindow     string          `json:"usageWindow"`
+	UsageStatus     string          `json:"usageStatus,omitempty"`
+	UsageError      string          `json:"usageError,omitempty"`
+	CreatedAt       string          `json:"createdAt,omitempty"` // RFC 3339
+	AgeDays         int             `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost      CostValue       `json:"hourlyCost"`
+	CostComponents  []CostComponent `json:"costComponents,omitempty"`
+	PriceSource     string          `json:"priceSource,omitempty"` // "config": the rate is set in aws.messaging, not looked up
+	PriceAsOf       string          `json:"priceAsOf,omitempty"`   // Empty for config rates, which aren't fetched
+	Owner           string          `json:"owner,omitempty"`
+	CostCenter      string          `json:"costCenter,omitempty"`
+	HighCost        bool            `json:"highCost,omitempty"`
+}
+
+// SNSTopic represents an SNS topic with its cost estimated from the last
+// day's publishes. Deliveries aren't included.
+type SNSTopic struct {
+	AccountID      string          `json:"accountId"`
+	AccountName    string          `json:"accountName"`
+	Region         string          `json:"region"`
+	Name           string          `json:"name"`
+	ARN            string          `json:"arn"`
+	FIFO           bool            `json:"fifo"`
+	Subscriptions  int             `json:"subscriptions"` // Confirmed subscriptions
+	Publishes      float64         `json:"publishes"`     // Messages published in the usage window
+	UsageWindow    string          `json:"usageWindow"`
+	UsageStatus    string          `json:"usageStatus,omitempty"`
+	UsageError     string          `json:"usageError,omitempty"`
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	PriceSource    string          `json:"priceSource,omitempty"` // "config": the rate is set in aws.messaging, not looked up
+	PriceAsOf      string          `json:"priceAsOf,omitempty"`   // Empty for config rates, which aren't fetched
+	Owner          string          `json:"owner,omitempty"`
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
+}
+
+// EventBus represents an EventBridge event bus and its rules, with its cost
+// estimated from the last day's events
+type EventBus struct {
+	AccountID   string            `json:"accountId"`
+	AccountName string            `json:"accountName"`
+	Region      string            `json:"region"`
+	Name        string            `json:"name"`
+	ARN         string            `json:"arn"`
+	Rules       []EventBridgeRule `json:"rules"`
+	// Events is the events published to the bus with PutEvents in the usage
+	// window, whether or not a rule matched them
+	Events         float64         `json:"events"`
+	UsageWindow    string          `json:"usageWindow"`
+	UsageStatus    string          `json:"usageStatus,omitempty"`
+	UsageError     string          `json:"usageError,omitempty"`
+	CreatedAt      string          `json:"createdAt,omitempty"` // RFC 3339; empty for the default bus
+	AgeDays        int             `json:"ageDays,omitempty"`   // Whole days from CreatedAt to the scan
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	PriceSource    string          `json:"priceSource,omitempty"` // "config": the rate is set in aws.messaging, not looked up
+	PriceAsOf      string          `json:"priceAsOf,omitempty"`   // Empty for config rates, which aren't fetched
+	Owner          string          `json:"owner,omitempty"`
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
+}
+
+// EventBridgeRule is a rule on an event bus. Rules aren't billed.
+type EventBridgeRule struct {
+	Name               string `json:"name"`
+	State              string `json:"state"`                        // ENABLED or DISABLED
+	ScheduleExpression string `json:"scheduleExpression,omitempty"` // e.g. rate(5 minutes), for scheduled rules
+}
+
+// CloudWatch alarm kinds
+const (
+	AlarmKindStandard         = "standard"
+	AlarmKindHighResolution   = "high-resolution"
+	AlarmKindAnomalyDetection = "anomaly-detection"
+	AlarmKindComposite        = "composite"
+)
+
+// Alarm represents a CloudWatch metric or composite alarm
+type Alarm struct {
+	AccountID   string `json:"accountId"`
+	AccountName string `json:"accountName"`
+	Region      string `json:"region"`
+	Name        string `json:"name"`
+	ARN         string `json:"arn"`
+	Kind        string `json:"kind"`  // standard, high-resolution, anomaly-detection, or composite
+	State       string `json:"state"` // OK, ALARM, or INSUFFICIENT_DATA
+	// Metrics is the billed metrics the alarm watches: one per metric in a
+	// metric math expression, and three for an anomaly detection band
+	Metrics        int             `json:"metrics"`
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	PriceSource    string          `json:"priceSource,omitempty"` // "config": the rate is set in aws.cloudWatch, not looked up
+	PriceAsOf      string          `json:"priceAsOf,omitempty"`   // Empty for config rates, which aren't fetched
+	Owner          string          `json:"owner,omitempty"`
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
+}
+
+// Dashboard represents a CloudWatch dashboard. Dashboards are global, so
+// they're listed once per account in the partition's default region.
+type Dashboard struct {
+	AccountID      string          `json:"accountId"`
+	AccountName    string          `json:"accountName"`
+	Region         string          `json:"region"`
+	Name           string          `json:"name"`
+	ARN            string          `json:"arn"`
+	Size           int64           `json:"size"`                   // Bytes in the dashboard body
+	LastModified   string          `json:"lastModified,omitempty"` // RFC 3339
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	PriceSource    string          `json:"priceSource,omitempty"` // "config": the rate is set in aws.cloudWatch, not looked up
+	PriceAsOf      string          `json:"priceAsOf,omitempty"`   // Empty for config rates, which aren't fetched
+	Owner          string          `json:"owner,omitempty"`
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
+}
+
+// CustomMetrics is the custom metrics in one CloudWatch namespace that
+// received data in the last three hours, the ones CloudWatch bills for
+type CustomMetrics struct {
+	AccountID      string          `json:"accountId"`
+	AccountName    string          `json:"accountName"`
+	Region         string          `json:"region"`
+	Namespace      string          `json:"namespace"`
+	Metrics        int             `json:"metrics"`
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	PriceSource    string          `json:"priceSource,omitempty"` // "config": the rate is set in aws.cloudWatch, not looked up
+	PriceAsOf      string          `json:"priceAsOf,omitempty"`   // Empty for config rates, which aren't fetched
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
 }
 
 // LambdaFunction represents an AWS Lambda function with its observed usage cost
 type LambdaFunction struct {
-	AccountID         string    `json:"accountId"`
-	AccountName       string    `json:"accountName"`
-	Region            string    `json:"region"`
-	FunctionName      string    `json:"functionName"`
-	FunctionARN       string    `json:"functionArn"`
-	Runtime           string    `json:"runtime"`
-	Architectures     []string  `json:"architectures"`
-	MemorySize        int32     `json:"memorySize"`       // in MB
-	EphemeralStorage  int32     `json:"ephemeralStorage"` // in MB
-	PackageType       string    `json:"packageType"`
-	LastModified      string    `json:"lastModified"`
-	State             string    `json:"state"`
-	HourlyCost        CostValue `json:"hourlyCost"`
-	RequestHourlyCost CostValue `json:"requestHourlyCost"`
-	ComputeHourlyCost CostValue `json:"computeHourlyCost"`
-	Invocations       float64   `json:"invocations"`
-	AverageDurationMS float64   `json:"averageDurationMs"`
-	UsageWindow       string    `json:"usageWindow"`
-	UsageStart        string    `json:"usageStart"`
-	UsageEnd          string    `json:"usageEnd"`
-	UsageStatus       string    `json:"usageStatus,omitempty"`
-	UsageError        string    `json:"usageError,omitempty"`
+	AccountID         string          `json:"accountId"`
+	AccountName       string          `json:"accountName"`
+	Region            string          `json:"region"`
+	FunctionName      string          `json:"functionName"`
+	FunctionARN       string          `json:"functionArn"`
+	Runtime           string          `json:"runtime"`
+	Architectures     []string        `json:"architectures"`
+	MemorySize        int32           `json:"memorySize"`       // in MB
+	EphemeralStorage  int32           `json:"ephemeralStorage"` // in MB
+	PackageType       string          `json:"packageType"`
+	LastModified      string          `json:"lastModified"`
+	State             string          `json:"state"`
+	HourlyCost        CostValue       `json:"hourlyCost"`
+	CostComponents    []CostComponent `json:"costComponents,omitempty"`
+	PriceSource       string          `json:"priceSource,omitempty"` // "api", "cache", or "missing" if a lookup failed; empty if none was made
+	PriceAsOf         string          `json:"priceAsOf,omitempty"`   // When the oldest price used was fetched
+	RequestHourlyCost CostValue       `json:"requestHourlyCost"`
+	ComputeHourlyCost CostValue       `json:"computeHourlyCost"`
+	Invocations       float64         `json:"invocations"`
+	AverageDurationMS float64         `json:"averageDurationMs"`
+	UsageWindow       string          `json:"usageWindow"`
+	UsageStart        string          `json:"usageStart"`
+	UsageEnd          string          `json:"usageEnd"`
+	UsageStatus       string          `json:"usageStatus,omitempty"`
+	UsageError        string          `json:"usageError,omitempty"`
+	Owner             string          `json:"owner,omitempty"`
+	CostCenter        string          `json:"costCenter,omitempty"`
+	HighCost          bool            `json:"highCost,omitempty"`
+}
+
+// VirtualMachine represents a virtual machine from a non-AWS cloud provider with its cost
+type VirtualMachine struct {
+	Provider       string          `json:"provider"`    // azure
+	AccountID      string          `json:"accountId"`   // Subscription or project ID
+	AccountName    string          `json:"accountName"` // Subscription or project name
+	Region         string          `json:"region"`
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Size           string          `json:"size"` // Provider SKU, e.g. Standard_D2s_v3
+	OSType         string          `json:"osType"`
+	State          string          `json:"state"`
+	HourlyCost     CostValue       `json:"hourlyCost"`
+	CostComponents []CostComponent `json:"costComponents,omitempty"`
+	CostCenter     string          `json:"costCenter,omitempty"`
+	HighCost       bool            `json:"highCost,omitempty"`
 }
 
 // AccountSummary represents cost summary for an AWS account
 type AccountSummary struct {
-	AccountID       string    `json:"accountId"`
-	AccountName     string    `json:"accountName"`
-	EC2Count        int       `json:"ec2Count"`
-	EBSCount        int       `json:"ebsCount"`
-	ECSCount        int       `json:"ecsCount"`
-	RDSCount        int       `json:"rdsCount"`
-	EKSCount        int       `json:"eksCount"`
-	ELBCount        int       `json:"elbCount"`
-	NATCount        int       `json:"natCount"`
-	EIPCount        int       `json:"eipCount"`
-	SecretCount     int       `json:"secretCount"`
-	PublicIPv4Count int       `json:"publicIpv4Count"`
-	LambdaCount     int       `json:"lambdaCount"`
-	TotalCost       CostValue `json:"totalCost"`
+	AccountID         string               `json:"accountId"`
+	AccountName       string               `json:"accountName"`
+	DisplayName       string               `json:"displayName,omitempty"`      // From account metadata
+	Environment       string               `json:"environment,omitempty"`      // From account metadata
+	OwnerEmail        string               `json:"ownerEmail,omitempty"`       // From account metadata
+	Color             string               `json:"color,omitempty"`            // From account metadata
+	Counts            map[string]int       `json:"counts"`                     // Resource count by resource type
+	DataTransferCost  CostValue            `json:"dataTransferCost,omitempty"` // Estimated data transfer, included in TotalCost
+	Services          map[string]CostValue `json:"services"`                   // Hourly cost by resource type
+	Regions           map[string]CostValue `json:"regions"`                    // Hourly cost by region
+	TotalCost         CostValue            `json:"totalCost"`
+	PreviousTotalCost *CostValue           `json:"previousTotalCost,omitempty"` // Total in the previous snapshot, 0 if new since; unset without a previous snapshot
+	DeltaPercent      *float64             `json:"deltaPercent,omitempty"`      // Change since the previous snapshot; unset if its total was 0
 }
 
 // RegionSummary represents cost summary for a region
 type RegionSummary struct {
-	Region          string    `json:"region"`
-	EC2Count        int       `json:"ec2Count"`
-	EBSCount        int       `json:"ebsCount"`
-	ECSCount        int       `json:"ecsCount"`
-	RDSCount        int       `json:"rdsCount"`
-	EKSCount        int       `json:"eksCount"`
-	ELBCount        int       `json:"elbCount"`
-	NATCount        int       `json:"natCount"`
-	EIPCount        int       `json:"eipCount"`
-	SecretCount     int       `json:"secretCount"`
-	PublicIPv4Count int       `json:"publicIpv4Count"`
-	LambdaCount     int       `json:"lambdaCount"`
-	TotalCost       CostValue `json:"totalCost"`
+	Region            string               `json:"region"`
+	Counts            map[string]int       `json:"counts"`                     // Resource count by resource type
+	DataTransferCost  CostValue            `json:"dataTransferCost,omitempty"` // Estimated data transfer, included in TotalCost
+	Accounts          map[string]CostValue `json:"accounts"`                   // Hourly cost by account ID
+	TotalCost         CostValue            `json:"totalCost"`
+	PreviousTotalCost *CostValue           `json:"previousTotalCost,omitempty"` // Total in the previous snapshot, 0 if new since; unset without a previous snapshot
+	DeltaPercent      *float64             `json:"deltaPercent,omitempty"`      // Change since the previous snapshot; unset if its total was 0
 }
 
 // CostResponse is the API response for cost data
 type CostResponse struct {
-	Timestamp     string           `json:"timestamp"`
-	Status        string           `json:"status"`
-	Diagnostics   []Diagnostic     `json:"diagnostics,omitempty"`
-	TotalCost     CostValue        `json:"totalCost"`
-	Currency      string           `json:"currency"`
-	Accounts      []AccountSummary `json:"accounts,omitempty"`
-	Regions       []RegionSummary  `json:"regions,omitempty"`
-	EC2Instances  []EC2Instance    `json:"ec2Instances,omitempty"`
-	EBSVolumes    []EBSVolume      `json:"ebsVolumes,omitempty"`
-	ECSServices   []ECSService     `json:"ecsServices,omitempty"`
-	RDSInstances  []RDSInstance    `json:"rdsInstances,omitempty"`
-	EKSClusters   []EKSCluster     `json:"eksClusters,omitempty"`
-	LoadBalancers []LoadBalancer   `json:"loadBalancers,omitempty"`
-	NATGateways   []NATGateway     `json:"natGateways,omitempty"`
-	ElasticIPs    []ElasticIP      `json:"elasticIps,omitempty"`
-	Secrets       []Secret         `json:"secrets,omitempty"`
-	PublicIPv4s   []PublicIPv4     `json:"publicIpv4s,omitempty"`
-	Lambdas       []LambdaFunction `json:"lambdas,omitempty"`
-	Filters       AppliedFilters   `json:"filters"`
+	Timestamp            string                `json:"timestamp"`
+	Status               string                `json:"status"`
+	Diagnostics          []Diagnostic          `json:"diagnostics,omitempty"`
+	TotalCost            CostValue             `json:"totalCost"`
+	Billing              *BillingTotals        `json:"billing,omitempty"` // Set when billing discounts or credits are configured
+	Currency             string                `json:"currency"`
+	ComparedTo           string                `json:"comparedTo,omitempty"` // Timestamp of the snapshot summary deltas are computed against
+	Accounts             []AccountSummary      `json:"accounts,omitempty"`
+	Regions              []RegionSummary       `json:"regions,omitempty"`
+	Environments         []EnvironmentCost     `json:"environments,omitempty"`
+	UnpricedCount        int                   `json:"unpricedCount,omitempty"`
+	UnpricedResources    []UnpricedResource    `json:"unpricedResources,omitempty"` // Resources whose price lookup failed
+	IgnoredCount         int                   `json:"ignoredCount,omitempty"`
+	IgnoredCost          CostValue             `json:"ignoredCost,omitempty"`      // Hourly cost of ignored resources, not in totalCost
+	IgnoredResources     []IgnoredResource     `json:"ignoredResources,omitempty"` // Resources excluded by ignore rules
+	HighCostCount        int                   `json:"highCostCount,omitempty"`    // Resources over their type's costThresholds limit
+	EC2Instances         []EC2Instance         `json:"ec2Instances,omitempty"`
+	EBSVolumes           []EBSVolume           `json:"ebsVolumes,omitempty"`
+	ECSServices          []ECSService          `json:"ecsServices,omitempty"`
+	RDSInstances         []RDSInstance         `json:"rdsInstances,omitempty"`
+	EKSClusters          []EKSCluster          `json:"eksClusters,omitempty"`
+	LoadBalancers        []LoadBalancer        `json:"loadBalancers,omitempty"`
+	NATGateways          []NATGateway          `json:"natGateways,omitempty"`
+	ElasticIPs           []ElasticIP           `json:"elasticIps,omitempty"`
+	Secrets              []Secret              `json:"secrets,omitempty"`
+	PublicIPv4s          []PublicIPv4          `json:"publicIpv4s,omitempty"`
+	Lambdas              []LambdaFunction      `json:"lambdas,omitempty"`
+	CapacityReservations []CapacityReservation `json:"capacityReservations,omitempty"`
+	DedicatedHosts       []DedicatedHost       `json:"dedicatedHosts,omitempty"`
+	KinesisStreams       []KinesisStream       `json:"kinesisStreams,omitempty"`
+	FirehoseStreams      []FirehoseStream      `json:"firehoseStreams,omitempty"`
+	SQSQueues            []SQSQueue            `json:"sqsQueues,omitempty"`
+	SNSTopics            []SNSTopic            `json:"snsTopics,omitempty"`
+	EventBuses           []EventBus            `json:"eventBuses,omitempty"`
+	Alarms               []Alarm               `json:"alarms,omitempty"`
+	Dashboards           []Dashboard           `json:"dashboards,omitempty"`
+	CustomMetrics        []CustomMetrics       `json:"customMetrics,omitempty"`
+	DataTransfer         []DataTransfer        `json:"dataTransfer,omitempty"`
+	PluginResources      []PluginResource      `json:"pluginResources,omitempty"`
+	VirtualMachines      []VirtualMachine      `json:"virtualMachines,omitempty"`
+	Filters              AppliedFilters        `json:"filters"`
+	Scan                 *ScanStats            `json:"scan,omitempty"`
+}
+
+// How an unpriced resource was costed under the pricing.missingPrice policy
+const (
+	UnpricedZero      = "zero"
+	UnpricedOmitted   = "omitted"
+	UnpricedEstimated = "estimated"
+	UnpricedUnknown   = "unknown"
+)
+
+// UnpricedResource is a resource whose price lookup failed, so its cost is
+// understated or estimated
+type UnpricedResource struct {
+	ResourceType string    `json:"resourceType"`
+	ResourceID   string    `json:"resourceId"`
+	Name         string    `json:"name,omitempty"`
+	AccountID    string    `json:"accountId"`
+	AccountName  string    `json:"accountName"`
+	Region       string    `json:"region"`
+	HourlyCost   CostValue `json:"hourlyCost"` // Prices that were found, or the estimate
+	Handling     string    `json:"handling"`   // zero, omitted, estimated, or unknown
+}
+
+// ResourceTypeInfo describes a resource type awsCOGS can discover
+type ResourceTypeInfo struct {
+	Key         string   `json:"key"` // Resource type filter name, e.g. ec2
+	DisplayName string   `json:"displayName"`
+	Provider    string   `json:"provider"` // aws or azure
+	Scope       string   `json:"scope"`    // regional, or global for resources scanned once per account
+	IAMActions  []string `json:"iamActions"`
+	Enabled     bool     `json:"enabled"` // Whether the current config discovers it
+}
+
+// ResourceTypesResponse is the API response for the resource types
+type ResourceTypesResponse struct {
+	ResourceTypes []ResourceTypeInfo `json:"resourceTypes"` // In the order they're scanned
+}
+
+// IgnoredResource is a resource an ignore rule excludes from totals and the
+// waste report
+type IgnoredResource struct {
+	ResourceType string    `json:"resourceType"`
+	ResourceID   string    `json:"resourceId"`
+	Name         string    `json:"name,omitempty"`
+	AccountID    string    `json:"accountId"`
+	AccountName  string    `json:"accountName"`
+	Region       string    `json:"region"`
+	HourlyCost   CostValue `json:"hourlyCost"`
+	CreatedAt    string    `json:"createdAt,omitempty"` // RFC 3339, for types that report it
+	Rule         string    `json:"rule"`                // ID of the ignore rule, or "tag" for the ignore tag
+	Reason       string    `json:"reason"`
+}
+
+// BillingTotals is a response's total cost after the account-level discount
+// and credits, which aren't applied to resources' list prices. Costs are per
+// hour.
+type BillingTotals struct {
+	ListCost        CostValue `json:"listCost"` // Same as totalCost
+	DiscountPercent float64   `json:"discountPercent"`
+	Discount        CostValue `json:"discount"`
+	Credits         CostValue `json:"credits"` // Monthly credits prorated to an hour; only applied to unfiltered totals
+	NetCost         CostValue `json:"netCost"` // What is actually paid
+}
+
+// ScanStats counts the account, region, and resource type cells a scan
+// served from the discovery cache and the ones it rescanned
+type ScanStats struct {
+	Cells     int       `json:"cells"`
+	Cached    int       `json:"cached"`
+	Rescanned int       `json:"rescanned"`
+	SelfCost  *SelfCost `json:"selfCost,omitempty"` // What the scan's own API calls cost
+}
+
+// SelfCost counts the AWS API calls a scan made and estimates what they cost
+// the accounts awsCOGS scans, at list prices before the free tier
+type SelfCost struct {
+	APICalls         int            `json:"apiCalls"`
+	PricingCalls     int            `json:"pricingCalls"`     // Price List API calls, which are free
+	MetricDataCalls  int            `json:"metricDataCalls"`  // CloudWatch GetMetricData calls
+	MetricsRequested int            `json:"metricsRequested"` // Metrics queried with GetMetricData, which is billed per metric
+	BilledRequests   int            `json:"billedRequests"`   // Other CloudWatch calls billed per request, such as ListMetrics
+	Cost             CostValue      `json:"cost"`
+	Services         []ServiceCalls `json:"services"` // Most calls first
+}
+
+// ServiceCalls is the number of API calls a scan made to one service
+type ServiceCalls struct {
+	Service string `json:"service"` // SDK service ID, e.g. EC2 or CloudWatch
+	Calls   int    `json:"calls"`
+}
+
+// ScanCell is the discovery cache state of one account, region, and resource type
+type ScanCell struct {
+	AccountID    string    `json:"accountId"`
+	Region       string    `json:"region"`
+	ResourceType string    `json:"resourceType"`
+	ScannedAt    time.Time `json:"scannedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Expired      bool      `json:"expired"`
+}
+
+// ScanStatusResponse is the API response for the discovery cache state
+type ScanStatusResponse struct {
+	Timestamp  string     `json:"timestamp"`
+	TTLMinutes int        `json:"ttlMinutes"`
+	Cells      []ScanCell `json:"cells"`
+}
+
+// APICallStats counts the AWS API calls made to one service in one account
+// and region since the server started
+type APICallStats struct {
+	Region        string  `json:"region"`
+	Service       string  `json:"service"` // AWS service ID, e.g. EC2
+	Calls         int     `json:"calls"`
+	Retries       int     `json:"retries"`   // Attempts beyond the first
+	Errors        int     `json:"errors"`    // Calls that failed after any retries
+	Throttles     int     `json:"throttles"` // Attempts AWS throttled
+	AvgLatencyMs  float64 `json:"avgLatencyMs"`
+	MaxLatencyMs  float64 `json:"maxLatencyMs"`
+	PeakPerSecond int     `json:"peakPerSecond"` // Most calls started in one second, to compare with the API's rate quota
+}
+
+// AccountTelemetry is the scan timing and API usage of one account
+type AccountTelemetry struct {
+	AccountID     string         `json:"accountId"`
+	AccountName   string         `json:"accountName"`
+	Scans         int            `json:"scans"`         // Account and region pairs scanned
+	TotalScanSecs float64        `json:"totalScanSecs"` // Time spent scanning them
+	MaxScanSecs   float64        `json:"maxScanSecs"`   // Slowest region scan
+	LastScanSecs  float64        `json:"lastScanSecs"`  // Most recent region scan
+	Calls         int            `json:"calls"`         // Across all services and regions
+	Throttles     int            `json:"throttles"`     // Across all services and regions
+	Services      []APICallStats `json:"services"`      // Sorted by calls, most first
+}
+
+// Account credential status constants
+const (
+	CredentialStatusOK     = "ok"
+	CredentialStatusFailed = "failed"
+)
+
+// AccountStatus is the credential health of one scanned account
+type AccountStatus struct {
+	AccountID       string `json:"accountId,omitempty"`
+	AccountName     string `json:"accountName,omitempty"`
+	Partition       string `json:"partition"`
+	RoleARN         string `json:"roleArn,omitempty"` // Empty for accounts scanned with awsCOGS's own credentials
+	Status          string `json:"status"`            // ok if the credentials work now, otherwise failed
+	Error           string `json:"error,omitempty"`   // Why they don't
+	LastScanAt      string `json:"lastScanAt,omitempty"`
+	LastSuccessAt   string `json:"lastSuccessAt,omitempty"`   // Last scan of one of its regions with no errors
+	LastScanError   string `json:"lastScanError,omitempty"`   // First error of the last failed scan, cleared by a successful one
+	LastScanErrorAt string `json:"lastScanErrorAt,omitempty"` // RFC 3339, as are the other times
+}
+
+// AccountStatusResponse is the API response for the account credential
+// health check
+type AccountStatusResponse struct {
+	Timestamp string          `json:"timestamp"`
+	Status    string          `json:"status"` // ok, partial if some accounts failed, or failed if all did
+	Checked   int             `json:"checked"`
+	Failed    int             `json:"failed"`
+	Accounts  []AccountStatus `json:"accounts"` // Failed accounts first, then by name
+}
+
+// TelemetryResponse is the API response for discovery telemetry
+type TelemetryResponse struct {
+	Timestamp string             `json:"timestamp"`
+	Since     string             `json:"since"` // When counting started
+	Accounts  []AccountTelemetry `json:"accounts"`
+}
+
+// CostGroup is one row of a grouped cost table
+type CostGroup struct {
+	Values    []string  `json:"values"` // One value per dimension, in request order
+	Count     int       `json:"count"`
+	TotalCost CostValue `json:"totalCost"`
+}
+
+// CostGroupComparison is one row of a grouped cost table compared against a
+// baseline. Groups missing from the baseline have a baseline cost of 0 and no
+// percent change.
+type CostGroupComparison struct {
+	Values        []string  `json:"values"` // One value per dimension, in request order
+	BaselineCount int       `json:"baselineCount"`
+	BaselineCost  CostValue `json:"baselineCost"`
+	Count         int       `json:"count"`
+	TotalCost     CostValue `json:"totalCost"`
+	Delta         CostValue `json:"delta"`
+	DeltaPercent  *float64  `json:"deltaPercent,omitempty"`
+}
+
+// SubnetCost totals the resources in one subnet. An empty SubnetID covers
+// resources that span subnets, such as load balancers.
+type SubnetCost struct {
+	SubnetID  string    `json:"subnetId"`
+	Count     int       `json:"count"`
+	TotalCost CostValue `json:"totalCost"`
+}
+
+// VPCCost totals the resources in one VPC
+type VPCCost struct {
+	AccountID   string       `json:"accountId"`
+	AccountName string       `json:"accountName"`
+	Region      string       `json:"region"`
+	VPCID       string       `json:"vpcId"`
+	Count       int          `json:"count"`
+	TotalCost   CostValue    `json:"totalCost"`
+	Subnets     []SubnetCost `json:"subnets"`
+}
+
+// CostCenterCost totals the resources assigned to one cost center
+type CostCenterCost struct {
+	CostCenter string               `json:"costCenter"`
+	Count      int                  `json:"count"`
+	TotalCost  CostValue            `json:"totalCost"`
+	Services   map[string]CostValue `json:"services"` // Hourly cost by resource type
+}
+
+// EnvironmentCost totals the resources in one environment
+type EnvironmentCost struct {
+	Environment string               `json:"environment"` // e.g. prod, dev, or unclassified
+	Production  bool                 `json:"production"`
+	Count       int                  `json:"count"`
+	TotalCost   CostValue            `json:"totalCost"`
+	Services    map[string]CostValue `json:"services"` // Hourly cost by resource type
+}
+
+// AutoScalingGroupCost totals the instances in one Auto Scaling group
+type AutoScalingGroupCost struct {
+	AccountID     string   `json:"accountId"`
+	AccountName   string   `json:"accountName"`
+	Region        string   `json:"region"`
+	Name          string   `json:"name"`
+	InstanceTypes []string `json:"instanceTypes"`
+	Count         int      `json:"count"`
+	RunningCount  int      `json:"runningCount"`
+	*AutoScalingCapacity
+	TotalCost CostValue `json:"totalCost"`
+	// MaxCost estimates the hourly cost at maximum capacity from the average
+	// cost of the running instances. It is omitted when the capacity is unknown
+	// or no instance is running.
+	MaxCost CostValue `json:"maxCost,omitempty"`
+}
+
+// AutoScalingGroupCostResponse is the API response for costs by Auto Scaling
+// group
+type AutoScalingGroupCostResponse struct {
+	Timestamp   string                 `json:"timestamp"`
+	Status      string                 `json:"status"`
+	Diagnostics []Diagnostic           `json:"diagnostics,omitempty"`
+	TotalCost   CostValue              `json:"totalCost"` // Instances in a group only
+	Currency    string                 `json:"currency"`
+	Groups      []AutoScalingGroupCost `json:"groups"`
+	Filters     AppliedFilters         `json:"filters"`
+}
+
+// VPCCostResponse is the API response for costs by VPC
+type VPCCostResponse struct {
+	Timestamp   string         `json:"timestamp"`
+	Status      string         `json:"status"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	TotalCost   CostValue      `json:"totalCost"` // Resources in a VPC only
+	Currency    string         `json:"currency"`
+	VPCs        []VPCCost      `json:"vpcs"`
+	Filters     AppliedFilters `json:"filters"`
+}
+
+// CostCenterResponse is the API response for costs by cost center
+type CostCenterResponse struct {
+	Timestamp   string           `json:"timestamp"`
+	Status      string           `json:"status"`
+	Diagnostics []Diagnostic     `json:"diagnostics,omitempty"`
+	TotalCost   CostValue        `json:"totalCost"`
+	Currency    string           `json:"currency"`
+	CostCenters []CostCenterCost `json:"costCenters"`
+	Filters     AppliedFilters   `json:"filters"`
+}
+
+// EnvironmentResponse is the API response for costs by environment
+type EnvironmentResponse struct {
+	Timestamp         string            `json:"timestamp"`
+	Status            string            `json:"status"`
+	Diagnostics       []Diagnostic      `json:"diagnostics,omitempty"`
+	TotalCost         CostValue         `json:"totalCost"`
+	ProductionCost    CostValue         `json:"productionCost"`
+	NonProductionCost CostValue         `json:"nonProductionCost"` // Classified environments that aren't production
+	UnclassifiedCost  CostValue         `json:"unclassifiedCost"`
+	Currency          string            `json:"currency"`
+	Environments      []EnvironmentCost `json:"environments"`
+	Filters           AppliedFilters    `json:"filters"`
+}
+
+// GroupedCostResponse is the API response for costs grouped by dimensions
+type GroupedCostResponse struct {
+	Timestamp   string         `json:"timestamp"`
+	Status      string         `json:"status"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	Dimensions  []string       `json:"dimensions"`
+	TotalCost   CostValue      `json:"totalCost"`
+	Currency    string         `json:"currency"`
+	Groups      []CostGroup    `json:"groups"`
+	Filters     AppliedFilters `json:"filters"`
 }
 
 // AppliedFilters shows what filters were applied to the response
@@ -264,4 +1241,5 @@ type AppliedFilters struct {
 	Accounts      []string `json:"accounts,omitempty"`
 	Regions       []string `json:"regions,omitempty"`
 	ResourceTypes []string `json:"resourceTypes,omitempty"`
+	OlderThan     string   `json:"olderThan,omitempty"` // Only resources created longer ago than this age
 }
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAddDoesNotDrift(t *testing.T) {
+	var total CostValue
+	for range 100000 {
+		total = total.Add(0.1)
+	}
+	if total != 10000 {
+		t.Fatalf("total = %v, want exactly 10000", total)
+	}
+
+	var float CostValue
+	for range 100000 {
+		float += 0.1
+	}
+	if float == 10000 {
+		t.Fatal("expected plain float addition to drift; the test no longer shows anything")
+	}
+}
+
+func TestSumComponents(t *testing.T) {
+	components := []CostComponent{
+		HourlyComponent("instance", "hour", 1, 0.1),
+		HourlyComponent("license", "hour", 1, 0.2),
+	}
+	if got := SumComponents(components); got != 0.3 {
+		t.Fatalf("SumComponents = %v, want 0.3", got)
+	}
+}
+
+func TestCostValueJSON(t *testing.T) {
+	tests := []struct {
+		cost CostValue
+		want string
+	}{
+		{0, "0"},
+		{0.1 + 0.2, "0.3"},
+		{0.0000125, "0.0000125"},
+		{1.23456789012, "1.23456789"},
+		{-0.30000000000000004, "-0.3"},
+		{42, "42"},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.cost)
+		if err != nil {
+			t.Fatalf("marshal %v: %v", tt.cost, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("marshal %v = %s, want %s", float64(tt.cost), got, tt.want)
+		}
+	}
+
+	got, err := json.Marshal(map[string]CostValue{"ec2": 0.1 + 0.2})
+	if err != nil || string(got) != `{"ec2":0.3}` {
+		t.Fatalf("marshal map = %s, %v", got, err)
+	}
+}
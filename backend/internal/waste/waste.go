@@ -0,0 +1,227 @@
+// Package waste finds resources that cost money without doing useful work,
+// such as idle Elastic IPs, unattached EBS volumes, unused reserved capacity,
+// secrets nothing reads, and instances stopped for weeks.
+package waste
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/johnjeffers/awscogs/backend/internal/aggregate"
+	"github.com/johnjeffers/awscogs/backend/internal/snapshot"
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+// Finding categories
+const (
+	CategoryIdleElasticIP       = "idle-elastic-ip"
+	CategoryUnattachedEBSVolume = "unattached-ebs-volume"
+	CategoryUnusedReservation   = "unused-capacity-reservation"
+	CategoryIdleDedicatedHost   = "idle-dedicated-host"
+	CategoryUnusedSecret        = "unused-secret"
+	CategoryStoppedInstance     = "long-stopped-instance"
+)
+
+// Options holds the thresholds rules use
+type Options struct {
+	UnusedSecretDays    int // Days without a read before a secret is flagged (0 = never flag)
+	StoppedInstanceDays int // Days stopped before an EC2 instance is flagged (0 = never flag)
+}
+
+// Finding is a resource flagged as waste
+type Finding struct {
+	snapshot.Resource
+	Category    string          `json:"category"`
+	Reason      string          `json:"reason"`
+	MonthlyCost types.CostValue `json:"monthlyCost"`
+}
+
+// Report is the API response for the waste report
+type Report struct {
+	Timestamp         string               `json:"timestamp"`
+	Status            string               `json:"status"`
+	Diagnostics       []types.Diagnostic   `json:"diagnostics,omitempty"`
+	TotalHourlyCost   types.CostValue      `json:"totalHourlyCost"`
+	Currency          string               `json:"currency"`
+	Findings          []Finding            `json:"findings"`
+	IgnoredCount      int                  `json:"ignoredCount,omitempty"`      // Scanned resources ignore rules left out
+	IgnoredHourlyCost types.CostValue      `json:"ignoredHourlyCost,omitempty"` // Their hourly cost, not in totalHourlyCost
+	Filters           types.AppliedFilters `json:"filters"`
+}
+
+// match identifies a wasteful resource within a cost response
+type match struct {
+	resourceType, accountID, region, id string
+	reason                              string
+	cost                                *types.CostValue // Overrides the resource's hourly cost, e.g. a stopped instance's storage
+}
+
+// rule finds one category of waste
+type rule struct {
+	resourceType string
+	category     string
+	find         func(resp *types.CostResponse, opts Options) []match
+}
+
+var rules = []rule{
+	{"eip", CategoryIdleElasticIP, idleElasticIPs},
+	{"ebs", CategoryUnattachedEBSVolume, unattachedEBSVolumes},
+	{"capacityreservation", CategoryUnusedReservation, unusedCapacityReservations},
+	{"dedicatedhost", CategoryIdleDedicatedHost, idleDedicatedHosts},
+	{"secrets", CategoryUnusedSecret, unusedSecrets},
+	{"ec2", CategoryStoppedInstance, stoppedInstances},
+}
+
+// ResourceTypes returns the resource types the waste rules inspect
+func ResourceTypes() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if !seen[r.resourceType] {
+			seen[r.resourceType] = true
+			out = append(out, r.resourceType)
+		}
+	}
+	return out
+}
+
+// Find returns the waste in resp, highest cost first
+func Find(resp *types.CostResponse, opts Options) []Finding {
+	resources := make(map[string]snapshot.Resource)
+	for _, r := range snapshot.Resources(resp) {
+		resources[r.Key()] = r
+	}
+
+	findings := []Finding{}
+	for _, rule := range rules {
+		for _, m := range rule.find(resp, opts) {
+			key := snapshot.Resource{Type: m.resourceType, AccountID: m.accountID, Region: m.region, ID: m.id}.Key()
+			r, ok := resources[key]
+			if !ok {
+				continue
+			}
+			if m.cost != nil {
+				r.HourlyCost = *m.cost
+			}
+			findings = append(findings, Finding{
+				Resource:    r,
+				Category:    rule.category,
+				Reason:      m.reason,
+				MonthlyCost: r.HourlyCost * types.HoursPerMonth,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].HourlyCost > findings[j].HourlyCost })
+	return findings
+}
+
+// idleElasticIPs flags Elastic IPs billed at the idle rate
+func idleElasticIPs(resp *types.CostResponse, _ Options) []match {
+	var out []match
+	for _, eip := range resp.ElasticIPs {
+		if !eip.Idle {
+			continue
+		}
+		reason := "not associated with any resource"
+		if eip.IsAssociated {
+			reason = "associated instance " + eip.InstanceID + " is not running"
+		}
+		out = append(out, match{"eip", eip.AccountID, eip.Region, eip.AllocationID, reason, nil})
+	}
+	return out
+}
+
+// unattachedEBSVolumes flags volumes that are not attached to any instance
+func unattachedEBSVolumes(resp *types.CostResponse, _ Options) []match {
+	var out []match
+	for _, vol := range resp.EBSVolumes {
+		if len(vol.AttachedInstanceIDs) > 0 {
+			continue
+		}
+		out = append(out, match{"ebs", vol.AccountID, vol.Region, vol.VolumeID, "not attached to any instance", nil})
+	}
+	return out
+}
+
+// unusedCapacityReservations flags reservations with reserved instances not
+// in use. A reservation's cost is already only its unused capacity.
+func unusedCapacityReservations(resp *types.CostResponse, _ Options) []match {
+	var out []match
+	for _, cr := range resp.CapacityReservations {
+		if cr.AvailableCount == 0 {
+			continue
+		}
+		reason := fmt.Sprintf("%d of %d reserved %s instances unused", cr.AvailableCount, cr.TotalCount, cr.InstanceType)
+		out = append(out, match{"capacityreservation", cr.AccountID, cr.Region, cr.ID, reason, nil})
+	}
+	return out
+}
+
+// idleDedicatedHosts flags Dedicated Hosts with no instances running on them
+func idleDedicatedHosts(resp *types.CostResponse, _ Options) []match {
+	var out []match
+	for _, host := range resp.DedicatedHosts {
+		if len(host.InstanceIDs) > 0 {
+			continue
+		}
+		out = append(out, match{"dedicatedhost", host.AccountID, host.Region, host.HostID, "no instances running on the host", nil})
+	}
+	return out
+}
+
+// unusedSecrets flags secrets not read for opts.UnusedSecretDays or more.
+// Replicas are read in their own region, so each is judged separately.
+func unusedSecrets(resp *types.CostResponse, opts Options) []match {
+	if opts.UnusedSecretDays <= 0 {
+		return nil
+	}
+	var out []match
+	for _, secret := range resp.Secrets {
+		if secret.IdleDays < opts.UnusedSecretDays {
+			continue
+		}
+		where := ""
+		if secret.PrimaryRegion != "" {
+			where = " in this region"
+		}
+		reason := fmt.Sprintf("not read%s in %d days", where, secret.IdleDays)
+		if secret.LastAccessedAt == "" {
+			reason = fmt.Sprintf("never read%s since it was created %d days ago", where, secret.IdleDays)
+		}
+		if secret.PrimaryRegion != "" {
+			reason = "replica of a " + secret.PrimaryRegion + " secret, " + reason
+		}
+		out = append(out, match{"secrets", secret.AccountID, secret.Region, secret.ARN, reason, nil})
+	}
+	return out
+}
+
+// stoppedInstances flags EC2 instances stopped for opts.StoppedInstanceDays
+// or more. A stopped instance isn't billed, but its EBS volumes are, so the
+// finding costs what its attached volumes do.
+func stoppedInstances(resp *types.CostResponse, opts Options) []match {
+	if opts.StoppedInstanceDays <= 0 {
+		return nil
+	}
+	var out []match
+	var storage map[string]types.CostValue
+	for _, inst := range resp.EC2Instances {
+		if inst.State != "stopped" || inst.StoppedAt == "" || inst.StoppedDays < opts.StoppedInstanceDays {
+			continue
+		}
+		if storage == nil {
+			storage = aggregate.InstanceStorageCosts(resp.EBSVolumes)
+		}
+		cost := storage[inst.InstanceID]
+		reason := fmt.Sprintf("stopped for %d days", inst.StoppedDays)
+		if n := len(inst.VolumeIDs); n > 0 {
+			reason += fmt.Sprintf(", still paying for %d attached EBS volume", n)
+			if n > 1 {
+				reason += "s"
+			}
+		}
+		out = append(out, match{"ec2", inst.AccountID, inst.Region, inst.InstanceID, reason, &cost})
+	}
+	return out
+}
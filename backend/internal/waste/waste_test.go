@@ -0,0 +1,130 @@
+package waste
+
+import (
+	"testing"
+
+	"github.com/johnjeffers/awscogs/backend/internal/types"
+)
+
+func TestFindIdleElasticIPs(t *testing.T) {
+	resp := &types.CostResponse{
+		ElasticIPs: []types.ElasticIP{
+			{AccountID: "111", Region: "us-east-1", AllocationID: "eipalloc-free", Idle: true, HourlyCost: 0.005},
+			{AccountID: "111", Region: "us-east-1", AllocationID: "eipalloc-stopped", InstanceID: "i-1", IsAssociated: true, Idle: true, HourlyCost: 0.006},
+			{AccountID: "111", Region: "us-east-1", AllocationID: "eipalloc-used", InstanceID: "i-2", IsAssociated: true, HourlyCost: 0.005},
+		},
+	}
+
+	findings := Find(resp, Options{})
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].ID != "eipalloc-stopped" || findings[0].Reason != "associated instance i-1 is not running" {
+		t.Fatalf("unexpected first finding: %+v", findings[0])
+	}
+	if findings[1].Category != CategoryIdleElasticIP || findings[1].MonthlyCost != 0.005*types.HoursPerMonth {
+		t.Fatalf("unexpected second finding: %+v", findings[1])
+	}
+}
+
+func TestFindUnattachedEBSVolumes(t *testing.T) {
+	resp := &types.CostResponse{
+		EBSVolumes: []types.EBSVolume{
+			{AccountID: "111", Region: "us-east-1", VolumeID: "vol-orphan", State: "available", HourlyCost: 0.01},
+			{AccountID: "111", Region: "us-east-1", VolumeID: "vol-root", State: "in-use", AttachedInstanceIDs: []string{"i-1"}, AttachedInstanceID: "i-1", HourlyCost: 0.02},
+		},
+	}
+
+	findings := Find(resp, Options{})
+	if len(findings) != 1 || findings[0].ID != "vol-orphan" || findings[0].Category != CategoryUnattachedEBSVolume {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestFindUnusedReservedCapacity(t *testing.T) {
+	resp := &types.CostResponse{
+		CapacityReservations: []types.CapacityReservation{
+			{AccountID: "111", Region: "us-east-1", ID: "cr-partial", InstanceType: "m5.large", TotalCount: 4, AvailableCount: 3, HourlyCost: 0.288},
+			{AccountID: "111", Region: "us-east-1", ID: "cr-full", InstanceType: "m5.large", TotalCount: 2},
+		},
+		DedicatedHosts: []types.DedicatedHost{
+			{AccountID: "111", Region: "us-east-1", HostID: "h-empty", InstanceFamily: "m5", HourlyCost: 4.5},
+			{AccountID: "111", Region: "us-east-1", HostID: "h-used", InstanceFamily: "m5", InstanceIDs: []string{"i-1"}, HourlyCost: 4.5},
+		},
+	}
+
+	findings := Find(resp, Options{})
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].ID != "h-empty" || findings[0].Category != CategoryIdleDedicatedHost {
+		t.Fatalf("unexpected first finding: %+v", findings[0])
+	}
+	if findings[1].ID != "cr-partial" || findings[1].Reason != "3 of 4 reserved m5.large instances unused" {
+		t.Fatalf("unexpected second finding: %+v", findings[1])
+	}
+}
+
+func TestFindUnusedSecrets(t *testing.T) {
+	resp := &types.CostResponse{
+		Secrets: []types.Secret{
+			{AccountID: "111", Region: "us-east-1", ARN: "arn:stale", LastAccessedAt: "2026-01-05T00:00:00Z", IdleDays: 120, HourlyCost: 0.000548},
+			{AccountID: "111", Region: "us-east-1", ARN: "arn:never", AgeDays: 95, IdleDays: 95, HourlyCost: 0.000548},
+			{AccountID: "111", Region: "us-east-1", ARN: "arn:used", LastAccessedAt: "2026-04-30T00:00:00Z", IdleDays: 2, HourlyCost: 0.000548},
+			{AccountID: "111", Region: "eu-west-1", ARN: "arn:replica", PrimaryRegion: "us-east-1", IdleDays: 200, HourlyCost: 0.000548},
+		},
+	}
+
+	if findings := Find(resp, Options{}); len(findings) != 0 {
+		t.Fatalf("expected no findings with the check off, got %+v", findings)
+	}
+
+	findings := Find(resp, Options{UnusedSecretDays: 90})
+	reasons := make(map[string]string)
+	for _, f := range findings {
+		if f.Category != CategoryUnusedSecret || f.MonthlyCost != f.HourlyCost*types.HoursPerMonth {
+			t.Errorf("unexpected finding: %+v", f)
+		}
+		reasons[f.ID] = f.Reason
+	}
+	want := map[string]string{
+		"arn:stale":   "not read in 120 days",
+		"arn:never":   "never read since it was created 95 days ago",
+		"arn:replica": "replica of a us-east-1 secret, never read in this region since it was created 200 days ago",
+	}
+	if len(reasons) != len(want) {
+		t.Fatalf("findings = %v, want %v", reasons, want)
+	}
+	for id, reason := range want {
+		if reasons[id] != reason {
+			t.Errorf("%s reason = %q, want %q", id, reasons[id], reason)
+		}
+	}
+}
+
+func TestFindStoppedInstances(t *testing.T) {
+	resp := &types.CostResponse{
+		EC2Instances: []types.EC2Instance{
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-old", State: "stopped", StoppedAt: "2026-01-01T00:00:00Z", StoppedDays: 45, VolumeIDs: []string{"vol-root", "vol-data"}},
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-recent", State: "stopped", StoppedAt: "2026-02-10T00:00:00Z", StoppedDays: 5, VolumeIDs: []string{"vol-recent"}},
+			{AccountID: "111", Region: "us-east-1", InstanceID: "i-running", State: "running", HourlyCost: 0.096},
+		},
+		EBSVolumes: []types.EBSVolume{
+			{AccountID: "111", Region: "us-east-1", VolumeID: "vol-root", AttachedInstanceIDs: []string{"i-old"}, HourlyCost: 0.011},
+			{AccountID: "111", Region: "us-east-1", VolumeID: "vol-data", AttachedInstanceIDs: []string{"i-old"}, HourlyCost: 0.1},
+			{AccountID: "111", Region: "us-east-1", VolumeID: "vol-recent", AttachedInstanceIDs: []string{"i-recent"}, HourlyCost: 0.011},
+		},
+	}
+
+	if findings := Find(resp, Options{}); len(findings) != 0 {
+		t.Fatalf("expected no findings with the check off, got %+v", findings)
+	}
+
+	findings := Find(resp, Options{StoppedInstanceDays: 30})
+	if len(findings) != 1 || findings[0].ID != "i-old" || findings[0].Category != CategoryStoppedInstance {
+		t.Fatalf("unexpected findings: %+v", findings)
+	}
+	if findings[0].HourlyCost != 0.111 || findings[0].Reason != "stopped for 45 days, still paying for 2 attached EBS volumes" {
+		t.Fatalf("unexpected finding: %+v", findings[0])
+	}
+}
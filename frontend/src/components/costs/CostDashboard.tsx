@@ -343,17 +343,17 @@ export const CostDashboard: React.FC = () => {
     switch (activeTab) {
       case 'accounts': {
         const countCols = [
-          { key: 'ec2Count', label: 'EC2', id: 'ec2' },
-          { key: 'ebsCount', label: 'EBS', id: 'ebs' },
-          { key: 'ecsCount', label: 'ECS', id: 'ecs' },
-          { key: 'rdsCount', label: 'RDS', id: 'rds' },
-          { key: 'eksCount', label: 'EKS', id: 'eks' },
-          { key: 'elbCount', label: 'ELB', id: 'elb' },
-          { key: 'natCount', label: 'NAT', id: 'nat' },
-          { key: 'eipCount', label: 'EIP', id: 'eip' },
-          { key: 'secretCount', label: 'Secrets', id: 'secrets' },
-          { key: 'publicIpv4Count', label: 'IPv4', id: 'publicipv4' },
-          { key: 'lambdaCount', label: 'Lambda', id: 'lambda' },
+          { label: 'EC2', id: 'ec2' },
+          { label: 'EBS', id: 'ebs' },
+          { label: 'ECS', id: 'ecs' },
+          { label: 'RDS', id: 'rds' },
+          { label: 'EKS', id: 'eks' },
+          { label: 'ELB', id: 'elb' },
+          { label: 'NAT', id: 'nat' },
+          { label: 'EIP', id: 'eip' },
+          { label: 'Secrets', id: 'secrets' },
+          { label: 'IPv4', id: 'publicipv4' },
+          { label: 'Lambda', id: 'lambda' },
         ].filter((c) => selectedResources.includes(c.id));
         headers = [
           'Account ID',
@@ -366,7 +366,7 @@ export const CostDashboard: React.FC = () => {
         rows = (filteredData.accounts || []).map((a) => [
           a.accountId,
           a.accountName,
-          ...countCols.map((c) => String((a as unknown as Record<string, unknown>)[c.key])),
+          ...countCols.map((c) => String(a.counts?.[c.id] ?? 0)),
           a.totalCost.toFixed(4),
           dailyCost(a.totalCost).toFixed(2),
           monthlyCost(a.totalCost).toFixed(2),
@@ -375,22 +375,22 @@ export const CostDashboard: React.FC = () => {
       }
       case 'regions': {
         const countCols = [
-          { key: 'ec2Count', label: 'EC2', id: 'ec2' },
-          { key: 'ebsCount', label: 'EBS', id: 'ebs' },
-          { key: 'ecsCount', label: 'ECS', id: 'ecs' },
-          { key: 'rdsCount', label: 'RDS', id: 'rds' },
-          { key: 'eksCount', label: 'EKS', id: 'eks' },
-          { key: 'elbCount', label: 'ELB', id: 'elb' },
-          { key: 'natCount', label: 'NAT', id: 'nat' },
-          { key: 'eipCount', label: 'EIP', id: 'eip' },
-          { key: 'secretCount', label: 'Secrets', id: 'secrets' },
-          { key: 'publicIpv4Count', label: 'IPv4', id: 'publicipv4' },
-          { key: 'lambdaCount', label: 'Lambda', id: 'lambda' },
+          { label: 'EC2', id: 'ec2' },
+          { label: 'EBS', id: 'ebs' },
+          { label: 'ECS', id: 'ecs' },
+          { label: 'RDS', id: 'rds' },
+          { label: 'EKS', id: 'eks' },
+          { label: 'ELB', id: 'elb' },
+          { label: 'NAT', id: 'nat' },
+          { label: 'EIP', id: 'eip' },
+          { label: 'Secrets', id: 'secrets' },
+          { label: 'IPv4', id: 'publicipv4' },
+          { label: 'Lambda', id: 'lambda' },
         ].filter((c) => selectedResources.includes(c.id));
         headers = ['Region', ...countCols.map((c) => c.label), 'Hourly Cost', 'Daily Cost', 'Monthly Cost'];
         rows = (filteredData.regions || []).map((r) => [
           r.region,
-          ...countCols.map((c) => String((r as unknown as Record<string, unknown>)[c.key])),
+          ...countCols.map((c) => String(r.counts?.[c.id] ?? 0)),
           r.totalCost.toFixed(4),
           dailyCost(r.totalCost).toFixed(2),
           monthlyCost(r.totalCost).toFixed(2),
@@ -806,6 +806,7 @@ export const CostDashboard: React.FC = () => {
             selectedCount={selectedData.count}
             totalCount={totals.count}
             currency={data.currency}
+            billing={data.billing}
             traffic={trafficTotals}
           />
 
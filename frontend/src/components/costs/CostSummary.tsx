@@ -1,4 +1,5 @@
 import React from 'react';
+import type { BillingTotals } from '../../types/cost';
 
 interface CostSummaryProps {
   selectedCost: number;
@@ -6,6 +7,7 @@ interface CostSummaryProps {
   selectedCount: number;
   totalCount: number;
   currency: string;
+  billing?: BillingTotals;
   traffic?: {
     window: string;
     selectedRequests: number;
@@ -34,6 +36,7 @@ export const CostSummary: React.FC<CostSummaryProps> = ({
   selectedCount,
   totalCount,
   currency,
+  billing,
   traffic,
 }) => {
   const formatCost = (cost: number, decimals: number = 2) => {
@@ -100,6 +103,12 @@ export const CostSummary: React.FC<CostSummaryProps> = ({
                     {formatCost(showBoth ? selectedMonthly : totalMonthly)}
                   </div>
                   {showBoth && <div className="text-xs text-gray-500">of {formatCost(totalMonthly)}</div>}
+                  {!showBoth && billing && (
+                    <div className="text-xs text-gray-500">
+                      {formatCost(billing.netCost * 730)} after discount
+                      {billing.credits > 0 ? ' and credits' : ''}
+                    </div>
+                  )}
                 </div>
               </dd>
             </div>
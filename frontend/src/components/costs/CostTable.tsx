@@ -13,6 +13,7 @@ import type {
   Secret,
   PublicIPv4,
   LambdaFunction,
+  PriceSource,
 } from '../../types/cost';
 
 interface CostTableProps {
@@ -34,18 +35,18 @@ interface CostTableProps {
   onUsageWindowChange?: (window: '1h' | '24h' | '30d') => void;
 }
 
-const SUMMARY_RESOURCE_COLUMNS: { id: string; label: string; countKey: string }[] = [
-  { id: 'ec2', label: 'EC2', countKey: 'ec2Count' },
-  { id: 'ebs', label: 'EBS', countKey: 'ebsCount' },
-  { id: 'ecs', label: 'ECS', countKey: 'ecsCount' },
-  { id: 'rds', label: 'RDS', countKey: 'rdsCount' },
-  { id: 'eks', label: 'EKS', countKey: 'eksCount' },
-  { id: 'elb', label: 'ELB', countKey: 'elbCount' },
-  { id: 'nat', label: 'NAT', countKey: 'natCount' },
-  { id: 'eip', label: 'EIP', countKey: 'eipCount' },
-  { id: 'secrets', label: 'Secrets', countKey: 'secretCount' },
-  { id: 'publicipv4', label: 'IPv4', countKey: 'publicIpv4Count' },
-  { id: 'lambda', label: 'Lambda', countKey: 'lambdaCount' },
+const SUMMARY_RESOURCE_COLUMNS: { id: string; label: string }[] = [
+  { id: 'ec2', label: 'EC2' },
+  { id: 'ebs', label: 'EBS' },
+  { id: 'ecs', label: 'ECS' },
+  { id: 'rds', label: 'RDS' },
+  { id: 'eks', label: 'EKS' },
+  { id: 'elb', label: 'ELB' },
+  { id: 'nat', label: 'NAT' },
+  { id: 'eip', label: 'EIP' },
+  { id: 'secrets', label: 'Secrets' },
+  { id: 'publicipv4', label: 'IPv4' },
+  { id: 'lambda', label: 'Lambda' },
 ];
 
 type SortDirection = 'asc' | 'desc';
@@ -149,6 +150,33 @@ function paginate<T>(data: T[], page: number, pageSize: PageSize): T[] {
   return data.slice(start, start + pageSize);
 }
 
+// priceTitle describes where a resource's price came from, for the tooltip on
+// its hourly cost
+function priceTitle(resource: { priceSource?: PriceSource; priceAsOf?: string }): string | undefined {
+  if (resource.priceSource === 'missing') return 'A price lookup failed, so this cost is understated';
+  if (resource.priceSource === 'estimate') return 'Estimated from other sizes of the instance family';
+  if (resource.priceSource === 'config') return 'Priced at a rate set in the awsCOGS config';
+  if (!resource.priceSource || !resource.priceAsOf) return undefined;
+  const asOf = new Date(resource.priceAsOf).toLocaleString();
+  return resource.priceSource === 'api'
+    ? `Price fetched from the AWS Pricing API at ${asOf}`
+    : `Cached price, fetched at ${asOf}`;
+}
+
+// ecsAttributed reports whether an ECS service's cost is its share of the
+// cluster's EC2 container instances rather than its own cost
+function ecsAttributed(svc: ECSService): boolean {
+  return !svc.hourlyCost && !!svc.attributedHourlyCost;
+}
+
+// ecsCost returns an ECS service's own cost, or its attributed share of EC2
+function ecsCost(svc: ECSService): number {
+  return ecsAttributed(svc) ? (svc.attributedHourlyCost ?? 0) : svc.hourlyCost;
+}
+
+const ecsAttributedTitle =
+  "Share of the cluster's EC2 container instances by CPU and memory reservation. Counted under EC2, not in totals.";
+
 const SortIcon: React.FC<{ active: boolean; direction: SortDirection }> = ({ active, direction }) => {
   if (!active) return null;
   return <span className="ml-1 inline-block text-blue-600">{direction === 'asc' ? '▲' : '▼'}</span>;
@@ -242,11 +270,26 @@ const NUMERIC_SORT_KEYS = new Set([
   'bandwidthBytes',
 ]);
 
+// Summary count columns sort by a resource type's entry in counts
+const COUNT_SORT_PREFIX = 'counts.';
+
+function countSortKey(resourceType: string): string {
+  return COUNT_SORT_PREFIX + resourceType;
+}
+
+function sortValue(item: unknown, key: string): unknown {
+  if (key.startsWith(COUNT_SORT_PREFIX)) {
+    const counts = (item as { counts?: Record<string, number> }).counts;
+    return counts?.[key.slice(COUNT_SORT_PREFIX.length)];
+  }
+  return (item as Record<string, unknown>)[key];
+}
+
 function sortData<T>(data: T[], sortConfig: SortConfig): T[] {
-  const isNumeric = NUMERIC_SORT_KEYS.has(sortConfig.key);
+  const isNumeric = NUMERIC_SORT_KEYS.has(sortConfig.key) || sortConfig.key.startsWith(COUNT_SORT_PREFIX);
   return [...data].sort((a, b) => {
-    let aVal = (a as Record<string, unknown>)[sortConfig.key];
-    let bVal = (b as Record<string, unknown>)[sortConfig.key];
+    let aVal = sortValue(a, sortConfig.key);
+    let bVal = sortValue(b, sortConfig.key);
 
     // Treat missing numeric values as 0
     if (isNumeric) {
@@ -456,7 +499,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   <SortableHeader
                     key={col.id}
                     label={col.label}
-                    sortKey={col.countKey}
+                    sortKey={countSortKey(col.id)}
                     currentSort={accountSort}
                     onSort={(k) => handleSort(setAccountSort, accountSort, k, () => setAccountPage(1))}
                     rowSpan={2}
@@ -481,7 +524,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   </td>
                   {visibleColumns.map((col) => (
                     <td key={col.id} className="px-6 py-4 whitespace-nowrap text-sm text-gray-500 text-right">
-                      {(account as unknown as Record<string, number>)[col.countKey]}
+                      {account.counts?.[col.id] ?? 0}
                     </td>
                   ))}
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -529,7 +572,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   <SortableHeader
                     key={col.id}
                     label={col.label}
-                    sortKey={col.countKey}
+                    sortKey={countSortKey(col.id)}
                     currentSort={regionSort}
                     onSort={(k) => handleSort(setRegionSort, regionSort, k, () => setRegionPage(1))}
                     rowSpan={2}
@@ -552,7 +595,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   <td className="px-6 py-4 whitespace-nowrap text-sm font-medium text-gray-900">{region.region}</td>
                   {visibleColumns.map((col) => (
                     <td key={col.id} className="px-6 py-4 whitespace-nowrap text-sm text-gray-500 text-right">
-                      {(region as unknown as Record<string, number>)[col.countKey]}
+                      {region.counts?.[col.id] ?? 0}
                     </td>
                   ))}
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -664,7 +707,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                       {inst.state}
                     </span>
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(inst)}>
                     {formatCost(inst.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -782,7 +825,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                       {vol.state}
                     </span>
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(vol)}>
                     {formatCost(vol.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -909,14 +952,17 @@ export const CostTable: React.FC<CostTableProps> = ({
                       {svc.state}
                     </span>
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
-                    {formatCost(svc.hourlyCost)}
+                  <td
+                    className={`px-6 py-4 whitespace-nowrap text-sm text-right ${ecsAttributed(svc) ? 'text-gray-500 italic' : 'text-gray-900'}`}
+                    title={ecsAttributed(svc) ? ecsAttributedTitle : priceTitle(svc)}
+                  >
+                    {formatCost(ecsCost(svc))}
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
-                    {formatCost(dailyCost(svc.hourlyCost), 2)}
+                  <td className={`px-6 py-4 whitespace-nowrap text-sm text-right ${ecsAttributed(svc) ? 'text-gray-500 italic' : 'text-gray-900'}`}>
+                    {formatCost(dailyCost(ecsCost(svc)), 2)}
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
-                    {formatCost(monthlyCost(svc.hourlyCost), 2)}
+                  <td className={`px-6 py-4 whitespace-nowrap text-sm text-right ${ecsAttributed(svc) ? 'text-gray-500 italic' : 'text-gray-900'}`}>
+                    {formatCost(monthlyCost(ecsCost(svc)), 2)}
                   </td>
                 </tr>
               ))}
@@ -1026,7 +1072,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                       {inst.state}
                     </span>
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(inst)}>
                     {formatCost(inst.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -1137,9 +1183,19 @@ export const CostTable: React.FC<CostTableProps> = ({
                       {cluster.status}
                     </span>
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{cluster.version}</td>
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">
+                    {cluster.version}
+                    {cluster.extendedSupport && (
+                      <span
+                        className="ml-2 inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-yellow-100 text-yellow-800"
+                        title="Past the end of standard support; billed at the extended support rate"
+                      >
+                        extended support
+                      </span>
+                    )}
+                  </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{cluster.platform}</td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(cluster)}>
                     {formatCost(cluster.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -1303,6 +1359,15 @@ export const CostTable: React.FC<CostTableProps> = ({
                     >
                       {lb.state}
                     </span>
+                    {lb.warnings?.map((warning) => (
+                      <span
+                        key={warning.code}
+                        className="ml-2 inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium bg-yellow-100 text-yellow-800"
+                        title={warning.message}
+                      >
+                        {warning.code}
+                      </span>
+                    ))}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
                     {!lb.usageStatus ? (
@@ -1473,7 +1538,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{gateway.type}</td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{gateway.vpcId}</td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(gateway)}>
                     {formatCost(gateway.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -1550,7 +1615,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   rowSpan={2}
                 />
                 <SortableHeader
-                  label="Instance ID"
+                  label="Associated Resource"
                   sortKey="instanceId"
                   currentSort={eipSort}
                   onSort={(k) => handleSort(setEipSort, eipSort, k, () => setEipPage(1))}
@@ -1579,14 +1644,14 @@ export const CostTable: React.FC<CostTableProps> = ({
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">
                     <span
                       className={`inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium ${
-                        ip.isAssociated ? 'bg-green-100 text-green-800' : 'bg-yellow-100 text-yellow-800'
+                        ip.idle ? 'bg-yellow-100 text-yellow-800' : 'bg-green-100 text-green-800'
                       }`}
                     >
-                      {ip.isAssociated ? 'Yes' : 'No'}
+                      {ip.isAssociated ? (ip.idle ? 'Yes (idle)' : 'Yes') : 'No'}
                     </span>
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{ip.instanceId || '-'}</td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{ip.associatedResourceId || ip.instanceId || '-'}</td>
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(ip)}>
                     {formatCost(ip.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -1667,7 +1732,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{secret.region}</td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm font-medium text-gray-900">{secret.name}</td>
                   <td className="px-6 py-4 text-sm text-gray-500 max-w-xs truncate">{secret.description || '-'}</td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(secret)}>
                     {formatCost(secret.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -1756,7 +1821,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                   <td className="px-6 py-4 whitespace-nowrap text-sm font-medium text-gray-900">{pip.publicIp}</td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{pip.instanceId || '-'}</td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{pip.instanceName || '-'}</td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(pip)}>
                     {formatCost(pip.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -1896,7 +1961,7 @@ export const CostTable: React.FC<CostTableProps> = ({
                       {fn.state || '-'}
                     </span>
                   </td>
-                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
+                  <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right" title={priceTitle(fn)}>
                     {formatCost(fn.hourlyCost)}
                   </td>
                   <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-900 text-right">
@@ -175,7 +175,9 @@ export const ResourceSelector: React.FC = () => {
     lambda: 'Lambda Functions',
   };
 
-  const resourceOptions = RESOURCE_TYPES.map((resource) => ({
+  const resourceOptions = RESOURCE_TYPES.filter(
+    (resource) => !config.services || config.services.includes(resource)
+  ).map((resource) => ({
     value: resource,
     label: resourceLabels[resource] || resource.toUpperCase(),
   })).sort((a, b) => a.label.localeCompare(b.label));
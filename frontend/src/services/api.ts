@@ -1,4 +1,5 @@
-import type { CostResponse, CostFilters, ConfigResponse } from '../types/cost';
+import type { CostResponse, CostFilters, ConfigResponse, ApiErrorBody, ApiErrorCode, ScanJob } from '../types/cost';
+import { getBasePath } from './basePath';
 
 function createTimeoutSignal(timeoutMs: number, signal?: AbortSignal): AbortSignal {
   const controller = new AbortController();
@@ -42,18 +43,48 @@ function appendRequestId(params: URLSearchParams, requestId?: string) {
   }
 }
 
+export class ApiError extends Error {
+  readonly status: number;
+  readonly code: ApiErrorCode;
+  readonly details?: unknown;
+  readonly requestId?: string;
+
+  constructor(status: number, body: ApiErrorBody) {
+    super(body.message);
+    this.name = 'ApiError';
+    this.status = status;
+    this.code = body.code;
+    this.details = body.details;
+    this.requestId = body.requestId;
+  }
+}
+
+// apiError reads the error envelope from a failed response, falling back to
+// the status line for responses that aren't JSON (e.g. from a proxy)
+async function apiError(response: Response): Promise<Error> {
+  try {
+    const body = (await response.json()) as ApiErrorBody;
+    if (body?.code && body?.message) {
+      return new ApiError(response.status, body);
+    }
+  } catch {
+    // Not an API error envelope
+  }
+  return new Error(`API error: ${response.status} ${response.statusText}`);
+}
+
 async function fetchApi<T>(url: string, signal?: AbortSignal): Promise<T> {
-  const response = await fetch(`/api/v1${url}`, {
+  const response = await fetch(`${getBasePath()}/api/v1${url}`, {
     signal: createTimeoutSignal(5 * 60 * 1000, signal), // 5 minutes for large queries
   });
   if (!response.ok) {
-    throw new Error(`API error: ${response.status} ${response.statusText}`);
+    throw await apiError(response);
   }
   return response.json();
 }
 
 async function postApi<T>(url: string, signal?: AbortSignal): Promise<T> {
-  const response = await fetch(`/api/v1${url}`, {
+  const response = await fetch(`${getBasePath()}/api/v1${url}`, {
     method: 'POST',
     signal: createTimeoutSignal(5 * 60 * 1000, signal),
   });
@@ -61,13 +92,69 @@ async function postApi<T>(url: string, signal?: AbortSignal): Promise<T> {
     return await fetchApi<T>(url, signal);
   }
   if (!response.ok) {
-    throw new Error(`API error: ${response.status} ${response.statusText}`);
+    throw await apiError(response);
   }
   return response.json();
 }
 
+const SCAN_POLL_INTERVAL_MS = 2000;
+
+function sleep(ms: number, signal?: AbortSignal): Promise<void> {
+  return new Promise((resolve, reject) => {
+    if (signal?.aborted) {
+      reject(signal.reason);
+      return;
+    }
+    const timeout = window.setTimeout(resolve, ms);
+    signal?.addEventListener(
+      'abort',
+      () => {
+        window.clearTimeout(timeout);
+        reject(signal.reason);
+      },
+      { once: true },
+    );
+  });
+}
+
+// runScan starts a scan job and polls it until it finishes, so no single
+// request is held open for the whole scan. It returns null if the server
+// predates the job API.
+async function runScan(filters: CostFilters, signal?: AbortSignal): Promise<CostResponse | null> {
+  const response = await fetch(`${getBasePath()}/api/v1/scans`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ accounts: filters.accounts, regions: filters.regions, resourceTypes: filters.resources }),
+    signal,
+  });
+  if (response.status === 404 || response.status === 405) {
+    return null;
+  }
+
+  let job: ScanJob;
+  if (response.ok) {
+    job = (await response.json()) as ScanJob;
+  } else {
+    // With the reject policy, wait for the overlapping scan instead
+    const error = await apiError(response);
+    if (!(error instanceof ApiError) || error.code !== 'SCAN_IN_PROGRESS') {
+      throw error;
+    }
+    job = error.details as ScanJob;
+  }
+  while (job.status === 'queued' || job.status === 'running') {
+    await sleep(SCAN_POLL_INTERVAL_MS, signal);
+    job = await fetchApi<ScanJob>(`/scans/${job.id}`, signal);
+  }
+  return await fetchApi<CostResponse>(`/scans/${job.id}/result`, signal);
+}
+
 export const costApi = {
   async getCosts(filters: CostFilters = {}, signal?: AbortSignal, requestId?: string): Promise<CostResponse> {
+    const result = await runScan(filters, signal);
+    if (result) {
+      return result;
+    }
     const params = buildCostParams(filters);
     appendRequestId(params, requestId);
     return await fetchApi<CostResponse>(`/costs?${params.toString()}`, signal);
@@ -0,0 +1,7 @@
+// Resolves the URL prefix the app is served under. The backend injects a meta
+// tag into index.html at runtime; VITE_BASE_PATH covers builds served elsewhere.
+export function getBasePath(): string {
+  const meta = document.querySelector<HTMLMetaElement>('meta[name="awscogs-base-path"]');
+  const basePath = meta?.content ?? import.meta.env.VITE_BASE_PATH ?? '';
+  return basePath.replace(/\/+$/, '');
+}
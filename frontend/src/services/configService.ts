@@ -1,4 +1,5 @@
 import { load as yamlLoad } from 'js-yaml';
+import { getBasePath } from './basePath';
 
 interface AppConfig {
   exclude?: {
@@ -16,7 +17,7 @@ export async function loadAppConfig(): Promise<AppConfig> {
   if (cachedConfig) return cachedConfig;
 
   try {
-    const response = await fetch('/config.yaml');
+    const response = await fetch(`${getBasePath()}/config.yaml`);
     if (!response.ok) {
       console.warn('Could not load config.yaml, using defaults');
       return {};
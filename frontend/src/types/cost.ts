@@ -3,9 +3,18 @@ export interface CostResponse {
   status: 'ok' | 'partial' | 'failed';
   diagnostics?: Diagnostic[];
   totalCost: number;
+  billing?: BillingTotals;
   currency: string;
+  comparedTo?: string;
   accounts?: AccountSummary[];
   regions?: RegionSummary[];
+  environments?: EnvironmentCost[];
+  unpricedCount?: number;
+  unpricedResources?: UnpricedResource[];
+  ignoredCount?: number;
+  ignoredCost?: number;
+  ignoredResources?: IgnoredResource[];
+  highCostCount?: number;
   ec2Instances?: EC2Instance[];
   ebsVolumes?: EBSVolume[];
   rdsInstances?: RDSInstance[];
@@ -17,9 +26,66 @@ export interface CostResponse {
   secrets?: Secret[];
   publicIpv4s?: PublicIPv4[];
   lambdas?: LambdaFunction[];
+  capacityReservations?: CapacityReservation[];
+  dedicatedHosts?: DedicatedHost[];
+  kinesisStreams?: KinesisStream[];
+  firehoseStreams?: FirehoseStream[];
+  sqsQueues?: SQSQueue[];
+  snsTopics?: SNSTopic[];
+  eventBuses?: EventBus[];
+  alarms?: Alarm[];
+  dashboards?: Dashboard[];
+  customMetrics?: CustomMetrics[];
+  dataTransfer?: DataTransfer[];
+  pluginResources?: PluginResource[];
   filters: AppliedFilters;
+  scan?: ScanStats;
 }
 
+// Total cost per hour after the configured account-level discount and credits
+export interface BillingTotals {
+  listCost: number;
+  discountPercent: number;
+  discount: number;
+  credits: number;
+  netCost: number;
+}
+
+export interface ScanStats {
+  cells: number;
+  cached: number;
+  rescanned: number;
+  selfCost?: SelfCost;
+}
+
+export interface ServiceCalls {
+  service: string;
+  calls: number;
+}
+
+export interface SelfCost {
+  apiCalls: number;
+  pricingCalls: number;
+  metricDataCalls: number;
+  metricsRequested: number;
+  billedRequests: number;
+  cost: number;
+  services: ServiceCalls[];
+}
+
+export interface CostComponent {
+  name: string;
+  unit: string;
+  quantity: number;
+  rate: number;
+  hourlyCost: number;
+  override?: string;
+}
+
+// Where a resource's prices came from: fetched from the Pricing API for this
+// scan, or served from the price cache
+export type PriceSource = 'api' | 'cache' | 'missing' | 'estimate' | 'config';
+
 export interface Diagnostic {
   level: 'warning' | 'error';
   resourceType?: string;
@@ -34,34 +100,59 @@ export interface Diagnostic {
 export interface AccountSummary {
   accountId: string;
   accountName: string;
-  ec2Count: number;
-  ebsCount: number;
-  rdsCount: number;
-  ecsCount: number;
-  eksCount: number;
-  elbCount: number;
-  natCount: number;
-  eipCount: number;
-  secretCount: number;
-  publicIpv4Count: number;
-  lambdaCount: number;
+  displayName?: string;
+  environment?: string;
+  ownerEmail?: string;
+  color?: string;
+  counts: Record<string, number>;
+  dataTransferCost?: number;
+  services: Record<string, number>;
+  regions: Record<string, number>;
+  totalCost: number;
+  previousTotalCost?: number;
+  deltaPercent?: number;
+}
+
+export interface UnpricedResource {
+  resourceType: string;
+  resourceId: string;
+  name?: string;
+  accountId: string;
+  accountName: string;
+  region: string;
+  hourlyCost: number;
+  handling: 'zero' | 'omitted' | 'estimated' | 'unknown';
+}
+
+export interface IgnoredResource {
+  resourceType: string;
+  resourceId: string;
+  name?: string;
+  accountId: string;
+  accountName: string;
+  region: string;
+  hourlyCost: number;
+  createdAt?: string;
+  rule: string;
+  reason: string;
+}
+
+export interface EnvironmentCost {
+  environment: string;
+  production: boolean;
+  count: number;
   totalCost: number;
+  services: Record<string, number>;
 }
 
 export interface RegionSummary {
   region: string;
-  ec2Count: number;
-  ebsCount: number;
-  rdsCount: number;
-  ecsCount: number;
-  eksCount: number;
-  elbCount: number;
-  natCount: number;
-  eipCount: number;
-  secretCount: number;
-  publicIpv4Count: number;
-  lambdaCount: number;
+  counts: Record<string, number>;
+  dataTransferCost?: number;
+  accounts: Record<string, number>;
   totalCost: number;
+  previousTotalCost?: number;
+  deltaPercent?: number;
 }
 
 export interface EC2Instance {
@@ -69,10 +160,38 @@ export interface EC2Instance {
   accountName: string;
   region: string;
   instanceId: string;
+  arn: string;
   name: string;
   instanceType: string;
   state: string;
+  architecture?: string;
+  tenancy?: 'default' | 'dedicated' | 'host';
+  capacityReservationId?: string;
+  cpuCreditMode?: 'standard' | 'unlimited';
+  autoScalingGroup?: string;
+  autoScalingCapacity?: AutoScalingCapacity;
+  vpcId?: string;
+  subnetId?: string;
+  createdAt?: string;
+  ageDays?: number;
+  stoppedAt?: string;
+  stoppedDays?: number;
+  volumeIds?: string[];
   hourlyCost: number;
+  attachedStorageCost?: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface AutoScalingCapacity {
+  minSize: number;
+  maxSize: number;
+  desiredCapacity: number;
 }
 
 export interface EBSVolume {
@@ -80,13 +199,27 @@ export interface EBSVolume {
   accountName: string;
   region: string;
   volumeId: string;
+  arn: string;
   name: string;
   volumeType: string;
   size: number;
   iops: number;
   throughput: number;
   state: string;
+  createdAt?: string;
+  ageDays?: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  attachedInstanceIds?: string[];
+  attachedInstanceId?: string;
+  device?: string;
+  attachTime?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface RDSInstance {
@@ -94,6 +227,7 @@ export interface RDSInstance {
   accountName: string;
   region: string;
   dbInstanceId: string;
+  arn: string;
   name: string;
   engine: string;
   engineVersion: string;
@@ -102,7 +236,18 @@ export interface RDSInstance {
   storageType: string;
   allocatedStorage: number;
   state: string;
+  vpcId?: string;
+  subnetId?: string;
+  createdAt?: string;
+  ageDays?: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface ECSService {
@@ -111,11 +256,23 @@ export interface ECSService {
   region: string;
   clusterName: string;
   serviceName: string;
+  arn: string;
   launchType: string;
   desiredCount: number;
   runningCount: number;
   state: string;
+  cpuReserved?: number;
+  memoryReserved?: number;
+  attributedHourlyCost?: number;
+  createdAt?: string;
+  ageDays?: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface EKSCluster {
@@ -123,10 +280,21 @@ export interface EKSCluster {
   accountName: string;
   region: string;
   clusterName: string;
+  arn: string;
   status: string;
   version: string;
+  extendedSupport?: boolean;
   platform: string;
+  createdAt?: string;
+  ageDays?: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface LoadBalancer {
@@ -138,7 +306,14 @@ export interface LoadBalancer {
   type: string;
   scheme: string;
   state: string;
+  vpcId?: string;
+  subnetIds?: string[];
+  createdAt?: string;
+  ageDays?: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
   baseHourlyCost: number;
   lcuHourlyCost: number;
   consumedLcus: number;
@@ -151,6 +326,18 @@ export interface LoadBalancer {
   bandwidthMetricName?: string;
   usageStatus?: string;
   usageError?: string;
+  registeredTargets?: number;
+  healthyTargets?: number;
+  warnings?: ResourceWarning[];
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface ResourceWarning {
+  code: 'classic-load-balancer' | 'no-targets' | 'no-healthy-targets';
+  message: string;
+  monthlySavings: number;
 }
 
 export interface NATGateway {
@@ -158,12 +345,22 @@ export interface NATGateway {
   accountName: string;
   region: string;
   id: string;
+  arn: string;
   name: string;
   state: string;
   type: string;
   vpcId: string;
   subnetId: string;
+  createdAt?: string;
+  ageDays?: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface ElasticIP {
@@ -171,12 +368,26 @@ export interface ElasticIP {
   accountName: string;
   region: string;
   allocationId: string;
+  arn: string;
   publicIp: string;
   name: string;
   associationId: string;
   instanceId: string;
+  networkInterfaceId?: string;
+  associatedResourceType?: string;
+  associatedResourceId?: string;
   isAssociated: boolean;
+  idle: boolean;
+  vpcId?: string;
+  subnetId?: string;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface Secret {
@@ -186,7 +397,19 @@ export interface Secret {
   name: string;
   arn: string;
   description: string;
+  createdAt?: string;
+  ageDays?: number;
+  primaryRegion?: string;
+  lastAccessedAt?: string;
+  idleDays: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface PublicIPv4 {
@@ -196,7 +419,274 @@ export interface PublicIPv4 {
   publicIp: string;
   instanceId: string;
   instanceName: string;
+  networkInterfaceId?: string;
+  associatedResourceType: string;
+  associatedResourceId: string;
+  idle: boolean;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface CapacityReservation {
+  accountId: string;
+  accountName: string;
+  region: string;
+  id: string;
+  arn: string;
+  name: string;
+  instanceType: string;
+  platform: string;
+  tenancy: string;
+  availabilityZone: string;
+  state: string;
+  totalCount: number;
+  availableCount: number;
+  utilization: number;
+  endDate?: string;
+  createdAt?: string;
+  ageDays?: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface DedicatedHost {
+  accountId: string;
+  accountName: string;
+  region: string;
+  hostId: string;
+  arn: string;
+  name: string;
+  instanceFamily: string;
+  instanceType?: string;
+  availabilityZone: string;
+  state: string;
+  instanceIds?: string[];
+  capacity?: number;
+  utilization: number;
+  createdAt?: string;
+  ageDays?: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  tags?: Record<string, string>;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface KinesisStream {
+  accountId: string;
+  accountName: string;
+  region: string;
+  streamName: string;
+  streamArn: string;
+  status: string;
+  mode: 'PROVISIONED' | 'ON_DEMAND';
+  shardCount: number;
+  retentionHours: number;
+  consumerCount: number;
+  createdAt?: string;
+  ageDays?: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+// Estimated data transfer cost of an EC2 instance or NAT gateway
+export interface DataTransfer {
+  accountId: string;
+  accountName: string;
+  region: string;
+  resourceType: 'ec2' | 'nat';
+  resourceId: string;
+  name?: string;
+  internetGbPerHour: number;
+  interAzGbPerHour: number;
+  processedGbPerHour?: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  tags?: Record<string, string>;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface PluginResource {
+  type: string;
+  accountId: string;
+  accountName: string;
+  region: string;
+  id: string;
+  name?: string;
+  size?: string;
+  state?: string;
+  createdAt?: string;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  attributes?: Record<string, unknown>;
+  tags?: Record<string, string>;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface FirehoseStream {
+  accountId: string;
+  accountName: string;
+  region: string;
+  name: string;
+  arn: string;
+  status: string;
+  sourceType: string;
+  destinations: string[];
+  incomingBytes: number;
+  usageWindow: string;
+  usageStatus?: string;
+  usageError?: string;
+  createdAt?: string;
+  ageDays?: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface SQSQueue {
+  accountId: string;
+  accountName: string;
+  region: string;
+  name: string;
+  url: string;
+  arn: string;
+  fifo: boolean;
+  messagesVisible: number;
+  requests: number;
+  usageWindow: string;
+  usageStatus?: string;
+  usageError?: string;
+  createdAt?: string;
+  ageDays?: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface SNSTopic {
+  accountId: string;
+  accountName: string;
+  region: string;
+  name: string;
+  arn: string;
+  fifo: boolean;
+  subscriptions: number;
+  publishes: number;
+  usageWindow: string;
+  usageStatus?: string;
+  usageError?: string;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface EventBridgeRule {
+  name: string;
+  state: string;
+  scheduleExpression?: string;
+}
+
+export interface EventBus {
+  accountId: string;
+  accountName: string;
+  region: string;
+  name: string;
+  arn: string;
+  rules: EventBridgeRule[];
+  events: number;
+  usageWindow: string;
+  usageStatus?: string;
+  usageError?: string;
+  createdAt?: string;
+  ageDays?: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface Alarm {
+  accountId: string;
+  accountName: string;
+  region: string;
+  name: string;
+  arn: string;
+  kind: 'standard' | 'high-resolution' | 'anomaly-detection' | 'composite';
+  state: string;
+  metrics: number;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface Dashboard {
+  accountId: string;
+  accountName: string;
+  region: string;
+  name: string;
+  arn: string;
+  size: number;
+  lastModified?: string;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
+}
+
+export interface CustomMetrics {
+  accountId: string;
+  accountName: string;
+  region: string;
+  namespace: string;
+  metrics: number;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface LambdaFunction {
@@ -213,6 +703,9 @@ export interface LambdaFunction {
   lastModified: string;
   state: string;
   hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: PriceSource;
+  priceAsOf?: string;
   requestHourlyCost: number;
   computeHourlyCost: number;
   invocations: number;
@@ -222,12 +715,16 @@ export interface LambdaFunction {
   usageEnd: string;
   usageStatus?: string;
   usageError?: string;
+  owner?: string;
+  costCenter?: string;
+  highCost?: boolean;
 }
 
 export interface AppliedFilters {
   accounts?: string[];
   regions?: string[];
   resourceTypes?: string[];
+  olderThan?: string;
 }
 
 export interface CostFilters {
@@ -236,6 +733,33 @@ export interface CostFilters {
   resources?: string[];
 }
 
+export interface ScanJob {
+  id: string;
+  status: 'queued' | 'running' | 'succeeded' | 'failed';
+  filters: AppliedFilters;
+  progress: { done: number; total: number; percent: number };
+  createdAt: string;
+  finishedAt?: string;
+  error?: string;
+  resultUrl?: string;
+}
+
+export interface ViewSpec {
+  name: string;
+  description?: string;
+  accounts?: string[];
+  regions?: string[];
+  resourceTypes?: string[];
+  tags?: Record<string, string>;
+  groupBy?: string[];
+}
+
+export interface SavedView extends ViewSpec {
+  id: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
 export const RESOURCE_TYPES = [
   'ec2',
   'ebs',
@@ -259,5 +783,78 @@ export interface VersionInfo {
 export interface ConfigResponse {
   accounts: { id: string; name: string }[];
   regions: string[];
+  services?: string[];
+  profile?: string;
+  profiles?: string[];
+  quickScan?: boolean;
   version: VersionInfo;
 }
+
+export type ApiErrorCode =
+  | 'INVALID_FILTER'
+  | 'INVALID_PARAMETER'
+  | 'INVALID_REQUEST'
+  | 'NOT_FOUND'
+  | 'RESOURCE_TYPE_DISABLED'
+  | 'UNKNOWN_PROFILE'
+  | 'AWS_AUTH_FAILED'
+  | 'AWS_ACCESS_DENIED'
+  | 'THROTTLED'
+  | 'RATE_LIMITED'
+  | 'SCAN_IN_PROGRESS'
+  | 'SHUTTING_DOWN'
+  | 'TIMEOUT'
+  | 'INTERNAL';
+
+export interface ResourceTypeInfo {
+  key: string;
+  displayName: string;
+  provider: string;
+  scope: 'regional' | 'global';
+  iamActions: string[];
+  enabled: boolean;
+}
+
+export interface ResourceTypesResponse {
+  resourceTypes: ResourceTypeInfo[];
+}
+
+export interface ListedResource {
+  type: string;
+  id: string;
+  arn?: string;
+  name?: string;
+  accountId: string;
+  accountName: string;
+  region: string;
+  size?: string;
+  state?: string;
+  vpcId?: string;
+  subnetId?: string;
+  hourlyCost: number;
+  costComponents?: CostComponent[];
+  priceSource?: string;
+  createdAt?: string;
+  costCenter?: string;
+  highCost?: boolean;
+  environment?: string;
+  tags?: Record<string, string>;
+}
+
+export interface ResourceListResponse {
+  timestamp: string;
+  status: string;
+  diagnostics?: Diagnostic[];
+  totalCost: number;
+  currency: string;
+  count: number;
+  resources: ListedResource[];
+  filters: AppliedFilters;
+}
+
+export interface ApiErrorBody {
+  code: ApiErrorCode;
+  message: string;
+  details?: unknown;
+  requestId?: string;
+}
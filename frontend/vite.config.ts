@@ -2,12 +2,16 @@ import { defineConfig } from 'vite'
 import react from '@vitejs/plugin-react'
 import tailwindcss from '@tailwindcss/vite'
 
+// Optional URL prefix, e.g. VITE_BASE_PATH=/awscogs when served behind a reverse proxy
+const basePath = (process.env.VITE_BASE_PATH ?? '').replace(/\/+$/, '')
+
 export default defineConfig({
+  base: `${basePath}/`,
   plugins: [react(), tailwindcss()],
   server: {
     port: 3000,
     proxy: {
-      '/api': {
+      [`${basePath}/api`]: {
         target: 'http://localhost:8080',
         changeOrigin: true,
         timeout: 5 * 60 * 1000, // 5 minutes